@@ -0,0 +1,309 @@
+package admin
+
+import (
+	"sync-backend/api/admin/dto"
+	"sync-backend/api/admin/model"
+	"sync-backend/api/common/email"
+	"sync-backend/arch/common"
+	"sync-backend/arch/network"
+	"sync-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type adminController struct {
+	logger utils.AppLogger
+	network.BaseController
+	common.ContextPayload
+	authProvider          network.AuthenticationProvider
+	authorizationProvider network.AuthorizationProvider
+	adminService          AdminService
+	emailService          email.EmailService
+}
+
+func NewAdminController(
+	authProvider network.AuthenticationProvider,
+	authorizationProvider network.AuthorizationProvider,
+	adminService AdminService,
+	emailService email.EmailService,
+) network.Controller {
+	return &adminController{
+		logger:                utils.NewServiceLogger("AdminController"),
+		BaseController:        network.NewBaseController("/admin", authProvider),
+		ContextPayload:        common.NewContextPayload(),
+		authProvider:          authProvider,
+		authorizationProvider: authorizationProvider,
+		adminService:          adminService,
+		emailService:          emailService,
+	}
+}
+
+func (c *adminController) MountRoutes(group *gin.RouterGroup) {
+	c.logger.Info("Mounting admin routes")
+	superadmin := c.authorizationProvider.Middleware()
+	trustSafety := c.authorizationProvider.Middleware(string(model.RoleTrustSafety))
+	staff := c.authorizationProvider.Middleware(string(model.RoleTrustSafety), string(model.RoleSupport))
+	support := c.authorizationProvider.Middleware(string(model.RoleSupport))
+
+	group.Use(c.authProvider.Middleware())
+
+	/* PLATFORM STAFF */
+	group.GET("/staff", superadmin, c.ListStaff)
+	group.PUT("/staff/:userId", superadmin, c.GrantRole)
+	group.DELETE("/staff/:userId", superadmin, c.RevokeRole)
+
+	/* USERS */
+	group.GET("/users", staff, c.ListUsers)
+	group.POST("/users/:userId/ban", trustSafety, c.BanUser)
+	group.DELETE("/users/:userId/ban", trustSafety, c.UnbanUser)
+
+	/* COMMUNITIES */
+	group.GET("/communities", staff, c.ListCommunities)
+	group.POST("/communities/:communityId/takedown", trustSafety, c.TakedownCommunity)
+	group.DELETE("/communities/:communityId/takedown", trustSafety, c.RestoreCommunity)
+
+	/* REPORTS */
+	group.GET("/reports", trustSafety, c.ListReports)
+	group.PUT("/reports/:reportId", trustSafety, c.ProcessReport)
+
+	/* ADMIN LOG */
+	group.GET("/logs", superadmin, c.ListLogs)
+
+	/* EMAILS */
+	group.GET("/emails", support, c.ListEmails)
+	group.POST("/emails/:emailId/replay", support, c.ReplayEmail)
+	group.GET("/emails/templates/:template/preview", support, c.PreviewTemplate)
+}
+
+// actor is the staff member making the request, the authorization middleware resolved their role
+func (c *adminController) actor(ctx *gin.Context) *Actor {
+	return &Actor{
+		UserId:    *c.MustGetUserId(ctx),
+		Role:      model.PlatformRole(c.GetPlatformRole(ctx)),
+		IpAddress: c.MustGetIP(ctx),
+	}
+}
+
+func (c *adminController) ListStaff(ctx *gin.Context) {
+	staff, err := c.adminService.ListStaff(ctx.Request.Context())
+	if err != nil {
+		c.Send(ctx).MixedError(err)
+		return
+	}
+	c.Send(ctx).SuccessDataResponse("Platform staff retrieved successfully", staff)
+}
+
+// GrantRole gives the user a platform role, replacing the one they held
+func (c *adminController) GrantRole(ctx *gin.Context) {
+	body, err := network.ReqBody(ctx, dto.NewGrantRoleRequest())
+	if err != nil {
+		return
+	}
+
+	staff, apiErr := c.adminService.GrantRole(ctx.Request.Context(), c.actor(ctx), ctx.Param("userId"), body.Role)
+	if apiErr != nil {
+		c.Send(ctx).MixedError(apiErr)
+		return
+	}
+	c.Send(ctx).SuccessDataResponse("Platform role granted successfully", staff)
+}
+
+func (c *adminController) RevokeRole(ctx *gin.Context) {
+	if err := c.adminService.RevokeRole(ctx.Request.Context(), c.actor(ctx), ctx.Param("userId")); err != nil {
+		c.Send(ctx).MixedError(err)
+		return
+	}
+	c.Send(ctx).SuccessMsgResponse("Platform role revoked successfully")
+}
+
+// ListUsers lists users of any status, ?status=banned shows the banned ones
+func (c *adminController) ListUsers(ctx *gin.Context) {
+	query, err := network.ReqQuery(ctx, dto.NewListUsersRequest())
+	if err != nil {
+		return
+	}
+
+	users, page, apiErr := c.adminService.ListUsers(ctx.Request.Context(), query.Status, query.Cursor, query.Limit)
+	if apiErr != nil {
+		c.Send(ctx).MixedError(apiErr)
+		return
+	}
+	c.Send(ctx).SuccessDataResponse("Users retrieved successfully", dto.NewListUsersResponse(users, page.NextCursor, page.HasMore))
+}
+
+// BanUser bans the user platform-wide and ends all their sessions
+func (c *adminController) BanUser(ctx *gin.Context) {
+	body, err := network.ReqBody(ctx, dto.NewAdminActionRequest())
+	if err != nil {
+		return
+	}
+
+	adminLog, apiErr := c.adminService.BanUser(ctx.Request.Context(), c.actor(ctx), ctx.Param("userId"), body.Reason)
+	if apiErr != nil {
+		c.Send(ctx).MixedError(apiErr)
+		return
+	}
+	c.Send(ctx).SuccessDataResponse("User banned successfully", adminLog)
+}
+
+func (c *adminController) UnbanUser(ctx *gin.Context) {
+	body, err := network.ReqBody(ctx, dto.NewAdminActionRequest())
+	if err != nil {
+		return
+	}
+
+	adminLog, apiErr := c.adminService.UnbanUser(ctx.Request.Context(), c.actor(ctx), ctx.Param("userId"), body.Reason)
+	if apiErr != nil {
+		c.Send(ctx).MixedError(apiErr)
+		return
+	}
+	c.Send(ctx).SuccessDataResponse("User unbanned successfully", adminLog)
+}
+
+// ListCommunities lists communities of any status, ?status=banned shows the ones taken down
+func (c *adminController) ListCommunities(ctx *gin.Context) {
+	query, err := network.ReqQuery(ctx, dto.NewListCommunitiesRequest())
+	if err != nil {
+		return
+	}
+
+	communities, page, apiErr := c.adminService.ListCommunities(ctx.Request.Context(), query.Status, query.Cursor, query.Limit)
+	if apiErr != nil {
+		c.Send(ctx).MixedError(apiErr)
+		return
+	}
+	c.Send(ctx).SuccessDataResponse("Communities retrieved successfully", dto.NewListCommunitiesResponse(communities, page.NextCursor, page.HasMore))
+}
+
+func (c *adminController) TakedownCommunity(ctx *gin.Context) {
+	body, err := network.ReqBody(ctx, dto.NewAdminActionRequest())
+	if err != nil {
+		return
+	}
+
+	adminLog, apiErr := c.adminService.TakedownCommunity(ctx.Request.Context(), c.actor(ctx), ctx.Param("communityId"), body.Reason)
+	if apiErr != nil {
+		c.Send(ctx).MixedError(apiErr)
+		return
+	}
+	c.Send(ctx).SuccessDataResponse("Community taken down successfully", adminLog)
+}
+
+func (c *adminController) RestoreCommunity(ctx *gin.Context) {
+	body, err := network.ReqBody(ctx, dto.NewAdminActionRequest())
+	if err != nil {
+		return
+	}
+
+	adminLog, apiErr := c.adminService.RestoreCommunity(ctx.Request.Context(), c.actor(ctx), ctx.Param("communityId"), body.Reason)
+	if apiErr != nil {
+		c.Send(ctx).MixedError(apiErr)
+		return
+	}
+	c.Send(ctx).SuccessDataResponse("Community restored successfully", adminLog)
+}
+
+// ListReports is the report queue of every community, ?status=pending shows the open reports
+func (c *adminController) ListReports(ctx *gin.Context) {
+	query, err := network.ReqQuery(ctx, dto.NewListReportsRequest())
+	if err != nil {
+		return
+	}
+
+	reports, page, apiErr := c.adminService.ListReports(ctx.Request.Context(), query.CommunityId, query.Status, query.TargetType, query.Cursor, query.Limit)
+	if apiErr != nil {
+		c.Send(ctx).MixedError(apiErr)
+		return
+	}
+	c.Send(ctx).SuccessDataResponse("Reports retrieved successfully", dto.NewListReportsResponse(reports, page.NextCursor, page.HasMore))
+}
+
+func (c *adminController) ProcessReport(ctx *gin.Context) {
+	body, err := network.ReqBody(ctx, dto.NewProcessReportRequest())
+	if err != nil {
+		return
+	}
+
+	report, apiErr := c.adminService.ProcessReport(ctx.Request.Context(), c.actor(ctx), ctx.Param("reportId"), body.Status, body.Notes, body.ActionTaken)
+	if apiErr != nil {
+		c.Send(ctx).MixedError(apiErr)
+		return
+	}
+	c.Send(ctx).SuccessDataResponse("Report processed successfully", report)
+}
+
+// ListLogs reads the admin log, newest first
+func (c *adminController) ListLogs(ctx *gin.Context) {
+	query, err := network.ReqQuery(ctx, dto.NewListLogsRequest())
+	if err != nil {
+		return
+	}
+
+	logs, page, apiErr := c.adminService.ListLogs(ctx.Request.Context(), query.ActorId, query.Action, query.Cursor, query.Limit)
+	if apiErr != nil {
+		c.Send(ctx).MixedError(apiErr)
+		return
+	}
+	c.Send(ctx).SuccessDataResponse("Admin logs retrieved successfully", dto.NewListLogsResponse(logs, page.NextCursor, page.HasMore))
+}
+
+// ListEmails lists outbox emails, ?status=failed shows the dead letter queue
+func (c *adminController) ListEmails(ctx *gin.Context) {
+	query, err := network.ReqQuery(ctx, dto.NewListEmailsRequest())
+	if err != nil {
+		return
+	}
+
+	emails, page, apiErr := c.emailService.ListEmails(ctx.Request.Context(), query.Status, query.Cursor, query.Limit)
+	if apiErr != nil {
+		c.Send(ctx).MixedError(apiErr)
+		return
+	}
+
+	c.Send(ctx).SuccessDataResponse(
+		"Emails retrieved successfully",
+		dto.NewListEmailsResponse(emails, page.NextCursor, page.HasMore),
+	)
+}
+
+// ReplayEmail requeues a dead-lettered email
+func (c *adminController) ReplayEmail(ctx *gin.Context) {
+	emailId := ctx.Param("emailId")
+	if emailId == "" {
+		c.Send(ctx).BadRequestError(
+			"Email ID is required",
+			"Please provide a valid email ID in the request params",
+			nil,
+		)
+		return
+	}
+
+	emailLog, apiErr := c.adminService.ReplayEmail(ctx.Request.Context(), c.actor(ctx), emailId)
+	if apiErr != nil {
+		c.Send(ctx).MixedError(apiErr)
+		return
+	}
+
+	c.Send(ctx).SuccessDataResponse("Email queued for delivery", emailLog)
+}
+
+// PreviewTemplate renders an email template with sample data, ?locale= picks the language
+func (c *adminController) PreviewTemplate(ctx *gin.Context) {
+	query, err := network.ReqQuery(ctx, dto.NewPreviewTemplateRequest())
+	if err != nil {
+		return
+	}
+
+	locale := query.Locale
+	if locale == "" {
+		locale = email.DefaultLocale
+	}
+
+	rendered, apiErr := c.emailService.PreviewTemplate(ctx.Request.Context(), email.TemplateName(ctx.Param("template")), locale)
+	if apiErr != nil {
+		c.Send(ctx).MixedError(apiErr)
+		return
+	}
+
+	c.Send(ctx).SuccessDataResponse("Template rendered successfully", rendered)
+}
@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// AdminActionRequest carries the reason for a ban, unban, takedown or restore, it is kept in the admin log
+type AdminActionRequest struct {
+	Reason string `json:"reason" binding:"required" validate:"required,min=3,max=500"`
+}
+
+// NewAdminActionRequest creates a new admin action request
+func NewAdminActionRequest() *AdminActionRequest {
+	return &AdminActionRequest{}
+}
+
+func (a *AdminActionRequest) GetValue() *AdminActionRequest {
+	return a
+}
+
+func (a *AdminActionRequest) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		switch err.Tag() {
+		case "required":
+			msgs = append(msgs, err.Field()+" is required")
+		case "min", "max":
+			msgs = append(msgs, err.Field()+" must be between 3 and 500 characters")
+		default:
+			msgs = append(msgs, err.Field()+" is invalid")
+		}
+	}
+	return msgs, nil
+}
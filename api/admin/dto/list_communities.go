@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"sync-backend/api/community/model"
+	coredto "sync-backend/arch/dto"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ListCommunitiesRequest is the request for listing communities of the platform
+type ListCommunitiesRequest struct {
+	coredto.CursorPagination
+	Status model.CommunityStatus `form:"status" query:"status" validate:"omitempty,oneof=active inactive deleted banned"`
+}
+
+// NewListCommunitiesRequest creates a new request for listing communities
+func NewListCommunitiesRequest() *ListCommunitiesRequest {
+	return &ListCommunitiesRequest{
+		CursorPagination: *coredto.NewCursorPagination(),
+	}
+}
+
+func (l *ListCommunitiesRequest) GetValue() *ListCommunitiesRequest {
+	return l
+}
+
+func (l *ListCommunitiesRequest) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		switch err.Tag() {
+		case "oneof":
+			msgs = append(msgs, err.Field()+" must be one of: active, inactive, deleted, banned")
+		default:
+			msgs = append(msgs, err.Field()+" is invalid")
+		}
+	}
+	return msgs, nil
+}
+
+// ListCommunitiesResponse is the response for listing communities
+type ListCommunitiesResponse struct {
+	Communities []*model.Community `json:"communities"`
+	NextCursor  string             `json:"nextCursor,omitempty"`
+	HasMore     bool               `json:"hasMore"`
+}
+
+// NewListCommunitiesResponse creates a new response for listing communities
+func NewListCommunitiesResponse(communities []*model.Community, nextCursor string, hasMore bool) *ListCommunitiesResponse {
+	if communities == nil {
+		communities = []*model.Community{}
+	}
+	return &ListCommunitiesResponse{
+		Communities: communities,
+		NextCursor:  nextCursor,
+		HasMore:     hasMore,
+	}
+}
@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"sync-backend/api/common/email/model"
+	coredto "sync-backend/arch/dto"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ListEmailsRequest is the request for listing outbox emails
+type ListEmailsRequest struct {
+	coredto.CursorPagination
+	Status model.EmailStatus `form:"status" query:"status" validate:"omitempty,oneof=pending sending sent failed"`
+}
+
+// NewListEmailsRequest creates a new request for listing outbox emails
+func NewListEmailsRequest() *ListEmailsRequest {
+	return &ListEmailsRequest{
+		CursorPagination: *coredto.NewCursorPagination(),
+	}
+}
+
+func (l *ListEmailsRequest) GetValue() *ListEmailsRequest {
+	return l
+}
+
+func (l *ListEmailsRequest) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		switch err.Tag() {
+		case "oneof":
+			msgs = append(msgs, err.Field()+" must be one of: pending, sending, sent, failed")
+		default:
+			msgs = append(msgs, err.Field()+" is invalid")
+		}
+	}
+	return msgs, nil
+}
+
+// ListEmailsResponse is the response for listing outbox emails
+type ListEmailsResponse struct {
+	Emails     []*model.EmailLog `json:"emails"`
+	NextCursor string            `json:"nextCursor,omitempty"`
+	HasMore    bool              `json:"hasMore"`
+}
+
+// NewListEmailsResponse creates a new response for listing outbox emails
+func NewListEmailsResponse(emails []*model.EmailLog, nextCursor string, hasMore bool) *ListEmailsResponse {
+	if emails == nil {
+		emails = []*model.EmailLog{}
+	}
+	return &ListEmailsResponse{
+		Emails:     emails,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}
+}
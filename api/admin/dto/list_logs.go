@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"sync-backend/api/admin/model"
+	coredto "sync-backend/arch/dto"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ListLogsRequest is the request for reading the admin log
+type ListLogsRequest struct {
+	coredto.CursorPagination
+	ActorId string                `form:"actorId" query:"actorId" validate:"omitempty,max=64"`
+	Action  model.AdminActionType `form:"action" query:"action" validate:"omitempty,max=64"`
+}
+
+// NewListLogsRequest creates a new request for reading the admin log
+func NewListLogsRequest() *ListLogsRequest {
+	return &ListLogsRequest{
+		CursorPagination: *coredto.NewCursorPagination(),
+	}
+}
+
+func (l *ListLogsRequest) GetValue() *ListLogsRequest {
+	return l
+}
+
+func (l *ListLogsRequest) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Field()+" is invalid")
+	}
+	return msgs, nil
+}
+
+// ListLogsResponse is the response for reading the admin log
+type ListLogsResponse struct {
+	Logs       []*model.AdminLog `json:"logs"`
+	NextCursor string            `json:"nextCursor,omitempty"`
+	HasMore    bool              `json:"hasMore"`
+}
+
+// NewListLogsResponse creates a new response for reading the admin log
+func NewListLogsResponse(logs []*model.AdminLog, nextCursor string, hasMore bool) *ListLogsResponse {
+	if logs == nil {
+		logs = []*model.AdminLog{}
+	}
+	return &ListLogsResponse{
+		Logs:       logs,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}
+}
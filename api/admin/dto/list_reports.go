@@ -0,0 +1,94 @@
+package dto
+
+import (
+	"sync-backend/api/moderator/model"
+	coredto "sync-backend/arch/dto"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ListReportsRequest is the request for the report queue across all communities
+type ListReportsRequest struct {
+	coredto.CursorPagination
+	CommunityId string             `form:"communityId" query:"communityId" validate:"omitempty,max=64"`
+	Status      model.ReportStatus `form:"status" query:"status" validate:"omitempty,oneof=pending approved rejected ignored"`
+	TargetType  model.ReportType   `form:"targetType" query:"targetType" validate:"omitempty,oneof=post comment user community"`
+}
+
+// NewListReportsRequest creates a new request for listing reports
+func NewListReportsRequest() *ListReportsRequest {
+	return &ListReportsRequest{
+		CursorPagination: *coredto.NewCursorPagination(),
+	}
+}
+
+func (l *ListReportsRequest) GetValue() *ListReportsRequest {
+	return l
+}
+
+func (l *ListReportsRequest) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		switch {
+		case err.Tag() == "oneof" && err.Field() == "Status":
+			msgs = append(msgs, err.Field()+" must be one of: pending, approved, rejected, ignored")
+		case err.Tag() == "oneof":
+			msgs = append(msgs, err.Field()+" must be one of: post, comment, user, community")
+		default:
+			msgs = append(msgs, err.Field()+" is invalid")
+		}
+	}
+	return msgs, nil
+}
+
+// ListReportsResponse is the response for listing reports
+type ListReportsResponse struct {
+	Reports    []*model.Report `json:"reports"`
+	NextCursor string          `json:"nextCursor,omitempty"`
+	HasMore    bool            `json:"hasMore"`
+}
+
+// NewListReportsResponse creates a new response for listing reports
+func NewListReportsResponse(reports []*model.Report, nextCursor string, hasMore bool) *ListReportsResponse {
+	if reports == nil {
+		reports = []*model.Report{}
+	}
+	return &ListReportsResponse{
+		Reports:    reports,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}
+}
+
+// ProcessReportRequest is the request for settling a report
+type ProcessReportRequest struct {
+	Status      model.ReportStatus `json:"status" binding:"required" validate:"required,oneof=approved rejected ignored"`
+	Notes       string             `json:"notes" validate:"max=1000"`
+	ActionTaken string             `json:"actionTaken" validate:"max=200"`
+}
+
+// NewProcessReportRequest creates a new request for settling a report
+func NewProcessReportRequest() *ProcessReportRequest {
+	return &ProcessReportRequest{}
+}
+
+func (p *ProcessReportRequest) GetValue() *ProcessReportRequest {
+	return p
+}
+
+func (p *ProcessReportRequest) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		switch err.Tag() {
+		case "required":
+			msgs = append(msgs, err.Field()+" is required")
+		case "oneof":
+			msgs = append(msgs, err.Field()+" must be one of: approved, rejected, ignored")
+		case "max":
+			msgs = append(msgs, err.Field()+" must be at most "+err.Param()+" characters")
+		default:
+			msgs = append(msgs, err.Field()+" is invalid")
+		}
+	}
+	return msgs, nil
+}
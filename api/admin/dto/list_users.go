@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"sync-backend/api/user/model"
+	coredto "sync-backend/arch/dto"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ListUsersRequest is the request for listing users of the platform
+type ListUsersRequest struct {
+	coredto.CursorPagination
+	Status model.UserStatus `form:"status" query:"status" validate:"omitempty,oneof=active inactive banned deleted"`
+}
+
+// NewListUsersRequest creates a new request for listing users
+func NewListUsersRequest() *ListUsersRequest {
+	return &ListUsersRequest{
+		CursorPagination: *coredto.NewCursorPagination(),
+	}
+}
+
+func (l *ListUsersRequest) GetValue() *ListUsersRequest {
+	return l
+}
+
+func (l *ListUsersRequest) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		switch err.Tag() {
+		case "oneof":
+			msgs = append(msgs, err.Field()+" must be one of: active, inactive, banned, deleted")
+		default:
+			msgs = append(msgs, err.Field()+" is invalid")
+		}
+	}
+	return msgs, nil
+}
+
+// ListUsersResponse is the response for listing users
+type ListUsersResponse struct {
+	Users      []*model.User `json:"users"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+	HasMore    bool          `json:"hasMore"`
+}
+
+// NewListUsersResponse creates a new response for listing users
+func NewListUsersResponse(users []*model.User, nextCursor string, hasMore bool) *ListUsersResponse {
+	if users == nil {
+		users = []*model.User{}
+	}
+	return &ListUsersResponse{
+		Users:      users,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}
+}
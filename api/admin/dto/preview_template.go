@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// PreviewTemplateRequest is the request for previewing an email template
+type PreviewTemplateRequest struct {
+	Locale string `form:"locale" query:"locale" validate:"omitempty,min=2,max=10"`
+}
+
+// NewPreviewTemplateRequest creates a new request for previewing an email template
+func NewPreviewTemplateRequest() *PreviewTemplateRequest {
+	return &PreviewTemplateRequest{}
+}
+
+func (p *PreviewTemplateRequest) GetValue() *PreviewTemplateRequest {
+	return p
+}
+
+func (p *PreviewTemplateRequest) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		switch err.Tag() {
+		case "min", "max":
+			msgs = append(msgs, err.Field()+" must be a language code such as en or es-MX")
+		default:
+			msgs = append(msgs, err.Field()+" is invalid")
+		}
+	}
+	return msgs, nil
+}
@@ -0,0 +1,36 @@
+package dto
+
+import (
+	"sync-backend/api/admin/model"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// GrantRoleRequest is the request for giving a user a platform role
+type GrantRoleRequest struct {
+	Role model.PlatformRole `json:"role" binding:"required" validate:"required,oneof=superadmin trust_safety support"`
+}
+
+// NewGrantRoleRequest creates a new request for granting a platform role
+func NewGrantRoleRequest() *GrantRoleRequest {
+	return &GrantRoleRequest{}
+}
+
+func (g *GrantRoleRequest) GetValue() *GrantRoleRequest {
+	return g
+}
+
+func (g *GrantRoleRequest) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		switch err.Tag() {
+		case "required":
+			msgs = append(msgs, err.Field()+" is required")
+		case "oneof":
+			msgs = append(msgs, err.Field()+" must be one of: superadmin, trust_safety, support")
+		default:
+			msgs = append(msgs, err.Field()+" is invalid")
+		}
+	}
+	return msgs, nil
+}
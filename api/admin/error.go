@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"fmt"
+	"sync-backend/arch/network"
+)
+
+const (
+	ERR_ADMIN_DB          = "ERR_ADMIN_DB"
+	ERR_ADMIN_LOG         = "ERR_ADMIN_LOG"
+	ERR_SESSION_REVOKE    = "ERR_SESSION_REVOKE"
+	ERR_PROTECTED_ACCOUNT = "ERR_PROTECTED_ACCOUNT"
+)
+
+func NewDBError(action, extra string) network.ApiError {
+	return network.NewInternalServerError(
+		"Database Error",
+		fmt.Sprintf("Database error occurred during %s. Details: %s", action, extra),
+		ERR_ADMIN_DB,
+		nil,
+	)
+}
+
+// The action was applied but could not be recorded
+func NewAdminLogError(action string, targetId string, err error) network.ApiError {
+	return network.NewInternalServerError(
+		"Admin action not logged",
+		fmt.Sprintf("The %s action was applied but could not be written to the admin log. [Context: targetId=%s]", action, targetId),
+		ERR_ADMIN_LOG,
+		err,
+	)
+}
+
+func NewSessionRevokeError(userId string, failed int) network.ApiError {
+	return network.NewInternalServerError(
+		"Sessions not revoked",
+		fmt.Sprintf("The user is banned but %d of their sessions could not be revoked, ban the user again to retry. [Context: userId=%s]", failed, userId),
+		ERR_SESSION_REVOKE,
+		nil,
+	)
+}
+
+func NewSelfActionError(action string) network.ApiError {
+	return network.NewBadRequestError(
+		"Self Action Not Allowed",
+		fmt.Sprintf("You cannot %s yourself. This action is not allowed.", action),
+		nil,
+	)
+}
+
+func NewProtectedAccountError(action string, userId string) network.ApiError {
+	return network.NewForbiddenErrorWithCode(
+		"Account is platform staff",
+		fmt.Sprintf("Cannot %s a user holding a platform role, revoke the role first. [Context: userId=%s]", action, userId),
+		ERR_PROTECTED_ACCOUNT,
+		nil,
+	)
+}
+
+func NewConfiguredAdminError(userId string) network.ApiError {
+	return network.NewBadRequestError(
+		"Role set in configuration",
+		fmt.Sprintf("The user is listed in admin.user_ids and stays a superadmin until removed from the configuration. [Context: userId=%s]", userId),
+		nil,
+	)
+}
+
+func NewStaffNotFoundError(userId string) network.ApiError {
+	return network.NewNotFoundError(
+		"Staff member not found",
+		fmt.Sprintf("The user holds no platform role. [Context: userId=%s]", userId),
+		nil,
+	)
+}
+
+func NewCommunityNotFoundError(communityId string) network.ApiError {
+	return network.NewNotFoundError(
+		"Community not found",
+		fmt.Sprintf("Community with ID '%s' does not exist. [Context: communityId=%s]", communityId, communityId),
+		nil,
+	)
+}
+
+func NewStatusConflictError(targetType string, targetId string, status string, action string) network.ApiError {
+	return network.NewConflictError(
+		fmt.Sprintf("Cannot %s %s", action, targetType),
+		fmt.Sprintf("The %s is %s. [Context: %sId=%s, status=%s]", targetType, status, targetType, targetId, status),
+		nil,
+	)
+}
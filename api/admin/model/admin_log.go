@@ -0,0 +1,135 @@
+package model
+
+import (
+	"context"
+	"sync-backend/arch/mongo"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongod "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const AdminLogCollectionName = "admin_logs"
+
+// AdminActionType is the kind of platform action recorded in the admin log
+type AdminActionType string
+
+const (
+	// Staff actions
+	ActionGrantRole  AdminActionType = "grant_role"
+	ActionRevokeRole AdminActionType = "revoke_role"
+
+	// User actions
+	ActionBanUser   AdminActionType = "ban_user"
+	ActionUnbanUser AdminActionType = "unban_user"
+
+	// Community actions
+	ActionTakedownCommunity AdminActionType = "takedown_community"
+	ActionRestoreCommunity  AdminActionType = "restore_community"
+
+	// Report actions
+	ActionProcessReport AdminActionType = "process_report"
+
+	// Email actions
+	ActionReplayEmail AdminActionType = "replay_email"
+)
+
+// AdminLog records an action of the platform staff. Entries are only ever inserted, the admin
+// service has no way to change or remove them.
+type AdminLog struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	LogId      string             `bson:"logId" json:"id"`
+	ActorId    string             `bson:"actorId" json:"actorId" validate:"required"`
+	ActorRole  PlatformRole       `bson:"actorRole" json:"actorRole" validate:"required"`
+	Action     AdminActionType    `bson:"action" json:"action" validate:"required"`
+	TargetId   string             `bson:"targetId" json:"targetId" validate:"required"`
+	TargetType string             `bson:"targetType" json:"targetType" validate:"required"` // user, community, report, email
+	Reason     string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	Details    map[string]string  `bson:"details,omitempty" json:"details,omitempty"`
+	CreatedAt  primitive.DateTime `bson:"createdAt" json:"createdAt"`
+	IPAddress  string             `bson:"ipAddress,omitempty" json:"ipAddress,omitempty"`
+}
+
+// NewAdminLog creates a new admin log entry
+func NewAdminLog(actorId string, actorRole PlatformRole, action AdminActionType, targetId string, targetType string) *AdminLog {
+	return &AdminLog{
+		LogId:      uuid.New().String(),
+		ActorId:    actorId,
+		ActorRole:  actorRole,
+		Action:     action,
+		TargetId:   targetId,
+		TargetType: targetType,
+		CreatedAt:  primitive.NewDateTimeFromTime(time.Now()),
+	}
+}
+
+// WithReason adds the reason given by the actor
+func (a *AdminLog) WithReason(reason string) *AdminLog {
+	a.Reason = reason
+	return a
+}
+
+// WithDetails adds details about the action
+func (a *AdminLog) WithDetails(details map[string]string) *AdminLog {
+	a.Details = details
+	return a
+}
+
+// WithIPAddress adds the IP address the action came from
+func (a *AdminLog) WithIPAddress(ipAddress string) *AdminLog {
+	a.IPAddress = ipAddress
+	return a
+}
+
+// GetValue implements mongo.Model interface
+func (a *AdminLog) GetValue() *AdminLog {
+	return a
+}
+
+// Validate implements mongo.Model interface
+func (a *AdminLog) Validate() error {
+	validate := validator.New()
+	return validate.Struct(a)
+}
+
+// GetCollectionName implements mongo.Model interface
+func (a *AdminLog) GetCollectionName() string {
+	return AdminLogCollectionName
+}
+
+// EnsureIndexes implements mongo.Model interface
+func (*AdminLog) EnsureIndexes(db mongo.Database) {
+	indexes := []mongod.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "logId", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("idx_admin_log_id_unique"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "createdAt", Value: -1},
+			},
+			Options: options.Index().SetName("idx_admin_log_created"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "actorId", Value: 1},
+				{Key: "createdAt", Value: -1},
+			},
+			Options: options.Index().SetName("idx_admin_log_actor_created"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "targetId", Value: 1},
+				{Key: "targetType", Value: 1},
+			},
+			Options: options.Index().SetName("idx_admin_log_target"),
+		},
+	}
+	mongo.NewQueryBuilder[AdminLog](db, AdminLogCollectionName).Query(context.Background()).CheckIndexes(indexes)
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"context"
+	"sync-backend/arch/mongo"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongod "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const PlatformStaffCollectionName = "platform_staff"
+
+// PlatformRole is a platform wide role, independent of the community moderator roles
+type PlatformRole string
+
+const (
+	// RoleSuperadmin holds every platform role and manages the staff
+	RoleSuperadmin PlatformRole = "superadmin"
+	// RoleTrustSafety bans users, takes communities down and works the report queue
+	RoleTrustSafety PlatformRole = "trust_safety"
+	// RoleSupport looks users and communities up and handles the email outbox
+	RoleSupport PlatformRole = "support"
+)
+
+// PlatformRoles lists the roles that can be granted
+var PlatformRoles = []PlatformRole{RoleSuperadmin, RoleTrustSafety, RoleSupport}
+
+// PlatformStaff is a user holding a platform role
+type PlatformStaff struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	UserId    string             `bson:"userId" json:"userId" validate:"required"`
+	Role      PlatformRole       `bson:"role" json:"role" validate:"required,oneof=superadmin trust_safety support"`
+	GrantedBy string             `bson:"grantedBy" json:"grantedBy" validate:"required"`
+	CreatedAt primitive.DateTime `bson:"createdAt" json:"createdAt"`
+	UpdatedAt primitive.DateTime `bson:"updatedAt" json:"updatedAt"`
+}
+
+// NewPlatformStaff creates a staff entry granting the role to the user
+func NewPlatformStaff(userId string, role PlatformRole, grantedBy string) *PlatformStaff {
+	now := primitive.NewDateTimeFromTime(time.Now())
+	return &PlatformStaff{
+		UserId:    userId,
+		Role:      role,
+		GrantedBy: grantedBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// GetValue implements mongo.Model interface
+func (p *PlatformStaff) GetValue() *PlatformStaff {
+	return p
+}
+
+// Validate implements mongo.Model interface
+func (p *PlatformStaff) Validate() error {
+	validate := validator.New()
+	return validate.Struct(p)
+}
+
+// GetCollectionName implements mongo.Model interface
+func (p *PlatformStaff) GetCollectionName() string {
+	return PlatformStaffCollectionName
+}
+
+// EnsureIndexes implements mongo.Model interface
+func (*PlatformStaff) EnsureIndexes(db mongo.Database) {
+	indexes := []mongod.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "userId", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("idx_platform_staff_user_unique"),
+		},
+	}
+	mongo.NewQueryBuilder[PlatformStaff](db, PlatformStaffCollectionName).Query(context.Background()).CheckIndexes(indexes)
+}
@@ -0,0 +1,556 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sync-backend/api/admin/model"
+	"sync-backend/api/common/email"
+	emailModels "sync-backend/api/common/email/model"
+	"sync-backend/api/common/session"
+	"sync-backend/api/community"
+	communityModels "sync-backend/api/community/model"
+	"sync-backend/api/moderator"
+	moderatorModels "sync-backend/api/moderator/model"
+	"sync-backend/api/user"
+	userModels "sync-backend/api/user/model"
+	"sync-backend/arch/config"
+	"sync-backend/arch/metrics"
+	"sync-backend/arch/mongo"
+	"sync-backend/arch/network"
+	"sync-backend/arch/redis"
+	"sync-backend/arch/tracing"
+	"sync-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Actor is the staff member performing an admin action
+type Actor struct {
+	UserId    string
+	Role      model.PlatformRole
+	IpAddress string
+}
+
+type AdminService interface {
+	/* PLATFORM STAFF */
+	// GetPlatformRole returns the role of the user, empty when the user is not platform staff
+	GetPlatformRole(ctx context.Context, userId string) (model.PlatformRole, network.ApiError)
+	ListStaff(ctx context.Context) ([]*model.PlatformStaff, network.ApiError)
+	GrantRole(ctx context.Context, actor *Actor, userId string, role model.PlatformRole) (*model.PlatformStaff, network.ApiError)
+	RevokeRole(ctx context.Context, actor *Actor, userId string) network.ApiError
+
+	/* USERS */
+	ListUsers(ctx context.Context, status userModels.UserStatus, cursor string, limit int) ([]*userModels.User, *mongo.CursorResult, network.ApiError)
+	BanUser(ctx context.Context, actor *Actor, userId string, reason string) (*model.AdminLog, network.ApiError)
+	UnbanUser(ctx context.Context, actor *Actor, userId string, reason string) (*model.AdminLog, network.ApiError)
+
+	/* COMMUNITIES */
+	ListCommunities(ctx context.Context, status communityModels.CommunityStatus, cursor string, limit int) ([]*communityModels.Community, *mongo.CursorResult, network.ApiError)
+	TakedownCommunity(ctx context.Context, actor *Actor, communityId string, reason string) (*model.AdminLog, network.ApiError)
+	RestoreCommunity(ctx context.Context, actor *Actor, communityId string, reason string) (*model.AdminLog, network.ApiError)
+
+	/* REPORTS */
+	ListReports(ctx context.Context, communityId string, status moderatorModels.ReportStatus, targetType moderatorModels.ReportType, cursor string, limit int) ([]*moderatorModels.Report, *mongo.CursorResult, network.ApiError)
+	ProcessReport(ctx context.Context, actor *Actor, reportId string, status moderatorModels.ReportStatus, notes string, action string) (*moderatorModels.Report, network.ApiError)
+
+	/* EMAILS */
+	ReplayEmail(ctx context.Context, actor *Actor, emailId string) (*emailModels.EmailLog, network.ApiError)
+
+	/* ADMIN LOG */
+	ListLogs(ctx context.Context, actorId string, action model.AdminActionType, cursor string, limit int) ([]*model.AdminLog, *mongo.CursorResult, network.ApiError)
+}
+
+type adminService struct {
+	network.BaseService
+	logger                utils.AppLogger
+	configuredAdmins      map[string]bool
+	staffQueryBuilder     mongo.QueryBuilder[model.PlatformStaff]
+	logQueryBuilder       mongo.QueryBuilder[model.AdminLog]
+	userQueryBuilder      mongo.QueryBuilder[userModels.User]
+	communityQueryBuilder mongo.QueryBuilder[communityModels.Community]
+	userService           user.UserService
+	sessionService        session.SessionService
+	communityService      community.CommunityService
+	moderatorService      moderator.ModeratorService
+	emailService          email.EmailService
+	store                 redis.Store
+	cacheStore            redis.CacheStore
+	transaction           mongo.TransactionBuilder
+}
+
+func NewAdminService(
+	adminConfig config.AdminConfig,
+	db mongo.Database,
+	store redis.Store,
+	cacheStore redis.CacheStore,
+	userService user.UserService,
+	sessionService session.SessionService,
+	communityService community.CommunityService,
+	moderatorService moderator.ModeratorService,
+	emailService email.EmailService,
+) AdminService {
+	configuredAdmins := make(map[string]bool, len(adminConfig.UserIds))
+	for _, id := range adminConfig.UserIds {
+		configuredAdmins[id] = true
+	}
+	return &adminService{
+		BaseService:           network.NewBaseService(),
+		logger:                utils.NewServiceLogger("AdminService"),
+		configuredAdmins:      configuredAdmins,
+		staffQueryBuilder:     mongo.NewQueryBuilder[model.PlatformStaff](db, model.PlatformStaffCollectionName),
+		logQueryBuilder:       mongo.NewQueryBuilder[model.AdminLog](db, model.AdminLogCollectionName),
+		userQueryBuilder:      mongo.NewQueryBuilder[userModels.User](db, userModels.UserCollectionName),
+		communityQueryBuilder: mongo.NewQueryBuilder[communityModels.Community](db, communityModels.CommunityCollectionName),
+		userService:           userService,
+		sessionService:        sessionService,
+		communityService:      communityService,
+		moderatorService:      moderatorService,
+		emailService:          emailService,
+		store:                 store,
+		cacheStore:            cacheStore,
+		transaction:           mongo.NewTransactionBuilder(db),
+	}
+}
+
+// Users listed in admin.user_ids are superadmins, so there is always someone to grant the first roles
+func (s *adminService) GetPlatformRole(ctx context.Context, userId string) (model.PlatformRole, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AdminService.GetPlatformRole")
+	defer span.End()
+
+	if s.configuredAdmins[userId] {
+		return model.RoleSuperadmin, nil
+	}
+	staff, err := s.staffQueryBuilder.SingleQuery(ctx).FindOne(bson.M{"userId": userId}, nil)
+	if err != nil {
+		if mongo.IsNoDocumentFoundError(err) {
+			return "", nil
+		}
+		s.logger.WithContext(ctx).Error("Error fetching platform role of user %s: %v", userId, err)
+		return "", NewDBError("fetching platform role", err.Error())
+	}
+	return staff.Role, nil
+}
+
+func (s *adminService) ListStaff(ctx context.Context) ([]*model.PlatformStaff, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AdminService.ListStaff")
+	defer span.End()
+
+	staff, err := s.staffQueryBuilder.SingleQuery(ctx).FilterMany(bson.M{}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil && !mongo.IsNoDocumentFoundError(err) {
+		s.logger.WithContext(ctx).Error("Error listing platform staff: %v", err)
+		return nil, NewDBError("listing platform staff", err.Error())
+	}
+
+	// Configured superadmins are listed too, they exist nowhere else
+	listed := make(map[string]bool, len(staff))
+	for _, member := range staff {
+		listed[member.UserId] = true
+	}
+	for userId := range s.configuredAdmins {
+		if !listed[userId] {
+			staff = append(staff, model.NewPlatformStaff(userId, model.RoleSuperadmin, "config"))
+		}
+	}
+	return staff, nil
+}
+
+// GrantRole gives the user a platform role, replacing the one they held
+func (s *adminService) GrantRole(ctx context.Context, actor *Actor, userId string, role model.PlatformRole) (*model.PlatformStaff, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AdminService.GrantRole")
+	defer span.End()
+
+	if actor.UserId == userId {
+		return nil, NewSelfActionError("change the platform role of")
+	}
+	if s.configuredAdmins[userId] {
+		return nil, NewConfiguredAdminError(userId)
+	}
+	if _, err := s.userService.FindUserById(ctx, userId); err != nil {
+		return nil, err
+	}
+	previous, err := s.GetPlatformRole(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	details := map[string]string{"role": string(role)}
+	if previous != "" {
+		details["previousRole"] = string(previous)
+	}
+	now := primitive.NewDateTimeFromTime(time.Now())
+	staff := &model.PlatformStaff{}
+	_, err = s.perform(ctx, s.newLog(actor, model.ActionGrantRole, userId, "user", "", details), func(session mongo.TransactionSession) error {
+		collection := session.Collection(model.PlatformStaffCollectionName)
+		_, upsertErr := collection.UpsertOne(
+			bson.M{"userId": userId},
+			bson.M{
+				"$set":         bson.M{"role": role, "grantedBy": actor.UserId, "updatedAt": now},
+				"$setOnInsert": bson.M{"userId": userId, "createdAt": now},
+			},
+		)
+		if upsertErr != nil {
+			return upsertErr
+		}
+		return collection.FindOne(bson.M{"userId": userId}).Decode(staff)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return staff, nil
+}
+
+func (s *adminService) RevokeRole(ctx context.Context, actor *Actor, userId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "AdminService.RevokeRole")
+	defer span.End()
+
+	if actor.UserId == userId {
+		return NewSelfActionError("revoke the platform role of")
+	}
+	if s.configuredAdmins[userId] {
+		return NewConfiguredAdminError(userId)
+	}
+
+	previous, err := s.GetPlatformRole(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if previous == "" {
+		return NewStaffNotFoundError(userId)
+	}
+
+	adminLog := s.newLog(actor, model.ActionRevokeRole, userId, "user", "", map[string]string{"previousRole": string(previous)})
+	_, err = s.perform(ctx, adminLog, func(session mongo.TransactionSession) error {
+		deleted, deleteErr := session.Collection(model.PlatformStaffCollectionName).DeleteOne(bson.M{"userId": userId, "role": previous})
+		if deleteErr != nil {
+			return deleteErr
+		}
+		if deleted == 0 {
+			return NewStaffNotFoundError(userId)
+		}
+		return nil
+	})
+	return err
+}
+
+func (s *adminService) ListUsers(ctx context.Context, status userModels.UserStatus, cursor string, limit int) ([]*userModels.User, *mongo.CursorResult, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AdminService.ListUsers")
+	defer span.End()
+
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	users, page, err := s.userQueryBuilder.SingleQuery(ctx).FindCursorPaginated(filter, mongo.NewCursorQuery("createdAt", int64(limit), cursor), nil)
+	if err != nil {
+		return nil, nil, s.listError(ctx, "users", err)
+	}
+	return users, page, nil
+}
+
+// BanUser bans the user from the whole platform and ends all their sessions. Banning a banned user
+// again only revokes the sessions a previous ban failed to end.
+func (s *adminService) BanUser(ctx context.Context, actor *Actor, userId string, reason string) (*model.AdminLog, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AdminService.BanUser")
+	defer span.End()
+
+	if actor.UserId == userId {
+		return nil, NewSelfActionError("ban")
+	}
+	target, err := s.userService.FindUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if target.Status == userModels.Deleted {
+		return nil, NewStatusConflictError("user", userId, string(target.Status), "ban")
+	}
+	role, err := s.GetPlatformRole(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if role != "" {
+		return nil, NewProtectedAccountError("ban", userId)
+	}
+
+	adminLog := s.newLog(actor, model.ActionBanUser, userId, "user", reason, map[string]string{"previousStatus": string(target.Status)})
+	adminLog, err = s.perform(ctx, adminLog, func(session mongo.TransactionSession) error {
+		if target.Status == userModels.Banned {
+			return nil
+		}
+		return changeStatus(session, userModels.UserCollectionName, "user", userId, target.Status, userModels.Banned, "ban")
+	})
+	if err != nil {
+		return nil, err
+	}
+	if target.Status != userModels.Banned {
+		s.invalidate(ctx, userModels.UserCacheTag(userId))
+		metrics.BanIssued(metrics.BanScopePlatform)
+	}
+
+	// Sessions are revoked once the ban is committed, a failure is fixed by banning again
+	revoked, failed := s.revokeSessions(ctx, userId)
+	if failed > 0 {
+		return nil, NewSessionRevokeError(userId, failed)
+	}
+	s.logger.WithContext(ctx).Info("User %s banned from the platform by %s, %d sessions revoked", userId, actor.UserId, revoked)
+	return adminLog, nil
+}
+
+func (s *adminService) UnbanUser(ctx context.Context, actor *Actor, userId string, reason string) (*model.AdminLog, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AdminService.UnbanUser")
+	defer span.End()
+
+	target, err := s.userService.FindUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if target.Status != userModels.Banned {
+		return nil, NewStatusConflictError("user", userId, string(target.Status), "unban")
+	}
+	adminLog, err := s.perform(ctx, s.newLog(actor, model.ActionUnbanUser, userId, "user", reason, nil), func(session mongo.TransactionSession) error {
+		return changeStatus(session, userModels.UserCollectionName, "user", userId, userModels.Banned, userModels.Active, "unban")
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, userModels.UserCacheTag(userId))
+	return adminLog, nil
+}
+
+// revokeSessions ends every active session of the user, dropping the cached session so the access
+// tokens stop working at once, and returns how many sessions were and were not revoked
+func (s *adminService) revokeSessions(ctx context.Context, userId string) (int, int) {
+	sessions, err := s.sessionService.GetActiveSessionsByUserID(ctx, userId)
+	if err != nil && !mongo.IsNoDocumentFoundError(err) {
+		s.logger.WithContext(ctx).Error("Error fetching sessions of user %s: %v", userId, err)
+		return 0, 1
+	}
+
+	revoked, failed := 0, 0
+	for _, userSession := range sessions {
+		if err := s.sessionService.InvalidateSession(ctx, userSession.SessionID); err != nil {
+			s.logger.WithContext(ctx).Error("Error revoking session %s of user %s: %v", userSession.SessionID, userId, err)
+			failed++
+			continue
+		}
+		if err := s.store.GetInstance().Del(ctx, session.CacheKey(userSession.Token)).Err(); err != nil {
+			s.logger.WithContext(ctx).Error("Error dropping cached session %s: %v", userSession.SessionID, err)
+		}
+		if err := s.userService.RemoveSessionDeviceTokens(ctx, userId, userSession.SessionID); err != nil {
+			s.logger.WithContext(ctx).Error("Error removing device tokens of session %s: %v", userSession.SessionID, err)
+		}
+		revoked++
+	}
+	return revoked, failed
+}
+
+func (s *adminService) ListCommunities(ctx context.Context, status communityModels.CommunityStatus, cursor string, limit int) ([]*communityModels.Community, *mongo.CursorResult, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AdminService.ListCommunities")
+	defer span.End()
+
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	communities, page, err := s.communityQueryBuilder.SingleQuery(ctx).FindCursorPaginated(filter, mongo.NewCursorQuery("createdAt", int64(limit), cursor), nil)
+	if err != nil {
+		return nil, nil, s.listError(ctx, "communities", err)
+	}
+	return communities, page, nil
+}
+
+// TakedownCommunity bans the community, it disappears from listings and can no longer be joined
+func (s *adminService) TakedownCommunity(ctx context.Context, actor *Actor, communityId string, reason string) (*model.AdminLog, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AdminService.TakedownCommunity")
+	defer span.End()
+
+	target, err := s.findCommunity(ctx, communityId)
+	if err != nil {
+		return nil, err
+	}
+	if target.Status == communityModels.CommunityStatusBanned || target.Status == communityModels.CommunityStatusDeleted {
+		return nil, NewStatusConflictError("community", communityId, string(target.Status), "take down")
+	}
+	adminLog := s.newLog(actor, model.ActionTakedownCommunity, communityId, "community", reason, map[string]string{"previousStatus": string(target.Status)})
+	adminLog, err = s.perform(ctx, adminLog, func(session mongo.TransactionSession) error {
+		return changeStatus(session, communityModels.CommunityCollectionName, "community", communityId, target.Status, communityModels.CommunityStatusBanned, "take down")
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, communityModels.CommunityCacheTag(communityId))
+	return adminLog, nil
+}
+
+func (s *adminService) RestoreCommunity(ctx context.Context, actor *Actor, communityId string, reason string) (*model.AdminLog, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AdminService.RestoreCommunity")
+	defer span.End()
+
+	target, err := s.findCommunity(ctx, communityId)
+	if err != nil {
+		return nil, err
+	}
+	if target.Status != communityModels.CommunityStatusBanned {
+		return nil, NewStatusConflictError("community", communityId, string(target.Status), "restore")
+	}
+	adminLog, err := s.perform(ctx, s.newLog(actor, model.ActionRestoreCommunity, communityId, "community", reason, nil), func(session mongo.TransactionSession) error {
+		return changeStatus(session, communityModels.CommunityCollectionName, "community", communityId, communityModels.CommunityStatusBanned, communityModels.CommunityStatusActive, "restore")
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, communityModels.CommunityCacheTag(communityId))
+	return adminLog, nil
+}
+
+// findCommunity finds the community in any status, the community service only finds active ones
+func (s *adminService) findCommunity(ctx context.Context, communityId string) (*communityModels.Community, network.ApiError) {
+	target, err := s.communityQueryBuilder.SingleQuery(ctx).FindOne(bson.M{"communityId": communityId}, nil)
+	if err != nil {
+		if mongo.IsNoDocumentFoundError(err) {
+			return nil, NewCommunityNotFoundError(communityId)
+		}
+		s.logger.WithContext(ctx).Error("Error fetching community %s: %v", communityId, err)
+		return nil, NewDBError("fetching community", err.Error())
+	}
+	return target, nil
+}
+
+func (s *adminService) ListReports(ctx context.Context, communityId string, status moderatorModels.ReportStatus, targetType moderatorModels.ReportType, cursor string, limit int) ([]*moderatorModels.Report, *mongo.CursorResult, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AdminService.ListReports")
+	defer span.End()
+
+	return s.moderatorService.ListPlatformReports(ctx, communityId, status, targetType, cursor, limit)
+}
+
+// ProcessReport settles a report of any community, it shows in the community moderation log as well
+func (s *adminService) ProcessReport(ctx context.Context, actor *Actor, reportId string, status moderatorModels.ReportStatus, notes string, action string) (*moderatorModels.Report, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AdminService.ProcessReport")
+	defer span.End()
+
+	report, err := s.moderatorService.ProcessReport(ctx, reportId, actor.UserId, status, notes, action)
+	if err != nil {
+		return nil, err
+	}
+	details := map[string]string{"status": string(status), "communityId": report.CommunityId}
+	if action != "" {
+		details["action"] = action
+	}
+	if _, err := s.record(ctx, actor, model.ActionProcessReport, reportId, "report", notes, details); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *adminService) ReplayEmail(ctx context.Context, actor *Actor, emailId string) (*emailModels.EmailLog, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AdminService.ReplayEmail")
+	defer span.End()
+
+	emailLog, err := s.emailService.ReplayEmail(ctx, emailId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.record(ctx, actor, model.ActionReplayEmail, emailId, "email", "", nil); err != nil {
+		return nil, err
+	}
+	return emailLog, nil
+}
+
+func (s *adminService) ListLogs(ctx context.Context, actorId string, action model.AdminActionType, cursor string, limit int) ([]*model.AdminLog, *mongo.CursorResult, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AdminService.ListLogs")
+	defer span.End()
+
+	filter := bson.M{}
+	if actorId != "" {
+		filter["actorId"] = actorId
+	}
+	if action != "" {
+		filter["action"] = action
+	}
+	logs, page, err := s.logQueryBuilder.SingleQuery(ctx).FindCursorPaginated(filter, mongo.NewCursorQuery("createdAt", int64(limit), cursor), nil)
+	if err != nil {
+		return nil, nil, s.listError(ctx, "admin logs", err)
+	}
+	return logs, page, nil
+}
+
+func (s *adminService) newLog(actor *Actor, action model.AdminActionType, targetId string, targetType string, reason string, details map[string]string) *model.AdminLog {
+	return model.NewAdminLog(actor.UserId, actor.Role, action, targetId, targetType).
+		WithReason(reason).
+		WithDetails(details).
+		WithIPAddress(actor.IpAddress)
+}
+
+// perform applies an admin action and appends its entry to the admin log in one transaction, so the
+// log holds every action that took effect and nothing else
+func (s *adminService) perform(ctx context.Context, adminLog *model.AdminLog, apply func(session mongo.TransactionSession) error) (*model.AdminLog, network.ApiError) {
+	tx := s.transaction.GetTransaction(ctx, mongo.DefaultShortTransactionTimeout)
+	err := tx.PerformSingleTransaction(func(session mongo.TransactionSession) error {
+		return applyAndLog(session, adminLog, apply)
+	})
+	if err == nil {
+		return adminLog, nil
+	}
+	if network.IsApiError(err) {
+		return nil, network.AsApiError(err)
+	}
+	s.logger.WithContext(ctx).Error("Error applying %s on %s %s by %s: %v", adminLog.Action, adminLog.TargetType, adminLog.TargetId, adminLog.ActorId, err)
+	return nil, NewDBError(string(adminLog.Action), err.Error())
+}
+
+func applyAndLog(session mongo.TransactionSession, adminLog *model.AdminLog, apply func(session mongo.TransactionSession) error) error {
+	if err := apply(session); err != nil {
+		return err
+	}
+	_, err := session.Collection(model.AdminLogCollectionName).InsertOne(adminLog)
+	return err
+}
+
+// changeStatus moves a user or community from one status to another, failing with a conflict when
+// the status changed since it was read
+func changeStatus[S ~string](session mongo.TransactionSession, collection string, targetType string, targetId string, from S, to S, action string) error {
+	updated, err := session.Collection(collection).UpdateOne(
+		bson.M{targetType + "Id": targetId, "status": from},
+		bson.M{"$set": bson.M{"status": to, "updatedAt": primitive.NewDateTimeFromTime(time.Now())}},
+	)
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return NewStatusConflictError(targetType, targetId, string(from), action)
+	}
+	return nil
+}
+
+// invalidate drops the cached views of a user or community whose status the admin changed
+func (s *adminService) invalidate(ctx context.Context, tag string) {
+	if err := s.cacheStore.InvalidateTag(context.WithoutCancel(ctx), tag); err != nil {
+		s.logger.WithContext(ctx).Error("Error invalidating cache tag %s: %v", tag, err)
+	}
+}
+
+// record appends an action applied outside of the admin service to the admin log, entries are never
+// updated or deleted
+func (s *adminService) record(ctx context.Context, actor *Actor, action model.AdminActionType, targetId string, targetType string, reason string, details map[string]string) (*model.AdminLog, network.ApiError) {
+	adminLog := s.newLog(actor, action, targetId, targetType, reason, details)
+	if _, err := s.logQueryBuilder.SingleQuery(ctx).InsertOne(adminLog); err != nil {
+		s.logger.WithContext(ctx).Error("Error writing admin log for %s on %s %s by %s: %v", action, targetType, targetId, actor.UserId, err)
+		return nil, NewAdminLogError(string(action), targetId, err)
+	}
+	return adminLog, nil
+}
+
+func (s *adminService) listError(ctx context.Context, collection string, err error) network.ApiError {
+	if mongo.IsInvalidCursorError(err) {
+		return network.NewBadRequestError(
+			"Invalid pagination cursor",
+			fmt.Sprintf("Cursor for %s is malformed or expired. [Context: list=%s]", collection, collection),
+			err,
+		)
+	}
+	s.logger.WithContext(ctx).Error("Error listing %s: %v", collection, err)
+	return NewDBError("listing "+collection, err.Error())
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"sync-backend/api/admin/model"
+	userModels "sync-backend/api/user/model"
+	"sync-backend/arch/mongo"
+	"sync-backend/arch/network"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func banLog() *model.AdminLog {
+	return model.NewAdminLog("admin-1", model.RoleTrustSafety, model.ActionBanUser, "user-1", "user").WithReason("spam")
+}
+
+func banUser(session mongo.TransactionSession) error {
+	return changeStatus(session, userModels.UserCollectionName, "user", "user-1", userModels.Active, userModels.Banned, "ban")
+}
+
+func TestApplyAndLogRecordsTheAppliedAction(t *testing.T) {
+	session := mongo.NewMockTransactionSession()
+	_, err := session.Collection(userModels.UserCollectionName).InsertOne(bson.M{"userId": "user-1", "status": userModels.Active})
+	require.NoError(t, err)
+
+	require.NoError(t, applyAndLog(session, banLog(), banUser))
+
+	users := session.Documents(userModels.UserCollectionName)
+	assert.Equal(t, string(userModels.Banned), users[0]["status"])
+	logs := session.Documents(model.AdminLogCollectionName)
+	require.Len(t, logs, 1)
+	assert.Equal(t, string(model.ActionBanUser), logs[0]["action"])
+	assert.Equal(t, "user-1", logs[0]["targetId"])
+}
+
+func TestApplyAndLogSkipsTheLogOfAFailedAction(t *testing.T) {
+	session := mongo.NewMockTransactionSession()
+	// The user was banned since it was read, the ban conflicts and nothing is logged
+	_, err := session.Collection(userModels.UserCollectionName).InsertOne(bson.M{"userId": "user-1", "status": userModels.Banned})
+	require.NoError(t, err)
+
+	err = applyAndLog(session, banLog(), banUser)
+	require.True(t, network.IsApiError(err))
+	assert.Equal(t, http.StatusConflict, network.AsApiError(err).GetStatusCode())
+	assert.Empty(t, session.Documents(model.AdminLogCollectionName))
+
+	err = applyAndLog(session, banLog(), func(mongo.TransactionSession) error { return errors.New("write conflict") })
+	assert.EqualError(t, err, "write conflict")
+	assert.Empty(t, session.Documents(model.AdminLogCollectionName))
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"sync-backend/api/auth/dto"
 	"sync-backend/arch/common"
+	"sync-backend/arch/config"
 	coreMW "sync-backend/arch/middleware"
 	"sync-backend/arch/network"
 	"sync-backend/utils"
@@ -17,14 +18,18 @@ type authController struct {
 	authProvider     network.AuthenticationProvider
 	uploadProvider   coreMW.UploadProvider
 	locationProvider network.LocationProvider
+	csrfProvider     network.CSRFProvider
 	authService      AuthService
+	cookies          *tokenCookies
 }
 
 func NewAuthController(
 	authProvider network.AuthenticationProvider,
 	locationProvider network.LocationProvider,
 	uploadProvider coreMW.UploadProvider,
+	csrfProvider network.CSRFProvider,
 	authService AuthService,
+	authConfig config.AuthConfig,
 ) network.Controller {
 	return &authController{
 		logger:           utils.NewServiceLogger("AuthController"),
@@ -33,7 +38,9 @@ func NewAuthController(
 		authProvider:     authProvider,
 		uploadProvider:   uploadProvider,
 		locationProvider: locationProvider,
+		csrfProvider:     csrfProvider,
 		authService:      authService,
+		cookies:          newTokenCookies(authConfig),
 	}
 }
 
@@ -43,6 +50,7 @@ func (c *authController) MountRoutes(group *gin.RouterGroup) {
 	/* ACCOUNT CREATION */
 	group.POST("/signup", c.locationProvider.Middleware(), c.uploadProvider.Middleware("profile_photo", "background_photo"), c.SignUp)
 	group.POST("/login", c.locationProvider.Middleware(), c.Login)
+	group.POST("/login/verify", c.VerifyLogin)
 	group.POST("/google", c.locationProvider.Middleware(), c.GoogleLogin)
 
 	/* AUTHENTICATION */
@@ -54,9 +62,11 @@ func (c *authController) MountRoutes(group *gin.RouterGroup) {
 
 	/* EMAIL VERIFICATION */
 	group.GET("/verify-email/:token", c.VerifyEmail)
+	group.POST("/resend-verification", c.authProvider.Middleware(), c.ResendVerificationEmail)
 
 	/* TOKEN MANAGEMENT */
-	group.POST("/refresh-token", c.locationProvider.Middleware(), c.RefreshToken)
+	group.POST("/refresh-token", c.locationProvider.Middleware(), c.csrfProvider.Middleware(), c.RefreshToken)
+	group.GET("/csrf", c.authProvider.Middleware(), c.CSRFToken)
 }
 
 func (c *authController) SignUp(ctx *gin.Context) {
@@ -76,13 +86,13 @@ func (c *authController) SignUp(ctx *gin.Context) {
 
 	c.SetRequestDeviceDetails(ctx, &body.BaseDeviceRequest)
 	c.SetRequestLocationDetails(ctx, &body.BaseLocationRequest)
-	data, err := c.authService.SignUp(body)
+	data, err := c.authService.SignUp(ctx.Request.Context(), body)
 
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
 	}
-	c.Send(ctx).SuccessDataResponse("User created successfully", data)
+	c.sendSession(ctx, "User created successfully", data, &data.SessionTokens)
 	c.uploadProvider.DeleteUploadedFiles(ctx, "profile_photo")
 	c.uploadProvider.DeleteUploadedFiles(ctx, "background_photo")
 }
@@ -94,12 +104,29 @@ func (c *authController) Login(ctx *gin.Context) {
 	}
 	c.SetRequestDeviceDetails(ctx, &body.BaseDeviceRequest)
 	c.SetRequestLocationDetails(ctx, &body.BaseLocationRequest)
-	data, err := c.authService.Login(body)
+	data, err := c.authService.Login(ctx.Request.Context(), body)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
 	}
-	c.Send(ctx).SuccessDataResponse("User logged in successfully", data)
+	if data.OtpRequired {
+		c.Send(ctx).SuccessDataResponse("Login code sent to your email", data)
+		return
+	}
+	c.sendSession(ctx, "User logged in successfully", data, &data.SessionTokens)
+}
+
+func (c *authController) VerifyLogin(ctx *gin.Context) {
+	body, err := network.ReqBody(ctx, dto.NewLoginVerifyRequest())
+	if err != nil {
+		return
+	}
+	data, err := c.authService.VerifyLogin(ctx.Request.Context(), body)
+	if err != nil {
+		c.Send(ctx).MixedError(err)
+		return
+	}
+	c.sendSession(ctx, "User logged in successfully", data, &data.SessionTokens)
 }
 
 func (c *authController) GoogleLogin(ctx *gin.Context) {
@@ -109,17 +136,19 @@ func (c *authController) GoogleLogin(ctx *gin.Context) {
 	}
 	c.SetRequestDeviceDetails(ctx, &body.BaseDeviceRequest)
 	c.SetRequestLocationDetails(ctx, &body.BaseLocationRequest)
-	data, err := c.authService.GoogleLogin(body)
+	data, err := c.authService.GoogleLogin(ctx.Request.Context(), body)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
 	}
-	c.Send(ctx).SuccessDataResponse("User logged in with google successfully", data)
+	c.sendSession(ctx, "User logged in with google successfully", data, &data.SessionTokens)
 }
 
 func (c *authController) Logout(ctx *gin.Context) {
 	userId := *c.MustGetUserId(ctx)
-	err := c.authService.Logout(userId)
+	err := c.authService.Logout(ctx.Request.Context(), userId, c.MustGetSessionId(ctx))
+	// The browser drops its tokens even when the session could not be ended, it asked to be logged out
+	c.cookies.clear(ctx)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -134,7 +163,7 @@ func (c *authController) ForgotPassword(ctx *gin.Context) {
 	}
 
 	c.SetRequestDeviceDetails(ctx, &body.BaseDeviceRequest)
-	err = c.authService.ForgotPassword(body)
+	err = c.authService.ForgotPassword(ctx.Request.Context(), body)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -143,18 +172,59 @@ func (c *authController) ForgotPassword(ctx *gin.Context) {
 }
 
 func (c *authController) RefreshToken(ctx *gin.Context) {
-	body, err := network.ReqBody(ctx, dto.NewRefreshTokenRequest())
-	if err != nil {
-		return
+	var body *dto.RefreshTokenRequest
+	if c.cookies.requested(ctx) {
+		// The tokens are HttpOnly cookies the client can not put in the body
+		body = dto.NewRefreshTokenRequest()
+		body.RefreshToken = c.cookies.refreshToken(ctx)
+		if body.RefreshToken == "" {
+			c.Send(ctx).UnauthorizedError("Missing refresh token", "The refresh token cookie is not set", nil)
+			return
+		}
+	} else {
+		var err error
+		body, err = network.ReqBody(ctx, dto.NewRefreshTokenRequest())
+		if err != nil {
+			return
+		}
 	}
 	c.SetRequestDeviceDetails(ctx, &body.BaseDeviceRequest)
 	c.SetRequestLocationDetails(ctx, &body.BaseLocationRequest)
-	data, err := c.authService.RefreshToken(body)
+	data, err := c.authService.RefreshToken(ctx.Request.Context(), body)
+	if err != nil {
+		c.Send(ctx).MixedError(err)
+		return
+	}
+	c.sendSession(ctx, "Token refreshed successfully", data, &data.SessionTokens)
+}
+
+// CSRFToken replaces the csrf token of the session, cookie clients call it once theirs expired
+func (c *authController) CSRFToken(ctx *gin.Context) {
+	csrfToken, err := c.authService.IssueCSRFToken(ctx.Request.Context(), c.MustGetSessionId(ctx))
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
 	}
-	c.Send(ctx).SuccessDataResponse("Token refreshed successfully", data)
+	if c.cookies.config.Enabled {
+		c.cookies.setCSRF(ctx, csrfToken)
+	}
+	c.Send(ctx).SuccessDataResponse("CSRF token issued successfully", dto.NewCSRFTokenResponse(csrfToken))
+}
+
+// sendSession answers a request that started or refreshed a session. Clients asking for the cookie
+// transport get the tokens as cookies, with a new csrf token, instead of in the body.
+func (c *authController) sendSession(ctx *gin.Context, message string, data any, tokens *dto.SessionTokens) {
+	if c.cookies.requested(ctx) {
+		csrfToken, err := c.authService.IssueCSRFToken(ctx.Request.Context(), tokens.SessionId)
+		if err != nil {
+			c.Send(ctx).MixedError(err)
+			return
+		}
+		c.cookies.set(ctx, tokens, csrfToken)
+		tokens.AccessToken = ""
+		tokens.RefreshToken = ""
+	}
+	c.Send(ctx).SuccessDataResponse(message, data)
 }
 
 func (c *authController) VerifyEmail(ctx *gin.Context) {
@@ -164,7 +234,7 @@ func (c *authController) VerifyEmail(ctx *gin.Context) {
 		return
 	}
 
-	user, err := c.authService.VerifyEmail(req.Token)
+	user, err := c.authService.VerifyEmail(ctx.Request.Context(), req.Token)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -177,13 +247,24 @@ func (c *authController) VerifyEmail(ctx *gin.Context) {
 	c.Send(ctx).SuccessDataResponse("Email verified successfully", response)
 }
 
+func (c *authController) ResendVerificationEmail(ctx *gin.Context) {
+	userId := c.MustGetUserId(ctx)
+
+	if err := c.authService.ResendVerificationEmail(ctx.Request.Context(), *userId); err != nil {
+		c.Send(ctx).MixedError(err)
+		return
+	}
+
+	c.Send(ctx).SuccessMsgResponse("Verification email sent")
+}
+
 func (c *authController) ResetPassword(ctx *gin.Context) {
 	body, err := network.ReqBody(ctx, dto.NewResetPasswordRequest())
 	if err != nil {
 		return
 	}
 
-	err = c.authService.ResetPassword(body.Token, body.NewPassword)
+	err = c.authService.ResetPassword(ctx.Request.Context(), body.Token, body.NewPassword)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
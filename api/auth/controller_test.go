@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sync-backend/arch/common"
+	"sync-backend/arch/config"
+	"sync-backend/arch/network"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubLogoutService struct {
+	AuthService
+	err network.ApiError
+}
+
+func (s *stubLogoutService) Logout(context.Context, string, string) network.ApiError {
+	return s.err
+}
+
+func TestLogoutClearsCookiesWhenSessionCanNotBeEnded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	controller := &authController{
+		BaseController: network.NewBaseController("/auth", nil),
+		ContextPayload: common.NewContextPayload(),
+		authService: &stubLogoutService{
+			err: network.NewServiceUnavailableError("Error logging out", "session store unavailable", errors.New("dial tcp: connection refused")),
+		},
+		cookies: newTokenCookies(config.AuthConfig{Cookie: config.AuthCookieConfig{
+			Enabled:          true,
+			AccessTokenName:  "access_token",
+			RefreshTokenName: "refresh_token",
+			CSRFTokenName:    "csrf_token",
+		}}),
+	}
+
+	rr := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rr)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	ctx.Set(network.UserPayload, "user-1")
+	ctx.Set(network.SessionIdHeader, "session-1")
+	controller.Logout(ctx)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	cleared := map[string]bool{}
+	for _, cookie := range rr.Result().Cookies() {
+		cleared[cookie.Name] = cookie.MaxAge < 0
+	}
+	assert.Equal(t, map[string]bool{"access_token": true, "refresh_token": true, "csrf_token": true}, cleared)
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"sync-backend/api/auth/dto"
+	"sync-backend/arch/config"
+	"sync-backend/arch/network"
+	"sync-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tokenCookies writes the tokens of the clients that asked for the cookie transport. The access and
+// refresh tokens are HttpOnly, the csrf token is left readable so the client can send it back in
+// the X-CSRF-Token header.
+type tokenCookies struct {
+	config        config.AuthCookieConfig
+	sameSite      http.SameSite
+	accessMaxAge  int
+	refreshMaxAge int
+}
+
+func newTokenCookies(authConfig config.AuthConfig) *tokenCookies {
+	cookies := &tokenCookies{config: authConfig.Cookie}
+	if !cookies.config.Enabled {
+		return cookies
+	}
+	if cookies.config.Path == "" {
+		cookies.config.Path = "/"
+	}
+	switch strings.ToLower(cookies.config.SameSite) {
+	case "strict":
+		cookies.sameSite = http.SameSiteStrictMode
+	case "none":
+		// Browsers reject SameSite=None cookies that are not Secure
+		cookies.sameSite = http.SameSiteNoneMode
+		cookies.config.Secure = true
+	default:
+		cookies.sameSite = http.SameSiteLaxMode
+	}
+	cookies.accessMaxAge = int(utils.ParseSafeDuration(authConfig.JWT.AccessTokenExpiry).Seconds())
+	cookies.refreshMaxAge = int(utils.ParseSafeDuration(authConfig.JWT.RefreshTokenExpiry).Seconds())
+	return cookies
+}
+
+// requested reports whether the client asked for the cookie transport. It is a custom header, so a
+// cross-site form can not log the browser into another account.
+func (c *tokenCookies) requested(ctx *gin.Context) bool {
+	return c.config.Enabled && ctx.GetHeader(network.TokenTransportHeader) == network.CookieTransport
+}
+
+func (c *tokenCookies) set(ctx *gin.Context, tokens *dto.SessionTokens, csrfToken string) {
+	c.write(ctx, c.config.AccessTokenName, tokens.AccessToken, c.accessMaxAge, true)
+	c.write(ctx, c.config.RefreshTokenName, tokens.RefreshToken, c.refreshMaxAge, true)
+	c.setCSRF(ctx, csrfToken)
+}
+
+// setCSRF keeps the cookie as long as the refresh token, the storage enforces the token expiry. The
+// refresh only compares the cookie to the header and must keep working after the token expired.
+func (c *tokenCookies) setCSRF(ctx *gin.Context, csrfToken string) {
+	c.write(ctx, c.config.CSRFTokenName, csrfToken, c.refreshMaxAge, false)
+	ctx.Header(network.CSRFTokenHeader, csrfToken)
+}
+
+func (c *tokenCookies) clear(ctx *gin.Context) {
+	if !c.config.Enabled {
+		return
+	}
+	for _, name := range []string{c.config.AccessTokenName, c.config.RefreshTokenName, c.config.CSRFTokenName} {
+		c.write(ctx, name, "", -1, true)
+	}
+}
+
+func (c *tokenCookies) refreshToken(ctx *gin.Context) string {
+	token, err := ctx.Cookie(c.config.RefreshTokenName)
+	if err != nil {
+		return ""
+	}
+	return token
+}
+
+func (c *tokenCookies) write(ctx *gin.Context, name string, value string, maxAge int, httpOnly bool) {
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     c.config.Path,
+		Domain:   c.config.Domain,
+		MaxAge:   maxAge,
+		Secure:   c.config.Secure,
+		HttpOnly: httpOnly,
+		SameSite: c.sameSite,
+	})
+}
@@ -0,0 +1,36 @@
+package dto
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// =======================================
+// ||          CSRF Token Response       ||
+// =======================================
+
+type CSRFTokenResponse struct {
+	CSRFToken string `json:"csrf_token"`
+}
+
+func NewCSRFTokenResponse(csrfToken string) *CSRFTokenResponse {
+	return &CSRFTokenResponse{
+		CSRFToken: csrfToken,
+	}
+}
+
+func (c *CSRFTokenResponse) GetValue() *CSRFTokenResponse {
+	return c
+}
+
+func (c *CSRFTokenResponse) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		switch err.Tag() {
+		case "required":
+			msgs = append(msgs, err.Field()+" is required")
+		default:
+			msgs = append(msgs, err.Field()+" is invalid")
+		}
+	}
+	return msgs, nil
+}
@@ -44,16 +44,14 @@ func (l *GoogleLoginRequest) ValidateErrors(errs validator.ValidationErrors) ([]
 // =======================================
 
 type GoogleLoginResponse struct {
-	User         model.UserInfo `json:"user"`
-	AccessToken  string         `json:"access_token"`
-	RefreshToken string         `json:"refresh_token"`
+	User model.UserInfo `json:"user"`
+	SessionTokens
 }
 
-func NewGoogleLoginResponse(userInfo model.UserInfo, accessToken string, refreshToken string) *GoogleLoginResponse {
+func NewGoogleLoginResponse(userInfo model.UserInfo, sessionId string, accessToken string, refreshToken string) *GoogleLoginResponse {
 	return &GoogleLoginResponse{
-		User:         userInfo,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+		User:          userInfo,
+		SessionTokens: NewSessionTokens(sessionId, accessToken, refreshToken),
 	}
 }
 
@@ -51,16 +51,24 @@ func (s *LoginRequest) ValidateErrors(errs validator.ValidationErrors) ([]string
 // =======================================
 
 type LoginResponse struct {
-	User         model.UserInfo `json:"user"`
-	AccessToken  string         `json:"access_token" validate:"required"`
-	RefreshToken string         `json:"refresh_token" validate:"required"`
+	User *model.UserInfo `json:"user,omitempty"`
+	SessionTokens
+	// Set instead of the tokens when the login must be completed with the code emailed to the user
+	OtpRequired bool   `json:"otp_required,omitempty"`
+	ChallengeId string `json:"challenge_id,omitempty"`
 }
 
-func NewLoginResponse(userInfo model.UserInfo, accessToken string, refreshToken string) *LoginResponse {
+func NewLoginResponse(userInfo model.UserInfo, sessionId string, accessToken string, refreshToken string) *LoginResponse {
 	return &LoginResponse{
-		User:         userInfo,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+		User:          &userInfo,
+		SessionTokens: NewSessionTokens(sessionId, accessToken, refreshToken),
+	}
+}
+
+func NewLoginChallengeResponse(challengeId string) *LoginResponse {
+	return &LoginResponse{
+		OtpRequired: true,
+		ChallengeId: challengeId,
 	}
 }
 
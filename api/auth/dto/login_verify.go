@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// =======================================
+// ||       Login Verify Request        ||
+// =======================================
+
+type LoginVerifyRequest struct {
+	ChallengeId string `json:"challenge_id" binding:"required" validate:"required"`
+	Code        string `json:"code" binding:"required" validate:"required,numeric,min=4,max=10"`
+}
+
+func NewLoginVerifyRequest() *LoginVerifyRequest {
+	return &LoginVerifyRequest{}
+}
+
+func (l *LoginVerifyRequest) GetValue() *LoginVerifyRequest {
+	return l
+}
+
+func (l *LoginVerifyRequest) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		switch err.Tag() {
+		case "required":
+			msgs = append(msgs, fmt.Sprintf("%s is required", err.Field()))
+		case "numeric":
+			msgs = append(msgs, fmt.Sprintf("%s must contain digits only", err.Field()))
+		case "min":
+			msgs = append(msgs, fmt.Sprintf("%s must be at least %s characters", err.Field(), err.Param()))
+		case "max":
+			msgs = append(msgs, fmt.Sprintf("%s must be at most %s characters", err.Field(), err.Param()))
+		default:
+			msgs = append(msgs, fmt.Sprintf("%s is invalid", err.Field()))
+		}
+	}
+	return msgs, nil
+}
@@ -43,14 +43,12 @@ func (r *RefreshTokenRequest) ValidateErrors(errs validator.ValidationErrors) ([
 // =======================================
 
 type RefreshTokenResponse struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	SessionTokens
 }
 
-func NewRefreshTokenResponse(accessToken, refreshToken string) *RefreshTokenResponse {
+func NewRefreshTokenResponse(sessionId, accessToken, refreshToken string) *RefreshTokenResponse {
 	return &RefreshTokenResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+		SessionTokens: NewSessionTokens(sessionId, accessToken, refreshToken),
 	}
 }
 
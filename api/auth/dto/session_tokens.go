@@ -0,0 +1,17 @@
+package dto
+
+// SessionTokens are the tokens of the session a response started or refreshed. The cookie transport
+// moves them out of the body into HttpOnly cookies.
+type SessionTokens struct {
+	SessionId    string `json:"-"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+func NewSessionTokens(sessionId string, accessToken string, refreshToken string) SessionTokens {
+	return SessionTokens{
+		SessionId:    sessionId,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}
+}
@@ -60,16 +60,14 @@ func (s *SignUpRequest) ValidateErrors(errs validator.ValidationErrors) ([]strin
 // =======================================
 
 type SignUpResponse struct {
-	User         model.UserInfo `json:"user"`
-	AccessToken  string         `json:"access_token" validate:"required"`
-	RefreshToken string         `json:"refresh_token" validate:"required"`
+	User model.UserInfo `json:"user"`
+	SessionTokens
 }
 
-func NewSignUpResponse(userInfo model.UserInfo, accessToken string, refreshToken string) *SignUpResponse {
+func NewSignUpResponse(userInfo model.UserInfo, sessionId string, accessToken string, refreshToken string) *SignUpResponse {
 	return &SignUpResponse{
-		User:         userInfo,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+		User:          userInfo,
+		SessionTokens: NewSessionTokens(sessionId, accessToken, refreshToken),
 	}
 }
 
@@ -3,6 +3,7 @@ package auth
 import (
 	"fmt"
 	"sync-backend/arch/network"
+	"time"
 )
 
 const (
@@ -18,6 +19,10 @@ const (
 	ERR_EXPIRED_TOKEN          = "ERR_EXPIRED_TOKEN"
 	ERR_EMAIL_ALREADY_VERIFIED = "ERR_EMAIL_ALREADY_VERIFIED"
 	ERR_EMAIL_SEND_FAILED      = "ERR_EMAIL_SEND_FAILED"
+	ERR_VERIFICATION_RESEND    = "ERR_VERIFICATION_RESEND"
+	ERR_EMAIL_NOT_VERIFIED     = "ERR_EMAIL_NOT_VERIFIED"
+	ERR_LOGIN_CHALLENGE        = "ERR_LOGIN_CHALLENGE"
+	ERR_LOGIN_CODE             = "ERR_LOGIN_CODE"
 )
 
 // User not found error
@@ -179,3 +184,55 @@ func NewEmailSendError(emailType string, err error) network.ApiError {
 		err,
 	)
 }
+
+// Verification email resend rate limited error
+func NewVerificationResendLimitError(userId string, retryAfter time.Duration) network.ApiError {
+	return network.NewTooManyRequestsErrorWithCode(
+		"Too many verification emails requested",
+		fmt.Sprintf("Verification emails were requested too often. Try again in %d seconds. [Context: userId=%s]", int(retryAfter.Seconds()), userId),
+		ERR_VERIFICATION_RESEND,
+		nil,
+	)
+}
+
+// Email not verified error
+func NewEmailNotVerifiedError(userId string) network.ApiError {
+	return network.NewForbiddenErrorWithCode(
+		"Email not verified",
+		fmt.Sprintf("Verify your email address before posting or commenting. A new link can be requested through /auth/resend-verification. [Context: userId=%s]", userId),
+		ERR_EMAIL_NOT_VERIFIED,
+		nil,
+	)
+}
+
+// Login challenge error
+func NewLoginChallengeError(action string, details string) network.ApiError {
+	return network.NewInternalServerError(
+		"Error while "+action,
+		fmt.Sprintf("Error occurred while %s. [Context: details=%s]", action, details),
+		ERR_LOGIN_CHALLENGE,
+		nil,
+	)
+}
+
+// Login challenge not found error
+func NewLoginChallengeNotFoundError(challengeId string) network.ApiError {
+	return network.NewNotFoundError(
+		"Login code expired or already used",
+		fmt.Sprintf("The login challenge does not exist, has expired or ran out of attempts. Please log in again. [Context: challengeId=%s]", challengeId),
+		nil,
+	)
+}
+
+// Invalid login code error
+func NewInvalidLoginCodeError(challengeId string, remainingAttempts int) network.ApiError {
+	if remainingAttempts < 0 {
+		remainingAttempts = 0
+	}
+	return network.NewUnauthorizedErrorWithCode(
+		"Entered login code is incorrect",
+		fmt.Sprintf("The login code does not match, %d attempts left. [Context: challengeId=%s]", remainingAttempts, challengeId),
+		ERR_LOGIN_CODE,
+		nil,
+	)
+}
@@ -7,6 +7,7 @@ import (
 	"sync-backend/api/common/token"
 	"sync-backend/api/user"
 	"sync-backend/arch/common"
+	"sync-backend/arch/config"
 	"sync-backend/arch/network"
 	"sync-backend/arch/redis"
 	"sync-backend/utils"
@@ -23,6 +24,8 @@ type authenticationProvider struct {
 	sessionService session.SessionService
 	cacheStore     redis.Store
 	userService    user.UserService
+	cookieConfig   config.AuthCookieConfig
+	csrfProvider   *csrfProvider
 }
 
 func NewAuthenticationProvider(
@@ -30,6 +33,8 @@ func NewAuthenticationProvider(
 	userService user.UserService,
 	sessionService session.SessionService,
 	cacheStore redis.Store,
+	cookieConfig config.AuthCookieConfig,
+	csrfProvider *csrfProvider,
 ) *authenticationProvider {
 	return &authenticationProvider{
 		ResponseSender: network.NewResponseSender(),
@@ -39,6 +44,8 @@ func NewAuthenticationProvider(
 		sessionService: sessionService,
 		cacheStore:     cacheStore,
 		userService:    userService,
+		cookieConfig:   cookieConfig,
+		csrfProvider:   csrfProvider,
 	}
 }
 
@@ -46,31 +53,35 @@ func (p *authenticationProvider) Middleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 
 		authHeader := ctx.GetHeader(network.AuthorizationHeader)
-		if len(authHeader) < 8 || authHeader[:7] != "Bearer " {
-			p.Send(ctx).UnauthorizedError(
-				"Invalid or missing Authorization header",
-				"Authorization header must start with 'Bearer ' and contain a token",
-				nil,
-			)
-			return
-		}
+		// Without an Authorization header web clients using the cookie transport send the token as a cookie
+		tokenString, fromCookie := p.cookieToken(ctx, authHeader)
+		if !fromCookie {
+			if len(authHeader) < 8 || authHeader[:7] != "Bearer " {
+				p.Send(ctx).UnauthorizedError(
+					"Invalid or missing Authorization header",
+					"Authorization header must start with 'Bearer ' and contain a token",
+					nil,
+				)
+				return
+			}
 
-		tokenSplit := strings.Split(authHeader, " ")
-		if len(tokenSplit) != 2 {
-			p.logger.Error("Invalid Authorization header format: %s", authHeader)
-			p.Send(ctx).UnauthorizedError(
-				"Invalid Authorization header format",
-				fmt.Sprintf("Expected format: 'Bearer <token>' but got: %s", authHeader),
-				nil,
-			)
-			return
-		}
+			tokenSplit := strings.Split(authHeader, " ")
+			if len(tokenSplit) != 2 {
+				p.logger.WithContext(ctx).Error("Invalid Authorization header format")
+				p.Send(ctx).UnauthorizedError(
+					"Invalid Authorization header format",
+					"Expected format: 'Bearer <token>'",
+					nil,
+				)
+				return
+			}
 
-		tokenString := tokenSplit[len(tokenSplit)-1]
+			tokenString = tokenSplit[len(tokenSplit)-1]
+		}
 
 		token, claims, err := p.tokenService.ValidateToken(tokenString, true)
 		if err != nil {
-			p.logger.Error("Failed to validate token: %v %v", tokenString, err)
+			p.logger.WithContext(ctx).Error("Failed to validate token: %v", err)
 			p.Send(ctx).UnauthorizedError(
 				"Invalid or expired token",
 				fmt.Sprintf("Token validation failed: %v", err),
@@ -79,34 +90,33 @@ func (p *authenticationProvider) Middleware() gin.HandlerFunc {
 			return
 		}
 		if !token.Valid {
-			p.logger.Error("Token is not valid: %v", tokenString)
+			p.logger.WithContext(ctx).Error("Token is not valid")
 			p.Send(ctx).UnauthorizedError(
 				"Token is not valid",
-				fmt.Sprintf("Token is not valid: %v", tokenString),
+				"The token signature or claims are not valid",
 				nil,
 			)
 			return
 		}
 
-		// check if cache has the user ID
-		redisCmd := p.cacheStore.GetInstance().Get(ctx, claims.UserID)
-		hasUserId, err := redisCmd.Bool()
-
+		// the session behind the token is cached so only the first request of a session hits the database
+		cacheKey := session.CacheKey(tokenString)
+		sessionId, err := p.cacheStore.GetInstance().Get(ctx, cacheKey).Result()
 		if err != nil && err.Error() != "redis: nil" {
-			p.logger.Error("Failed to get user ID from cache: %v", err)
+			p.logger.WithContext(ctx).Error("Failed to get session from cache: %v", err)
 			p.Send(ctx).InternalServerError(
-				"Failed to get user ID from cache",
-				fmt.Sprintf("Failed to get user ID from cache: %v", err),
+				"Failed to get session from cache",
+				fmt.Sprintf("Failed to get session from cache: %v", err),
 				network.CACHE_ERROR,
 				err,
 			)
 			return
 		}
 
-		if !hasUserId {
-			session, err := p.sessionService.GetSessionByToken(tokenString)
+		if sessionId == "" {
+			session, err := p.sessionService.GetSessionByToken(ctx.Request.Context(), tokenString)
 			if err != nil {
-				p.logger.Error("Failed to get session by token: %v", err)
+				p.logger.WithContext(ctx).Error("Failed to get session by token: %v", err)
 				p.Send(ctx).UnauthorizedError(
 					"Invalid or expired session",
 					fmt.Sprintf("Session retrieval failed: %v", err),
@@ -115,42 +125,59 @@ func (p *authenticationProvider) Middleware() gin.HandlerFunc {
 				return
 			}
 			if session == nil {
-				p.logger.Error("Session not found for token: %s", tokenString)
+				p.logger.WithContext(ctx).Error("Session not found for token [Context: userId=%s]", claims.UserID)
 				p.Send(ctx).UnauthorizedError(
 					"Invalid or expired session",
-					fmt.Sprintf("Session not found for token: %s", tokenString),
+					"No session exists for the token",
 					nil,
 				)
 				return
 			}
 			if session.IsRevoked {
-				p.logger.Error("Session is revoked for token: %s", tokenString)
+				p.logger.WithContext(ctx).Error("Session is revoked [Context: sessionId=%s]", session.SessionID)
 				p.Send(ctx).UnauthorizedError(
 					"Session is revoked",
-					fmt.Sprintf("Session is revoked for token: %s", tokenString),
+					"The session behind the token has been revoked",
 					nil,
 				)
 				return
 			}
 
 			//save to cache
-			err = p.cacheStore.GetInstance().Set(ctx, claims.UserID, true, time.Hour*1).Err()
+			sessionId = session.SessionID
+			err = p.cacheStore.GetInstance().Set(ctx, cacheKey, sessionId, time.Hour*1).Err()
 			if err != nil {
-				p.logger.Error("Failed to set user ID in cache: %v", err)
+				p.logger.WithContext(ctx).Error("Failed to set session in cache: %v", err)
 				p.Send(ctx).InternalServerError(
-					"Failed to set user ID in cache",
-					fmt.Sprintf("Failed to set user ID in cache: %v", err),
+					"Failed to set session in cache",
+					fmt.Sprintf("Failed to set session in cache: %v", err),
 					network.CACHE_ERROR,
 					err,
 				)
 				return
 			}
 
-			p.logger.Debug("Set user ID in cache: %s", claims.UserID)
+			p.logger.WithContext(ctx).Debug("Set session in cache: %s", sessionId)
 		}
 
+		p.SetSessionId(ctx, sessionId)
 		p.SetUserId(ctx, claims.UserID)
-		p.logger.Debug("User ID from token: %s", claims.UserID)
+		p.logger.WithContext(ctx).Debug("User ID from token: %s", claims.UserID)
+		// Browsers send cookies on cross-site requests too, only the csrf token proves the request is the client's
+		if fromCookie && !p.csrfProvider.Verify(ctx) {
+			return
+		}
 		ctx.Next()
 	}
 }
+
+func (p *authenticationProvider) cookieToken(ctx *gin.Context, authHeader string) (string, bool) {
+	if !p.cookieConfig.Enabled || authHeader != "" {
+		return "", false
+	}
+	token, err := ctx.Cookie(p.cookieConfig.AccessTokenName)
+	if err != nil || token == "" {
+		return "", false
+	}
+	return token, true
+}
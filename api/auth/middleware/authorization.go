@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync-backend/api/admin"
+	"sync-backend/api/admin/model"
+	"sync-backend/arch/common"
+	"sync-backend/arch/network"
+	"sync-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const ERR_PLATFORM_ROLE_REQUIRED = "ERR_PLATFORM_ROLE_REQUIRED"
+
+type authorizationProvider struct {
+	network.ResponseSender
+	common.ContextPayload
+	logger       utils.AppLogger
+	adminService admin.AdminService
+}
+
+// NewAuthorizationProvider restricts routes to platform staff holding one of the roles passed to Middleware,
+// superadmins pass every check. It must run after the authentication middleware.
+func NewAuthorizationProvider(adminService admin.AdminService) network.AuthorizationProvider {
+	return &authorizationProvider{
+		ResponseSender: network.NewResponseSender(),
+		ContextPayload: common.NewContextPayload(),
+		logger:         utils.NewServiceLogger("AuthorizationProvider"),
+		adminService:   adminService,
+	}
+}
+
+// Middleware allows the given platform roles, only superadmins when none are given
+func (p *authorizationProvider) Middleware(roles ...string) gin.HandlerFunc {
+	if len(roles) == 0 {
+		roles = []string{string(model.RoleSuperadmin)}
+	}
+	return func(ctx *gin.Context) {
+		userId := p.MustGetUserId(ctx)
+		role, err := p.adminService.GetPlatformRole(ctx.Request.Context(), *userId)
+		if err != nil {
+			p.Send(ctx).MixedError(err)
+			return
+		}
+		if role == model.RoleSuperadmin || (role != "" && slices.Contains(roles, string(role))) {
+			p.SetPlatformRole(ctx, string(role))
+			ctx.Next()
+			return
+		}
+
+		p.logger.WithContext(ctx).Warn("Rejected platform request of user %s with role %q to %s", *userId, role, ctx.FullPath())
+		p.Send(ctx).MixedError(network.NewForbiddenErrorWithCode(
+			"Insufficient platform role",
+			fmt.Sprintf("This endpoint requires one of the platform roles: %s. [Context: userId=%s]", strings.Join(roles, ", "), *userId),
+			ERR_PLATFORM_ROLE_REQUIRED,
+			nil,
+		))
+	}
+}
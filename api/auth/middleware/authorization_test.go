@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sync-backend/api/admin"
+	"sync-backend/api/admin/model"
+	"sync-backend/arch/network"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubAdminService struct {
+	admin.AdminService
+	roles map[string]model.PlatformRole
+}
+
+func (s *stubAdminService) GetPlatformRole(_ context.Context, userId string) (model.PlatformRole, network.ApiError) {
+	return s.roles[userId], nil
+}
+
+func TestAuthorizationMiddlewareChecksPlatformRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	provider := NewAuthorizationProvider(&stubAdminService{roles: map[string]model.PlatformRole{
+		"root":    model.RoleSuperadmin,
+		"safety":  model.RoleTrustSafety,
+		"support": model.RoleSupport,
+	}})
+
+	engine := gin.New()
+	route := func(path string, roles ...string) {
+		engine.GET(path, func(ctx *gin.Context) {
+			ctx.Set(network.UserPayload, ctx.Query("user"))
+		}, provider.Middleware(roles...), func(ctx *gin.Context) {
+			ctx.String(http.StatusOK, ctx.GetString(network.PlatformRole))
+		})
+	}
+	route("/logs")
+	route("/bans", string(model.RoleTrustSafety))
+
+	tests := []struct {
+		path, user string
+		status     int
+	}{
+		{"/logs", "root", http.StatusOK},
+		{"/logs", "safety", http.StatusForbidden},
+		{"/bans", "root", http.StatusOK},
+		{"/bans", "safety", http.StatusOK},
+		{"/bans", "support", http.StatusForbidden},
+		{"/bans", "someone", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path+"?user="+tt.user, nil))
+		assert.Equal(t, tt.status, recorder.Code, "%s as %s", tt.path, tt.user)
+	}
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"sync-backend/api/common/csrf"
+	"sync-backend/arch/common"
+	"sync-backend/arch/config"
+	"sync-backend/arch/network"
+	"sync-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type csrfProvider struct {
+	network.ResponseSender
+	common.ContextPayload
+	logger      utils.AppLogger
+	csrfService csrf.CSRFService
+	config      config.AuthCookieConfig
+}
+
+// NewCSRFProvider protects the requests that browsers authenticate on their own, through the token
+// cookies. Their unsafe requests must send the csrf cookie again in the X-CSRF-Token header (double
+// submit) and, once authenticated, the token must be the current one of the session (synchronizer).
+// Safe methods, requests with an Authorization header and requests without token cookies are exempt.
+func NewCSRFProvider(csrfService csrf.CSRFService, config config.AuthCookieConfig) *csrfProvider {
+	return &csrfProvider{
+		ResponseSender: network.NewResponseSender(),
+		ContextPayload: common.NewContextPayload(),
+		logger:         utils.NewServiceLogger("CSRFProvider"),
+		csrfService:    csrfService,
+		config:         config,
+	}
+}
+
+// Middleware checks the double submitted token, it checks the session token too when it runs after
+// authentication. The authentication provider already checks the requests it authenticates by cookie.
+func (p *csrfProvider) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !p.Verify(ctx) {
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// Verify answers the request with 403 and returns false when it fails the check
+func (p *csrfProvider) Verify(ctx *gin.Context) bool {
+	if !p.config.Enabled || isSafeMethod(ctx.Request.Method) || ctx.GetHeader(network.AuthorizationHeader) != "" {
+		return true
+	}
+	if !p.hasCookie(ctx, p.config.AccessTokenName) && !p.hasCookie(ctx, p.config.RefreshTokenName) {
+		return true
+	}
+
+	headerToken := ctx.GetHeader(network.CSRFTokenHeader)
+	cookieToken, _ := ctx.Cookie(p.config.CSRFTokenName)
+	if headerToken == "" || subtle.ConstantTimeCompare([]byte(headerToken), []byte(cookieToken)) != 1 {
+		p.logger.WithContext(ctx).Warn("CSRF token missing or not matching its cookie [Context: path=%s]", ctx.FullPath())
+		p.Send(ctx).ForbiddenError(
+			"Invalid CSRF token",
+			"Requests authenticated by cookie must send the csrf token cookie in the X-CSRF-Token header",
+			nil,
+		)
+		return false
+	}
+
+	sessionId := p.MustGetSessionId(ctx)
+	if sessionId == "" {
+		return true
+	}
+	valid, err := p.csrfService.VerifyToken(ctx.Request.Context(), sessionId, headerToken)
+	if err != nil {
+		p.logger.WithContext(ctx).Error("Failed to verify csrf token of session %s: %v", sessionId, err)
+		p.Send(ctx).InternalServerError(
+			"Failed to verify CSRF token",
+			"The csrf token of the session could not be read",
+			network.CACHE_ERROR,
+			err,
+		)
+		return false
+	}
+	if !valid {
+		p.Send(ctx).ForbiddenError(
+			"Expired CSRF token",
+			"The csrf token is expired or was replaced, get a new one from GET /auth/csrf",
+			nil,
+		)
+		return false
+	}
+	return true
+}
+
+func (p *csrfProvider) hasCookie(ctx *gin.Context, name string) bool {
+	value, err := ctx.Cookie(name)
+	return err == nil && value != ""
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
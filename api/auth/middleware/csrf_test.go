@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sync-backend/arch/config"
+	"sync-backend/arch/network"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubCSRFService struct {
+	tokens map[string]string
+}
+
+func (s *stubCSRFService) IssueToken(_ context.Context, sessionId string) (string, error) {
+	return s.tokens[sessionId], nil
+}
+
+func (s *stubCSRFService) VerifyToken(_ context.Context, sessionId string, token string) (bool, error) {
+	return s.tokens[sessionId] == token, nil
+}
+
+func (s *stubCSRFService) RevokeToken(_ context.Context, sessionId string) error {
+	delete(s.tokens, sessionId)
+	return nil
+}
+
+func TestCSRFMiddlewareChecksCookieRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	provider := NewCSRFProvider(&stubCSRFService{tokens: map[string]string{"session-1": "current"}}, config.AuthCookieConfig{
+		Enabled:          true,
+		AccessTokenName:  "access_token",
+		RefreshTokenName: "refresh_token",
+		CSRFTokenName:    "csrf_token",
+	})
+
+	engine := gin.New()
+	ok := func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	}
+	engine.Any("/refresh", provider.Middleware(), ok)
+	engine.Any("/posts", func(ctx *gin.Context) {
+		ctx.Set(network.SessionIdHeader, "session-1")
+	}, provider.Middleware(), ok)
+
+	tests := []struct {
+		name, method, path  string
+		cookies             bool
+		bearer              bool
+		cookieToken, header string
+		status              int
+	}{
+		{"safe method", http.MethodGet, "/posts", true, false, "", "", http.StatusOK},
+		{"bearer request", http.MethodPost, "/posts", true, true, "", "", http.StatusOK},
+		{"no token cookies", http.MethodPost, "/posts", false, false, "", "", http.StatusOK},
+		{"missing header", http.MethodPost, "/posts", true, false, "current", "", http.StatusForbidden},
+		{"header not matching cookie", http.MethodPost, "/posts", true, false, "current", "forged", http.StatusForbidden},
+		{"current session token", http.MethodDelete, "/posts", true, false, "current", "current", http.StatusOK},
+		{"replaced session token", http.MethodPost, "/posts", true, false, "stale", "stale", http.StatusForbidden},
+		{"double submit without session", http.MethodPost, "/refresh", true, false, "stale", "stale", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.cookies {
+			req.AddCookie(&http.Cookie{Name: "access_token", Value: "jwt"})
+		}
+		if tt.cookieToken != "" {
+			req.AddCookie(&http.Cookie{Name: "csrf_token", Value: tt.cookieToken})
+		}
+		if tt.header != "" {
+			req.Header.Set(network.CSRFTokenHeader, tt.header)
+		}
+		if tt.bearer {
+			req.Header.Set(network.AuthorizationHeader, "Bearer jwt")
+		}
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, req)
+		assert.Equal(t, tt.status, recorder.Code, tt.name)
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"sync-backend/api/auth"
+	"sync-backend/api/user"
+	"sync-backend/arch/common"
+	"sync-backend/arch/config"
+	"sync-backend/arch/network"
+	"sync-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type emailVerificationProvider struct {
+	network.ResponseSender
+	common.ContextPayload
+	logger      utils.AppLogger
+	config      config.VerificationConfig
+	userService user.UserService
+}
+
+// NewEmailVerificationProvider blocks users without a verified email when verification is
+// required, it must run after the authentication middleware. The user is read through the user cache.
+func NewEmailVerificationProvider(
+	config config.VerificationConfig,
+	userService user.UserService,
+) *emailVerificationProvider {
+	return &emailVerificationProvider{
+		ResponseSender: network.NewResponseSender(),
+		ContextPayload: common.NewContextPayload(),
+		logger:         utils.NewServiceLogger("EmailVerificationProvider"),
+		config:         config,
+		userService:    userService,
+	}
+}
+
+func (p *emailVerificationProvider) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !p.config.RequireEmailVerification {
+			ctx.Next()
+			return
+		}
+
+		userId := p.MustGetUserId(ctx)
+		user, err := p.userService.FindUserById(ctx.Request.Context(), *userId)
+		if err != nil {
+			p.Send(ctx).MixedError(err)
+			return
+		}
+		if !user.VerifiedEmail {
+			p.logger.WithContext(ctx).Debug("Rejected write of unverified user %s", *userId)
+			p.Send(ctx).MixedError(auth.NewEmailNotVerifiedError(*userId))
+			return
+		}
+		ctx.Next()
+	}
+}
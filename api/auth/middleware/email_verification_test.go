@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sync-backend/api/auth"
+	"sync-backend/api/user"
+	"sync-backend/api/user/model"
+	"sync-backend/arch/config"
+	"sync-backend/arch/network"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubUserService struct {
+	user.UserService
+	verified map[string]bool
+}
+
+func (s *stubUserService) FindUserById(_ context.Context, userId string) (*model.User, network.ApiError) {
+	verified, ok := s.verified[userId]
+	if !ok {
+		return nil, network.NewNotFoundError("User not found", userId, nil)
+	}
+	return &model.User{UserId: userId, VerifiedEmail: verified}, nil
+}
+
+func TestEmailVerificationMiddlewareBlocksUnverifiedUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userService := &stubUserService{verified: map[string]bool{"verified": true, "unverified": false}}
+
+	newEngine := func(required bool) *gin.Engine {
+		provider := NewEmailVerificationProvider(config.VerificationConfig{RequireEmailVerification: required}, userService)
+		engine := gin.New()
+		engine.POST("/posts", func(ctx *gin.Context) {
+			ctx.Set(network.UserPayload, ctx.Query("user"))
+		}, provider.Middleware(), func(ctx *gin.Context) {
+			ctx.Status(http.StatusCreated)
+		})
+		return engine
+	}
+
+	tests := []struct {
+		required bool
+		user     string
+		status   int
+	}{
+		{true, "verified", http.StatusCreated},
+		{true, "unverified", http.StatusForbidden},
+		{true, "missing", http.StatusNotFound},
+		{false, "unverified", http.StatusCreated},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		newEngine(tt.required).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/posts?user="+tt.user, nil))
+		assert.Equal(t, tt.status, recorder.Code, "required=%v as %s", tt.required, tt.user)
+		if tt.status == http.StatusForbidden {
+			assert.Contains(t, recorder.Body.String(), auth.ERR_EMAIL_NOT_VERIFIED)
+		}
+	}
+}
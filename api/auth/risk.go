@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"math"
+	"time"
+
+	sessionModels "sync-backend/api/common/session/model"
+	userModels "sync-backend/api/user/model"
+	"sync-backend/arch/config"
+	coredto "sync-backend/arch/dto"
+	"sync-backend/arch/network"
+)
+
+const earthRadiusKm = 6371.0
+
+// Logins closer in time than this are compared as if this much time passed, so that
+// two requests from neighbouring regions a second apart do not look like supersonic travel
+const minTravelTime = 5 * time.Minute
+
+// evaluateLoginRisk compares a login with the user's previous ones, newest first, and returns why it
+// looks unusual. A user without history has nothing to compare to and is never flagged.
+func evaluateLoginRisk(history []userModels.LoginHistory, attempt userModels.LoginHistory, riskConfig config.LoginRiskConfig) []userModels.LoginRiskReason {
+	if len(history) == 0 {
+		return nil
+	}
+
+	var reasons []userModels.LoginRiskReason
+	if isNewDevice(history, attempt) {
+		reasons = append(reasons, userModels.LoginRiskNewDevice)
+	}
+	if isNewCountry(history, attempt) {
+		reasons = append(reasons, userModels.LoginRiskNewCountry)
+	}
+	if isImpossibleTravel(history, attempt, riskConfig) {
+		reasons = append(reasons, userModels.LoginRiskImpossibleTravel)
+	}
+	return reasons
+}
+
+// isNewDevice is false for a login identifying neither its device nor its user agent, such a device is
+// unknown rather than new and the country and travel checks decide
+func isNewDevice(history []userModels.LoginHistory, attempt userModels.LoginHistory) bool {
+	hasDeviceId := attempt.DeviceId != "" && attempt.DeviceId != network.DefaultDeviceId
+	if !hasDeviceId && attempt.UserAgent == "" {
+		return false
+	}
+	for _, login := range history {
+		if hasDeviceId && login.DeviceId == attempt.DeviceId {
+			return false
+		}
+		if attempt.UserAgent != "" && login.UserAgent == attempt.UserAgent {
+			return false
+		}
+	}
+	return true
+}
+
+func isNewCountry(history []userModels.LoginHistory, attempt userModels.LoginHistory) bool {
+	if !hasKnownCountry(attempt) {
+		return false
+	}
+	compared := false
+	for _, login := range history {
+		if !hasKnownCountry(login) {
+			continue
+		}
+		if login.Location.Country == attempt.Location.Country {
+			return false
+		}
+		compared = true
+	}
+	return compared
+}
+
+func isImpossibleTravel(history []userModels.LoginHistory, attempt userModels.LoginHistory, riskConfig config.LoginRiskConfig) bool {
+	if riskConfig.MaxTravelSpeed <= 0 || !hasCoordinates(attempt) {
+		return false
+	}
+	for _, login := range history {
+		if !hasCoordinates(login) {
+			continue
+		}
+		distance := haversineDistance(login.Location.Latitude, login.Location.Longitude, attempt.Location.Latitude, attempt.Location.Longitude)
+		if distance < riskConfig.MinTravelDistance {
+			return false
+		}
+		elapsed := attempt.LoginTime.Time().Sub(login.LoginTime.Time())
+		if elapsed < minTravelTime {
+			elapsed = minTravelTime
+		}
+		return distance/elapsed.Hours() > riskConfig.MaxTravelSpeed
+	}
+	return false
+}
+
+func hasKnownCountry(login userModels.LoginHistory) bool {
+	return login.Location != nil && login.Location.Country != "" && login.Location.Country != "Unknown Country"
+}
+
+// Unresolved locations are stored at 0,0, which is in the ocean and never a real login
+func hasCoordinates(login userModels.LoginHistory) bool {
+	return login.Location != nil && (login.Location.Latitude != 0 || login.Location.Longitude != 0)
+}
+
+// haversineDistance is the great-circle distance in kilometers between two coordinates
+func haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// loginChallenge is a password login waiting for the code emailed to the user
+type loginChallenge struct {
+	UserId   string                     `json:"userId"`
+	CodeHash string                     `json:"codeHash"`
+	History  userModels.LoginHistory    `json:"history"`
+	Device   sessionModels.DeviceInfo   `json:"device"`
+	Location sessionModels.LocationInfo `json:"location"`
+}
+
+func newLoginLocation(location *coredto.BaseLocationRequest) *userModels.LoginLocation {
+	if location.Country == "" && location.Latitude == 0 && location.Longitude == 0 {
+		return nil
+	}
+	return &userModels.LoginLocation{
+		Country:   location.Country,
+		City:      location.City,
+		Latitude:  location.Latitude,
+		Longitude: location.Longitude,
+	}
+}
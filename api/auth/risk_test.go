@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	userModels "sync-backend/api/user/model"
+	"sync-backend/arch/config"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var testRiskConfig = config.LoginRiskConfig{Enabled: true, MaxTravelSpeed: 1000, MinTravelDistance: 500}
+
+func testLogin(at time.Time, deviceId, userAgent, country string, latitude, longitude float64) userModels.LoginHistory {
+	return userModels.LoginHistory{
+		LoginTime: primitive.NewDateTimeFromTime(at),
+		DeviceId:  deviceId,
+		UserAgent: userAgent,
+		Location:  &userModels.LoginLocation{Country: country, Latitude: latitude, Longitude: longitude},
+	}
+}
+
+func TestEvaluateLoginRisk(t *testing.T) {
+	now := time.Now()
+	history := []userModels.LoginHistory{
+		testLogin(now.Add(-2*time.Hour), "phone", "app/1.0", "United Kingdom", 51.5, -0.1),
+		testLogin(now.Add(-48*time.Hour), "laptop", "firefox", "France", 48.9, 2.4),
+	}
+
+	tests := []struct {
+		name    string
+		history []userModels.LoginHistory
+		attempt userModels.LoginHistory
+		want    []userModels.LoginRiskReason
+	}{
+		{
+			name:    "first login",
+			attempt: testLogin(now, "phone", "app/1.0", "Japan", 35.7, 139.7),
+		},
+		{
+			name:    "usual device nearby",
+			history: history,
+			attempt: testLogin(now, "phone", "app/1.0", "United Kingdom", 53.5, -2.2),
+		},
+		{
+			name:    "known user agent without device id",
+			history: history,
+			attempt: testLogin(now, "default-device-id", "firefox", "France", 48.9, 2.4),
+		},
+		{
+			name:    "new device in known country",
+			history: history,
+			attempt: testLogin(now, "tablet", "safari", "France", 48.9, 2.4),
+			want:    []userModels.LoginRiskReason{userModels.LoginRiskNewDevice},
+		},
+		{
+			name:    "new country reachable in time",
+			history: history,
+			attempt: testLogin(now, "phone", "app/1.0", "Spain", 40.4, -3.7),
+			want:    []userModels.LoginRiskReason{userModels.LoginRiskNewCountry},
+		},
+		{
+			name:    "other side of the world two hours later",
+			history: history,
+			attempt: testLogin(now, "desktop", "chrome", "Japan", 35.7, 139.7),
+			want: []userModels.LoginRiskReason{
+				userModels.LoginRiskNewDevice,
+				userModels.LoginRiskNewCountry,
+				userModels.LoginRiskImpossibleTravel,
+			},
+		},
+		{
+			name:    "unidentified device in known country",
+			history: history,
+			attempt: testLogin(now, "", "", "United Kingdom", 53.5, -2.2),
+		},
+		{
+			name:    "unidentified device on the other side of the world",
+			history: history,
+			attempt: testLogin(now, "default-device-id", "", "Japan", 35.7, 139.7),
+			want: []userModels.LoginRiskReason{
+				userModels.LoginRiskNewCountry,
+				userModels.LoginRiskImpossibleTravel,
+			},
+		},
+		{
+			name:    "unresolved location",
+			history: history,
+			attempt: testLogin(now, "phone", "app/1.0", "Unknown Country", 0, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, evaluateLoginRisk(tt.history, tt.attempt, testRiskConfig))
+		})
+	}
+}
+
+func TestHaversineDistance(t *testing.T) {
+	assert.InDelta(t, 344, haversineDistance(51.5074, -0.1278, 48.8566, 2.3522), 5)
+	assert.InDelta(t, 0, haversineDistance(10, 10, 10, 10), 0.001)
+}
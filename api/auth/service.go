@@ -1,36 +1,52 @@
 package auth
 
 import (
+	"context"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"sync-backend/api/auth/dto"
+	"sync-backend/api/common/csrf"
 	"sync-backend/api/common/email"
 	sessionModels "sync-backend/api/common/session/model"
 	userModels "sync-backend/api/user/model"
+	"sync-backend/arch/tracing"
 
 	"sync-backend/api/common/session"
 	"sync-backend/api/common/token"
 	"sync-backend/api/user"
 	"sync-backend/arch/config"
+	"sync-backend/arch/limiter"
+	"sync-backend/arch/metrics"
 	"sync-backend/arch/network"
+	"sync-backend/arch/redis"
 	"sync-backend/utils"
 	"time"
 
+	goredis "github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 const EMPTY_PASSWORD_HASH = "$2a$10$Cv/Xb2ykZ9FLmWyB6vaPEueAzA51kkU2GDZj8C4hwgAH3gQhwIo.q"
 
 type AuthService interface {
-	SignUp(signUpRequest *dto.SignUpRequest) (*dto.SignUpResponse, network.ApiError)
-	Login(loginRequest *dto.LoginRequest) (*dto.LoginResponse, network.ApiError)
-	GoogleLogin(googleLoginRequest *dto.GoogleLoginRequest) (*dto.GoogleLoginResponse, network.ApiError)
-	Logout(userId string) network.ApiError
-	ForgotPassword(forgotPasswordRequest *dto.ForgotPassRequest) network.ApiError
-	RefreshToken(refreshTokenRequest *dto.RefreshTokenRequest) (*dto.RefreshTokenResponse, network.ApiError)
-	VerifyEmail(token string) (*userModels.User, network.ApiError)
-	ResetPassword(token string, newPassword string) network.ApiError
+	SignUp(ctx context.Context, signUpRequest *dto.SignUpRequest) (*dto.SignUpResponse, network.ApiError)
+	Login(ctx context.Context, loginRequest *dto.LoginRequest) (*dto.LoginResponse, network.ApiError)
+	VerifyLogin(ctx context.Context, verifyRequest *dto.LoginVerifyRequest) (*dto.LoginResponse, network.ApiError)
+	GoogleLogin(ctx context.Context, googleLoginRequest *dto.GoogleLoginRequest) (*dto.GoogleLoginResponse, network.ApiError)
+	Logout(ctx context.Context, userId string, sessionId string) network.ApiError
+	ForgotPassword(ctx context.Context, forgotPasswordRequest *dto.ForgotPassRequest) network.ApiError
+	RefreshToken(ctx context.Context, refreshTokenRequest *dto.RefreshTokenRequest) (*dto.RefreshTokenResponse, network.ApiError)
+	VerifyEmail(ctx context.Context, token string) (*userModels.User, network.ApiError)
+	ResendVerificationEmail(ctx context.Context, userId string) network.ApiError
+	ResetPassword(ctx context.Context, token string, newPassword string) network.ApiError
+	// IssueCSRFToken replaces the CSRF token of a session using the cookie transport
+	IssueCSRFToken(ctx context.Context, sessionId string) (string, network.ApiError)
 }
 
 type authService struct {
@@ -41,7 +57,11 @@ type authService struct {
 	userService    user.UserService
 	sessionService session.SessionService
 	tokenService   token.TokenService
+	csrfService    csrf.CSRFService
 	emailService   email.EmailService
+	store          redis.Store
+	limiter        limiter.Limiter
+	loginCodeKey   []byte
 }
 
 func NewAuthService(
@@ -50,7 +70,9 @@ func NewAuthService(
 	userService user.UserService,
 	sessionService session.SessionService,
 	tokenService token.TokenService,
+	csrfService csrf.CSRFService,
 	emailService email.EmailService,
+	store redis.Store,
 ) AuthService {
 	return &authService{
 		BaseService:    network.NewBaseService(),
@@ -60,17 +82,25 @@ func NewAuthService(
 		userService:    userService,
 		sessionService: sessionService,
 		tokenService:   tokenService,
+		csrfService:    csrfService,
 		emailService:   emailService,
+		store:          store,
+		limiter:        limiter.NewRedisLimiter(store),
+		loginCodeKey:   utils.DeriveKey(env.JWTSecret, "login-code"),
 	}
 }
 
-func (s *authService) SignUp(signUpRequest *dto.SignUpRequest) (*dto.SignUpResponse, network.ApiError) {
-	s.logger.Info("Signing up user with email: %s", signUpRequest.Email)
+func (s *authService) SignUp(ctx context.Context, signUpRequest *dto.SignUpRequest) (*dto.SignUpResponse, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AuthService.SignUp")
+	defer span.End()
 
-	user, err := s.userService.CreateUser(signUpRequest.UserName, signUpRequest.Email, signUpRequest.Password, signUpRequest.ProfileFilePath, signUpRequest.BackgroundFilePath, signUpRequest.Locale, signUpRequest.TimeZone, signUpRequest.Country)
+	s.logger.WithContext(ctx).Info("Signing up user with email: %s", signUpRequest.Email)
+
+	user, err := s.userService.CreateUser(ctx, signUpRequest.UserName, signUpRequest.Email, signUpRequest.Password, signUpRequest.ProfileFilePath, signUpRequest.BackgroundFilePath, signUpRequest.Locale, signUpRequest.TimeZone, signUpRequest.Country)
 	if err != nil {
 		return nil, err
 	}
+	metrics.SignupCompleted(metrics.SignupPassword)
 
 	token, tokenErr := s.tokenService.GenerateTokenPair(user.UserId)
 	if tokenErr != nil {
@@ -98,19 +128,27 @@ func (s *authService) SignUp(signUpRequest *dto.SignUpRequest) (*dto.SignUpRespo
 		IpAddress:  signUpRequest.IpAddress,
 	}
 
-	_, sessionErr := s.sessionService.CreateSession(user.UserId, token.AccessToken, token.RefreshToken, token.AccessTokenExpiresIn.Time(), deviceInfo, locationInfo)
+	session, sessionErr := s.sessionService.CreateSession(ctx, user.UserId, token.AccessToken, token.RefreshToken, token.AccessTokenExpiresIn.Time(), deviceInfo, locationInfo)
 	if sessionErr != nil {
 		return nil, NewSessionError("creating session", sessionErr.Error())
 	}
 
-	signUpResponse := dto.NewSignUpResponse(*user.GetUserInfo(), token.AccessToken, token.RefreshToken)
-	s.logger.Success("User signed up successfully: %s", signUpRequest.Email)
+	// The account is usable right away, a failed email can be requested again through resend
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to send verification email on signup to %s: %v", user.Email, err)
+	}
+
+	signUpResponse := dto.NewSignUpResponse(*user.GetUserInfo(), session.SessionID, token.AccessToken, token.RefreshToken)
+	s.logger.WithContext(ctx).Success("User signed up successfully: %s", signUpRequest.Email)
 	return signUpResponse, nil
 }
 
-func (s *authService) Login(loginRequest *dto.LoginRequest) (*dto.LoginResponse, network.ApiError) {
-	s.logger.Info("Logging in user with email: %s", loginRequest.Email)
-	user, err := s.userService.FindUserByEmail(loginRequest.Email)
+func (s *authService) Login(ctx context.Context, loginRequest *dto.LoginRequest) (*dto.LoginResponse, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AuthService.Login")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Logging in user with email: %s", loginRequest.Email)
+	user, err := s.userService.FindUserByEmail(ctx, loginRequest.Email)
 	if err != nil {
 		return nil, err
 	}
@@ -130,25 +168,23 @@ func (s *authService) Login(loginRequest *dto.LoginRequest) (*dto.LoginResponse,
 		return nil, NewUserBannedError(loginRequest.Email, "Banned due to violation of terms of service")
 	}
 
-	err = s.userService.ValidateUserPassword(user, loginRequest.Password)
+	err = s.userService.ValidateUserPassword(ctx, user, loginRequest.Password)
 	if err != nil {
 		return nil, err
 	}
 
-	session, sessionErr := s.sessionService.GetUserActiveSession(user.UserId)
-	if sessionErr != nil {
-		return nil, NewSessionError("getting user session", sessionErr.Error())
-	}
 	loginHistory := userModels.LoginHistory{
 		LoginTime: primitive.NewDateTimeFromTime(time.Now()),
 		IpAddress: loginRequest.IpAddress,
 		UserAgent: loginRequest.DeviceUserAgent,
+		DeviceId:  loginRequest.DeviceId,
 		Device: userModels.UserDeviceInfo{
 			Os:    loginRequest.DeviceOS,
 			Type:  loginRequest.DeviceType,
 			Name:  loginRequest.DeviceName,
 			Model: loginRequest.DeviceModel,
 		},
+		Location: newLoginLocation(&loginRequest.BaseLocationRequest),
 	}
 	deviceInfo := sessionModels.DeviceInfo{
 		DeviceId:        loginRequest.DeviceId,
@@ -169,36 +205,106 @@ func (s *authService) Login(loginRequest *dto.LoginRequest) (*dto.LoginResponse,
 		GmtOffset:  loginRequest.GMTOffset,
 		IpAddress:  loginRequest.IpAddress,
 	}
-	if session != nil {
-		s.sessionService.UpdateSessionInfo(session.SessionID, deviceInfo, locationInfo)
-		loginHistory.SessionId = session.SessionID
-		s.userService.UpdateLoginHistory(user.UserId, loginHistory)
-		loginResponse := dto.NewLoginResponse(*user.GetUserInfo(), session.Token, session.RefreshToken)
-		s.logger.Success("User logged in successfully: %s", loginRequest.Email)
-		return loginResponse, nil
-	} else {
-		token, err := s.tokenService.GenerateTokenPair(user.UserId)
-		if err != nil {
-			return nil, NewTokenError("generating token", err.Error())
+
+	loginHistory.Risk = s.assessLogin(ctx, user, loginHistory)
+	if len(loginHistory.Risk) > 0 && s.config.Auth.LoginRisk.StepUp {
+		return s.startLoginChallenge(ctx, user, &loginChallenge{
+			UserId:   user.UserId,
+			History:  loginHistory,
+			Device:   deviceInfo,
+			Location: locationInfo,
+		})
+	}
+
+	session, apiErr := s.startSession(ctx, user.UserId, deviceInfo, locationInfo)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	loginHistory.SessionId = session.SessionID
+	s.recordLogin(ctx, user, loginHistory)
+
+	s.logger.WithContext(ctx).Success("User logged in successfully: %s", loginRequest.Email)
+	return dto.NewLoginResponse(*user.GetUserInfo(), session.SessionID, session.Token, session.RefreshToken), nil
+}
+
+// VerifyLogin completes a login held back by startLoginChallenge once the emailed code is entered
+func (s *authService) VerifyLogin(ctx context.Context, verifyRequest *dto.LoginVerifyRequest) (*dto.LoginResponse, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AuthService.VerifyLogin")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Verifying login challenge: %s", verifyRequest.ChallengeId)
+	client := s.store.GetInstance()
+	key := loginChallengeKey(verifyRequest.ChallengeId)
+
+	data, redisErr := client.Get(ctx, key).Result()
+	if redisErr != nil {
+		if redisErr.Error() != "redis: nil" {
+			s.logger.WithContext(ctx).Error("Failed to get login challenge %s: %v", verifyRequest.ChallengeId, redisErr)
 		}
+		return nil, NewLoginChallengeNotFoundError(verifyRequest.ChallengeId)
+	}
+	var challenge loginChallenge
+	if jsonErr := json.Unmarshal([]byte(data), &challenge); jsonErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to decode login challenge %s: %v", verifyRequest.ChallengeId, jsonErr)
+		client.Del(ctx, key)
+		return nil, NewLoginChallengeNotFoundError(verifyRequest.ChallengeId)
+	}
 
-		session, err = s.sessionService.CreateSession(
-			user.UserId, token.AccessToken, token.RefreshToken, token.AccessTokenExpiresIn.Time(), deviceInfo, locationInfo)
-		loginHistory.SessionId = session.SessionID
-		s.userService.UpdateLoginHistory(user.UserId, loginHistory)
-		if err != nil {
-			return nil, NewSessionError("creating session", err.Error())
+	// Every attempt is counted before the code is compared, a code is never checked without the count
+	attempts, incrErr := loginAttemptScript.Run(ctx, client.Client, []string{key + ":attempts"}, s.loginCodeExpiry().Milliseconds()).Int()
+	if incrErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to count attempts of login challenge %s: %v", verifyRequest.ChallengeId, incrErr)
+		return nil, NewLoginChallengeError("counting login code attempts", incrErr.Error())
+	}
+	if attempts > s.loginCodeAttempts() {
+		client.Del(ctx, key, key+":attempts")
+		return nil, NewLoginChallengeNotFoundError(verifyRequest.ChallengeId)
+	}
+	if subtle.ConstantTimeCompare([]byte(s.hashLoginCode(verifyRequest.ChallengeId, verifyRequest.Code)), []byte(challenge.CodeHash)) != 1 {
+		remaining := s.loginCodeAttempts() - attempts
+		if remaining <= 0 {
+			client.Del(ctx, key, key+":attempts")
 		}
+		return nil, NewInvalidLoginCodeError(verifyRequest.ChallengeId, remaining)
+	}
+	// A code completes one login only
+	if deleted, _ := client.Del(ctx, key, key+":attempts").Result(); deleted == 0 {
+		return nil, NewLoginChallengeNotFoundError(verifyRequest.ChallengeId)
+	}
+
+	user, err := s.userService.FindUserById(ctx, challenge.UserId)
+	if err != nil {
+		return nil, err
+	}
+	switch user.Status {
+	case userModels.Deleted:
+		return nil, NewUserDeletedError(user.Email)
+	case userModels.Banned:
+		return nil, NewUserBannedError(user.Email, "Banned due to violation of terms of service")
+	}
 
-		loginResponse := dto.NewLoginResponse(*user.GetUserInfo(), token.AccessToken, token.RefreshToken)
-		s.logger.Success("User logged in successfully: %s", loginRequest.Email)
-		return loginResponse, nil
+	session, err := s.startSession(ctx, user.UserId, challenge.Device, challenge.Location)
+	if err != nil {
+		return nil, err
 	}
+	loginHistory := challenge.History
+	loginHistory.LoginTime = primitive.NewDateTimeFromTime(time.Now())
+	loginHistory.SessionId = session.SessionID
+	loginHistory.SteppedUp = true
+	s.recordLogin(ctx, user, loginHistory)
+
+	s.logger.WithContext(ctx).Success("User logged in with login code successfully: %s", user.Email)
+	return dto.NewLoginResponse(*user.GetUserInfo(), session.SessionID, session.Token, session.RefreshToken), nil
 }
 
-func (s *authService) GoogleLogin(googleLoginRequest *dto.GoogleLoginRequest) (*dto.GoogleLoginResponse, network.ApiError) {
-	s.logger.Info("Logging in user with Google")
-	user, err := s.userService.FindUserAuthProvider(googleLoginRequest.GoogleIdToken, googleLoginRequest.Username, userModels.GoogleProviderName)
+// GoogleLogin is never held back for a code, the Google account already verified the user,
+// risky logins only raise an alert
+func (s *authService) GoogleLogin(ctx context.Context, googleLoginRequest *dto.GoogleLoginRequest) (*dto.GoogleLoginResponse, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AuthService.GoogleLogin")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Logging in user with Google")
+	user, err := s.userService.FindUserAuthProvider(ctx, googleLoginRequest.GoogleIdToken, googleLoginRequest.Username, userModels.GoogleProviderName)
 	if err != nil {
 		return nil, err
 	}
@@ -206,12 +312,14 @@ func (s *authService) GoogleLogin(googleLoginRequest *dto.GoogleLoginRequest) (*
 		LoginTime: primitive.NewDateTimeFromTime(time.Now()),
 		IpAddress: googleLoginRequest.IpAddress,
 		UserAgent: googleLoginRequest.DeviceUserAgent,
+		DeviceId:  googleLoginRequest.DeviceId,
 		Device: userModels.UserDeviceInfo{
 			Os:    googleLoginRequest.DeviceType,
 			Type:  googleLoginRequest.DeviceType,
 			Name:  googleLoginRequest.DeviceName,
 			Model: googleLoginRequest.DeviceModel,
 		},
+		Location: newLoginLocation(&googleLoginRequest.BaseLocationRequest),
 		Provider: userModels.GoogleProviderName,
 	}
 	deviceInfo := sessionModels.DeviceInfo{
@@ -233,83 +341,87 @@ func (s *authService) GoogleLogin(googleLoginRequest *dto.GoogleLoginRequest) (*
 		GmtOffset:  googleLoginRequest.GMTOffset,
 		IpAddress:  googleLoginRequest.IpAddress,
 	}
-	var loginResponse *dto.GoogleLoginResponse
-	var session *sessionModels.Session
 	if user == nil {
-		s.logger.Debug("User not found, creating new user")
-		user, err = s.userService.CreateUserWithGoogleId(googleLoginRequest.Username, googleLoginRequest.GoogleIdToken, googleLoginRequest.Locale, googleLoginRequest.TimeZone, googleLoginRequest.Country)
+		s.logger.WithContext(ctx).Debug("User not found, creating new user")
+		user, err = s.userService.CreateUserWithGoogleId(ctx, googleLoginRequest.Username, googleLoginRequest.GoogleIdToken, googleLoginRequest.Locale, googleLoginRequest.TimeZone, googleLoginRequest.Country)
 		if err != nil {
 			return nil, NewUserError("creating user with GoogleId", err.Error())
 		}
-		token, err := s.tokenService.GenerateTokenPair(user.UserId)
-		if err != nil {
-			return nil, NewTokenError("generating token", err.Error())
-		}
-		session, err = s.sessionService.CreateSession(user.UserId, token.AccessToken, token.RefreshToken, token.AccessTokenExpiresIn.Time(), deviceInfo, locationInfo)
-		if err != nil {
-			return nil, NewSessionError("creating session", err.Error())
-		}
-		loginResponse = dto.NewGoogleLoginResponse(*user.GetUserInfo(), token.AccessToken, token.RefreshToken)
-		s.logger.Success("User logged in with Google successfully: %s", user.Email)
+		metrics.SignupCompleted(metrics.SignupGoogle)
 	} else {
-		s.logger.Debug("User found, updating session")
-		session, sessionErr := s.sessionService.GetUserActiveSession(user.UserId)
-		if sessionErr != nil {
-			return nil, NewSessionError("getting user session", sessionErr.Error())
-		}
-
 		switch user.Status {
 		case userModels.Deleted:
 			return nil, NewUserDeletedError(user.Email)
 		case userModels.Banned:
 			return nil, NewUserBannedError(user.Email, "Banned due to violation of terms of service")
 		}
+		loginHistory.Risk = s.assessLogin(ctx, user, loginHistory)
+	}
 
-		if session != nil {
-			s.sessionService.UpdateSessionInfo(session.SessionID, deviceInfo, locationInfo)
-			loginResponse := dto.NewGoogleLoginResponse(*user.GetUserInfo(), session.Token, session.RefreshToken)
-			s.logger.Success("User logged in with Google successfully: %s", user.Email)
-			return loginResponse, nil
-		} else {
-			token, err := s.tokenService.GenerateTokenPair(user.UserId)
-			if err != nil {
-				return nil, NewTokenError("generating token", err.Error())
-			}
-			_, err = s.sessionService.CreateSession(user.UserId, token.AccessToken, token.RefreshToken, token.AccessTokenExpiresIn.Time(), deviceInfo, locationInfo)
-			if err != nil {
-				return nil, NewSessionError("creating session", err.Error())
-			}
-			loginResponse = dto.NewGoogleLoginResponse(*user.GetUserInfo(), token.AccessToken, token.RefreshToken)
-			s.logger.Success("User logged in with Google successfully: %s", user.Email)
-		}
+	session, err := s.startSession(ctx, user.UserId, deviceInfo, locationInfo)
+	if err != nil {
+		return nil, err
 	}
 	loginHistory.SessionId = session.SessionID
-	s.userService.UpdateLoginHistory(user.UserId, loginHistory)
-	return loginResponse, nil
+	s.recordLogin(ctx, user, loginHistory)
+
+	s.logger.WithContext(ctx).Success("User logged in with Google successfully: %s", user.Email)
+	return dto.NewGoogleLoginResponse(*user.GetUserInfo(), session.SessionID, session.Token, session.RefreshToken), nil
 }
 
-func (s *authService) Logout(userId string) network.ApiError {
-	s.logger.Info("Logging out user with ID: %s", userId)
-	session, err := s.sessionService.GetUserActiveSession(userId)
+// startSession reuses the active session of the user, refreshing its device and location, or creates one
+func (s *authService) startSession(ctx context.Context, userId string, deviceInfo sessionModels.DeviceInfo, locationInfo sessionModels.LocationInfo) (*sessionModels.Session, network.ApiError) {
+	session, sessionErr := s.sessionService.GetUserActiveSession(ctx, userId)
+	if sessionErr != nil {
+		return nil, NewSessionError("getting user session", sessionErr.Error())
+	}
+	if session != nil {
+		s.sessionService.UpdateSessionInfo(ctx, session.SessionID, deviceInfo, locationInfo)
+		return session, nil
+	}
+
+	token, err := s.tokenService.GenerateTokenPair(userId)
+	if err != nil {
+		return nil, NewTokenError("generating token", err.Error())
+	}
+	session, err = s.sessionService.CreateSession(ctx, userId, token.AccessToken, token.RefreshToken, token.AccessTokenExpiresIn.Time(), deviceInfo, locationInfo)
 	if err != nil {
-		return NewSessionError("getting session", err.Error())
+		return nil, NewSessionError("creating session", err.Error())
 	}
-	if session == nil {
+	return session, nil
+}
+
+func (s *authService) Logout(ctx context.Context, userId string, sessionId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "AuthService.Logout")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Logging out user with ID: %s", userId)
+	if sessionId == "" {
 		return NewSessionNotFoundError(userId)
 	}
-	err = s.sessionService.InvalidateSession(session.SessionID)
+	err := s.sessionService.InvalidateSession(ctx, sessionId)
 	if err != nil {
-		return NewSessionInvalidError(session.SessionID)
+		return NewSessionInvalidError(sessionId)
+	}
+	if err := s.csrfService.RevokeToken(ctx, sessionId); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to revoke csrf token of session %s: %v", sessionId, err)
+	}
+	// the devices of the session stop receiving push notifications with it
+	if apiErr := s.userService.RemoveSessionDeviceTokens(ctx, userId, sessionId); apiErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to remove device tokens of session %s: %v", sessionId, apiErr)
 	}
-	s.logger.Success("User logged out successfully: %s", userId)
+	s.logger.WithContext(ctx).Success("User logged out successfully: %s", userId)
 	return nil
 }
 
-func (s *authService) ForgotPassword(forgotPasswordRequest *dto.ForgotPassRequest) network.ApiError {
-	s.logger.Info("Processing forgot password for email: %s", forgotPasswordRequest.Email)
+func (s *authService) ForgotPassword(ctx context.Context, forgotPasswordRequest *dto.ForgotPassRequest) network.ApiError {
+	ctx, span := tracing.Start(ctx, "AuthService.ForgotPassword")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Processing forgot password for email: %s", forgotPasswordRequest.Email)
 
 	// 1. Find user by email
-	user, err := s.userService.FindUserByEmail(forgotPasswordRequest.Email)
+	user, err := s.userService.FindUserByEmail(ctx, forgotPasswordRequest.Email)
 	if err != nil {
 		return err
 	}
@@ -322,27 +434,30 @@ func (s *authService) ForgotPassword(forgotPasswordRequest *dto.ForgotPassReques
 	expiry := time.Now().Add(1 * time.Hour)
 
 	// 3. Save token to user model
-	err = s.userService.UpdatePasswordResetToken(user.UserId, token, expiry)
+	err = s.userService.UpdatePasswordResetToken(ctx, user.UserId, token, expiry)
 	if err != nil {
-		s.logger.Error("Failed to update password reset token: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to update password reset token: %v", err)
 		return NewTokenError("generating password reset token", err.Error())
 	}
 
 	// 4. Send email via EmailService
 	resetUrl := fmt.Sprintf("%s/reset-password?token=%s", s.env.AppFrontendURL, token)
-	emailErr := s.emailService.SendPasswordReset(user.Email, token, resetUrl)
+	emailErr := s.emailService.SendPasswordReset(ctx, user.Email, user.Preferences.Language.ID(), token, resetUrl)
 	if emailErr != nil {
-		s.logger.Error("Failed to send password reset email: %v", emailErr)
+		s.logger.WithContext(ctx).Error("Failed to send password reset email: %v", emailErr)
 		return NewEmailSendError("password reset", emailErr)
 	}
 
-	s.logger.Success("Password reset email sent successfully to: %s", forgotPasswordRequest.Email)
+	s.logger.WithContext(ctx).Success("Password reset email sent successfully to: %s", forgotPasswordRequest.Email)
 	return nil
 }
 
-func (s *authService) RefreshToken(refreshTokenRequest *dto.RefreshTokenRequest) (*dto.RefreshTokenResponse, network.ApiError) {
-	s.logger.Info("Refreshing token")
-	session, err := s.sessionService.GetSessionByRefreshToken(refreshTokenRequest.RefreshToken)
+func (s *authService) RefreshToken(ctx context.Context, refreshTokenRequest *dto.RefreshTokenRequest) (*dto.RefreshTokenResponse, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AuthService.RefreshToken")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Refreshing token")
+	session, err := s.sessionService.GetSessionByRefreshToken(ctx, refreshTokenRequest.RefreshToken)
 	if err != nil {
 		return nil, NewSessionError("getting session by refresh token", err.Error())
 	}
@@ -350,16 +465,17 @@ func (s *authService) RefreshToken(refreshTokenRequest *dto.RefreshTokenRequest)
 	if err != nil {
 		return nil, NewTokenError("getting user ID from access token", err.Error())
 	}
-	var accessToken, refreshToken string
+	var sessionId, accessToken, refreshToken string
 	token, err := s.tokenService.GenerateTokenPair(userId)
 	if err != nil {
 		return nil, NewTokenError("generating token", err.Error())
 	}
 	if session != nil {
-		_, err = s.sessionService.UpdateSession(session.SessionID, token.AccessToken, token.RefreshToken, token.AccessTokenExpiresIn.Time())
+		_, err = s.sessionService.UpdateSession(ctx, session.SessionID, token.AccessToken, token.RefreshToken, token.AccessTokenExpiresIn.Time())
 		if err != nil {
 			return nil, NewSessionInvalidError(session.SessionID)
 		}
+		sessionId = session.SessionID
 		accessToken = token.AccessToken
 		refreshToken = token.RefreshToken
 	} else {
@@ -382,22 +498,37 @@ func (s *authService) RefreshToken(refreshTokenRequest *dto.RefreshTokenRequest)
 			GmtOffset:  refreshTokenRequest.GMTOffset,
 			IpAddress:  refreshTokenRequest.IpAddress,
 		}
-		session, err := s.sessionService.CreateSession(userId, token.AccessToken, token.RefreshToken, token.AccessTokenExpiresIn.Time(), deviceInfo, locationInfo)
+		session, err := s.sessionService.CreateSession(ctx, userId, token.AccessToken, token.RefreshToken, token.AccessTokenExpiresIn.Time(), deviceInfo, locationInfo)
 		if err != nil {
 			return nil, NewSessionError("creating session", err.Error())
 		}
+		sessionId = session.SessionID
 		accessToken = session.Token
 		refreshToken = session.RefreshToken
 	}
-	s.logger.Success("Tokens refreshed successfully")
-	return dto.NewRefreshTokenResponse(accessToken, refreshToken), nil
+	s.logger.WithContext(ctx).Success("Tokens refreshed successfully")
+	return dto.NewRefreshTokenResponse(sessionId, accessToken, refreshToken), nil
 }
 
-func (s *authService) VerifyEmail(token string) (*userModels.User, network.ApiError) {
-	s.logger.Info("Verifying email with token")
+func (s *authService) IssueCSRFToken(ctx context.Context, sessionId string) (string, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AuthService.IssueCSRFToken")
+	defer span.End()
+
+	csrfToken, err := s.csrfService.IssueToken(ctx, sessionId)
+	if err != nil {
+		return "", NewTokenError("issuing csrf token", err.Error())
+	}
+	return csrfToken, nil
+}
+
+func (s *authService) VerifyEmail(ctx context.Context, token string) (*userModels.User, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "AuthService.VerifyEmail")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Verifying email with token")
 
 	// 1. Find user by token
-	user, err := s.userService.FindUserByEmailVerificationToken(token)
+	user, err := s.userService.FindUserByEmailVerificationToken(ctx, token)
 	if err != nil {
 		return nil, err
 	}
@@ -407,38 +538,46 @@ func (s *authService) VerifyEmail(token string) (*userModels.User, network.ApiEr
 
 	// 2. Check token expiry
 	if user.EmailVerificationExpiry != nil && user.EmailVerificationExpiry.Time().Before(time.Now()) {
-		s.logger.Error("Email verification token expired for user: %s", user.Email)
+		s.logger.WithContext(ctx).Error("Email verification token expired for user: %s", user.Email)
 		return nil, NewExpiredTokenError("email verification")
 	}
 
 	// 3. Check if already verified
 	if user.VerifiedEmail {
-		s.logger.Info("Email already verified for user: %s", user.Email)
+		s.logger.WithContext(ctx).Info("Email already verified for user: %s", user.Email)
 		return nil, NewEmailAlreadyVerifiedError(user.Email)
 	}
 
 	// 4. Mark email as verified and clear token
-	err = s.userService.MarkEmailAsVerified(user.UserId)
+	err = s.userService.MarkEmailAsVerified(ctx, user.UserId)
 	if err != nil {
-		s.logger.Error("Failed to mark email as verified: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to mark email as verified: %v", err)
 		return nil, err
 	}
 
 	// 5. Get updated user
-	updatedUser, err := s.userService.FindUserById(user.UserId)
+	updatedUser, err := s.userService.FindUserById(ctx, user.UserId)
 	if err != nil {
 		return nil, err
 	}
 
-	s.logger.Success("Email verified successfully for user: %s", user.Email)
+	// 6. Welcome the user, the verification itself already succeeded
+	if emailErr := s.emailService.SendWelcomeEmail(ctx, updatedUser.Email, updatedUser.Preferences.Language.ID(), updatedUser.Username); emailErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to send welcome email to %s: %v", updatedUser.Email, emailErr)
+	}
+
+	s.logger.WithContext(ctx).Success("Email verified successfully for user: %s", user.Email)
 	return updatedUser, nil
 }
 
-func (s *authService) ResetPassword(token string, newPassword string) network.ApiError {
-	s.logger.Info("Resetting password with token")
+func (s *authService) ResetPassword(ctx context.Context, token string, newPassword string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "AuthService.ResetPassword")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Resetting password with token")
 
 	// 1. Find user by reset token
-	user, err := s.userService.FindUserByPasswordResetToken(token)
+	user, err := s.userService.FindUserByPasswordResetToken(ctx, token)
 	if err != nil {
 		return err
 	}
@@ -448,42 +587,253 @@ func (s *authService) ResetPassword(token string, newPassword string) network.Ap
 
 	// 2. Check token expiry
 	if user.PasswordResetExpiry != nil && user.PasswordResetExpiry.Time().Before(time.Now()) {
-		s.logger.Error("Password reset token expired for user: %s", user.Email)
+		s.logger.WithContext(ctx).Error("Password reset token expired for user: %s", user.Email)
 		return NewExpiredTokenError("password reset")
 	}
 
 	// 3. Hash new password
 	hashedPassword, hashErr := utils.HashPassword(newPassword)
 	if hashErr != nil {
-		s.logger.Error("Failed to hash password: %v", hashErr)
+		s.logger.WithContext(ctx).Error("Failed to hash password: %v", hashErr)
 		return NewUserError("hashing password", hashErr.Error())
 	}
 
 	// 4. Update password and clear reset token
-	err = s.userService.UpdatePasswordWithResetToken(user.UserId, hashedPassword)
+	err = s.userService.UpdatePasswordWithResetToken(ctx, user.UserId, hashedPassword)
 	if err != nil {
-		s.logger.Error("Failed to update password: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to update password: %v", err)
 		return err
 	}
 
 	// 5. Invalidate all user sessions (security measure)
-	sessions, sessionErr := s.sessionService.GetActiveSessionsByUserID(user.UserId)
+	sessions, sessionErr := s.sessionService.GetActiveSessionsByUserID(ctx, user.UserId)
 	if sessionErr != nil {
-		s.logger.Error("Failed to get active sessions: %v", sessionErr)
+		s.logger.WithContext(ctx).Error("Failed to get active sessions: %v", sessionErr)
 		// Don't fail the request, just log the error
 	} else {
 		for _, session := range sessions {
-			invalidateErr := s.sessionService.InvalidateSession(session.SessionID)
+			invalidateErr := s.sessionService.InvalidateSession(ctx, session.SessionID)
 			if invalidateErr != nil {
-				s.logger.Error("Failed to invalidate session %s: %v", session.SessionID, invalidateErr)
+				s.logger.WithContext(ctx).Error("Failed to invalidate session %s: %v", session.SessionID, invalidateErr)
 			}
 		}
 	}
 
-	s.logger.Success("Password reset successfully for user: %s", user.Email)
+	s.logger.WithContext(ctx).Success("Password reset successfully for user: %s", user.Email)
+	return nil
+}
+
+// ResendVerificationEmail issues a fresh verification token, rate limited per user by the
+// verification rule of the auth rate limits
+func (s *authService) ResendVerificationEmail(ctx context.Context, userId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "AuthService.ResendVerificationEmail")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Resending verification email for user: %s", userId)
+
+	user, err := s.userService.FindUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user.VerifiedEmail {
+		return NewEmailAlreadyVerifiedError(user.Email)
+	}
+
+	if err := s.checkVerificationResendLimit(ctx, userId); err != nil {
+		return err
+	}
+
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		return err
+	}
+
+	s.logger.WithContext(ctx).Success("Verification email resent to: %s", user.Email)
+	return nil
+}
+
+// sendVerificationEmail stores a new verification token on the user and emails the link to it
+func (s *authService) sendVerificationEmail(ctx context.Context, user *userModels.User) network.ApiError {
+	verification := s.config.Auth.Verification
+	tokenLength := verification.VerificationTokenLength
+	if tokenLength <= 0 {
+		tokenLength = 32
+	}
+	expiry, parseErr := time.ParseDuration(verification.EmailVerificationExpiry)
+	if parseErr != nil || expiry <= 0 {
+		expiry = 24 * time.Hour
+	}
+
+	token := generateSecureToken(tokenLength)
+	if err := s.userService.UpdateEmailVerificationToken(ctx, user.UserId, token, time.Now().Add(expiry)); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to update email verification token: %v", err)
+		return err
+	}
+
+	verificationUrl := fmt.Sprintf("%s/verify-email?token=%s", s.env.AppFrontendURL, token)
+	if emailErr := s.emailService.SendEmailVerification(ctx, user.Email, user.Preferences.Language.ID(), token, verificationUrl); emailErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to send verification email: %v", emailErr)
+		return NewEmailSendError("email verification", emailErr)
+	}
+	return nil
+}
+
+func (s *authService) checkVerificationResendLimit(ctx context.Context, userId string) network.ApiError {
+	rule := s.config.Auth.RateLimit.Verification
+	if rule.Requests <= 0 || rule.Duration <= 0 {
+		return nil
+	}
+
+	policy := limiter.Policy{
+		Name:      "verification",
+		Algorithm: limiter.SlidingWindow,
+		Limit:     rule.Requests,
+		Window:    rule.Duration,
+	}
+	result, err := s.limiter.Allow(ctx, policy, userId)
+	if err != nil {
+		// Sending an extra email beats locking the user out when the cache is down
+		s.logger.WithContext(ctx).Error("Failed to check verification resend limit for user %s: %v", userId, err)
+		return nil
+	}
+
+	if !result.Allowed {
+		metrics.RateLimitRejected("verification")
+		retryAfter := result.RetryAfter
+		if retryAfter <= 0 {
+			retryAfter = rule.Duration
+		}
+		return NewVerificationResendLimitError(userId, retryAfter)
+	}
 	return nil
 }
 
+// assessLogin returns why the login looks unusual for the user, nothing when the checks are disabled
+func (s *authService) assessLogin(ctx context.Context, user *userModels.User, loginHistory userModels.LoginHistory) []userModels.LoginRiskReason {
+	riskConfig := s.config.Auth.LoginRisk
+	if !riskConfig.Enabled {
+		return nil
+	}
+	risk := evaluateLoginRisk(user.LoginHistory, loginHistory, riskConfig)
+	if len(risk) > 0 {
+		s.logger.WithContext(ctx).Warn("Risky login for user %s from %s: %v", user.UserId, loginHistory.IpAddress, risk)
+	}
+	return risk
+}
+
+// recordLogin adds the login to the user's history and warns the user by email when it looked risky
+func (s *authService) recordLogin(ctx context.Context, user *userModels.User, loginHistory userModels.LoginHistory) {
+	if err := s.userService.UpdateLoginHistory(ctx, user.UserId, loginHistory); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to update login history of user %s: %v", user.UserId, err)
+	}
+	if len(loginHistory.Risk) == 0 || !s.config.Auth.LoginRisk.AlertEmail || user.Email == "" {
+		return
+	}
+
+	reasons := make([]string, len(loginHistory.Risk))
+	for i, reason := range loginHistory.Risk {
+		reasons[i] = string(reason)
+	}
+	location := "Unknown location"
+	if loginHistory.Location != nil && loginHistory.Location.Country != "" {
+		location = loginHistory.Location.City + ", " + loginHistory.Location.Country
+	}
+	device := loginHistory.Device.Name
+	if loginHistory.Device.Os != "" {
+		device = fmt.Sprintf("%s (%s)", device, loginHistory.Device.Os)
+	}
+
+	alert := &email.LoginAlertData{
+		Username:    user.Username,
+		Time:        loginHistory.LoginTime.Time().In(user.Preferences.Timezone.Location()).Format("Jan 2, 2006 15:04 MST"),
+		Device:      device,
+		Location:    location,
+		IpAddress:   loginHistory.IpAddress,
+		Reasons:     reasons,
+		SecurityUrl: s.env.AppFrontendURL + "/settings/security",
+		ResetUrl:    s.env.AppFrontendURL + "/forgot-password",
+	}
+	if err := s.emailService.SendLoginAlert(ctx, user.Email, user.Preferences.Language.ID(), alert); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to send login alert to user %s: %v", user.UserId, err)
+	}
+}
+
+// startLoginChallenge holds a risky login back and emails the user a code to complete it with VerifyLogin
+func (s *authService) startLoginChallenge(ctx context.Context, user *userModels.User, challenge *loginChallenge) (*dto.LoginResponse, network.ApiError) {
+	riskConfig := s.config.Auth.LoginRisk
+	code := generateLoginCode(riskConfig.CodeLength)
+	challengeId := generateSecureToken(16)
+	challenge.CodeHash = s.hashLoginCode(challengeId, code)
+	data, jsonErr := json.Marshal(challenge)
+	if jsonErr != nil {
+		return nil, NewLoginChallengeError("encoding login challenge", jsonErr.Error())
+	}
+
+	expiry := s.loginCodeExpiry()
+	if redisErr := s.store.GetInstance().Set(ctx, loginChallengeKey(challengeId), data, expiry).Err(); redisErr != nil {
+		return nil, NewLoginChallengeError("storing login challenge", redisErr.Error())
+	}
+
+	if emailErr := s.emailService.SendLoginCode(ctx, user.Email, user.Preferences.Language.ID(), code, expiry); emailErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to send login code: %v", emailErr)
+		return nil, NewEmailSendError("login code", emailErr)
+	}
+
+	s.logger.WithContext(ctx).Info("Login of user %s requires a login code, challenge: %s", user.UserId, challengeId)
+	return dto.NewLoginChallengeResponse(challengeId), nil
+}
+
+func (s *authService) loginCodeExpiry() time.Duration {
+	if expiry := s.config.Auth.LoginRisk.CodeExpiry; expiry > 0 {
+		return expiry
+	}
+	return 10 * time.Minute
+}
+
+func (s *authService) loginCodeAttempts() int {
+	if attempts := s.config.Auth.LoginRisk.CodeAttempts; attempts > 0 {
+		return attempts
+	}
+	return 5
+}
+
+func loginChallengeKey(challengeId string) string {
+	return "auth:login-challenge:" + challengeId
+}
+
+// loginAttemptScript counts an attempt at a login code, the first attempt starts the expiry so the
+// counter can not outlive its challenge even when the connection drops in between
+var loginAttemptScript = goredis.NewScript(`
+local attempts = redis.call('INCR', KEYS[1])
+if attempts == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return attempts
+`)
+
+// hashLoginCode keys the hash with a server secret and binds it to the challenge. Only the hash is
+// stored, and with codes of a few digits a leaked cache entry can not be brute forced offline.
+func (s *authService) hashLoginCode(challengeId string, code string) string {
+	mac := hmac.New(sha256.New, s.loginCodeKey)
+	mac.Write([]byte(challengeId + "\n" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// generateLoginCode returns a random code of decimal digits, easy to type from an email
+func generateLoginCode(length int) string {
+	if length <= 0 {
+		length = 6
+	}
+	digits := make([]byte, length)
+	for i := range digits {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			panic(err)
+		}
+		digits[i] = byte('0' + n.Int64())
+	}
+	return string(digits)
+}
+
 // Helper function to generate cryptographically secure random token
 func generateSecureToken(length int) string {
 	bytes := make([]byte, length)
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"sync-backend/arch/config"
+	"sync-backend/arch/limiter"
+	"sync-backend/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryLimiter is a fixed window limiter that never resets, enough to count attempts in a test
+type memoryLimiter struct {
+	counts map[string]int
+	err    error
+}
+
+func (l *memoryLimiter) Allow(_ context.Context, policy limiter.Policy, key string) (limiter.Result, error) {
+	if l.err != nil {
+		return limiter.Result{}, l.err
+	}
+	l.counts[policy.Name+":"+key]++
+	count := l.counts[policy.Name+":"+key]
+	if count > policy.Limit {
+		return limiter.Result{Limit: policy.Limit, RetryAfter: 30 * time.Second}, nil
+	}
+	return limiter.Result{Allowed: true, Limit: policy.Limit, Remaining: policy.Limit - count}, nil
+}
+
+func newResendLimitService(rule config.RateLimitRule, resendLimiter *memoryLimiter) *authService {
+	cfg := &config.Config{}
+	cfg.Auth.RateLimit.Verification = rule
+	return &authService{
+		logger:  utils.NewServiceLogger("AuthService"),
+		config:  cfg,
+		limiter: resendLimiter,
+	}
+}
+
+func TestVerificationResendLimit(t *testing.T) {
+	ctx := context.Background()
+	service := newResendLimitService(config.RateLimitRule{Requests: 2, Duration: time.Minute}, &memoryLimiter{counts: map[string]int{}})
+
+	assert.Nil(t, service.checkVerificationResendLimit(ctx, "user-1"))
+	assert.Nil(t, service.checkVerificationResendLimit(ctx, "user-1"))
+
+	err := service.checkVerificationResendLimit(ctx, "user-1")
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, err.GetStatusCode())
+	assert.Equal(t, ERR_VERIFICATION_RESEND, err.GetErrorCode())
+	assert.Contains(t, err.Error(), "Too many verification emails requested")
+
+	// Limits are per user
+	assert.Nil(t, service.checkVerificationResendLimit(ctx, "user-2"))
+}
+
+func TestVerificationResendLimitDisabled(t *testing.T) {
+	resendLimiter := &memoryLimiter{counts: map[string]int{}}
+	service := newResendLimitService(config.RateLimitRule{}, resendLimiter)
+
+	for range 5 {
+		assert.Nil(t, service.checkVerificationResendLimit(context.Background(), "user-1"))
+	}
+	assert.Empty(t, resendLimiter.counts)
+}
+
+func TestVerificationResendLimitFailsOpen(t *testing.T) {
+	service := newResendLimitService(
+		config.RateLimitRule{Requests: 1, Duration: time.Minute},
+		&memoryLimiter{err: errors.New("redis down")},
+	)
+
+	assert.Nil(t, service.checkVerificationResendLimit(context.Background(), "user-1"))
+}
+
+func TestHashLoginCode(t *testing.T) {
+	service := &authService{loginCodeKey: utils.DeriveKey("secret", "login-code")}
+	hash := service.hashLoginCode("challenge-1", "123456")
+
+	assert.Equal(t, hash, service.hashLoginCode("challenge-1", "123456"))
+	assert.NotEqual(t, hash, service.hashLoginCode("challenge-1", "123457"))
+	assert.NotEqual(t, hash, service.hashLoginCode("challenge-2", "123456"))
+
+	rotated := &authService{loginCodeKey: utils.DeriveKey("other", "login-code")}
+	assert.NotEqual(t, hash, rotated.hashLoginCode("challenge-1", "123456"))
+}
@@ -1,7 +1,11 @@
 package comment
 
 import (
+	"sync-backend/api/common/analytics"
+	"sync-backend/arch/background"
 	"sync-backend/arch/common"
+	coredto "sync-backend/arch/dto"
+	"sync-backend/arch/middleware"
 	"sync-backend/arch/network"
 	"sync-backend/utils"
 
@@ -14,19 +18,27 @@ type commentController struct {
 	network.BaseController
 	common.ContextPayload
 	authenticatorProvider network.AuthenticationProvider
+	verificationProvider  network.EmailVerificationProvider
 	locationProvider      network.LocationProvider
+	rateLimitProvider     network.RateLimitProvider
+	idempotencyProvider   network.IdempotencyProvider
 	logger                utils.AppLogger
 	commentService        CommentService
+	commentAnalytics      analytics.CommentAnalytics
 }
 
-func NewCommentController(authenticatorProvider network.AuthenticationProvider, locationProvider network.LocationProvider, commentService CommentService) *commentController {
+func NewCommentController(authenticatorProvider network.AuthenticationProvider, verificationProvider network.EmailVerificationProvider, locationProvider network.LocationProvider, rateLimitProvider network.RateLimitProvider, idempotencyProvider network.IdempotencyProvider, commentService CommentService, commentAnalytics analytics.CommentAnalytics) *commentController {
 	return &commentController{
 		BaseController:        network.NewBaseController("/comment", authenticatorProvider),
 		ContextPayload:        common.NewContextPayload(),
 		logger:                utils.NewServiceLogger("CommentController"),
 		authenticatorProvider: authenticatorProvider,
+		verificationProvider:  verificationProvider,
 		locationProvider:      locationProvider,
+		rateLimitProvider:     rateLimitProvider,
+		idempotencyProvider:   idempotencyProvider,
 		commentService:        commentService,
+		commentAnalytics:      commentAnalytics,
 	}
 }
 
@@ -34,22 +46,33 @@ func (c *commentController) MountRoutes(group *gin.RouterGroup) {
 	c.logger.Info("Mounting comment routes")
 
 	group.Use(c.authenticatorProvider.Middleware())
+	idempotent := c.idempotencyProvider.Middleware()
+	create := c.rateLimitProvider.Middleware(middleware.RateLimitCommentCreate)
+	vote := c.rateLimitProvider.Middleware(middleware.RateLimitVote)
 
 	/* POST COMMENT ROUTES */
-	group.POST("/post/create", c.locationProvider.Middleware(), c.CreatePostComment)
+	group.POST("/post/create", idempotent, create, c.verificationProvider.Middleware(), c.locationProvider.Middleware(), c.CreatePostComment)
 	group.PUT("/post/:commentId", c.EditPostComment)
 	group.DELETE("/post/:commentId", c.DeletePostComment)
 	group.GET("/post/:postId", c.GetPostComments)
 	group.GET("/post/:postId/reply/:commentId", c.GetPostCommentReplies)
 
 	/* POST COMMENT REPLY ROUTES */
-	group.POST("/post/reply/create", c.locationProvider.Middleware(), c.CreatePostCommentReply)
+	group.POST("/post/reply/create", idempotent, create, c.verificationProvider.Middleware(), c.locationProvider.Middleware(), c.CreatePostCommentReply)
 	group.POST("/post/reply/edit/:commentId", c.EditPostCommentReply)
 	group.POST("/post/reply/delete/:commentId", c.DeletePostCommentReply)
 
 	/* POST COMMENT LIKE/DISLIKE ROUTES */
-	group.POST("/like/:commentId", c.LikePostComment)
-	group.POST("/dislike/:commentId", c.DislikePostComment)
+	group.POST("/like/:commentId", vote, c.LikePostComment)
+	group.POST("/dislike/:commentId", vote, c.DislikePostComment)
+
+	/* COMMENT REACTION ROUTES */
+	group.PUT("/reaction/:commentId", vote, c.SetCommentReaction)
+	group.DELETE("/reaction/:commentId", vote, c.RemoveCommentReaction)
+	group.GET("/reaction/:commentId", c.GetCommentReactors)
+
+	/* COMMENT REVISION ROUTES */
+	group.GET("/revisions/:commentId", c.GetCommentRevisions)
 
 	/* USER COMMENT ROUTES */
 	group.GET("/user/:userId", c.GetUserComments)
@@ -65,9 +88,9 @@ func (c *commentController) CreatePostComment(ctx *gin.Context) {
 	userId := c.MustGetUserId(ctx)
 	c.SetRequestDeviceDetails(ctx, &body.BaseDeviceRequest)
 	c.SetRequestLocationDetails(ctx, &body.BaseLocationRequest)
-	_, err = c.commentService.CreatePostComment(*userId, body)
+	_, err = c.commentService.CreatePostComment(ctx.Request.Context(), *userId, body)
 	if err != nil {
-		c.logger.Error("Failed to create post comment: %v", err)
+		c.logger.WithContext(ctx).Error("Failed to create post comment: %v", err)
 		c.Send(ctx).MixedError(err)
 		return
 	}
@@ -78,7 +101,7 @@ func (c *commentController) CreatePostComment(ctx *gin.Context) {
 func (c *commentController) EditPostComment(ctx *gin.Context) {
 	commentId := ctx.Param("commentId")
 	if commentId == "" {
-		c.logger.Error("Comment ID is required")
+		c.logger.WithContext(ctx).Error("Comment ID is required")
 		c.Send(ctx).BadRequestError(
 			"Comment ID is required",
 			"Please provide a valid comment ID in the request params.",
@@ -93,9 +116,9 @@ func (c *commentController) EditPostComment(ctx *gin.Context) {
 	}
 
 	userId := c.MustGetUserId(ctx)
-	_, err = c.commentService.EditPostComment(*userId, commentId, body)
+	_, err = c.commentService.EditPostComment(ctx.Request.Context(), *userId, commentId, body)
 	if err != nil {
-		c.logger.Error("Failed to edit post comment: %v", err)
+		c.logger.WithContext(ctx).Error("Failed to edit post comment: %v", err)
 		c.Send(ctx).MixedError(err)
 		return
 	}
@@ -107,9 +130,9 @@ func (c *commentController) DeletePostComment(ctx *gin.Context) {
 	commentId := ctx.Param("commentId")
 	userId := c.MustGetUserId(ctx)
 
-	err := c.commentService.DeletePostComment(*userId, commentId)
+	err := c.commentService.DeletePostComment(ctx.Request.Context(), *userId, commentId)
 	if err != nil {
-		c.logger.Error("Failed to delete post comment: %v", err)
+		c.logger.WithContext(ctx).Error("Failed to delete post comment: %v", err)
 		c.Send(ctx).MixedError(err)
 		return
 	}
@@ -120,7 +143,7 @@ func (c *commentController) DeletePostComment(ctx *gin.Context) {
 func (c *commentController) GetPostComments(ctx *gin.Context) {
 	postId := ctx.Param("postId")
 	if postId == "" {
-		c.logger.Error("Post ID is required")
+		c.logger.WithContext(ctx).Error("Post ID is required")
 		c.Send(ctx).BadRequestError(
 			"Post ID is required",
 			"Please provide a valid post ID in the request params.",
@@ -130,24 +153,24 @@ func (c *commentController) GetPostComments(ctx *gin.Context) {
 	}
 	params, err := network.ReqQuery(ctx, dto.NewGetPostComentRequest())
 	if err != nil {
-		c.logger.Error("Failed to parse query parameters: %v", err)
+		c.logger.WithContext(ctx).Error("Failed to parse query parameters: %v", err)
 		return
 	}
 	userId := c.MustGetUserId(ctx)
-	comments, err := c.commentService.GetPostComments(*userId, postId, params.Pagination.Page, params.Pagination.Limit)
+	comments, page, err := c.commentService.GetPostComments(ctx.Request.Context(), *userId, postId, params.Cursor, params.Limit)
 	if err != nil {
-		c.logger.Error("Failed to get post comments: %v", err)
+		c.logger.WithContext(ctx).Error("Failed to get post comments: %v", err)
 		c.Send(ctx).MixedError(err)
 		return
 	}
 
-	c.Send(ctx).SuccessDataResponse("Comments retrieved successfully", comments)
+	c.Send(ctx).SuccessDataResponse("Comments retrieved successfully", coredto.NewCursorPage(comments, page.NextCursor, page.HasMore))
 }
 
 func (c *commentController) GetPostCommentReplies(ctx *gin.Context) {
 	commentId := ctx.Param("commentId")
 	if commentId == "" {
-		c.logger.Error("Comment ID is required")
+		c.logger.WithContext(ctx).Error("Comment ID is required")
 		c.Send(ctx).BadRequestError(
 			"Comment ID is required",
 			"Please provide a valid comment ID in the request params.",
@@ -157,7 +180,7 @@ func (c *commentController) GetPostCommentReplies(ctx *gin.Context) {
 	}
 	postId := ctx.Param("postId")
 	if postId == "" {
-		c.logger.Error("Post ID is required")
+		c.logger.WithContext(ctx).Error("Post ID is required")
 		c.Send(ctx).BadRequestError(
 			"Post ID is required",
 			"Please provide a valid post ID in the request params.",
@@ -168,19 +191,19 @@ func (c *commentController) GetPostCommentReplies(ctx *gin.Context) {
 
 	params, err := network.ReqQuery(ctx, dto.NewGetPostRepliesParams())
 	if err != nil {
-		c.logger.Error("Failed to parse query parameters: %v", err)
+		c.logger.WithContext(ctx).Error("Failed to parse query parameters: %v", err)
 		return
 	}
 
 	userId := c.MustGetUserId(ctx)
-	replies, err := c.commentService.GetPostCommentReplies(*userId, postId, commentId, params.Page, params.Limit)
+	replies, page, err := c.commentService.GetPostCommentReplies(ctx.Request.Context(), *userId, postId, commentId, params.Cursor, params.Limit)
 	if err != nil {
-		c.logger.Error("Failed to get post comment replies: %v", err)
+		c.logger.WithContext(ctx).Error("Failed to get post comment replies: %v", err)
 		c.Send(ctx).MixedError(err)
 		return
 	}
 
-	c.Send(ctx).SuccessDataResponse("Replies retrieved successfully", replies)
+	c.Send(ctx).SuccessDataResponse("Replies retrieved successfully", coredto.NewCursorPage(replies, page.NextCursor, page.HasMore))
 }
 
 func (c *commentController) CreatePostCommentReply(ctx *gin.Context) {
@@ -193,9 +216,9 @@ func (c *commentController) CreatePostCommentReply(ctx *gin.Context) {
 	c.SetRequestDeviceDetails(ctx, &body.BaseDeviceRequest)
 	c.SetRequestLocationDetails(ctx, &body.BaseLocationRequest)
 
-	_, err = c.commentService.CreatePostCommentReply(*userId, body)
+	_, err = c.commentService.CreatePostCommentReply(ctx.Request.Context(), *userId, body)
 	if err != nil {
-		c.logger.Error("Failed to create post comment reply: %v", err)
+		c.logger.WithContext(ctx).Error("Failed to create post comment reply: %v", err)
 		c.Send(ctx).MixedError(err)
 		return
 	}
@@ -206,7 +229,7 @@ func (c *commentController) CreatePostCommentReply(ctx *gin.Context) {
 func (c *commentController) EditPostCommentReply(ctx *gin.Context) {
 	commentId := ctx.Param("commentId")
 	if commentId == "" {
-		c.logger.Error("Comment ID is required")
+		c.logger.WithContext(ctx).Error("Comment ID is required")
 		c.Send(ctx).BadRequestError(
 			"Comment ID is required",
 			"Please provide a valid comment ID in the request params.",
@@ -221,9 +244,9 @@ func (c *commentController) EditPostCommentReply(ctx *gin.Context) {
 	}
 
 	userId := c.MustGetUserId(ctx)
-	_, err = c.commentService.EditPostCommentReply(*userId, commentId, body)
+	_, err = c.commentService.EditPostCommentReply(ctx.Request.Context(), *userId, commentId, body)
 	if err != nil {
-		c.logger.Error("Failed to edit post comment reply: %v", err)
+		c.logger.WithContext(ctx).Error("Failed to edit post comment reply: %v", err)
 		c.Send(ctx).MixedError(err)
 		return
 	}
@@ -235,9 +258,9 @@ func (c *commentController) DeletePostCommentReply(ctx *gin.Context) {
 	commentId := ctx.Param("commentId")
 	userId := c.MustGetUserId(ctx)
 
-	err := c.commentService.DeletePostComment(*userId, commentId)
+	err := c.commentService.DeletePostComment(ctx.Request.Context(), *userId, commentId)
 	if err != nil {
-		c.logger.Error("Failed to delete post comment: %v", err)
+		c.logger.WithContext(ctx).Error("Failed to delete post comment: %v", err)
 		c.Send(ctx).MixedError(err)
 		return
 	}
@@ -249,9 +272,9 @@ func (c *commentController) LikePostComment(ctx *gin.Context) {
 	commentId := ctx.Param("commentId")
 	userId := c.MustGetUserId(ctx)
 
-	isLiked, synergy, err := c.commentService.LikePostComment(*userId, commentId)
+	isLiked, synergy, err := c.commentService.LikePostComment(ctx.Request.Context(), *userId, commentId)
 	if err != nil {
-		c.logger.Error("Failed to like post comment: %v", err)
+		c.logger.WithContext(ctx).Error("Failed to like post comment: %v", err)
 		c.Send(ctx).MixedError(err)
 		return
 	}
@@ -263,9 +286,9 @@ func (c *commentController) DislikePostComment(ctx *gin.Context) {
 	commentId := ctx.Param("commentId")
 	userId := c.MustGetUserId(ctx)
 
-	isDisliked, synergy, err := c.commentService.DislikePostComment(*userId, commentId)
+	isDisliked, synergy, err := c.commentService.DislikePostComment(ctx.Request.Context(), *userId, commentId)
 	if err != nil {
-		c.logger.Error("Failed to dislike post comment: %v", err)
+		c.logger.WithContext(ctx).Error("Failed to dislike post comment: %v", err)
 		c.Send(ctx).MixedError(err)
 		return
 	}
@@ -276,7 +299,7 @@ func (c *commentController) DislikePostComment(ctx *gin.Context) {
 func (c *commentController) GetUserComments(ctx *gin.Context) {
 	userId := ctx.Param("userId")
 	if userId == "" {
-		c.logger.Error("User ID is required")
+		c.logger.WithContext(ctx).Error("User ID is required")
 		c.Send(ctx).BadRequestError(
 			"User ID is required",
 			"Please provide a valid user ID in the request params.",
@@ -287,18 +310,18 @@ func (c *commentController) GetUserComments(ctx *gin.Context) {
 
 	params, err := network.ReqQuery(ctx, dto.NewGetUserCommentRequest())
 	if err != nil {
-		c.logger.Error("Failed to parse query parameters: %v", err)
+		c.logger.WithContext(ctx).Error("Failed to parse query parameters: %v", err)
 		return
 	}
 
-	comments, err := c.commentService.GetUserComments(userId, params.Pagination.Page, params.Pagination.Limit)
+	comments, page, err := c.commentService.GetUserComments(ctx.Request.Context(), userId, params.Cursor, params.Limit)
 	if err != nil {
-		c.logger.Error("Failed to get user comments: %v", err)
+		c.logger.WithContext(ctx).Error("Failed to get user comments: %v", err)
 		c.Send(ctx).MixedError(err)
 		return
 	}
 
-	c.Send(ctx).SuccessDataResponse("Comments retrieved successfully", comments)
+	c.Send(ctx).SuccessDataResponse("Comments retrieved successfully", coredto.NewCursorPage(comments, page.NextCursor, page.HasMore))
 }
 
 func (c *commentController) GetMyUserComments(ctx *gin.Context) {
@@ -306,16 +329,90 @@ func (c *commentController) GetMyUserComments(ctx *gin.Context) {
 
 	params, err := network.ReqQuery(ctx, dto.NewGetMyCommentsRequest())
 	if err != nil {
-		c.logger.Error("Failed to parse query parameters: %v", err)
+		c.logger.WithContext(ctx).Error("Failed to parse query parameters: %v", err)
+		return
+	}
+
+	comments, page, err := c.commentService.GetUserComments(ctx.Request.Context(), *userId, params.Cursor, params.Limit)
+	if err != nil {
+		c.logger.WithContext(ctx).Error("Failed to get my comments: %v", err)
+		c.Send(ctx).MixedError(err)
+		return
+	}
+
+	c.Send(ctx).SuccessDataResponse("Comments retrieved successfully", coredto.NewCursorPage(comments, page.NextCursor, page.HasMore))
+}
+
+func (c *commentController) GetCommentRevisions(ctx *gin.Context) {
+	commentId := ctx.Param("commentId")
+	if commentId == "" {
+		c.logger.WithContext(ctx).Error("Comment ID is required")
+		c.Send(ctx).BadRequestError(
+			"Comment ID is required",
+			"Please provide a valid comment ID in the request params.",
+			nil,
+		)
+		return
+	}
+
+	userId := c.MustGetUserId(ctx)
+	revisions, err := c.commentService.GetCommentRevisions(ctx.Request.Context(), *userId, commentId)
+	if err != nil {
+		c.logger.WithContext(ctx).Error("Failed to get comment revisions: %v", err)
+		c.Send(ctx).MixedError(err)
+		return
+	}
+
+	c.Send(ctx).SuccessDataResponse("Comment revisions retrieved successfully", revisions)
+}
+
+func (c *commentController) SetCommentReaction(ctx *gin.Context) {
+	commentId := ctx.Param("commentId")
+	body, err := network.ReqBody(ctx, common.NewSetReactionRequest())
+	if err != nil {
+		return
+	}
+
+	userId := c.MustGetUserId(ctx)
+	reaction, err := c.commentService.SetCommentReaction(ctx.Request.Context(), *userId, commentId, body.Reaction)
+	if err != nil {
+		c.logger.WithContext(ctx).Error("Failed to set comment reaction: %v", err)
+		c.Send(ctx).MixedError(err)
+		return
+	}
+
+	background.Go(func() { c.commentAnalytics.RecordCommentReaction(commentId, *userId, body.Reaction) })
+	c.Send(ctx).SuccessDataResponse("Reaction set successfully", reaction)
+}
+
+func (c *commentController) RemoveCommentReaction(ctx *gin.Context) {
+	commentId := ctx.Param("commentId")
+	userId := c.MustGetUserId(ctx)
+
+	reaction, err := c.commentService.RemoveCommentReaction(ctx.Request.Context(), *userId, commentId)
+	if err != nil {
+		c.logger.WithContext(ctx).Error("Failed to remove comment reaction: %v", err)
+		c.Send(ctx).MixedError(err)
+		return
+	}
+
+	c.Send(ctx).SuccessDataResponse("Reaction removed successfully", reaction)
+}
+
+func (c *commentController) GetCommentReactors(ctx *gin.Context) {
+	commentId := ctx.Param("commentId")
+	params, err := network.ReqQuery(ctx, common.NewGetReactorsRequest())
+	if err != nil {
+		c.logger.WithContext(ctx).Error("Failed to parse query parameters: %v", err)
 		return
 	}
 
-	comments, err := c.commentService.GetUserComments(*userId, params.Pagination.Page, params.Pagination.Limit)
+	reactors, page, err := c.commentService.GetCommentReactors(ctx.Request.Context(), commentId, params.Reaction, params.Cursor, params.Limit)
 	if err != nil {
-		c.logger.Error("Failed to get my comments: %v", err)
+		c.logger.WithContext(ctx).Error("Failed to get comment reactors: %v", err)
 		c.Send(ctx).MixedError(err)
 		return
 	}
 
-	c.Send(ctx).SuccessDataResponse("Comments retrieved successfully", comments)
+	c.Send(ctx).SuccessDataResponse("Reactions retrieved successfully", coredto.NewCursorPage(reactors, page.NextCursor, page.HasMore))
 }
@@ -14,8 +14,9 @@ import (
 type CreateCommentReplyRequest struct {
 	coredto.BaseDeviceRequest
 	coredto.BaseLocationRequest
-	CommentId string `json:"comment_id" validate:"required"`
-	Reply     string `json:"reply" validate:"required"`
+	CommentId string   `json:"comment_id" validate:"required"`
+	Reply     string   `json:"reply" validate:"required"`
+	MediaIds  []string `json:"media_ids" validate:"omitempty,max=4,dive,required"`
 }
 
 func NewCreateCommentReplyRequest() *CreateCommentReplyRequest {
@@ -30,6 +31,8 @@ func (s *CreateCommentReplyRequest) ValidateErrors(errs validator.ValidationErro
 	var msgs []string
 	for _, err := range errs {
 		switch err.Tag() {
+		case "max":
+			msgs = append(msgs, fmt.Sprintf("%s must have at most %s items", err.Field(), err.Param()))
 		default:
 			msgs = append(msgs, fmt.Sprintf("%s is invalid", err.Field()))
 		}
@@ -14,10 +14,11 @@ import (
 type CreatePostCommentRequest struct {
 	coredto.BaseDeviceRequest
 	coredto.BaseLocationRequest
-	PostId      string `json:"post_id" binding:"required" validate:"required"`
-	CommunityId string `json:"community_id" binding:"required" validate:"required"`
-	Comment     string `json:"comment" binding:"required" validate:"required"`
-	ParentId    string `json:"parent_id" binding:"omitempty" validate:"omitempty"`
+	PostId      string   `json:"post_id" binding:"required" validate:"required"`
+	CommunityId string   `json:"community_id" binding:"required" validate:"required"`
+	Comment     string   `json:"comment" binding:"required" validate:"required"`
+	ParentId    string   `json:"parent_id" binding:"omitempty" validate:"omitempty"`
+	MediaIds    []string `json:"media_ids" validate:"omitempty,max=4,dive,required"`
 }
 
 func NewCreatePostCommentRequest() *CreatePostCommentRequest {
@@ -34,6 +35,8 @@ func (s *CreatePostCommentRequest) ValidateErrors(errs validator.ValidationError
 		switch err.Tag() {
 		case "required":
 			msgs = append(msgs, fmt.Sprintf("%s is required", err.Field()))
+		case "max":
+			msgs = append(msgs, fmt.Sprintf("%s must have at most %s items", err.Field(), err.Param()))
 		default:
 			msgs = append(msgs, fmt.Sprintf("%s is invalid", err.Field()))
 		}
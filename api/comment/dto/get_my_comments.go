@@ -12,12 +12,12 @@ import (
 // ==========================================
 
 type GetMyCommentsRequest struct {
-	coredto.Pagination
+	coredto.CursorPagination
 }
 
 func NewGetMyCommentsRequest() *GetMyCommentsRequest {
 	return &GetMyCommentsRequest{
-		Pagination: *coredto.NewPagination(),
+		CursorPagination: *coredto.NewCursorPagination(),
 	}
 }
 
@@ -13,12 +13,12 @@ import (
 // ==========================================
 
 type GetPostCommentRequest struct {
-	coredto.Pagination
+	coredto.CursorPagination
 }
 
 func NewGetPostComentRequest() *GetPostCommentRequest {
 	return &GetPostCommentRequest{
-		Pagination: *coredto.NewPagination(),
+		CursorPagination: *coredto.NewCursorPagination(),
 	}
 }
 
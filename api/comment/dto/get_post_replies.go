@@ -13,12 +13,12 @@ import (
 // ===========================================
 
 type GetPostRepliesParams struct {
-	coredto.Pagination
+	coredto.CursorPagination
 }
 
 func NewGetPostRepliesParams() *GetPostRepliesParams {
 	return &GetPostRepliesParams{
-		Pagination: *coredto.NewPagination(),
+		CursorPagination: *coredto.NewCursorPagination(),
 	}
 }
 
@@ -12,12 +12,12 @@ import (
 // ===========================================
 
 type GetUserCommentRequest struct {
-	coredto.Pagination
+	coredto.CursorPagination
 }
 
 func NewGetUserCommentRequest() *GetUserCommentRequest {
 	return &GetUserCommentRequest{
-		Pagination: *coredto.NewPagination(),
+		CursorPagination: *coredto.NewCursorPagination(),
 	}
 }
 
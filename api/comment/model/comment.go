@@ -3,7 +3,10 @@ package model
 import (
 	"context"
 	"strings"
+	mediaModels "sync-backend/api/common/media/model"
+	"sync-backend/arch/common"
 	"sync-backend/arch/mongo"
+	"sync-backend/utils"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -29,17 +32,17 @@ const (
 	CommentStatusFlagged  CommentStatus = "flagged" // Flagged for review
 )
 
-// ReactionType defines the type of reaction to a comment
-type ReactionType string
+// ReactionType defines the type of reaction to a comment, shared with posts
+type ReactionType = common.ReactionType
 
 const (
-	ReactionTypeLike    ReactionType = "like"
-	ReactionTypeLove    ReactionType = "love"
-	ReactionTypeLaugh   ReactionType = "laugh"
-	ReactionTypeSad     ReactionType = "sad"
-	ReactionTypeAngry   ReactionType = "angry"
-	ReactionTypeWow     ReactionType = "wow"
-	ReactionTypeSupport ReactionType = "support"
+	ReactionTypeLike    = common.ReactionTypeLike
+	ReactionTypeLove    = common.ReactionTypeLove
+	ReactionTypeLaugh   = common.ReactionTypeLaugh
+	ReactionTypeSad     = common.ReactionTypeSad
+	ReactionTypeAngry   = common.ReactionTypeAngry
+	ReactionTypeWow     = common.ReactionTypeWow
+	ReactionTypeSupport = common.ReactionTypeSupport
 )
 
 // Reaction represents a user's reaction to a comment
@@ -96,12 +99,14 @@ type Comment struct {
 	Reactions        []Reaction           `bson:"reactions,omitempty" json:"-"`
 	Level            int                  `bson:"level" json:"level"` // Nesting level (0 for top-level)
 	IsEdited         bool                 `bson:"isEdited" json:"isEdited"`
+	LastEditedAt     *primitive.DateTime  `bson:"lastEditedAt,omitempty" json:"lastEditedAt,omitempty"`
 	IsPinned         bool                 `bson:"isPinned" json:"isPinned"`     // Pinned by author
 	IsStickied       bool                 `bson:"isStickied" json:"isStickied"` // Stickied by moderator
 	IsLocked         bool                 `bson:"isLocked" json:"isLocked"`     // Can't be replied to
 	IsDeleted        bool                 `bson:"isDeleted" json:"isDeleted"`   // Soft delete by user
 	IsRemoved        bool                 `bson:"isRemoved" json:"isRemoved"`   // Removed by moderator
 	HasMedia         bool                 `bson:"hasMedia" json:"hasMedia"`
+	Media            []CommentMedia       `bson:"media,omitempty" json:"media,omitempty"`
 	Mentions         []string             `bson:"mentions,omitempty" json:"mentions,omitempty"` // User IDs mentioned in the comment
 	DeviceInfo       DeviceInfo           `bson:"deviceInfo" json:"-"`
 	LocationInfo     LocationInfo         `bson:"locationInfo" json:"-"`
@@ -115,15 +120,39 @@ type Comment struct {
 	Flags            map[string]bool      `bson:"flags,omitempty" json:"-"`       // For feature flags or special attributes
 }
 
-// CommentEdit represents a record of an edit made to a comment
+// CommentMedia is an uploaded image or video attached to a comment
+type CommentMedia struct {
+	Id     string `bson:"id" json:"id"`
+	Type   string `bson:"type" json:"type"`
+	Url    string `bson:"url" json:"url"`
+	Width  int    `bson:"width,omitempty" json:"width,omitempty"`
+	Height int    `bson:"height,omitempty" json:"height,omitempty"`
+
+	Renditions    []mediaModels.ImageRendition `bson:"renditions,omitempty" json:"renditions,omitempty"`
+	BlurHash      string                       `bson:"blurHash,omitempty" json:"blurHash,omitempty"`
+	DominantColor string                       `bson:"dominantColor,omitempty" json:"dominantColor,omitempty"`
+}
+
+// CommentEdit represents a record of an edit made to a comment, Content holds the content before the edit
 type CommentEdit struct {
-	EditorId  string             `bson:"editorId" json:"-"`
-	Content   string             `bson:"content" json:"-"`
-	Reason    string             `bson:"reason,omitempty" json:"-"`
-	EditedAt  primitive.DateTime `bson:"editedAt" json:"-"`
+	EditorId  string             `bson:"editorId" json:"editorId"`
+	Content   string             `bson:"content" json:"previousContent"`
+	DiffSize  int                `bson:"diffSize" json:"diffSize"`
+	Reason    string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	EditedAt  primitive.DateTime `bson:"editedAt" json:"editedAt"`
 	IPAddress string             `bson:"ipAddress,omitempty" json:"-"`
 }
 
+// NewCommentEdit creates a revision record for an edit from previousContent to newContent
+func NewCommentEdit(editorId string, previousContent string, newContent string) *CommentEdit {
+	return &CommentEdit{
+		EditorId: editorId,
+		Content:  previousContent,
+		DiffSize: utils.DiffSize(previousContent, newContent),
+		EditedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+}
+
 // CommentTree represents a hierarchical structure of comments with their replies
 type CommentTree struct {
 	Comment Comment       `bson:"comment" json:"comment"`
@@ -259,6 +288,13 @@ func (*Comment) EnsureIndexes(db mongo.Database) {
 			},
 			Options: options.Index().SetName("idx_comment_community"),
 		},
+		{
+			Keys: bson.D{
+				{Key: "mentions", Value: 1},
+				{Key: "createdAt", Value: -1},
+			},
+			Options: options.Index().SetName("idx_comment_mentions").SetSparse(true),
+		},
 		// TTL index for deleted comments - 7 days
 		{
 			Keys: bson.D{
@@ -20,7 +20,8 @@ type CommentInteraction struct {
 	InteractionId   string                 `bson:"interactionId" json:"interactionId"`
 	CommentId       string                 `bson:"commentId" json:"commentId" validate:"required"`
 	UserId          string                 `bson:"userId" json:"userId" validate:"required"`
-	InteractionType CommentInteractionType `bson:"interactionType" json:"interactionType" validate:"required,oneof=like dislike save"`
+	InteractionType CommentInteractionType `bson:"interactionType" json:"interactionType" validate:"required,oneof=like dislike save reaction"`
+	Reaction        ReactionType           `bson:"reaction,omitempty" json:"reaction,omitempty" validate:"required_if=InteractionType reaction"`
 	CreatedAt       primitive.DateTime     `bson:"createdAt" json:"createdAt"`
 	UpdatedAt       primitive.DateTime     `bson:"updatedAt" json:"updatedAt"`
 	DeletedAt       *primitive.DateTime    `bson:"deletedAt,omitempty" json:"-"`
@@ -31,6 +32,8 @@ type CommentInteractionType string
 const (
 	CommentInteractionTypeLike    CommentInteractionType = "like"
 	CommentInteractionTypeDislike CommentInteractionType = "dislike"
+	// A user holds at most one reaction per comment, enforced by idx_comment_interaction_unique
+	CommentInteractionTypeReaction CommentInteractionType = "reaction"
 )
 
 func NewCommentInteraction(userId string, commentId string, interactionType CommentInteractionType) *CommentInteraction {
@@ -44,6 +47,12 @@ func NewCommentInteraction(userId string, commentId string, interactionType Comm
 	}
 }
 
+func NewCommentReaction(userId string, commentId string, reaction ReactionType) *CommentInteraction {
+	interaction := NewCommentInteraction(userId, commentId, CommentInteractionTypeReaction)
+	interaction.Reaction = reaction
+	return interaction
+}
+
 func (c *CommentInteraction) GetValue() *CommentInteraction {
 	return c
 }
@@ -101,6 +110,15 @@ func (*CommentInteraction) EnsureIndexes(db mongo.Database) {
 			},
 			Options: options.Index().SetName("idx_comment_interaction_deleted").SetExpireAfterSeconds(0),
 		},
+		{
+			Keys: bson.D{
+				{Key: "commentId", Value: 1},
+				{Key: "interactionType", Value: 1},
+				{Key: "reaction", Value: 1},
+				{Key: "createdAt", Value: -1},
+			},
+			Options: options.Index().SetName("idx_comment_interaction_reaction"),
+		},
 	}
 	mongo.NewQueryBuilder[CommentInteraction](db, CommentInteractionCollectionName).Query(context.Background()).CheckIndexes(indexes)
 }
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MaxMentions caps the users a single comment can mention, further mentions are ignored
+const MaxMentions = 10
+
+// mentionPattern matches @username not preceded by a word character, so email addresses are skipped
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([\w.]{3,50})`)
+
+// ParseMentions returns the distinct usernames mentioned in the content, in order of appearance
+func ParseMentions(content string) []string {
+	var usernames []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		// A mention ending a sentence keeps its period
+		username := strings.TrimRight(match[1], ".")
+		if len(username) < 3 || seen[username] {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+		if len(usernames) == MaxMentions {
+			break
+		}
+	}
+	return usernames
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected []string
+	}{
+		{"thanks @alice and @bob_2!", []string{"alice", "bob_2"}},
+		{"@alice said so. Ask @john.doe.", []string{"alice", "john.doe"}},
+		{"@alice @alice", []string{"alice"}},
+		{"mail me at me@example.com", nil},
+		{"@al is too short", nil},
+		{"no mentions here", nil},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, ParseMentions(tt.content), tt.content)
+	}
+}
+
+func TestParseMentionsIsCapped(t *testing.T) {
+	var content strings.Builder
+	for i := range MaxMentions + 5 {
+		fmt.Fprintf(&content, "@user%d ", i)
+	}
+	assert.Len(t, ParseMentions(content.String()), MaxMentions)
+}
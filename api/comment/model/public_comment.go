@@ -21,15 +21,25 @@ type PublicGetComment struct {
 	ReactionCounts   map[ReactionType]int      `json:"reactionCounts,omitempty"` // Count by reaction type
 	Level            int                       `json:"level"`                    // Nesting level (0 for top-level)
 	IsEdited         bool                      `json:"isEdited"`
+	LastEditedAt     *primitive.DateTime       `json:"lastEditedAt,omitempty"`
 	IsPinned         bool                      `json:"isPinned"`   // Pinned by author
 	IsStickied       bool                      `json:"isStickied"` // Stickied by moderator
 	IsLocked         bool                      `json:"isLocked"`   // Can't be replied to
 	IsDeleted        bool                      `json:"isDeleted"`  // Soft delete by user
 	IsRemoved        bool                      `json:"isRemoved"`  // Removed by moderator
 	HasMedia         bool                      `json:"hasMedia"`
+	Media            []CommentMedia            `json:"media,omitempty"`
 	Mentions         []string                  `json:"mentions,omitempty"`
 	Path             string                    `json:"path"`
 	IsLiked          bool                      `json:"isLiked"`
 	IsDisliked       bool                      `json:"isDisliked"`
+	MyReaction       ReactionType              `json:"myReaction,omitempty"`
 	CreatedAt        primitive.DateTime        `json:"createdAt"`
 }
+
+// PublicCommentReactor is a single entry of the "who reacted" listing of a comment
+type PublicCommentReactor struct {
+	User      user.PublicUser    `json:"user"`
+	Reaction  ReactionType       `json:"reaction"`
+	ReactedAt primitive.DateTime `json:"reactedAt"`
+}
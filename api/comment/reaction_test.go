@@ -0,0 +1,75 @@
+package comment
+
+import (
+	"context"
+	"testing"
+
+	"sync-backend/api/comment/model"
+	"sync-backend/arch/mongo"
+	"sync-backend/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func newReactionSession(t *testing.T) *mongo.MockTransactionSession {
+	session := mongo.NewMockTransactionSession()
+	_, err := session.Collection(model.CommentCollectionName).InsertOne(bson.M{"commentId": "comment-1"})
+	require.NoError(t, err)
+	return session
+}
+
+func commentReactionCounts(t *testing.T, session *mongo.MockTransactionSession) map[model.ReactionType]int {
+	var comment model.Comment
+	require.NoError(t, session.Collection(model.CommentCollectionName).FindOne(bson.M{"commentId": "comment-1"}).Decode(&comment))
+	return comment.ReactionCounts
+}
+
+func userCommentReactions(session *mongo.MockTransactionSession, userId string) []model.ReactionType {
+	var reactions []model.ReactionType
+	for _, interaction := range session.Documents(model.CommentInteractionCollectionName) {
+		if interaction["userId"] == userId {
+			reactions = append(reactions, model.ReactionType(interaction["reaction"].(string)))
+		}
+	}
+	return reactions
+}
+
+func TestApplyCommentReactionSwapsTheSingleReaction(t *testing.T) {
+	ctx := context.Background()
+	s := &commentService{logger: utils.NewServiceLogger("CommentService")}
+	session := newReactionSession(t)
+
+	require.NoError(t, s.applyCommentReaction(ctx, session, "user-1", "comment-1", model.ReactionTypeLike))
+	require.NoError(t, s.applyCommentReaction(ctx, session, "user-2", "comment-1", model.ReactionTypeLike))
+	assert.Equal(t, map[model.ReactionType]int{model.ReactionTypeLike: 2}, commentReactionCounts(t, session))
+
+	// Changing the reaction moves the count instead of adding a second reaction
+	require.NoError(t, s.applyCommentReaction(ctx, session, "user-1", "comment-1", model.ReactionTypeLove))
+	assert.Equal(t, []model.ReactionType{model.ReactionTypeLove}, userCommentReactions(session, "user-1"))
+	assert.Equal(t, map[model.ReactionType]int{model.ReactionTypeLike: 1, model.ReactionTypeLove: 1}, commentReactionCounts(t, session))
+
+	// Setting the held reaction again changes nothing
+	require.NoError(t, s.applyCommentReaction(ctx, session, "user-1", "comment-1", model.ReactionTypeLove))
+	assert.Len(t, session.Documents(model.CommentInteractionCollectionName), 2)
+	assert.Equal(t, map[model.ReactionType]int{model.ReactionTypeLike: 1, model.ReactionTypeLove: 1}, commentReactionCounts(t, session))
+}
+
+func TestClearCommentReactionTakesItOffTheCounts(t *testing.T) {
+	ctx := context.Background()
+	s := &commentService{logger: utils.NewServiceLogger("CommentService")}
+	session := newReactionSession(t)
+
+	require.NoError(t, s.applyCommentReaction(ctx, session, "user-1", "comment-1", model.ReactionTypeWow))
+	require.NoError(t, s.applyCommentReaction(ctx, session, "user-2", "comment-1", model.ReactionTypeWow))
+
+	require.NoError(t, s.clearCommentReaction(ctx, session, "user-1", "comment-1"))
+	assert.Empty(t, userCommentReactions(session, "user-1"))
+	assert.Equal(t, []model.ReactionType{model.ReactionTypeWow}, userCommentReactions(session, "user-2"))
+	assert.Equal(t, map[model.ReactionType]int{model.ReactionTypeWow: 1}, commentReactionCounts(t, session))
+
+	// Without a reaction there is nothing to take off
+	require.NoError(t, s.clearCommentReaction(ctx, session, "user-1", "comment-1"))
+	assert.Equal(t, map[model.ReactionType]int{model.ReactionTypeWow: 1}, commentReactionCounts(t, session))
+}
@@ -1,11 +1,22 @@
 package comment
 
 import (
+	"context"
 	"fmt"
 	"sync-backend/api/comment/dto"
 	"sync-backend/api/comment/model"
+	"sync-backend/api/common/guard"
+	"sync-backend/api/common/push"
+	"sync-backend/api/media"
+	mediaModel "sync-backend/api/media/model"
+	"sync-backend/api/moderator"
+	moderatorModel "sync-backend/api/moderator/model"
+	"sync-backend/arch/background"
+	"sync-backend/arch/common"
+	"sync-backend/arch/metrics"
 	"sync-backend/arch/mongo"
 	"sync-backend/arch/network"
+	"sync-backend/arch/tracing"
 	"sync-backend/utils"
 	"time"
 
@@ -15,124 +26,189 @@ import (
 
 	community "sync-backend/api/community/model"
 	post "sync-backend/api/post/model"
+	user "sync-backend/api/user/model"
 )
 
 type CommentService interface {
-	CreatePostComment(userId string, comment *dto.CreatePostCommentRequest) (*model.Comment, network.ApiError)
-	EditPostComment(userId string, commentId string, comment *dto.EditPostCommentRequest) (*model.Comment, network.ApiError)
-	DeletePostComment(userId string, commentId string) network.ApiError
-	GetPostComments(userId string, postId string, page int, limit int) ([]*model.PublicGetComment, network.ApiError)
-	GetPostCommentReplies(userId string, postId string, parentId string, page int, limit int) ([]*model.PublicGetComment, network.ApiError)
+	CreatePostComment(ctx context.Context, userId string, comment *dto.CreatePostCommentRequest) (*model.Comment, network.ApiError)
+	EditPostComment(ctx context.Context, userId string, commentId string, comment *dto.EditPostCommentRequest) (*model.Comment, network.ApiError)
+	DeletePostComment(ctx context.Context, userId string, commentId string) network.ApiError
+	GetPostComments(ctx context.Context, userId string, postId string, cursor string, limit int) ([]*model.PublicGetComment, *mongo.CursorResult, network.ApiError)
+	GetPostCommentReplies(ctx context.Context, userId string, postId string, parentId string, cursor string, limit int) ([]*model.PublicGetComment, *mongo.CursorResult, network.ApiError)
 
-	CreatePostCommentReply(userId string, comment *dto.CreateCommentReplyRequest) (*model.Comment, network.ApiError)
-	EditPostCommentReply(userId string, commentId string, comment *dto.EditCommentReplyRequest) (*model.Comment, network.ApiError)
-	DeletePostCommentReply(userId string, commentId string) network.ApiError
+	CreatePostCommentReply(ctx context.Context, userId string, comment *dto.CreateCommentReplyRequest) (*model.Comment, network.ApiError)
+	EditPostCommentReply(ctx context.Context, userId string, commentId string, comment *dto.EditCommentReplyRequest) (*model.Comment, network.ApiError)
+	DeletePostCommentReply(ctx context.Context, userId string, commentId string) network.ApiError
 
-	LikePostComment(userId string, commentId string) (*bool, *int, network.ApiError)
-	DislikePostComment(userId string, commentId string) (*bool, *int, network.ApiError)
+	LikePostComment(ctx context.Context, userId string, commentId string) (*bool, *int, network.ApiError)
+	DislikePostComment(ctx context.Context, userId string, commentId string) (*bool, *int, network.ApiError)
 
-	GetUserComments(userId string, page int, limit int) ([]*model.PublicGetComment, network.ApiError)
+	GetUserComments(ctx context.Context, userId string, cursor string, limit int) ([]*model.PublicGetComment, *mongo.CursorResult, network.ApiError)
+	GetCommentRevisions(ctx context.Context, userId string, commentId string) ([]model.CommentEdit, network.ApiError)
+
+	SetCommentReaction(ctx context.Context, userId string, commentId string, reaction model.ReactionType) (*common.ReactionResponse, network.ApiError)
+	RemoveCommentReaction(ctx context.Context, userId string, commentId string) (*common.ReactionResponse, network.ApiError)
+	GetCommentReactors(ctx context.Context, commentId string, reaction model.ReactionType, cursor string, limit int) ([]*model.PublicCommentReactor, *mongo.CursorResult, network.ApiError)
 }
 
 type commentService struct {
 	network.BaseService
 	logger                         utils.AppLogger
+	contentGuard                   guard.ContentGuard
+	mediaLibraryService            media.MediaLibraryService
+	moderatorService               moderator.ModeratorService
+	pushService                    push.PushService
 	commentQueryBuilder            mongo.QueryBuilder[model.Comment]
 	commentInteractionQueryBuilder mongo.QueryBuilder[model.CommentInteraction]
 	postQueryBuilder               mongo.QueryBuilder[post.Post]
 	communityQueryBuilder          mongo.QueryBuilder[community.Community]
+	userQueryBuilder               mongo.QueryBuilder[user.User]
 	commentAggregateBuilder        mongo.AggregateBuilder[model.Comment, model.PublicGetComment]
+	reactorAggregateBuilder        mongo.AggregateBuilder[model.CommentInteraction, model.PublicCommentReactor]
 	transaction                    mongo.TransactionBuilder
 }
 
-func NewCommentService(db mongo.Database) CommentService {
+func NewCommentService(db mongo.Database, contentGuard guard.ContentGuard, moderatorService moderator.ModeratorService, mediaLibraryService media.MediaLibraryService, pushService push.PushService) CommentService {
 	return &commentService{
 		BaseService:                    network.NewBaseService(),
 		logger:                         utils.NewServiceLogger("CommentService"),
+		contentGuard:                   contentGuard,
+		mediaLibraryService:            mediaLibraryService,
+		moderatorService:               moderatorService,
+		pushService:                    pushService,
 		commentQueryBuilder:            mongo.NewQueryBuilder[model.Comment](db, model.CommentCollectionName),
 		commentInteractionQueryBuilder: mongo.NewQueryBuilder[model.CommentInteraction](db, model.CommentInteractionCollectionName),
 		postQueryBuilder:               mongo.NewQueryBuilder[post.Post](db, post.PostCollectionName),
 		communityQueryBuilder:          mongo.NewQueryBuilder[community.Community](db, community.CommunityCollectionName),
+		userQueryBuilder:               mongo.NewQueryBuilder[user.User](db, user.UserCollectionName),
 		commentAggregateBuilder:        mongo.NewAggregateBuilder[model.Comment, model.PublicGetComment](db, model.CommentCollectionName),
+		reactorAggregateBuilder:        mongo.NewAggregateBuilder[model.CommentInteraction, model.PublicCommentReactor](db, model.CommentInteractionCollectionName),
 		transaction:                    mongo.NewTransactionBuilder(db),
 	}
 }
 
-func (s *commentService) CreatePostComment(userId string, comment *dto.CreatePostCommentRequest) (*model.Comment, network.ApiError) {
+func (s *commentService) CreatePostComment(ctx context.Context, userId string, comment *dto.CreatePostCommentRequest) (*model.Comment, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommentService.CreatePostComment")
+	defer span.End()
+
 	// check for post existence
 	postFilter := bson.M{"postId": comment.PostId}
-	_, err := s.postQueryBuilder.SingleQuery().FindOne(postFilter, nil)
+	postModel, err := s.postQueryBuilder.SingleQuery(ctx).FindOne(postFilter, nil)
 	if err != nil {
-		s.logger.Error("Failed to find post - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to find post - %v", err)
 		return nil, NewPostNotFoundError(comment.PostId)
 	}
+	// A parent given here makes the comment a reply, the thread lock of the parent applies to it
+	if comment.ParentId != "" {
+		parentModel, err := s.commentQueryBuilder.SingleQuery(ctx).FindOne(bson.M{"commentId": comment.ParentId, "postId": comment.PostId}, nil)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("Failed to find parent comment - %v", err)
+			return nil, NewCommentNotFoundError(comment.ParentId)
+		}
+		if guardErr := s.contentGuard.CheckCommentWrite(ctx, userId, postModel, parentModel); guardErr != nil {
+			return nil, guardErr
+		}
+	} else if guardErr := s.contentGuard.CheckPostWrite(ctx, userId, postModel); guardErr != nil {
+		return nil, guardErr
+	}
 	// check for community existence
 	communityFilter := bson.M{"communityId": comment.CommunityId}
-	_, err = s.communityQueryBuilder.SingleQuery().FindOne(communityFilter, nil)
+	_, err = s.communityQueryBuilder.SingleQuery(ctx).FindOne(communityFilter, nil)
 	if err != nil {
-		s.logger.Error("Failed to find community - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to find community - %v", err)
 		return nil, NewCommunityNotFoundError(comment.CommunityId)
 	}
 
 	commentModel := model.NewComment(comment.PostId, userId, comment.CommunityId, comment.Comment, comment.ParentId)
 	commentModel.AddDeviceInfo(comment.DeviceId, comment.DeviceType, comment.DeviceOS, comment.DeviceVersion)
 	commentModel.AddLocationInfo(comment.Country, comment.City, comment.Latitude, comment.Longitude, comment.IpAddress, comment.TimeZone)
-	_, err = s.commentQueryBuilder.SingleQuery().InsertOne(commentModel)
+	commentModel.Mentions = s.resolveMentions(ctx, userId, commentModel.Content)
+	if apiErr := s.attachCommentMedia(ctx, userId, commentModel, comment.MediaIds); apiErr != nil {
+		return nil, apiErr
+	}
+	_, err = s.commentQueryBuilder.SingleQuery(ctx).InsertOne(commentModel)
 	if err != nil {
-		s.logger.Error("Failed to create post comment - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to create post comment - %v", err)
+		s.releaseCommentMedia(ctx, commentModel)
 		return nil, NewDBError("creating comment", err.Error())
 	}
+	metrics.CommentCreated(metrics.CommentKindComment)
+	s.notifyReply(ctx, postModel.AuthorId, commentModel, push.Notification{
+		Title: "New comment on your post",
+		Body:  commentModel.Content,
+		Data:  map[string]string{"type": "post_comment", "postId": commentModel.PostId, "commentId": commentModel.CommentId},
+	})
 	return commentModel, nil
 }
 
-func (s *commentService) EditPostComment(userId string, commentId string, comment *dto.EditPostCommentRequest) (*model.Comment, network.ApiError) {
+func (s *commentService) EditPostComment(ctx context.Context, userId string, commentId string, comment *dto.EditPostCommentRequest) (*model.Comment, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommentService.EditPostComment")
+	defer span.End()
+
 	filter := bson.M{"commentId": commentId}
 	if comment.ParentId != "" {
 		filter["parentId"] = comment.ParentId
 	}
-	commentModel, err := s.commentQueryBuilder.SingleQuery().FindOne(filter, nil)
+	commentModel, err := s.commentQueryBuilder.SingleQuery(ctx).FindOne(filter, nil)
 	if err != nil {
-		s.logger.Error("Failed to find comment - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to find comment - %v", err)
 		return nil, NewCommentNotFoundError(commentId)
 	}
 
 	if commentModel.AuthorId != userId {
-		s.logger.Error("User is not authorized to edit this comment")
+		s.logger.WithContext(ctx).Error("User is not authorized to edit this comment")
 		return nil, NewForbiddenError("edit", userId, commentId)
 	}
+	if guardErr := s.guardCommentWrite(ctx, userId, commentModel); guardErr != nil {
+		return nil, guardErr
+	}
 
+	// Saving the same content again is not a revision
+	if comment.Comment == commentModel.Content {
+		return commentModel, nil
+	}
+
+	edit := model.NewCommentEdit(userId, commentModel.Content, comment.Comment)
 	commentModel.Content = comment.Comment
 	commentModel.ParentId = comment.ParentId
+	commentModel.IsEdited = true
+	commentModel.LastEditedAt = &edit.EditedAt
+	commentModel.UpdatedAt = edit.EditedAt
 	update := bson.M{
 		"$set": bson.M{
-			"status":    model.CommentStatusActive,
-			"isEdited":  true,
-			"content":   commentModel.Content,
-			"parentId":  commentModel.ParentId,
-			"updatedAt": commentModel.UpdatedAt,
+			"status":       model.CommentStatusActive,
+			"isEdited":     true,
+			"content":      commentModel.Content,
+			"parentId":     commentModel.ParentId,
+			"lastEditedAt": edit.EditedAt,
+			"updatedAt":    commentModel.UpdatedAt,
 		},
+		"$push": bson.M{"editHistory": edit},
 	}
-	_, err = s.commentQueryBuilder.SingleQuery().UpdateOne(filter, update, nil)
+	_, err = s.commentQueryBuilder.SingleQuery(ctx).UpdateOne(filter, update, nil)
 	if err != nil {
-		s.logger.Error("Failed to update post comment - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to update post comment - %v", err)
 		return nil, NewDBError("updating comment", err.Error())
 	}
 
 	return commentModel, nil
 }
 
-func (s *commentService) DeletePostComment(userId string, commentId string) network.ApiError {
+func (s *commentService) DeletePostComment(ctx context.Context, userId string, commentId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "CommentService.DeletePostComment")
+	defer span.End()
+
 	filter := bson.M{"commentId": commentId}
-	commentModel, err := s.commentQueryBuilder.SingleQuery().FindOne(filter, nil)
+	commentModel, err := s.commentQueryBuilder.SingleQuery(ctx).FindOne(filter, nil)
 	if err != nil {
-		s.logger.Error("Failed to find comment - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to find comment - %v", err)
 		return NewCommentNotFoundError(commentId)
 	}
 	if commentModel.AuthorId != userId {
-		s.logger.Error("User is not authorized to delete this comment")
+		s.logger.WithContext(ctx).Error("User is not authorized to delete this comment")
 		return NewForbiddenError("delete", userId, commentId)
 	}
-	_, err = s.commentQueryBuilder.SingleQuery().UpdateOne(
+	_, err = s.commentQueryBuilder.SingleQuery(ctx).UpdateOne(
 		bson.M{"commentId": commentId},
 		bson.M{
 			"$set": bson.M{
@@ -145,19 +221,20 @@ func (s *commentService) DeletePostComment(userId string, commentId string) netw
 		nil,
 	)
 	if err != nil {
-		s.logger.Error("Failed to delete post comment - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to delete post comment - %v", err)
 		return NewDBError("deleting comment", err.Error())
 	}
+	s.releaseCommentMedia(ctx, commentModel)
 	return nil
 }
 
-func (s *commentService) GetPostComments(userId string, postId string, page int, limit int) ([]*model.PublicGetComment, network.ApiError) {
-	s.logger.Debug("GetPostComments - postId: %s, page: %d, limit: %d", postId, page, limit)
-	aggregate := s.commentAggregateBuilder.SingleAggregate()
+func (s *commentService) GetPostComments(ctx context.Context, userId string, postId string, cursor string, limit int) ([]*model.PublicGetComment, *mongo.CursorResult, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommentService.GetPostComments")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Debug("GetPostComments - postId: %s, limit: %d", postId, limit)
+	aggregate := s.commentAggregateBuilder.SingleAggregate(ctx)
 	aggregate.Match(bson.M{"postId": postId, "status": model.CommentStatusActive, "isDeleted": false, "parentId": bson.M{"$exists": false}})
-	aggregate.Sort(bson.D{{Key: "createdAt", Value: -1}, {Key: "synergy", Value: -1}})
-	aggregate.Limit(int64(limit))
-	aggregate.Skip(int64((page - 1) * limit))
 	aggregate.Lookup("users", "authorId", "userId", "author")
 	aggregate.Lookup("communities", "communityId", "communityId", "community")
 
@@ -197,6 +274,7 @@ func (s *commentService) GetPostComments(userId string, postId string, page int,
 		})
 		aggregate.AddFields(bson.M{
 			"userInteraction": bson.M{"$arrayElemAt": bson.A{"$userInteractions", 0}},
+			"myReaction":      userReactionExpr(userId),
 		})
 	}
 
@@ -228,14 +306,17 @@ func (s *commentService) GetPostComments(userId string, postId string, page int,
 		"synergy":          1,
 		"replyCount":       1,
 		"reactionCounts":   1,
+		"myReaction":       1,
 		"level":            1,
 		"isEdited":         1,
+		"lastEditedAt":     1,
 		"isPinned":         1,
 		"isStickied":       1,
 		"isLocked":         1,
 		"isDeleted":        1,
 		"isRemoved":        1,
 		"hasMedia":         1,
+		"media":            1,
 		"mentions":         1,
 		"path":             1,
 		"createdAt":        1,
@@ -266,10 +347,13 @@ func (s *commentService) GetPostComments(userId string, postId string, page int,
 
 	aggregate.Project(projectFields)
 
-	comments, err := aggregate.Exec()
+	comments, page, err := aggregate.ExecCursorPaginated(mongo.NewCursorQuery("createdAt", int64(limit), cursor))
 	if err != nil {
-		s.logger.Error("Failed to get post comments - %v", err)
-		return nil, network.NewInternalServerError(
+		if mongo.IsInvalidCursorError(err) {
+			return nil, nil, NewInvalidCursorError(cursor)
+		}
+		s.logger.WithContext(ctx).Error("Failed to get post comments - %v", err)
+		return nil, nil, network.NewInternalServerError(
 			"Failed to get comments",
 			fmt.Sprintf("It seems the comments for post '%s' could not be retrieved - Aggregation failed. Please try again later. [Context: postId=%s]", postId, postId),
 			network.DB_ERROR,
@@ -278,19 +362,19 @@ func (s *commentService) GetPostComments(userId string, postId string, page int,
 
 	}
 	if len(comments) == 0 {
-		return []*model.PublicGetComment{}, nil
+		return []*model.PublicGetComment{}, page, nil
 	} else {
-		return comments, nil
+		return comments, page, nil
 	}
 }
 
-func (s *commentService) GetPostCommentReplies(userId string, postId string, parentId string, page int, limit int) ([]*model.PublicGetComment, network.ApiError) {
-	s.logger.Debug("GetPostComments - postId: %s, page: %d, limit: %d", postId, page, limit)
-	aggregate := s.commentAggregateBuilder.SingleAggregate()
+func (s *commentService) GetPostCommentReplies(ctx context.Context, userId string, postId string, parentId string, cursor string, limit int) ([]*model.PublicGetComment, *mongo.CursorResult, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommentService.GetPostCommentReplies")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Debug("GetPostComments - postId: %s, limit: %d", postId, limit)
+	aggregate := s.commentAggregateBuilder.SingleAggregate(ctx)
 	aggregate.Match(bson.M{"postId": postId, "status": model.CommentStatusActive, "isDeleted": false, "parentId": parentId})
-	aggregate.Sort(bson.D{{Key: "createdAt", Value: -1}, {Key: "synergy", Value: -1}})
-	aggregate.Limit(int64(limit))
-	aggregate.Skip(int64((page - 1) * limit))
 	aggregate.Lookup("users", "authorId", "userId", "author")
 	aggregate.Lookup("communities", "communityId", "communityId", "community")
 
@@ -330,6 +414,7 @@ func (s *commentService) GetPostCommentReplies(userId string, postId string, par
 		})
 		aggregate.AddFields(bson.M{
 			"userInteraction": bson.M{"$arrayElemAt": bson.A{"$userInteractions", 0}},
+			"myReaction":      userReactionExpr(userId),
 		})
 	}
 
@@ -361,14 +446,17 @@ func (s *commentService) GetPostCommentReplies(userId string, postId string, par
 		"synergy":          1,
 		"replyCount":       1,
 		"reactionCounts":   1,
+		"myReaction":       1,
 		"level":            1,
 		"isEdited":         1,
+		"lastEditedAt":     1,
 		"isPinned":         1,
 		"isStickied":       1,
 		"isLocked":         1,
 		"isDeleted":        1,
 		"isRemoved":        1,
 		"hasMedia":         1,
+		"media":            1,
 		"mentions":         1,
 		"path":             1,
 		"createdAt":        1,
@@ -399,10 +487,13 @@ func (s *commentService) GetPostCommentReplies(userId string, postId string, par
 
 	aggregate.Project(projectFields)
 
-	comments, err := aggregate.Exec()
+	comments, page, err := aggregate.ExecCursorPaginated(mongo.NewCursorQuery("createdAt", int64(limit), cursor))
 	if err != nil {
-		s.logger.Error("Failed to get post comments - %v", err)
-		return nil, network.NewInternalServerError(
+		if mongo.IsInvalidCursorError(err) {
+			return nil, nil, NewInvalidCursorError(cursor)
+		}
+		s.logger.WithContext(ctx).Error("Failed to get post comments - %v", err)
+		return nil, nil, network.NewInternalServerError(
 			"Failed to get comments",
 			fmt.Sprintf("It seems the comments for post '%s' could not be retrieved - Aggregation failed. Please try again later. [Context: postId=%s]", postId, postId),
 			network.DB_ERROR,
@@ -410,17 +501,20 @@ func (s *commentService) GetPostCommentReplies(userId string, postId string, par
 		)
 	}
 	if len(comments) == 0 {
-		return []*model.PublicGetComment{}, nil
+		return []*model.PublicGetComment{}, page, nil
 	} else {
-		return comments, nil
+		return comments, page, nil
 	}
 }
 
-func (s *commentService) CreatePostCommentReply(userId string, comment *dto.CreateCommentReplyRequest) (*model.Comment, network.ApiError) {
+func (s *commentService) CreatePostCommentReply(ctx context.Context, userId string, comment *dto.CreateCommentReplyRequest) (*model.Comment, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommentService.CreatePostCommentReply")
+	defer span.End()
+
 	commentFilter := bson.M{"commentId": comment.CommentId}
-	commentModel, err := s.commentQueryBuilder.SingleQuery().FindOne(commentFilter, nil)
+	commentModel, err := s.commentQueryBuilder.SingleQuery(ctx).FindOne(commentFilter, nil)
 	if err != nil {
-		s.logger.Error("Failed to find comment - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to find comment - %v", err)
 		return nil, network.NewNotFoundError(
 			"Comment not found",
 			fmt.Sprintf("Comment with ID '%s' not found, it may have been deleted or the ID is incorrect", comment.CommentId),
@@ -430,21 +524,21 @@ func (s *commentService) CreatePostCommentReply(userId string, comment *dto.Crea
 
 	switch commentModel.Status {
 	case model.CommentStatusDeleted:
-		s.logger.Error("Comment is deleted")
+		s.logger.WithContext(ctx).Error("Comment is deleted")
 		return nil, network.NewForbiddenError(
 			"Comment is deleted",
 			fmt.Sprintf("Comment with ID '%s' is deleted. It cannot be replied to.", comment.CommentId),
 			fmt.Errorf("comment %s is deleted", comment.CommentId),
 		)
 	case model.CommentStatusHidden:
-		s.logger.Error("Comment is hidden")
+		s.logger.WithContext(ctx).Error("Comment is hidden")
 		return nil, network.NewForbiddenError(
 			"Comment is hidden",
 			fmt.Sprintf("Comment with ID '%s' is hidden. It cannot be replied to.", comment.CommentId),
 			fmt.Errorf("comment %s is hidden", comment.CommentId),
 		)
 	case model.CommentStatusRemoved:
-		s.logger.Error("Comment is removed")
+		s.logger.WithContext(ctx).Error("Comment is removed")
 		return nil, network.NewForbiddenError(
 			"Comment is removed",
 			fmt.Sprintf("Comment with ID '%s' is removed. It cannot be replied to.", comment.CommentId),
@@ -452,7 +546,7 @@ func (s *commentService) CreatePostCommentReply(userId string, comment *dto.Crea
 		)
 
 	case model.CommentStatusArchived:
-		s.logger.Error("Comment is archived")
+		s.logger.WithContext(ctx).Error("Comment is archived")
 		return nil, network.NewForbiddenError(
 			"Comment is archived",
 			fmt.Sprintf("Comment with ID '%s' is archived. It cannot be replied to.", comment.CommentId),
@@ -461,7 +555,7 @@ func (s *commentService) CreatePostCommentReply(userId string, comment *dto.Crea
 	}
 
 	if commentModel.IsDeleted {
-		s.logger.Error("Comment is deleted")
+		s.logger.WithContext(ctx).Error("Comment is deleted")
 		return nil, network.NewForbiddenError(
 			"Comment is deleted",
 			fmt.Sprintf("Comment with ID '%s' is deleted. It cannot be replied to.", comment.CommentId),
@@ -469,15 +563,24 @@ func (s *commentService) CreatePostCommentReply(userId string, comment *dto.Crea
 		)
 	}
 
+	if guardErr := s.guardCommentWrite(ctx, userId, commentModel); guardErr != nil {
+		return nil, guardErr
+	}
+
 	replyComment := model.NewComment(commentModel.PostId, userId, commentModel.CommunityId, comment.Reply, comment.CommentId)
 	replyComment.AddDeviceInfo(comment.DeviceId, comment.DeviceType, comment.DeviceOS, comment.DeviceVersion)
 	replyComment.AddLocationInfo(comment.Country, comment.City, comment.Latitude, comment.Longitude, comment.IpAddress, comment.TimeZone)
 	replyComment.Path = fmt.Sprintf("%s.%s", commentModel.Path, commentModel.CommentId)
 	replyComment.ParentId = commentModel.CommentId
+	replyComment.Mentions = s.resolveMentions(ctx, userId, replyComment.Content)
+	if apiErr := s.attachCommentMedia(ctx, userId, replyComment, comment.MediaIds); apiErr != nil {
+		return nil, apiErr
+	}
 
-	_, err = s.commentQueryBuilder.SingleQuery().InsertOne(replyComment)
+	_, err = s.commentQueryBuilder.SingleQuery(ctx).InsertOne(replyComment)
 	if err != nil {
-		s.logger.Error("Failed to create post comment reply - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to create post comment reply - %v", err)
+		s.releaseCommentMedia(ctx, replyComment)
 		return nil, network.NewInternalServerError(
 			"Failed to create comment reply",
 			fmt.Sprintf("Failed to create comment reply - %s Context - [Query Failed]", err),
@@ -485,6 +588,7 @@ func (s *commentService) CreatePostCommentReply(userId string, comment *dto.Crea
 			err,
 		)
 	}
+	metrics.CommentCreated(metrics.CommentKindReply)
 
 	// update the comment with the new reply
 	update := bson.M{
@@ -496,9 +600,9 @@ func (s *commentService) CreatePostCommentReply(userId string, comment *dto.Crea
 			"replyCount": 1,
 		},
 	}
-	_, err = s.commentQueryBuilder.SingleQuery().UpdateOne(commentFilter, update, nil)
+	_, err = s.commentQueryBuilder.SingleQuery(ctx).UpdateOne(commentFilter, update, nil)
 	if err != nil {
-		s.logger.Error("Failed to update post comment with reply - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to update post comment with reply - %v", err)
 		return nil, network.NewInternalServerError(
 			"Failed to update comment with reply",
 			fmt.Sprintf("Failed to update comment with reply - %s Context - [Query Failed]", err),
@@ -507,14 +611,22 @@ func (s *commentService) CreatePostCommentReply(userId string, comment *dto.Crea
 		)
 	}
 
+	s.notifyReply(ctx, commentModel.AuthorId, replyComment, push.Notification{
+		Title: "New reply to your comment",
+		Body:  replyComment.Content,
+		Data:  map[string]string{"type": "comment_reply", "postId": replyComment.PostId, "commentId": replyComment.CommentId},
+	})
 	return replyComment, nil
 }
 
-func (s *commentService) EditPostCommentReply(userId string, commentId string, comment *dto.EditCommentReplyRequest) (*model.Comment, network.ApiError) {
+func (s *commentService) EditPostCommentReply(ctx context.Context, userId string, commentId string, comment *dto.EditCommentReplyRequest) (*model.Comment, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommentService.EditPostCommentReply")
+	defer span.End()
+
 	filter := bson.M{"commentId": commentId}
-	commentModel, err := s.commentQueryBuilder.SingleQuery().FindOne(filter, nil)
+	commentModel, err := s.commentQueryBuilder.SingleQuery(ctx).FindOne(filter, nil)
 	if err != nil {
-		s.logger.Error("Failed to find comment - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to find comment - %v", err)
 		return nil, network.NewNotFoundError(
 			"Comment not found",
 			fmt.Sprintf("Comment with ID '%s' not found, it may have been deleted or the ID is incorrect", commentId),
@@ -523,28 +635,42 @@ func (s *commentService) EditPostCommentReply(userId string, commentId string, c
 	}
 
 	if commentModel.AuthorId != userId {
-		s.logger.Error("User is not authorized to edit this comment")
+		s.logger.WithContext(ctx).Error("User is not authorized to edit this comment")
 		return nil, network.NewForbiddenError(
 			"User is not authorized to edit this comment",
 			fmt.Sprintf("User with ID '%s' is not authorized to edit comment with ID '%s', since it was created by user '%s'", userId, commentId, commentModel.AuthorId),
 			fmt.Errorf("user %s is not authorized to edit comment %s", userId, commentId),
 		)
 	}
+	if guardErr := s.guardCommentWrite(ctx, userId, commentModel); guardErr != nil {
+		return nil, guardErr
+	}
 
+	// Saving the same content again is not a revision
+	if comment.Reply == commentModel.Content {
+		return commentModel, nil
+	}
+
+	edit := model.NewCommentEdit(userId, commentModel.Content, comment.Reply)
 	commentModel.Content = comment.Reply
 	commentModel.ParentId = comment.CommentId
+	commentModel.IsEdited = true
+	commentModel.LastEditedAt = &edit.EditedAt
+	commentModel.UpdatedAt = edit.EditedAt
 	update := bson.M{
 		"$set": bson.M{
-			"status":    model.CommentStatusActive,
-			"isEdited":  true,
-			"content":   commentModel.Content,
-			"parentId":  commentModel.ParentId,
-			"updatedAt": commentModel.UpdatedAt,
+			"status":       model.CommentStatusActive,
+			"isEdited":     true,
+			"content":      commentModel.Content,
+			"parentId":     commentModel.ParentId,
+			"lastEditedAt": edit.EditedAt,
+			"updatedAt":    commentModel.UpdatedAt,
 		},
+		"$push": bson.M{"editHistory": edit},
 	}
-	_, err = s.commentQueryBuilder.SingleQuery().UpdateOne(filter, update, nil)
+	_, err = s.commentQueryBuilder.SingleQuery(ctx).UpdateOne(filter, update, nil)
 	if err != nil {
-		s.logger.Error("Failed to update post comment reply - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to update post comment reply - %v", err)
 		return nil, network.NewInternalServerError(
 			"Failed to update comment reply",
 			fmt.Sprintf("Failed to update comment reply - %s Context - [Query Failed]", err),
@@ -556,11 +682,14 @@ func (s *commentService) EditPostCommentReply(userId string, commentId string, c
 	return commentModel, nil
 }
 
-func (s *commentService) DeletePostCommentReply(userId string, commentId string) network.ApiError {
+func (s *commentService) DeletePostCommentReply(ctx context.Context, userId string, commentId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "CommentService.DeletePostCommentReply")
+	defer span.End()
+
 	filter := bson.M{"commentId": commentId}
-	commentModel, err := s.commentQueryBuilder.SingleQuery().FindOne(filter, nil)
+	commentModel, err := s.commentQueryBuilder.SingleQuery(ctx).FindOne(filter, nil)
 	if err != nil {
-		s.logger.Error("Failed to find comment - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to find comment - %v", err)
 		return network.NewNotFoundError(
 			"Comment not found",
 			fmt.Sprintf("Comment with ID '%s' not found, it may have been deleted or the ID is incorrect", commentId),
@@ -568,14 +697,14 @@ func (s *commentService) DeletePostCommentReply(userId string, commentId string)
 		)
 	}
 	if commentModel.AuthorId != userId {
-		s.logger.Error("User is not authorized to delete this comment")
+		s.logger.WithContext(ctx).Error("User is not authorized to delete this comment")
 		return network.NewForbiddenError(
 			"User is not authorized to delete this comment",
 			fmt.Sprintf("User with ID '%s' is not authorized to delete comment with ID '%s', since it was created by user '%s'", userId, commentId, commentModel.AuthorId),
 			fmt.Errorf("user %s is not authorized to delete comment %s", userId, commentId),
 		)
 	}
-	_, err = s.commentQueryBuilder.SingleQuery().UpdateOne(
+	_, err = s.commentQueryBuilder.SingleQuery(ctx).UpdateOne(
 		bson.M{"commentId": commentId},
 		bson.M{
 			"$set": bson.M{
@@ -588,7 +717,7 @@ func (s *commentService) DeletePostCommentReply(userId string, commentId string)
 		nil,
 	)
 	if err != nil {
-		s.logger.Error("Failed to delete post comment reply - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to delete post comment reply - %v", err)
 		return network.NewInternalServerError(
 			"Failed to delete comment reply",
 			fmt.Sprintf("Failed to delete comment reply - %s Context[ Query Failed : %v]", filter, err),
@@ -596,8 +725,9 @@ func (s *commentService) DeletePostCommentReply(userId string, commentId string)
 			err,
 		)
 	}
+	s.releaseCommentMedia(ctx, commentModel)
 
-	_, err = s.commentQueryBuilder.SingleQuery().UpdateOne(
+	_, err = s.commentQueryBuilder.SingleQuery(ctx).UpdateOne(
 		bson.M{"commentId": commentModel.ParentId},
 		bson.M{
 			"$set": bson.M{
@@ -611,7 +741,7 @@ func (s *commentService) DeletePostCommentReply(userId string, commentId string)
 		nil,
 	)
 	if err != nil {
-		s.logger.Error("Failed to update post comment reply - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to update post comment reply - %v", err)
 		return network.NewInternalServerError(
 			"Failed to update comment reply",
 			fmt.Sprintf("Failed to update comment reply - %s Context[ Query Failed : %v]", filter, err),
@@ -622,19 +752,31 @@ func (s *commentService) DeletePostCommentReply(userId string, commentId string)
 	return nil
 }
 
-func (s *commentService) LikePostComment(userId string, commentId string) (*bool, *int, network.ApiError) {
-	err := s.toggleCommentInteraction(userId, commentId, model.CommentInteractionTypeLike)
+func (s *commentService) LikePostComment(ctx context.Context, userId string, commentId string) (*bool, *int, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommentService.LikePostComment")
+	defer span.End()
+
+	commentModel, findErr := s.commentQueryBuilder.SingleQuery(ctx).FindOne(bson.M{"commentId": commentId}, nil)
+	if findErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to find comment - %v", findErr)
+		return nil, nil, NewCommentNotFoundError(commentId)
+	}
+	if guardErr := s.guardCommentWrite(ctx, userId, commentModel); guardErr != nil {
+		return nil, nil, guardErr
+	}
+
+	err := s.toggleCommentInteraction(ctx, userId, commentId, model.CommentInteractionTypeLike)
 	if err != nil {
-		s.logger.Error("Failed to like post comment - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to like post comment - %v", err)
 		return nil, nil, err
 	}
 
-	commentSynergy, mongoErr := s.commentQueryBuilder.SingleQuery().FindOne(
+	commentSynergy, mongoErr := s.commentQueryBuilder.SingleQuery(ctx).FindOne(
 		bson.M{"commentId": commentId},
 		options.FindOne().SetProjection(bson.M{"synergy": -1}),
 	)
 	if mongoErr != nil {
-		s.logger.Error("Failed to get comment synergy - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to get comment synergy - %v", err)
 		return nil, nil, network.NewInternalServerError(
 			"Failed to get comment synergy",
 			fmt.Sprintf("Failed to get comment synergy - %s Context - [Query Failed]", err),
@@ -643,17 +785,17 @@ func (s *commentService) LikePostComment(userId string, commentId string) (*bool
 		)
 	}
 
-	commentInteraction, mongoErr := s.commentInteractionQueryBuilder.SingleQuery().FindOne(
+	commentInteraction, mongoErr := s.commentInteractionQueryBuilder.SingleQuery(ctx).FindOne(
 		bson.M{"commentId": commentId, "userId": userId},
 		options.FindOne().SetProjection(bson.M{"interactionType": 1}),
 	)
 	if mongoErr != nil {
 		if mongo.IsNoDocumentFoundError(mongoErr) {
-			s.logger.Error("Comment interaction not found - %v", err)
+			s.logger.WithContext(ctx).Error("Comment interaction not found - %v", err)
 			falseValue := false
 			return &falseValue, &commentSynergy.Synergy, nil
 		}
-		s.logger.Error("Failed to get comment interaction - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to get comment interaction - %v", err)
 		return nil, nil, network.NewInternalServerError(
 			"Failed to get comment interaction",
 			fmt.Sprintf("Failed to get comment interaction - %s Context - [Query Failed]", err),
@@ -677,19 +819,31 @@ func (s *commentService) LikePostComment(userId string, commentId string) (*bool
 	return isLiked, &commentSynergy.Synergy, nil
 }
 
-func (s *commentService) DislikePostComment(userId string, commentId string) (*bool, *int, network.ApiError) {
-	err := s.toggleCommentInteraction(userId, commentId, model.CommentInteractionTypeDislike)
+func (s *commentService) DislikePostComment(ctx context.Context, userId string, commentId string) (*bool, *int, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommentService.DislikePostComment")
+	defer span.End()
+
+	commentModel, findErr := s.commentQueryBuilder.SingleQuery(ctx).FindOne(bson.M{"commentId": commentId}, nil)
+	if findErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to find comment - %v", findErr)
+		return nil, nil, NewCommentNotFoundError(commentId)
+	}
+	if guardErr := s.guardCommentWrite(ctx, userId, commentModel); guardErr != nil {
+		return nil, nil, guardErr
+	}
+
+	err := s.toggleCommentInteraction(ctx, userId, commentId, model.CommentInteractionTypeDislike)
 	if err != nil {
-		s.logger.Error("Failed to dislike post comment - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to dislike post comment - %v", err)
 		return nil, nil, err
 	}
 
-	commentSynergy, mongoErr := s.commentQueryBuilder.SingleQuery().FindOne(
+	commentSynergy, mongoErr := s.commentQueryBuilder.SingleQuery(ctx).FindOne(
 		bson.M{"commentId": commentId},
 		options.FindOne().SetProjection(bson.M{"synergy": -1}),
 	)
 	if mongoErr != nil {
-		s.logger.Error("Failed to get comment synergy - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to get comment synergy - %v", err)
 		return nil, nil, network.NewInternalServerError(
 			"Failed to get comment synergy",
 			fmt.Sprintf("Failed to get comment synergy - %s Context - [Query Failed]", err),
@@ -698,17 +852,17 @@ func (s *commentService) DislikePostComment(userId string, commentId string) (*b
 		)
 	}
 
-	commentInteraction, mongoErr := s.commentInteractionQueryBuilder.SingleQuery().FindOne(
+	commentInteraction, mongoErr := s.commentInteractionQueryBuilder.SingleQuery(ctx).FindOne(
 		bson.M{"commentId": commentId, "userId": userId},
 		options.FindOne().SetProjection(bson.M{"interactionType": 1}),
 	)
 	if mongoErr != nil {
 		if mongo.IsNoDocumentFoundError(mongoErr) {
-			s.logger.Error("Comment interaction not found - %v", err)
+			s.logger.WithContext(ctx).Error("Comment interaction not found - %v", err)
 			falseValue := false
 			return &falseValue, &commentSynergy.Synergy, nil
 		}
-		s.logger.Error("Failed to get comment interaction - %v", err)
+		s.logger.WithContext(ctx).Error("Failed to get comment interaction - %v", err)
 		return nil, nil, network.NewInternalServerError(
 			"Failed to get comment interaction",
 			fmt.Sprintf("Failed to get comment interaction - %s Context - [Query Failed]", err),
@@ -733,16 +887,118 @@ func (s *commentService) DislikePostComment(userId string, commentId string) (*b
 	return isDisliked, &commentSynergy.Synergy, nil
 }
 
-func (s *commentService) toggleCommentInteraction(userId string, commentId string, interactionType model.CommentInteractionType) network.ApiError {
+// userReactionExpr resolves the reaction userId left on a comment from its looked up interactions
+func userReactionExpr(userId string) bson.M {
+	return bson.M{
+		"$arrayElemAt": bson.A{
+			bson.M{"$map": bson.M{
+				"input": bson.M{"$filter": bson.M{
+					"input": "$interactions",
+					"as":    "interaction",
+					"cond": bson.M{"$and": bson.A{
+						bson.M{"$eq": bson.A{"$$interaction.userId", userId}},
+						bson.M{"$eq": bson.A{"$$interaction.interactionType", model.CommentInteractionTypeReaction}},
+					}},
+				}},
+				"as": "interaction",
+				"in": "$$interaction.reaction",
+			}},
+			0,
+		},
+	}
+}
+
+// notifyReply pushes a new comment to the author of what it answers, in the background so the request isn't held up by the providers
+func (s *commentService) notifyReply(ctx context.Context, recipientId string, reply *model.Comment, notification push.Notification) {
+	if recipientId == "" || recipientId == reply.AuthorId {
+		return
+	}
+	background.Go(func() {
+		if err := s.pushService.NotifyUser(context.WithoutCancel(ctx), recipientId, notification); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to push comment %s to user %s - %v", reply.CommentId, recipientId, err)
+		}
+	})
+}
+
+// resolveMentions returns the ids of the users mentioned in a new comment, other than its author. A failed
+// lookup only loses the mentions, the comment is still created.
+func (s *commentService) resolveMentions(ctx context.Context, authorId string, content string) []string {
+	usernames := model.ParseMentions(content)
+	if len(usernames) == 0 {
+		return nil
+	}
+	users, err := s.userQueryBuilder.SingleQuery(ctx).FilterMany(
+		bson.M{"username": bson.M{"$in": usernames}, "userId": bson.M{"$ne": authorId}},
+		options.Find().SetProjection(bson.M{"userId": 1}).SetLimit(model.MaxMentions),
+	)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to resolve mentions of user %s - %v", authorId, err)
+		return nil
+	}
+	userIds := make([]string, 0, len(users))
+	for _, mentioned := range users {
+		userIds = append(userIds, mentioned.UserId)
+	}
+	return userIds
+}
+
+// attachCommentMedia attaches pre-uploaded media of the author to a comment that is about to be inserted
+func (s *commentService) attachCommentMedia(ctx context.Context, userId string, commentModel *model.Comment, mediaIds []string) network.ApiError {
+	if len(mediaIds) == 0 {
+		return nil
+	}
+	attached, apiErr := s.mediaLibraryService.AttachMedia(ctx, userId, mediaIds, mediaModel.NewMediaReference(mediaModel.MediaReferenceComment, commentModel.CommentId))
+	if apiErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to attach media to comment - %v", apiErr)
+		return apiErr
+	}
+	for _, media := range attached {
+		commentModel.Media = append(commentModel.Media, model.CommentMedia{
+			Id:     media.MediaId,
+			Type:   string(media.Type),
+			Url:    media.Url,
+			Width:  media.Width,
+			Height: media.Height,
+
+			Renditions:    media.Renditions,
+			BlurHash:      media.BlurHash,
+			DominantColor: media.DominantColor,
+		})
+	}
+	commentModel.HasMedia = len(commentModel.Media) > 0
+	return nil
+}
+
+// releaseCommentMedia drops the media references of a comment that was deleted or never saved
+func (s *commentService) releaseCommentMedia(ctx context.Context, commentModel *model.Comment) {
+	if !commentModel.HasMedia {
+		return
+	}
+	if apiErr := s.mediaLibraryService.ReleaseMedia(ctx, mediaModel.MediaReferenceComment, commentModel.CommentId); apiErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to release comment media - %v", apiErr)
+	}
+}
+
+// guardCommentWrite loads the post a comment belongs to and runs the write through the content guard
+func (s *commentService) guardCommentWrite(ctx context.Context, userId string, commentModel *model.Comment) network.ApiError {
+	postModel, err := s.postQueryBuilder.SingleQuery(ctx).FindOne(bson.M{"postId": commentModel.PostId}, nil)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to find post - %v", err)
+		return NewPostNotFoundError(commentModel.PostId)
+	}
+	return s.contentGuard.CheckCommentWrite(ctx, userId, postModel, commentModel)
+}
+
+func (s *commentService) toggleCommentInteraction(ctx context.Context, userId string, commentId string, interactionType model.CommentInteractionType) network.ApiError {
 	action := "liking"
 	if interactionType == model.CommentInteractionTypeDislike {
 		action = "disliking"
 	}
-	s.logger.Info("%s comment with ID: %s by user: %s", action, commentId, userId)
-	tx := s.transaction.GetTransaction(mongo.DefaultShortTransactionTimeout)
+	s.logger.WithContext(ctx).Info("%s comment with ID: %s by user: %s", action, commentId, userId)
+	tx := s.transaction.GetTransaction(ctx, mongo.DefaultShortTransactionTimeout)
 
 	if err := tx.Start(); err != nil {
-		s.logger.Error("Failed to start transaction: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to start transaction: %v", err)
 		return network.NewInternalServerError(
 			"Failed to start transaction",
 			fmt.Sprintf("Starting transaction failed - %s Context - [Transaction Failed]", err),
@@ -755,11 +1011,11 @@ func (s *commentService) toggleCommentInteraction(userId string, commentId strin
 	defer func() {
 		if txErr != nil {
 			if abortErr := tx.Abort(); abortErr != nil {
-				s.logger.Error("Failed to abort transaction: %v", abortErr)
+				s.logger.WithContext(ctx).Error("Failed to abort transaction: %v", abortErr)
 			}
 		} else {
 			if commitErr := tx.Commit(); commitErr != nil {
-				s.logger.Error("Failed to commit transaction: %v", commitErr)
+				s.logger.WithContext(ctx).Error("Failed to commit transaction: %v", commitErr)
 				txErr = commitErr
 			}
 		}
@@ -770,7 +1026,7 @@ func (s *commentService) toggleCommentInteraction(userId string, commentId strin
 		bson.M{"commentId": commentId, "status": model.CommentStatusActive},
 	)
 	if txErr != nil {
-		s.logger.Error("Failed to get comment: %v", txErr)
+		s.logger.WithContext(ctx).Error("Failed to get comment: %v", txErr)
 		return network.NewInternalServerError(
 			"Failed to get comment",
 			fmt.Sprintf("Failed to get comment - %s Context - [Query Failed]", txErr),
@@ -781,7 +1037,7 @@ func (s *commentService) toggleCommentInteraction(userId string, commentId strin
 	var comment *model.Comment
 	result.Decode(&comment)
 	if comment == nil {
-		s.logger.Error("Comment not found")
+		s.logger.WithContext(ctx).Error("Comment not found")
 		return network.NewNotFoundError(
 			"Comment not found",
 			fmt.Sprintf("Comment with ID '%s' not found, it may have been deleted or the ID is incorrect", commentId),
@@ -790,7 +1046,7 @@ func (s *commentService) toggleCommentInteraction(userId string, commentId strin
 	}
 
 	if result.IsNotFound() {
-		s.logger.Error("Comment not found")
+		s.logger.WithContext(ctx).Error("Comment not found")
 		return network.NewNotFoundError(
 			"Comment not found",
 			fmt.Sprintf("Comment with ID '%s' not found, it may have been deleted or the ID is incorrect", commentId),
@@ -810,7 +1066,7 @@ func (s *commentService) toggleCommentInteraction(userId string, commentId strin
 		},
 	)
 	if txErr != nil {
-		s.logger.Error("Failed to get comment interactions: %v", txErr)
+		s.logger.WithContext(ctx).Error("Failed to get comment interactions: %v", txErr)
 		return network.NewInternalServerError(
 			"Failed to get comment interactions",
 			fmt.Sprintf("Failed to get comment interactions - %s Context - [Query Failed]", txErr),
@@ -827,14 +1083,14 @@ func (s *commentService) toggleCommentInteraction(userId string, commentId strin
 	}
 	if commentResult.Err() != nil {
 		if mongo.IsNoDocumentFoundError(commentResult.Err()) {
-			s.logger.Error("Comment interaction not found - %v", commentResult.Err())
+			s.logger.WithContext(ctx).Error("Comment interaction not found - %v", commentResult.Err())
 			return network.NewNotFoundError(
 				"Comment interaction not found",
 				fmt.Sprintf("Comment interaction with ID '%s' not found, it may have been deleted or the ID is incorrect", commentId),
 				nil,
 			)
 		}
-		s.logger.Error("Failed to get comment interactions: %v", commentResult.Err())
+		s.logger.WithContext(ctx).Error("Failed to get comment interactions: %v", commentResult.Err())
 		return network.NewInternalServerError(
 			"Failed to get comment interactions",
 			fmt.Sprintf("Failed to get comment interactions - %s Context - [Query Failed]", commentResult.Err()),
@@ -878,13 +1134,13 @@ func (s *commentService) toggleCommentInteraction(userId string, commentId strin
 		}
 	} else {
 		// Clean up duplicate interactions
-		s.logger.Warn("Multiple interactions found for user %s on comment %s - cleaning up", userId, commentId)
+		s.logger.WithContext(ctx).Warn("Multiple interactions found for user %s on comment %s - cleaning up", userId, commentId)
 		_, txErr = tx.DeleteMany(
 			model.CommentInteractionCollectionName,
 			bson.M{"commentId": commentId, "userId": userId},
 		)
 		if txErr != nil {
-			s.logger.Error("Failed to clean up duplicate interactions: %v", txErr)
+			s.logger.WithContext(ctx).Error("Failed to clean up duplicate interactions: %v", txErr)
 			return network.NewInternalServerError(
 				"Failed to clean up duplicate interactions",
 				fmt.Sprintf("Failed to clean up duplicate interactions - %s Context - [Query Failed]", txErr),
@@ -911,7 +1167,7 @@ func (s *commentService) toggleCommentInteraction(userId string, commentId strin
 	)
 
 	if updateResult != nil {
-		s.logger.Error("Failed to update comment synergy: %v", updateResult)
+		s.logger.WithContext(ctx).Error("Failed to update comment synergy: %v", updateResult)
 		txErr = updateResult
 		return network.NewInternalServerError(
 			"Failed to update comment",
@@ -929,7 +1185,7 @@ func (s *commentService) toggleCommentInteraction(userId string, commentId strin
 			bson.M{"_id": objID},
 		)
 		if txErr != nil {
-			s.logger.Error("Failed to remove existing interaction: %v", txErr)
+			s.logger.WithContext(ctx).Error("Failed to remove existing interaction: %v", txErr)
 			return network.NewInternalServerError(
 				"Failed to update interaction",
 				fmt.Sprintf("Failed to update interaction - %s Context - [Query Failed]", txErr),
@@ -945,10 +1201,10 @@ func (s *commentService) toggleCommentInteraction(userId string, commentId strin
 		_, txErr := tx.InsertOne(model.CommentInteractionCollectionName, commentInteraction)
 		if txErr != nil {
 			if mongo.IsDuplicateKeyError(txErr) {
-				s.logger.Warn("Comment interaction already exists (race condition): %v", txErr)
+				s.logger.WithContext(ctx).Warn("Comment interaction already exists (race condition): %v", txErr)
 				txErr = nil
 			} else {
-				s.logger.Error("Failed to insert comment interaction: %v", txErr)
+				s.logger.WithContext(ctx).Error("Failed to insert comment interaction: %v", txErr)
 				return network.NewInternalServerError(
 					"Failed to insert interaction",
 					fmt.Sprintf("Failed to insert interaction - %s Context - [Query Failed]", txErr),
@@ -959,17 +1215,17 @@ func (s *commentService) toggleCommentInteraction(userId string, commentId strin
 		}
 	}
 
-	s.logger.Info("Comment interaction updated successfully for comment ID: %s", commentId)
+	s.logger.WithContext(ctx).Info("Comment interaction updated successfully for comment ID: %s", commentId)
 	return nil
 }
 
-func (s *commentService) GetUserComments(userId string, page int, limit int) ([]*model.PublicGetComment, network.ApiError) {
-	s.logger.Debug("GetMyUserComments - userId: %s, page: %d, limit: %d", userId, page, limit)
-	aggregate := s.commentAggregateBuilder.SingleAggregate()
+func (s *commentService) GetUserComments(ctx context.Context, userId string, cursor string, limit int) ([]*model.PublicGetComment, *mongo.CursorResult, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommentService.GetUserComments")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Debug("GetMyUserComments - userId: %s, limit: %d", userId, limit)
+	aggregate := s.commentAggregateBuilder.SingleAggregate(ctx)
 	aggregate.Match(bson.M{"authorId": userId})
-	aggregate.Sort(bson.D{{Key: "createdAt", Value: -1}, {Key: "synergy", Value: -1}})
-	aggregate.Limit(int64(limit))
-	aggregate.Skip(int64((page - 1) * limit))
 	aggregate.Lookup("users", "authorId", "userId", "author")
 	aggregate.Lookup("communities", "communityId", "communityId", "community")
 
@@ -1007,6 +1263,7 @@ func (s *commentService) GetUserComments(userId string, page int, limit int) ([]
 
 	aggregate.AddFields(bson.M{
 		"userInteraction": bson.M{"$arrayElemAt": bson.A{"$userInteractions", 0}},
+		"myReaction":      userReactionExpr(userId),
 	}) // Project fields including isLiked/isDisliked flags
 	aggregate.Project(bson.M{
 		"id":       "$commentId",
@@ -1055,22 +1312,28 @@ func (s *commentService) GetUserComments(userId string, page int, limit int) ([]
 		"synergy":          1,
 		"replyCount":       1,
 		"reactionCounts":   1,
+		"myReaction":       1,
 		"level":            1,
 		"isEdited":         1,
+		"lastEditedAt":     1,
 		"isPinned":         1,
 		"isStickied":       1,
 		"isLocked":         1,
 		"isDeleted":        1,
 		"isRemoved":        1,
 		"hasMedia":         1,
+		"media":            1,
 		"mentions":         1,
 		"path":             1,
 		"createdAt":        1,
 	})
-	comments, err := aggregate.Exec()
+	comments, page, err := aggregate.ExecCursorPaginated(mongo.NewCursorQuery("createdAt", int64(limit), cursor))
 	if err != nil {
-		s.logger.Error("Failed to get my comments - %v", err)
-		return nil, network.NewInternalServerError(
+		if mongo.IsInvalidCursorError(err) {
+			return nil, nil, NewInvalidCursorError(cursor)
+		}
+		s.logger.WithContext(ctx).Error("Failed to get my comments - %v", err)
+		return nil, nil, network.NewInternalServerError(
 			"Failed to get comments",
 			fmt.Sprintf("It seems the comments for user '%s' could not be retrieved - Aggregation failed. Please try again later. [Context: userId=%s]", userId, userId),
 			network.DB_ERROR,
@@ -1079,8 +1342,301 @@ func (s *commentService) GetUserComments(userId string, page int, limit int) ([]
 	}
 
 	if len(comments) == 0 {
-		return []*model.PublicGetComment{}, nil
+		return []*model.PublicGetComment{}, page, nil
+	} else {
+		return comments, page, nil
+	}
+}
+
+// GetCommentRevisions returns the edit history of a comment, newest first. Only the author
+// and moderators with the view_mod_log permission can read it.
+func (s *commentService) GetCommentRevisions(ctx context.Context, userId string, commentId string) ([]model.CommentEdit, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommentService.GetCommentRevisions")
+	defer span.End()
+
+	commentModel, err := s.commentQueryBuilder.SingleQuery(ctx).FindOne(
+		bson.M{"commentId": commentId},
+		options.FindOne().SetProjection(bson.M{"commentId": 1, "authorId": 1, "communityId": 1, "editHistory": 1}),
+	)
+	if err != nil {
+		if mongo.IsNoDocumentFoundError(err) {
+			return nil, NewCommentNotFoundError(commentId)
+		}
+		s.logger.WithContext(ctx).Error("Failed to find comment - %v", err)
+		return nil, NewDBError("finding comment", err.Error())
+	}
+
+	if commentModel.AuthorId != userId {
+		canView, modErr := s.moderatorService.HasModeratorPermission(ctx, userId, commentModel.CommunityId, moderatorModel.PermissionViewModLog)
+		if modErr != nil {
+			return nil, modErr
+		}
+		if !canView {
+			return nil, NewForbiddenError("view revisions of", userId, commentId)
+		}
+	}
+
+	revisions := make([]model.CommentEdit, 0, len(commentModel.EditHistory))
+	for i := len(commentModel.EditHistory) - 1; i >= 0; i-- {
+		revisions = append(revisions, commentModel.EditHistory[i])
+	}
+	return revisions, nil
+}
+
+// SetCommentReaction sets or changes the caller's reaction on a comment. The interaction and the
+// comment's reaction counts are updated in one transaction so a user only ever holds one reaction.
+func (s *commentService) SetCommentReaction(ctx context.Context, userId string, commentId string, reaction model.ReactionType) (*common.ReactionResponse, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommentService.SetCommentReaction")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Setting reaction %s on comment %s by user %s", reaction, commentId, userId)
+	commentModel, findErr := s.commentQueryBuilder.SingleQuery(ctx).FindOne(bson.M{"commentId": commentId, "status": model.CommentStatusActive}, nil)
+	if findErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to find comment - %v", findErr)
+		return nil, NewCommentNotFoundError(commentId)
+	}
+	if guardErr := s.guardCommentWrite(ctx, userId, commentModel); guardErr != nil {
+		return nil, guardErr
+	}
+
+	tx := s.transaction.GetTransaction(ctx, mongo.DefaultShortTransactionTimeout)
+	err := tx.PerformSingleTransaction(func(session mongo.TransactionSession) error {
+		return s.applyCommentReaction(ctx, session, userId, commentId, reaction)
+	})
+	if err != nil {
+		return nil, s.reactionTransactionError(ctx, err, userId, commentId)
+	}
+
+	counts, countErr := s.getCommentReactionCounts(ctx, commentId)
+	if countErr != nil {
+		return nil, countErr
+	}
+	return common.NewReactionResponse(reaction, counts), nil
+}
+
+// applyCommentReaction records the reaction of the user in the session, replacing the one the user held
+// and moving the comment counts from the old reaction to the new one
+func (s *commentService) applyCommentReaction(ctx context.Context, session mongo.TransactionSession, userId string, commentId string, reaction model.ReactionType) error {
+	interactionCollection := session.Collection(model.CommentInteractionCollectionName)
+	existing, lookupErr := s.findCommentReaction(ctx, session, userId, commentId)
+	if lookupErr != nil {
+		return lookupErr
+	}
+	if existing != nil && existing.Reaction == reaction {
+		return nil
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	inc := bson.M{"reactionCounts." + string(reaction): 1}
+	if existing != nil {
+		inc["reactionCounts."+string(existing.Reaction)] = -1
+		_, updateErr := interactionCollection.UpdateOne(
+			bson.M{"_id": existing.Id},
+			bson.M{"$set": bson.M{"reaction": reaction, "updatedAt": now}},
+		)
+		if updateErr != nil {
+			s.logger.WithContext(ctx).Error("Failed to change comment reaction: %v", updateErr)
+			return network.NewInternalServerError(
+				"Failed to update reaction",
+				fmt.Sprintf("Failed to change reaction for user %s on comment %s. Context - [ Update Failed ]", userId, commentId),
+				network.DB_ERROR,
+				updateErr)
+		}
 	} else {
-		return comments, nil
+		_, insertErr := interactionCollection.InsertOne(model.NewCommentReaction(userId, commentId, reaction))
+		if insertErr != nil {
+			if mongo.IsDuplicateKeyError(insertErr) {
+				return network.NewConflictError(
+					"Reaction already being updated",
+					fmt.Sprintf("Another reaction for user %s on comment %s was recorded concurrently. Please retry. [Context: commentId=%s]", userId, commentId, commentId),
+					insertErr)
+			}
+			s.logger.WithContext(ctx).Error("Failed to insert comment reaction: %v", insertErr)
+			return network.NewInternalServerError(
+				"Failed to insert reaction",
+				fmt.Sprintf("Failed to record reaction for user %s on comment %s. Context - [ Insert Failed ]", userId, commentId),
+				network.DB_ERROR,
+				insertErr)
+		}
+	}
+
+	_, updateErr := session.Collection(model.CommentCollectionName).UpdateOne(
+		bson.M{"commentId": commentId},
+		bson.M{"$inc": inc, "$set": bson.M{"updatedAt": now}},
+	)
+	if updateErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to update comment reaction counts: %v", updateErr)
+		return network.NewInternalServerError(
+			"Failed to update comment",
+			fmt.Sprintf("Failed to update reaction counts for comment %s. Context - [ Update Failed ]", commentId),
+			network.DB_ERROR,
+			updateErr)
+	}
+	return nil
+}
+
+// RemoveCommentReaction removes the caller's reaction from a comment, it is a no-op when there is none
+func (s *commentService) RemoveCommentReaction(ctx context.Context, userId string, commentId string) (*common.ReactionResponse, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommentService.RemoveCommentReaction")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Removing reaction on comment %s by user %s", commentId, userId)
+	commentModel, findErr := s.commentQueryBuilder.SingleQuery(ctx).FindOne(bson.M{"commentId": commentId, "status": model.CommentStatusActive}, nil)
+	if findErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to find comment - %v", findErr)
+		return nil, NewCommentNotFoundError(commentId)
+	}
+	if guardErr := s.guardCommentWrite(ctx, userId, commentModel); guardErr != nil {
+		return nil, guardErr
+	}
+
+	tx := s.transaction.GetTransaction(ctx, mongo.DefaultShortTransactionTimeout)
+	err := tx.PerformSingleTransaction(func(session mongo.TransactionSession) error {
+		return s.clearCommentReaction(ctx, session, userId, commentId)
+	})
+	if err != nil {
+		return nil, s.reactionTransactionError(ctx, err, userId, commentId)
+	}
+
+	counts, countErr := s.getCommentReactionCounts(ctx, commentId)
+	if countErr != nil {
+		return nil, countErr
+	}
+	return common.NewReactionResponse("", counts), nil
+}
+
+// clearCommentReaction deletes the reaction of the user in the session and takes it off the comment counts
+func (s *commentService) clearCommentReaction(ctx context.Context, session mongo.TransactionSession, userId string, commentId string) error {
+	existing, lookupErr := s.findCommentReaction(ctx, session, userId, commentId)
+	if lookupErr != nil {
+		return lookupErr
+	}
+	if existing == nil {
+		return nil
+	}
+
+	deleted, deleteErr := session.Collection(model.CommentInteractionCollectionName).DeleteOne(bson.M{"_id": existing.Id})
+	if deleteErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to remove comment reaction: %v", deleteErr)
+		return network.NewInternalServerError(
+			"Failed to remove reaction",
+			fmt.Sprintf("Failed to remove reaction for user %s on comment %s. Context - [ Delete Failed ]", userId, commentId),
+			network.DB_ERROR,
+			deleteErr)
+	}
+	if deleted == 0 {
+		return nil
+	}
+
+	_, updateErr := session.Collection(model.CommentCollectionName).UpdateOne(
+		bson.M{"commentId": commentId},
+		bson.M{
+			"$inc": bson.M{"reactionCounts." + string(existing.Reaction): -1},
+			"$set": bson.M{"updatedAt": primitive.NewDateTimeFromTime(time.Now())},
+		},
+	)
+	if updateErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to update comment reaction counts: %v", updateErr)
+		return network.NewInternalServerError(
+			"Failed to update comment",
+			fmt.Sprintf("Failed to update reaction counts for comment %s. Context - [ Update Failed ]", commentId),
+			network.DB_ERROR,
+			updateErr)
+	}
+	return nil
+}
+
+// GetCommentReactors lists the users who reacted to a comment, newest first, optionally filtered by reaction
+func (s *commentService) GetCommentReactors(ctx context.Context, commentId string, reaction model.ReactionType, cursor string, limit int) ([]*model.PublicCommentReactor, *mongo.CursorResult, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommentService.GetCommentReactors")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Debug("GetCommentReactors - commentId: %s, reaction: %s, limit: %d", commentId, reaction, limit)
+	match := bson.M{"commentId": commentId, "interactionType": model.CommentInteractionTypeReaction}
+	if reaction != "" {
+		match["reaction"] = reaction
+	}
+
+	aggregate := s.reactorAggregateBuilder.SingleAggregate(ctx)
+	aggregate.Match(match)
+	aggregate.Lookup("users", "userId", "userId", "user")
+	aggregate.AddFields(bson.M{
+		"user": bson.M{"$arrayElemAt": bson.A{"$user", 0}},
+	})
+	aggregate.Project(bson.M{
+		"user": bson.M{
+			"userId":     "$user.userId",
+			"username":   "$user.username",
+			"avatar":     "$user.avatar.profile.url",
+			"background": "$user.avatar.background.url",
+			"status":     "$user.status",
+		},
+		"reaction":  1,
+		"reactedAt": "$createdAt",
+		"createdAt": 1,
+	})
+
+	reactors, page, err := aggregate.ExecCursorPaginated(mongo.NewCursorQuery("createdAt", int64(limit), cursor))
+	if err != nil {
+		if mongo.IsInvalidCursorError(err) {
+			return nil, nil, NewInvalidCursorError(cursor)
+		}
+		s.logger.WithContext(ctx).Error("Failed to get comment reactors - %v", err)
+		return nil, nil, network.NewInternalServerError(
+			"Failed to get reactions",
+			fmt.Sprintf("It seems the reactions for comment '%s' could not be retrieved - Aggregation failed. Please try again later. [Context: commentId=%s]", commentId, commentId),
+			network.DB_ERROR,
+			err,
+		)
+	}
+	if reactors == nil {
+		return []*model.PublicCommentReactor{}, page, nil
+	}
+	return reactors, page, nil
+}
+
+func (s *commentService) findCommentReaction(ctx context.Context, session mongo.TransactionSession, userId string, commentId string) (*model.CommentInteraction, network.ApiError) {
+	var existing model.CommentInteraction
+	err := session.Collection(model.CommentInteractionCollectionName).FindOne(bson.M{
+		"commentId":       commentId,
+		"userId":          userId,
+		"interactionType": model.CommentInteractionTypeReaction,
+	}).Decode(&existing)
+	if err != nil {
+		if mongo.IsNoDocumentFoundError(err) {
+			return nil, nil
+		}
+		s.logger.WithContext(ctx).Error("Failed to get comment reaction: %v", err)
+		return nil, network.NewInternalServerError(
+			"Failed to get comment reaction",
+			fmt.Sprintf("Failed to retrieve reaction for user %s on comment %s. Context - [ Query Failed ]", userId, commentId),
+			network.DB_ERROR,
+			err)
+	}
+	return &existing, nil
+}
+
+func (s *commentService) getCommentReactionCounts(ctx context.Context, commentId string) (map[model.ReactionType]int, network.ApiError) {
+	commentModel, err := s.commentQueryBuilder.SingleQuery(ctx).FindOne(
+		bson.M{"commentId": commentId},
+		options.FindOne().SetProjection(bson.M{"reactionCounts": 1}),
+	)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to get comment reaction counts - %v", err)
+		return nil, NewDBError("getting comment reaction counts", err.Error())
+	}
+	return commentModel.ReactionCounts, nil
+}
+
+func (s *commentService) reactionTransactionError(ctx context.Context, err error, userId string, commentId string) network.ApiError {
+	if network.IsApiError(err) {
+		s.logger.WithContext(ctx).Error("Failed to update reaction: %v", err)
+		return network.AsApiError(err)
 	}
+	s.logger.WithContext(ctx).Error("Failed to commit transaction: %v", err)
+	return network.NewInternalServerError(
+		"Failed to commit transaction",
+		fmt.Sprintf("Failed to commit reaction changes for user %s on comment %s. Context - [ Transaction Failed ]", userId, commentId),
+		network.DB_ERROR,
+		err)
 }
@@ -0,0 +1,65 @@
+package csrf
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryToken struct {
+	hash      string
+	expiresAt time.Time
+}
+
+// memoryTokenStore keeps the tokens in the process, they are lost on restart and not shared between
+// instances, so it only suits a single instance
+type memoryTokenStore struct {
+	mu        sync.Mutex
+	tokens    map[string]memoryToken
+	lastSweep time.Time
+}
+
+func newMemoryTokenStore() *memoryTokenStore {
+	return &memoryTokenStore{tokens: make(map[string]memoryToken)}
+}
+
+func (s *memoryTokenStore) save(_ context.Context, sessionId string, tokenHash string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	// Tokens of sessions that are never used again would stay forever, expired ones are swept once per ttl
+	if now.Sub(s.lastSweep) > ttl {
+		for id, token := range s.tokens {
+			if now.After(token.expiresAt) {
+				delete(s.tokens, id)
+			}
+		}
+		s.lastSweep = now
+	}
+	s.tokens[sessionId] = memoryToken{hash: tokenHash, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *memoryTokenStore) get(_ context.Context, sessionId string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.tokens[sessionId]
+	if !ok {
+		return "", nil
+	}
+	if time.Now().After(token.expiresAt) {
+		delete(s.tokens, sessionId)
+		return "", nil
+	}
+	return token.hash, nil
+}
+
+func (s *memoryTokenStore) delete(_ context.Context, sessionId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tokens, sessionId)
+	return nil
+}
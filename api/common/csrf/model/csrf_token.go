@@ -0,0 +1,58 @@
+package model
+
+import (
+	"context"
+
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongod "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"sync-backend/arch/mongo"
+)
+
+const CSRFTokenCollectionName = "csrf_tokens"
+
+// CSRFToken is the synchronizer token of a cookie authenticated session, only its hash is stored
+type CSRFToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	SessionId string             `bson:"sessionId" json:"sessionId" validate:"required"`
+	TokenHash string             `bson:"tokenHash" json:"-" validate:"required"`
+	ExpiresAt primitive.DateTime `bson:"expiresAt" json:"expiresAt"`
+	CreatedAt primitive.DateTime `bson:"createdAt" json:"createdAt"`
+	UpdatedAt primitive.DateTime `bson:"updatedAt" json:"updatedAt"`
+}
+
+func (t *CSRFToken) GetCollectionName() string {
+	return CSRFTokenCollectionName
+}
+
+func (t *CSRFToken) GetValue() *CSRFToken {
+	return t
+}
+
+func (t *CSRFToken) Validate() error {
+	validate := validator.New()
+	return validate.Struct(t)
+}
+
+func (*CSRFToken) EnsureIndexes(db mongo.Database) {
+	indexes := []mongod.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "sessionId", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("idx_csrf_token_session_unique"),
+		},
+		// TTL index removing tokens once they expired
+		{
+			Keys: bson.D{
+				{Key: "expiresAt", Value: 1},
+			},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("ttl_csrf_token_expired"),
+		},
+	}
+
+	mongo.NewQueryBuilder[CSRFToken](db, CSRFTokenCollectionName).Query(context.Background()).CheckIndexes(indexes)
+}
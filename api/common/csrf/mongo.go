@@ -0,0 +1,58 @@
+package csrf
+
+import (
+	"context"
+	"time"
+
+	"sync-backend/api/common/csrf/model"
+	"sync-backend/arch/mongo"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoTokenStore shares the tokens between instances through the database, a TTL index removes
+// them after expiry
+type mongoTokenStore struct {
+	queryBuilder mongo.QueryBuilder[model.CSRFToken]
+}
+
+func newMongoTokenStore(db mongo.Database) *mongoTokenStore {
+	return &mongoTokenStore{
+		queryBuilder: mongo.NewQueryBuilder[model.CSRFToken](db, model.CSRFTokenCollectionName),
+	}
+}
+
+func (s *mongoTokenStore) save(ctx context.Context, sessionId string, tokenHash string, ttl time.Duration) error {
+	now := time.Now()
+	filter := bson.M{"sessionId": sessionId}
+	update := bson.M{
+		"$set": bson.M{
+			"tokenHash": tokenHash,
+			"expiresAt": primitive.NewDateTimeFromTime(now.Add(ttl)),
+			"updatedAt": primitive.NewDateTimeFromTime(now),
+		},
+		"$setOnInsert": bson.M{"createdAt": primitive.NewDateTimeFromTime(now)},
+	}
+	_, err := s.queryBuilder.SingleQuery(ctx).UpdateOne(filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (s *mongoTokenStore) get(ctx context.Context, sessionId string) (string, error) {
+	// The TTL monitor only runs every minute, expired tokens are filtered out until it removed them
+	filter := bson.M{"sessionId": sessionId, "expiresAt": bson.M{"$gt": time.Now()}}
+	token, err := s.queryBuilder.SingleQuery(ctx).FilterOne(filter, nil)
+	if mongo.IsNoDocumentFoundError(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return token.TokenHash, nil
+}
+
+func (s *mongoTokenStore) delete(ctx context.Context, sessionId string) error {
+	_, err := s.queryBuilder.SingleQuery(ctx).DeleteOne(bson.M{"sessionId": sessionId}, nil)
+	return err
+}
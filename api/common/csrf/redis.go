@@ -0,0 +1,40 @@
+package csrf
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"sync-backend/arch/redis"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// redisTokenStore shares the tokens between instances, Redis expires them
+type redisTokenStore struct {
+	client *goredis.Client
+}
+
+func newRedisTokenStore(store redis.Store) *redisTokenStore {
+	return &redisTokenStore{client: store.GetInstance().Client}
+}
+
+func redisTokenKey(sessionId string) string {
+	return "csrf:" + sessionId
+}
+
+func (s *redisTokenStore) save(ctx context.Context, sessionId string, tokenHash string, ttl time.Duration) error {
+	return s.client.Set(ctx, redisTokenKey(sessionId), tokenHash, ttl).Err()
+}
+
+func (s *redisTokenStore) get(ctx context.Context, sessionId string) (string, error) {
+	tokenHash, err := s.client.Get(ctx, redisTokenKey(sessionId)).Result()
+	if errors.Is(err, goredis.Nil) {
+		return "", nil
+	}
+	return tokenHash, err
+}
+
+func (s *redisTokenStore) delete(ctx context.Context, sessionId string) error {
+	return s.client.Del(ctx, redisTokenKey(sessionId)).Err()
+}
@@ -0,0 +1,139 @@
+package csrf
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"sync-backend/arch/config"
+	"sync-backend/arch/mongo"
+	"sync-backend/arch/redis"
+	"sync-backend/arch/tracing"
+	"sync-backend/utils"
+)
+
+// Token storages selectable through auth.csrf.token_storage
+const (
+	StorageMemory   = "in-memory"
+	StorageRedis    = "redis"
+	StorageDatabase = "database"
+)
+
+const (
+	defaultTokenLength = 32
+	defaultTokenExpiry = 15 * time.Minute
+)
+
+// CSRFService issues the synchronizer tokens of cookie authenticated sessions, a session has one
+// token at a time and issuing a new one replaces it
+type CSRFService interface {
+	IssueToken(ctx context.Context, sessionId string) (string, error)
+	// VerifyToken reports whether token is the current, unexpired token of the session
+	VerifyToken(ctx context.Context, sessionId string, token string) (bool, error)
+	RevokeToken(ctx context.Context, sessionId string) error
+}
+
+// tokenStore keeps the hash of the token of each session until it expires, a missing or expired
+// token is returned as ""
+type tokenStore interface {
+	save(ctx context.Context, sessionId string, tokenHash string, ttl time.Duration) error
+	get(ctx context.Context, sessionId string) (string, error)
+	delete(ctx context.Context, sessionId string) error
+}
+
+type csrfService struct {
+	log         utils.AppLogger
+	store       tokenStore
+	tokenLength int
+	tokenExpiry time.Duration
+}
+
+// NewCSRFService creates the service for the storage selected in the config, db and store are only
+// used by their storage. Panics when the storage is misconfigured.
+func NewCSRFService(csrfConfig config.CSRFConfig, db mongo.Database, store redis.Store) CSRFService {
+	var (
+		tokens tokenStore
+		err    error
+	)
+	switch csrfConfig.TokenStorage {
+	case StorageMemory, "":
+		tokens = newMemoryTokenStore()
+	case StorageRedis:
+		tokens = newRedisTokenStore(store)
+	case StorageDatabase:
+		tokens = newMongoTokenStore(db)
+	default:
+		err = fmt.Errorf("unknown token storage %q, expected %s, %s or %s", csrfConfig.TokenStorage, StorageMemory, StorageRedis, StorageDatabase)
+	}
+	if err != nil {
+		panic("Failed to initialize csrf service - Properly configure auth.csrf: " + err.Error())
+	}
+	var tokenExpiry time.Duration
+	if csrfConfig.TokenExpiry != "" {
+		tokenExpiry = utils.ParseSafeDuration(csrfConfig.TokenExpiry)
+	}
+	return newCSRFService(tokens, csrfConfig.TokenLength, tokenExpiry)
+}
+
+func newCSRFService(store tokenStore, tokenLength int, tokenExpiry time.Duration) *csrfService {
+	if tokenLength <= 0 {
+		tokenLength = defaultTokenLength
+	}
+	if tokenExpiry <= 0 {
+		tokenExpiry = defaultTokenExpiry
+	}
+	return &csrfService{
+		log:         utils.NewServiceLogger("CSRFService"),
+		store:       store,
+		tokenLength: tokenLength,
+		tokenExpiry: tokenExpiry,
+	}
+}
+
+func (s *csrfService) IssueToken(ctx context.Context, sessionId string) (string, error) {
+	ctx, span := tracing.Start(ctx, "CSRFService.IssueToken")
+	defer span.End()
+
+	random := make([]byte, s.tokenLength)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(random)
+	if err := s.store.save(ctx, sessionId, hashToken(token), s.tokenExpiry); err != nil {
+		s.log.WithContext(ctx).Error("Failed to save csrf token of session %s: %v", sessionId, err)
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *csrfService) VerifyToken(ctx context.Context, sessionId string, token string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "CSRFService.VerifyToken")
+	defer span.End()
+
+	if token == "" {
+		return false, nil
+	}
+	stored, err := s.store.get(ctx, sessionId)
+	if err != nil || stored == "" {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(hashToken(token))) == 1, nil
+}
+
+func (s *csrfService) RevokeToken(ctx context.Context, sessionId string) error {
+	ctx, span := tracing.Start(ctx, "CSRFService.RevokeToken")
+	defer span.End()
+
+	return s.store.delete(ctx, sessionId)
+}
+
+// hashToken keeps the tokens themselves out of the storage
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
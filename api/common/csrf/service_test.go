@@ -0,0 +1,54 @@
+package csrf
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSRFTokenIsBoundToSession(t *testing.T) {
+	ctx := context.Background()
+	service := newCSRFService(newMemoryTokenStore(), 32, time.Minute)
+
+	token, err := service.IssueToken(ctx, "session-1")
+	require.NoError(t, err)
+	assert.Len(t, token, 43, "32 random bytes in unpadded base64url")
+
+	valid, err := service.VerifyToken(ctx, "session-1", token)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, _ = service.VerifyToken(ctx, "session-2", token)
+	assert.False(t, valid, "tokens are not valid for other sessions")
+	valid, _ = service.VerifyToken(ctx, "session-1", "")
+	assert.False(t, valid)
+
+	replacement, err := service.IssueToken(ctx, "session-1")
+	require.NoError(t, err)
+	valid, _ = service.VerifyToken(ctx, "session-1", token)
+	assert.False(t, valid, "issuing replaces the previous token")
+	valid, _ = service.VerifyToken(ctx, "session-1", replacement)
+	assert.True(t, valid)
+
+	require.NoError(t, service.RevokeToken(ctx, "session-1"))
+	valid, _ = service.VerifyToken(ctx, "session-1", replacement)
+	assert.False(t, valid)
+}
+
+func TestCSRFTokenExpires(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryTokenStore()
+	service := newCSRFService(store, 16, 20*time.Millisecond)
+
+	token, err := service.IssueToken(ctx, "session-1")
+	require.NoError(t, err)
+	time.Sleep(30 * time.Millisecond)
+
+	valid, err := service.VerifyToken(ctx, "session-1", token)
+	require.NoError(t, err)
+	assert.False(t, valid)
+	assert.Empty(t, store.tokens, "expired tokens are dropped")
+}
@@ -0,0 +1,55 @@
+package email
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"sync-backend/arch/config"
+	"sync-backend/utils"
+)
+
+// fileProvider is the development driver, it writes emails to .eml files or logs them
+type fileProvider struct {
+	logger utils.AppLogger
+	dir    string
+}
+
+func newFileProvider(fileConfig config.FileEmailConfig) (EmailProvider, error) {
+	if fileConfig.Dir != "" {
+		if err := os.MkdirAll(fileConfig.Dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	return &fileProvider{
+		logger: utils.NewServiceLogger("EmailFileProvider"),
+		dir:    fileConfig.Dir,
+	}, nil
+}
+
+func (p *fileProvider) Name() string {
+	return DriverFile
+}
+
+func (p *fileProvider) Send(message EmailMessage) (string, error) {
+	if p.dir == "" {
+		body := message.TextContent
+		if body == "" {
+			body = message.HtmlContent
+		}
+		p.logger.Info("Email to %s - %s\n%s", message.To, message.Subject, body)
+		return message.MessageId, nil
+	}
+
+	content, err := buildMIMEMessage(message, "localhost")
+	if err != nil {
+		return "", permanentError("building message: %v", err)
+	}
+	path := filepath.Join(p.dir, fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), message.MessageId))
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return "", err
+	}
+	p.logger.Debug("Email to %s written to %s", message.To, path)
+	return message.MessageId, nil
+}
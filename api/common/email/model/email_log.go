@@ -2,6 +2,7 @@ package model
 
 import (
 	"context"
+	"slices"
 	"sync-backend/arch/mongo"
 	"time"
 
@@ -21,37 +22,66 @@ const (
 	EmailTypeVerification  EmailType = "verification"
 	EmailTypeWelcome       EmailType = "welcome"
 	EmailTypeNotification  EmailType = "notification"
+	EmailTypeSecurity      EmailType = "security"
 )
 
+// UnreplayableEmailTypes are never sent again once dead-lettered. They carry one-time tokens and codes
+// that are stale by then, the user requests a fresh one instead.
+var UnreplayableEmailTypes = []EmailType{EmailTypePasswordReset, EmailTypeVerification, EmailTypeSecurity}
+
+func (t EmailType) Replayable() bool {
+	return !slices.Contains(UnreplayableEmailTypes, t)
+}
+
 type EmailStatus string
 
 const (
+	// Queued in the outbox, sent once nextAttemptAt has passed
 	EmailStatusPending EmailStatus = "pending"
+	// Claimed by the outbox worker until lockedUntil
+	EmailStatusSending EmailStatus = "sending"
 	EmailStatusSent    EmailStatus = "sent"
-	EmailStatusFailed  EmailStatus = "failed"
+	// Dead-lettered after a permanent error or the last attempt, only replayed by an admin
+	EmailStatusFailed EmailStatus = "failed"
 )
 
+// EmailLog is an outbox entry, it keeps the rendered email so sending can be retried
 type EmailLog struct {
-	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
-	EmailId   string              `bson:"emailId" json:"emailId" validate:"required"`
-	To        string              `bson:"to" json:"to" validate:"required,email"`
-	Subject   string              `bson:"subject" json:"subject" validate:"required"`
-	Type      EmailType           `bson:"type" json:"type" validate:"required"`
-	Status    EmailStatus         `bson:"status" json:"status" validate:"required"`
-	Error     string              `bson:"error,omitempty" json:"error,omitempty"`
-	SentAt    *primitive.DateTime `bson:"sentAt,omitempty" json:"sentAt,omitempty"`
-	CreatedAt primitive.DateTime  `bson:"createdAt" json:"createdAt"`
+	ID                primitive.ObjectID  `bson:"_id,omitempty" json:"-"`
+	EmailId           string              `bson:"emailId" json:"emailId" validate:"required"`
+	To                string              `bson:"to" json:"to" validate:"required,email"`
+	Subject           string              `bson:"subject" json:"subject" validate:"required"`
+	Type              EmailType           `bson:"type" json:"type" validate:"required"`
+	Status            EmailStatus         `bson:"status" json:"status" validate:"required"`
+	HtmlContent       string              `bson:"htmlContent" json:"-"`
+	TextContent       string              `bson:"textContent,omitempty" json:"-"`
+	Headers           map[string]string   `bson:"headers,omitempty" json:"-"`
+	Attempts          int                 `bson:"attempts" json:"attempts"`
+	NextAttemptAt     primitive.DateTime  `bson:"nextAttemptAt" json:"nextAttemptAt"`
+	LockedUntil       *primitive.DateTime `bson:"lockedUntil,omitempty" json:"-"`
+	Provider          string              `bson:"provider,omitempty" json:"provider,omitempty"`
+	ProviderMessageId string              `bson:"providerMessageId,omitempty" json:"providerMessageId,omitempty"`
+	Error             string              `bson:"error,omitempty" json:"error,omitempty"`
+	SentAt            *primitive.DateTime `bson:"sentAt,omitempty" json:"sentAt,omitempty"`
+	CreatedAt         primitive.DateTime  `bson:"createdAt" json:"createdAt"`
+	UpdatedAt         primitive.DateTime  `bson:"updatedAt" json:"updatedAt"`
 }
 
-func NewEmailLog(to, subject string, emailType EmailType) *EmailLog {
+// NewEmailLog creates a pending outbox entry that is due right away
+func NewEmailLog(to, subject string, emailType EmailType, htmlContent string, textContent string) *EmailLog {
 	now := primitive.NewDateTimeFromTime(time.Now())
 	return &EmailLog{
-		EmailId:   uuid.New().String(),
-		To:        to,
-		Subject:   subject,
-		Type:      emailType,
-		Status:    EmailStatusPending,
-		CreatedAt: now,
+		ID:            primitive.NewObjectID(),
+		EmailId:       uuid.New().String(),
+		To:            to,
+		Subject:       subject,
+		Type:          emailType,
+		Status:        EmailStatusPending,
+		HtmlContent:   htmlContent,
+		TextContent:   textContent,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
 	}
 }
 
@@ -89,6 +119,13 @@ func (*EmailLog) EnsureIndexes(db mongo.Database) {
 			},
 			Options: options.Index().SetName("idx_email_status"),
 		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "nextAttemptAt", Value: 1},
+			},
+			Options: options.Index().SetName("idx_email_status_next_attempt"),
+		},
 		{
 			Keys: bson.D{
 				{Key: "createdAt", Value: 1},
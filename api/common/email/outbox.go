@@ -0,0 +1,263 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"sync-backend/api/common/email/model"
+	"sync-backend/arch/config"
+	"sync-backend/arch/mongo"
+	"sync-backend/arch/network"
+	"sync-backend/arch/tracing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// withOutboxDefaults fills unset outbox settings so a missing config section still delivers email
+func withOutboxDefaults(outbox config.EmailOutboxConfig) config.EmailOutboxConfig {
+	if outbox.PollInterval <= 0 {
+		outbox.PollInterval = 5 * time.Second
+	}
+	if outbox.BatchSize <= 0 {
+		outbox.BatchSize = 20
+	}
+	if outbox.MaxAttempts <= 0 {
+		outbox.MaxAttempts = 6
+	}
+	if outbox.BaseBackoff <= 0 {
+		outbox.BaseBackoff = 30 * time.Second
+	}
+	if outbox.MaxBackoff <= 0 {
+		outbox.MaxBackoff = time.Hour
+	}
+	if outbox.LockTimeout <= 0 {
+		outbox.LockTimeout = 2 * time.Minute
+	}
+	return outbox
+}
+
+// backoff returns the delay before the next attempt, doubling from BaseBackoff up to MaxBackoff
+// with up to 20% jitter so failed emails do not retry in lockstep
+func backoff(outbox config.EmailOutboxConfig, attempt int) time.Duration {
+	delay := outbox.MaxBackoff
+	if attempt < 1 {
+		attempt = 1
+	}
+	if attempt <= 32 {
+		if d := outbox.BaseBackoff << (attempt - 1); d > 0 && d < outbox.MaxBackoff {
+			delay = d
+		}
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/5+1))
+}
+
+// StartOutboxWorker delivers queued emails every PollInterval until ctx is cancelled
+func (s *emailService) StartOutboxWorker(ctx context.Context) {
+	ticker := time.NewTicker(s.outbox.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ProcessOutbox(context.WithoutCancel(ctx)); err != nil {
+				s.logger.WithContext(ctx).Error("Failed to process email outbox: %v", err)
+			}
+		}
+	}
+}
+
+// ProcessOutbox claims and delivers up to BatchSize due emails, returning how many were sent.
+// Emails claimed by a worker that died are picked up again once their lock expires.
+func (s *emailService) ProcessOutbox(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "EmailService.ProcessOutbox")
+	defer span.End()
+
+	sent := 0
+	for range s.outbox.BatchSize {
+		emailLog, err := s.claimNext(ctx)
+		if err != nil {
+			if mongo.IsNoDocumentFoundError(err) {
+				return sent, nil
+			}
+			return sent, err
+		}
+		if s.deliver(ctx, emailLog) {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// claimNext locks the oldest due email so concurrent workers never send it twice
+func (s *emailService) claimNext(ctx context.Context) (*model.EmailLog, error) {
+	now := time.Now()
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": model.EmailStatusPending, "nextAttemptAt": bson.M{"$lte": primitive.NewDateTimeFromTime(now)}},
+		bson.M{"status": model.EmailStatusSending, "lockedUntil": bson.M{"$lt": primitive.NewDateTimeFromTime(now)}},
+	}}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      model.EmailStatusSending,
+			"lockedUntil": primitive.NewDateTimeFromTime(now.Add(s.outbox.LockTimeout)),
+			"updatedAt":   primitive.NewDateTimeFromTime(now),
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).
+		SetReturnDocument(options.After)
+	return s.queryBuilder.SingleQuery(ctx).FilterOneAndUpdate(filter, update, opts)
+}
+
+// deliver sends a claimed email and records the outcome, returning whether it was sent
+func (s *emailService) deliver(ctx context.Context, emailLog *model.EmailLog) bool {
+	messageId, err := s.provider.Send(EmailMessage{
+		MessageId:   emailLog.EmailId,
+		FromEmail:   s.fromEmail,
+		FromName:    s.fromName,
+		To:          emailLog.To,
+		Subject:     emailLog.Subject,
+		HtmlContent: emailLog.HtmlContent,
+		TextContent: emailLog.TextContent,
+		Headers:     emailLog.Headers,
+	})
+
+	// Only the worker still holding the claim records the outcome, once the lock expired another
+	// worker may have claimed the email again
+	update := s.deliveryUpdate(emailLog, messageId, err, time.Now())
+	result, updateErr := s.queryBuilder.SingleQuery(ctx).UpdateOne(deliveryFilter(emailLog), update, nil)
+	switch {
+	case updateErr != nil:
+		s.logger.WithContext(ctx).Error("Failed to record delivery of email %s: %v", emailLog.EmailId, updateErr)
+	case result.MatchedCount == 0:
+		s.logger.WithContext(ctx).Warn("Email %s lost its claim before the delivery was recorded", emailLog.EmailId)
+	}
+
+	switch {
+	case err == nil:
+		s.logger.WithContext(ctx).Success("Email %s (%s) sent to %s via %s", emailLog.EmailId, emailLog.Type, emailLog.To, s.provider.Name())
+	case update["$set"].(bson.M)["status"] == model.EmailStatusFailed:
+		s.logger.WithContext(ctx).Error("Email %s (%s) to %s dead-lettered after %d attempts: %v", emailLog.EmailId, emailLog.Type, emailLog.To, emailLog.Attempts, err)
+	default:
+		s.logger.WithContext(ctx).Warn("Email %s (%s) to %s failed on attempt %d, retrying: %v", emailLog.EmailId, emailLog.Type, emailLog.To, emailLog.Attempts, err)
+	}
+	return err == nil
+}
+
+// deliveryFilter matches the email only while it holds the claim it was delivered under
+func deliveryFilter(emailLog *model.EmailLog) bson.M {
+	return bson.M{
+		"emailId":     emailLog.EmailId,
+		"status":      model.EmailStatusSending,
+		"lockedUntil": emailLog.LockedUntil,
+	}
+}
+
+// deliveryUpdate builds the outbox update for a delivery attempt, failed emails are rescheduled
+// with backoff until MaxAttempts or a permanent error moves them to the dead letter status
+func (s *emailService) deliveryUpdate(emailLog *model.EmailLog, messageId string, sendErr error, now time.Time) bson.M {
+	set := bson.M{
+		"provider":  s.provider.Name(),
+		"updatedAt": primitive.NewDateTimeFromTime(now),
+	}
+	update := bson.M{"$set": set, "$unset": bson.M{"lockedUntil": ""}}
+
+	if sendErr == nil {
+		set["status"] = model.EmailStatusSent
+		set["sentAt"] = primitive.NewDateTimeFromTime(now)
+		set["providerMessageId"] = messageId
+		update["$unset"] = bson.M{"lockedUntil": "", "error": ""}
+		return update
+	}
+
+	set["error"] = sendErr.Error()
+	if errors.Is(sendErr, ErrPermanent) || emailLog.Attempts >= s.outbox.MaxAttempts {
+		set["status"] = model.EmailStatusFailed
+		return update
+	}
+	set["status"] = model.EmailStatusPending
+	set["nextAttemptAt"] = primitive.NewDateTimeFromTime(now.Add(backoff(s.outbox, emailLog.Attempts)))
+	return update
+}
+
+// ListEmails lists outbox entries newest first, optionally filtered by status
+func (s *emailService) ListEmails(ctx context.Context, status model.EmailStatus, cursor string, limit int) ([]*model.EmailLog, *mongo.CursorResult, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "EmailService.ListEmails")
+	defer span.End()
+
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	emails, page, err := s.queryBuilder.SingleQuery(ctx).FindCursorPaginated(filter, mongo.NewCursorQuery("createdAt", int64(limit), cursor), nil)
+	if err != nil {
+		if mongo.IsInvalidCursorError(err) {
+			return nil, nil, network.NewBadRequestError(
+				"Invalid pagination cursor",
+				fmt.Sprintf("Cursor for emails with status '%s' is malformed or expired. [Context: status=%s]", status, status),
+				err,
+			)
+		}
+		return nil, nil, network.NewInternalServerError(
+			"Error fetching emails",
+			fmt.Sprintf("Database error when fetching emails with status '%s'. [Context: status=%s]", status, status),
+			network.DB_ERROR,
+			err,
+		)
+	}
+	return emails, page, nil
+}
+
+// ReplayEmail puts a dead-lettered email back in the outbox with a fresh set of attempts
+func (s *emailService) ReplayEmail(ctx context.Context, emailId string) (*model.EmailLog, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "EmailService.ReplayEmail")
+	defer span.End()
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	emailLog, err := s.queryBuilder.SingleQuery(ctx).FindOneAndUpdate(
+		bson.M{"emailId": emailId, "status": model.EmailStatusFailed, "type": bson.M{"$nin": model.UnreplayableEmailTypes}},
+		bson.M{
+			"$set":   bson.M{"status": model.EmailStatusPending, "attempts": 0, "nextAttemptAt": now, "updatedAt": now},
+			"$unset": bson.M{"error": "", "lockedUntil": ""},
+		},
+	)
+	if err == nil {
+		return emailLog, nil
+	}
+	if !mongo.IsNoDocumentFoundError(err) {
+		return nil, network.NewInternalServerError(
+			"Error replaying email",
+			fmt.Sprintf("Database error when replaying email '%s'. [Context: emailId=%s]", emailId, emailId),
+			network.DB_ERROR,
+			err,
+		)
+	}
+
+	existing, err := s.queryBuilder.SingleQuery(ctx).FindOne(bson.M{"emailId": emailId}, nil)
+	if err != nil || existing == nil {
+		return nil, network.NewNotFoundError(
+			"Email not found",
+			fmt.Sprintf("No email with ID '%s' exists in the outbox. [Context: emailId=%s]", emailId, emailId),
+			err,
+		)
+	}
+	if !existing.Type.Replayable() {
+		return nil, network.NewConflictError(
+			"Email can not be replayed",
+			fmt.Sprintf("Email '%s' is a %s email, its token is stale and the user has to request a new one. [Context: emailId=%s, type=%s]", emailId, existing.Type, emailId, existing.Type),
+			nil,
+		)
+	}
+	return nil, network.NewConflictError(
+		"Email is not dead-lettered",
+		fmt.Sprintf("Only failed emails can be replayed, email '%s' is %s. [Context: emailId=%s, status=%s]", emailId, existing.Status, emailId, existing.Status),
+		nil,
+	)
+}
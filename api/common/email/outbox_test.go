@@ -0,0 +1,62 @@
+package email
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"sync-backend/api/common/email/model"
+	"sync-backend/arch/config"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type stubProvider struct{}
+
+func (stubProvider) Name() string                      { return "stub" }
+func (stubProvider) Send(EmailMessage) (string, error) { return "", nil }
+
+func TestBackoffDoublesUpToMax(t *testing.T) {
+	outbox := withOutboxDefaults(config.EmailOutboxConfig{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second})
+
+	for attempt, base := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 5: 10 * time.Second, 80: 10 * time.Second} {
+		delay := backoff(outbox, attempt)
+		assert.GreaterOrEqual(t, delay, base, "attempt %d", attempt)
+		assert.LessOrEqual(t, delay, base+base/5, "attempt %d", attempt)
+	}
+}
+
+func TestDeliveryUpdate(t *testing.T) {
+	s := &emailService{outbox: withOutboxDefaults(config.EmailOutboxConfig{MaxAttempts: 3}), provider: stubProvider{}}
+	now := time.Now()
+
+	sent := s.deliveryUpdate(&model.EmailLog{Attempts: 1}, "msg-1", nil, now)
+	assert.Equal(t, model.EmailStatusSent, sent["$set"].(bson.M)["status"])
+	assert.Equal(t, "msg-1", sent["$set"].(bson.M)["providerMessageId"])
+
+	retried := s.deliveryUpdate(&model.EmailLog{Attempts: 1}, "", errors.New("timeout"), now)
+	assert.Equal(t, model.EmailStatusPending, retried["$set"].(bson.M)["status"])
+	assert.Contains(t, retried["$set"].(bson.M), "nextAttemptAt")
+
+	exhausted := s.deliveryUpdate(&model.EmailLog{Attempts: 3}, "", errors.New("timeout"), now)
+	assert.Equal(t, model.EmailStatusFailed, exhausted["$set"].(bson.M)["status"])
+
+	rejected := s.deliveryUpdate(&model.EmailLog{Attempts: 1}, "", permanentError("bad recipient"), now)
+	assert.Equal(t, model.EmailStatusFailed, rejected["$set"].(bson.M)["status"])
+}
+
+func TestDeliveryFilterRequiresTheClaim(t *testing.T) {
+	lockedUntil := primitive.NewDateTimeFromTime(time.Now().Add(time.Minute))
+	filter := deliveryFilter(&model.EmailLog{EmailId: "email-1", LockedUntil: &lockedUntil})
+	assert.Equal(t, bson.M{"emailId": "email-1", "status": model.EmailStatusSending, "lockedUntil": &lockedUntil}, filter)
+}
+
+func TestTokenEmailsAreNotReplayable(t *testing.T) {
+	assert.False(t, model.EmailTypePasswordReset.Replayable())
+	assert.False(t, model.EmailTypeVerification.Replayable())
+	assert.False(t, model.EmailTypeSecurity.Replayable())
+	assert.True(t, model.EmailTypeWelcome.Replayable())
+	assert.True(t, model.EmailTypeNotification.Replayable())
+}
@@ -0,0 +1,63 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+
+	"sync-backend/arch/config"
+)
+
+// Email providers selectable through email.driver
+const (
+	DriverSendGrid = "sendgrid"
+	DriverSMTP     = "smtp"
+	DriverFile     = "file"
+)
+
+// EmailMessage is a rendered email ready to be handed to a provider
+type EmailMessage struct {
+	MessageId   string
+	FromEmail   string
+	FromName    string
+	To          string
+	Subject     string
+	HtmlContent string
+	TextContent string
+	Headers     map[string]string // Extra headers such as List-Unsubscribe
+}
+
+// EmailProvider delivers emails, it returns the ID the provider assigned to the message if any.
+// Errors wrapping ErrPermanent are not retried.
+type EmailProvider interface {
+	Name() string
+	Send(message EmailMessage) (string, error)
+}
+
+// ErrPermanent marks delivery errors that will not go away by retrying, e.g. a rejected recipient
+var ErrPermanent = errors.New("permanent email delivery error")
+
+func permanentError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrPermanent, fmt.Sprintf(format, args...))
+}
+
+// NewEmailProvider creates the provider selected in the config, panics when it is misconfigured
+func NewEmailProvider(env *config.Env, emailConfig config.EmailConfig) EmailProvider {
+	var (
+		provider EmailProvider
+		err      error
+	)
+	switch emailConfig.Driver {
+	case DriverSendGrid, "":
+		provider, err = newSendGridProvider(env.SendGridAPIKey)
+	case DriverSMTP:
+		provider, err = newSMTPProvider(emailConfig.SMTP, env.SMTPUsername, env.SMTPPassword)
+	case DriverFile:
+		provider, err = newFileProvider(emailConfig.File)
+	default:
+		err = fmt.Errorf("unknown driver %q, expected one of %s, %s or %s", emailConfig.Driver, DriverSendGrid, DriverSMTP, DriverFile)
+	}
+	if err != nil {
+		panic("Failed to initialize email provider - Properly configure email: " + err.Error())
+	}
+	return provider
+}
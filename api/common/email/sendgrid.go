@@ -0,0 +1,52 @@
+package email
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/sendgrid/sendgrid-go"
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+)
+
+type sendGridProvider struct {
+	client *sendgrid.Client
+}
+
+func newSendGridProvider(apiKey string) (EmailProvider, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("sendgrid driver requires SENDGRID_API_KEY")
+	}
+	return &sendGridProvider{client: sendgrid.NewSendClient(apiKey)}, nil
+}
+
+func (p *sendGridProvider) Name() string {
+	return DriverSendGrid
+}
+
+func (p *sendGridProvider) Send(message EmailMessage) (string, error) {
+	from := mail.NewEmail(message.FromName, message.FromEmail)
+	recipient := mail.NewEmail("", message.To)
+	content := mail.NewSingleEmail(from, message.Subject, recipient, message.TextContent, message.HtmlContent)
+	for name, value := range message.Headers {
+		content.SetHeader(name, value)
+	}
+
+	response, err := p.client.Send(content)
+	if err != nil {
+		return "", err
+	}
+
+	// Rate limiting and server errors are worth retrying, other client errors are not
+	if response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500 {
+		return "", fmt.Errorf("sendgrid returned status %d: %s", response.StatusCode, response.Body)
+	}
+	if response.StatusCode >= 400 {
+		return "", permanentError("sendgrid returned status %d: %s", response.StatusCode, response.Body)
+	}
+
+	var messageId string
+	if ids := response.Headers["X-Message-Id"]; len(ids) > 0 {
+		messageId = ids[0]
+	}
+	return messageId, nil
+}
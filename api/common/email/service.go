@@ -3,199 +3,244 @@ package email
 import (
 	"context"
 	"fmt"
-	"html/template"
-	"path/filepath"
 	"sync-backend/api/common/email/model"
 	"sync-backend/arch/config"
 	"sync-backend/arch/mongo"
 	"sync-backend/arch/network"
+	"sync-backend/arch/tracing"
 	"sync-backend/utils"
 	"time"
-
-	"github.com/sendgrid/sendgrid-go"
-	"github.com/sendgrid/sendgrid-go/helpers/mail"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type EmailService interface {
-	SendPasswordReset(email, resetToken, resetUrl string) error
-	SendEmailVerification(email, verificationToken, verificationUrl string) error
-	SendWelcomeEmail(email, username string) error
+	SendPasswordReset(ctx context.Context, email, locale, resetToken, resetUrl string) error
+	SendEmailVerification(ctx context.Context, email, locale, verificationToken, verificationUrl string) error
+	SendWelcomeEmail(ctx context.Context, email, locale, username string) error
+	SendDigest(ctx context.Context, email, locale string, digest *DigestData) error
+	SendLoginAlert(ctx context.Context, email, locale string, alert *LoginAlertData) error
+	SendLoginCode(ctx context.Context, email, locale, code string, expiresIn time.Duration) error
+	PreviewTemplate(ctx context.Context, name TemplateName, locale string) (*RenderedEmail, network.ApiError)
+	ProcessOutbox(ctx context.Context) (int, error)
+	StartOutboxWorker(ctx context.Context)
+	ListEmails(ctx context.Context, status model.EmailStatus, cursor string, limit int) ([]*model.EmailLog, *mongo.CursorResult, network.ApiError)
+	ReplayEmail(ctx context.Context, emailId string) (*model.EmailLog, network.ApiError)
 }
 
 type emailService struct {
 	network.BaseService
-	logger         utils.AppLogger
-	env            *config.Env
-	queryBuilder   mongo.QueryBuilder[model.EmailLog]
-	sendgridClient *sendgrid.Client
-	fromEmail      string
-	fromName       string
+	logger       utils.AppLogger
+	env          *config.Env
+	outbox       config.EmailOutboxConfig
+	queryBuilder mongo.QueryBuilder[model.EmailLog]
+	provider     EmailProvider
+	templates    *templateRegistry
+	fromEmail    string
+	fromName     string
+}
+
+func NewEmailService(env *config.Env, config *config.Config, db mongo.Database) EmailService {
+	return newEmailService(env, config, db, NewEmailProvider(env, config.Email))
 }
 
-func NewEmailService(env *config.Env, db mongo.Database) EmailService {
-	client := sendgrid.NewSendClient(env.SendGridAPIKey)
+func newEmailService(env *config.Env, config *config.Config, db mongo.Database, provider EmailProvider) *emailService {
+	templates, err := newTemplateRegistry(templateFS)
+	if err != nil {
+		panic("Failed to load email templates: " + err.Error())
+	}
+
+	fromEmail, fromName := config.Email.FromEmail, config.Email.FromName
+	if fromEmail == "" {
+		fromEmail = env.SendGridFromEmail
+	}
+	if fromName == "" {
+		fromName = env.SendGridFromName
+	}
 
 	return &emailService{
-		BaseService:    network.NewBaseService(),
-		logger:         utils.NewServiceLogger("EmailService"),
-		env:            env,
-		queryBuilder:   mongo.NewQueryBuilder[model.EmailLog](db, model.EmailLogCollectionName),
-		sendgridClient: client,
-		fromEmail:      env.SendGridFromEmail,
-		fromName:       env.SendGridFromName,
+		BaseService:  network.NewBaseService(),
+		logger:       utils.NewServiceLogger("EmailService"),
+		env:          env,
+		outbox:       withOutboxDefaults(config.Email.Outbox),
+		queryBuilder: mongo.NewQueryBuilder[model.EmailLog](db, model.EmailLogCollectionName),
+		provider:     provider,
+		templates:    templates,
+		fromEmail:    fromEmail,
+		fromName:     fromName,
 	}
 }
 
-func (s *emailService) SendPasswordReset(email, resetToken, resetUrl string) error {
-	subject := "Reset Your Password - Sync"
+func (s *emailService) SendPasswordReset(ctx context.Context, email, locale, resetToken, resetUrl string) error {
+	ctx, span := tracing.Start(ctx, "EmailService.SendPasswordReset")
+	defer span.End()
 
-	// Render HTML template
-	htmlContent, err := s.renderTemplate("password_reset.html", map[string]interface{}{
+	rendered, err := s.templates.Render(TemplatePasswordReset, locale, map[string]interface{}{
 		"ResetUrl": resetUrl,
 		"Email":    email,
 	})
 	if err != nil {
-		s.logger.Error("Failed to render password reset template: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to render password reset template: %v", err)
 		return err
 	}
 
-	// Send email
-	err = s.sendEmail(email, subject, htmlContent, model.EmailTypePasswordReset)
+	// Queue email
+	err = s.enqueue(ctx, email, rendered, model.EmailTypePasswordReset, nil)
 	if err != nil {
-		s.logger.Error("Failed to send password reset email to %s: %v", email, err)
+		s.logger.WithContext(ctx).Error("Failed to queue password reset email to %s: %v", email, err)
 		return err
 	}
 
-	s.logger.Success("Password reset email sent successfully to: %s", email)
+	s.logger.WithContext(ctx).Success("Password reset email queued for: %s", email)
 	return nil
 }
 
-func (s *emailService) SendEmailVerification(email, verificationToken, verificationUrl string) error {
-	subject := "Verify Your Email - Sync"
+func (s *emailService) SendEmailVerification(ctx context.Context, email, locale, verificationToken, verificationUrl string) error {
+	ctx, span := tracing.Start(ctx, "EmailService.SendEmailVerification")
+	defer span.End()
 
-	// Render HTML template
-	htmlContent, err := s.renderTemplate("email_verification.html", map[string]interface{}{
+	rendered, err := s.templates.Render(TemplateEmailVerification, locale, map[string]interface{}{
 		"VerificationUrl": verificationUrl,
 		"Email":           email,
 	})
 	if err != nil {
-		s.logger.Error("Failed to render email verification template: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to render email verification template: %v", err)
 		return err
 	}
 
-	// Send email
-	err = s.sendEmail(email, subject, htmlContent, model.EmailTypeVerification)
+	// Queue email
+	err = s.enqueue(ctx, email, rendered, model.EmailTypeVerification, nil)
 	if err != nil {
-		s.logger.Error("Failed to send email verification to %s: %v", email, err)
+		s.logger.WithContext(ctx).Error("Failed to queue email verification to %s: %v", email, err)
 		return err
 	}
 
-	s.logger.Success("Email verification sent successfully to: %s", email)
+	s.logger.WithContext(ctx).Success("Email verification queued for: %s", email)
 	return nil
 }
 
-func (s *emailService) SendWelcomeEmail(email, username string) error {
-	subject := "Welcome to Sync!"
+func (s *emailService) SendWelcomeEmail(ctx context.Context, email, locale, username string) error {
+	ctx, span := tracing.Start(ctx, "EmailService.SendWelcomeEmail")
+	defer span.End()
 
-	// Render HTML template
-	htmlContent, err := s.renderTemplate("welcome.html", map[string]interface{}{
-		"Username": username,
-		"Email":    email,
+	rendered, err := s.templates.Render(TemplateWelcome, locale, map[string]interface{}{
+		"Username":    username,
+		"Email":       email,
+		"FrontendUrl": s.env.AppFrontendURL,
 	})
 	if err != nil {
-		s.logger.Error("Failed to render welcome email template: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to render welcome email template: %v", err)
 		return err
 	}
 
-	// Send email
-	err = s.sendEmail(email, subject, htmlContent, model.EmailTypeWelcome)
+	// Queue email
+	err = s.enqueue(ctx, email, rendered, model.EmailTypeWelcome, nil)
 	if err != nil {
-		s.logger.Error("Failed to send welcome email to %s: %v", email, err)
+		s.logger.WithContext(ctx).Error("Failed to queue welcome email to %s: %v", email, err)
 		return err
 	}
 
-	s.logger.Success("Welcome email sent successfully to: %s", email)
+	s.logger.WithContext(ctx).Success("Welcome email queued for: %s", email)
 	return nil
 }
 
-func (s *emailService) sendEmail(to, subject, htmlContent string, emailType model.EmailType) error {
-	// Create email log
-	emailLog := model.NewEmailLog(to, subject, emailType)
-
-	// Create SendGrid message
-	from := mail.NewEmail(s.fromName, s.fromEmail)
-	recipient := mail.NewEmail("", to)
-	message := mail.NewSingleEmail(from, subject, recipient, "", htmlContent)
+// SendLoginAlert queues a warning about a sign-in that did not look like the user's usual ones
+func (s *emailService) SendLoginAlert(ctx context.Context, email, locale string, alert *LoginAlertData) error {
+	ctx, span := tracing.Start(ctx, "EmailService.SendLoginAlert")
+	defer span.End()
 
-	// Send email via SendGrid
-	response, err := s.sendgridClient.Send(message)
+	rendered, err := s.templates.Render(TemplateLoginAlert, locale, alert.templateData())
 	if err != nil {
-		// Log failure
-		emailLog.Status = model.EmailStatusFailed
-		emailLog.Error = err.Error()
-		s.logEmail(emailLog)
+		s.logger.WithContext(ctx).Error("Failed to render login alert template: %v", err)
 		return err
 	}
 
-	// Check response status
-	if response.StatusCode >= 400 {
-		errMsg := fmt.Sprintf("SendGrid returned status %d: %s", response.StatusCode, response.Body)
-		emailLog.Status = model.EmailStatusFailed
-		emailLog.Error = errMsg
-		s.logEmail(emailLog)
-		return fmt.Errorf("%s", errMsg)
+	err = s.enqueue(ctx, email, rendered, model.EmailTypeSecurity, nil)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to queue login alert to %s: %v", email, err)
+		return err
 	}
 
-	// Log success
-	now := primitive.NewDateTimeFromTime(time.Now())
-	emailLog.Status = model.EmailStatusSent
-	emailLog.SentAt = &now
-	s.logEmail(emailLog)
-
+	s.logger.WithContext(ctx).Success("Login alert queued for: %s", email)
 	return nil
 }
 
-func (s *emailService) renderTemplate(templateName string, data map[string]interface{}) (string, error) {
-	// Get the absolute path to templates directory
-	templatesDir := filepath.Join("api", "common", "email", "templates")
-	templatePath := filepath.Join(templatesDir, templateName)
+// SendLoginCode queues the one-time code that completes a sign-in held back for verification
+func (s *emailService) SendLoginCode(ctx context.Context, email, locale, code string, expiresIn time.Duration) error {
+	ctx, span := tracing.Start(ctx, "EmailService.SendLoginCode")
+	defer span.End()
 
-	// Parse and execute template
-	tmpl, err := template.ParseFiles(templatePath)
+	rendered, err := s.templates.Render(TemplateLoginCode, locale, map[string]interface{}{
+		"Email":            email,
+		"Code":             code,
+		"ExpiresInMinutes": int(expiresIn.Minutes()),
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to parse template %s: %w", templateName, err)
+		s.logger.WithContext(ctx).Error("Failed to render login code template: %v", err)
+		return err
 	}
 
-	// Execute template to string
-	var result string
-	builder := &stringBuilder{}
-	err = tmpl.Execute(builder, data)
+	err = s.enqueue(ctx, email, rendered, model.EmailTypeSecurity, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute template %s: %w", templateName, err)
+		s.logger.WithContext(ctx).Error("Failed to queue login code to %s: %v", email, err)
+		return err
 	}
 
-	result = builder.String()
-	return result, nil
+	s.logger.WithContext(ctx).Success("Login code queued for: %s", email)
+	return nil
 }
 
-func (s *emailService) logEmail(emailLog *model.EmailLog) {
-	_, err := s.queryBuilder.Query(context.Background()).InsertOne(emailLog)
+// SendDigest queues a notification digest with one-click unsubscribe headers (RFC 8058)
+func (s *emailService) SendDigest(ctx context.Context, email, locale string, digest *DigestData) error {
+	ctx, span := tracing.Start(ctx, "EmailService.SendDigest")
+	defer span.End()
+
+	rendered, err := s.templates.Render(TemplateDigest, locale, digest.templateData())
 	if err != nil {
-		s.logger.Error("Failed to log email: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to render digest template: %v", err)
+		return err
 	}
-}
 
-// stringBuilder is a simple wrapper to implement io.Writer for template execution
-type stringBuilder struct {
-	content string
+	headers := map[string]string{
+		"List-Unsubscribe":      "<" + digest.UnsubscribeUrl + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+	if err := s.enqueue(ctx, email, rendered, model.EmailTypeNotification, headers); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to queue %s digest to %s: %v", digest.Frequency, email, err)
+		return err
+	}
+	return nil
 }
 
-func (sb *stringBuilder) Write(p []byte) (n int, err error) {
-	sb.content += string(p)
-	return len(p), nil
+// PreviewTemplate renders a template with sample data without sending it
+func (s *emailService) PreviewTemplate(ctx context.Context, name TemplateName, locale string) (*RenderedEmail, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "EmailService.PreviewTemplate")
+	defer span.End()
+
+	if !s.templates.Has(name) {
+		return nil, network.NewNotFoundError(
+			"Email template not found",
+			fmt.Sprintf("No email template named '%s' exists, available templates are %v. [Context: template=%s]", name, templateNames, name),
+			nil,
+		)
+	}
+
+	rendered, err := s.templates.Render(name, locale, sampleTemplateData(name, s.env.AppFrontendURL))
+	if err != nil {
+		return nil, network.NewInternalServerError(
+			"Error rendering email template",
+			fmt.Sprintf("Rendering template '%s' for locale '%s' failed. [Context: template=%s, locale=%s]", name, locale, name, locale),
+			network.InternalServerErrorCode,
+			err,
+		)
+	}
+	return rendered, nil
 }
 
-func (sb *stringBuilder) String() string {
-	return sb.content
+// enqueue stores the rendered email in the outbox, the outbox worker delivers it
+func (s *emailService) enqueue(ctx context.Context, to string, rendered *RenderedEmail, emailType model.EmailType, headers map[string]string) error {
+	emailLog := model.NewEmailLog(to, rendered.Subject, emailType, rendered.Html, rendered.Text)
+	emailLog.Headers = headers
+	if _, err := s.queryBuilder.SingleQuery(ctx).InsertOne(emailLog); err != nil {
+		return fmt.Errorf("failed to queue email: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,116 @@
+package email
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"sync-backend/arch/config"
+)
+
+const smtpTimeout = 30 * time.Second
+
+type smtpProvider struct {
+	config   config.SMTPConfig
+	username string
+	password string
+}
+
+func newSMTPProvider(smtpConfig config.SMTPConfig, username string, password string) (EmailProvider, error) {
+	if smtpConfig.Host == "" || smtpConfig.Port <= 0 {
+		return nil, fmt.Errorf("smtp driver requires email.smtp.host and email.smtp.port")
+	}
+	switch smtpConfig.TLS {
+	case "", "none", "starttls", "tls":
+	default:
+		return nil, fmt.Errorf("unknown email.smtp.tls %q, expected none, starttls or tls", smtpConfig.TLS)
+	}
+	return &smtpProvider{config: smtpConfig, username: username, password: password}, nil
+}
+
+func (p *smtpProvider) Name() string {
+	return DriverSMTP
+}
+
+func (p *smtpProvider) Send(message EmailMessage) (string, error) {
+	content, err := buildMIMEMessage(message, p.config.Host)
+	if err != nil {
+		return "", permanentError("building message: %v", err)
+	}
+
+	client, err := p.dial()
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	if p.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", p.username, p.password, p.config.Host)); err != nil {
+			return "", classifySMTPError(err)
+		}
+	}
+	if err := client.Mail(message.FromEmail); err != nil {
+		return "", classifySMTPError(err)
+	}
+	if err := client.Rcpt(message.To); err != nil {
+		return "", classifySMTPError(err)
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return "", classifySMTPError(err)
+	}
+	if _, err := writer.Write(content); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", classifySMTPError(err)
+	}
+	return message.MessageId, client.Quit()
+}
+
+func (p *smtpProvider) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(p.config.Host, strconv.Itoa(p.config.Port))
+	tlsConfig := &tls.Config{ServerName: p.config.Host}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	if p.config.TLS == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	client, err := smtp.NewClient(conn, p.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if p.config.TLS == "starttls" {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+// classifySMTPError marks 5xx replies as permanent, 4xx replies and network errors are retried
+func classifySMTPError(err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		return fmt.Errorf("%w: %v", ErrPermanent, err)
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package email
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"sync-backend/arch/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer accepts one session, answering rcptCode to RCPT TO, and returns the DATA it received
+func fakeSMTPServer(t *testing.T, rcptCode string) (int, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL FROM"):
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "RCPT TO"):
+				reply(rcptCode + " recipient")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				received <- data.String()
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port, received
+}
+
+func TestSMTPProviderSendsMultipartMessage(t *testing.T) {
+	port, received := fakeSMTPServer(t, "250")
+	provider, err := newSMTPProvider(config.SMTPConfig{Host: "127.0.0.1", Port: port, TLS: "none"}, "", "")
+	require.NoError(t, err)
+
+	messageId, err := provider.Send(EmailMessage{
+		MessageId:   "abc-123",
+		FromEmail:   "noreply@sync.test",
+		FromName:    "Sync",
+		To:          "user@sync.test",
+		Subject:     "Welcome",
+		HtmlContent: "<p>Hello</p>",
+		TextContent: "Hello",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "abc-123", messageId)
+
+	data := <-received
+	assert.Contains(t, data, "Message-ID: <abc-123@127.0.0.1>")
+	assert.Contains(t, data, "multipart/alternative")
+	assert.Contains(t, data, "text/plain")
+	assert.Contains(t, data, "<p>Hello</p>")
+}
+
+func TestSMTPProviderRejectedRecipientIsPermanent(t *testing.T) {
+	port, _ := fakeSMTPServer(t, "550")
+	provider, err := newSMTPProvider(config.SMTPConfig{Host: "127.0.0.1", Port: port}, "", "")
+	require.NoError(t, err)
+
+	_, err = provider.Send(EmailMessage{MessageId: "abc", FromEmail: "a@sync.test", To: "b@sync.test", Subject: "x", HtmlContent: "x"})
+	assert.True(t, errors.Is(err, ErrPermanent))
+}
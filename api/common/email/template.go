@@ -0,0 +1,286 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Each locale directory holds <name>.html (content and optional header blocks) and <name>.txt
+// (subject block and plain-text body), sharing layout.html and the per template styles in style/
+//
+//go:embed templates
+var templateFS embed.FS
+
+type TemplateName string
+
+const (
+	TemplatePasswordReset     TemplateName = "password_reset"
+	TemplateEmailVerification TemplateName = "email_verification"
+	TemplateWelcome           TemplateName = "welcome"
+	TemplateDigest            TemplateName = "digest"
+	TemplateLoginAlert        TemplateName = "login_alert"
+	TemplateLoginCode         TemplateName = "login_code"
+)
+
+// DefaultLocale must provide every template, other locales fall back to it per template
+const DefaultLocale = "en"
+
+var templateNames = []TemplateName{TemplatePasswordReset, TemplateEmailVerification, TemplateWelcome, TemplateDigest, TemplateLoginAlert, TemplateLoginCode}
+
+// RenderedEmail is a template rendered for one locale
+type RenderedEmail struct {
+	Template TemplateName `json:"template"`
+	Locale   string       `json:"locale"`
+	Subject  string       `json:"subject"`
+	Html     string       `json:"html"`
+	Text     string       `json:"text"`
+}
+
+type localizedTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+type templateRegistry struct {
+	locales map[string]map[TemplateName]*localizedTemplate
+}
+
+func newTemplateRegistry(fsys fs.FS) (*templateRegistry, error) {
+	entries, err := fs.ReadDir(fsys, "templates")
+	if err != nil {
+		return nil, err
+	}
+
+	registry := &templateRegistry{locales: map[string]map[TemplateName]*localizedTemplate{}}
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == "style" {
+			continue
+		}
+		locale := entry.Name()
+		registry.locales[locale] = map[TemplateName]*localizedTemplate{}
+		for _, name := range templateNames {
+			tmpl, err := parseLocalizedTemplate(fsys, locale, name)
+			if err != nil {
+				return nil, fmt.Errorf("template %s/%s: %w", locale, name, err)
+			}
+			if tmpl != nil {
+				registry.locales[locale][name] = tmpl
+			}
+		}
+	}
+
+	for _, name := range templateNames {
+		if registry.locales[DefaultLocale][name] == nil {
+			return nil, fmt.Errorf("template %s/%s is missing, every template needs a %s variant", DefaultLocale, name, DefaultLocale)
+		}
+	}
+	return registry, nil
+}
+
+// parseLocalizedTemplate returns nil when the locale has no variant of the template
+func parseLocalizedTemplate(fsys fs.FS, locale string, name TemplateName) (*localizedTemplate, error) {
+	dir := path.Join("templates", locale)
+	htmlPath := path.Join(dir, string(name)+".html")
+	textPath := path.Join(dir, string(name)+".txt")
+	if _, err := fs.Stat(fsys, htmlPath); err != nil {
+		return nil, nil
+	}
+
+	html, err := htmltemplate.New("layout").ParseFS(fsys,
+		"templates/layout.html",
+		path.Join("templates", "style", string(name)+".html"),
+		path.Join(dir, "footer.html"),
+		htmlPath,
+	)
+	if err != nil {
+		return nil, err
+	}
+	text, err := texttemplate.New(path.Base(textPath)).ParseFS(fsys, path.Join(dir, "footer.txt"), textPath)
+	if err != nil {
+		return nil, err
+	}
+	if text.Lookup("subject") == nil {
+		return nil, fmt.Errorf("%s has no subject block", textPath)
+	}
+	return &localizedTemplate{html: html, text: text}, nil
+}
+
+// normalizeLocale reduces a language tag such as es-MX to its base language
+func normalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	return locale
+}
+
+// Has reports whether the template exists
+func (r *templateRegistry) Has(name TemplateName) bool {
+	_, ok := r.locales[DefaultLocale][name]
+	return ok
+}
+
+// Render renders the template in locale, falling back to English when the locale has no variant
+func (r *templateRegistry) Render(name TemplateName, locale string, data map[string]interface{}) (*RenderedEmail, error) {
+	locale = normalizeLocale(locale)
+	tmpl, ok := r.locales[locale][name]
+	if !ok {
+		locale = DefaultLocale
+		if tmpl, ok = r.locales[locale][name]; !ok {
+			return nil, fmt.Errorf("unknown email template %s", name)
+		}
+	}
+
+	values := make(map[string]interface{}, len(data)+2)
+	for k, v := range data {
+		values[k] = v
+	}
+	values["Locale"] = locale
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", values); err != nil {
+		return nil, fmt.Errorf("failed to render subject of %s/%s: %w", locale, name, err)
+	}
+	if err := tmpl.text.Execute(&text, values); err != nil {
+		return nil, fmt.Errorf("failed to render text of %s/%s: %w", locale, name, err)
+	}
+	values["Subject"] = strings.TrimSpace(subject.String())
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", values); err != nil {
+		return nil, fmt.Errorf("failed to render html of %s/%s: %w", locale, name, err)
+	}
+
+	return &RenderedEmail{
+		Template: name,
+		Locale:   locale,
+		Subject:  values["Subject"].(string),
+		Html:     html.String(),
+		Text:     strings.TrimSpace(text.String()) + "\n",
+	}, nil
+}
+
+// DigestItem is one entry of a digest section
+type DigestItem struct {
+	Title     string
+	Author    string
+	Community string
+	Excerpt   string
+	Url       string
+	Score     int
+	Kind      string // Reported content type of moderation outcomes
+	Status    string // Report status of moderation outcomes, approved or rejected
+}
+
+// DigestData is the content of a notification digest
+type DigestData struct {
+	Username           string
+	Frequency          string // daily or weekly
+	Replies            []DigestItem
+	Mentions           []DigestItem
+	TopPosts           []DigestItem
+	ModerationOutcomes []DigestItem
+	SettingsUrl        string
+	UnsubscribeUrl     string
+}
+
+// IsEmpty reports whether there is nothing worth sending
+func (d *DigestData) IsEmpty() bool {
+	return len(d.Replies) == 0 && len(d.Mentions) == 0 && len(d.TopPosts) == 0 && len(d.ModerationOutcomes) == 0
+}
+
+func (d *DigestData) templateData() map[string]interface{} {
+	return map[string]interface{}{
+		"Username":           d.Username,
+		"Frequency":          d.Frequency,
+		"Replies":            d.Replies,
+		"Mentions":           d.Mentions,
+		"TopPosts":           d.TopPosts,
+		"ModerationOutcomes": d.ModerationOutcomes,
+		"SettingsUrl":        d.SettingsUrl,
+		"UnsubscribeUrl":     d.UnsubscribeUrl,
+	}
+}
+
+// LoginAlertData describes a sign-in that did not look like the user's usual ones
+type LoginAlertData struct {
+	Username    string
+	Time        string // Formatted in the user's timezone
+	Device      string
+	Location    string
+	IpAddress   string
+	Reasons     []string // new_device, new_country or impossible_travel
+	SecurityUrl string
+	ResetUrl    string
+}
+
+func (d *LoginAlertData) templateData() map[string]interface{} {
+	return map[string]interface{}{
+		"Username":    d.Username,
+		"Time":        d.Time,
+		"Device":      d.Device,
+		"Location":    d.Location,
+		"IpAddress":   d.IpAddress,
+		"Reasons":     d.Reasons,
+		"SecurityUrl": d.SecurityUrl,
+		"ResetUrl":    d.ResetUrl,
+	}
+}
+
+// sampleTemplateData is the data admin previews are rendered with
+func sampleTemplateData(name TemplateName, frontendUrl string) map[string]interface{} {
+	if name == TemplateDigest {
+		digest := &DigestData{
+			Username:  "jane",
+			Frequency: "daily",
+			Replies: []DigestItem{
+				{Title: "Weekend hiking spots", Author: "alex", Excerpt: "Try the ridge trail, the view is worth it.", Url: frontendUrl + "/post/sample"},
+			},
+			Mentions: []DigestItem{
+				{Title: "Photo contest results", Author: "sam", Excerpt: "Congrats @jane on second place!", Url: frontendUrl + "/post/sample"},
+			},
+			TopPosts: []DigestItem{
+				{Title: "Best trails of the season", Community: "outdoors", Score: 412, Url: frontendUrl + "/post/sample"},
+			},
+			ModerationOutcomes: []DigestItem{
+				{Kind: "post", Community: "outdoors", Status: "approved"},
+			},
+			SettingsUrl:    frontendUrl + "/settings/notifications",
+			UnsubscribeUrl: frontendUrl + "/unsubscribe?token=sample-token",
+		}
+		return digest.templateData()
+	}
+	if name == TemplateLoginAlert {
+		alert := &LoginAlertData{
+			Username:    "jane",
+			Time:        "Jan 2, 2026 15:04 UTC",
+			Device:      "Chrome on macOS",
+			Location:    "Lisbon, Portugal",
+			IpAddress:   "203.0.113.7",
+			Reasons:     []string{"new_device", "new_country"},
+			SecurityUrl: frontendUrl + "/settings/security",
+			ResetUrl:    frontendUrl + "/forgot-password",
+		}
+		return alert.templateData()
+	}
+
+	data := map[string]interface{}{
+		"Email":       "jane@example.com",
+		"Username":    "jane",
+		"FrontendUrl": frontendUrl,
+	}
+	switch name {
+	case TemplatePasswordReset:
+		data["ResetUrl"] = frontendUrl + "/reset-password?token=sample-token"
+	case TemplateEmailVerification:
+		data["VerificationUrl"] = frontendUrl + "/verify-email?token=sample-token"
+	case TemplateLoginCode:
+		data["Code"] = "482913"
+		data["ExpiresInMinutes"] = 10
+	}
+	return data
+}
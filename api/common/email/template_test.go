@@ -0,0 +1,54 @@
+package email
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplatesRenderEveryLocale(t *testing.T) {
+	registry, err := newTemplateRegistry(templateFS)
+	require.NoError(t, err)
+
+	for locale := range registry.locales {
+		for _, name := range templateNames {
+			rendered, err := registry.Render(name, locale, sampleTemplateData(name, "https://sync.test"))
+			require.NoError(t, err, "%s/%s", locale, name)
+			assert.NotEmpty(t, rendered.Subject, "%s/%s", locale, name)
+			assert.Contains(t, rendered.Html, `<html lang="`+rendered.Locale+`">`)
+			assert.Contains(t, rendered.Html, "<title>"+rendered.Subject+"</title>")
+			assert.NotContains(t, rendered.Text, "<", "%s/%s text part has markup", locale, name)
+		}
+	}
+}
+
+func TestTemplatesFallBackToEnglish(t *testing.T) {
+	registry, err := newTemplateRegistry(templateFS)
+	require.NoError(t, err)
+
+	spanish, err := registry.Render(TemplatePasswordReset, "es-MX", map[string]interface{}{"Email": "a@b.c", "ResetUrl": "https://sync.test/r"})
+	require.NoError(t, err)
+	assert.Equal(t, "es", spanish.Locale)
+	assert.Equal(t, "Restablece tu contraseña - Sync", spanish.Subject)
+	assert.Contains(t, spanish.Text, "https://sync.test/r")
+
+	unknown, err := registry.Render(TemplatePasswordReset, "ja", nil)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultLocale, unknown.Locale)
+	assert.Equal(t, "Reset Your Password - Sync", unknown.Subject)
+}
+
+func TestTemplateRegistryRequiresEnglishVariants(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/layout.html":        {Data: []byte(`{{define "layout"}}{{template "content" .}}{{end}}`)},
+		"templates/style/welcome.html": {Data: []byte(`{{define "style"}}{{end}}`)},
+		"templates/es/footer.html":     {Data: []byte(`{{define "footer"}}{{end}}`)},
+		"templates/es/footer.txt":      {Data: []byte(`{{define "footer"}}{{end}}`)},
+		"templates/es/welcome.html":    {Data: []byte(`{{define "content"}}Hola{{end}}`)},
+		"templates/es/welcome.txt":     {Data: []byte(`{{define "subject"}}Hola{{end}}Hola`)},
+	}
+	_, err := newTemplateRegistry(fsys)
+	assert.ErrorContains(t, err, "en/")
+}
@@ -0,0 +1,59 @@
+package guard
+
+import (
+	"fmt"
+	"sync-backend/arch/network"
+)
+
+const (
+	ERR_POST_LOCKED    = "ERR_POST_LOCKED"
+	ERR_POST_ARCHIVED  = "ERR_POST_ARCHIVED"
+	ERR_COMMENT_LOCKED = "ERR_COMMENT_LOCKED"
+	ERR_USER_BANNED    = "ERR_USER_BANNED"
+	ERR_USER_MUTED     = "ERR_USER_MUTED"
+)
+
+func NewPostLockedError(postId string) network.ApiError {
+	return network.NewForbiddenErrorWithCode(
+		"Post is locked",
+		fmt.Sprintf("The post with ID '%s' has been locked by a moderator and no longer accepts comments or votes. [Context: postId=%s]", postId, postId),
+		ERR_POST_LOCKED,
+		nil,
+	)
+}
+
+func NewPostArchivedError(postId string) network.ApiError {
+	return network.NewForbiddenErrorWithCode(
+		"Post is archived",
+		fmt.Sprintf("The post with ID '%s' is archived and is read-only. [Context: postId=%s]", postId, postId),
+		ERR_POST_ARCHIVED,
+		nil,
+	)
+}
+
+func NewCommentLockedError(commentId string) network.ApiError {
+	return network.NewForbiddenErrorWithCode(
+		"Comment thread is locked",
+		fmt.Sprintf("The comment thread with ID '%s' has been locked and no longer accepts replies or votes. [Context: commentId=%s]", commentId, commentId),
+		ERR_COMMENT_LOCKED,
+		nil,
+	)
+}
+
+func NewUserBannedError(userId string, communityId string, reason string) network.ApiError {
+	return network.NewForbiddenErrorWithCode(
+		fmt.Sprintf("You are banned from this community. Reason - %s", reason),
+		fmt.Sprintf("Banned users cannot comment or vote in the community. [Context: userId=%s, communityId=%s]", userId, communityId),
+		ERR_USER_BANNED,
+		nil,
+	)
+}
+
+func NewUserMutedError(userId string, communityId string, reason string) network.ApiError {
+	return network.NewForbiddenErrorWithCode(
+		fmt.Sprintf("You are muted in this community. Reason - %s", reason),
+		fmt.Sprintf("Muted users cannot comment or vote in the community until the mute is lifted. [Context: userId=%s, communityId=%s]", userId, communityId),
+		ERR_USER_MUTED,
+		nil,
+	)
+}
@@ -0,0 +1,85 @@
+package guard
+
+import (
+	"context"
+	comment "sync-backend/api/comment/model"
+	moderator "sync-backend/api/moderator/model"
+	post "sync-backend/api/post/model"
+	"sync-backend/arch/network"
+	"sync-backend/arch/tracing"
+	"sync-backend/utils"
+)
+
+// ContentGuard is the single check every comment and vote write goes through.
+// Callers load the post (and comment, when acting on one) and the guard decides
+// whether the user may write to it.
+type ContentGuard interface {
+	CheckPostWrite(ctx context.Context, userId string, post *post.Post) network.ApiError
+	CheckCommentWrite(ctx context.Context, userId string, post *post.Post, comment *comment.Comment) network.ApiError
+}
+
+// ModerationLookup is the subset of the moderator service the guard depends on
+type ModerationLookup interface {
+	IsUserBanned(ctx context.Context, userId string, communityId string) (bool, *moderator.BanInfo, network.ApiError)
+	IsUserMuted(ctx context.Context, userId string, communityId string) (bool, *moderator.MuteInfo, network.ApiError)
+}
+
+type contentGuard struct {
+	logger     utils.AppLogger
+	moderation ModerationLookup
+}
+
+func NewContentGuard(moderation ModerationLookup) ContentGuard {
+	return &contentGuard{
+		logger:     utils.NewServiceLogger("ContentGuard"),
+		moderation: moderation,
+	}
+}
+
+func (g *contentGuard) CheckPostWrite(ctx context.Context, userId string, post *post.Post) network.ApiError {
+	ctx, span := tracing.Start(ctx, "ContentGuard.CheckPostWrite")
+	defer span.End()
+
+	if post.IsArchived {
+		g.logger.WithContext(ctx).Debug("Rejected write on archived post %s by user %s", post.PostId, userId)
+		return NewPostArchivedError(post.PostId)
+	}
+	if post.IsLocked {
+		g.logger.WithContext(ctx).Debug("Rejected write on locked post %s by user %s", post.PostId, userId)
+		return NewPostLockedError(post.PostId)
+	}
+	return g.checkUser(ctx, userId, post.CommunityId)
+}
+
+func (g *contentGuard) CheckCommentWrite(ctx context.Context, userId string, post *post.Post, comment *comment.Comment) network.ApiError {
+	ctx, span := tracing.Start(ctx, "ContentGuard.CheckCommentWrite")
+	defer span.End()
+
+	if err := g.CheckPostWrite(ctx, userId, post); err != nil {
+		return err
+	}
+	if comment.IsLocked {
+		g.logger.WithContext(ctx).Debug("Rejected write on locked comment %s by user %s", comment.CommentId, userId)
+		return NewCommentLockedError(comment.CommentId)
+	}
+	return nil
+}
+
+func (g *contentGuard) checkUser(ctx context.Context, userId string, communityId string) network.ApiError {
+	banned, banInfo, err := g.moderation.IsUserBanned(ctx, userId, communityId)
+	if err != nil {
+		return err
+	}
+	if banned {
+		return NewUserBannedError(userId, communityId, banInfo.Reason)
+	}
+
+	muted, muteInfo, err := g.moderation.IsUserMuted(ctx, userId, communityId)
+	if err != nil {
+		return err
+	}
+	if muted {
+		return NewUserMutedError(userId, communityId, muteInfo.Reason)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package guard
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	comment "sync-backend/api/comment/model"
+	moderator "sync-backend/api/moderator/model"
+	post "sync-backend/api/post/model"
+	"sync-backend/arch/network"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeModeration struct {
+	banned  bool
+	muted   bool
+	failErr network.ApiError
+}
+
+func (f *fakeModeration) IsUserBanned(_ context.Context, userId string, communityId string) (bool, *moderator.BanInfo, network.ApiError) {
+	if f.failErr != nil {
+		return false, nil, f.failErr
+	}
+	if f.banned {
+		return true, &moderator.BanInfo{Reason: "spam", IsPermanent: true}, nil
+	}
+	return false, nil, nil
+}
+
+func (f *fakeModeration) IsUserMuted(_ context.Context, userId string, communityId string) (bool, *moderator.MuteInfo, network.ApiError) {
+	if f.muted {
+		return true, &moderator.MuteInfo{Reason: "cool down", IsPermanent: false}, nil
+	}
+	return false, nil, nil
+}
+
+func newTestPost() *post.Post {
+	return post.NewPost("author", "community", "title", "content", nil, nil, post.TextPost, false, false)
+}
+
+func newTestComment(p *post.Post) *comment.Comment {
+	return comment.NewComment(p.PostId, "author", p.CommunityId, "hello", "")
+}
+
+func assertGuardError(t *testing.T, err network.ApiError, code string) {
+	t.Helper()
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusForbidden, err.GetStatusCode())
+		assert.Equal(t, code, err.GetErrorCode())
+	}
+}
+
+func TestCheckPostWrite_Allowed(t *testing.T) {
+	g := NewContentGuard(&fakeModeration{})
+	assert.Nil(t, g.CheckPostWrite(context.Background(), "user", newTestPost()))
+}
+
+func TestCheckPostWrite_LockedPost(t *testing.T) {
+	p := newTestPost()
+	p.IsLocked = true
+	err := NewContentGuard(&fakeModeration{}).CheckPostWrite(context.Background(), "user", p)
+	assertGuardError(t, err, ERR_POST_LOCKED)
+}
+
+func TestCheckPostWrite_ArchivedPost(t *testing.T) {
+	p := newTestPost()
+	p.IsArchived = true
+	err := NewContentGuard(&fakeModeration{}).CheckPostWrite(context.Background(), "user", p)
+	assertGuardError(t, err, ERR_POST_ARCHIVED)
+}
+
+func TestCheckPostWrite_ArchivedTakesPrecedenceOverLocked(t *testing.T) {
+	p := newTestPost()
+	p.IsLocked = true
+	p.IsArchived = true
+	err := NewContentGuard(&fakeModeration{}).CheckPostWrite(context.Background(), "user", p)
+	assertGuardError(t, err, ERR_POST_ARCHIVED)
+}
+
+func TestCheckPostWrite_BannedUser(t *testing.T) {
+	err := NewContentGuard(&fakeModeration{banned: true}).CheckPostWrite(context.Background(), "user", newTestPost())
+	assertGuardError(t, err, ERR_USER_BANNED)
+}
+
+func TestCheckPostWrite_MutedUser(t *testing.T) {
+	err := NewContentGuard(&fakeModeration{muted: true}).CheckPostWrite(context.Background(), "user", newTestPost())
+	assertGuardError(t, err, ERR_USER_MUTED)
+}
+
+func TestCheckPostWrite_LookupFailure(t *testing.T) {
+	dbErr := network.NewInternalServerError("db down", "db down", network.DB_ERROR, nil)
+	err := NewContentGuard(&fakeModeration{failErr: dbErr}).CheckPostWrite(context.Background(), "user", newTestPost())
+	if assert.NotNil(t, err) {
+		assert.Equal(t, network.DB_ERROR, err.GetErrorCode())
+	}
+}
+
+func TestCheckCommentWrite_Allowed(t *testing.T) {
+	p := newTestPost()
+	assert.Nil(t, NewContentGuard(&fakeModeration{}).CheckCommentWrite(context.Background(), "user", p, newTestComment(p)))
+}
+
+func TestCheckCommentWrite_LockedComment(t *testing.T) {
+	p := newTestPost()
+	c := newTestComment(p)
+	c.IsLocked = true
+	err := NewContentGuard(&fakeModeration{}).CheckCommentWrite(context.Background(), "user", p, c)
+	assertGuardError(t, err, ERR_COMMENT_LOCKED)
+}
+
+func TestCheckCommentWrite_LockedPost(t *testing.T) {
+	p := newTestPost()
+	p.IsLocked = true
+	err := NewContentGuard(&fakeModeration{}).CheckCommentWrite(context.Background(), "user", p, newTestComment(p))
+	assertGuardError(t, err, ERR_POST_LOCKED)
+}
+
+func TestCheckCommentWrite_BannedUser(t *testing.T) {
+	p := newTestPost()
+	err := NewContentGuard(&fakeModeration{banned: true}).CheckCommentWrite(context.Background(), "user", p, newTestComment(p))
+	assertGuardError(t, err, ERR_USER_BANNED)
+}
+
+func TestCheckCommentWrite_MutedUser(t *testing.T) {
+	p := newTestPost()
+	err := NewContentGuard(&fakeModeration{muted: true}).CheckCommentWrite(context.Background(), "user", p, newTestComment(p))
+	assertGuardError(t, err, ERR_USER_MUTED)
+}
@@ -0,0 +1,73 @@
+package location
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"sync-backend/api/common/location/model"
+)
+
+// locationCache is a fixed size LRU cache of IP lookups, entries also expire after ttl
+// so database updates are picked up without a restart
+type locationCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	order    *list.List // Front is the most recently used
+	entries  map[string]*list.Element
+}
+
+type cacheEntry struct {
+	ip        string
+	location  model.UserLocationInfo
+	expiresAt time.Time
+}
+
+func newLocationCache(capacity int, ttl time.Duration) *locationCache {
+	return &locationCache{
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element, capacity),
+	}
+}
+
+func (c *locationCache) get(ip string, now time.Time) (*model.UserLocationInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[ip]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if now.After(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, ip)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	location := entry.location
+	return &location, true
+}
+
+func (c *locationCache) set(ip string, location *model.UserLocationInfo, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[ip]; ok {
+		entry := element.Value.(*cacheEntry)
+		entry.location = *location
+		entry.expiresAt = now.Add(c.ttl)
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[ip] = c.order.PushFront(&cacheEntry{ip: ip, location: *location, expiresAt: now.Add(c.ttl)})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).ip)
+	}
+}
@@ -0,0 +1,92 @@
+package location
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"sync-backend/api/common/location/model"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Names are read in this language, it is what the postgres tables hold as locale_code
+const maxmindLocale = "en"
+
+var errLocaleLookupUnsupported = errors.New("locale code lookups need the postgres location backend")
+
+// maxmindBackend reads a GeoLite2 or GeoIP2 City database from disk, lookups never leave the process
+type maxmindBackend struct {
+	reader *maxminddb.Reader
+}
+
+// maxmindCity is the part of a City database record the backend reads
+type maxmindCity struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+		TimeZone  string  `maxminddb:"time_zone"`
+	} `maxminddb:"location"`
+}
+
+func newMaxmindBackend(path string) (*maxmindBackend, error) {
+	if path == "" {
+		return nil, fmt.Errorf("maxmind backend requires location.maxmind.path")
+	}
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	return &maxmindBackend{reader: reader}, nil
+}
+
+func (b *maxmindBackend) lookupIp(_ context.Context, ip net.IP) (*model.UserLocationInfo, error) {
+	var record maxmindCity
+	_, found, err := b.reader.LookupNetwork(ip, &record)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+	return model.NewUserLocationInfo(
+		record.Country.Names[maxmindLocale],
+		record.City.Names[maxmindLocale],
+		record.Location.Latitude,
+		record.Location.Longitude,
+		record.Location.TimeZone,
+		gmtOffset(record.Location.TimeZone, time.Now()),
+		maxmindLocale,
+	), nil
+}
+
+// lookupLocaleCode is not supported, the database can only be searched by IP
+func (b *maxmindBackend) lookupLocaleCode(_ context.Context, localeCode string) (*model.UserLocationInfo, error) {
+	return nil, errLocaleLookupUnsupported
+}
+
+// gmtOffset formats the current UTC offset of the time zone as +05:30, empty when the zone is unknown
+func gmtOffset(timeZone string, now time.Time) string {
+	if timeZone == "" {
+		return ""
+	}
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return ""
+	}
+	_, offset := now.In(location).Zone()
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, offset/3600, offset%3600/60)
+}
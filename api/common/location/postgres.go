@@ -0,0 +1,43 @@
+package location
+
+import (
+	"context"
+	"net"
+
+	"sync-backend/api/common/location/model"
+	pg "sync-backend/arch/postgres"
+)
+
+// postgresBackend queries the geoip2_network and geoip2_location tables of the IP database
+type postgresBackend struct {
+	ipQueryBuilder pg.QueryBuilder[model.UserLocationInfo]
+}
+
+func newPostgresBackend(db pg.Database) *postgresBackend {
+	return &postgresBackend{
+		ipQueryBuilder: pg.NewQueryBuilder[model.UserLocationInfo](db),
+	}
+}
+
+func (b *postgresBackend) lookupIp(ctx context.Context, ip net.IP) (*model.UserLocationInfo, error) {
+	query := `SELECT country_name, city_name, latitude, longitude, time_zone, gmt_offset, locale_code 
+		FROM geoip2_network net
+		LEFT JOIN geoip2_location location ON (
+			net.geoname_id = location.geoname_id
+		)
+		WHERE network >>= $1`
+
+	return b.ipQueryBuilder.Query(ctx).FilterOne(query, ip.String())
+}
+
+func (b *postgresBackend) lookupLocaleCode(ctx context.Context, localeCode string) (*model.UserLocationInfo, error) {
+	query := `SELECT country_name, city_name, latitude, longitude, time_zone, gmt_offset, locale_code 
+		FROM geoip2_network net
+		LEFT JOIN geoip2_location location ON (
+			net.geoname_id = location.geoname_id
+		)
+		WHERE locale_code = $1
+		LIMIT 1`
+
+	return b.ipQueryBuilder.Query(ctx).FilterOne(query, localeCode)
+}
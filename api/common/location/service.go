@@ -1,79 +1,135 @@
 package location
 
 import (
-	pg "sync-backend/arch/postgres"
+	"context"
+	"fmt"
+	"net"
+	"time"
 
 	"sync-backend/api/common/location/model"
+	"sync-backend/arch/config"
+	pg "sync-backend/arch/postgres"
+	"sync-backend/arch/tracing"
 	"sync-backend/utils"
 )
 
+// Location backends selectable through location.backend
+const (
+	BackendPostgres = "postgres"
+	BackendMaxMind  = "maxmind"
+)
+
+const (
+	defaultCacheSize = 10000
+	defaultCacheTTL  = time.Hour
+)
+
 type LocationService interface {
-	GetLocationByIp(ip string) (*model.UserLocationInfo, error)
-	GetLocationByLocaleCode(localeCode string) (*model.UserLocationInfo, error)
+	GetLocationByIp(ctx context.Context, ip string) (*model.UserLocationInfo, error)
+	GetLocationByLocaleCode(ctx context.Context, localeCode string) (*model.UserLocationInfo, error)
+}
+
+// locationBackend resolves locations, a nil location means there is no data
+type locationBackend interface {
+	lookupIp(ctx context.Context, ip net.IP) (*model.UserLocationInfo, error)
+	lookupLocaleCode(ctx context.Context, localeCode string) (*model.UserLocationInfo, error)
 }
 
 type locationService struct {
-	log            utils.AppLogger
-	ipQueryBuilder pg.QueryBuilder[model.UserLocationInfo]
+	log     utils.AppLogger
+	backend locationBackend
+	cache   *locationCache
 }
 
-func NewLocationService(db pg.Database) LocationService {
+// NewLocationService creates the service for the backend selected in the config, ipDb is only used by
+// the postgres backend and may be nil otherwise. Panics when the backend is misconfigured.
+func NewLocationService(locationConfig config.LocationConfig, ipDb pg.Database) LocationService {
+	var (
+		backend locationBackend
+		err     error
+	)
+	switch locationConfig.Backend {
+	case BackendPostgres, "":
+		if ipDb == nil {
+			err = fmt.Errorf("postgres backend requires the IP database")
+		} else {
+			backend = newPostgresBackend(ipDb)
+		}
+	case BackendMaxMind:
+		backend, err = newMaxmindBackend(locationConfig.MaxMind.Path)
+	default:
+		err = fmt.Errorf("unknown backend %q, expected %s or %s", locationConfig.Backend, BackendPostgres, BackendMaxMind)
+	}
+	if err != nil {
+		panic("Failed to initialize location service - Properly configure location: " + err.Error())
+	}
+	return newLocationService(backend, locationConfig.CacheSize, locationConfig.CacheTTL)
+}
+
+func newLocationService(backend locationBackend, cacheSize int, cacheTTL time.Duration) *locationService {
+	if cacheSize <= 0 {
+		cacheSize = defaultCacheSize
+	}
+	if cacheTTL <= 0 {
+		cacheTTL = defaultCacheTTL
+	}
 	return &locationService{
-		log:            utils.NewServiceLogger("LocationService"),
-		ipQueryBuilder: pg.NewQueryBuilder[model.UserLocationInfo](db),
+		log:     utils.NewServiceLogger("LocationService"),
+		backend: backend,
+		cache:   newLocationCache(cacheSize, cacheTTL),
 	}
 }
 
-func (s *locationService) GetLocationByIp(ip string) (*model.UserLocationInfo, error) {
-	query := `SELECT country_name, city_name, latitude, longitude, time_zone, gmt_offset, locale_code 
-		FROM geoip2_network net
-		LEFT JOIN geoip2_location location ON (
-			net.geoname_id = location.geoname_id
-		)
-		WHERE network >>= $1`
+func (s *locationService) GetLocationByIp(ctx context.Context, ip string) (*model.UserLocationInfo, error) {
+	ctx, span := tracing.Start(ctx, "LocationService.GetLocationByIp")
+	defer span.End()
+
+	parsedIp := net.ParseIP(ip)
+	if parsedIp == nil {
+		s.log.WithContext(ctx).Debug("Not an IP address: %s", ip)
+		return unknownLocation(), nil
+	}
+
+	now := time.Now()
+	if locationData, ok := s.cache.get(ip, now); ok {
+		return locationData, nil
+	}
 
-	locationData, err := s.ipQueryBuilder.SingleQuery().FilterOne(query, ip)
+	locationData, err := s.backend.lookupIp(ctx, parsedIp)
 	if err != nil {
-		s.log.Error("Error getting location by IP: %s, error: %v", ip, err)
+		s.log.WithContext(ctx).Error("Error getting location by IP: %s, error: %v", ip, err)
 		return nil, err
 	}
 	if locationData == nil {
-		return model.NewUserLocationInfo(
-			"Unknown Country",
-			"Unknown City",
-			0,
-			0,
-			"Unknown Timezone",
-			"Unknown GMT",
-			"Unknown Local",
-		), nil
+		locationData = unknownLocation()
 	}
+	s.cache.set(ip, locationData, now)
 	return locationData, nil
 }
 
-func (s *locationService) GetLocationByLocaleCode(localeCode string) (*model.UserLocationInfo, error) {
-	query := `SELECT country_name, city_name, latitude, longitude, time_zone, gmt_offset, locale_code 
-		FROM geoip2_network net
-		LEFT JOIN geoip2_location location ON (
-			net.geoname_id = location.geoname_id
-		)
-		WHERE locale_code = $1`
+func (s *locationService) GetLocationByLocaleCode(ctx context.Context, localeCode string) (*model.UserLocationInfo, error) {
+	ctx, span := tracing.Start(ctx, "LocationService.GetLocationByLocaleCode")
+	defer span.End()
 
-	locationData, err := s.ipQueryBuilder.SingleQuery().FilterOne(query, localeCode)
+	locationData, err := s.backend.lookupLocaleCode(ctx, localeCode)
 	if err != nil {
-		s.log.Error("Error getting location by locale code: %s, error: %v", localeCode, err)
+		s.log.WithContext(ctx).Error("Error getting location by locale code: %s, error: %v", localeCode, err)
 		return nil, err
 	}
 	if locationData == nil {
-		return model.NewUserLocationInfo(
-			"Unknown Country",
-			"Unknown City",
-			0,
-			0,
-			"Unknown Timezone",
-			"Unknown GMT",
-			"Unknown Local",
-		), nil
+		return unknownLocation(), nil
 	}
 	return locationData, nil
 }
+
+func unknownLocation() *model.UserLocationInfo {
+	return model.NewUserLocationInfo(
+		"Unknown Country",
+		"Unknown City",
+		0,
+		0,
+		"Unknown Timezone",
+		"Unknown GMT",
+		"Unknown Local",
+	)
+}
@@ -0,0 +1,89 @@
+package location
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"sync-backend/api/common/location/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingBackend struct {
+	lookups   int
+	locations map[string]*model.UserLocationInfo
+}
+
+func (b *countingBackend) lookupIp(_ context.Context, ip net.IP) (*model.UserLocationInfo, error) {
+	b.lookups++
+	return b.locations[ip.String()], nil
+}
+
+func (b *countingBackend) lookupLocaleCode(_ context.Context, localeCode string) (*model.UserLocationInfo, error) {
+	return nil, errLocaleLookupUnsupported
+}
+
+func TestGetLocationByIpCachesLookups(t *testing.T) {
+	backend := &countingBackend{locations: map[string]*model.UserLocationInfo{
+		"81.2.69.142": model.NewUserLocationInfo("United Kingdom", "London", 51.5, -0.1, "Europe/London", "+00:00", "en"),
+	}}
+	service := newLocationService(backend, 10, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		location, err := service.GetLocationByIp(context.Background(), "81.2.69.142")
+		require.NoError(t, err)
+		assert.Equal(t, "London", location.City)
+	}
+	assert.Equal(t, 1, backend.lookups)
+
+	location, err := service.GetLocationByIp(context.Background(), "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, "Unknown City", location.City)
+	assert.Equal(t, 2, backend.lookups)
+
+	location, err = service.GetLocationByIp(context.Background(), "not-an-ip")
+	require.NoError(t, err)
+	assert.Equal(t, "Unknown City", location.City)
+	assert.Equal(t, 2, backend.lookups, "invalid addresses are not looked up")
+}
+
+func TestLocationCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	now := time.Now()
+	cache := newLocationCache(2, time.Minute)
+	cache.set("1.1.1.1", &model.UserLocationInfo{City: "a"}, now)
+	cache.set("2.2.2.2", &model.UserLocationInfo{City: "b"}, now)
+	_, ok := cache.get("1.1.1.1", now)
+	require.True(t, ok)
+
+	cache.set("3.3.3.3", &model.UserLocationInfo{City: "c"}, now)
+
+	_, ok = cache.get("2.2.2.2", now)
+	assert.False(t, ok)
+	_, ok = cache.get("1.1.1.1", now)
+	assert.True(t, ok)
+	_, ok = cache.get("3.3.3.3", now)
+	assert.True(t, ok)
+}
+
+func TestLocationCacheExpiresEntries(t *testing.T) {
+	now := time.Now()
+	cache := newLocationCache(2, time.Minute)
+	cache.set("1.1.1.1", &model.UserLocationInfo{City: "a"}, now)
+
+	_, ok := cache.get("1.1.1.1", now.Add(59*time.Second))
+	assert.True(t, ok)
+	_, ok = cache.get("1.1.1.1", now.Add(61*time.Second))
+	assert.False(t, ok)
+}
+
+func TestGmtOffset(t *testing.T) {
+	winter := time.Date(2025, time.January, 15, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, "+05:30", gmtOffset("Asia/Kolkata", winter))
+	assert.Equal(t, "-05:00", gmtOffset("America/New_York", winter))
+	assert.Equal(t, "-04:00", gmtOffset("America/New_York", winter.AddDate(0, 6, 0)))
+	assert.Equal(t, "", gmtOffset("Mars/Olympus", winter))
+	assert.Equal(t, "", gmtOffset("", winter))
+}
@@ -0,0 +1,152 @@
+package media
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sync-backend/api/common/media/model"
+	"sync-backend/arch/config"
+	"sync-backend/utils"
+)
+
+var ErrInvalidSignature = errors.New("invalid media url signature")
+
+// localDriver stores files below a root directory and hands out HMAC signed URLs served by the API.
+// The URLs are stored with the content, so they do not expire.
+type localDriver struct {
+	logger  utils.AppLogger
+	root    string
+	baseURL string
+	secret  []byte
+}
+
+func newLocalDriver(config config.LocalStorageConfig, baseURL string, secret string) (*localDriver, error) {
+	if config.Root == "" {
+		return nil, fmt.Errorf("local driver requires media.storage.local.root")
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("local driver requires a signing secret")
+	}
+	root, err := filepath.Abs(config.Root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &localDriver{
+		logger:  utils.NewServiceLogger("MediaService"),
+		root:    root,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		secret:  []byte(secret),
+	}, nil
+}
+
+func (s *localDriver) Driver() string {
+	return DriverLocal
+}
+
+func (s *localDriver) UploadMedia(filePath string, filename string, folderPath string) (model.MediaInfo, error) {
+	s.logger.Debug("Storing media file: %s", filePath)
+	info, err := inspectFile(filePath)
+	if err != nil {
+		s.logger.Error("Failed to inspect file %s: %v", filePath, err)
+		return model.MediaInfo{}, err
+	}
+
+	key := objectKey(folderPath, filename, filePath)
+	target, err := s.path(key)
+	if err != nil {
+		return model.MediaInfo{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		s.logger.Error("Failed to create media directory for %s: %v", key, err)
+		return model.MediaInfo{}, err
+	}
+	// The staged upload is removed by the upload middleware, so copy instead of moving it
+	if err := copyFile(filePath, target); err != nil {
+		s.logger.Error("Failed to store file %s: %v", key, err)
+		return model.MediaInfo{}, err
+	}
+
+	s.logger.Info("Media stored successfully: key=%s", key)
+	return model.MediaInfo{
+		Id:     key,
+		Type:   string(info.kind),
+		Url:    s.signedURL(key),
+		Width:  info.width,
+		Height: info.height,
+		Size:   info.size,
+	}, nil
+}
+
+func (s *localDriver) DeleteMedia(publicID string) error {
+	s.logger.Debug("Deleting media file with key: %s", publicID)
+	target, err := s.path(publicID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		s.logger.Error("Failed to delete file %s: %v", publicID, err)
+		return err
+	}
+	s.logger.Info("Media deleted successfully: key=%s", publicID)
+	return nil
+}
+
+// ResolveSignedPath checks the signature of a URL and returns the file on disk it points at
+func (s *localDriver) ResolveSignedPath(key string, signature string) (string, error) {
+	key = strings.TrimPrefix(key, "/")
+	if !hmac.Equal([]byte(signature), []byte(s.sign(key))) {
+		return "", ErrInvalidSignature
+	}
+	return s.path(key)
+}
+
+func (s *localDriver) signedURL(key string) string {
+	query := url.Values{}
+	query.Set("signature", s.sign(key))
+	return fmt.Sprintf("%s/%s?%s", s.baseURL, key, query.Encode())
+}
+
+func (s *localDriver) sign(key string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// path maps a key to a file below the root, rejecting keys escaping it
+func (s *localDriver) path(key string) (string, error) {
+	target := filepath.Join(s.root, filepath.FromSlash(key))
+	if !strings.HasPrefix(target, s.root+string(filepath.Separator)) {
+		return "", fmt.Errorf("media key %q resolves outside of the storage root", key)
+	}
+	return target, nil
+}
+
+func copyFile(source string, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(target)
+		return err
+	}
+	return out.Close()
+}
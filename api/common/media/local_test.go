@@ -0,0 +1,65 @@
+package media
+
+import (
+	"image"
+	"image/png"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sync-backend/arch/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePNG(t *testing.T, dir string, width int, height int) string {
+	path := filepath.Join(dir, "upload.png")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer file.Close()
+	require.NoError(t, png.Encode(file, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return path
+}
+
+func signedParts(t *testing.T, rawURL string, baseURL string) (string, string) {
+	parsed, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	key := strings.TrimPrefix(parsed.Scheme+"://"+parsed.Host+parsed.Path, baseURL+"/")
+	return key, parsed.Query().Get("signature")
+}
+
+func TestLocalDriver_SignedRoundTrip(t *testing.T) {
+	baseURL := "http://localhost:8080/api/v1/media/file"
+	driver, err := newLocalDriver(config.LocalStorageConfig{Root: t.TempDir()}, baseURL, "secret")
+	require.NoError(t, err)
+
+	info, err := driver.UploadMedia(writePNG(t, t.TempDir(), 3, 2), "user_media", "posts/media")
+	require.NoError(t, err)
+	assert.Equal(t, "image", info.Type)
+	assert.Equal(t, 3, info.Width)
+	assert.Equal(t, 2, info.Height)
+
+	key, signature := signedParts(t, info.Url, baseURL)
+	assert.Equal(t, info.Id, key)
+
+	path, err := driver.ResolveSignedPath("/"+key, signature)
+	require.NoError(t, err)
+	assert.FileExists(t, path)
+
+	_, err = driver.ResolveSignedPath(key, signature+"x")
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	require.NoError(t, driver.DeleteMedia(key))
+	assert.NoFileExists(t, path)
+}
+
+func TestLocalDriver_Traversal(t *testing.T) {
+	driver, err := newLocalDriver(config.LocalStorageConfig{Root: t.TempDir()}, "http://h/f", "secret")
+	require.NoError(t, err)
+
+	_, err = driver.ResolveSignedPath("../escape", driver.sign("../escape"))
+	assert.Error(t, err)
+}
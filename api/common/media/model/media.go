@@ -1,12 +1,35 @@
 package model
 
 type MediaInfo struct {
-	Id     string `json:"id" bson:"id"`
-	Type   string `json:"type" bson:"type"`
+	Id       string `json:"id" bson:"id"`
+	Type     string `json:"type" bson:"type"`
+	MimeType string `json:"mimeType,omitempty" bson:"mimeType,omitempty"`
+	Url      string `json:"url" bson:"url"`
+	Width    int    `json:"width" bson:"width"`
+	Height   int    `json:"height" bson:"height"`
+	Size     int64  `json:"size" bson:"size"`
+
+	// Only set for images processed by the image pipeline
+	Renditions    []ImageRendition `json:"renditions,omitempty" bson:"renditions,omitempty"`
+	BlurHash      string           `json:"blurHash,omitempty" bson:"blurHash,omitempty"`
+	DominantColor string           `json:"dominantColor,omitempty" bson:"dominantColor,omitempty"`
+}
+
+// StorageIds returns the storage IDs of the file and all of its renditions
+func (m MediaInfo) StorageIds() []string {
+	ids := []string{m.Id}
+	for _, rendition := range m.Renditions {
+		ids = append(ids, rendition.Id)
+	}
+	return ids
+}
+
+// ImageRendition is a downscaled copy of an image stored next to the original
+type ImageRendition struct {
+	Id     string `json:"-" bson:"id"`
 	Url    string `json:"url" bson:"url"`
 	Width  int    `json:"width" bson:"width"`
 	Height int    `json:"height" bson:"height"`
-	Size   int64  `json:"size" bson:"size"`
 }
 
 type MediaMimeType string
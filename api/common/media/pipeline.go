@@ -0,0 +1,255 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"slices"
+
+	"sync-backend/arch/config"
+	"sync-backend/utils"
+
+	"github.com/buckket/go-blurhash"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// ErrUnsupportedImage is returned for files that are not a decodable JPEG, PNG, GIF or WebP image
+var ErrUnsupportedImage = errors.New("unsupported image")
+
+// Width of the thumbnail the blurhash and dominant color are computed from
+const placeholderWidth = 64
+
+// ImagePipeline verifies and normalizes uploaded images before they are stored
+type ImagePipeline interface {
+	Process(filePath string) (*ProcessedImage, error)
+}
+
+// ProcessedImage is the result of the pipeline, its files are temporary and removed by Cleanup
+type ProcessedImage struct {
+	Path          string
+	MimeType      string
+	Width         int
+	Height        int
+	Renditions    []ProcessedRendition
+	BlurHash      string
+	DominantColor string
+	tempFiles     []string
+}
+
+// ProcessedRendition is a downscaled copy of a processed image
+type ProcessedRendition struct {
+	Path   string
+	Width  int
+	Height int
+}
+
+// Cleanup removes the temporary files written by the pipeline
+func (p *ProcessedImage) Cleanup() {
+	for _, path := range p.tempFiles {
+		os.Remove(path)
+	}
+}
+
+type imagePipeline struct {
+	logger utils.AppLogger
+	config config.ImageConfig
+}
+
+func NewImagePipeline(imageConfig config.ImageConfig) ImagePipeline {
+	if imageConfig.MaxDimension <= 0 {
+		imageConfig.MaxDimension = 2560
+	}
+	if imageConfig.JPEGQuality <= 0 || imageConfig.JPEGQuality > 100 {
+		imageConfig.JPEGQuality = 85
+	}
+	if imageConfig.BlurHashXComps <= 0 || imageConfig.BlurHashXComps > 9 {
+		imageConfig.BlurHashXComps = 4
+	}
+	if imageConfig.BlurHashYComps <= 0 || imageConfig.BlurHashYComps > 9 {
+		imageConfig.BlurHashYComps = 3
+	}
+	if imageConfig.MaxDecodedPixels <= 0 {
+		imageConfig.MaxDecodedPixels = 50_000_000
+	}
+	imageConfig.RenditionWidths = slices.Clone(imageConfig.RenditionWidths)
+	slices.Sort(imageConfig.RenditionWidths)
+	return &imagePipeline{
+		logger: utils.NewServiceLogger("ImagePipeline"),
+		config: imageConfig,
+	}
+}
+
+// Process decodes the image, which verifies the content matches its magic bytes, and re-encodes it.
+// Re-encoding drops all metadata including EXIF GPS, the EXIF orientation is applied first. Animated
+// GIFs are kept as-is since GIF carries no EXIF and re-encoding would drop the animation.
+func (p *imagePipeline) Process(filePath string) (*ProcessedImage, error) {
+	mimeType, err := utils.DetectContentType(filePath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var decodeConfig func(*bytes.Reader) (image.Config, error)
+	switch mimeType {
+	case "image/jpeg":
+		decodeConfig = func(r *bytes.Reader) (image.Config, error) { return jpeg.DecodeConfig(r) }
+	case "image/png":
+		decodeConfig = func(r *bytes.Reader) (image.Config, error) { return png.DecodeConfig(r) }
+	case "image/gif":
+		decodeConfig = func(r *bytes.Reader) (image.Config, error) { return gif.DecodeConfig(r) }
+	case "image/webp":
+		decodeConfig = func(r *bytes.Reader) (image.Config, error) { return webp.DecodeConfig(r) }
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedImage, mimeType)
+	}
+
+	// Check the size before decoding so a tiny file cannot claim a huge canvas
+	imageConfig, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	if imageConfig.Width*imageConfig.Height > p.config.MaxDecodedPixels {
+		return nil, fmt.Errorf("%w: %dx%d exceeds %d pixels", ErrUnsupportedImage, imageConfig.Width, imageConfig.Height, p.config.MaxDecodedPixels)
+	}
+
+	result := &ProcessedImage{MimeType: mimeType}
+	if mimeType == "image/gif" {
+		animation, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+		}
+		first := animation.Image[0]
+		result.Path = filePath
+		result.Width, result.Height = imageConfig.Width, imageConfig.Height
+		p.placeholder(result, first)
+		return result, nil
+	}
+
+	var img image.Image
+	switch mimeType {
+	case "image/jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case "image/png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "image/webp":
+		img, err = webp.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+
+	img = fit(img, p.config.MaxDimension)
+	if mimeType == "image/jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	// WebP has no encoder in the standard library, store it as JPEG or as PNG when it has transparency
+	encodeAs := mimeType
+	if mimeType == "image/webp" {
+		encodeAs = "image/jpeg"
+		if !isOpaque(img) {
+			encodeAs = "image/png"
+		}
+	}
+	result.MimeType = encodeAs
+
+	path, err := p.write(result, img, encodeAs)
+	if err != nil {
+		result.Cleanup()
+		return nil, err
+	}
+	result.Path = path
+	result.Width, result.Height = img.Bounds().Dx(), img.Bounds().Dy()
+
+	for _, width := range p.config.RenditionWidths {
+		if width <= 0 || width >= result.Width {
+			continue
+		}
+		rendition := scale(img, width, max(1, result.Height*width/result.Width))
+		path, err := p.write(result, rendition, encodeAs)
+		if err != nil {
+			result.Cleanup()
+			return nil, err
+		}
+		result.Renditions = append(result.Renditions, ProcessedRendition{
+			Path:   path,
+			Width:  rendition.Bounds().Dx(),
+			Height: rendition.Bounds().Dy(),
+		})
+	}
+
+	p.placeholder(result, img)
+	return result, nil
+}
+
+// placeholder computes the blurhash and dominant color, a failure only leaves them empty
+func (p *imagePipeline) placeholder(result *ProcessedImage, img image.Image) {
+	thumb := fit(img, placeholderWidth)
+	hash, err := blurhash.Encode(p.config.BlurHashXComps, p.config.BlurHashYComps, thumb)
+	if err != nil {
+		p.logger.Warn("Failed to compute blurhash: %v", err)
+	} else {
+		result.BlurHash = hash
+	}
+	if colors, err := utils.GetImageDominantColors(thumb); err == nil && len(colors) > 0 {
+		result.DominantColor = colors[0]
+	}
+}
+
+func (p *imagePipeline) write(result *ProcessedImage, img image.Image, mimeType string) (string, error) {
+	ext := ".jpg"
+	if mimeType == "image/png" {
+		ext = ".png"
+	}
+	file, err := os.CreateTemp("", "sync-image-*"+ext)
+	if err != nil {
+		return "", err
+	}
+	result.tempFiles = append(result.tempFiles, file.Name())
+	defer file.Close()
+
+	if mimeType == "image/png" {
+		err = png.Encode(file, img)
+	} else {
+		err = jpeg.Encode(file, img, &jpeg.Options{Quality: p.config.JPEGQuality})
+	}
+	if err != nil {
+		return "", err
+	}
+	return file.Name(), file.Close()
+}
+
+// fit downscales an image so neither side exceeds maxSide, smaller images are returned unchanged
+func fit(img image.Image, maxSide int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= maxSide && h <= maxSide {
+		return img
+	}
+	if w >= h {
+		return scale(img, maxSide, max(1, h*maxSide/w))
+	}
+	return scale(img, max(1, w*maxSide/h), maxSide)
+}
+
+func scale(img image.Image, width int, height int) image.Image {
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return dst
+}
+
+func isOpaque(img image.Image) bool {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok {
+		return opaque.Opaque()
+	}
+	return false
+}
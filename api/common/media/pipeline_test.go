@@ -0,0 +1,90 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sync-backend/arch/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeJPEGWithOrientation writes a JPEG whose EXIF block carries the orientation and a GPS-like marker
+func writeJPEGWithOrientation(t *testing.T, dir string, width int, height int, orientation uint16) string {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var encoded bytes.Buffer
+	require.NoError(t, jpeg.Encode(&encoded, img, nil))
+
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry[0:], 0x0112)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], orientation)
+	tiff = append(tiff, entry...)
+	tiff = append(tiff, []byte("\x00\x00\x00\x00GPSSECRET")...)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+
+	data := append([]byte{0xFF, 0xD8}, app1...)
+	data = append(data, segment...)
+	data = append(data, encoded.Bytes()[2:]...)
+
+	path := filepath.Join(dir, "photo.png")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestImagePipeline_StripsExifAndAppliesOrientation(t *testing.T) {
+	path := writeJPEGWithOrientation(t, t.TempDir(), 200, 100, 6)
+	pipeline := NewImagePipeline(config.ImageConfig{RenditionWidths: []int{80, 400}})
+
+	processed, err := pipeline.Process(path)
+	require.NoError(t, err)
+	defer processed.Cleanup()
+
+	assert.Equal(t, "image/jpeg", processed.MimeType, "type comes from the content, not the .png name")
+	assert.Equal(t, 100, processed.Width)
+	assert.Equal(t, 200, processed.Height)
+	assert.NotEmpty(t, processed.BlurHash)
+	assert.Regexp(t, `^#[0-9A-F]{6}$`, processed.DominantColor)
+
+	output, err := os.ReadFile(processed.Path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, jpegOrientation(output))
+	assert.NotContains(t, string(output), "GPSSECRET")
+
+	require.Len(t, processed.Renditions, 1, "renditions wider than the image are skipped")
+	assert.Equal(t, 80, processed.Renditions[0].Width)
+	assert.Equal(t, 160, processed.Renditions[0].Height)
+
+	processed.Cleanup()
+	assert.NoFileExists(t, processed.Path)
+}
+
+func TestImagePipeline_RejectsNonImages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fake.jpg")
+	require.NoError(t, os.WriteFile(path, []byte("definitely not a jpeg"), 0o600))
+
+	_, err := NewImagePipeline(config.ImageConfig{}).Process(path)
+	assert.ErrorIs(t, err, ErrUnsupportedImage)
+
+	truncated := filepath.Join(t.TempDir(), "broken.jpg")
+	require.NoError(t, os.WriteFile(truncated, []byte("\xFF\xD8\xFF\xE0\x00\x10JFIF"), 0o600))
+	_, err = NewImagePipeline(config.ImageConfig{}).Process(truncated)
+	assert.ErrorIs(t, err, ErrUnsupportedImage)
+}
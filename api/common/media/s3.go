@@ -0,0 +1,97 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"sync-backend/api/common/media/model"
+	"sync-backend/arch/config"
+	"sync-backend/utils"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// s3Driver stores files in an S3-compatible bucket such as AWS S3, MinIO or R2
+type s3Driver struct {
+	logger    utils.AppLogger
+	client    *minio.Client
+	bucket    string
+	publicURL string
+}
+
+func newS3Driver(config config.S3StorageConfig, accessKey string, secretKey string) (*s3Driver, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, fmt.Errorf("s3 driver requires media.storage.s3.endpoint and media.storage.s3.bucket")
+	}
+	if accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("s3 driver requires S3_ACCESS_KEY and S3_SECRET_KEY")
+	}
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: config.UseSSL,
+		Region: config.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publicURL := strings.TrimSuffix(config.PublicURL, "/")
+	if publicURL == "" {
+		scheme := "http"
+		if config.UseSSL {
+			scheme = "https"
+		}
+		publicURL = fmt.Sprintf("%s://%s/%s", scheme, config.Endpoint, config.Bucket)
+	}
+
+	return &s3Driver{
+		logger:    utils.NewServiceLogger("MediaService"),
+		client:    client,
+		bucket:    config.Bucket,
+		publicURL: publicURL,
+	}, nil
+}
+
+func (s *s3Driver) Driver() string {
+	return DriverS3
+}
+
+func (s *s3Driver) UploadMedia(filePath string, filename string, folderPath string) (model.MediaInfo, error) {
+	s.logger.Debug("Uploading media file: %s", filePath)
+	info, err := inspectFile(filePath)
+	if err != nil {
+		s.logger.Error("Failed to inspect file %s: %v", filePath, err)
+		return model.MediaInfo{}, err
+	}
+
+	key := objectKey(folderPath, filename, filePath)
+	_, err = s.client.FPutObject(context.Background(), s.bucket, key, filePath, minio.PutObjectOptions{
+		ContentType: info.mimeType,
+	})
+	if err != nil {
+		s.logger.Error("Failed to upload file to bucket %s: %v", s.bucket, err)
+		return model.MediaInfo{}, err
+	}
+
+	s.logger.Info("Media uploaded successfully: key=%s", key)
+	return model.MediaInfo{
+		Id:     key,
+		Type:   string(info.kind),
+		Url:    fmt.Sprintf("%s/%s", s.publicURL, key),
+		Width:  info.width,
+		Height: info.height,
+		Size:   info.size,
+	}, nil
+}
+
+func (s *s3Driver) DeleteMedia(publicID string) error {
+	s.logger.Debug("Deleting media file with key: %s", publicID)
+	if err := s.client.RemoveObject(context.Background(), s.bucket, publicID, minio.RemoveObjectOptions{}); err != nil {
+		s.logger.Error("Failed to delete file from bucket %s: %v", s.bucket, err)
+		return err
+	}
+	s.logger.Info("Media deleted successfully: key=%s", publicID)
+	return nil
+}
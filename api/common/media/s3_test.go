@@ -0,0 +1,108 @@
+package media
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"sync-backend/arch/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type s3Request struct {
+	method      string
+	path        string
+	contentType string
+	size        int
+}
+
+// fakeS3Server answers object PUTs and DELETEs of a path-style bucket and records them. Requests for
+// other buckets get the AccessDenied error S3 returns for buckets the credentials can not write.
+func fakeS3Server(t *testing.T, bucket string) (string, func() []s3Request) {
+	var mu sync.Mutex
+	var requests []s3Request
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		size := len(body)
+		// Over plain HTTP the client signs the body in aws-chunked encoding and sends its length apart
+		if decoded := r.Header.Get("X-Amz-Decoded-Content-Length"); decoded != "" {
+			size, _ = strconv.Atoi(decoded)
+		}
+		mu.Lock()
+		requests = append(requests, s3Request{method: r.Method, path: r.URL.Path, contentType: r.Header.Get("Content-Type"), size: size})
+		mu.Unlock()
+
+		if !strings.HasPrefix(r.URL.Path, "/"+bucket+"/") {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+			w.WriteHeader(http.StatusOK)
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return strings.TrimPrefix(server.URL, "http://"), func() []s3Request {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]s3Request(nil), requests...)
+	}
+}
+
+func TestS3Driver_UploadAndDelete(t *testing.T) {
+	endpoint, requests := fakeS3Server(t, "sync-media")
+	driver, err := newS3Driver(config.S3StorageConfig{
+		Endpoint:  endpoint,
+		Region:    "us-east-1",
+		Bucket:    "sync-media",
+		PublicURL: "https://cdn.sync.test/",
+	}, "access", "secret")
+	require.NoError(t, err)
+
+	info, err := driver.UploadMedia(writePNG(t, t.TempDir(), 3, 2), "user_media", "posts/media")
+	require.NoError(t, err)
+	assert.Equal(t, "image", info.Type)
+	assert.Equal(t, 3, info.Width)
+	assert.Equal(t, 2, info.Height)
+	assert.Contains(t, info.Id, "posts/media/user_media-")
+	assert.Equal(t, "https://cdn.sync.test/"+info.Id, info.Url)
+
+	require.NoError(t, driver.DeleteMedia(info.Id))
+
+	recorded := requests()
+	require.Len(t, recorded, 2)
+	assert.Equal(t, http.MethodPut, recorded[0].method)
+	assert.Equal(t, "/sync-media/"+info.Id, recorded[0].path)
+	assert.Equal(t, "image/png", recorded[0].contentType)
+	assert.Equal(t, int(info.Size), recorded[0].size)
+	assert.Equal(t, http.MethodDelete, recorded[1].method)
+	assert.Equal(t, "/sync-media/"+info.Id, recorded[1].path)
+}
+
+func TestS3Driver_DefaultPublicURLAndRejectedUpload(t *testing.T) {
+	endpoint, _ := fakeS3Server(t, "other-bucket")
+	driver, err := newS3Driver(config.S3StorageConfig{Endpoint: endpoint, Region: "us-east-1", Bucket: "sync-media"}, "access", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, "http://"+endpoint+"/sync-media", driver.publicURL)
+
+	_, err = driver.UploadMedia(writePNG(t, t.TempDir(), 1, 1), "user_media", "posts/media")
+	assert.ErrorContains(t, err, "Access Denied")
+
+	_, err = newS3Driver(config.S3StorageConfig{Endpoint: endpoint, Bucket: "sync-media"}, "", "")
+	assert.Error(t, err)
+}
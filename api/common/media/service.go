@@ -1,92 +1,194 @@
 package media
 
 import (
-	"context"
+	"encoding/hex"
 	"fmt"
+	"mime"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"sync-backend/api/common/media/model"
 	"sync-backend/arch/config"
 	"sync-backend/utils"
 
-	"sync-backend/api/common/media/model"
+	"github.com/google/uuid"
+)
 
-	"github.com/cloudinary/cloudinary-go/v2"
-	"github.com/cloudinary/cloudinary-go/v2/api"
-	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+// Storage drivers selectable through media.storage.driver
+const (
+	DriverLocal      = "local"
+	DriverS3         = "s3"
+	DriverCloudinary = "cloudinary"
 )
 
 type MediaService interface {
 	UploadMedia(filePath string, filename string, folderPath string) (model.MediaInfo, error)
 	DeleteMedia(publicID string) error
+	Driver() string
 }
 
-type mediaService struct {
-	logger        utils.AppLogger
-	mediaUploader *cloudinary.Cloudinary
+// LocalFiles is implemented by drivers storing files on this server, the media controller serves
+// them through a signed URL route
+type LocalFiles interface {
+	ResolveSignedPath(key string, signature string) (string, error)
 }
 
-func NewMediaService(env config.Env) MediaService {
-	cld, err := cloudinary.NewFromParams(env.CloudinaryCloudName, env.CloudinaryAPIKey, env.CloudinaryAPISecret)
+// NewMediaService creates the storage driver selected in the config, panics when it is misconfigured
+func NewMediaService(env config.Env, config *config.Config) MediaService {
+	var (
+		service MediaService
+		err     error
+	)
+	storage := config.Media.Storage
+	switch storage.Driver {
+	case DriverLocal, "":
+		baseURL := fmt.Sprintf("%s%s/v%s/media/file", strings.TrimSuffix(env.AppBackendURL, "/"), config.API.Prefix, config.API.Version)
+		service, err = newLocalDriver(storage.Local, baseURL, mediaSigningKey(env))
+	case DriverS3:
+		service, err = newS3Driver(storage.S3, env.S3AccessKey, env.S3SecretKey)
+	case DriverCloudinary:
+		service, err = newCloudinaryDriver(env)
+	default:
+		err = fmt.Errorf("unknown driver %q, expected one of %s, %s or %s", storage.Driver, DriverLocal, DriverS3, DriverCloudinary)
+	}
 	if err != nil {
-		panic("Failed to initialize Cloudinary - Properly initialize Cloudinary: " + err.Error())
+		panic("Failed to initialize media storage - Properly configure media.storage: " + err.Error())
 	}
-	return &mediaService{
-		logger:        utils.NewServiceLogger("MediaService"),
-		mediaUploader: cld,
+	return &processingService{
+		MediaService: service,
+		logger:       utils.NewServiceLogger("MediaService"),
+		pipeline:     NewImagePipeline(config.Media.Images),
 	}
 }
 
-func (s *mediaService) UploadMedia(filePath string, filename string, folderPath string) (model.MediaInfo, error) {
-	s.logger.Debug("Uploading media file: %s", filePath)
-	file, err := os.Open(filePath)
+// mediaSigningKey is the key signing local file URLs, derived from JWT_SECRET when MEDIA_SIGNING_KEY
+// is unset so a file signature can never pass as a token signature
+func mediaSigningKey(env config.Env) string {
+	if env.MediaSigningKey != "" {
+		return env.MediaSigningKey
+	}
+	return hex.EncodeToString(utils.DeriveKey(env.JWTSecret, "media-signed-url"))
+}
+
+// AsLocalFiles returns the storage driver behind the service when it serves files itself
+func AsLocalFiles(service MediaService) (LocalFiles, bool) {
+	if processing, ok := service.(*processingService); ok {
+		service = processing.MediaService
+	}
+	localFiles, ok := service.(LocalFiles)
+	return localFiles, ok
+}
+
+// processingService runs images through the image pipeline and stores the result and its
+// renditions with the wrapped driver, other files are passed through untouched
+type processingService struct {
+	MediaService
+	logger   utils.AppLogger
+	pipeline ImagePipeline
+}
+
+func (s *processingService) UploadMedia(filePath string, filename string, folderPath string) (model.MediaInfo, error) {
+	mimeType, err := utils.DetectContentType(filePath)
 	if err != nil {
-		s.logger.Error("Failed to open file %s: %v", filePath, err)
 		return model.MediaInfo{}, err
 	}
-	defer file.Close()
+	if !strings.HasPrefix(mimeType, "image/") {
+		info, err := s.MediaService.UploadMedia(filePath, filename, folderPath)
+		info.MimeType = mimeType
+		return info, err
+	}
 
-	uploadFolder := fmt.Sprintf("sync-backend/%s", folderPath)
-	uploadParams := uploader.UploadParams{
-		PublicID:       filename,
-		Folder:         uploadFolder,
-		UniqueFilename: api.Bool(true),
-		Overwrite:      api.Bool(true),
+	processed, err := s.pipeline.Process(filePath)
+	if err != nil {
+		s.logger.Error("Failed to process image %s: %v", filePath, err)
+		return model.MediaInfo{}, err
 	}
-	resp, err := s.mediaUploader.Upload.Upload(context.Background(), file, uploadParams)
+	defer processed.Cleanup()
 
+	info, err := s.MediaService.UploadMedia(processed.Path, filename, folderPath)
 	if err != nil {
-		s.logger.Error("Failed to upload file to Cloudinary: %v", err)
 		return model.MediaInfo{}, err
 	}
+	info.Type = string(model.MediaMimeTypeImage)
+	info.MimeType = processed.MimeType
+	info.Width, info.Height = processed.Width, processed.Height
+	info.BlurHash = processed.BlurHash
+	info.DominantColor = processed.DominantColor
 
-	s.logger.Info("Media uploaded successfully: publicID=%s, url=%s", resp.PublicID, resp.SecureURL)
+	for _, rendition := range processed.Renditions {
+		stored, err := s.MediaService.UploadMedia(rendition.Path, fmt.Sprintf("%s_w%d", filename, rendition.Width), folderPath)
+		if err != nil {
+			s.logger.Error("Failed to store %dpx rendition of %s: %v", rendition.Width, info.Id, err)
+			for _, id := range info.StorageIds() {
+				if err := s.DeleteMedia(id); err != nil {
+					s.logger.Error("Failed to delete partially stored image %s: %v", id, err)
+				}
+			}
+			return model.MediaInfo{}, err
+		}
+		info.Renditions = append(info.Renditions, model.ImageRendition{
+			Id:     stored.Id,
+			Url:    stored.Url,
+			Width:  rendition.Width,
+			Height: rendition.Height,
+		})
+	}
+	return info, nil
+}
 
-	return model.MediaInfo{
-		Id:     resp.PublicID,
-		Type:   resp.ResourceType,
-		Url:    resp.SecureURL,
-		Width:  resp.Width,
-		Height: resp.Height,
-		Size:   int64(resp.Bytes),
-	}, nil
+// fileInfo is what the self-hosted drivers learn about a file before storing it
+type fileInfo struct {
+	mimeType string
+	kind     model.MediaMimeType
+	width    int
+	height   int
+	size     int64
 }
 
-func (s *mediaService) DeleteMedia(publicID string) error {
-	s.logger.Debug("Deleting media file with publicID: %s", publicID)
-	deleteParams := uploader.DestroyParams{
-		PublicID: publicID,
+// inspectFile sniffs the content type of a file and reads the dimensions of images
+func inspectFile(filePath string) (fileInfo, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fileInfo{}, err
 	}
-	resp, err := s.mediaUploader.Upload.Destroy(context.Background(), deleteParams)
+	defer file.Close()
+
+	stat, err := file.Stat()
 	if err != nil {
-		s.logger.Error("Failed to delete file from Cloudinary: %v", err)
-		return err
+		return fileInfo{}, err
 	}
 
-	if resp.Result != "ok" {
-		s.logger.Error("Failed to delete file from Cloudinary: %s", resp.Result)
-		return fmt.Errorf("failed to delete file from Cloudinary: %s", resp.Result)
+	head := make([]byte, 512)
+	n, _ := file.Read(head)
+	mimeType := http.DetectContentType(head[:n])
+	if mimeType == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(strings.ToLower(filepath.Ext(filePath))); byExt != "" {
+			mimeType = byExt
+		}
 	}
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+
+	info := fileInfo{mimeType: mimeType, kind: model.MediaMimeTypeDocument, size: stat.Size()}
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		info.kind = model.MediaMimeTypeImage
+		// Formats without a registered decoder simply have no dimensions
+		if width, height, err := utils.GetImageSizeFromFile(filePath); err == nil {
+			info.width, info.height = width, height
+		}
+	case strings.HasPrefix(mimeType, "video/"):
+		info.kind = model.MediaMimeTypeVideo
+	case strings.HasPrefix(mimeType, "audio/"):
+		info.kind = model.MediaMimeTypeAudio
+	}
+	return info, nil
+}
 
-	s.logger.Info("Media deleted successfully: publicID=%s", publicID)
-	return nil
+// objectKey builds a unique storage key from the folder and file name, keeping the extension
+func objectKey(folderPath string, filename string, sourcePath string) string {
+	name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	ext := strings.ToLower(filepath.Ext(sourcePath))
+	return fmt.Sprintf("sync-backend/%s/%s-%s%s", strings.Trim(folderPath, "/"), name, uuid.New().String()[:8], ext)
 }
@@ -0,0 +1,169 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"sync-backend/api/user/model"
+	"sync-backend/arch/config"
+	"sync-backend/arch/tracing"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	apnsProductionHost = "https://api.push.apple.com"
+	apnsSandboxHost    = "https://api.sandbox.push.apple.com"
+
+	// Apple rejects provider tokens older than an hour and throttles refreshes more often than every 20 minutes
+	apnsTokenLifetime = 50 * time.Minute
+)
+
+// apnsDispatcher sends over HTTP/2 to the APNs provider API, authenticating with ES256 provider tokens
+type apnsDispatcher struct {
+	client *http.Client
+	host   string
+	keyId  string
+	teamId string
+	topic  string
+	key    *ecdsa.PrivateKey
+
+	mu            sync.Mutex
+	providerToken string
+	issuedAt      time.Time
+}
+
+func newAPNsDispatcher(authKeyFile string, apnsConfig config.APNsConfig) (*apnsDispatcher, error) {
+	if apnsConfig.KeyId == "" || apnsConfig.TeamId == "" || apnsConfig.Topic == "" {
+		return nil, fmt.Errorf("apns requires push.apns.key_id, team_id and topic")
+	}
+	data, err := os.ReadFile(authKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading APNS_AUTH_KEY_FILE: %w", err)
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing APNS_AUTH_KEY_FILE: %w", err)
+	}
+
+	host := apnsSandboxHost
+	if apnsConfig.Production {
+		host = apnsProductionHost
+	}
+	return &apnsDispatcher{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			// A custom TLS config turns off HTTP/2 unless it is forced, APNs only speaks HTTP/2
+			Transport: tracing.NewTransport(&http.Transport{
+				ForceAttemptHTTP2: true,
+				TLSClientConfig:   &tls.Config{MinVersion: tls.VersionTLS12},
+			}),
+		},
+		host:   host,
+		keyId:  apnsConfig.KeyId,
+		teamId: apnsConfig.TeamId,
+		topic:  apnsConfig.Topic,
+		key:    key,
+	}, nil
+}
+
+func (d *apnsDispatcher) Name() string {
+	return string(model.DeviceTokenAPNs)
+}
+
+func (d *apnsDispatcher) Send(ctx context.Context, token string, notification Notification) error {
+	providerToken, err := d.getProviderToken()
+	if err != nil {
+		return err
+	}
+
+	// Custom data sits next to aps at the top level of the payload
+	payload := map[string]interface{}{}
+	for key, value := range notification.Data {
+		payload[key] = value
+	}
+	payload["aps"] = map[string]interface{}{
+		"alert": map[string]string{"title": notification.Title, "body": notification.Body},
+		"sound": "default",
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, d.host+"/3/device/"+token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("authorization", "bearer "+providerToken)
+	request.Header.Set("apns-topic", d.topic)
+	request.Header.Set("apns-push-type", "alert")
+	request.Header.Set("apns-priority", "10")
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(response.Body, 64<<10))
+	var errorResponse struct {
+		Reason string `json:"reason"`
+	}
+	_ = json.Unmarshal(data, &errorResponse)
+
+	// See https://developer.apple.com/documentation/usernotifications/handling-notification-responses-from-apns
+	switch {
+	case response.StatusCode == http.StatusGone,
+		errorResponse.Reason == "BadDeviceToken",
+		errorResponse.Reason == "DeviceTokenNotForTopic":
+		return invalidTokenError("apns returned status %d %s", response.StatusCode, errorResponse.Reason)
+	case errorResponse.Reason == "ExpiredProviderToken":
+		d.resetProviderToken()
+	}
+	return fmt.Errorf("apns returned status %d %s: %s", response.StatusCode, errorResponse.Reason, strings.TrimSpace(string(data)))
+}
+
+// getProviderToken returns the cached provider token, signing a new one when it gets old
+func (d *apnsDispatcher) getProviderToken() (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if d.providerToken != "" && now.Sub(d.issuedAt) < apnsTokenLifetime {
+		return d.providerToken, nil
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": d.teamId,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = d.keyId
+	signed, err := token.SignedString(d.key)
+	if err != nil {
+		return "", fmt.Errorf("signing apns provider token: %w", err)
+	}
+	d.providerToken = signed
+	d.issuedAt = now
+	return signed, nil
+}
+
+func (d *apnsDispatcher) resetProviderToken() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.providerToken = ""
+}
@@ -0,0 +1,74 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sync-backend/api/user/model"
+	"sync-backend/arch/config"
+	"sync-backend/utils"
+)
+
+// Push drivers selectable through push.driver
+const (
+	DriverNative = "native"
+	DriverFake   = "fake"
+)
+
+// Notification is a push notification, Data is handed to the app untouched
+// so clients can route the tap and localize the text
+type Notification struct {
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// Dispatcher delivers notifications to the device tokens of one push provider.
+// Errors wrapping ErrInvalidToken mean the provider will never accept the token again.
+type Dispatcher interface {
+	Name() string
+	Send(ctx context.Context, token string, notification Notification) error
+}
+
+// ErrInvalidToken marks tokens the provider rejected for good, e.g. after the app was uninstalled
+var ErrInvalidToken = errors.New("push token is no longer valid")
+
+func invalidTokenError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidToken, fmt.Sprintf(format, args...))
+}
+
+// NewDispatchers creates a dispatcher per device token type for the driver selected in the config,
+// panics when it is misconfigured
+func NewDispatchers(env *config.Env, pushConfig config.PushConfig) map[model.DeviceTokenType]Dispatcher {
+	logger := utils.NewServiceLogger("PushDispatcher")
+	dispatchers := map[model.DeviceTokenType]Dispatcher{}
+
+	switch pushConfig.Driver {
+	case DriverFake, "":
+		dispatchers[model.DeviceTokenFCM] = NewFakeDispatcher(string(model.DeviceTokenFCM))
+		dispatchers[model.DeviceTokenAPNs] = NewFakeDispatcher(string(model.DeviceTokenAPNs))
+	case DriverNative:
+		if env.FCMCredentialsFile == "" {
+			logger.Warn("FCM_CREDENTIALS_FILE is not set, push notifications to fcm tokens are disabled")
+		} else {
+			dispatcher, err := newFCMDispatcher(env.FCMCredentialsFile, pushConfig.FCM)
+			if err != nil {
+				panic("Failed to initialize FCM push dispatcher - Properly configure push.fcm: " + err.Error())
+			}
+			dispatchers[model.DeviceTokenFCM] = dispatcher
+		}
+		if env.APNsAuthKeyFile == "" {
+			logger.Warn("APNS_AUTH_KEY_FILE is not set, push notifications to apns tokens are disabled")
+		} else {
+			dispatcher, err := newAPNsDispatcher(env.APNsAuthKeyFile, pushConfig.APNs)
+			if err != nil {
+				panic("Failed to initialize APNs push dispatcher - Properly configure push.apns: " + err.Error())
+			}
+			dispatchers[model.DeviceTokenAPNs] = dispatcher
+		}
+	default:
+		panic(fmt.Sprintf("Failed to initialize push dispatchers - unknown driver %q, expected %s or %s", pushConfig.Driver, DriverNative, DriverFake))
+	}
+	return dispatchers
+}
@@ -0,0 +1,63 @@
+package push
+
+import (
+	"context"
+	"sync"
+
+	"sync-backend/utils"
+)
+
+// FakeDelivery is a notification accepted by a FakeDispatcher
+type FakeDelivery struct {
+	Token        string
+	Notification Notification
+}
+
+// FakeDispatcher logs notifications instead of sending them, for local development and tests.
+// Tokens marked with Invalidate are rejected the way a real provider rejects uninstalled apps.
+type FakeDispatcher struct {
+	name    string
+	logger  utils.AppLogger
+	mu      sync.Mutex
+	sent    []FakeDelivery
+	invalid map[string]bool
+}
+
+func NewFakeDispatcher(name string) *FakeDispatcher {
+	return &FakeDispatcher{
+		name:    name,
+		logger:  utils.NewServiceLogger("FakePushDispatcher"),
+		invalid: map[string]bool{},
+	}
+}
+
+func (d *FakeDispatcher) Name() string {
+	return d.name
+}
+
+func (d *FakeDispatcher) Send(_ context.Context, token string, notification Notification) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.invalid[token] {
+		return invalidTokenError("fake %s token %s is invalidated", d.name, token)
+	}
+	d.sent = append(d.sent, FakeDelivery{Token: token, Notification: notification})
+	d.logger.Info("Push to %s token %s: %s - %s", d.name, token, notification.Title, notification.Body)
+	return nil
+}
+
+// Invalidate makes following sends to the tokens fail with ErrInvalidToken
+func (d *FakeDispatcher) Invalidate(tokens ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, token := range tokens {
+		d.invalid[token] = true
+	}
+}
+
+// Sent returns the notifications accepted so far
+func (d *FakeDispatcher) Sent() []FakeDelivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]FakeDelivery(nil), d.sent...)
+}
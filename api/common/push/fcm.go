@@ -0,0 +1,224 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"sync-backend/api/user/model"
+	"sync-backend/arch/config"
+	"sync-backend/arch/tracing"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	fcmEndpoint        = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+	fcmScope           = "https://www.googleapis.com/auth/firebase.messaging"
+	fcmDefaultTokenUri = "https://oauth2.googleapis.com/token"
+)
+
+// fcmServiceAccount is the part of a Firebase service account JSON the dispatcher needs
+type fcmServiceAccount struct {
+	ProjectId   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenUri    string `json:"token_uri"`
+}
+
+// fcmDispatcher sends through the FCM HTTP v1 API, authenticating with OAuth2 access tokens
+// obtained from the service account key
+type fcmDispatcher struct {
+	client   *http.Client
+	endpoint string
+	account  fcmServiceAccount
+	key      *rsa.PrivateKey
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+func newFCMDispatcher(credentialsFile string, fcmConfig config.FCMConfig) (*fcmDispatcher, error) {
+	data, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading FCM_CREDENTIALS_FILE: %w", err)
+	}
+	var account fcmServiceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("parsing FCM_CREDENTIALS_FILE: %w", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, fmt.Errorf("FCM_CREDENTIALS_FILE is not a service account key")
+	}
+	if account.TokenUri == "" {
+		account.TokenUri = fcmDefaultTokenUri
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("parsing service account private key: %w", err)
+	}
+
+	projectId := fcmConfig.ProjectId
+	if projectId == "" {
+		projectId = account.ProjectId
+	}
+	if projectId == "" {
+		return nil, fmt.Errorf("fcm requires push.fcm.project_id or a service account with a project_id")
+	}
+
+	return &fcmDispatcher{
+		client:   &http.Client{Timeout: 10 * time.Second, Transport: tracing.NewTransport(nil)},
+		endpoint: fmt.Sprintf(fcmEndpoint, projectId),
+		account:  account,
+		key:      key,
+	}, nil
+}
+
+func (d *fcmDispatcher) Name() string {
+	return string(model.DeviceTokenFCM)
+}
+
+type fcmMessage struct {
+	Message struct {
+		Token        string            `json:"token"`
+		Notification fcmNotification   `json:"notification"`
+		Data         map[string]string `json:"data,omitempty"`
+	} `json:"message"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Details []struct {
+			Type      string `json:"@type"`
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+func (d *fcmDispatcher) Send(ctx context.Context, token string, notification Notification) error {
+	accessToken, err := d.getAccessToken()
+	if err != nil {
+		return err
+	}
+
+	var message fcmMessage
+	message.Message.Token = token
+	message.Message.Notification = fcmNotification{Title: notification.Title, Body: notification.Body}
+	message.Message.Data = notification.Data
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	if response.StatusCode == http.StatusUnauthorized {
+		d.resetAccessToken()
+	}
+	return fcmError(response)
+}
+
+// fcmError classifies a failed send, see https://firebase.google.com/docs/reference/fcm/rest/v1/ErrorCode
+func fcmError(response *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(response.Body, 64<<10))
+	var errorResponse fcmErrorResponse
+	_ = json.Unmarshal(data, &errorResponse)
+
+	errorCode := errorResponse.Error.Status
+	for _, detail := range errorResponse.Error.Details {
+		if strings.HasSuffix(detail.Type, "FcmError") && detail.ErrorCode != "" {
+			errorCode = detail.ErrorCode
+		}
+	}
+
+	switch errorCode {
+	// The payload is built here, so an invalid argument is the token itself
+	case "UNREGISTERED", "SENDER_ID_MISMATCH", "INVALID_ARGUMENT":
+		return invalidTokenError("fcm returned status %d %s: %s", response.StatusCode, errorCode, errorResponse.Error.Message)
+	}
+	return fmt.Errorf("fcm returned status %d %s: %s", response.StatusCode, errorCode, strings.TrimSpace(string(data)))
+}
+
+// getAccessToken returns a cached OAuth2 access token, exchanging a signed assertion for a new one when it is about to expire
+func (d *fcmDispatcher) getAccessToken() (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if d.accessToken != "" && now.Add(time.Minute).Before(d.expiresAt) {
+		return d.accessToken, nil
+	}
+
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   d.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   d.account.TokenUri,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(d.key)
+	if err != nil {
+		return "", fmt.Errorf("signing fcm token assertion: %w", err)
+	}
+
+	response, err := d.client.PostForm(d.account.TokenUri, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	})
+	if err != nil {
+		return "", fmt.Errorf("requesting fcm access token: %w", err)
+	}
+	defer response.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(response.Body, 64<<10))
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("requesting fcm access token returned status %d: %s", response.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(data, &token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("invalid fcm access token response: %s", strings.TrimSpace(string(data)))
+	}
+	d.accessToken = token.AccessToken
+	d.expiresAt = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+	return d.accessToken, nil
+}
+
+func (d *fcmDispatcher) resetAccessToken() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.accessToken = ""
+}
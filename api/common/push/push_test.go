@@ -0,0 +1,180 @@
+package push
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"sync-backend/api/user/model"
+	"sync-backend/arch/config"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeliverPrunesRejectedTokens(t *testing.T) {
+	fcm := NewFakeDispatcher("fcm")
+	apns := NewFakeDispatcher("apns")
+	apns.Invalidate("ios-old")
+	service := newPushService(nil, map[model.DeviceTokenType]Dispatcher{
+		model.DeviceTokenFCM:  fcm,
+		model.DeviceTokenAPNs: apns,
+	})
+
+	invalid := service.deliver(context.Background(), []model.DeviceToken{
+		*model.NewDeviceToken("android", "device-1", "session-1", model.DeviceTokenFCM),
+		*model.NewDeviceToken("ios-old", "device-2", "session-2", model.DeviceTokenAPNs),
+		*model.NewDeviceToken("ios-new", "device-3", "session-3", model.DeviceTokenAPNs),
+	}, Notification{Title: "New reply", Body: strings.Repeat("a", 300)})
+
+	assert.Equal(t, []string{"ios-old"}, invalid)
+	require.Len(t, fcm.Sent(), 1)
+	assert.Equal(t, "android", fcm.Sent()[0].Token)
+	assert.Len(t, []rune(fcm.Sent()[0].Notification.Body), maxBodyLength+1)
+	require.Len(t, apns.Sent(), 1)
+	assert.Equal(t, "ios-new", apns.Sent()[0].Token)
+}
+
+func TestDeliverSkipsPlatformsWithoutDispatcher(t *testing.T) {
+	service := newPushService(nil, map[model.DeviceTokenType]Dispatcher{})
+
+	invalid := service.deliver(context.Background(), []model.DeviceToken{
+		*model.NewDeviceToken("android", "device-1", "session-1", model.DeviceTokenFCM),
+	}, Notification{Title: "New reply"})
+
+	assert.Empty(t, invalid)
+}
+
+func TestAPNsDispatcher(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "AuthKey.p8")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, 2, r.ProtoMajor)
+		assert.Equal(t, "com.example.app", r.Header.Get("apns-topic"))
+		assert.Equal(t, "alert", r.Header.Get("apns-push-type"))
+
+		token, err := jwt.Parse(strings.TrimPrefix(r.Header.Get("authorization"), "bearer "), func(token *jwt.Token) (interface{}, error) {
+			assert.Equal(t, "KEY123", token.Header["kid"])
+			return &key.PublicKey, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "TEAM123", token.Claims.(jwt.MapClaims)["iss"])
+
+		var payload map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, "post-1", payload["postId"])
+		assert.Equal(t, "Hello", payload["aps"].(map[string]interface{})["alert"].(map[string]interface{})["title"])
+
+		switch strings.TrimPrefix(r.URL.Path, "/3/device/") {
+		case "valid":
+			w.WriteHeader(http.StatusOK)
+		case "uninstalled":
+			w.WriteHeader(http.StatusGone)
+			w.Write([]byte(`{"reason":"Unregistered","timestamp":1700000000000}`))
+		case "malformed":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"reason":"BadDeviceToken"}`))
+		default:
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"reason":"TooManyRequests"}`))
+		}
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	dispatcher, err := newAPNsDispatcher(keyFile, config.APNsConfig{KeyId: "KEY123", TeamId: "TEAM123", Topic: "com.example.app"})
+	require.NoError(t, err)
+	dispatcher.client = server.Client()
+	dispatcher.host = server.URL
+
+	notification := Notification{Title: "Hello", Body: "World", Data: map[string]string{"postId": "post-1"}}
+	assert.NoError(t, dispatcher.Send(context.Background(), "valid", notification))
+	assert.ErrorIs(t, dispatcher.Send(context.Background(), "uninstalled", notification), ErrInvalidToken)
+	assert.ErrorIs(t, dispatcher.Send(context.Background(), "malformed", notification), ErrInvalidToken)
+
+	err = dispatcher.Send(context.Background(), "throttled", notification)
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrInvalidToken))
+}
+
+func TestFCMDispatcher(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var tokenRequests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.FormValue("grant_type"))
+		_, err := jwt.Parse(r.FormValue("assertion"), func(token *jwt.Token) (interface{}, error) {
+			return &key.PublicKey, nil
+		})
+		assert.NoError(t, err)
+		w.Write([]byte(`{"access_token":"access-1","expires_in":3600,"token_type":"Bearer"}`))
+	})
+	mux.HandleFunc("/v1/projects/demo/messages:send", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer access-1", r.Header.Get("Authorization"))
+		var message fcmMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+		assert.Equal(t, "Hello", message.Message.Notification.Title)
+
+		switch message.Message.Token {
+		case "valid":
+			w.Write([]byte(`{"name":"projects/demo/messages/1"}`))
+		case "uninstalled":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"message":"Requested entity was not found.","status":"NOT_FOUND",
+				"details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"UNREGISTERED"}]}}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":{"code":503,"message":"The service is currently unavailable.","status":"UNAVAILABLE"}}`))
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	credentials, err := json.Marshal(fcmServiceAccount{
+		ProjectId:   "demo",
+		ClientEmail: "push@demo.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		TokenUri:    server.URL + "/token",
+	})
+	require.NoError(t, err)
+	credentialsFile := filepath.Join(t.TempDir(), "service-account.json")
+	require.NoError(t, os.WriteFile(credentialsFile, credentials, 0600))
+
+	dispatcher, err := newFCMDispatcher(credentialsFile, config.FCMConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "https://fcm.googleapis.com/v1/projects/demo/messages:send", dispatcher.endpoint)
+	dispatcher.endpoint = server.URL + "/v1/projects/demo/messages:send"
+
+	notification := Notification{Title: "Hello", Body: "World"}
+	assert.NoError(t, dispatcher.Send(context.Background(), "valid", notification))
+	assert.ErrorIs(t, dispatcher.Send(context.Background(), "uninstalled", notification), ErrInvalidToken)
+
+	err = dispatcher.Send(context.Background(), "unavailable", notification)
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrInvalidToken))
+
+	assert.Equal(t, int32(1), tokenRequests.Load(), "the access token is reused until it expires")
+}
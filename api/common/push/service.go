@@ -0,0 +1,94 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"sync-backend/api/user"
+	"sync-backend/api/user/model"
+	"sync-backend/arch/config"
+	"sync-backend/arch/network"
+	"sync-backend/arch/tracing"
+	"sync-backend/utils"
+)
+
+// Longer bodies are cut, APNs caps the whole payload at 4KB
+const maxBodyLength = 180
+
+type PushService interface {
+	// NotifyUser sends the notification to every device of the user who enabled push notifications,
+	// removing the tokens the providers rejected
+	NotifyUser(ctx context.Context, userId string, notification Notification) network.ApiError
+}
+
+type pushService struct {
+	network.BaseService
+	logger      utils.AppLogger
+	userService user.UserService
+	dispatchers map[model.DeviceTokenType]Dispatcher
+}
+
+func NewPushService(env *config.Env, config *config.Config, userService user.UserService) PushService {
+	return newPushService(userService, NewDispatchers(env, config.Push))
+}
+
+func newPushService(userService user.UserService, dispatchers map[model.DeviceTokenType]Dispatcher) *pushService {
+	return &pushService{
+		BaseService: network.NewBaseService(),
+		logger:      utils.NewServiceLogger("PushService"),
+		userService: userService,
+		dispatchers: dispatchers,
+	}
+}
+
+func (s *pushService) NotifyUser(ctx context.Context, userId string, notification Notification) network.ApiError {
+	ctx, span := tracing.Start(ctx, "PushService.NotifyUser")
+	defer span.End()
+
+	userModel, err := s.userService.FindUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if !userModel.Preferences.Notifications.Push || len(userModel.DeviceTokens) == 0 {
+		return nil
+	}
+
+	invalid := s.deliver(ctx, userModel.DeviceTokens, notification)
+	if len(invalid) > 0 {
+		s.logger.WithContext(ctx).Info("Pruning %d invalid device tokens of user %s", len(invalid), userId)
+		return s.userService.RemoveDeviceTokens(ctx, userId, invalid)
+	}
+	return nil
+}
+
+// deliver sends to each token and returns the tokens the providers reported as invalid
+func (s *pushService) deliver(ctx context.Context, tokens []model.DeviceToken, notification Notification) []string {
+	notification.Body = truncate(notification.Body, maxBodyLength)
+
+	var invalid []string
+	for _, deviceToken := range tokens {
+		dispatcher, ok := s.dispatchers[deviceToken.Type]
+		if !ok {
+			s.logger.WithContext(ctx).Debug("No push dispatcher for %s tokens, skipping device %s", deviceToken.Type, deviceToken.DeviceId)
+			continue
+		}
+		err := dispatcher.Send(ctx, deviceToken.Token, notification)
+		if errors.Is(err, ErrInvalidToken) {
+			s.logger.WithContext(ctx).Debug("Device %s token rejected: %v", deviceToken.DeviceId, err)
+			invalid = append(invalid, deviceToken.Token)
+		} else if err != nil {
+			s.logger.WithContext(ctx).Error("Failed to push to device %s through %s: %v", deviceToken.DeviceId, dispatcher.Name(), err)
+		}
+	}
+	return invalid
+}
+
+func truncate(content string, length int) string {
+	content = strings.Join(strings.Fields(content), " ")
+	runes := []rune(content)
+	if len(runes) <= length {
+		return content
+	}
+	return strings.TrimSpace(string(runes[:length])) + "…"
+}
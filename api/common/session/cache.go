@@ -0,0 +1,13 @@
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// CacheKey keys the session the authentication middleware caches for an access token. The key is a hash
+// of the token so tokens never end up in the cache, revoking a session must delete it to take effect at once.
+func CacheKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "auth:session:" + hex.EncodeToString(sum[:])
+}
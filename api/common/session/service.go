@@ -1,10 +1,12 @@
 package session
 
 import (
+	"context"
 	"time"
 
 	"sync-backend/api/common/session/model"
 	"sync-backend/arch/mongo"
+	"sync-backend/arch/tracing"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -12,17 +14,17 @@ import (
 )
 
 type SessionService interface {
-	CreateSession(userID string, token string, refreshToken string, expiresAt time.Time, deviceInfo model.DeviceInfo, userLocationInfo model.LocationInfo) (*model.Session, error)
-	GetSessionByToken(token string) (*model.Session, error)
-	GetSessionByRefreshToken(refreshToken string) (*model.Session, error)
-	UpdateSession(sessionID string, accessToken string, refreshToken string, expiresAt time.Time) (*model.Session, error)
-	UpdateSessionInfo(sessionID string, deviceInfo model.DeviceInfo, userLocationInfo model.LocationInfo) error
-	GetUserActiveSession(userID string) (*model.Session, error)
-	GetActiveSessionsByUserID(userID string) ([]*model.Session, error)
-	InvalidateSession(sessionID string) error
-	RefreshSession(sessionID string, newToken string, newExpiresAt time.Time) error
-	TouchSession(sessionID string) error
-	CleanupExpiredSessions() (int64, error)
+	CreateSession(ctx context.Context, userID string, token string, refreshToken string, expiresAt time.Time, deviceInfo model.DeviceInfo, userLocationInfo model.LocationInfo) (*model.Session, error)
+	GetSessionByToken(ctx context.Context, token string) (*model.Session, error)
+	GetSessionByRefreshToken(ctx context.Context, refreshToken string) (*model.Session, error)
+	UpdateSession(ctx context.Context, sessionID string, accessToken string, refreshToken string, expiresAt time.Time) (*model.Session, error)
+	UpdateSessionInfo(ctx context.Context, sessionID string, deviceInfo model.DeviceInfo, userLocationInfo model.LocationInfo) error
+	GetUserActiveSession(ctx context.Context, userID string) (*model.Session, error)
+	GetActiveSessionsByUserID(ctx context.Context, userID string) ([]*model.Session, error)
+	InvalidateSession(ctx context.Context, sessionID string) error
+	RefreshSession(ctx context.Context, sessionID string, newToken string, newExpiresAt time.Time) error
+	TouchSession(ctx context.Context, sessionID string) error
+	CleanupExpiredSessions(ctx context.Context) (int64, error)
 }
 
 type sessionService struct {
@@ -36,6 +38,7 @@ func NewSessionService(db mongo.Database) SessionService {
 }
 
 func (s *sessionService) CreateSession(
+	ctx context.Context,
 	userID string,
 	token string,
 	refreshToken string,
@@ -43,6 +46,8 @@ func (s *sessionService) CreateSession(
 	deviceInfo model.DeviceInfo,
 	userLocationInfo model.LocationInfo,
 ) (*model.Session, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.CreateSession")
+	defer span.End()
 
 	session, err := model.NewSession(model.NewSessionArgs{
 		UserId:       userID,
@@ -55,7 +60,7 @@ func (s *sessionService) CreateSession(
 	if err != nil {
 		return nil, err
 	}
-	id, err := s.queryBuilder.SingleQuery().InsertOne(session)
+	id, err := s.queryBuilder.SingleQuery(ctx).InsertOne(session)
 	if err != nil {
 		return nil, err
 	}
@@ -66,10 +71,13 @@ func (s *sessionService) CreateSession(
 	return session, nil
 }
 
-func (s *sessionService) GetSessionByToken(token string) (*model.Session, error) {
+func (s *sessionService) GetSessionByToken(ctx context.Context, token string) (*model.Session, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.GetSessionByToken")
+	defer span.End()
+
 	filter := bson.M{"token": token, "isRevoked": false, "expiresAt": bson.M{"$gt": time.Now()}}
 	options := options.FindOne().SetSort(bson.M{"expiresAt": 1})
-	session, err := s.queryBuilder.SingleQuery().FilterOne(filter, options)
+	session, err := s.queryBuilder.SingleQuery(ctx).FilterOne(filter, options)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
 			return nil, nil
@@ -79,10 +87,13 @@ func (s *sessionService) GetSessionByToken(token string) (*model.Session, error)
 	return session, nil
 }
 
-func (s *sessionService) GetSessionByRefreshToken(refreshToken string) (*model.Session, error) {
+func (s *sessionService) GetSessionByRefreshToken(ctx context.Context, refreshToken string) (*model.Session, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.GetSessionByRefreshToken")
+	defer span.End()
+
 	filter := bson.M{"refreshToken": refreshToken, "isRevoked": false, "expiresAt": bson.M{"$gt": time.Now()}}
 	options := options.FindOne().SetSort(bson.M{"expiresAt": 1})
-	session, err := s.queryBuilder.SingleQuery().FilterOne(filter, options)
+	session, err := s.queryBuilder.SingleQuery(ctx).FilterOne(filter, options)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
 			return nil, nil
@@ -92,7 +103,10 @@ func (s *sessionService) GetSessionByRefreshToken(refreshToken string) (*model.S
 	return session, nil
 }
 
-func (s *sessionService) UpdateSession(sessionID string, accessToken string, refreshToken string, expiresAt time.Time) (*model.Session, error) {
+func (s *sessionService) UpdateSession(ctx context.Context, sessionID string, accessToken string, refreshToken string, expiresAt time.Time) (*model.Session, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.UpdateSession")
+	defer span.End()
+
 	filter := bson.M{"sessionId": sessionID, "isRevoked": false, "expiresAt": bson.M{"$gt": time.Now()}}
 	update := bson.M{
 		"$set": bson.M{
@@ -102,7 +116,7 @@ func (s *sessionService) UpdateSession(sessionID string, accessToken string, ref
 			"updatedAt":    time.Now(),
 		},
 	}
-	session, err := s.queryBuilder.SingleQuery().FilterOneAndUpdate(filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	session, err := s.queryBuilder.SingleQuery(ctx).FilterOneAndUpdate(filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
 			return nil, nil
@@ -113,7 +127,10 @@ func (s *sessionService) UpdateSession(sessionID string, accessToken string, ref
 	return session, nil
 }
 
-func (s *sessionService) UpdateSessionInfo(sessionID string, deviceInfo model.DeviceInfo, userLocation model.LocationInfo) error {
+func (s *sessionService) UpdateSessionInfo(ctx context.Context, sessionID string, deviceInfo model.DeviceInfo, userLocation model.LocationInfo) error {
+	ctx, span := tracing.Start(ctx, "SessionService.UpdateSessionInfo")
+	defer span.End()
+
 	filter := bson.M{"sessionId": sessionID, "isRevoked": false, "expiresAt": bson.M{"$gt": time.Now()}}
 	update := bson.M{
 		"$set": bson.M{
@@ -122,14 +139,17 @@ func (s *sessionService) UpdateSessionInfo(sessionID string, deviceInfo model.De
 			"updatedAt": time.Now(),
 		},
 	}
-	s.queryBuilder.SingleQuery().UpdateOne(filter, update, options.Update().SetUpsert(true))
+	s.queryBuilder.SingleQuery(ctx).UpdateOne(filter, update, options.Update().SetUpsert(true))
 	return nil
 }
 
-func (s *sessionService) GetUserActiveSession(userID string) (*model.Session, error) {
+func (s *sessionService) GetUserActiveSession(ctx context.Context, userID string) (*model.Session, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.GetUserActiveSession")
+	defer span.End()
+
 	filter := bson.M{"userId": userID, "isRevoked": false, "expiresAt": bson.M{"$gt": time.Now()}}
 	options := options.FindOne().SetSort(bson.M{"expiresAt": 1})
-	session, err := s.queryBuilder.SingleQuery().FilterOne(filter, options)
+	session, err := s.queryBuilder.SingleQuery(ctx).FilterOne(filter, options)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
 			return nil, nil
@@ -139,50 +159,65 @@ func (s *sessionService) GetUserActiveSession(userID string) (*model.Session, er
 	return session, nil
 }
 
-func (s *sessionService) GetActiveSessionsByUserID(userID string) ([]*model.Session, error) {
+func (s *sessionService) GetActiveSessionsByUserID(ctx context.Context, userID string) ([]*model.Session, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.GetActiveSessionsByUserID")
+	defer span.End()
+
 	filter := bson.M{"userId": userID, "isRevoked": false, "expiresAt": bson.M{"$gt": time.Now()}}
 	options := options.Find().SetSort(bson.M{"expiresAt": 1})
-	sessions, err := s.queryBuilder.SingleQuery().FilterMany(filter, options)
+	sessions, err := s.queryBuilder.SingleQuery(ctx).FilterMany(filter, options)
 	if err != nil {
 		return nil, err
 	}
 	return sessions, nil
 }
 
-func (s *sessionService) InvalidateSession(sessionID string) error {
+func (s *sessionService) InvalidateSession(ctx context.Context, sessionID string) error {
+	ctx, span := tracing.Start(ctx, "SessionService.InvalidateSession")
+	defer span.End()
+
 	filter := bson.M{"sessionId": sessionID}
 	update := bson.M{"$set": bson.M{"isRevoked": true, "updatedAt": time.Now(), "deletedAt": time.Now()}}
 
-	_, err := s.queryBuilder.SingleQuery().UpdateOne(filter, update, options.Update().SetUpsert(true))
+	_, err := s.queryBuilder.SingleQuery(ctx).UpdateOne(filter, update, options.Update().SetUpsert(true))
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *sessionService) RefreshSession(sessionID string, newToken string, newExpiresAt time.Time) error {
+func (s *sessionService) RefreshSession(ctx context.Context, sessionID string, newToken string, newExpiresAt time.Time) error {
+	ctx, span := tracing.Start(ctx, "SessionService.RefreshSession")
+	defer span.End()
+
 	filter := bson.M{"sessionId": sessionID, "isRevoked": false, "expiresAt": bson.M{"$gt": time.Now()}}
 	update := bson.M{"$set": bson.M{"token": newToken, "expiresAt": newExpiresAt, "updatedAt": time.Now()}}
-	_, err := s.queryBuilder.SingleQuery().UpdateOne(filter, update, options.Update().SetUpsert(true))
+	_, err := s.queryBuilder.SingleQuery(ctx).UpdateOne(filter, update, options.Update().SetUpsert(true))
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *sessionService) TouchSession(sessionID string) error {
+func (s *sessionService) TouchSession(ctx context.Context, sessionID string) error {
+	ctx, span := tracing.Start(ctx, "SessionService.TouchSession")
+	defer span.End()
+
 	filter := bson.M{"sessionId": sessionID, "isRevoked": false, "expiresAt": bson.M{"$gt": time.Now()}}
 	update := bson.M{"$set": bson.M{"updatedAt": time.Now()}}
-	_, err := s.queryBuilder.SingleQuery().UpdateOne(filter, update, options.Update().SetUpsert(true))
+	_, err := s.queryBuilder.SingleQuery(ctx).UpdateOne(filter, update, options.Update().SetUpsert(true))
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *sessionService) CleanupExpiredSessions() (int64, error) {
+func (s *sessionService) CleanupExpiredSessions(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "SessionService.CleanupExpiredSessions")
+	defer span.End()
+
 	filter := bson.M{"expiresAt": bson.M{"$lt": time.Now()}}
-	result, err := s.queryBuilder.SingleQuery().DeleteMany(filter, options.Delete())
+	result, err := s.queryBuilder.SingleQuery(ctx).DeleteMany(filter, options.Delete())
 	if err != nil {
 		return 0, err
 	}
@@ -12,6 +12,7 @@ import (
 	modMW "sync-backend/api/moderator/middleware"
 	moderatorModel "sync-backend/api/moderator/model"
 	"sync-backend/api/user"
+	"sync-backend/arch/background"
 	"sync-backend/arch/common"
 	coreMW "sync-backend/arch/middleware"
 	"sync-backend/arch/network"
@@ -37,6 +38,8 @@ type communityController struct {
 	communityService    CommunityService
 	moderatorService    moderator.ModeratorService
 	moderatorMiddleware modMW.ModeratorMiddleware
+	rateLimitProvider   network.RateLimitProvider
+	idempotencyProvider network.IdempotencyProvider
 	analytics           analytics.CommunityAnalytics
 }
 
@@ -47,6 +50,8 @@ func NewCommunityController(
 	communityService CommunityService,
 	moderatorService moderator.ModeratorService,
 	moderatorMiddleware modMW.ModeratorMiddleware,
+	rateLimitProvider network.RateLimitProvider,
+	idempotencyProvider network.IdempotencyProvider,
 	analytics analytics.CommunityAnalytics,
 ) network.Controller {
 	return &communityController{
@@ -59,6 +64,8 @@ func NewCommunityController(
 		communityService:    communityService,
 		moderatorService:    moderatorService,
 		moderatorMiddleware: moderatorMiddleware,
+		rateLimitProvider:   rateLimitProvider,
+		idempotencyProvider: idempotencyProvider,
 		analytics:           analytics,
 	}
 }
@@ -69,7 +76,7 @@ func (c *communityController) MountRoutes(group *gin.RouterGroup) {
 	group.Use(c.authProvider.Middleware())
 
 	/*	COMMUNITY ROUTES */
-	group.POST("/create", c.uploadProvider.Middleware("avatar_photo", "background_photo"), c.CreateCommunity)
+	group.POST("/create", c.idempotencyProvider.Middleware(), c.uploadProvider.Middleware("avatar_photo", "background_photo"), c.CreateCommunity)
 	group.GET("/:communityId", c.GetCommunityById)
 	group.PUT("/:communityId", c.uploadProvider.Middleware("avatar_photo", "background_photo"), c.UpdateCommunity)
 	group.DELETE("/:communityId", c.DeleteCommunity)
@@ -99,9 +106,11 @@ func (c *communityController) MountRoutes(group *gin.RouterGroup) {
 	/* MODERATOR REPORTS AND LOGS */
 	moderatorGroup.POST("/:communityId/ban/:userId", c.moderatorMiddleware.RequiresModerator("communityId"), c.BanUser)
 	moderatorGroup.POST("/:communityId/unban/:userId", c.moderatorMiddleware.RequiresModerator("communityId"), c.UnbanUser)
+	moderatorGroup.POST("/:communityId/mute/:userId", c.moderatorMiddleware.RequiresPermission("communityId", moderatorModel.PermissionMuteUser), c.MuteUser)
+	moderatorGroup.POST("/:communityId/unmute/:userId", c.moderatorMiddleware.RequiresPermission("communityId", moderatorModel.PermissionMuteUser), c.UnmuteUser)
 
 	/* MODERATOR REPORTS */
-	moderatorGroup.POST("/report/create", c.CreateReport)
+	moderatorGroup.POST("/report/create", c.rateLimitProvider.Middleware(coreMW.RateLimitReport), c.CreateReport)
 	moderatorGroup.PATCH("/report/:reportId/process", c.ProcessReport)
 	moderatorGroup.GET("/report/:reportId", c.GetReport)
 	moderatorGroup.GET("/:communityId/reports", c.ListReports)
@@ -130,7 +139,7 @@ func (c *communityController) CreateCommunity(ctx *gin.Context) {
 		tagIds[i] = strings.TrimSpace(tagIds[i])
 	}
 
-	community, err := c.communityService.CreateCommunity(body.Name, body.Description, tagIds, body.AvatarFilePath, body.BackgroundFilePath, *userId)
+	community, err := c.communityService.CreateCommunity(ctx.Request.Context(), body.Name, body.Description, tagIds, body.AvatarFilePath, body.BackgroundFilePath, *userId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -145,9 +154,9 @@ func (c *communityController) CreateCommunity(ctx *gin.Context) {
 	c.uploadProvider.DeleteUploadedFiles(ctx, "background_photo")
 
 	// Add owner as a moderator
-	c.moderatorService.AddModerator(*userId, community.CommunityId, moderatorModel.RoleAdmin, *userId)
-	c.communityService.AddModerator(community.CommunityId, *userId, *userId)
-	c.userService.AddModerator(*userId, community.CommunityId)
+	c.moderatorService.AddModerator(ctx.Request.Context(), *userId, community.CommunityId, moderatorModel.RoleAdmin, *userId)
+	c.communityService.AddModerator(ctx.Request.Context(), community.CommunityId, *userId, *userId)
+	c.userService.AddModerator(ctx.Request.Context(), *userId, community.CommunityId)
 }
 
 func (c *communityController) UpdateCommunity(ctx *gin.Context) {
@@ -177,6 +186,7 @@ func (c *communityController) UpdateCommunity(ctx *gin.Context) {
 	}
 
 	_, err = c.communityService.UpdateCommunity(
+		ctx.Request.Context(),
 		communityId,
 		body.CommunityDescription,
 		body.AvatarFilePath,
@@ -203,7 +213,7 @@ func (c *communityController) DeleteCommunity(ctx *gin.Context) {
 		return
 	}
 	userId := c.ContextPayload.MustGetUserId(ctx)
-	err := c.communityService.DeleteCommunity(communityId, *userId)
+	err := c.communityService.DeleteCommunity(ctx.Request.Context(), communityId, *userId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -219,7 +229,7 @@ func (c *communityController) GetCommunityById(ctx *gin.Context) {
 		return
 	}
 
-	community, err := c.communityService.GetCommunityById(params.Id)
+	community, err := c.communityService.GetCommunityById(ctx.Request.Context(), params.Id)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -227,7 +237,8 @@ func (c *communityController) GetCommunityById(ctx *gin.Context) {
 
 	c.Send(ctx).SuccessDataResponse("Community fetched successfully", community)
 
-	go c.analytics.RecordCommunityView(params.Id, *c.MustGetUserId(ctx))
+	userId := c.MustGetUserId(ctx)
+	background.Go(func() { c.analytics.RecordCommunityView(params.Id, *userId) })
 }
 
 func (c *communityController) SearchCommunities(ctx *gin.Context) {
@@ -237,6 +248,7 @@ func (c *communityController) SearchCommunities(ctx *gin.Context) {
 	}
 
 	communities, err := c.communityService.SearchCommunities(
+		ctx.Request.Context(),
 		query.Query,
 		query.Page,
 		query.Limit,
@@ -256,7 +268,7 @@ func (c *communityController) AutocompeleteCommunities(ctx *gin.Context) {
 		return
 	}
 
-	communities, err := c.communityService.AutocompleteCommunities(query.Query, query.Page, query.Limit, query.ShowPrivate)
+	communities, err := c.communityService.AutocompleteCommunities(ctx.Request.Context(), query.Query, query.Page, query.Limit, query.ShowPrivate)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -274,7 +286,7 @@ func (c *communityController) JoinCommunity(ctx *gin.Context) {
 
 	communityId := params.CommunityId
 	userId := c.ContextPayload.MustGetUserId(ctx)
-	user, err := c.userService.FindUserById(*userId)
+	user, err := c.userService.FindUserById(ctx.Request.Context(), *userId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -289,19 +301,19 @@ func (c *communityController) JoinCommunity(ctx *gin.Context) {
 		return
 	}
 
-	err = c.communityService.JoinCommunity(*userId, communityId)
+	err = c.communityService.JoinCommunity(ctx.Request.Context(), *userId, communityId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
 	}
-	err = c.userService.JoinCommunity(*userId, communityId)
+	err = c.userService.JoinCommunity(ctx.Request.Context(), *userId, communityId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
 	}
 
 	c.Send(ctx).SuccessMsgResponse("Joined community successfully")
-	go c.analytics.RecordMemberJoin(communityId, *userId)
+	background.Go(func() { c.analytics.RecordMemberJoin(communityId, *userId) })
 }
 
 func (c *communityController) LeaveCommunity(ctx *gin.Context) {
@@ -313,7 +325,7 @@ func (c *communityController) LeaveCommunity(ctx *gin.Context) {
 
 	communityId := params.CommunityId
 	userId := c.ContextPayload.MustGetUserId(ctx)
-	user, err := c.userService.FindUserById(*userId)
+	user, err := c.userService.FindUserById(ctx.Request.Context(), *userId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -328,19 +340,19 @@ func (c *communityController) LeaveCommunity(ctx *gin.Context) {
 		return
 	}
 
-	err = c.communityService.LeaveCommunity(*userId, communityId)
+	err = c.communityService.LeaveCommunity(ctx.Request.Context(), *userId, communityId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
 	}
-	err = c.userService.LeaveCommunity(*userId, communityId)
+	err = c.userService.LeaveCommunity(ctx.Request.Context(), *userId, communityId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
 	}
 
 	c.Send(ctx).SuccessMsgResponse("Left community successfully")
-	go c.analytics.RecordMemberLeave(communityId, *userId)
+	background.Go(func() { c.analytics.RecordMemberLeave(communityId, *userId) })
 }
 
 func (c *communityController) GetMyCommunities(ctx *gin.Context) {
@@ -350,12 +362,12 @@ func (c *communityController) GetMyCommunities(ctx *gin.Context) {
 	}
 
 	userId := c.ContextPayload.MustGetUserId(ctx)
-	communities, err := c.communityService.GetCommunities(*userId, body.Page, body.Limit)
+	communities, err := c.communityService.GetCommunities(ctx.Request.Context(), *userId, body.Page, body.Limit)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
 	}
-	c.logger.Debug("Communities: %+v", communities)
+	c.logger.WithContext(ctx).Debug("Communities: %+v", communities)
 
 	if communities == nil {
 		c.Send(ctx).NotFoundError(
@@ -383,7 +395,7 @@ func (c *communityController) GetMyCommunities(ctx *gin.Context) {
 
 	for _, community := range communities {
 		if community != nil {
-			go c.analytics.RecordCommunityView(community.CommunityId, *userId)
+			background.Go(func() { c.analytics.RecordCommunityView(community.CommunityId, *userId) })
 		}
 	}
 }
@@ -395,7 +407,7 @@ func (c *communityController) GetJoinedCommunities(ctx *gin.Context) {
 	}
 
 	userId := c.ContextPayload.MustGetUserId(ctx)
-	communities, err := c.communityService.GetCommunities(*userId, body.Page, body.Limit)
+	communities, err := c.communityService.GetCommunities(ctx.Request.Context(), *userId, body.Page, body.Limit)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -427,7 +439,7 @@ func (c *communityController) GetJoinedCommunities(ctx *gin.Context) {
 
 	for _, community := range communities {
 		if community != nil {
-			go c.analytics.RecordCommunityView(community.CommunityId, *userId)
+			background.Go(func() { c.analytics.RecordCommunityView(community.CommunityId, *userId) })
 		}
 	}
 }
@@ -459,20 +471,20 @@ func (c *communityController) AddModerator(ctx *gin.Context) {
 		}
 	}
 
-	moderator, apiErr := c.moderatorService.AddModerator(body.UserId, communityId, body.Role, *userId)
+	moderator, apiErr := c.moderatorService.AddModerator(ctx.Request.Context(), body.UserId, communityId, body.Role, *userId)
 	if apiErr != nil {
 		c.Send(ctx).MixedError(apiErr)
 		return
 	}
 
 	// add moderator info to community
-	apiErr = c.communityService.AddModerator(communityId, body.UserId, *userId)
+	apiErr = c.communityService.AddModerator(ctx.Request.Context(), communityId, body.UserId, *userId)
 	if apiErr != nil {
 		c.Send(ctx).MixedError(apiErr)
 		return
 	}
 	// add moderator info to the user
-	err = c.userService.AddModerator(body.UserId, communityId)
+	err = c.userService.AddModerator(ctx.Request.Context(), body.UserId, communityId)
 	if err != nil {
 		c.Send(ctx).InternalServerError(
 			"Failed to add moderator info to user",
@@ -504,20 +516,20 @@ func (c *communityController) RemoveModerator(ctx *gin.Context) {
 		return
 	}
 
-	apiErr := c.moderatorService.RemoveModerator(communityId, body.UserId, *requesterId, body.Reason)
+	apiErr := c.moderatorService.RemoveModerator(ctx.Request.Context(), communityId, body.UserId, *requesterId, body.Reason)
 	if apiErr != nil {
 		c.Send(ctx).MixedError(apiErr)
 		return
 	}
 
 	// Remove moderator info from community
-	err = c.communityService.RemoveModerator(communityId, body.UserId, *requesterId)
+	err = c.communityService.RemoveModerator(ctx.Request.Context(), communityId, body.UserId, *requesterId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
 	}
 
-	insertErr := c.userService.RemoveModerator(body.UserId, communityId)
+	insertErr := c.userService.RemoveModerator(ctx.Request.Context(), body.UserId, communityId)
 	if insertErr != nil {
 		c.Send(ctx).InternalServerError(
 			"Failed to remove moderator info from user",
@@ -569,7 +581,7 @@ func (c *communityController) UpdateModerator(ctx *gin.Context) {
 		role = &r
 	}
 
-	moderator, apiErr := c.moderatorService.UpdateModerator(communityId, body.UserId, *requesterId, role, permissions, status, &body.Notes)
+	moderator, apiErr := c.moderatorService.UpdateModerator(ctx.Request.Context(), communityId, body.UserId, *requesterId, role, permissions, status, &body.Notes)
 	if apiErr != nil {
 		c.Send(ctx).MixedError(apiErr)
 		return
@@ -591,7 +603,7 @@ func (c *communityController) GetModerator(ctx *gin.Context) {
 		return
 	}
 
-	moderator, apiErr := c.moderatorService.GetModerator(communityId, userId)
+	moderator, apiErr := c.moderatorService.GetModerator(ctx.Request.Context(), communityId, userId)
 	if apiErr != nil {
 		c.Send(ctx).MixedError(apiErr)
 		return
@@ -618,7 +630,7 @@ func (c *communityController) ListModerators(ctx *gin.Context) {
 		return
 	}
 
-	moderators, total, apiErr := c.moderatorService.ListModerators(communityId, query.Page, query.Limit)
+	moderators, total, apiErr := c.moderatorService.ListModerators(ctx.Request.Context(), communityId, query.Page, query.Limit)
 	if apiErr != nil {
 		c.Send(ctx).MixedError(apiErr)
 		return
@@ -644,7 +656,7 @@ func (c *communityController) CheckModeratorPermission(ctx *gin.Context) {
 	}
 
 	userId := c.ContextPayload.MustGetUserId(ctx)
-	hasPermission, apiErr := c.moderatorService.HasModeratorPermission(*userId, communityId, moderatorModel.ModeratorPermission(permission))
+	hasPermission, apiErr := c.moderatorService.HasModeratorPermission(ctx.Request.Context(), *userId, communityId, moderatorModel.ModeratorPermission(permission))
 	if apiErr != nil {
 		c.Send(ctx).MixedError(apiErr)
 		return
@@ -663,6 +675,7 @@ func (c *communityController) CreateReport(ctx *gin.Context) {
 	}
 
 	report, apiErr := c.moderatorService.CreateReport(
+		ctx.Request.Context(),
 		*userId,
 		body.CommunityId,
 		body.TargetId,
@@ -678,7 +691,7 @@ func (c *communityController) CreateReport(ctx *gin.Context) {
 
 	c.Send(ctx).SuccessDataResponse("Report created successfully", report)
 
-	go c.analytics.RecordReport(body.CommunityId, *userId)
+	background.Go(func() { c.analytics.RecordReport(body.CommunityId, *userId) })
 }
 
 // ProcessReport handles processing a report
@@ -700,6 +713,7 @@ func (c *communityController) ProcessReport(ctx *gin.Context) {
 	}
 
 	report, apiErr := c.moderatorService.ProcessReport(
+		ctx.Request.Context(),
 		reportId,
 		*userId,
 		moderatorModel.ReportStatus(body.Status),
@@ -727,7 +741,7 @@ func (c *communityController) GetReport(ctx *gin.Context) {
 		return
 	}
 
-	report, apiErr := c.moderatorService.GetReport(reportId)
+	report, apiErr := c.moderatorService.GetReport(ctx.Request.Context(), reportId)
 	if apiErr != nil {
 		c.Send(ctx).MixedError(apiErr)
 		return
@@ -767,6 +781,7 @@ func (c *communityController) ListReports(ctx *gin.Context) {
 	}
 
 	reports, total, apiErr := c.moderatorService.ListReports(
+		ctx.Request.Context(),
 		communityId,
 		status,
 		targetType,
@@ -804,7 +819,7 @@ func (c *communityController) GetModLogs(ctx *gin.Context) {
 
 	moderatorId := query.ModeratorId // Can be empty for all moderators
 
-	logs, total, apiErr := c.moderatorService.GetModLogs(communityId, moderatorId, query.Page, query.Limit)
+	logs, page, apiErr := c.moderatorService.GetModLogs(ctx.Request.Context(), communityId, moderatorId, query.Cursor, query.Limit)
 	if apiErr != nil {
 		c.Send(ctx).MixedError(apiErr)
 		return
@@ -812,7 +827,7 @@ func (c *communityController) GetModLogs(ctx *gin.Context) {
 
 	c.Send(ctx).SuccessDataResponse(
 		"Moderation logs retrieved successfully",
-		moderatordto.NewListModLogsResponse(logs, query.Page, query.Limit, total),
+		moderatordto.NewListModLogsResponse(logs, page.NextCursor, page.HasMore),
 	)
 }
 
@@ -842,7 +857,7 @@ func (c *communityController) BanUser(ctx *gin.Context) {
 		duration = &d
 	}
 
-	modLog, apiErr := c.moderatorService.BanUser(*moderatorId, userId, communityId, body.Reason, duration)
+	modLog, apiErr := c.moderatorService.BanUser(ctx.Request.Context(), *moderatorId, userId, communityId, body.Reason, duration)
 	if apiErr != nil {
 		c.Send(ctx).MixedError(apiErr)
 		return
@@ -866,7 +881,7 @@ func (c *communityController) UnbanUser(ctx *gin.Context) {
 
 	moderatorId := c.ContextPayload.MustGetUserId(ctx)
 
-	modLog, apiErr := c.moderatorService.UnbanUser(*moderatorId, userId, communityId)
+	modLog, apiErr := c.moderatorService.UnbanUser(ctx.Request.Context(), *moderatorId, userId, communityId)
 	if apiErr != nil {
 		c.Send(ctx).MixedError(apiErr)
 		return
@@ -874,3 +889,61 @@ func (c *communityController) UnbanUser(ctx *gin.Context) {
 
 	c.Send(ctx).SuccessDataResponse("User unbanned successfully", modLog)
 }
+
+// MuteUser handles muting a user in a community
+func (c *communityController) MuteUser(ctx *gin.Context) {
+	communityId := ctx.Param("communityId")
+	userId := ctx.Param("userId")
+	if communityId == "" || userId == "" {
+		c.Send(ctx).BadRequestError(
+			"Community ID and User ID are required",
+			"Please provide valid Community ID and User ID in the request params",
+			nil,
+		)
+		return
+	}
+
+	moderatorId := c.ContextPayload.MustGetUserId(ctx)
+	body, err := network.ReqBody(ctx, moderatordto.NewMuteUserRequest())
+	if err != nil {
+		return
+	}
+
+	var duration *int
+	if body.Duration > 0 {
+		d := body.Duration
+		duration = &d
+	}
+
+	modLog, apiErr := c.moderatorService.MuteUser(ctx.Request.Context(), *moderatorId, userId, communityId, body.Reason, duration)
+	if apiErr != nil {
+		c.Send(ctx).MixedError(apiErr)
+		return
+	}
+
+	c.Send(ctx).SuccessDataResponse("User muted successfully", modLog)
+}
+
+// UnmuteUser handles lifting a mute on a user
+func (c *communityController) UnmuteUser(ctx *gin.Context) {
+	communityId := ctx.Param("communityId")
+	userId := ctx.Param("userId")
+	if communityId == "" || userId == "" {
+		c.Send(ctx).BadRequestError(
+			"Community ID and User ID are required",
+			"Please provide valid Community ID and User ID in the request params",
+			nil,
+		)
+		return
+	}
+
+	moderatorId := c.ContextPayload.MustGetUserId(ctx)
+
+	modLog, apiErr := c.moderatorService.UnmuteUser(ctx.Request.Context(), *moderatorId, userId, communityId)
+	if apiErr != nil {
+		c.Send(ctx).MixedError(apiErr)
+		return
+	}
+
+	c.Send(ctx).SuccessDataResponse("User unmuted successfully", modLog)
+}
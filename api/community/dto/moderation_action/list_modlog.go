@@ -9,14 +9,14 @@ import (
 
 // ListModLogsRequest is the request for listing moderation logs
 type ListModLogsRequest struct {
-	coredto.Pagination
+	coredto.CursorPagination
 	ModeratorId string `form:"moderatorId" json:"moderatorId"`
 }
 
 // NewListModLogsRequest creates a new request for listing moderation logs
 func NewListModLogsRequest() *ListModLogsRequest {
 	return &ListModLogsRequest{
-		Pagination: *coredto.NewPagination(),
+		CursorPagination: *coredto.NewCursorPagination(),
 	}
 }
 
@@ -41,18 +41,19 @@ func (l *ListModLogsRequest) ValidateErrors(errs validator.ValidationErrors) ([]
 
 // ListModLogsResponse is the response for listing moderation logs
 type ListModLogsResponse struct {
-	ModLogs []*model.ModLog `json:"modLogs"`
-	Page    int             `json:"page"`
-	Limit   int             `json:"limit"`
-	Total   int             `json:"total"`
+	ModLogs    []*model.ModLog `json:"modLogs"`
+	NextCursor string          `json:"nextCursor,omitempty"`
+	HasMore    bool            `json:"hasMore"`
 }
 
 // NewListModLogsResponse creates a new response for listing moderation logs
-func NewListModLogsResponse(modLogs []*model.ModLog, page, limit, total int) *ListModLogsResponse {
+func NewListModLogsResponse(modLogs []*model.ModLog, nextCursor string, hasMore bool) *ListModLogsResponse {
+	if modLogs == nil {
+		modLogs = []*model.ModLog{}
+	}
 	return &ListModLogsResponse{
-		ModLogs: modLogs,
-		Page:    page,
-		Limit:   limit,
-		Total:   total,
+		ModLogs:    modLogs,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
 	}
 }
@@ -0,0 +1,36 @@
+package moderatordto
+
+import (
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// MuteUserRequest represents the request to mute a user, duration is in hours
+type MuteUserRequest struct {
+	Reason   string `json:"reason" binding:"required" validate:"required"`
+	Duration int    `json:"duration,omitempty" validate:"min=0"`
+}
+
+func NewMuteUserRequest() *MuteUserRequest {
+	return &MuteUserRequest{}
+}
+
+func (r *MuteUserRequest) GetValue() *MuteUserRequest {
+	return r
+}
+
+func (r *MuteUserRequest) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		switch err.Tag() {
+		case "required":
+			msgs = append(msgs, fmt.Sprintf("%s is required", err.Field()))
+		case "min":
+			msgs = append(msgs, fmt.Sprintf("%s must be at least %s", err.Field(), err.Param()))
+		default:
+			msgs = append(msgs, fmt.Sprintf("%s is invalid", err.Field()))
+		}
+	}
+	return msgs, nil
+}
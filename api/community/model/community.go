@@ -14,6 +14,7 @@ import (
 	mongod "go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	mediaModels "sync-backend/api/common/media/model"
 	"sync-backend/arch/mongo"
 )
 
@@ -71,6 +72,23 @@ type Image struct {
 	Url    string `bson:"url" json:"url"`
 	Width  int    `bson:"width" json:"width"`
 	Height int    `bson:"height" json:"height"`
+
+	Renditions    []mediaModels.ImageRendition `bson:"renditions,omitempty" json:"renditions,omitempty"`
+	BlurHash      string                       `bson:"blurHash,omitempty" json:"blurHash,omitempty"`
+	DominantColor string                       `bson:"dominantColor,omitempty" json:"dominantColor,omitempty"`
+}
+
+// NewImage creates an image from a stored media file
+func NewImage(info mediaModels.MediaInfo) Image {
+	return Image{
+		ID:            info.Id,
+		Url:           info.Url,
+		Width:         info.Width,
+		Height:        info.Height,
+		Renditions:    info.Renditions,
+		BlurHash:      info.BlurHash,
+		DominantColor: info.DominantColor,
+	}
 }
 
 type CommunityRule struct {
@@ -6,6 +6,11 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// CommunityCacheTag tags every cached view of a community, invalidating it drops them after a write
+func CommunityCacheTag(communityId string) string {
+	return "community:" + communityId
+}
+
 type PublicGetCommunity struct {
 	CommunityId string             `bson:"communityId" json:"id"`
 	Slug        string             `bson:"slug" json:"slug"`
@@ -1,6 +1,7 @@
 package community
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"slices"
@@ -8,8 +9,11 @@ import (
 	mediaMadels "sync-backend/api/common/media/model"
 	"sync-backend/api/community/model"
 	postModel "sync-backend/api/post/model"
+	"sync-backend/arch/config"
 	"sync-backend/arch/mongo"
 	"sync-backend/arch/network"
+	"sync-backend/arch/redis"
+	"sync-backend/arch/tracing"
 	"sync-backend/utils"
 	"time"
 
@@ -20,25 +24,26 @@ import (
 
 type CommunityService interface {
 	/* COMMUNITY CRUD */
-	GetCommunityById(id string) (*model.PublicGetCommunity, network.ApiError)
-	CreateCommunity(name string, description string, tags []string, avatarFilePath string, backgroundFilePath string, userId string) (*model.Community, network.ApiError)
-	UpdateCommunity(id string, description string, avatarFilePath string, backgroundFilePath string, userId string) (*model.Community, network.ApiError)
-	DeleteCommunity(id string, userId string) network.ApiError
+	GetCommunityById(ctx context.Context, id string) (*model.PublicGetCommunity, network.ApiError)
+	CreateCommunity(ctx context.Context, name string, description string, tags []string, avatarFilePath string, backgroundFilePath string, userId string) (*model.Community, network.ApiError)
+	UpdateCommunity(ctx context.Context, id string, description string, avatarFilePath string, backgroundFilePath string, userId string) (*model.Community, network.ApiError)
+	DeleteCommunity(ctx context.Context, id string, userId string) network.ApiError
+	UpdateCommunityStatus(ctx context.Context, id string, status model.CommunityStatus) network.ApiError
 
-	CheckUserInCommunity(userId string, communityId string) network.ApiError
-	GetCommunities(userId string, page int, limit int) ([]*model.Community, network.ApiError)
+	CheckUserInCommunity(ctx context.Context, userId string, communityId string) network.ApiError
+	GetCommunities(ctx context.Context, userId string, page int, limit int) ([]*model.Community, network.ApiError)
 
 	/* USER COMMUNITY INTERACTIONS */
-	JoinCommunity(userId string, communityId string) network.ApiError
-	LeaveCommunity(userId string, communityId string) network.ApiError
+	JoinCommunity(ctx context.Context, userId string, communityId string) network.ApiError
+	LeaveCommunity(ctx context.Context, userId string, communityId string) network.ApiError
 
 	/* COMMUNITY SEARCH */
-	SearchCommunities(query string, page int, limit int, showPrivate bool) ([]*model.CommunitySearchResult, network.ApiError)
-	AutocompleteCommunities(query string, page int, limit int, showPrivate bool) ([]*model.CommunityAutocomplete, network.ApiError)
+	SearchCommunities(ctx context.Context, query string, page int, limit int, showPrivate bool) ([]*model.CommunitySearchResult, network.ApiError)
+	AutocompleteCommunities(ctx context.Context, query string, page int, limit int, showPrivate bool) ([]*model.CommunityAutocomplete, network.ApiError)
 
 	/* MODERATION */
-	AddModerator(communityId string, userId string, moderatorId string) network.ApiError
-	RemoveModerator(communityId string, userId string, moderatorId string) network.ApiError
+	AddModerator(ctx context.Context, communityId string, userId string, moderatorId string) network.ApiError
+	RemoveModerator(ctx context.Context, communityId string, userId string, moderatorId string) network.ApiError
 }
 
 type communityService struct {
@@ -53,9 +58,10 @@ type communityService struct {
 	communitySearchPipeline          mongo.AggregateBuilder[model.Community, model.CommunitySearchResult]
 	communityAutocompletePipeline    mongo.AggregateBuilder[model.Community, model.CommunityAutocomplete]
 	transaction                      mongo.TransactionBuilder
+	communityCache                   redis.Cache[model.PublicGetCommunity]
 }
 
-func NewCommunityService(db mongo.Database, mediaService media.MediaService) CommunityService {
+func NewCommunityService(db mongo.Database, mediaService media.MediaService, cacheStore redis.CacheStore, cacheConfig config.CacheConfig) CommunityService {
 	return &communityService{
 		mediaService:                     mediaService,
 		BaseService:                      network.NewBaseService(),
@@ -68,24 +74,39 @@ func NewCommunityService(db mongo.Database, mediaService media.MediaService) Com
 		communitySearchPipeline:          mongo.NewAggregateBuilder[model.Community, model.CommunitySearchResult](db, model.CommunityCollectionName),
 		communityAutocompletePipeline:    mongo.NewAggregateBuilder[model.Community, model.CommunityAutocomplete](db, model.CommunityCollectionName),
 		transaction:                      mongo.NewTransactionBuilder(db),
+		communityCache: redis.NewCache[model.PublicGetCommunity](cacheStore, redis.CacheOptions{
+			Name:   "community",
+			TTL:    cacheConfig.CommunityTTL,
+			Jitter: cacheConfig.Jitter,
+		}),
 	}
 }
 
-func (s *communityService) CreateCommunity(name string, description string, tags []string, avatarfilePath string, backgroundFilePath string, userId string) (*model.Community, network.ApiError) {
-	s.logger.Info("Creating community with name: %s", name)
+// invalidateCommunity drops the cached community after a write, whether or not the write went through
+func (s *communityService) invalidateCommunity(ctx context.Context, communityId string) {
+	if err := s.communityCache.Invalidate(context.WithoutCancel(ctx), model.CommunityCacheTag(communityId)); err != nil {
+		s.logger.WithContext(ctx).Error("Error invalidating cached community %s: %v", communityId, err)
+	}
+}
+
+func (s *communityService) CreateCommunity(ctx context.Context, name string, description string, tags []string, avatarfilePath string, backgroundFilePath string, userId string) (*model.Community, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommunityService.CreateCommunity")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Creating community with name: %s", name)
 	// get all community tags with the given tags
 	filter := bson.M{"tag_id": bson.M{"$in": tags}}
-	communityTags, err := s.communityTagQueryBuilder.Query(s.Context()).FindAll(filter, nil)
+	communityTags, err := s.communityTagQueryBuilder.Query(ctx).FindAll(filter, nil)
 	if err != nil {
-		s.logger.Error("Error fetching community tags: %v", err)
+		s.logger.WithContext(ctx).Error("Error fetching community tags: %v", err)
 		return nil, NewDBError("fetching community tags", err.Error())
 	}
 
 	if len(communityTags) == 0 {
-		s.logger.Error("No community tags found")
+		s.logger.WithContext(ctx).Error("No community tags found")
 		return nil, NewDBError("fetching community tags", "no community tags found")
 	}
-	s.logger.Info("Community tags found: %v", communityTags)
+	s.logger.WithContext(ctx).Info("Community tags found: %v", communityTags)
 	convertedTags := make([]model.CommunityTagInfo, len(communityTags))
 	for i, tag := range communityTags {
 		convertedTags[i] = tag.ToCommunityTagInfo()
@@ -95,7 +116,7 @@ func (s *communityService) CreateCommunity(name string, description string, tags
 	if avatarfilePath != "" {
 		avatarPhoto, err = s.mediaService.UploadMedia(avatarfilePath, userId+"_avatar", "community")
 		if err != nil {
-			s.logger.Error("Error uploading media: %v", err)
+			s.logger.WithContext(ctx).Error("Error uploading media: %v", err)
 		}
 	} else {
 		avatarPhoto = mediaMadels.MediaInfo{
@@ -110,7 +131,7 @@ func (s *communityService) CreateCommunity(name string, description string, tags
 	if backgroundFilePath != "" {
 		backgroundPhoto, err = s.mediaService.UploadMedia(backgroundFilePath, userId+"_background", "community")
 		if err != nil {
-			s.logger.Error("Error uploading media: %v", err)
+			s.logger.WithContext(ctx).Error("Error uploading media: %v", err)
 		}
 	} else {
 		backgroundPhoto = mediaMadels.MediaInfo{
@@ -120,18 +141,8 @@ func (s *communityService) CreateCommunity(name string, description string, tags
 			Height: 300,
 		}
 	}
-	avatarPhotoInfo := model.Image{
-		ID:     avatarPhoto.Id,
-		Url:    avatarPhoto.Url,
-		Width:  avatarPhoto.Width,
-		Height: avatarPhoto.Height,
-	}
-	backgroundPhotoInfo := model.Image{
-		ID:     backgroundPhoto.Id,
-		Url:    backgroundPhoto.Url,
-		Width:  backgroundPhoto.Width,
-		Height: backgroundPhoto.Height,
-	}
+	avatarPhotoInfo := model.NewImage(avatarPhoto)
+	backgroundPhotoInfo := model.NewImage(backgroundPhoto)
 	community := model.NewCommunity(model.NewCommunityArgs{
 		Name:        name,
 		Description: description,
@@ -141,34 +152,37 @@ func (s *communityService) CreateCommunity(name string, description string, tags
 		Tags:        convertedTags,
 	})
 
-	_, err = s.communityQueryBuilder.Query(s.Context()).InsertOne(community)
+	_, err = s.communityQueryBuilder.Query(ctx).InsertOne(community)
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err, "communityName") {
-			s.logger.Error("Community with name %s already exists: %v", name, err)
+			s.logger.WithContext(ctx).Error("Community with name %s already exists: %v", name, err)
 			return nil, NewDuplicateCommunityError("name", err)
 		}
 		if mongo.IsDuplicateKeyError(err, "communitySlug") {
-			s.logger.Error("Community with slug %s already exists: %v", community.Slug, err)
+			s.logger.WithContext(ctx).Error("Community with slug %s already exists: %v", community.Slug, err)
 			return nil, NewDuplicateCommunityError("slug", err)
 		}
-		s.logger.Error("Error inserting community: %v", err)
+		s.logger.WithContext(ctx).Error("Error inserting community: %v", err)
 		return nil, NewDBError("inserting community", err.Error())
 	}
-	s.logger.Info("Community created successfully with ID: %s", community.CommunityId)
+	s.logger.WithContext(ctx).Info("Community created successfully with ID: %s", community.CommunityId)
 	return community, nil
 }
 
-func (s *communityService) UpdateCommunity(id string, description string, avatarFilePath string, backgroundFilePath string, userId string) (*model.Community, network.ApiError) {
+func (s *communityService) UpdateCommunity(ctx context.Context, id string, description string, avatarFilePath string, backgroundFilePath string, userId string) (*model.Community, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommunityService.UpdateCommunity")
+	defer span.End()
+	defer s.invalidateCommunity(ctx, id)
 
-	s.logger.Info("Updating community with id: %s", id)
+	s.logger.WithContext(ctx).Info("Updating community with id: %s", id)
 	filter := bson.M{"communityId": id}
-	community, err := s.communityQueryBuilder.Query(s.Context()).FindOne(filter, nil)
+	community, err := s.communityQueryBuilder.Query(ctx).FindOne(filter, nil)
 	if err != nil && !mongo.IsNoDocumentFoundError(err) {
-		s.logger.Error("Error fetching community: %v", err)
+		s.logger.WithContext(ctx).Error("Error fetching community: %v", err)
 		return nil, NewDBError("fetching community", err.Error())
 	}
 	if community == nil {
-		s.logger.Error("Community not found")
+		s.logger.WithContext(ctx).Error("Community not found")
 		return nil, NewCommunityNotFoundError(id)
 	}
 	isOwner := false
@@ -183,7 +197,7 @@ func (s *communityService) UpdateCommunity(id string, description string, avatar
 		}
 	}
 	if !isOwner && !isModerator {
-		s.logger.Error("User is not the owner or moderator of the community")
+		s.logger.WithContext(ctx).Error("User is not the owner or moderator of the community")
 		if !isOwner {
 			return nil, NewNotAuthorizedError("user is not the owner of the community", userId, id)
 		} else {
@@ -194,7 +208,7 @@ func (s *communityService) UpdateCommunity(id string, description string, avatar
 	if avatarFilePath != "" {
 		avatarPhoto, err = s.mediaService.UploadMedia(avatarFilePath, userId+"_avatar", "community")
 		if err != nil {
-			s.logger.Error("Error uploading media: %v", err)
+			s.logger.WithContext(ctx).Error("Error uploading media: %v", err)
 			return nil, NewDBError("uploading media", err.Error())
 		}
 	}
@@ -202,29 +216,19 @@ func (s *communityService) UpdateCommunity(id string, description string, avatar
 	if backgroundFilePath != "" {
 		backgroundPhoto, err = s.mediaService.UploadMedia(backgroundFilePath, userId+"_background", "community")
 		if err != nil {
-			s.logger.Error("Error uploading media: %v", err)
+			s.logger.WithContext(ctx).Error("Error uploading media: %v", err)
 			return nil, NewDBError("uploading media", err.Error())
 		}
 	}
 
 	avatarPhotoInfo := community.Media.Avatar
 	if avatarFilePath != "" {
-		avatarPhotoInfo = model.Image{
-			ID:     avatarPhoto.Id,
-			Url:    avatarPhoto.Url,
-			Width:  avatarPhoto.Width,
-			Height: avatarPhoto.Height,
-		}
+		avatarPhotoInfo = model.NewImage(avatarPhoto)
 	}
 
 	backgroundPhotoInfo := community.Media.Background
 	if backgroundFilePath != "" {
-		backgroundPhotoInfo = model.Image{
-			ID:     backgroundPhoto.Id,
-			Url:    backgroundPhoto.Url,
-			Width:  backgroundPhoto.Width,
-			Height: backgroundPhoto.Height,
-		}
+		backgroundPhotoInfo = model.NewImage(backgroundPhoto)
 	}
 
 	community.Description = description
@@ -242,38 +246,64 @@ func (s *communityService) UpdateCommunity(id string, description string, avatar
 			"version": 1,
 		},
 	}
-	_, err = s.communityQueryBuilder.Query(s.Context()).UpdateOne(filter, update, nil)
+	_, err = s.communityQueryBuilder.Query(ctx).UpdateOne(filter, update, nil)
 	if err != nil {
-		s.logger.Error("Error updating community: %v", err)
+		s.logger.WithContext(ctx).Error("Error updating community: %v", err)
 		return nil, NewDBError("updating community", err.Error())
 	}
 	return community, nil
 }
 
-func (s *communityService) DeleteCommunity(id string, userId string) network.ApiError {
-	s.logger.Info("Deleting community with id: %s", id)
+// UpdateCommunityStatus sets the status of the community, only active communities are listed and joinable
+func (s *communityService) UpdateCommunityStatus(ctx context.Context, id string, status model.CommunityStatus) network.ApiError {
+	ctx, span := tracing.Start(ctx, "CommunityService.UpdateCommunityStatus")
+	defer span.End()
+	defer s.invalidateCommunity(ctx, id)
+
+	s.logger.WithContext(ctx).Info("Updating status of community %s to %s", id, status)
+	result, err := s.communityQueryBuilder.SingleQuery(ctx).UpdateOne(
+		bson.M{"communityId": id},
+		bson.M{"$set": bson.M{"status": status, "updatedAt": primitive.NewDateTimeFromTime(time.Now())}},
+		nil,
+	)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Error updating community status: %v", err)
+		return NewDBError("updating community status", err.Error())
+	}
+	if result.MatchedCount == 0 {
+		return NewCommunityNotFoundError(id)
+	}
+	return nil
+}
+
+func (s *communityService) DeleteCommunity(ctx context.Context, id string, userId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "CommunityService.DeleteCommunity")
+	defer span.End()
+	defer s.invalidateCommunity(ctx, id)
+
+	s.logger.WithContext(ctx).Info("Deleting community with id: %s", id)
 	filter := bson.M{"communityId": id}
-	community, err := s.communityQueryBuilder.Query(s.Context()).FindOne(filter, nil)
+	community, err := s.communityQueryBuilder.Query(ctx).FindOne(filter, nil)
 	if err != nil && !mongo.IsNoDocumentFoundError(err) {
-		s.logger.Error("Error fetching community: %v", err)
+		s.logger.WithContext(ctx).Error("Error fetching community: %v", err)
 		return NewDBError("fetching community", err.Error())
 	}
 	if community == nil {
-		s.logger.Error("Community not found")
+		s.logger.WithContext(ctx).Error("Community not found")
 		return NewCommunityNotFoundError(id)
 	}
 
 	if community.OwnerId != userId {
-		s.logger.Error("User is not the owner of the community")
+		s.logger.WithContext(ctx).Error("User is not the owner of the community")
 		return NewNotAuthorizedError("user is not the owner of the community", userId, id)
 	}
 	if community.Status != model.CommunityStatusActive {
-		s.logger.Error("Community is not active")
+		s.logger.WithContext(ctx).Error("Community is not active")
 		return NewNotAuthorizedError("community is not active", userId, id)
 	}
 
 	// Start a transaction for consistent state
-	tx := s.transaction.GetTransaction(mongo.DefaultShortTransactionTimeout)
+	tx := s.transaction.GetTransaction(ctx, mongo.DefaultShortTransactionTimeout)
 	err = tx.PerformSingleTransaction(func(session mongo.TransactionSession) error {
 		communityCollection := session.Collection(model.CommunityCollectionName)
 		communityCollection.FindOneAndUpdate(
@@ -287,10 +317,10 @@ func (s *communityService) DeleteCommunity(id string, userId string) network.Api
 		)
 		if err != nil {
 			if mongo.IsNoDocumentFoundError(err) {
-				s.logger.Error("Community with id %s not found: %v", id, err)
+				s.logger.WithContext(ctx).Error("Community with id %s not found: %v", id, err)
 				return network.NewNotFoundError("community not found", fmt.Sprintf("Community with ID '%s' not found. It may have been deleted or never existed. Context - [ No Data ] ", id), err)
 			}
-			s.logger.Error("Error updating community: %v", err)
+			s.logger.WithContext(ctx).Error("Error updating community: %v", err)
 			return network.NewInternalServerError("error updating community", fmt.Sprintf("Error updating community with ID '%s'. Context - [ Query Failed ] ", id), network.DB_ERROR, err)
 		}
 
@@ -305,7 +335,7 @@ func (s *communityService) DeleteCommunity(id string, userId string) network.Api
 			},
 		)
 		if err != nil {
-			s.logger.Error("Error deleting community interactions: %v", err)
+			s.logger.WithContext(ctx).Error("Error deleting community interactions: %v", err)
 			return network.NewInternalServerError("error deleting community interactions", fmt.Sprintf("Error deleting community interactions for community %s. Context - [ Query Failed ] ", id), network.DB_ERROR, err)
 		}
 
@@ -333,7 +363,7 @@ func (s *communityService) DeleteCommunity(id string, userId string) network.Api
 			},
 		)
 		if err != nil {
-			s.logger.Error("Error deleting post interactions: %v", err)
+			s.logger.WithContext(ctx).Error("Error deleting post interactions: %v", err)
 			return network.NewInternalServerError("error deleting post interactions", fmt.Sprintf("Error deleting post interactions for community %s. Context - [ Query Failed ] ", id), network.DB_ERROR, err)
 		}
 
@@ -341,19 +371,41 @@ func (s *communityService) DeleteCommunity(id string, userId string) network.Api
 	})
 	if err != nil {
 		if network.IsApiError(err) {
-			s.logger.Error("Failed to delete community: %v", err)
+			s.logger.WithContext(ctx).Error("Failed to delete community: %v", err)
 			return network.AsApiError(err)
 		}
-		s.logger.Error("Failed to commit transaction: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to commit transaction: %v", err)
 		return network.NewInternalServerError("failed to commit transaction", fmt.Sprintf("Failed to commit transaction for community %s. Context - [ Transaction Failed ] ", id), network.DB_ERROR, err)
 	}
-	s.logger.Info("Community with id %s deleted successfully", id)
+	s.logger.WithContext(ctx).Info("Community with id %s deleted successfully", id)
 	return nil
 }
 
-func (s *communityService) GetCommunityById(id string) (*model.PublicGetCommunity, network.ApiError) {
-	s.logger.Info("Fetching community with id: %s", id)
-	getCommunityByIdPipeline := s.getCommunityByIdPipeline.SingleAggregate()
+func (s *communityService) GetCommunityById(ctx context.Context, id string) (*model.PublicGetCommunity, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommunityService.GetCommunityById")
+	defer span.End()
+
+	community, err := s.communityCache.GetOrLoad(ctx, id, func(ctx context.Context) (*model.PublicGetCommunity, error) {
+		return s.findCommunityById(ctx, id)
+	}, model.CommunityCacheTag(id))
+	if err != nil {
+		var apiErr network.ApiError
+		if errors.As(err, &apiErr) {
+			return nil, apiErr
+		}
+		return nil, network.NewInternalServerError(
+			"Error fetching community",
+			fmt.Sprintf("Error fetching community for id %s. Context - [ Cache Failed ] ", id),
+			network.CACHE_ERROR,
+			err,
+		)
+	}
+	return community, nil
+}
+
+func (s *communityService) findCommunityById(ctx context.Context, id string) (*model.PublicGetCommunity, network.ApiError) {
+	s.logger.WithContext(ctx).Info("Fetching community with id: %s", id)
+	getCommunityByIdPipeline := s.getCommunityByIdPipeline.SingleAggregate(ctx)
 	getCommunityByIdPipeline.Match(bson.M{"communityId": id, "status": model.CommunityStatusActive})
 
 	// Lookup community interactions to check if user has joined
@@ -467,7 +519,7 @@ func (s *communityService) GetCommunityById(id string) (*model.PublicGetCommunit
 
 	communityResults, err := getCommunityByIdPipeline.Exec()
 	if err != nil {
-		s.logger.Error("Error executing community query: %v", err)
+		s.logger.WithContext(ctx).Error("Error executing community query: %v", err)
 		return nil, network.NewInternalServerError(
 			"Error executing community query",
 			fmt.Sprintf("Error executing community query for id %s. Context - [ Query Failed ] ", id),
@@ -477,7 +529,7 @@ func (s *communityService) GetCommunityById(id string) (*model.PublicGetCommunit
 	}
 
 	if len(communityResults) == 0 {
-		s.logger.Error("Community not found")
+		s.logger.WithContext(ctx).Error("Community not found")
 		return nil, network.NewNotFoundError(
 			"Community not found",
 			fmt.Sprintf("Community with ID '%s' not found. It may have been deleted or never existed. Context - [ No Data ] ", id),
@@ -489,11 +541,14 @@ func (s *communityService) GetCommunityById(id string) (*model.PublicGetCommunit
 	return communityResults[0], nil
 }
 
-func (s *communityService) CheckUserInCommunity(userId string, communityId string) network.ApiError {
-	s.logger.Info("Checking if user %s is in community %s", userId, communityId)
-	community, err := s.communityQueryBuilder.Query(s.Context()).FindOne(bson.M{"communityId": communityId}, nil)
+func (s *communityService) CheckUserInCommunity(ctx context.Context, userId string, communityId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "CommunityService.CheckUserInCommunity")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Checking if user %s is in community %s", userId, communityId)
+	community, err := s.communityQueryBuilder.Query(ctx).FindOne(bson.M{"communityId": communityId}, nil)
 	if err != nil {
-		s.logger.Error("Error fetching community: %v", err)
+		s.logger.WithContext(ctx).Error("Error fetching community: %v", err)
 		return network.NewInternalServerError(
 			"Error fetching community",
 			fmt.Sprintf("Error fetching community with id %s. Context - [ Query Failed ] ", communityId),
@@ -503,7 +558,7 @@ func (s *communityService) CheckUserInCommunity(userId string, communityId strin
 	}
 
 	if community == nil {
-		s.logger.Error("Community not found")
+		s.logger.WithContext(ctx).Error("Community not found")
 		return network.NewNotFoundError(
 			"Community not found",
 			fmt.Sprintf("It seems the community with ID '%s' does not exist. The community may have been deleted or never existed. Context - [ No Data ] ", communityId),
@@ -511,17 +566,17 @@ func (s *communityService) CheckUserInCommunity(userId string, communityId strin
 		)
 	}
 
-	communityInteraction, err := s.communityInteractionQueryBuilder.Query(s.Context()).FindOne(bson.M{"communityId": communityId, "userId": userId}, nil)
+	communityInteraction, err := s.communityInteractionQueryBuilder.Query(ctx).FindOne(bson.M{"communityId": communityId, "userId": userId}, nil)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
-			s.logger.Error("Community interaction not found: %v", err)
+			s.logger.WithContext(ctx).Error("Community interaction not found: %v", err)
 			return network.NewNotFoundError(
 				"User is not a member of the community",
 				fmt.Sprintf("User %s is not a member of community %s. Context - [ No Data ] ", userId, communityId),
 				err,
 			)
 		}
-		s.logger.Error("Error fetching community interaction: %v", err)
+		s.logger.WithContext(ctx).Error("Error fetching community interaction: %v", err)
 		return network.NewInternalServerError(
 			"Error fetching community interaction",
 			fmt.Sprintf("Error fetching community interaction for user %s in community %s. Context - [ Query Failed ] ", userId, communityId),
@@ -531,10 +586,10 @@ func (s *communityService) CheckUserInCommunity(userId string, communityId strin
 	}
 	switch communityInteraction.InteractionType {
 	case model.CommunityInteractionTypeJoin:
-		s.logger.Info("User %s is a member of community %s", userId, communityId)
+		s.logger.WithContext(ctx).Info("User %s is a member of community %s", userId, communityId)
 		return nil
 	case model.CommunityInteractionTypeLeave:
-		s.logger.Info("User %s left the community %s", userId, communityId)
+		s.logger.WithContext(ctx).Info("User %s left the community %s", userId, communityId)
 		return network.NewNotFoundError(
 			"User is not a member of the community",
 			fmt.Sprintf("User %s left the community %s. Context - [ No Data ] ", userId, communityId),
@@ -542,7 +597,7 @@ func (s *communityService) CheckUserInCommunity(userId string, communityId strin
 		)
 	default:
 		// This case should not happen, but just in case
-		s.logger.Error("User is not a member of the community")
+		s.logger.WithContext(ctx).Error("User is not a member of the community")
 		return network.NewNotFoundError(
 			"User is not a member of the community",
 			fmt.Sprintf("User %s is not a member of community %s. Context - [ No Data ] ", userId, communityId),
@@ -551,10 +606,13 @@ func (s *communityService) CheckUserInCommunity(userId string, communityId strin
 	}
 }
 
-func (s *communityService) GetCommunities(userId string, page int, limit int) ([]*model.Community, network.ApiError) {
-	s.logger.Info("Fetching communities for user %s, page: %d, limit: %d", userId, page, limit)
+func (s *communityService) GetCommunities(ctx context.Context, userId string, page int, limit int) ([]*model.Community, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommunityService.GetCommunities")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Fetching communities for user %s, page: %d, limit: %d", userId, page, limit)
 
-	communityInteractions, err := s.communityInteractionQueryBuilder.Query(s.Context()).FindPaginated(
+	communityInteractions, err := s.communityInteractionQueryBuilder.Query(ctx).FindPaginated(
 		bson.M{"userId": userId, "interactionType": model.CommunityInteractionTypeJoin},
 		int64(page),
 		int64(limit),
@@ -562,7 +620,7 @@ func (s *communityService) GetCommunities(userId string, page int, limit int) ([
 	)
 
 	if err != nil {
-		s.logger.Error("Error fetching communities: %v", err)
+		s.logger.WithContext(ctx).Error("Error fetching communities: %v", err)
 		return nil, network.NewInternalServerError(
 			"Error fetching communities",
 			fmt.Sprintf("Error fetching communities for user %s. Context - [ Query Failed ] ", userId),
@@ -580,13 +638,13 @@ func (s *communityService) GetCommunities(userId string, page int, limit int) ([
 		communityIds = append(communityIds, interaction.CommunityId)
 	}
 
-	aggregator := s.communityAggregateBuilder.SingleAggregate()
+	aggregator := s.communityAggregateBuilder.SingleAggregate(ctx)
 	aggregator.Match(bson.M{"communityId": bson.M{"$in": communityIds}, "status": model.CommunityStatusActive})
 	aggregator.Skip(int64((page - 1) * limit))
 	aggregator.Limit(int64(limit))
 	communityResults, err := aggregator.Exec()
 	if err != nil {
-		s.logger.Error("Error executing community query: %v", err)
+		s.logger.WithContext(ctx).Error("Error executing community query: %v", err)
 		return nil, network.NewInternalServerError(
 			"Error executing community query",
 			fmt.Sprintf("Error executing community query for user %s. Context - [ Query Failed ] ", userId),
@@ -598,11 +656,15 @@ func (s *communityService) GetCommunities(userId string, page int, limit int) ([
 	return communityResults, nil
 }
 
-func (s *communityService) JoinCommunity(userId string, communityId string) network.ApiError {
-	s.logger.Info("User %s is joining community %s", userId, communityId)
+func (s *communityService) JoinCommunity(ctx context.Context, userId string, communityId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "CommunityService.JoinCommunity")
+	defer span.End()
+	defer s.invalidateCommunity(ctx, communityId)
+
+	s.logger.WithContext(ctx).Info("User %s is joining community %s", userId, communityId)
 
 	// Start a transaction for consistent state
-	tx := s.transaction.GetTransaction(mongo.DefaultShortTransactionTimeout)
+	tx := s.transaction.GetTransaction(ctx, mongo.DefaultShortTransactionTimeout)
 
 	err := tx.PerformSingleTransaction(func(session mongo.TransactionSession) error {
 		communityCollection := session.Collection(model.CommunityCollectionName)
@@ -615,14 +677,14 @@ func (s *communityService) JoinCommunity(userId string, communityId string) netw
 
 		if mongoErr.Err() != nil {
 			if mongo.IsNoDocumentFoundError(mongoErr.Err()) {
-				s.logger.Error("Community with id %s not found: %v", communityId, mongoErr.Err())
+				s.logger.WithContext(ctx).Error("Community with id %s not found: %v", communityId, mongoErr.Err())
 				return network.NewNotFoundError(
 					"Community not found",
 					fmt.Sprintf("Community with ID '%s' not found. It may have been deleted or never existed. Context - [ No Data ] ", communityId),
 					mongoErr.Err(),
 				)
 			}
-			s.logger.Error("Error updating community: %v", mongoErr.Err())
+			s.logger.WithContext(ctx).Error("Error updating community: %v", mongoErr.Err())
 			return network.NewInternalServerError(
 				"Error updating community",
 				fmt.Sprintf("Error updating community with ID '%s'. Context - [ Query Failed ] ", communityId),
@@ -636,14 +698,14 @@ func (s *communityService) JoinCommunity(userId string, communityId string) netw
 		_, insertErr := communityInteractionCollection.InsertOne(communityInteraction)
 		if insertErr != nil {
 			if mongo.IsDuplicateKeyError(insertErr) {
-				s.logger.Warn("Community interaction already exists (race condition): %v", insertErr)
+				s.logger.WithContext(ctx).Warn("Community interaction already exists (race condition): %v", insertErr)
 				return network.NewConflictError(
 					"Community interaction already exists",
 					fmt.Sprintf("User %s is already a member of community %s. Context - [ Duplicate Key ] ", userId, communityId),
 					insertErr,
 				)
 			} else {
-				s.logger.Error("Failed to insert community interaction: %v", insertErr)
+				s.logger.WithContext(ctx).Error("Failed to insert community interaction: %v", insertErr)
 				return network.NewInternalServerError(
 					"Failed to insert community interaction",
 					fmt.Sprintf("Failed to insert community interaction for user %s in community %s. Context - [ Query Failed ] ", userId, communityId),
@@ -657,10 +719,10 @@ func (s *communityService) JoinCommunity(userId string, communityId string) netw
 
 	if err != nil {
 		if network.IsApiError(err) {
-			s.logger.Error("Failed to join community: %v", err)
+			s.logger.WithContext(ctx).Error("Failed to join community: %v", err)
 			return network.AsApiError(err)
 		}
-		s.logger.Error("Failed to commit transaction: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to commit transaction: %v", err)
 		return network.NewInternalServerError(
 			"Failed to commit transaction",
 			fmt.Sprintf("Failed to commit transaction for user %s in community %s. Context - [ Transaction Failed ] ", userId, communityId),
@@ -669,15 +731,19 @@ func (s *communityService) JoinCommunity(userId string, communityId string) netw
 		)
 	}
 
-	s.logger.Info("User %s successfully joined community %s", userId, communityId)
+	s.logger.WithContext(ctx).Info("User %s successfully joined community %s", userId, communityId)
 	return nil
 }
 
-func (s *communityService) LeaveCommunity(userId string, communityId string) network.ApiError {
-	s.logger.Info("User %s is leaving community %s", userId, communityId)
+func (s *communityService) LeaveCommunity(ctx context.Context, userId string, communityId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "CommunityService.LeaveCommunity")
+	defer span.End()
+	defer s.invalidateCommunity(ctx, communityId)
+
+	s.logger.WithContext(ctx).Info("User %s is leaving community %s", userId, communityId)
 
 	// Start a transaction for consistent state
-	tx := s.transaction.GetTransaction(mongo.DefaultShortTransactionTimeout)
+	tx := s.transaction.GetTransaction(ctx, mongo.DefaultShortTransactionTimeout)
 
 	err := tx.PerformSingleTransaction(func(session mongo.TransactionSession) error {
 		communityCollection := session.Collection(model.CommunityCollectionName)
@@ -690,14 +756,14 @@ func (s *communityService) LeaveCommunity(userId string, communityId string) net
 
 		if updateErr.Err() != nil {
 			if mongo.IsNoDocumentFoundError(updateErr.Err()) {
-				s.logger.Error("Community with id %s not found: %v", communityId, updateErr.Err())
+				s.logger.WithContext(ctx).Error("Community with id %s not found: %v", communityId, updateErr.Err())
 				return network.NewNotFoundError(
 					"Community not found",
 					fmt.Sprintf("Community with ID '%s' not found. It may have been deleted or never existed. Context - [ No Data ] ", communityId),
 					updateErr.Err(),
 				)
 			}
-			s.logger.Error("Error updating community: %v", updateErr.Err())
+			s.logger.WithContext(ctx).Error("Error updating community: %v", updateErr.Err())
 			return network.NewInternalServerError(
 				"Error updating community",
 				fmt.Sprintf("Error updating community with ID '%s'. Context - [ Query Failed ] ", communityId),
@@ -721,14 +787,14 @@ func (s *communityService) LeaveCommunity(userId string, communityId string) net
 		if insertErr.Err() != nil {
 			if mongo.IsNoDocumentFoundError(insertErr.Err()) {
 				// user hasnt joined the community yet
-				s.logger.Error("Community interaction not found: %v", insertErr.Err())
+				s.logger.WithContext(ctx).Error("Community interaction not found: %v", insertErr.Err())
 				return network.NewNotFoundError(
 					"Community interaction not found",
 					fmt.Sprintf("Community interaction not found for user %s in community %s. Context - [ No Data ] ", userId, communityId),
 					insertErr.Err(),
 				)
 			}
-			s.logger.Error("Failed to update community interaction: %v", insertErr.Err())
+			s.logger.WithContext(ctx).Error("Failed to update community interaction: %v", insertErr.Err())
 			return network.NewInternalServerError(
 				"Failed to update community interaction",
 				fmt.Sprintf("Failed to update community interaction for user %s in community %s. Context - [ Query Failed ] ", userId, communityId),
@@ -745,7 +811,7 @@ func (s *communityService) LeaveCommunity(userId string, communityId string) net
 			},
 		)
 		if removeErr != nil {
-			s.logger.Error("Failed to remove user from moderators list: %v", removeErr)
+			s.logger.WithContext(ctx).Error("Failed to remove user from moderators list: %v", removeErr)
 			return network.NewInternalServerError(
 				"Failed to remove user from moderators list",
 				fmt.Sprintf("Failed to remove user %s from moderators list in community %s. Context - [ Query Failed ] ", userId, communityId),
@@ -759,10 +825,10 @@ func (s *communityService) LeaveCommunity(userId string, communityId string) net
 
 	if err != nil {
 		if network.IsApiError(err) {
-			s.logger.Error("Failed to leave community: %v", err)
+			s.logger.WithContext(ctx).Error("Failed to leave community: %v", err)
 			return network.AsApiError(err)
 		}
-		s.logger.Error("Failed to commit transaction: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to commit transaction: %v", err)
 		return network.NewInternalServerError(
 			"Failed to commit transaction",
 			fmt.Sprintf("Failed to commit transaction for user %s in community %s. Context - [ Transaction Failed ] ", userId, communityId),
@@ -771,14 +837,17 @@ func (s *communityService) LeaveCommunity(userId string, communityId string) net
 		)
 	}
 
-	s.logger.Info("User %s successfully left community %s", userId, communityId)
+	s.logger.WithContext(ctx).Info("User %s successfully left community %s", userId, communityId)
 	return nil
 }
 
-func (s *communityService) SearchCommunities(query string, page int, limit int, showPrivate bool) ([]*model.CommunitySearchResult, network.ApiError) {
-	s.logger.Info("Searching communities with query: %s, page: %d, limit: %d", query, page, limit)
+func (s *communityService) SearchCommunities(ctx context.Context, query string, page int, limit int, showPrivate bool) ([]*model.CommunitySearchResult, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommunityService.SearchCommunities")
+	defer span.End()
 
-	aggregator := s.communitySearchPipeline.Aggregate(s.Context())
+	s.logger.WithContext(ctx).Info("Searching communities with query: %s, page: %d, limit: %d", query, page, limit)
+
+	aggregator := s.communitySearchPipeline.Aggregate(ctx)
 
 	matchStage := bson.M{
 		"$and": []bson.M{
@@ -917,7 +986,7 @@ func (s *communityService) SearchCommunities(query string, page int, limit int,
 		Exec()
 
 	if err != nil {
-		s.logger.Error("Error executing community search: %v", err)
+		s.logger.WithContext(ctx).Error("Error executing community search: %v", err)
 		return nil, network.NewInternalServerError(
 			"Error executing community search",
 			fmt.Sprintf("Error executing community search with query '%s'. Context - [ Query Failed ] ", query),
@@ -930,10 +999,13 @@ func (s *communityService) SearchCommunities(query string, page int, limit int,
 	return communitiesResults, nil
 }
 
-func (s *communityService) AutocompleteCommunities(query string, page int, limit int, showPrivate bool) ([]*model.CommunityAutocomplete, network.ApiError) {
-	s.logger.Info("Autocomplete communities with query: %s, page: %d, limit: %d", query, page, limit)
+func (s *communityService) AutocompleteCommunities(ctx context.Context, query string, page int, limit int, showPrivate bool) ([]*model.CommunityAutocomplete, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "CommunityService.AutocompleteCommunities")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Autocomplete communities with query: %s, page: %d, limit: %d", query, page, limit)
 
-	aggregator := s.communityAutocompletePipeline.Aggregate(s.Context())
+	aggregator := s.communityAutocompletePipeline.Aggregate(ctx)
 
 	if query != "" {
 		searchQuery := bson.M{
@@ -1015,7 +1087,7 @@ func (s *communityService) AutocompleteCommunities(query string, page int, limit
 
 	communitiesResults, err := aggregator.Exec()
 	if err != nil {
-		s.logger.Error("Error executing community autocomplete: %v", err)
+		s.logger.WithContext(ctx).Error("Error executing community autocomplete: %v", err)
 		return nil, network.NewInternalServerError(
 			"Error executing community autocomplete",
 			fmt.Sprintf("Error executing community autocomplete with query '%s'. Context - [ Query Failed ] ", query),
@@ -1028,55 +1100,63 @@ func (s *communityService) AutocompleteCommunities(query string, page int, limit
 	return communitiesResults, nil
 }
 
-func (s *communityService) AddModerator(userId string, communityId string, moderatorId string) network.ApiError {
-	s.logger.Info("Adding moderator %s to community %s by user %s", moderatorId, communityId, userId)
+func (s *communityService) AddModerator(ctx context.Context, userId string, communityId string, moderatorId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "CommunityService.AddModerator")
+	defer span.End()
+	defer s.invalidateCommunity(ctx, communityId)
+
+	s.logger.WithContext(ctx).Info("Adding moderator %s to community %s by user %s", moderatorId, communityId, userId)
 
 	// Check if the user is the owner or a moderator of the community
 	filter := bson.M{"communityId": communityId, "status": model.CommunityStatusActive}
 	update := bson.M{
 		"$addToSet": bson.M{"moderators": bson.M{"userId": moderatorId, "addedBy": userId, "addedAt": primitive.NewDateTimeFromTime(time.Now())}},
 	}
-	community, err := s.communityQueryBuilder.Query(s.Context()).FindOneAndUpdate(filter, update)
+	community, err := s.communityQueryBuilder.Query(ctx).FindOneAndUpdate(filter, update)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
 
 		}
 		if mongo.IsDuplicateKeyError(err) {
-			s.logger.Error("Moderator already exists in the community: %v", err)
+			s.logger.WithContext(ctx).Error("Moderator already exists in the community: %v", err)
 			return NewConflictError("moderator already exists in the community", fmt.Sprintf("User %s is already a moderator of community %s. Context - [ Duplicate ] ", moderatorId, communityId), err)
 		} else {
-			s.logger.Error("Error adding moderator to community: %v", err)
+			s.logger.WithContext(ctx).Error("Error adding moderator to community: %v", err)
 			return NewDBError("adding moderator to community", err.Error())
 		}
 	}
 	// Check if the community was found and updated
 	if community == nil {
-		s.logger.Error("Community not found or not updated")
+		s.logger.WithContext(ctx).Error("Community not found or not updated")
 		return NewCommunityNotFoundError(communityId)
 	}
 	return nil
 }
 
-func (s *communityService) RemoveModerator(userId string, communityId string, moderatorId string) network.ApiError {
-	s.logger.Info("Removing moderator %s from community %s by user %s", moderatorId, communityId, userId)
+func (s *communityService) RemoveModerator(ctx context.Context, userId string, communityId string, moderatorId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "CommunityService.RemoveModerator")
+	defer span.End()
+	defer s.invalidateCommunity(ctx, communityId)
+
+	s.logger.WithContext(ctx).Info("Removing moderator %s from community %s by user %s", moderatorId, communityId, userId)
 
 	// Check if the user is the owner or a moderator of the community
 	filter := bson.M{"communityId": communityId, "status": model.CommunityStatusActive}
 	update := bson.M{
 		"$pull": bson.M{"moderators": bson.M{"userId": moderatorId}},
 	}
-	community, err := s.communityQueryBuilder.Query(s.Context()).FindOneAndUpdate(filter, update)
+	community, err := s.communityQueryBuilder.Query(ctx).FindOneAndUpdate(filter, update)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
-			s.logger.Error("Community with id %s not found: %v", communityId, err)
+			s.logger.WithContext(ctx).Error("Community with id %s not found: %v", communityId, err)
 			return NewCommunityNotFoundError(communityId)
 		}
-		s.logger.Error("Error removing moderator from community: %v", err)
+		s.logger.WithContext(ctx).Error("Error removing moderator from community: %v", err)
 		return NewDBError("removing moderator from community", err.Error())
 	}
 	// Check if the community was found and updated
 	if community == nil {
-		s.logger.Error("Community not found or not updated")
+		s.logger.WithContext(ctx).Error("Community not found or not updated")
 		return NewCommunityNotFoundError(communityId)
 	}
 	return nil
@@ -0,0 +1,87 @@
+package digest
+
+import (
+	"html/template"
+	"net/http"
+	"sync-backend/api/digest/dto"
+	"sync-backend/arch/network"
+	"sync-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type digestController struct {
+	logger utils.AppLogger
+	network.BaseController
+	digestService DigestService
+}
+
+func NewDigestController(
+	authProvider network.AuthenticationProvider,
+	digestService DigestService,
+) network.Controller {
+	return &digestController{
+		logger:         utils.NewServiceLogger("DigestController"),
+		BaseController: network.NewBaseController("/digest", authProvider),
+		digestService:  digestService,
+	}
+}
+
+func (c *digestController) MountRoutes(group *gin.RouterGroup) {
+	c.logger.Info("Mounting digest routes")
+	// Public, the signed token identifies the user. Only POST unsubscribes, it is sent by mail clients
+	// for one-click unsubscribe (RFC 8058) and by the confirmation page GET renders, so link scanners
+	// opening the link do not unsubscribe anyone.
+	group.GET("/unsubscribe", c.ConfirmUnsubscribe)
+	group.POST("/unsubscribe", c.Unsubscribe)
+}
+
+var confirmUnsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="UTF-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1.0">
+	<title>Unsubscribe from Sync digests</title>
+</head>
+<body style="font-family: sans-serif; max-width: 480px; margin: 80px auto; text-align: center;">
+	<h1>Unsubscribe from digests?</h1>
+	<p>You will no longer receive digest emails. They can be turned back on in your notification settings.</p>
+	<form method="POST" action="?token={{.}}">
+		<button type="submit">Unsubscribe</button>
+	</form>
+</body>
+</html>
+`))
+
+// ConfirmUnsubscribe renders a page asking to confirm the unsubscribe, it changes nothing
+func (c *digestController) ConfirmUnsubscribe(ctx *gin.Context) {
+	query, err := network.ReqQuery(ctx, dto.NewUnsubscribeRequest())
+	if err != nil {
+		return
+	}
+
+	if apiErr := c.digestService.CheckUnsubscribeToken(ctx.Request.Context(), query.Token); apiErr != nil {
+		c.Send(ctx).MixedError(apiErr)
+		return
+	}
+
+	ctx.Header("Content-Type", "text/html; charset=utf-8")
+	ctx.Status(http.StatusOK)
+	if err := confirmUnsubscribePage.Execute(ctx.Writer, query.Token); err != nil {
+		c.logger.WithContext(ctx).Error("Failed to render unsubscribe page: %v", err)
+	}
+}
+
+func (c *digestController) Unsubscribe(ctx *gin.Context) {
+	query, err := network.ReqQuery(ctx, dto.NewUnsubscribeRequest())
+	if err != nil {
+		return
+	}
+
+	if apiErr := c.digestService.Unsubscribe(ctx.Request.Context(), query.Token); apiErr != nil {
+		c.Send(ctx).MixedError(apiErr)
+		return
+	}
+
+	c.Send(ctx).SuccessMsgResponse("You have been unsubscribed from digest emails")
+}
@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// UnsubscribeRequest is the request of one-click digest unsubscribe links
+type UnsubscribeRequest struct {
+	Token string `form:"token" query:"token" validate:"required,max=512"`
+}
+
+// NewUnsubscribeRequest creates a new digest unsubscribe request
+func NewUnsubscribeRequest() *UnsubscribeRequest {
+	return &UnsubscribeRequest{}
+}
+
+func (u *UnsubscribeRequest) GetValue() *UnsubscribeRequest {
+	return u
+}
+
+func (u *UnsubscribeRequest) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		switch err.Tag() {
+		case "required":
+			msgs = append(msgs, err.Field()+" is required")
+		default:
+			msgs = append(msgs, err.Field()+" is invalid")
+		}
+	}
+	return msgs, nil
+}
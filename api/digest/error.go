@@ -0,0 +1,31 @@
+package digest
+
+import (
+	"fmt"
+	"sync-backend/arch/network"
+)
+
+func NewInvalidUnsubscribeTokenError(err error) network.ApiError {
+	return network.NewBadRequestError(
+		"Invalid unsubscribe link",
+		"The unsubscribe link is malformed or was not issued by Sync. Digests can also be turned off in the notification preferences.",
+		err,
+	)
+}
+
+func NewExpiredUnsubscribeTokenError(err error) network.ApiError {
+	return network.NewBadRequestError(
+		"Unsubscribe link expired",
+		"The unsubscribe link is from an old digest. Use the link in a recent digest or turn digests off in the notification preferences.",
+		err,
+	)
+}
+
+func NewUnsubscribeError(userId string, err error) network.ApiError {
+	return network.NewInternalServerError(
+		"Error unsubscribing from digests",
+		fmt.Sprintf("Database error when turning off digests for user '%s'. [Context: userId=%s]", userId, userId),
+		network.DB_ERROR,
+		err,
+	)
+}
@@ -0,0 +1,63 @@
+package digest
+
+import (
+	"strings"
+	"time"
+
+	userModels "sync-backend/api/user/model"
+)
+
+// lastSlot returns the most recent time at or before now a digest of the frequency was due in loc,
+// sendHour o'clock every day for daily digests and on weekday for weekly ones
+func lastSlot(now time.Time, loc *time.Location, frequency userModels.DigestFrequency, sendHour int, weekday time.Weekday) time.Time {
+	local := now.In(loc)
+	slot := time.Date(local.Year(), local.Month(), local.Day(), sendHour, 0, 0, 0, loc)
+	if slot.After(local) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+	if frequency == userModels.DigestWeekly {
+		for slot.Weekday() != weekday {
+			slot = slot.AddDate(0, 0, -1)
+		}
+	}
+	return slot
+}
+
+// period is the longest span a digest covers, the first digest of a user starts here too
+func period(frequency userModels.DigestFrequency) time.Duration {
+	if frequency == userModels.DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// dueSince reports whether a digest is due for the user and the time it should cover activity from
+func dueSince(user *userModels.User, now time.Time, sendHour int, weekday time.Weekday) (time.Time, bool) {
+	frequency := user.Preferences.Notifications.Digest()
+	if frequency == userModels.DigestOff {
+		return time.Time{}, false
+	}
+
+	// Users are not sent a digest right after signing up, their first one waits for the next slot
+	reference := user.CreatedAt.Time()
+	if user.DigestSentAt != nil {
+		reference = user.DigestSentAt.Time()
+	}
+	if !reference.Before(lastSlot(now, user.Preferences.Timezone.Location(), frequency, sendHour, weekday)) {
+		return time.Time{}, false
+	}
+
+	if earliest := now.Add(-period(frequency)); reference.Before(earliest) {
+		reference = earliest
+	}
+	return reference, true
+}
+
+func parseWeekday(day string) time.Weekday {
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if strings.EqualFold(weekday.String(), day) {
+			return weekday
+		}
+	}
+	return time.Monday
+}
@@ -0,0 +1,107 @@
+package digest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	userModels "sync-backend/api/user/model"
+	"sync-backend/arch/common"
+	"sync-backend/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestLastSlotUsesUserTimezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	// 23:30 UTC on Sunday is 08:30 Monday in Tokyo
+	now := time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 3, 2, 8, 0, 0, 0, tokyo), lastSlot(now, tokyo, userModels.DigestDaily, 8, time.Monday))
+	assert.Equal(t, time.Date(2026, 3, 2, 8, 0, 0, 0, tokyo), lastSlot(now, tokyo, userModels.DigestWeekly, 8, time.Monday))
+
+	// Before the send hour the previous day's slot applies
+	assert.Equal(t, time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC), lastSlot(now, time.UTC, userModels.DigestDaily, 8, time.Monday))
+	assert.Equal(t, time.Date(2026, 2, 23, 8, 0, 0, 0, time.UTC), lastSlot(now, time.UTC, userModels.DigestWeekly, 8, time.Monday))
+}
+
+func TestDueSince(t *testing.T) {
+	now := time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC)
+	user := &userModels.User{
+		CreatedAt: primitive.NewDateTimeFromTime(now.AddDate(0, -1, 0)),
+		Preferences: userModels.UserPreferences{
+			Timezone:      common.UTC.ToDetail(),
+			Notifications: userModels.UserNotificationSettings{Email: true, DigestFrequency: userModels.DigestDaily},
+		},
+	}
+
+	// First digest covers one period only
+	since, due := dueSince(user, now, 8, time.Monday)
+	assert.True(t, due)
+	assert.Equal(t, now.Add(-24*time.Hour), since)
+
+	sentAt := primitive.NewDateTimeFromTime(now.Add(-30 * time.Minute))
+	user.DigestSentAt = &sentAt
+	_, due = dueSince(user, now, 8, time.Monday)
+	assert.False(t, due, "already sent for today's slot")
+
+	sentAt = primitive.NewDateTimeFromTime(now.Add(-25 * time.Hour))
+	since, due = dueSince(user, now, 8, time.Monday)
+	assert.True(t, due)
+	assert.Equal(t, now.Add(-24*time.Hour), since)
+
+	user.Preferences.Notifications.Email = false
+	_, due = dueSince(user, now, 8, time.Monday)
+	assert.False(t, due, "email notifications off")
+}
+
+func TestNewUsersWaitForTheNextSlot(t *testing.T) {
+	now := time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC)
+	user := &userModels.User{
+		CreatedAt: primitive.NewDateTimeFromTime(now.Add(-10 * time.Minute)),
+		Preferences: userModels.UserPreferences{
+			Notifications: userModels.UserNotificationSettings{Email: true},
+		},
+	}
+	_, due := dueSince(user, now, 8, time.Monday)
+	assert.False(t, due)
+}
+
+func TestUnsubscribeToken(t *testing.T) {
+	key := utils.DeriveKey("secret", "digest-unsubscribe")
+	issuedAt := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	token := signUnsubscribeToken(key, "user-1", issuedAt)
+
+	userId, err := verifyUnsubscribeToken(key, token, 24*time.Hour, issuedAt.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", userId)
+
+	_, err = verifyUnsubscribeToken(key, token, 24*time.Hour, issuedAt.Add(25*time.Hour))
+	assert.ErrorIs(t, err, errExpiredUnsubscribeToken)
+
+	otherKey := utils.DeriveKey("rotated", "digest-unsubscribe")
+	_, err = verifyUnsubscribeToken(otherKey, token, 24*time.Hour, issuedAt)
+	assert.ErrorIs(t, err, errInvalidUnsubscribeToken)
+}
+
+func TestUnsubscribeTokenRejectsTampering(t *testing.T) {
+	key := utils.DeriveKey("secret", "digest-unsubscribe")
+	issuedAt := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	parts := strings.Split(signUnsubscribeToken(key, "user-1", issuedAt), ".")
+	other := strings.Split(signUnsubscribeToken(key, "user-2", issuedAt), ".")
+
+	tampered := []string{
+		"",
+		parts[0] + "." + parts[2],
+		other[0] + "." + parts[1] + "." + parts[2],
+		parts[0] + ".9999999999." + parts[2],
+		parts[0] + "." + parts[1] + "." + other[2],
+	}
+	for _, token := range tampered {
+		_, err := verifyUnsubscribeToken(key, token, 24*time.Hour, issuedAt)
+		assert.ErrorIs(t, err, errInvalidUnsubscribeToken, token)
+	}
+}
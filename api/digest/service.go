@@ -0,0 +1,486 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	commentModels "sync-backend/api/comment/model"
+	"sync-backend/api/common/analytics"
+	"sync-backend/api/common/email"
+	communityModels "sync-backend/api/community/model"
+	moderatorModels "sync-backend/api/moderator/model"
+	postModels "sync-backend/api/post/model"
+	userModels "sync-backend/api/user/model"
+	"sync-backend/arch/config"
+	"sync-backend/arch/mongo"
+	"sync-backend/arch/network"
+	"sync-backend/arch/redis"
+	"sync-backend/arch/tracing"
+	"sync-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const excerptLength = 140
+
+type DigestService interface {
+	SendDueDigests(ctx context.Context, now time.Time) (int, error)
+	StartScheduler(ctx context.Context)
+	// CheckUnsubscribeToken validates a token without changing anything, for the confirmation page
+	CheckUnsubscribeToken(ctx context.Context, token string) network.ApiError
+	Unsubscribe(ctx context.Context, token string) network.ApiError
+}
+
+type digestService struct {
+	network.BaseService
+	logger                utils.AppLogger
+	config                config.DigestConfig
+	unsubscribeKey        []byte
+	frontendURL           string
+	unsubscribeURL        string
+	weekday               time.Weekday
+	emailService          email.EmailService
+	postAnalytics         analytics.PostAnalytics
+	cacheStore            redis.CacheStore
+	userQueryBuilder      mongo.QueryBuilder[userModels.User]
+	postQueryBuilder      mongo.QueryBuilder[postModels.Post]
+	commentQueryBuilder   mongo.QueryBuilder[commentModels.Comment]
+	communityQueryBuilder mongo.QueryBuilder[communityModels.Community]
+	reportQueryBuilder    mongo.QueryBuilder[moderatorModels.Report]
+}
+
+func NewDigestService(
+	env *config.Env,
+	config *config.Config,
+	db mongo.Database,
+	emailService email.EmailService,
+	postAnalytics analytics.PostAnalytics,
+	cacheStore redis.CacheStore,
+) DigestService {
+	digestConfig := config.Digest
+	if digestConfig.CheckInterval <= 0 {
+		digestConfig.CheckInterval = 15 * time.Minute
+	}
+	if digestConfig.BatchSize <= 0 {
+		digestConfig.BatchSize = 100
+	}
+	if digestConfig.MaxItems <= 0 {
+		digestConfig.MaxItems = 5
+	}
+	if digestConfig.MaxCommunities <= 0 {
+		digestConfig.MaxCommunities = 10
+	}
+	if digestConfig.UnsubscribeExpiry <= 0 {
+		digestConfig.UnsubscribeExpiry = 90 * 24 * time.Hour
+	}
+
+	return &digestService{
+		BaseService:           network.NewBaseService(),
+		logger:                utils.NewServiceLogger("DigestService"),
+		config:                digestConfig,
+		unsubscribeKey:        utils.DeriveKey(env.JWTSecret, "digest-unsubscribe"),
+		frontendURL:           strings.TrimSuffix(env.AppFrontendURL, "/"),
+		unsubscribeURL:        fmt.Sprintf("%s%s/v%s/digest/unsubscribe", strings.TrimSuffix(env.AppBackendURL, "/"), config.API.Prefix, config.API.Version),
+		weekday:               parseWeekday(digestConfig.WeeklyDay),
+		emailService:          emailService,
+		postAnalytics:         postAnalytics,
+		cacheStore:            cacheStore,
+		userQueryBuilder:      mongo.NewQueryBuilder[userModels.User](db, userModels.UserCollectionName),
+		postQueryBuilder:      mongo.NewQueryBuilder[postModels.Post](db, postModels.PostCollectionName),
+		commentQueryBuilder:   mongo.NewQueryBuilder[commentModels.Comment](db, commentModels.CommentCollectionName),
+		communityQueryBuilder: mongo.NewQueryBuilder[communityModels.Community](db, communityModels.CommunityCollectionName),
+		reportQueryBuilder:    mongo.NewQueryBuilder[moderatorModels.Report](db, moderatorModels.ReportCollectionName),
+	}
+}
+
+// StartScheduler sends due digests every CheckInterval until ctx is cancelled
+func (s *digestService) StartScheduler(ctx context.Context) {
+	if !s.config.Enabled {
+		return
+	}
+	ticker := time.NewTicker(s.config.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.SendDueDigests(context.WithoutCancel(ctx), time.Now()); err != nil {
+				s.logger.WithContext(ctx).Error("Failed to send digests: %v", err)
+			}
+		}
+	}
+}
+
+// SendDueDigests queues a digest for every user whose daily or weekly slot has passed in their
+// timezone since their last digest, returning how many were queued
+func (s *digestService) SendDueDigests(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := tracing.Start(ctx, "DigestService.SendDueDigests")
+	defer span.End()
+
+	// Narrow the scan to users who have not had a digest for most of their period, dueSince decides
+	notSentSince := func(d time.Duration) bson.A {
+		return bson.A{
+			bson.M{"digestSentAt": bson.M{"$exists": false}},
+			bson.M{"digestSentAt": bson.M{"$lt": primitive.NewDateTimeFromTime(now.Add(-d))}},
+		}
+	}
+	filter := bson.M{
+		"status":                          userModels.Active,
+		"verifiedEmail":                   true,
+		"preferences.notifications.email": true,
+		"$or": bson.A{
+			bson.M{"preferences.notifications.digestFrequency": userModels.DigestDaily, "$or": notSentSince(20 * time.Hour)},
+			bson.M{"preferences.notifications.digestFrequency": bson.M{"$in": bson.A{userModels.DigestWeekly, nil}}, "$or": notSentSince(6 * 24 * time.Hour)},
+		},
+	}
+	opts := options.Find().SetProjection(bson.M{
+		"userId": 1, "username": 1, "email": 1, "createdAt": 1, "digestSentAt": 1, "preferences": 1, "joinedWavelengths": 1,
+	})
+
+	sent := 0
+	cursor := ""
+	for {
+		users, page, err := s.userQueryBuilder.SingleQuery(ctx).FindCursorPaginated(filter, mongo.CursorQuery{SortField: "_id", SortOrder: 1, Limit: int64(s.config.BatchSize), After: cursor}, opts)
+		if err != nil {
+			return sent, err
+		}
+		for _, user := range users {
+			since, due := dueSince(user, now, s.config.SendHour, s.weekday)
+			if !due || !s.claim(ctx, user, now) {
+				continue
+			}
+			if err := s.sendDigest(ctx, user, since); err != nil {
+				s.logger.WithContext(ctx).Error("Failed to send digest to user %s: %v", user.UserId, err)
+				continue
+			}
+			sent++
+		}
+		if !page.HasMore {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	if sent > 0 {
+		s.logger.WithContext(ctx).Info("Queued %d notification digests", sent)
+	}
+	return sent, nil
+}
+
+// invalidateUser drops the cached views of a user the digest wrote to, the user service does not see
+// these writes
+func (s *digestService) invalidateUser(ctx context.Context, userId string) {
+	if err := s.cacheStore.InvalidateTag(context.WithoutCancel(ctx), userModels.UserCacheTag(userId)); err != nil {
+		s.logger.WithContext(ctx).Error("Error invalidating cached user %s: %v", userId, err)
+	}
+}
+
+// claim marks the digest as sent before composing it, so concurrent schedulers never send it twice
+func (s *digestService) claim(ctx context.Context, user *userModels.User, now time.Time) bool {
+	filter := bson.M{"userId": user.UserId, "digestSentAt": bson.M{"$exists": false}}
+	if user.DigestSentAt != nil {
+		filter["digestSentAt"] = *user.DigestSentAt
+	}
+	result, err := s.userQueryBuilder.SingleQuery(ctx).UpdateOne(filter, bson.M{
+		"$set": bson.M{"digestSentAt": primitive.NewDateTimeFromTime(now)},
+	}, nil)
+	s.invalidateUser(ctx, user.UserId)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to claim digest of user %s: %v", user.UserId, err)
+		return false
+	}
+	return result.ModifiedCount == 1
+}
+
+func (s *digestService) sendDigest(ctx context.Context, user *userModels.User, since time.Time) error {
+	frequency := user.Preferences.Notifications.Digest()
+	digest := &email.DigestData{
+		Username:       user.Username,
+		Frequency:      string(frequency),
+		SettingsUrl:    s.frontendURL + "/settings/notifications",
+		UnsubscribeUrl: s.unsubscribeURL + "?token=" + url.QueryEscape(signUnsubscribeToken(s.unsubscribeKey, user.UserId, time.Now())),
+	}
+
+	var err error
+	if digest.Replies, err = s.replies(ctx, user.UserId, since); err != nil {
+		return err
+	}
+	if digest.Mentions, err = s.mentions(ctx, user.UserId, since); err != nil {
+		return err
+	}
+	if digest.TopPosts, err = s.topPosts(ctx, user, since); err != nil {
+		return err
+	}
+	if digest.ModerationOutcomes, err = s.moderationOutcomes(ctx, user.UserId, since); err != nil {
+		return err
+	}
+	if digest.IsEmpty() {
+		return nil
+	}
+
+	return s.emailService.SendDigest(ctx, user.Email, user.Preferences.Language.ID(), digest)
+}
+
+// replies lists new comments on the user's posts and replies to the user's comments
+func (s *digestService) replies(ctx context.Context, userId string, since time.Time) ([]email.DigestItem, error) {
+	posts, err := s.postQueryBuilder.SingleQuery(ctx).FilterMany(
+		bson.M{"authorId": userId},
+		options.Find().SetProjection(bson.M{"postId": 1}).SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(100),
+	)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := s.commentQueryBuilder.SingleQuery(ctx).FilterMany(
+		bson.M{"authorId": userId},
+		options.Find().SetProjection(bson.M{"commentId": 1}).SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(200),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(posts) == 0 && len(comments) == 0 {
+		return nil, nil
+	}
+
+	postIds := make([]string, 0, len(posts))
+	for _, post := range posts {
+		postIds = append(postIds, post.PostId)
+	}
+	commentIds := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		commentIds = append(commentIds, comment.CommentId)
+	}
+
+	return s.commentItems(ctx, bson.M{
+		"$or": bson.A{
+			bson.M{"postId": bson.M{"$in": postIds}, "level": 0},
+			bson.M{"parentId": bson.M{"$in": commentIds}},
+		},
+	}, userId, since)
+}
+
+func (s *digestService) mentions(ctx context.Context, userId string, since time.Time) ([]email.DigestItem, error) {
+	return s.commentItems(ctx, bson.M{"mentions": userId}, userId, since)
+}
+
+// commentItems lists the newest visible comments matching filter written by someone else since
+func (s *digestService) commentItems(ctx context.Context, filter bson.M, userId string, since time.Time) ([]email.DigestItem, error) {
+	filter["authorId"] = bson.M{"$ne": userId}
+	filter["status"] = commentModels.CommentStatusActive
+	filter["createdAt"] = bson.M{"$gte": primitive.NewDateTimeFromTime(since)}
+	comments, err := s.commentQueryBuilder.SingleQuery(ctx).FilterMany(filter,
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(int64(s.config.MaxItems)),
+	)
+	if err != nil || len(comments) == 0 {
+		return nil, err
+	}
+
+	postIds := make([]string, 0, len(comments))
+	authorIds := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		postIds = append(postIds, comment.PostId)
+		authorIds = append(authorIds, comment.AuthorId)
+	}
+	titles, err := s.postTitles(ctx, postIds)
+	if err != nil {
+		return nil, err
+	}
+	usernames, err := s.usernames(ctx, authorIds)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]email.DigestItem, 0, len(comments))
+	for _, comment := range comments {
+		items = append(items, email.DigestItem{
+			Title:   titles[comment.PostId],
+			Author:  usernames[comment.AuthorId],
+			Excerpt: excerpt(comment.Content),
+			Url:     s.frontendURL + "/post/" + comment.PostId,
+		})
+	}
+	return items, nil
+}
+
+// topPosts picks the highest scoring new posts of the user's communities
+func (s *digestService) topPosts(ctx context.Context, user *userModels.User, since time.Time) ([]email.DigestItem, error) {
+	communityIds := user.JoinedWavelengths
+	if len(communityIds) > s.config.MaxCommunities {
+		communityIds = communityIds[:s.config.MaxCommunities]
+	}
+
+	var posts []*postModels.Post
+	for _, communityId := range communityIds {
+		top, err := s.postAnalytics.GetCommunityTopPosts(communityId, "hot", s.config.MaxItems)
+		if err != nil {
+			return nil, err
+		}
+		for _, post := range top {
+			if post.AuthorId != user.UserId && !post.CreatedAt.Time().Before(since) {
+				posts = append(posts, post)
+			}
+		}
+	}
+	if len(posts) == 0 {
+		return nil, nil
+	}
+	sort.SliceStable(posts, func(i, j int) bool { return posts[i].Synergy > posts[j].Synergy })
+	if len(posts) > s.config.MaxItems {
+		posts = posts[:s.config.MaxItems]
+	}
+
+	names, err := s.communityNames(ctx, communityIds)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]email.DigestItem, 0, len(posts))
+	for _, post := range posts {
+		items = append(items, email.DigestItem{
+			Title:     post.Title,
+			Community: names[post.CommunityId],
+			Score:     post.Synergy,
+			Url:       s.frontendURL + "/post/" + post.PostId,
+		})
+	}
+	return items, nil
+}
+
+// moderationOutcomes lists the user's reports moderators decided on
+func (s *digestService) moderationOutcomes(ctx context.Context, userId string, since time.Time) ([]email.DigestItem, error) {
+	reports, err := s.reportQueryBuilder.SingleQuery(ctx).FilterMany(
+		bson.M{
+			"reporterId":  userId,
+			"status":      bson.M{"$in": bson.A{moderatorModels.ReportStatusApproved, moderatorModels.ReportStatusRejected}},
+			"processedAt": bson.M{"$gte": primitive.NewDateTimeFromTime(since)},
+		},
+		options.Find().SetSort(bson.D{{Key: "processedAt", Value: -1}}).SetLimit(int64(s.config.MaxItems)),
+	)
+	if err != nil || len(reports) == 0 {
+		return nil, err
+	}
+
+	communityIds := make([]string, 0, len(reports))
+	for _, report := range reports {
+		communityIds = append(communityIds, report.CommunityId)
+	}
+	names, err := s.communityNames(ctx, communityIds)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]email.DigestItem, 0, len(reports))
+	for _, report := range reports {
+		items = append(items, email.DigestItem{
+			Kind:      string(report.TargetType),
+			Community: names[report.CommunityId],
+			Status:    string(report.Status),
+		})
+	}
+	return items, nil
+}
+
+func (s *digestService) postTitles(ctx context.Context, postIds []string) (map[string]string, error) {
+	posts, err := s.postQueryBuilder.SingleQuery(ctx).FilterMany(
+		bson.M{"postId": bson.M{"$in": postIds}},
+		options.Find().SetProjection(bson.M{"postId": 1, "title": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	titles := make(map[string]string, len(posts))
+	for _, post := range posts {
+		titles[post.PostId] = post.Title
+	}
+	return titles, nil
+}
+
+func (s *digestService) usernames(ctx context.Context, userIds []string) (map[string]string, error) {
+	users, err := s.userQueryBuilder.SingleQuery(ctx).FilterMany(
+		bson.M{"userId": bson.M{"$in": userIds}},
+		options.Find().SetProjection(bson.M{"userId": 1, "username": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	usernames := make(map[string]string, len(users))
+	for _, user := range users {
+		usernames[user.UserId] = user.Username
+	}
+	return usernames, nil
+}
+
+func (s *digestService) communityNames(ctx context.Context, communityIds []string) (map[string]string, error) {
+	communities, err := s.communityQueryBuilder.SingleQuery(ctx).FilterMany(
+		bson.M{"communityId": bson.M{"$in": communityIds}},
+		options.Find().SetProjection(bson.M{"communityId": 1, "name": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(communities))
+	for _, community := range communities {
+		names[community.CommunityId] = community.Name
+	}
+	return names, nil
+}
+
+func (s *digestService) CheckUnsubscribeToken(ctx context.Context, token string) network.ApiError {
+	_, span := tracing.Start(ctx, "DigestService.CheckUnsubscribeToken")
+	defer span.End()
+
+	_, err := s.verifyUnsubscribeToken(token)
+	return err
+}
+
+// Unsubscribe turns digests off for the user a one-click unsubscribe token was issued to
+func (s *digestService) Unsubscribe(ctx context.Context, token string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "DigestService.Unsubscribe")
+	defer span.End()
+
+	userId, apiErr := s.verifyUnsubscribeToken(token)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	defer s.invalidateUser(ctx, userId)
+	if _, err := s.userQueryBuilder.SingleQuery(ctx).UpdateOne(
+		bson.M{"userId": userId},
+		bson.M{"$set": bson.M{
+			"preferences.notifications.digestFrequency": userModels.DigestOff,
+			"updatedAt": primitive.NewDateTimeFromTime(time.Now()),
+		}},
+		nil,
+	); err != nil {
+		return NewUnsubscribeError(userId, err)
+	}
+
+	s.logger.WithContext(ctx).Info("User %s unsubscribed from digests", userId)
+	return nil
+}
+
+func (s *digestService) verifyUnsubscribeToken(token string) (string, network.ApiError) {
+	userId, err := verifyUnsubscribeToken(s.unsubscribeKey, token, s.config.UnsubscribeExpiry, time.Now())
+	if errors.Is(err, errExpiredUnsubscribeToken) {
+		return "", NewExpiredUnsubscribeTokenError(err)
+	}
+	if err != nil {
+		return "", NewInvalidUnsubscribeTokenError(err)
+	}
+	return userId, nil
+}
+
+func excerpt(content string) string {
+	content = strings.Join(strings.Fields(content), " ")
+	runes := []rune(content)
+	if len(runes) <= excerptLength {
+		return content
+	}
+	return strings.TrimSpace(string(runes[:excerptLength])) + "…"
+}
@@ -0,0 +1,52 @@
+package digest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+	errExpiredUnsubscribeToken = errors.New("expired unsubscribe token")
+)
+
+// signUnsubscribeToken creates the token of one-click unsubscribe links. It carries the time it was
+// issued, so links in old digests stop working after the configured expiry.
+func signUnsubscribeToken(key []byte, userId string, issuedAt time.Time) string {
+	issued := strconv.FormatInt(issuedAt.Unix(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(userId)) + "." + issued + "." + unsubscribeSignature(key, userId, issued)
+}
+
+// verifyUnsubscribeToken returns the user the token was issued to, tokens older than expiry are rejected
+func verifyUnsubscribeToken(key []byte, token string, expiry time.Duration, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errInvalidUnsubscribeToken
+	}
+	userId, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(userId) == 0 {
+		return "", errInvalidUnsubscribeToken
+	}
+	issuedAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", errInvalidUnsubscribeToken
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(unsubscribeSignature(key, string(userId), parts[1]))) {
+		return "", errInvalidUnsubscribeToken
+	}
+	if now.Sub(time.Unix(issuedAt, 0)) > expiry {
+		return "", errExpiredUnsubscribeToken
+	}
+	return string(userId), nil
+}
+
+func unsubscribeSignature(key []byte, userId string, issuedAt string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(userId + "\n" + issuedAt))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
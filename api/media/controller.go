@@ -0,0 +1,140 @@
+package media
+
+import (
+	"fmt"
+	"os"
+
+	storage "sync-backend/api/common/media"
+	"sync-backend/api/media/dto"
+	"sync-backend/api/media/model"
+	"sync-backend/arch/common"
+	coreMW "sync-backend/arch/middleware"
+	"sync-backend/arch/network"
+	"sync-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type mediaController struct {
+	logger utils.AppLogger
+	network.BaseController
+	common.ContextPayload
+	authProvider        network.AuthenticationProvider
+	uploadProvider      coreMW.UploadProvider
+	mediaLibraryService MediaLibraryService
+	localFiles          storage.LocalFiles
+	maxFilesPerUpload   int
+}
+
+func NewMediaController(
+	authProvider network.AuthenticationProvider,
+	uploadProvider coreMW.UploadProvider,
+	mediaLibraryService MediaLibraryService,
+	storageService storage.MediaService,
+	maxFilesPerUpload int,
+) network.Controller {
+	if maxFilesPerUpload <= 0 {
+		maxFilesPerUpload = 10
+	}
+	// Only drivers keeping files on this server need the API to serve them
+	localFiles, _ := storage.AsLocalFiles(storageService)
+	return &mediaController{
+		logger:              utils.NewServiceLogger("MediaController"),
+		BaseController:      network.NewBaseController("/media", authProvider),
+		ContextPayload:      common.NewContextPayload(),
+		authProvider:        authProvider,
+		uploadProvider:      uploadProvider,
+		mediaLibraryService: mediaLibraryService,
+		localFiles:          localFiles,
+		maxFilesPerUpload:   maxFilesPerUpload,
+	}
+}
+
+func (c *mediaController) MountRoutes(group *gin.RouterGroup) {
+	c.logger.Info("Mounting media routes")
+	if c.localFiles != nil {
+		// Public, access is granted by the signature in the URL
+		group.GET("/file/*key", c.ServeFile)
+	}
+	group.Use(c.authProvider.Middleware())
+	group.POST("/upload", c.uploadProvider.Middleware("files"), c.UploadMedia)
+	group.GET("/:mediaId", c.GetMedia)
+	group.DELETE("/:mediaId", c.DeleteMedia)
+}
+
+func (c *mediaController) UploadMedia(ctx *gin.Context) {
+	defer c.uploadProvider.DeleteUploadedFiles(ctx, "files")
+	userId := c.MustGetUserId(ctx)
+
+	files := c.uploadProvider.GetUploadedFiles(ctx, "files")
+	if files.IsEmpty() {
+		c.Send(ctx).BadRequestError(
+			"No files uploaded",
+			"Please upload at least one image or video in the 'files' form field",
+			nil,
+		)
+		return
+	}
+	if len(files.Files) > c.maxFilesPerUpload {
+		c.Send(ctx).BadRequestError(
+			"Too many files uploaded",
+			fmt.Sprintf("The maximum number of files allowed is %d", c.maxFilesPerUpload),
+			nil,
+		)
+		return
+	}
+
+	uploaded := make([]*model.Media, 0, len(files.Files))
+	for _, file := range files.Files {
+		media, err := c.mediaLibraryService.UploadMedia(ctx.Request.Context(), *userId, file)
+		if err != nil {
+			c.Send(ctx).MixedError(err)
+			return
+		}
+		uploaded = append(uploaded, media)
+	}
+
+	c.Send(ctx).SuccessDataResponse("Media uploaded successfully", dto.NewUploadMediaResponse(uploaded))
+}
+
+func (c *mediaController) GetMedia(ctx *gin.Context) {
+	mediaId := ctx.Param("mediaId")
+	userId := c.MustGetUserId(ctx)
+
+	media, err := c.mediaLibraryService.GetMedia(ctx.Request.Context(), *userId, mediaId)
+	if err != nil {
+		c.Send(ctx).MixedError(err)
+		return
+	}
+
+	c.Send(ctx).SuccessDataResponse("Media retrieved successfully", media)
+}
+
+func (c *mediaController) DeleteMedia(ctx *gin.Context) {
+	mediaId := ctx.Param("mediaId")
+	userId := c.MustGetUserId(ctx)
+
+	if err := c.mediaLibraryService.DeleteMedia(ctx.Request.Context(), *userId, mediaId); err != nil {
+		c.Send(ctx).MixedError(err)
+		return
+	}
+
+	c.Send(ctx).SuccessMsgResponse("Media deleted successfully")
+}
+
+func (c *mediaController) ServeFile(ctx *gin.Context) {
+	path, err := c.localFiles.ResolveSignedPath(ctx.Param("key"), ctx.Query("signature"))
+	if err != nil {
+		c.Send(ctx).ForbiddenError("Invalid media URL", "The media URL signature is invalid", err)
+		return
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		c.Send(ctx).NotFoundError("Media Not Found", "The media file no longer exists", err)
+		return
+	}
+
+	ctx.Header("Cache-Control", "private, max-age=3600")
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.File(path)
+}
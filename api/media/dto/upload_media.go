@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"sync-backend/api/media/model"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// =======================================
+// ||       Upload Media Response        ||
+// =======================================
+
+type UploadMediaResponse struct {
+	Media []*model.Media `json:"media"`
+}
+
+func NewUploadMediaResponse(media []*model.Media) *UploadMediaResponse {
+	if media == nil {
+		media = []*model.Media{}
+	}
+	return &UploadMediaResponse{
+		Media: media,
+	}
+}
+
+func (r *UploadMediaResponse) GetValue() *UploadMediaResponse {
+	return r
+}
+
+func (r *UploadMediaResponse) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		switch err.Tag() {
+		default:
+			msgs = append(msgs, err.Field()+" is invalid")
+		}
+	}
+	return msgs, nil
+}
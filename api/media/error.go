@@ -0,0 +1,61 @@
+package media
+
+import (
+	"fmt"
+	"sync-backend/arch/network"
+)
+
+const (
+	ERR_MEDIA_STORAGE = "ERR_MEDIA_STORAGE"
+	ERR_DB            = "ERR_DB"
+)
+
+func NewMediaNotFoundError(mediaId string) network.ApiError {
+	return network.NewNotFoundError(
+		"Media Not Found",
+		fmt.Sprintf("Media with ID '%s' not found. It may have been deleted or never existed. [Context: mediaId=%s]", mediaId, mediaId),
+		nil,
+	)
+}
+
+func NewMediaForbiddenError(action, userId, mediaId string) network.ApiError {
+	return network.NewForbiddenError(
+		"Forbidden",
+		fmt.Sprintf("User '%s' is not authorized to %s media '%s'. Only the uploader can. [Context: userId=%s, mediaId=%s]", userId, action, mediaId, userId, mediaId),
+		nil,
+	)
+}
+
+func NewMediaInUseError(mediaId string) network.ApiError {
+	return network.NewConflictError(
+		"Media In Use",
+		fmt.Sprintf("Media '%s' is attached to a post or comment. Delete the content first. [Context: mediaId=%s]", mediaId, mediaId),
+		nil,
+	)
+}
+
+func NewUnsupportedMediaError(filename, mimeType string) network.ApiError {
+	return network.NewBadRequestError(
+		"Unsupported Media",
+		fmt.Sprintf("File '%s' of type '%s' is not supported. Upload an image or a video. [Context: mimeType=%s]", filename, mimeType, mimeType),
+		nil,
+	)
+}
+
+func NewStorageError(action, extra string) network.ApiError {
+	return network.NewInternalServerError(
+		"Media Storage Error",
+		fmt.Sprintf("Media storage error occurred during %s. Details: %s", action, extra),
+		ERR_MEDIA_STORAGE,
+		nil,
+	)
+}
+
+func NewDBError(action, extra string) network.ApiError {
+	return network.NewInternalServerError(
+		"Database Error",
+		fmt.Sprintf("Database error occurred during %s. Details: %s", action, extra),
+		ERR_DB,
+		nil,
+	)
+}
@@ -0,0 +1,167 @@
+package model
+
+import (
+	"context"
+	mediaModels "sync-backend/api/common/media/model"
+	"sync-backend/arch/mongo"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongod "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Represents a unique collection name for uploaded media
+const MediaCollectionName = "media"
+
+// MediaStatus is the lifecycle state of an uploaded media file
+type MediaStatus string
+
+const (
+	// Uploaded and not referenced by any content, swept once it stays unreferenced past the grace period
+	MediaStatusPending MediaStatus = "pending"
+	// Referenced by at least one post or comment
+	MediaStatusAttached MediaStatus = "attached"
+	// Claimed for deletion, the document goes once the files are removed from storage
+	MediaStatusDeleting MediaStatus = "deleting"
+)
+
+// MediaType is the broad kind of a media file
+type MediaType string
+
+const (
+	MediaTypeImage MediaType = "image"
+	MediaTypeVideo MediaType = "video"
+)
+
+// MediaReferenceKind is the kind of content a media file is attached to
+type MediaReferenceKind string
+
+const (
+	MediaReferencePost    MediaReferenceKind = "post"
+	MediaReferenceComment MediaReferenceKind = "comment"
+)
+
+// MediaReference points at a piece of content using a media file
+type MediaReference struct {
+	Kind    MediaReferenceKind `bson:"kind" json:"kind" validate:"required,oneof=post comment"`
+	Id      string             `bson:"id" json:"id" validate:"required"`
+	AddedAt primitive.DateTime `bson:"addedAt" json:"addedAt"`
+}
+
+// NewMediaReference creates a reference from the content kind and id
+func NewMediaReference(kind MediaReferenceKind, id string) MediaReference {
+	return MediaReference{
+		Kind:    kind,
+		Id:      id,
+		AddedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+}
+
+// Media is an uploaded file owned by a user. Files are uploaded first and attached to posts or
+// comments by ID, the references keep track of the content using them.
+type Media struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	MediaId      string             `bson:"mediaId" json:"id"`
+	OwnerId      string             `bson:"ownerId" json:"ownerId" validate:"required"`
+	StorageId    string             `bson:"storageId" json:"-" validate:"required"` // ID of the file at the storage provider
+	Url          string             `bson:"url" json:"url" validate:"required"`
+	Type         MediaType          `bson:"type" json:"type" validate:"required,oneof=image video"`
+	MimeType     string             `bson:"mimeType" json:"mimeType" validate:"required"`
+	OriginalName string             `bson:"originalName,omitempty" json:"originalName,omitempty"`
+	Width        int                `bson:"width,omitempty" json:"width,omitempty"`
+	Height       int                `bson:"height,omitempty" json:"height,omitempty"`
+	Size         int64              `bson:"size" json:"size"`
+	// Image placeholders and downscaled copies produced by the image pipeline
+	Renditions    []mediaModels.ImageRendition `bson:"renditions,omitempty" json:"renditions,omitempty"`
+	BlurHash      string                       `bson:"blurHash,omitempty" json:"blurHash,omitempty"`
+	DominantColor string                       `bson:"dominantColor,omitempty" json:"dominantColor,omitempty"`
+	Status        MediaStatus                  `bson:"status" json:"status" validate:"required,oneof=pending attached deleting"`
+	References    []MediaReference             `bson:"references" json:"references" validate:"dive"`
+	CreatedAt     primitive.DateTime           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     primitive.DateTime           `bson:"updatedAt" json:"updatedAt"`
+}
+
+// NewMedia creates a pending media document for a file stored at the storage provider
+func NewMedia(ownerId string, storageId string, url string, mediaType MediaType, mimeType string, originalName string, width int, height int, size int64) *Media {
+	now := primitive.NewDateTimeFromTime(time.Now())
+	return &Media{
+		ID:           primitive.NewObjectID(),
+		MediaId:      uuid.New().String(),
+		OwnerId:      ownerId,
+		StorageId:    storageId,
+		Url:          url,
+		Type:         mediaType,
+		MimeType:     mimeType,
+		OriginalName: originalName,
+		Width:        width,
+		Height:       height,
+		Size:         size,
+		Status:       MediaStatusPending,
+		References:   []MediaReference{},
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+// StorageIds returns the storage IDs of the file and its renditions
+func (m *Media) StorageIds() []string {
+	ids := []string{m.StorageId}
+	for _, rendition := range m.Renditions {
+		ids = append(ids, rendition.Id)
+	}
+	return ids
+}
+
+func (m *Media) IsReferenced() bool {
+	return len(m.References) > 0
+}
+
+func (m *Media) GetValue() *Media {
+	return m
+}
+
+func (m *Media) Validate() error {
+	validate := validator.New()
+	return validate.Struct(m)
+}
+
+func (m *Media) GetCollectionName() string {
+	return MediaCollectionName
+}
+
+func (m *Media) EnsureIndexes(db mongo.Database) {
+	indexes := []mongod.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "mediaId", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("idx_media_id_unique"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "ownerId", Value: 1},
+				{Key: "createdAt", Value: -1},
+			},
+			Options: options.Index().SetName("idx_media_owner"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "references.kind", Value: 1},
+				{Key: "references.id", Value: 1},
+			},
+			Options: options.Index().SetName("idx_media_references"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "updatedAt", Value: 1},
+			},
+			Options: options.Index().SetName("idx_media_status_updated"),
+		},
+	}
+	mongo.NewQueryBuilder[Media](db, MediaCollectionName).Query(context.Background()).CheckIndexes(indexes)
+}
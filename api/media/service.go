@@ -0,0 +1,374 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	storage "sync-backend/api/common/media"
+	"sync-backend/api/media/model"
+	"sync-backend/arch/config"
+	coreMW "sync-backend/arch/middleware"
+	"sync-backend/arch/mongo"
+	"sync-backend/arch/network"
+	"sync-backend/arch/tracing"
+	"sync-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MediaLibraryService interface {
+	UploadMedia(ctx context.Context, ownerId string, file coreMW.UploadedFile) (*model.Media, network.ApiError)
+	GetMedia(ctx context.Context, userId string, mediaId string) (*model.Media, network.ApiError)
+	DeleteMedia(ctx context.Context, userId string, mediaId string) network.ApiError
+
+	// Content references
+	AttachMedia(ctx context.Context, ownerId string, mediaIds []string, reference model.MediaReference) ([]*model.Media, network.ApiError)
+	ReleaseMedia(ctx context.Context, kind model.MediaReferenceKind, id string) network.ApiError
+
+	// Orphan garbage collection
+	SweepOrphanedMedia(ctx context.Context) (int, error)
+	StartOrphanSweeper(ctx context.Context)
+}
+
+type mediaLibraryService struct {
+	network.BaseService
+	logger            utils.AppLogger
+	config            config.MediaConfig
+	storage           storage.MediaService
+	mediaQueryBuilder mongo.QueryBuilder[model.Media]
+}
+
+func NewMediaLibraryService(db mongo.Database, mediaConfig config.MediaConfig, storage storage.MediaService) MediaLibraryService {
+	if mediaConfig.OrphanGracePeriod <= 0 {
+		mediaConfig.OrphanGracePeriod = 24 * time.Hour
+	}
+	if mediaConfig.SweepInterval <= 0 {
+		mediaConfig.SweepInterval = time.Hour
+	}
+	if mediaConfig.SweepBatchSize <= 0 {
+		mediaConfig.SweepBatchSize = 100
+	}
+	return &mediaLibraryService{
+		BaseService:       network.NewBaseService(),
+		logger:            utils.NewServiceLogger("MediaLibraryService"),
+		config:            mediaConfig,
+		storage:           storage,
+		mediaQueryBuilder: mongo.NewQueryBuilder[model.Media](db, model.MediaCollectionName),
+	}
+}
+
+// UploadMedia pushes a file saved by the upload middleware to the storage provider and records it
+// as pending until it is attached to content
+func (s *mediaLibraryService) UploadMedia(ctx context.Context, ownerId string, file coreMW.UploadedFile) (*model.Media, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "MediaLibraryService.UploadMedia")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Uploading media %s for user: %s", file.Filename, ownerId)
+
+	mimeType, err := utils.DetectContentType(file.Path)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to read uploaded file %s: %v", file.Path, err)
+		return nil, NewStorageError("reading uploaded file", err.Error())
+	}
+	var mediaType model.MediaType
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		mediaType = model.MediaTypeImage
+	case strings.HasPrefix(mimeType, "video/"):
+		mediaType = model.MediaTypeVideo
+	default:
+		return nil, NewUnsupportedMediaError(file.Filename, mimeType)
+	}
+
+	info, err := s.storage.UploadMedia(file.Path, ownerId+"_media", "media")
+	if err != nil {
+		if errors.Is(err, storage.ErrUnsupportedImage) {
+			return nil, NewUnsupportedMediaError(file.Filename, mimeType)
+		}
+		s.logger.WithContext(ctx).Error("Failed to upload media to storage: %v", err)
+		return nil, NewStorageError("uploading media", err.Error())
+	}
+	// Images may be re-encoded by the pipeline, e.g. WebP is stored as JPEG
+	if info.MimeType != "" {
+		mimeType = info.MimeType
+	}
+
+	media := model.NewMedia(ownerId, info.Id, info.Url, mediaType, mimeType, file.Filename, info.Width, info.Height, file.Size)
+	if info.Size > 0 {
+		media.Size = info.Size
+	}
+	media.Renditions = info.Renditions
+	media.BlurHash = info.BlurHash
+	media.DominantColor = info.DominantColor
+	if _, err := s.mediaQueryBuilder.SingleQuery(ctx).InsertOne(media); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to save media %s: %v", media.MediaId, err)
+		if deleteErr := s.deleteStoredFiles(media); deleteErr != nil {
+			s.logger.WithContext(ctx).Error("Failed to delete unsaved media %s from storage: %v", info.Id, deleteErr)
+		}
+		return nil, NewDBError("saving media", err.Error())
+	}
+
+	s.logger.WithContext(ctx).Info("Media uploaded successfully with ID: %s", media.MediaId)
+	return media, nil
+}
+
+// GetMedia returns the metadata of a media file, pending files are only visible to their owner
+func (s *mediaLibraryService) GetMedia(ctx context.Context, userId string, mediaId string) (*model.Media, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "MediaLibraryService.GetMedia")
+	defer span.End()
+
+	media, err := s.mediaQueryBuilder.SingleQuery(ctx).FindOne(bson.M{"mediaId": mediaId}, nil)
+	if err != nil {
+		if mongo.IsNoDocumentFoundError(err) {
+			return nil, NewMediaNotFoundError(mediaId)
+		}
+		s.logger.WithContext(ctx).Error("Failed to get media %s: %v", mediaId, err)
+		return nil, NewDBError("getting media", err.Error())
+	}
+	if media.Status == model.MediaStatusDeleting || (media.Status == model.MediaStatusPending && media.OwnerId != userId) {
+		return nil, NewMediaNotFoundError(mediaId)
+	}
+	return media, nil
+}
+
+// DeleteMedia removes an unattached media file of the user from storage and the database
+func (s *mediaLibraryService) DeleteMedia(ctx context.Context, userId string, mediaId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "MediaLibraryService.DeleteMedia")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Deleting media %s for user: %s", mediaId, userId)
+	media, apiErr := s.GetMedia(ctx, userId, mediaId)
+	if apiErr != nil {
+		return apiErr
+	}
+	if media.OwnerId != userId {
+		return NewMediaForbiddenError("delete", userId, mediaId)
+	}
+	if media.IsReferenced() {
+		return NewMediaInUseError(mediaId)
+	}
+
+	// Only claim while still unreferenced, the media may have been attached since it was read
+	claimed, err := s.mediaQueryBuilder.SingleQuery(ctx).FindOneAndUpdate(
+		bson.M{"mediaId": mediaId, "status": model.MediaStatusPending, "references": bson.M{"$size": 0}},
+		bson.M{"$set": bson.M{"status": model.MediaStatusDeleting, "updatedAt": primitive.NewDateTimeFromTime(time.Now())}},
+	)
+	if err != nil {
+		if mongo.IsNoDocumentFoundError(err) {
+			return NewMediaInUseError(mediaId)
+		}
+		s.logger.WithContext(ctx).Error("Failed to delete media %s: %v", mediaId, err)
+		return NewDBError("deleting media", err.Error())
+	}
+
+	if err := s.purgeMedia(ctx, claimed); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to delete media %s: %v", claimed.StorageId, err)
+		return NewStorageError("deleting media", err.Error())
+	}
+	return nil
+}
+
+// AttachMedia references media files of the owner from a piece of content. The media is returned
+// in the order of mediaIds.
+func (s *mediaLibraryService) AttachMedia(ctx context.Context, ownerId string, mediaIds []string, reference model.MediaReference) ([]*model.Media, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "MediaLibraryService.AttachMedia")
+	defer span.End()
+
+	mediaIds = uniqueIds(mediaIds)
+	if len(mediaIds) == 0 {
+		return []*model.Media{}, nil
+	}
+
+	found, err := s.mediaQueryBuilder.SingleQuery(ctx).FindAll(bson.M{"mediaId": bson.M{"$in": mediaIds}}, nil)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to find media to attach: %v", err)
+		return nil, NewDBError("finding media", err.Error())
+	}
+	byId := make(map[string]*model.Media, len(found))
+	for _, media := range found {
+		byId[media.MediaId] = media
+	}
+
+	ordered := make([]*model.Media, 0, len(mediaIds))
+	for _, mediaId := range mediaIds {
+		media, ok := byId[mediaId]
+		if !ok {
+			return nil, NewMediaNotFoundError(mediaId)
+		}
+		if media.Status == model.MediaStatusDeleting {
+			return nil, NewMediaNotFoundError(mediaId)
+		}
+		if media.OwnerId != ownerId {
+			return nil, NewMediaForbiddenError("attach", ownerId, mediaId)
+		}
+		ordered = append(ordered, media)
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	result, err := s.mediaQueryBuilder.SingleQuery(ctx).UpdateMany(
+		bson.M{"mediaId": bson.M{"$in": mediaIds}, "ownerId": ownerId, "status": bson.M{"$ne": model.MediaStatusDeleting}},
+		bson.M{
+			"$push": bson.M{"references": reference},
+			"$set":  bson.M{"status": model.MediaStatusAttached, "updatedAt": now},
+		},
+		nil,
+	)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to attach media: %v", err)
+		return nil, NewDBError("attaching media", err.Error())
+	}
+	if result.MatchedCount != int64(len(mediaIds)) {
+		// Some media was swept or deleted in between, undo the references that were added
+		if releaseErr := s.ReleaseMedia(ctx, reference.Kind, reference.Id); releaseErr != nil {
+			s.logger.WithContext(ctx).Error("Failed to release partially attached media: %v", releaseErr)
+		}
+		return nil, NewMediaNotFoundError(strings.Join(mediaIds, ","))
+	}
+
+	for _, media := range ordered {
+		media.Status = model.MediaStatusAttached
+		media.References = append(media.References, reference)
+		media.UpdatedAt = now
+	}
+	return ordered, nil
+}
+
+// ReleaseMedia drops the references of a piece of content, media left without references goes back
+// to pending and is swept after the grace period
+func (s *mediaLibraryService) ReleaseMedia(ctx context.Context, kind model.MediaReferenceKind, id string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "MediaLibraryService.ReleaseMedia")
+	defer span.End()
+
+	referenced := bson.M{"references": bson.M{"$elemMatch": bson.M{"kind": kind, "id": id}}}
+	media, err := s.mediaQueryBuilder.SingleQuery(ctx).FindAll(referenced, options.Find().SetProjection(bson.M{"mediaId": 1}))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to find media referenced by %s %s: %v", kind, id, err)
+		return NewDBError("finding referenced media", err.Error())
+	}
+	if len(media) == 0 {
+		return nil
+	}
+
+	mediaIds := make([]string, len(media))
+	for i, m := range media {
+		mediaIds[i] = m.MediaId
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	_, err = s.mediaQueryBuilder.SingleQuery(ctx).UpdateMany(
+		bson.M{"mediaId": bson.M{"$in": mediaIds}},
+		bson.M{
+			"$pull": bson.M{"references": bson.M{"kind": kind, "id": id}},
+			"$set":  bson.M{"updatedAt": now},
+		},
+		nil,
+	)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to release media referenced by %s %s: %v", kind, id, err)
+		return NewDBError("releasing media", err.Error())
+	}
+
+	_, err = s.mediaQueryBuilder.SingleQuery(ctx).UpdateMany(
+		bson.M{"mediaId": bson.M{"$in": mediaIds}, "references": bson.M{"$size": 0}},
+		bson.M{"$set": bson.M{"status": model.MediaStatusPending, "updatedAt": now}},
+		nil,
+	)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to mark released media as pending: %v", err)
+		return NewDBError("releasing media", err.Error())
+	}
+	return nil
+}
+
+// SweepOrphanedMedia deletes up to a batch of media that stayed unreferenced past the grace period.
+// Each document is claimed before the file is removed from storage, so media attached concurrently is
+// never swept, and only dropped once the file is gone. Claims whose removal failed are retried by a
+// later sweep once they are older than the grace period.
+func (s *mediaLibraryService) SweepOrphanedMedia(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "MediaLibraryService.SweepOrphanedMedia")
+	defer span.End()
+
+	cutoff := primitive.NewDateTimeFromTime(time.Now().Add(-s.config.OrphanGracePeriod))
+	filter := bson.M{
+		"status":     bson.M{"$in": bson.A{model.MediaStatusPending, model.MediaStatusDeleting}},
+		"references": bson.M{"$size": 0},
+		"updatedAt":  bson.M{"$lt": cutoff},
+	}
+
+	swept := 0
+	for attempt := 0; attempt < s.config.SweepBatchSize; attempt++ {
+		media, err := s.mediaQueryBuilder.SingleQuery(ctx).FindOneAndUpdate(filter, bson.M{
+			"$set": bson.M{"status": model.MediaStatusDeleting, "updatedAt": primitive.NewDateTimeFromTime(time.Now())},
+		})
+		if err != nil {
+			if mongo.IsNoDocumentFoundError(err) {
+				break
+			}
+			return swept, err
+		}
+		if err := s.purgeMedia(ctx, media); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to delete orphaned media %s: %v", media.StorageId, err)
+			continue
+		}
+		swept++
+	}
+
+	if swept > 0 {
+		s.logger.WithContext(ctx).Info("Swept %d orphaned media", swept)
+	}
+	return swept, nil
+}
+
+// StartOrphanSweeper runs SweepOrphanedMedia every sweep interval until ctx is done
+func (s *mediaLibraryService) StartOrphanSweeper(ctx context.Context) {
+	ticker := time.NewTicker(s.config.SweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.SweepOrphanedMedia(context.WithoutCancel(ctx)); err != nil {
+				s.logger.WithContext(ctx).Error("Failed to sweep orphaned media: %v", err)
+			}
+		}
+	}
+}
+
+// uniqueIds drops repeated ids keeping the first occurrence
+func uniqueIds(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// purgeMedia removes the files of claimed media from storage and then drops its document. A failure
+// leaves the claim in place for the sweeper to retry.
+func (s *mediaLibraryService) purgeMedia(ctx context.Context, media *model.Media) error {
+	if err := s.deleteStoredFiles(media); err != nil {
+		return err
+	}
+	_, err := s.mediaQueryBuilder.SingleQuery(ctx).DeleteOne(bson.M{"mediaId": media.MediaId, "status": model.MediaStatusDeleting}, nil)
+	return err
+}
+
+// deleteStoredFiles removes a media file and its renditions from storage, returning the first error
+func (s *mediaLibraryService) deleteStoredFiles(media *model.Media) error {
+	var firstErr error
+	for _, id := range media.StorageIds() {
+		if err := s.storage.DeleteMedia(id); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,11 @@
+package media
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUniqueIds(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, uniqueIds([]string{"a", "", "b", "a"}))
+}
@@ -1,7 +1,6 @@
 package middleware
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -70,7 +69,7 @@ func (m *moderatorMiddleware) RequiresModerator(communityIdParam string) gin.Han
 		// Check cache first
 		cacheKey := fmt.Sprintf("moderator:%s:%s", *userId, communityId)
 		var isModerator bool
-		result, err := m.store.GetInstance().Get(context.Background(), cacheKey).Result()
+		result, err := m.store.GetInstance().Get(ctx.Request.Context(), cacheKey).Result()
 		if err == nil {
 			// Successfully got from cache
 			if err := json.Unmarshal([]byte(result), &isModerator); err == nil {
@@ -88,7 +87,7 @@ func (m *moderatorMiddleware) RequiresModerator(communityIdParam string) gin.Han
 		}
 
 		// Cache miss, query the database
-		isModerator, apiErr := m.moderatorService.IsModeratorOrHigher(*userId, communityId)
+		isModerator, apiErr := m.moderatorService.IsModeratorOrHigher(ctx.Request.Context(), *userId, communityId)
 		if apiErr != nil {
 			m.Send(ctx).MixedError(apiErr)
 			return
@@ -96,7 +95,7 @@ func (m *moderatorMiddleware) RequiresModerator(communityIdParam string) gin.Han
 
 		// Cache result (5 minutes TTL)
 		moderatorJSON, _ := json.Marshal(isModerator)
-		m.store.GetInstance().Set(context.Background(), cacheKey, moderatorJSON, time.Second*300)
+		m.store.GetInstance().Set(ctx.Request.Context(), cacheKey, moderatorJSON, time.Second*300)
 
 		if !isModerator {
 			m.Send(ctx).ForbiddenError(
@@ -137,7 +136,7 @@ func (m *moderatorMiddleware) RequiresPermission(communityIdParam string, permis
 		// Check cache first
 		cacheKey := fmt.Sprintf("permission:%s:%s:%s", *userId, communityId, permission)
 		var hasPermission bool
-		result, err := m.store.GetInstance().Get(context.Background(), cacheKey).Result()
+		result, err := m.store.GetInstance().Get(ctx.Request.Context(), cacheKey).Result()
 		if err == nil {
 			// Successfully got from cache
 			if err := json.Unmarshal([]byte(result), &hasPermission); err == nil {
@@ -155,7 +154,7 @@ func (m *moderatorMiddleware) RequiresPermission(communityIdParam string, permis
 		}
 
 		// Cache miss, query the database
-		hasPermission, apiErr := m.moderatorService.HasModeratorPermission(*userId, communityId, permission)
+		hasPermission, apiErr := m.moderatorService.HasModeratorPermission(ctx.Request.Context(), *userId, communityId, permission)
 		if apiErr != nil {
 			m.Send(ctx).MixedError(apiErr)
 			return
@@ -163,7 +162,7 @@ func (m *moderatorMiddleware) RequiresPermission(communityIdParam string, permis
 
 		// Cache result (5 minutes TTL)
 		permissionJSON, _ := json.Marshal(hasPermission)
-		m.store.GetInstance().Set(context.Background(), cacheKey, permissionJSON, time.Second*300)
+		m.store.GetInstance().Set(ctx.Request.Context(), cacheKey, permissionJSON, time.Second*300)
 
 		if !hasPermission {
 			m.Send(ctx).ForbiddenError(
@@ -204,7 +203,7 @@ func (m *moderatorMiddleware) RequiresAdmin(communityIdParam string) gin.Handler
 		// Check cache first
 		cacheKey := fmt.Sprintf("admin:%s:%s", *userId, communityId)
 		var isAdmin bool
-		result, err := m.store.GetInstance().Get(context.Background(), cacheKey).Result()
+		result, err := m.store.GetInstance().Get(ctx.Request.Context(), cacheKey).Result()
 		if err == nil {
 			// Successfully got from cache
 			if err := json.Unmarshal([]byte(result), &isAdmin); err == nil {
@@ -222,7 +221,7 @@ func (m *moderatorMiddleware) RequiresAdmin(communityIdParam string) gin.Handler
 		}
 
 		// Cache miss, query the database
-		isAdmin, apiErr := m.moderatorService.IsAdmin(*userId, communityId)
+		isAdmin, apiErr := m.moderatorService.IsAdmin(ctx.Request.Context(), *userId, communityId)
 		if apiErr != nil {
 			m.Send(ctx).MixedError(apiErr)
 			return
@@ -230,7 +229,7 @@ func (m *moderatorMiddleware) RequiresAdmin(communityIdParam string) gin.Handler
 
 		// Cache result (5 minutes TTL)
 		adminJSON, _ := json.Marshal(isAdmin)
-		m.store.GetInstance().Set(context.Background(), cacheKey, adminJSON, time.Second*300)
+		m.store.GetInstance().Set(ctx.Request.Context(), cacheKey, adminJSON, time.Second*300)
 
 		if !isAdmin {
 			m.Send(ctx).ForbiddenError(
@@ -275,7 +274,7 @@ func (m *moderatorMiddleware) CheckUserNotBanned(communityIdParam string) gin.Ha
 			BanInfo  *model.BanInfo
 		}
 
-		result, err := m.store.GetInstance().Get(context.Background(), cacheKey).Result()
+		result, err := m.store.GetInstance().Get(ctx.Request.Context(), cacheKey).Result()
 		if err == nil {
 			// Successfully got from cache
 			if err := json.Unmarshal([]byte(result), &banData); err == nil {
@@ -301,7 +300,7 @@ func (m *moderatorMiddleware) CheckUserNotBanned(communityIdParam string) gin.Ha
 		}
 
 		// Cache miss, query the database
-		isBanned, banInfo, apiErr := m.moderatorService.IsUserBanned(*userId, communityId)
+		isBanned, banInfo, apiErr := m.moderatorService.IsUserBanned(ctx.Request.Context(), *userId, communityId)
 		if apiErr != nil {
 			m.Send(ctx).MixedError(apiErr)
 			return
@@ -316,7 +315,7 @@ func (m *moderatorMiddleware) CheckUserNotBanned(communityIdParam string) gin.Ha
 			BanInfo:  banInfo,
 		}
 		banDataJSON, _ := json.Marshal(banData)
-		m.store.GetInstance().Set(context.Background(), cacheKey, banDataJSON, time.Second*60)
+		m.store.GetInstance().Set(ctx.Request.Context(), cacheKey, banDataJSON, time.Second*60)
 
 		if isBanned {
 			var banMessage string
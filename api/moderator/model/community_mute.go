@@ -0,0 +1,96 @@
+package model
+
+import (
+	"context"
+	"sync-backend/arch/mongo"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongod "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Represents a unique collection name for community mutes
+const CommunityMutesCollectionName = "community_mutes"
+
+// CommunityMute represents a user mute record in a community. A muted user
+// can still read and join the community but cannot write content in it.
+type CommunityMute struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"-"`
+	MuteId      string              `bson:"muteId" json:"id"`
+	CommunityId string              `bson:"communityId" json:"communityId" validate:"required"`
+	UserId      string              `bson:"userId" json:"userId" validate:"required"`
+	ModeratorId string              `bson:"moderatorId" json:"moderatorId" validate:"required"`
+	Reason      string              `bson:"reason" json:"reason"`
+	Duration    *int                `bson:"duration,omitempty" json:"duration,omitempty"`
+	ExpiresAt   *primitive.DateTime `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+	IsActive    bool                `bson:"isActive" json:"isActive"`
+	CreatedAt   primitive.DateTime  `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   primitive.DateTime  `bson:"updatedAt" json:"updatedAt"`
+}
+
+// NewCommunityMute creates a new community mute, duration is in hours
+func NewCommunityMute(communityId, userId, moderatorId, reason string, duration *int) *CommunityMute {
+	now := primitive.NewDateTimeFromTime(time.Now())
+
+	var expiresAt *primitive.DateTime
+	if duration != nil && *duration > 0 {
+		expTime := primitive.NewDateTimeFromTime(now.Time().Add(time.Duration(*duration) * time.Hour))
+		expiresAt = &expTime
+	}
+
+	return &CommunityMute{
+		ID:          primitive.NewObjectID(),
+		MuteId:      uuid.New().String(),
+		CommunityId: communityId,
+		UserId:      userId,
+		ModeratorId: moderatorId,
+		Reason:      reason,
+		Duration:    duration,
+		ExpiresAt:   expiresAt,
+		IsActive:    true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+type MuteInfo struct {
+	Reason      string     `json:"reason"`
+	IsPermanent bool       `json:"isPermanent"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+}
+
+func (m *CommunityMute) GetValue() *CommunityMute {
+	return m
+}
+
+func (m *CommunityMute) Validate() error {
+	validate := validator.New()
+	return validate.Struct(m)
+}
+
+func (m *CommunityMute) GetCollectionName() string {
+	return CommunityMutesCollectionName
+}
+
+func (m *CommunityMute) EnsureIndexes(db mongo.Database) {
+	indexes := []mongod.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "communityId", Value: 1},
+				{Key: "userId", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("idx_community_mute_unique"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "expiresAt", Value: 1},
+			},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("ttl_community_mute_expires"),
+		},
+	}
+	mongo.NewQueryBuilder[CommunityMute](db, CommunityMutesCollectionName).Query(context.Background()).CheckIndexes(indexes)
+}
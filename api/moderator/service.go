@@ -1,11 +1,14 @@
 package moderator
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync-backend/api/moderator/model"
+	"sync-backend/arch/metrics"
 	"sync-backend/arch/mongo"
 	"sync-backend/arch/network"
+	"sync-backend/arch/tracing"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -19,37 +22,44 @@ type moderatorService struct {
 	reportQueryBuilder    mongo.QueryBuilder[model.Report]
 	modLogQueryBuilder    mongo.QueryBuilder[model.ModLog]
 	bansQueryBuilder      mongo.QueryBuilder[model.CommunityBan]
+	mutesQueryBuilder     mongo.QueryBuilder[model.CommunityMute]
 	transactionBuilder    mongo.TransactionBuilder
 }
 
 // ModeratorService defines the interface for moderator-related operations
 type ModeratorService interface {
 	// Moderator management
-	AddModerator(userId string, communityId string, role model.ModeratorRole, invitedBy string) (*model.Moderator, network.ApiError)
-	RemoveModerator(communityId string, userId string, removedBy string, reason string) network.ApiError
-	UpdateModerator(communityId string, userId string, updatedBy string, role *model.ModeratorRole, permissions []model.ModeratorPermission, status *string, notes *string) (*model.Moderator, network.ApiError)
-	GetModerator(communityId string, userId string) (*model.Moderator, network.ApiError)
-	ListModerators(communityId string, page, limit int) ([]*model.Moderator, int, network.ApiError)
+	AddModerator(ctx context.Context, userId string, communityId string, role model.ModeratorRole, invitedBy string) (*model.Moderator, network.ApiError)
+	RemoveModerator(ctx context.Context, communityId string, userId string, removedBy string, reason string) network.ApiError
+	UpdateModerator(ctx context.Context, communityId string, userId string, updatedBy string, role *model.ModeratorRole, permissions []model.ModeratorPermission, status *string, notes *string) (*model.Moderator, network.ApiError)
+	GetModerator(ctx context.Context, communityId string, userId string) (*model.Moderator, network.ApiError)
+	ListModerators(ctx context.Context, communityId string, page, limit int) ([]*model.Moderator, int, network.ApiError)
 
 	// Permission checks
-	HasModeratorPermission(userId string, communityId string, permission model.ModeratorPermission) (bool, network.ApiError)
-	IsModeratorOrHigher(userId string, communityId string) (bool, network.ApiError)
-	IsAdmin(userId string, communityId string) (bool, network.ApiError)
+	HasModeratorPermission(ctx context.Context, userId string, communityId string, permission model.ModeratorPermission) (bool, network.ApiError)
+	IsModeratorOrHigher(ctx context.Context, userId string, communityId string) (bool, network.ApiError)
+	IsAdmin(ctx context.Context, userId string, communityId string) (bool, network.ApiError)
 
 	// User ban management
-	IsUserBanned(userId string, communityId string) (bool, *model.BanInfo, network.ApiError)
-	BanUser(moderatorId string, userId string, communityId string, reason string, duration *int) (*model.ModLog, network.ApiError)
-	UnbanUser(moderatorId string, userId string, communityId string) (*model.ModLog, network.ApiError)
+	IsUserBanned(ctx context.Context, userId string, communityId string) (bool, *model.BanInfo, network.ApiError)
+	BanUser(ctx context.Context, moderatorId string, userId string, communityId string, reason string, duration *int) (*model.ModLog, network.ApiError)
+	UnbanUser(ctx context.Context, moderatorId string, userId string, communityId string) (*model.ModLog, network.ApiError)
+
+	// User mute management
+	IsUserMuted(ctx context.Context, userId string, communityId string) (bool, *model.MuteInfo, network.ApiError)
+	MuteUser(ctx context.Context, moderatorId string, userId string, communityId string, reason string, duration *int) (*model.ModLog, network.ApiError)
+	UnmuteUser(ctx context.Context, moderatorId string, userId string, communityId string) (*model.ModLog, network.ApiError)
 
 	// Reporting system
-	CreateReport(reporterId string, communityId string, targetId string, targetType model.ReportType, reason model.ReportReason, description string) (*model.Report, network.ApiError)
-	ProcessReport(reportId string, moderatorId string, status model.ReportStatus, notes string, action string) (*model.Report, network.ApiError)
-	GetReport(reportId string) (*model.Report, network.ApiError)
-	ListReports(communityId string, status *model.ReportStatus, targetType *model.ReportType, page, limit int) ([]*model.Report, int, network.ApiError)
+	CreateReport(ctx context.Context, reporterId string, communityId string, targetId string, targetType model.ReportType, reason model.ReportReason, description string) (*model.Report, network.ApiError)
+	ProcessReport(ctx context.Context, reportId string, moderatorId string, status model.ReportStatus, notes string, action string) (*model.Report, network.ApiError)
+	GetReport(ctx context.Context, reportId string) (*model.Report, network.ApiError)
+	ListReports(ctx context.Context, communityId string, status *model.ReportStatus, targetType *model.ReportType, page, limit int) ([]*model.Report, int, network.ApiError)
+	ListPlatformReports(ctx context.Context, communityId string, status model.ReportStatus, targetType model.ReportType, cursor string, limit int) ([]*model.Report, *mongo.CursorResult, network.ApiError)
 
 	// Moderation actions logging
-	LogModAction(communityId string, moderatorId string, actionType model.ModActionType, targetId string, targetType string, details string) (*model.ModLog, network.ApiError)
-	GetModLogs(communityId string, moderatorId string, page, limit int) ([]*model.ModLog, int, network.ApiError)
+	LogModAction(ctx context.Context, communityId string, moderatorId string, actionType model.ModActionType, targetId string, targetType string, details string) (*model.ModLog, network.ApiError)
+	GetModLogs(ctx context.Context, communityId string, moderatorId string, cursor string, limit int) ([]*model.ModLog, *mongo.CursorResult, network.ApiError)
 }
 
 // NewModeratorService creates a new moderator service
@@ -61,14 +71,18 @@ func NewModeratorService(
 		reportQueryBuilder:    mongo.NewQueryBuilder[model.Report](db, model.ReportCollectionName),
 		modLogQueryBuilder:    mongo.NewQueryBuilder[model.ModLog](db, model.ModLogCollectionName),
 		bansQueryBuilder:      mongo.NewQueryBuilder[model.CommunityBan](db, model.CommunityBansCollectionName),
+		mutesQueryBuilder:     mongo.NewQueryBuilder[model.CommunityMute](db, model.CommunityMutesCollectionName),
 		transactionBuilder:    mongo.NewTransactionBuilder(db),
 	}
 }
 
 // AddModerator adds a new moderator to a community
-func (s *moderatorService) AddModerator(userId string, communityId string, role model.ModeratorRole, invitedBy string) (*model.Moderator, network.ApiError) {
+func (s *moderatorService) AddModerator(ctx context.Context, userId string, communityId string, role model.ModeratorRole, invitedBy string) (*model.Moderator, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.AddModerator")
+	defer span.End()
+
 	// Check if moderator already exists
-	existingMod, err := s.moderatorQueryBuilder.SingleQuery().FilterOne(
+	existingMod, err := s.moderatorQueryBuilder.SingleQuery(ctx).FilterOne(
 		bson.M{"userId": userId, "communityId": communityId},
 		nil,
 	)
@@ -94,7 +108,7 @@ func (s *moderatorService) AddModerator(userId string, communityId string, role
 	moderator := model.NewModerator(userId, communityId, role, invitedBy)
 
 	// Insert into database
-	_, err = s.moderatorQueryBuilder.SingleQuery().InsertOne(moderator)
+	_, err = s.moderatorQueryBuilder.SingleQuery(ctx).InsertOne(moderator)
 	if err != nil {
 		return nil, network.NewInternalServerError(
 			"Error creating moderator",
@@ -106,6 +120,7 @@ func (s *moderatorService) AddModerator(userId string, communityId string, role
 
 	// Log the action
 	_, _ = s.LogModAction(
+		ctx,
 		communityId,
 		invitedBy,
 		model.ActionAddModerator,
@@ -118,9 +133,12 @@ func (s *moderatorService) AddModerator(userId string, communityId string, role
 }
 
 // RemoveModerator removes a moderator from a community
-func (s *moderatorService) RemoveModerator(communityId string, userId string, removedBy string, reason string) network.ApiError {
+func (s *moderatorService) RemoveModerator(ctx context.Context, communityId string, userId string, removedBy string, reason string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "ModeratorService.RemoveModerator")
+	defer span.End()
+
 	// Check if moderator exists
-	_, err := s.moderatorQueryBuilder.SingleQuery().FilterOne(
+	_, err := s.moderatorQueryBuilder.SingleQuery(ctx).FilterOne(
 		bson.M{"userId": userId, "communityId": communityId},
 		nil,
 	)
@@ -142,7 +160,7 @@ func (s *moderatorService) RemoveModerator(communityId string, userId string, re
 	}
 
 	// Delete the moderator
-	_, err = s.moderatorQueryBuilder.SingleQuery().DeleteOne(
+	_, err = s.moderatorQueryBuilder.SingleQuery(ctx).DeleteOne(
 		bson.M{"userId": userId, "communityId": communityId},
 		nil,
 	)
@@ -163,6 +181,7 @@ func (s *moderatorService) RemoveModerator(communityId string, userId string, re
 	}
 
 	_, _ = s.LogModAction(
+		ctx,
 		communityId,
 		removedBy,
 		model.ActionRemoveModerator,
@@ -175,9 +194,12 @@ func (s *moderatorService) RemoveModerator(communityId string, userId string, re
 }
 
 // UpdateModerator updates a moderator's role, permissions, or status
-func (s *moderatorService) UpdateModerator(communityId string, userId string, updatedBy string, role *model.ModeratorRole, permissions []model.ModeratorPermission, status *string, notes *string) (*model.Moderator, network.ApiError) {
+func (s *moderatorService) UpdateModerator(ctx context.Context, communityId string, userId string, updatedBy string, role *model.ModeratorRole, permissions []model.ModeratorPermission, status *string, notes *string) (*model.Moderator, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.UpdateModerator")
+	defer span.End()
+
 	// Check if moderator exists
-	moderator, err := s.moderatorQueryBuilder.SingleQuery().FilterOne(
+	moderator, err := s.moderatorQueryBuilder.SingleQuery(ctx).FilterOne(
 		bson.M{"userId": userId, "communityId": communityId},
 		nil,
 	)
@@ -229,7 +251,7 @@ func (s *moderatorService) UpdateModerator(communityId string, userId string, up
 	}
 
 	// Update the moderator
-	_, err = s.moderatorQueryBuilder.SingleQuery().UpdateOne(
+	_, err = s.moderatorQueryBuilder.SingleQuery(ctx).UpdateOne(
 		bson.M{"userId": userId, "communityId": communityId},
 		update,
 		nil,
@@ -245,7 +267,7 @@ func (s *moderatorService) UpdateModerator(communityId string, userId string, up
 	}
 
 	// Get updated moderator
-	updatedModerator, err := s.moderatorQueryBuilder.SingleQuery().FilterOne(
+	updatedModerator, err := s.moderatorQueryBuilder.SingleQuery(ctx).FilterOne(
 		bson.M{"userId": userId, "communityId": communityId},
 		nil,
 	)
@@ -266,6 +288,7 @@ func (s *moderatorService) UpdateModerator(communityId string, userId string, up
 	}
 
 	_, _ = s.LogModAction(
+		ctx,
 		communityId,
 		updatedBy,
 		model.ActionChangeModerator,
@@ -278,8 +301,11 @@ func (s *moderatorService) UpdateModerator(communityId string, userId string, up
 }
 
 // GetModerator gets details of a specific moderator
-func (s *moderatorService) GetModerator(communityId string, userId string) (*model.Moderator, network.ApiError) {
-	moderator, err := s.moderatorQueryBuilder.SingleQuery().FilterOne(
+func (s *moderatorService) GetModerator(ctx context.Context, communityId string, userId string) (*model.Moderator, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.GetModerator")
+	defer span.End()
+
+	moderator, err := s.moderatorQueryBuilder.SingleQuery(ctx).FilterOne(
 		bson.M{"userId": userId, "communityId": communityId},
 		nil,
 	)
@@ -304,12 +330,15 @@ func (s *moderatorService) GetModerator(communityId string, userId string) (*mod
 }
 
 // ListModerators lists all moderators for a community
-func (s *moderatorService) ListModerators(communityId string, page, limit int) ([]*model.Moderator, int, network.ApiError) {
+func (s *moderatorService) ListModerators(ctx context.Context, communityId string, page, limit int) ([]*model.Moderator, int, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.ListModerators")
+	defer span.End()
+
 	// Calculate skip value for pagination
 	skip := (page - 1) * limit
 
 	// Get total count
-	count, err := s.moderatorQueryBuilder.SingleQuery().CountDocuments(
+	count, err := s.moderatorQueryBuilder.SingleQuery(ctx).CountDocuments(
 		bson.M{"communityId": communityId},
 		nil,
 	)
@@ -326,7 +355,7 @@ func (s *moderatorService) ListModerators(communityId string, page, limit int) (
 	// Get moderators with pagination
 	opts := options.Find().SetLimit(int64(limit)).SetSkip(int64(skip)).SetSort(bson.D{{Key: "createdAt", Value: -1}})
 
-	moderators, err := s.moderatorQueryBuilder.SingleQuery().FindAll(
+	moderators, err := s.moderatorQueryBuilder.SingleQuery(ctx).FindAll(
 		bson.M{"communityId": communityId},
 		opts,
 	)
@@ -344,9 +373,12 @@ func (s *moderatorService) ListModerators(communityId string, page, limit int) (
 }
 
 // HasModeratorPermission checks if a user has a specific permission in a community
-func (s *moderatorService) HasModeratorPermission(userId string, communityId string, permission model.ModeratorPermission) (bool, network.ApiError) {
+func (s *moderatorService) HasModeratorPermission(ctx context.Context, userId string, communityId string, permission model.ModeratorPermission) (bool, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.HasModeratorPermission")
+	defer span.End()
+
 	// Get the moderator document
-	moderator, err := s.moderatorQueryBuilder.SingleQuery().FilterOne(
+	moderator, err := s.moderatorQueryBuilder.SingleQuery(ctx).FilterOne(
 		bson.M{"userId": userId, "communityId": communityId, "status": "active"},
 		nil,
 	)
@@ -374,9 +406,12 @@ func (s *moderatorService) HasModeratorPermission(userId string, communityId str
 }
 
 // IsModeratorOrHigher checks if the user has any moderator role in the community
-func (s *moderatorService) IsModeratorOrHigher(userId string, communityId string) (bool, network.ApiError) {
+func (s *moderatorService) IsModeratorOrHigher(ctx context.Context, userId string, communityId string) (bool, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.IsModeratorOrHigher")
+	defer span.End()
+
 	// Check if the user has a moderator document
-	count, err := s.moderatorQueryBuilder.SingleQuery().CountDocuments(
+	count, err := s.moderatorQueryBuilder.SingleQuery(ctx).CountDocuments(
 		bson.M{"userId": userId, "communityId": communityId, "status": "active"},
 		nil,
 	)
@@ -394,9 +429,12 @@ func (s *moderatorService) IsModeratorOrHigher(userId string, communityId string
 }
 
 // IsAdmin checks if the user has an admin role in the community
-func (s *moderatorService) IsAdmin(userId string, communityId string) (bool, network.ApiError) {
+func (s *moderatorService) IsAdmin(ctx context.Context, userId string, communityId string) (bool, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.IsAdmin")
+	defer span.End()
+
 	// Check if the user has an admin role
-	count, err := s.moderatorQueryBuilder.SingleQuery().CountDocuments(
+	count, err := s.moderatorQueryBuilder.SingleQuery(ctx).CountDocuments(
 		bson.M{
 			"userId":      userId,
 			"communityId": communityId,
@@ -419,8 +457,11 @@ func (s *moderatorService) IsAdmin(userId string, communityId string) (bool, net
 }
 
 // IsUserBanned checks if a user is banned from a community
-func (s *moderatorService) IsUserBanned(userId string, communityId string) (bool, *model.BanInfo, network.ApiError) {
-	ban, err := s.bansQueryBuilder.SingleQuery().FilterOne(
+func (s *moderatorService) IsUserBanned(ctx context.Context, userId string, communityId string) (bool, *model.BanInfo, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.IsUserBanned")
+	defer span.End()
+
+	ban, err := s.bansQueryBuilder.SingleQuery(ctx).FilterOne(
 		bson.M{
 			"userId":      userId,
 			"communityId": communityId,
@@ -446,7 +487,7 @@ func (s *moderatorService) IsUserBanned(userId string, communityId string) (bool
 		expiryTime := ban.ExpiresAt.Time()
 		if time.Now().After(expiryTime) {
 			// Ban has expired, update it
-			_, err := s.bansQueryBuilder.SingleQuery().UpdateOne(
+			_, err := s.bansQueryBuilder.SingleQuery(ctx).UpdateOne(
 				bson.M{"_id": ban.ID},
 				bson.M{"$set": bson.M{"isActive": false}},
 				nil,
@@ -476,7 +517,10 @@ func (s *moderatorService) IsUserBanned(userId string, communityId string) (bool
 }
 
 // BanUser bans a user from a community
-func (s *moderatorService) BanUser(moderatorId string, userId string, communityId string, reason string, duration *int) (*model.ModLog, network.ApiError) {
+func (s *moderatorService) BanUser(ctx context.Context, moderatorId string, userId string, communityId string, reason string, duration *int) (*model.ModLog, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.BanUser")
+	defer span.End()
+
 	now := time.Now()
 	ptNow := primitive.NewDateTimeFromTime(now)
 
@@ -501,7 +545,7 @@ func (s *moderatorService) BanUser(moderatorId string, userId string, communityI
 	}
 
 	// Create or update ban
-	_, err := s.bansQueryBuilder.SingleQuery().UpdateOne(
+	_, err := s.bansQueryBuilder.SingleQuery(ctx).UpdateOne(
 		bson.M{"userId": userId, "communityId": communityId},
 		bson.M{"$set": ban},
 		options.Update().SetUpsert(true),
@@ -515,6 +559,7 @@ func (s *moderatorService) BanUser(moderatorId string, userId string, communityI
 			err,
 		)
 	}
+	metrics.BanIssued(metrics.BanScopeCommunity)
 
 	// Log the action
 	actionType := model.ActionBanUser
@@ -531,6 +576,7 @@ func (s *moderatorService) BanUser(moderatorId string, userId string, communityI
 	}
 
 	log, err := s.LogModAction(
+		ctx,
 		communityId,
 		moderatorId,
 		actionType,
@@ -552,9 +598,12 @@ func (s *moderatorService) BanUser(moderatorId string, userId string, communityI
 }
 
 // UnbanUser unbans a user from a community
-func (s *moderatorService) UnbanUser(moderatorId string, userId string, communityId string) (*model.ModLog, network.ApiError) {
+func (s *moderatorService) UnbanUser(ctx context.Context, moderatorId string, userId string, communityId string) (*model.ModLog, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.UnbanUser")
+	defer span.End()
+
 	// Find and update the ban
-	_, err := s.bansQueryBuilder.SingleQuery().UpdateOne(
+	_, err := s.bansQueryBuilder.SingleQuery(ctx).UpdateOne(
 		bson.M{
 			"userId":      userId,
 			"communityId": communityId,
@@ -589,6 +638,7 @@ func (s *moderatorService) UnbanUser(moderatorId string, userId string, communit
 	details := fmt.Sprintf("Unbanned user %s", userId)
 
 	log, err := s.LogModAction(
+		ctx,
 		communityId,
 		moderatorId,
 		model.ActionUnbanUser,
@@ -609,11 +659,191 @@ func (s *moderatorService) UnbanUser(moderatorId string, userId string, communit
 	return log, nil
 }
 
+// IsUserMuted checks if a user is muted in a community
+func (s *moderatorService) IsUserMuted(ctx context.Context, userId string, communityId string) (bool, *model.MuteInfo, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.IsUserMuted")
+	defer span.End()
+
+	mute, err := s.mutesQueryBuilder.SingleQuery(ctx).FilterOne(
+		bson.M{
+			"userId":      userId,
+			"communityId": communityId,
+			"isActive":    true,
+		},
+		nil,
+	)
+
+	if err != nil {
+		if mongo.IsNoDocumentFoundError(err) {
+			return false, nil, nil // User is not muted
+		}
+		return false, nil, network.NewInternalServerError(
+			"Error checking if user is muted",
+			fmt.Sprintf("Database error when checking if user '%s' is muted in community '%s'. Context - [ Query Failed ]", userId, communityId),
+			network.DB_ERROR,
+			err,
+		)
+	}
+
+	// Check if temporary mute has expired
+	if mute.ExpiresAt != nil && time.Now().After(mute.ExpiresAt.Time()) {
+		_, _ = s.mutesQueryBuilder.SingleQuery(ctx).UpdateOne(
+			bson.M{"_id": mute.ID},
+			bson.M{"$set": bson.M{"isActive": false}},
+			nil,
+		)
+		return false, nil, nil // Mute expired
+	}
+
+	info := &model.MuteInfo{
+		Reason:      mute.Reason,
+		IsPermanent: mute.ExpiresAt == nil,
+	}
+	if mute.ExpiresAt != nil {
+		expTime := mute.ExpiresAt.Time()
+		info.ExpiresAt = &expTime
+	}
+
+	return true, info, nil
+}
+
+// MuteUser mutes a user in a community, duration is in hours
+func (s *moderatorService) MuteUser(ctx context.Context, moderatorId string, userId string, communityId string, reason string, duration *int) (*model.ModLog, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.MuteUser")
+	defer span.End()
+
+	mute := model.NewCommunityMute(communityId, userId, moderatorId, reason, duration)
+
+	// Create or update mute. A re-mute keeps the id and creation time of the mute it extends, so the
+	// mod log can still be followed back to it. A permanent mute drops the expiry of an earlier timed
+	// one so the TTL index does not remove it.
+	set := bson.M{
+		"moderatorId": mute.ModeratorId,
+		"reason":      mute.Reason,
+		"isActive":    true,
+		"updatedAt":   mute.UpdatedAt,
+	}
+	update := bson.M{
+		"$set":         set,
+		"$setOnInsert": bson.M{"muteId": mute.MuteId, "createdAt": mute.CreatedAt},
+	}
+	if mute.ExpiresAt == nil {
+		update["$unset"] = bson.M{"expiresAt": "", "duration": ""}
+	} else {
+		set["expiresAt"] = mute.ExpiresAt
+		set["duration"] = mute.Duration
+	}
+	_, err := s.mutesQueryBuilder.SingleQuery(ctx).UpdateOne(
+		bson.M{"userId": userId, "communityId": communityId},
+		update,
+		options.Update().SetUpsert(true),
+	)
+
+	if err != nil {
+		return nil, network.NewInternalServerError(
+			"Error muting user",
+			fmt.Sprintf("Database error when muting user '%s' in community '%s'. Context - [ Query Failed ]", userId, communityId),
+			network.DB_ERROR,
+			err,
+		)
+	}
+
+	details := fmt.Sprintf("Muted user %s", userId)
+	if duration != nil && *duration > 0 {
+		details += fmt.Sprintf(" for %d hours", *duration)
+	}
+	if reason != "" {
+		details += fmt.Sprintf(" - Reason: %s", reason)
+	}
+
+	log, logErr := s.LogModAction(
+		ctx,
+		communityId,
+		moderatorId,
+		model.ActionMuteUser,
+		userId,
+		"user",
+		details,
+	)
+
+	if logErr != nil {
+		return nil, network.NewInternalServerError(
+			"Error logging mute action",
+			fmt.Sprintf("Database error when logging mute action for user '%s' in community '%s'. Context - [ Query Failed ]", userId, communityId),
+			network.DB_ERROR,
+			logErr,
+		)
+	}
+
+	return log, nil
+}
+
+// UnmuteUser lifts a mute on a user in a community
+func (s *moderatorService) UnmuteUser(ctx context.Context, moderatorId string, userId string, communityId string) (*model.ModLog, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.UnmuteUser")
+	defer span.End()
+
+	result, err := s.mutesQueryBuilder.SingleQuery(ctx).UpdateOne(
+		bson.M{
+			"userId":      userId,
+			"communityId": communityId,
+			"isActive":    true,
+		},
+		bson.M{
+			"$set": bson.M{
+				"isActive":  false,
+				"updatedAt": primitive.NewDateTimeFromTime(time.Now()),
+			},
+		},
+		nil,
+	)
+
+	if err != nil {
+		return nil, network.NewInternalServerError(
+			"Error unmuting user",
+			fmt.Sprintf("Database error when unmuting user '%s' in community '%s'. Context - [ Query Failed ]", userId, communityId),
+			network.DB_ERROR,
+			err,
+		)
+	}
+	if result == nil || result.MatchedCount == 0 {
+		return nil, network.NewNotFoundError(
+			"Mute not found",
+			fmt.Sprintf("User '%s' is not muted in community '%s'. Context - [ No Data ]", userId, communityId),
+			errors.New("mute not found"),
+		)
+	}
+
+	log, logErr := s.LogModAction(
+		ctx,
+		communityId,
+		moderatorId,
+		model.ActionUnmuteUser,
+		userId,
+		"user",
+		fmt.Sprintf("Unmuted user %s", userId),
+	)
+
+	if logErr != nil {
+		return nil, network.NewInternalServerError(
+			"Error logging unmute action",
+			fmt.Sprintf("Database error when logging unmute action for user '%s' in community '%s'. Context - [ Query Failed ]", userId, communityId),
+			network.DB_ERROR,
+			logErr,
+		)
+	}
+
+	return log, nil
+}
+
 // CreateReport creates a new report
-func (s *moderatorService) CreateReport(reporterId string, communityId string, targetId string, targetType model.ReportType, reason model.ReportReason, description string) (*model.Report, network.ApiError) {
+func (s *moderatorService) CreateReport(ctx context.Context, reporterId string, communityId string, targetId string, targetType model.ReportType, reason model.ReportReason, description string) (*model.Report, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.CreateReport")
+	defer span.End()
+
 	report := model.NewReport(reporterId, communityId, targetId, targetType, reason).WithDescription(description)
 
-	_, err := s.reportQueryBuilder.SingleQuery().InsertOne(report)
+	_, err := s.reportQueryBuilder.SingleQuery(ctx).InsertOne(report)
 	if err != nil {
 		return nil, network.NewInternalServerError(
 			"Error creating report",
@@ -622,14 +852,18 @@ func (s *moderatorService) CreateReport(reporterId string, communityId string, t
 			err,
 		)
 	}
+	metrics.ReportFiled(string(targetType))
 
 	return report, nil
 }
 
 // ProcessReport processes a report and updates its status
-func (s *moderatorService) ProcessReport(reportId string, moderatorId string, status model.ReportStatus, notes string, action string) (*model.Report, network.ApiError) {
+func (s *moderatorService) ProcessReport(ctx context.Context, reportId string, moderatorId string, status model.ReportStatus, notes string, action string) (*model.Report, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.ProcessReport")
+	defer span.End()
+
 	// Find the report
-	report, err := s.reportQueryBuilder.SingleQuery().FilterOne(
+	report, err := s.reportQueryBuilder.SingleQuery(ctx).FilterOne(
 		bson.M{"reportId": reportId},
 		nil,
 	)
@@ -661,7 +895,7 @@ func (s *moderatorService) ProcessReport(reportId string, moderatorId string, st
 		},
 	}
 
-	_, err = s.reportQueryBuilder.SingleQuery().UpdateOne(
+	_, err = s.reportQueryBuilder.SingleQuery(ctx).UpdateOne(
 		bson.M{"reportId": reportId},
 		update,
 		nil,
@@ -677,7 +911,7 @@ func (s *moderatorService) ProcessReport(reportId string, moderatorId string, st
 	}
 
 	// Get updated report
-	updatedReport, err := s.reportQueryBuilder.SingleQuery().FilterOne(
+	updatedReport, err := s.reportQueryBuilder.SingleQuery(ctx).FilterOne(
 		bson.M{"reportId": reportId},
 		nil,
 	)
@@ -698,6 +932,7 @@ func (s *moderatorService) ProcessReport(reportId string, moderatorId string, st
 	}
 
 	_, _ = s.LogModAction(
+		ctx,
 		report.CommunityId,
 		moderatorId,
 		model.ActionProcessReport,
@@ -710,8 +945,11 @@ func (s *moderatorService) ProcessReport(reportId string, moderatorId string, st
 }
 
 // GetReport gets details of a specific report
-func (s *moderatorService) GetReport(reportId string) (*model.Report, network.ApiError) {
-	report, err := s.reportQueryBuilder.SingleQuery().FilterOne(
+func (s *moderatorService) GetReport(ctx context.Context, reportId string) (*model.Report, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.GetReport")
+	defer span.End()
+
+	report, err := s.reportQueryBuilder.SingleQuery(ctx).FilterOne(
 		bson.M{"reportId": reportId},
 		nil,
 	)
@@ -736,7 +974,10 @@ func (s *moderatorService) GetReport(reportId string) (*model.Report, network.Ap
 }
 
 // ListReports lists reports for a community
-func (s *moderatorService) ListReports(communityId string, status *model.ReportStatus, targetType *model.ReportType, page, limit int) ([]*model.Report, int, network.ApiError) {
+func (s *moderatorService) ListReports(ctx context.Context, communityId string, status *model.ReportStatus, targetType *model.ReportType, page, limit int) ([]*model.Report, int, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.ListReports")
+	defer span.End()
+
 	// Calculate skip value for pagination
 	skip := (page - 1) * limit
 
@@ -752,7 +993,7 @@ func (s *moderatorService) ListReports(communityId string, status *model.ReportS
 	}
 
 	// Get total count
-	count, err := s.reportQueryBuilder.SingleQuery().CountDocuments(filter, nil)
+	count, err := s.reportQueryBuilder.SingleQuery(ctx).CountDocuments(filter, nil)
 	if err != nil {
 		return nil, 0, network.NewInternalServerError(
 			"Error counting reports",
@@ -765,7 +1006,7 @@ func (s *moderatorService) ListReports(communityId string, status *model.ReportS
 	// Get reports with pagination
 	opts := options.Find().SetLimit(int64(limit)).SetSkip(int64(skip)).SetSort(bson.D{{Key: "createdAt", Value: -1}})
 
-	reports, err := s.reportQueryBuilder.SingleQuery().FindAll(filter, opts)
+	reports, err := s.reportQueryBuilder.SingleQuery(ctx).FindAll(filter, opts)
 	if err != nil {
 		return nil, 0, network.NewInternalServerError(
 			"Error fetching reports",
@@ -778,11 +1019,51 @@ func (s *moderatorService) ListReports(communityId string, status *model.ReportS
 	return reports, int(count), nil
 }
 
+// ListPlatformReports lists reports across every community, newest first, for the platform staff.
+// Empty filters match everything.
+func (s *moderatorService) ListPlatformReports(ctx context.Context, communityId string, status model.ReportStatus, targetType model.ReportType, cursor string, limit int) ([]*model.Report, *mongo.CursorResult, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.ListPlatformReports")
+	defer span.End()
+
+	filter := bson.M{}
+	if communityId != "" {
+		filter["communityId"] = communityId
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+	if targetType != "" {
+		filter["targetType"] = targetType
+	}
+
+	reports, page, err := s.reportQueryBuilder.SingleQuery(ctx).FindCursorPaginated(filter, mongo.NewCursorQuery("createdAt", int64(limit), cursor), nil)
+	if err != nil {
+		if mongo.IsInvalidCursorError(err) {
+			return nil, nil, network.NewBadRequestError(
+				"Invalid pagination cursor",
+				"Cursor for the platform report queue is malformed or expired. Context - [ Invalid Cursor ]",
+				err,
+			)
+		}
+		return nil, nil, network.NewInternalServerError(
+			"Error fetching reports",
+			"Database error when fetching the platform report queue. Context - [ Query Failed ]",
+			network.DB_ERROR,
+			err,
+		)
+	}
+
+	return reports, page, nil
+}
+
 // LogModAction logs a moderation action
-func (s *moderatorService) LogModAction(communityId string, moderatorId string, actionType model.ModActionType, targetId string, targetType string, details string) (*model.ModLog, network.ApiError) {
+func (s *moderatorService) LogModAction(ctx context.Context, communityId string, moderatorId string, actionType model.ModActionType, targetId string, targetType string, details string) (*model.ModLog, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.LogModAction")
+	defer span.End()
+
 	modLog := model.NewModLog(communityId, moderatorId, actionType, targetId, targetType).WithDetails(map[string]string{"details": details})
 
-	_, err := s.modLogQueryBuilder.SingleQuery().InsertOne(modLog)
+	_, err := s.modLogQueryBuilder.SingleQuery(ctx).InsertOne(modLog)
 	if err != nil {
 		return nil, network.NewInternalServerError(
 			"Error logging moderation action",
@@ -795,10 +1076,10 @@ func (s *moderatorService) LogModAction(communityId string, moderatorId string,
 	return modLog, nil
 }
 
-// GetModLogs gets moderation logs for a community
-func (s *moderatorService) GetModLogs(communityId string, moderatorId string, page, limit int) ([]*model.ModLog, int, network.ApiError) {
-	// Calculate skip value for pagination
-	skip := (page - 1) * limit
+// GetModLogs gets moderation logs for a community, newest first
+func (s *moderatorService) GetModLogs(ctx context.Context, communityId string, moderatorId string, cursor string, limit int) ([]*model.ModLog, *mongo.CursorResult, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "ModeratorService.GetModLogs")
+	defer span.End()
 
 	// Build filter
 	filter := bson.M{"communityId": communityId}
@@ -806,23 +1087,16 @@ func (s *moderatorService) GetModLogs(communityId string, moderatorId string, pa
 		filter["moderatorId"] = moderatorId
 	}
 
-	// Get total count
-	count, err := s.modLogQueryBuilder.SingleQuery().CountDocuments(filter, nil)
-	if err != nil {
-		return nil, 0, network.NewInternalServerError(
-			"Error counting moderation logs",
-			fmt.Sprintf("Database error when counting moderation logs in community '%s'. Context - [ Query Failed ]", communityId),
-			network.DB_ERROR,
-			err,
-		)
-	}
-
-	// Get logs with pagination
-	opts := options.Find().SetLimit(int64(limit)).SetSkip(int64(skip)).SetSort(bson.D{{Key: "timestamp", Value: -1}})
-
-	logs, err := s.modLogQueryBuilder.SingleQuery().FindAll(filter, opts)
+	logs, page, err := s.modLogQueryBuilder.SingleQuery(ctx).FindCursorPaginated(filter, mongo.NewCursorQuery("createdAt", int64(limit), cursor), nil)
 	if err != nil {
-		return nil, 0, network.NewInternalServerError(
+		if mongo.IsInvalidCursorError(err) {
+			return nil, nil, network.NewBadRequestError(
+				"Invalid pagination cursor",
+				fmt.Sprintf("Cursor for moderation logs of community '%s' is malformed or expired. Context - [ Invalid Cursor ]", communityId),
+				err,
+			)
+		}
+		return nil, nil, network.NewInternalServerError(
 			"Error fetching moderation logs",
 			fmt.Sprintf("Database error when fetching moderation logs in community '%s'. Context - [ Query Failed ]", communityId),
 			network.DB_ERROR,
@@ -830,5 +1104,5 @@ func (s *moderatorService) GetModLogs(communityId string, moderatorId string, pa
 		)
 	}
 
-	return logs, int(count), nil
+	return logs, page, nil
 }
@@ -1,11 +1,15 @@
 package post
 
 import (
+	"context"
 	"sync-backend/api/common/analytics"
+	"sync-backend/api/media"
 	modMW "sync-backend/api/moderator/middleware"
 	"sync-backend/api/post/dto"
 	"sync-backend/api/post/model"
+	"sync-backend/arch/background"
 	"sync-backend/arch/common"
+	coredto "sync-backend/arch/dto"
 	"sync-backend/arch/middleware"
 	"sync-backend/arch/network"
 	"sync-backend/utils"
@@ -17,23 +21,31 @@ type postController struct {
 	network.BaseController
 	common.ContextPayload
 	authenticatorProvider network.AuthenticationProvider
+	verificationProvider  network.EmailVerificationProvider
 	uploadProvider        middleware.UploadProvider
 	moderatorMiddleware   modMW.ModeratorMiddleware
+	rateLimitProvider     network.RateLimitProvider
+	idempotencyProvider   network.IdempotencyProvider
 	logger                utils.AppLogger
 	postService           PostService
+	mediaLibraryService   media.MediaLibraryService
 	postAnalytics         analytics.PostAnalytics
 	communityAnalytics    analytics.CommunityAnalytics
 }
 
-func NewPostController(authenticatorProvider network.AuthenticationProvider, uploadProvider middleware.UploadProvider, postService PostService, postAnalytics analytics.PostAnalytics, communityAnalytics analytics.CommunityAnalytics, moderatorMiddleware modMW.ModeratorMiddleware) *postController {
+func NewPostController(authenticatorProvider network.AuthenticationProvider, verificationProvider network.EmailVerificationProvider, uploadProvider middleware.UploadProvider, postService PostService, mediaLibraryService media.MediaLibraryService, postAnalytics analytics.PostAnalytics, communityAnalytics analytics.CommunityAnalytics, moderatorMiddleware modMW.ModeratorMiddleware, rateLimitProvider network.RateLimitProvider, idempotencyProvider network.IdempotencyProvider) *postController {
 	return &postController{
 		BaseController:        network.NewBaseController("/post", authenticatorProvider),
 		ContextPayload:        common.NewContextPayload(),
 		logger:                utils.NewServiceLogger("PostController"),
 		authenticatorProvider: authenticatorProvider,
+		verificationProvider:  verificationProvider,
 		uploadProvider:        uploadProvider,
 		moderatorMiddleware:   moderatorMiddleware,
+		rateLimitProvider:     rateLimitProvider,
+		idempotencyProvider:   idempotencyProvider,
 		postService:           postService,
+		mediaLibraryService:   mediaLibraryService,
 		postAnalytics:         postAnalytics,
 		communityAnalytics:    communityAnalytics,
 	}
@@ -42,16 +54,25 @@ func NewPostController(authenticatorProvider network.AuthenticationProvider, upl
 func (c *postController) MountRoutes(group *gin.RouterGroup) {
 	c.logger.Info("Mounting post routes")
 	group.Use(c.authenticatorProvider.Middleware())
+	vote := c.rateLimitProvider.Middleware(middleware.RateLimitVote)
+
 	group.GET("/get/:postId", c.GetPost)
-	group.POST("/create", c.uploadProvider.Middleware("media"), c.CreatePost)
+	// Replays of a retried create are answered before they count against the limit
+	group.POST("/create", c.idempotencyProvider.Middleware(), c.rateLimitProvider.Middleware(middleware.RateLimitPostCreate), c.verificationProvider.Middleware(), c.uploadProvider.Middleware("media"), c.CreatePost)
 	group.PUT("/:postId", c.EditPost)
 	group.DELETE("/:postId", c.DeletePost)
+	group.GET("/:postId/revisions", c.GetPostRevisions)
 
-	group.POST("/like/:postId", c.LikePost)
-	group.POST("/dislike/:postId", c.DislikePost)
+	group.POST("/like/:postId", vote, c.LikePost)
+	group.POST("/dislike/:postId", vote, c.DislikePost)
 	group.POST("/save/:postId", c.SavePost)
 	group.POST("/share/:postId", c.SharePost)
 
+	// Post reaction routes
+	group.PUT("/reaction/:postId", vote, c.SetPostReaction)
+	group.DELETE("/reaction/:postId", vote, c.RemovePostReaction)
+	group.GET("/reaction/:postId", c.GetPostReactors)
+
 	// User post routes
 	group.GET("/get/user", c.UserPosts)
 	group.GET("/feed", c.UserFeedPosts)
@@ -74,16 +95,12 @@ func (c *postController) CreatePost(ctx *gin.Context) {
 	if err != nil {
 		return
 	}
+	defer c.uploadProvider.DeleteUploadedFiles(ctx, "media")
 	userId := c.MustGetUserId(ctx)
+
+	// Files sent along with the post go through the media library, then attach like pre-uploaded media
 	files := c.uploadProvider.GetUploadedFiles(ctx, "media")
-	var filesList []string
-	if files != nil && len(files.Files) > 0 {
-		for _, file := range files.Files {
-			c.logger.Debug("File uploaded: %s", file.Path)
-			filesList = append(filesList, file.Path)
-		}
-	}
-	if len(filesList) > 10 {
+	if len(files.Files)+len(body.MediaIds) > 10 {
 		c.Send(ctx).BadRequestError(
 			"Too many files uploaded",
 			"The maximum number of files allowed is 10",
@@ -91,11 +108,23 @@ func (c *postController) CreatePost(ctx *gin.Context) {
 		)
 		return
 	}
+	mediaIds := body.MediaIds
+	for _, file := range files.Files {
+		c.logger.WithContext(ctx).Debug("File uploaded: %s", file.Path)
+		media, err := c.mediaLibraryService.UploadMedia(ctx.Request.Context(), *userId, file)
+		if err != nil {
+			c.Send(ctx).MixedError(err)
+			return
+		}
+		mediaIds = append(mediaIds, media.MediaId)
+	}
+
 	post, err := c.postService.CreatePost(
+		ctx.Request.Context(),
 		body.Title,
 		body.Content,
 		body.Tags,
-		filesList,
+		mediaIds,
 		*userId,
 		body.CommunityId,
 		body.Type,
@@ -108,10 +137,9 @@ func (c *postController) CreatePost(ctx *gin.Context) {
 	}
 
 	c.Send(ctx).SuccessDataResponse("Post created successfully", dto.CreatePostResponse{PostId: post.PostId})
-	c.logger.Debug("Post details: %+v", post)
-	c.uploadProvider.DeleteUploadedFiles(ctx, "media")
+	c.logger.WithContext(ctx).Debug("Post details: %+v", post)
 
-	go c.communityAnalytics.RecordPostCreated(post.CommunityId, *userId)
+	background.Go(func() { c.communityAnalytics.RecordPostCreated(post.CommunityId, *userId) })
 }
 
 func (c *postController) GetPost(ctx *gin.Context) {
@@ -126,16 +154,17 @@ func (c *postController) GetPost(ctx *gin.Context) {
 	}
 
 	userId := c.MustGetUserId(ctx)
-	post, err := c.postService.GetPost(postId, *userId)
+	post, err := c.postService.GetPost(ctx.Request.Context(), postId, *userId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
 	}
 	c.Send(ctx).SuccessDataResponse("Post retrieved successfully", post)
-	c.logger.Debug("Post details: %+v", post)
+	c.logger.WithContext(ctx).Debug("Post details: %+v", post)
 
-	go c.postService.RecordPostView(postId, *userId)
-	go c.postAnalytics.RecordPostClick(postId, *userId)
+	viewCtx := context.WithoutCancel(ctx.Request.Context())
+	background.Go(func() { c.postService.RecordPostView(viewCtx, postId, *userId) })
+	background.Go(func() { c.postAnalytics.RecordPostClick(postId, *userId) })
 }
 
 func (c *postController) EditPost(ctx *gin.Context) {
@@ -153,15 +182,22 @@ func (c *postController) EditPost(ctx *gin.Context) {
 		return
 	}
 	userId := c.MustGetUserId(ctx)
-	title := body.Title
-	content := body.Content
+	// Empty title or content means the field was not sent, keep the current value
+	var title, content *string
+	if body.Title != "" {
+		title = &body.Title
+	}
+	if body.Content != "" {
+		content = &body.Content
+	}
 	isNSFW := body.IsNSFW
 	isSpoiler := body.IsSpoiler
-	_, err = c.postService.EditPost(
+	err = c.postService.EditPost(
+		ctx.Request.Context(),
 		*userId,
 		postId,
-		&title,
-		&content,
+		title,
+		content,
 		body.PostType,
 		&isNSFW,
 		&isSpoiler,
@@ -173,6 +209,26 @@ func (c *postController) EditPost(ctx *gin.Context) {
 	c.Send(ctx).SuccessMsgResponse("Post edited successfully")
 }
 
+func (c *postController) GetPostRevisions(ctx *gin.Context) {
+	postId := ctx.Param("postId")
+	if postId == "" {
+		c.Send(ctx).BadRequestError(
+			"Post ID is required",
+			"Please provide a valid post ID in the request params.",
+			nil,
+		)
+		return
+	}
+
+	userId := c.MustGetUserId(ctx)
+	revisions, err := c.postService.GetPostRevisions(ctx.Request.Context(), *userId, postId)
+	if err != nil {
+		c.Send(ctx).MixedError(err)
+		return
+	}
+	c.Send(ctx).SuccessDataResponse("Post revisions retrieved successfully", revisions)
+}
+
 func (c *postController) DeletePost(ctx *gin.Context) {
 	postId := ctx.Param("postId")
 	if postId == "" {
@@ -185,7 +241,7 @@ func (c *postController) DeletePost(ctx *gin.Context) {
 	}
 
 	userId := c.MustGetUserId(ctx)
-	err := c.postService.DeletePost(*userId, postId)
+	err := c.postService.DeletePost(ctx.Request.Context(), *userId, postId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -205,7 +261,7 @@ func (c *postController) LikePost(ctx *gin.Context) {
 	}
 
 	userId := c.MustGetUserId(ctx)
-	isLiked, synergy, err := c.postService.LikePost(*userId, postId)
+	isLiked, synergy, err := c.postService.LikePost(ctx.Request.Context(), *userId, postId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -217,7 +273,7 @@ func (c *postController) LikePost(ctx *gin.Context) {
 		Synergy: synergy,
 	})
 
-	go c.postAnalytics.RecordPostVote(postId, *userId, +1)
+	background.Go(func() { c.postAnalytics.RecordPostVote(postId, *userId, +1) })
 }
 
 func (c *postController) DislikePost(ctx *gin.Context) {
@@ -231,7 +287,7 @@ func (c *postController) DislikePost(ctx *gin.Context) {
 		return
 	}
 	userId := c.MustGetUserId(ctx)
-	isDisliked, synergy, err := c.postService.DislikePost(*userId, postId)
+	isDisliked, synergy, err := c.postService.DislikePost(ctx.Request.Context(), *userId, postId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -242,7 +298,7 @@ func (c *postController) DislikePost(ctx *gin.Context) {
 		Synergy:    synergy,
 	})
 
-	go c.postAnalytics.RecordPostVote(postId, *userId, -1)
+	background.Go(func() { c.postAnalytics.RecordPostVote(postId, *userId, -1) })
 }
 
 func (c *postController) SavePost(ctx *gin.Context) {
@@ -256,14 +312,14 @@ func (c *postController) SavePost(ctx *gin.Context) {
 		return
 	}
 	userId := c.MustGetUserId(ctx)
-	err := c.postService.SavePost(*userId, postId)
+	err := c.postService.SavePost(ctx.Request.Context(), *userId, postId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
 	}
 	c.Send(ctx).SuccessMsgResponse("Post saved successfully")
 
-	go c.postAnalytics.RecordPostSave(postId, *userId)
+	background.Go(func() { c.postAnalytics.RecordPostSave(postId, *userId) })
 }
 
 func (c *postController) SharePost(ctx *gin.Context) {
@@ -278,14 +334,14 @@ func (c *postController) SharePost(ctx *gin.Context) {
 	}
 
 	userId := c.MustGetUserId(ctx)
-	err := c.postService.SharePost(*userId, postId)
+	err := c.postService.SharePost(ctx.Request.Context(), *userId, postId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
 	}
 	c.Send(ctx).SuccessMsgResponse("Post shared successfully")
 
-	go c.postAnalytics.RecordPostShare(postId, *userId)
+	background.Go(func() { c.postAnalytics.RecordPostShare(postId, *userId) })
 }
 
 func (c *postController) UserPosts(ctx *gin.Context) {
@@ -294,7 +350,7 @@ func (c *postController) UserPosts(ctx *gin.Context) {
 	if err != nil {
 		return
 	}
-	posts, numberPosts, err := c.postService.GetPostsByUserId(*userId, body.Page, body.Limit)
+	posts, numberPosts, err := c.postService.GetPostsByUserId(ctx.Request.Context(), *userId, body.Page, body.Limit)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -309,7 +365,7 @@ func (c *postController) UserPosts(ctx *gin.Context) {
 	c.Send(ctx).SuccessDataResponse("User posts retrieved successfully", dto.NewGetUserPostResponse(postsValue, body.Page, body.Limit, numberPosts))
 
 	for _, post := range posts {
-		go c.postAnalytics.RecordPostView(post.PostId, *userId)
+		background.Go(func() { c.postAnalytics.RecordPostView(post.PostId, *userId) })
 	}
 }
 
@@ -319,7 +375,7 @@ func (c *postController) UserFeedPosts(ctx *gin.Context) {
 	if err != nil {
 		return
 	}
-	posts, err := c.postService.GetUserFeedPosts(*userId, body.Page, body.Limit)
+	posts, page, err := c.postService.GetUserFeedPosts(ctx.Request.Context(), *userId, body.Cursor, body.Limit)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -331,11 +387,12 @@ func (c *postController) UserFeedPosts(ctx *gin.Context) {
 			postsValue[i] = *post
 		}
 	}
-	c.Send(ctx).SuccessDataResponse("User feed posts retrieved successfully", dto.NewGetUserFeedPostResponse(postsValue, body.Page, body.Limit))
+	c.Send(ctx).SuccessDataResponse("User feed posts retrieved successfully", dto.NewGetUserFeedPostResponse(postsValue, page.NextCursor, page.HasMore))
 
+	viewCtx := context.WithoutCancel(ctx.Request.Context())
 	for _, post := range posts {
-		go c.postService.RecordPostView(post.ID, *userId)
-		go c.postAnalytics.RecordPostView(post.ID, *userId)
+		background.Go(func() { c.postService.RecordPostView(viewCtx, post.ID, *userId) })
+		background.Go(func() { c.postAnalytics.RecordPostView(post.ID, *userId) })
 	}
 }
 
@@ -346,7 +403,7 @@ func (c *postController) TrendingPosts(ctx *gin.Context) {
 	}
 
 	userId := c.MustGetUserId(ctx)
-	posts, err := c.postService.GetTrendingPosts(*userId, body.Page, body.Limit)
+	posts, err := c.postService.GetTrendingPosts(ctx.Request.Context(), *userId, body.Page, body.Limit)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -360,9 +417,10 @@ func (c *postController) TrendingPosts(ctx *gin.Context) {
 	}
 	c.Send(ctx).SuccessDataResponse("Trending posts retrieved successfully", dto.NewGetTrendingPostResponse(postsValue, body.Page, body.Limit))
 
+	viewCtx := context.WithoutCancel(ctx.Request.Context())
 	for _, post := range posts {
-		go c.postService.RecordPostView(post.ID, *userId)
-		go c.postAnalytics.RecordPostView(post.ID, *c.MustGetUserId(ctx))
+		background.Go(func() { c.postService.RecordPostView(viewCtx, post.ID, *userId) })
+		background.Go(func() { c.postAnalytics.RecordPostView(post.ID, *userId) })
 	}
 }
 
@@ -373,7 +431,7 @@ func (c *postController) PopularPosts(ctx *gin.Context) {
 	}
 
 	userId := c.MustGetUserId(ctx)
-	posts, err := c.postService.GetPopularPosts(*userId, body.Page, body.Limit)
+	posts, err := c.postService.GetPopularPosts(ctx.Request.Context(), *userId, body.Page, body.Limit)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -387,9 +445,10 @@ func (c *postController) PopularPosts(ctx *gin.Context) {
 	}
 	c.Send(ctx).SuccessDataResponse("Popular posts retrieved successfully", dto.NewGetPopularPostResponse(postsValue, body.Page, body.Limit))
 
+	viewCtx := context.WithoutCancel(ctx.Request.Context())
 	for _, post := range posts {
-		go c.postService.RecordPostView(post.ID, *userId)
-		go c.postAnalytics.RecordPostView(post.ID, *userId)
+		background.Go(func() { c.postService.RecordPostView(viewCtx, post.ID, *userId) })
+		background.Go(func() { c.postAnalytics.RecordPostView(post.ID, *userId) })
 	}
 }
 
@@ -399,7 +458,7 @@ func (c *postController) UserSavedPosts(ctx *gin.Context) {
 	if err != nil {
 		return
 	}
-	posts, err := c.postService.GetUserSavedPosts(*userId, body.Page, body.Limit)
+	posts, err := c.postService.GetUserSavedPosts(ctx.Request.Context(), *userId, body.Page, body.Limit)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -428,7 +487,7 @@ func (c *postController) GetCommunityPosts(ctx *gin.Context) {
 	if err != nil {
 		return
 	}
-	posts, numberPosts, err := c.postService.GetPostsByCommunityId(communityId, body.Page, body.Limit)
+	posts, numberPosts, err := c.postService.GetPostsByCommunityId(ctx.Request.Context(), communityId, body.Page, body.Limit)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -443,9 +502,10 @@ func (c *postController) GetCommunityPosts(ctx *gin.Context) {
 	c.Send(ctx).SuccessDataResponse("Community posts retrieved successfully", dto.NewGetCommunityPostResponse(postsValue, body.Page, body.Limit, numberPosts))
 
 	userId := c.MustGetUserId(ctx)
+	viewCtx := context.WithoutCancel(ctx.Request.Context())
 	for _, post := range posts {
-		go c.postService.RecordPostView(post.PostId, *userId)
-		go c.postAnalytics.RecordPostView(post.PostId, *userId)
+		background.Go(func() { c.postService.RecordPostView(viewCtx, post.PostId, *userId) })
+		background.Go(func() { c.postAnalytics.RecordPostView(post.PostId, *userId) })
 	}
 }
 
@@ -456,7 +516,7 @@ func (c *postController) StickyPost(ctx *gin.Context) {
 		return
 	}
 	userId := c.MustGetUserId(ctx)
-	newValue, err := c.postService.ToggleStickyPost(*userId, postId)
+	newValue, err := c.postService.ToggleStickyPost(ctx.Request.Context(), *userId, postId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -471,7 +531,7 @@ func (c *postController) LockPost(ctx *gin.Context) {
 		return
 	}
 	userId := c.MustGetUserId(ctx)
-	newValue, err := c.postService.ToggleLockPost(*userId, postId)
+	newValue, err := c.postService.ToggleLockPost(ctx.Request.Context(), *userId, postId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -486,7 +546,7 @@ func (c *postController) ArchivePost(ctx *gin.Context) {
 		return
 	}
 	userId := c.MustGetUserId(ctx)
-	newValue, err := c.postService.ToggleArchivePost(*userId, postId)
+	newValue, err := c.postService.ToggleArchivePost(ctx.Request.Context(), *userId, postId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -501,10 +561,56 @@ func (c *postController) MarkNSFW(ctx *gin.Context) {
 		return
 	}
 	userId := c.MustGetUserId(ctx)
-	newValue, err := c.postService.ToggleNSFWPost(*userId, postId)
+	newValue, err := c.postService.ToggleNSFWPost(ctx.Request.Context(), *userId, postId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
 	}
 	c.Send(ctx).SuccessDataResponse("Post NSFW toggled successfully", dto.NewTogglePostResponse(postId, "isNSFW", newValue))
 }
+
+func (c *postController) SetPostReaction(ctx *gin.Context) {
+	postId := ctx.Param("postId")
+	body, err := network.ReqBody(ctx, common.NewSetReactionRequest())
+	if err != nil {
+		return
+	}
+
+	userId := c.MustGetUserId(ctx)
+	reaction, err := c.postService.SetPostReaction(ctx.Request.Context(), *userId, postId, body.Reaction)
+	if err != nil {
+		c.Send(ctx).MixedError(err)
+		return
+	}
+
+	c.Send(ctx).SuccessDataResponse("Reaction set successfully", reaction)
+}
+
+func (c *postController) RemovePostReaction(ctx *gin.Context) {
+	postId := ctx.Param("postId")
+	userId := c.MustGetUserId(ctx)
+
+	reaction, err := c.postService.RemovePostReaction(ctx.Request.Context(), *userId, postId)
+	if err != nil {
+		c.Send(ctx).MixedError(err)
+		return
+	}
+
+	c.Send(ctx).SuccessDataResponse("Reaction removed successfully", reaction)
+}
+
+func (c *postController) GetPostReactors(ctx *gin.Context) {
+	postId := ctx.Param("postId")
+	params, err := network.ReqQuery(ctx, common.NewGetReactorsRequest())
+	if err != nil {
+		return
+	}
+
+	reactors, page, err := c.postService.GetPostReactors(ctx.Request.Context(), postId, params.Reaction, params.Cursor, params.Limit)
+	if err != nil {
+		c.Send(ctx).MixedError(err)
+		return
+	}
+
+	c.Send(ctx).SuccessDataResponse("Reactions retrieved successfully", coredto.NewCursorPage(reactors, page.NextCursor, page.HasMore))
+}
@@ -16,6 +16,7 @@ type CreatePostRequest struct {
 	Content     string                  `form:"content" json:"content" binding:"required" validate:"required,min=1,max=10000"`
 	Tags        []string                `form:"tags,omitempty" json:"tags"`
 	Media       *[]multipart.FileHeader `form:"media" json:"media" binding:"omitempty" validate:"dive"`
+	MediaIds    []string                `form:"mediaIds" json:"mediaIds" validate:"omitempty,max=10,dive,required"`
 	CommunityId string                  `form:"communityId" json:"communityId" binding:"required" validate:"required"`
 	Type        model.PostType          `form:"type" json:"type" binding:"required" validate:"required,oneof=TEXT IMAGE VIDEO"`
 	IsNSFW      bool                    `form:"isNSFW,omitempty" json:"isNSFW"`
@@ -39,7 +40,11 @@ func (r *CreatePostRequest) ValidateErrors(errs validator.ValidationErrors) ([]s
 		case "min":
 			msgs = append(msgs, err.Field()+" must be at least "+err.Param()+" characters")
 		case "max":
-			msgs = append(msgs, err.Field()+" must be at most "+err.Param()+" characters")
+			if err.Field() == "MediaIds" {
+				msgs = append(msgs, err.Field()+" must have at most "+err.Param()+" items")
+			} else {
+				msgs = append(msgs, err.Field()+" must be at most "+err.Param()+" characters")
+			}
 		case "oneof":
 			msgs = append(msgs, err.Field()+" must be one of "+err.Param())
 		case "dive":
@@ -13,12 +13,12 @@ import (
 // ============================================
 
 type GetUserFeedPostRequest struct {
-	coredto.Pagination
+	coredto.CursorPagination
 }
 
 func NewGetUserFeedPostRequest() *GetUserFeedPostRequest {
 	return &GetUserFeedPostRequest{
-		Pagination: *coredto.NewPagination(),
+		CursorPagination: *coredto.NewCursorPagination(),
 	}
 }
 
@@ -42,19 +42,13 @@ func (s *GetUserFeedPostRequest) ValidateErrors(errs validator.ValidationErrors)
 // ============================================
 
 type GetUserFeedPostResponse struct {
-	Posts []model.FeedPost `json:"posts"`
-	coredto.Pagination
+	coredto.CursorPage[model.FeedPost]
 }
 
-func NewGetUserFeedPostResponse(posts []model.FeedPost, page int, limit int) *GetUserFeedPostResponse {
+func NewGetUserFeedPostResponse(posts []model.FeedPost, nextCursor string, hasMore bool) *GetUserFeedPostResponse {
 	return &GetUserFeedPostResponse{
-		Posts: posts,
-		Pagination: coredto.Pagination{
-			Page:  page,
-			Limit: limit,
-		},
+		CursorPage: *coredto.NewCursorPage(posts, nextCursor, hasMore),
 	}
-
 }
 
 func (l *GetUserFeedPostResponse) GetValue() *GetUserFeedPostResponse {
@@ -45,3 +45,11 @@ func NewMediaError(action, extra string) network.ApiError {
 		nil,
 	)
 }
+
+func NewInvalidCursorError(cursor string) network.ApiError {
+	return network.NewBadRequestError(
+		"Invalid Cursor",
+		fmt.Sprintf("The pagination cursor is malformed or has expired. Start again from the first page. [Context: cursor=%s]", cursor),
+		nil,
+	)
+}
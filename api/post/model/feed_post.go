@@ -11,6 +11,7 @@ type FeedPost struct {
 	Title        string                `json:"title" bson:"title"`
 	Content      string                `json:"content" bson:"content"`
 	AuthorId     string                `json:"authorId" bson:"authorId"`
+	CommunityId  string                `json:"communityId" bson:"communityId"`
 	Community    model.PublicCommunity `json:"community" bson:"community"`
 	Type         PostType              `json:"type" bson:"type"`
 	Status       PostStatus            `json:"status" bson:"status"`
@@ -18,6 +19,7 @@ type FeedPost struct {
 	Synergy      int                   `json:"synergy" bson:"synergy"`
 	IsLiked      bool                  `json:"isLiked" bson:"isLiked"`
 	IsDisliked   bool                  `json:"isDisliked" bson:"isDisliked"`
+	IsSeen       bool                  `json:"isSeen" bson:"isSeen"`
 	CommentCount int                   `json:"commentCount" bson:"commentCount"`
 	ViewCount    int                   `json:"viewCount" bson:"viewCount"`
 	ShareCount   int                   `json:"shareCount" bson:"shareCount"`
@@ -28,4 +30,8 @@ type FeedPost struct {
 	IsLocked     bool                  `json:"isLocked" bson:"isLocked"`
 	CreatedAt    primitive.DateTime    `json:"createdAt" bson:"createdAt"`
 	UpdatedAt    primitive.DateTime    `json:"updatedAt" bson:"updatedAt"`
+
+	// Ranking inputs of the home feed, copied from the post analytics
+	HotScore      float64 `json:"-" bson:"hotScore"`
+	TrendingScore float64 `json:"-" bson:"trendingScore"`
 }
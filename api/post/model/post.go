@@ -2,6 +2,8 @@ package model
 
 import (
 	"context"
+	mediaModels "sync-backend/api/common/media/model"
+	"sync-backend/arch/common"
 	"sync-backend/arch/mongo"
 	"time"
 
@@ -17,32 +19,36 @@ const PostCollectionName = "posts"
 
 // Post represents a user post in the system, similar to a Reddit post
 type Post struct {
-	Id             primitive.ObjectID  `bson:"_id,omitempty" json:"-"`
-	PostId         string              `bson:"postId" json:"id"`
-	Title          string              `bson:"title" json:"title" validate:"required,min=1,max=300"`
-	Content        string              `bson:"content" json:"content"`
-	AuthorId       string              `bson:"authorId" json:"authorId" validate:"required"`
-	CommunityId    string              `bson:"communityId" json:"communityId" validate:"required"`
-	Type           PostType            `bson:"type" json:"type" validate:"required,oneof=text image video link poll gallery"`
-	Status         PostStatus          `bson:"status" json:"status"`
-	Media          []Media             `bson:"media,omitempty" json:"media,omitempty"`
-	Tags           []string            `bson:"tags,omitempty" json:"tags,omitempty"`
-	Synergy        int                 `bson:"synergy" json:"synergy"`
-	CommentCount   int                 `bson:"commentCount" json:"commentCount"`
-	ViewCount      int                 `bson:"viewCount" json:"viewCount"`
-	ShareCount     int                 `bson:"shareCount" json:"shareCount"`
-	SaveCount      int                 `bson:"saveCount" json:"saveCount"`
-	Voters         map[string]VoteType `bson:"voters,omitempty" json:"voters,omitempty"`
-	IsNSFW         bool                `bson:"isNSFW" json:"isNSFW"`
-	IsSpoiler      bool                `bson:"isSpoiler" json:"isSpoiler"`
-	IsStickied     bool                `bson:"isStickied" json:"isStickied"`
-	IsLocked       bool                `bson:"isLocked" json:"isLocked"`
-	IsArchived     bool                `bson:"isArchived" json:"isArchived"`
-	Analytics      *PostAnalytics      `bson:"analytics,omitempty" json:"analytics,omitempty"`
-	CreatedAt      primitive.DateTime  `bson:"createdAt" json:"createdAt"`
-	UpdatedAt      primitive.DateTime  `bson:"updatedAt" json:"updatedAt"`
-	DeletedAt      *primitive.DateTime `bson:"deletedAt,omitempty" json:"-"`
-	LastActivityAt primitive.DateTime  `bson:"lastActivityAt" json:"lastActivityAt"`
+	Id             primitive.ObjectID   `bson:"_id,omitempty" json:"-"`
+	PostId         string               `bson:"postId" json:"id"`
+	Title          string               `bson:"title" json:"title" validate:"required,min=1,max=300"`
+	Content        string               `bson:"content" json:"content"`
+	AuthorId       string               `bson:"authorId" json:"authorId" validate:"required"`
+	CommunityId    string               `bson:"communityId" json:"communityId" validate:"required"`
+	Type           PostType             `bson:"type" json:"type" validate:"required,oneof=text image video link poll gallery"`
+	Status         PostStatus           `bson:"status" json:"status"`
+	Media          []Media              `bson:"media,omitempty" json:"media,omitempty"`
+	Tags           []string             `bson:"tags,omitempty" json:"tags,omitempty"`
+	Synergy        int                  `bson:"synergy" json:"synergy"`
+	CommentCount   int                  `bson:"commentCount" json:"commentCount"`
+	ViewCount      int                  `bson:"viewCount" json:"viewCount"`
+	ShareCount     int                  `bson:"shareCount" json:"shareCount"`
+	SaveCount      int                  `bson:"saveCount" json:"saveCount"`
+	Voters         map[string]VoteType  `bson:"voters,omitempty" json:"voters,omitempty"`
+	ReactionCounts map[ReactionType]int `bson:"reactionCounts,omitempty" json:"reactionCounts,omitempty"` // Count by reaction type
+	IsNSFW         bool                 `bson:"isNSFW" json:"isNSFW"`
+	IsSpoiler      bool                 `bson:"isSpoiler" json:"isSpoiler"`
+	IsStickied     bool                 `bson:"isStickied" json:"isStickied"`
+	IsLocked       bool                 `bson:"isLocked" json:"isLocked"`
+	IsArchived     bool                 `bson:"isArchived" json:"isArchived"`
+	IsEdited       bool                 `bson:"isEdited" json:"isEdited"`
+	LastEditedAt   *primitive.DateTime  `bson:"lastEditedAt,omitempty" json:"lastEditedAt,omitempty"`
+	EditHistory    []PostEdit           `bson:"editHistory,omitempty" json:"-"` // Track edits for moderation purposes
+	Analytics      *PostAnalytics       `bson:"analytics,omitempty" json:"analytics,omitempty"`
+	CreatedAt      primitive.DateTime   `bson:"createdAt" json:"createdAt"`
+	UpdatedAt      primitive.DateTime   `bson:"updatedAt" json:"updatedAt"`
+	DeletedAt      *primitive.DateTime  `bson:"deletedAt,omitempty" json:"-"`
+	LastActivityAt primitive.DateTime   `bson:"lastActivityAt" json:"lastActivityAt"`
 }
 
 // PostType defines the type of post
@@ -66,6 +72,9 @@ const (
 	PostStatusArchived PostStatus = "archived"
 )
 
+// ReactionType defines the type of reaction to a post, shared with comments
+type ReactionType = common.ReactionType
+
 // VoteType represents the type of vote a user has cast on a post
 type VoteType int
 
@@ -84,6 +93,10 @@ type Media struct {
 	Height    int                `bson:"height,omitempty" json:"height,omitempty"`
 	FileSize  int64              `bson:"fileSize,omitempty" json:"fileSize,omitempty"`
 	CreatedAt primitive.DateTime `bson:"createdAt" json:"createdAt"`
+
+	Renditions    []mediaModels.ImageRendition `bson:"renditions,omitempty" json:"renditions,omitempty"`
+	BlurHash      string                       `bson:"blurHash,omitempty" json:"blurHash,omitempty"`
+	DominantColor string                       `bson:"dominantColor,omitempty" json:"dominantColor,omitempty"`
 }
 
 // MediaType defines the type of media
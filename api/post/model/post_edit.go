@@ -0,0 +1,28 @@
+package model
+
+import (
+	"sync-backend/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PostEdit represents a record of an edit made to a post, Title and Content hold the values before the edit
+type PostEdit struct {
+	EditorId string             `bson:"editorId" json:"editorId"`
+	Title    string             `bson:"title" json:"previousTitle"`
+	Content  string             `bson:"content" json:"previousContent"`
+	DiffSize int                `bson:"diffSize" json:"diffSize"`
+	EditedAt primitive.DateTime `bson:"editedAt" json:"editedAt"`
+}
+
+// NewPostEdit creates a revision record for an edit of a post's title and content
+func NewPostEdit(editorId string, previousTitle string, previousContent string, newTitle string, newContent string) *PostEdit {
+	return &PostEdit{
+		EditorId: editorId,
+		Title:    previousTitle,
+		Content:  previousContent,
+		DiffSize: utils.DiffSize(previousTitle, newTitle) + utils.DiffSize(previousContent, newContent),
+		EditedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+}
@@ -21,7 +21,8 @@ type PostInteraction struct {
 	InteractionId   string              `bson:"interactionId" json:"interactionId"`
 	PostId          string              `bson:"postId" json:"postId" validate:"required"`
 	UserId          string              `bson:"userId" json:"userId" validate:"required"`
-	InteractionType InteractionType     `bson:"interactionType" json:"interactionType" validate:"required,oneof=like dislike save share comment"`
+	InteractionType InteractionType     `bson:"interactionType" json:"interactionType" validate:"required,oneof=like dislike view save share comment reaction"`
+	Reaction        ReactionType        `bson:"reaction,omitempty" json:"reaction,omitempty" validate:"required_if=InteractionType reaction"`
 	CreatedAt       primitive.DateTime  `bson:"createdAt" json:"createdAt"`
 	UpdatedAt       primitive.DateTime  `bson:"updatedAt" json:"updatedAt"`
 	DeletedAt       *primitive.DateTime `bson:"deletedAt,omitempty" json:"-"`
@@ -36,6 +37,8 @@ const (
 	InteractionTypeView    InteractionType = "view"
 	InteractionTypeSave    InteractionType = "save"
 	InteractionTypeShare   InteractionType = "share"
+	// A user holds at most one reaction per post, enforced by idx_post_interaction_unique
+	InteractionTypeReaction InteractionType = "reaction"
 )
 
 func NewPostInteraction(userId string, postId string, interactionType InteractionType) *PostInteraction {
@@ -49,6 +52,12 @@ func NewPostInteraction(userId string, postId string, interactionType Interactio
 	}
 }
 
+func NewPostReaction(userId string, postId string, reaction ReactionType) *PostInteraction {
+	interaction := NewPostInteraction(userId, postId, InteractionTypeReaction)
+	interaction.Reaction = reaction
+	return interaction
+}
+
 func (p *PostInteraction) GetValue() *PostInteraction {
 	return p
 }
@@ -106,6 +115,15 @@ func (*PostInteraction) EnsureIndexes(db mongo.Database) {
 			},
 			Options: options.Index().SetName("idx_post_interaction_deleted").SetExpireAfterSeconds(0),
 		},
+		{
+			Keys: bson.D{
+				{Key: "postId", Value: 1},
+				{Key: "interactionType", Value: 1},
+				{Key: "reaction", Value: 1},
+				{Key: "createdAt", Value: -1},
+			},
+			Options: options.Index().SetName("idx_post_interaction_reaction"),
+		},
 	}
 	mongo.NewQueryBuilder[PostInteraction](db, PostInteractionCollectionName).Query(context.Background()).CheckIndexes(indexes)
 }
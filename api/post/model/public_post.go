@@ -7,32 +7,65 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// PostCacheTag tags every cached view of a post, invalidating it drops them after a write
+func PostCacheTag(postId string) string {
+	return "post:" + postId
+}
+
+// EmbeddedCacheTags tags a cached post with the author and community it embeds, so renaming either
+// drops the copies held in the post
+func EmbeddedCacheTags(value any) []string {
+	post, ok := value.(*PublicPost)
+	if !ok || post == nil {
+		return nil
+	}
+	var tags []string
+	if post.Author.UserId != "" {
+		tags = append(tags, user.UserCacheTag(post.Author.UserId))
+	}
+	if post.Community.Id != "" {
+		tags = append(tags, community.CommunityCacheTag(post.Community.Id))
+	}
+	return tags
+}
+
 type PublicPost struct {
-	Id           string                    `json:"id"`
-	Title        string                    `json:"title"`
-	Content      string                    `json:"content"`
-	Author       user.PublicUser           `json:"author"`
-	Community    community.PublicCommunity `json:"community"`
-	Type         PostType                  `json:"type"`
-	Status       PostStatus                `json:"status"`
-	Media        []Media                   `json:"media,omitempty"`
-	Tags         []string                  `json:"tags,omitempty"`
-	Synergy      int                       `json:"synergy"`
-	CommentCount int                       `json:"commentCount"`
-	ViewCount    int                       `json:"viewCount"`
-	ShareCount   int                       `json:"shareCount"`
-	SaveCount    int                       `json:"saveCount"`
-	Voters       map[string]VoteType       `json:"voters,omitempty"`
-	IsNSFW       bool                      `json:"isNSFW"`
-	IsSpoiler    bool                      `json:"isSpoiler"`
-	IsStickied   bool                      `json:"isStickied"`
-	IsLocked     bool                      `json:"isLocked"`
-	IsArchived   bool                      `json:"isArchived"`
-	IsLiked      bool                      `json:"isLiked"`
-	IsDisliked   bool                      `json:"isDisliked"`
-	CreatedAt    primitive.DateTime        `json:"createdAt"`
+	Id             string                    `json:"id"`
+	Title          string                    `json:"title"`
+	Content        string                    `json:"content"`
+	Author         user.PublicUser           `json:"author"`
+	Community      community.PublicCommunity `json:"community"`
+	Type           PostType                  `json:"type"`
+	Status         PostStatus                `json:"status"`
+	Media          []Media                   `json:"media,omitempty"`
+	Tags           []string                  `json:"tags,omitempty"`
+	Synergy        int                       `json:"synergy"`
+	CommentCount   int                       `json:"commentCount"`
+	ViewCount      int                       `json:"viewCount"`
+	ShareCount     int                       `json:"shareCount"`
+	SaveCount      int                       `json:"saveCount"`
+	Voters         map[string]VoteType       `json:"voters,omitempty"`
+	ReactionCounts map[ReactionType]int      `json:"reactionCounts,omitempty"`
+	MyReaction     ReactionType              `json:"myReaction,omitempty"`
+	IsNSFW         bool                      `json:"isNSFW"`
+	IsSpoiler      bool                      `json:"isSpoiler"`
+	IsStickied     bool                      `json:"isStickied"`
+	IsLocked       bool                      `json:"isLocked"`
+	IsArchived     bool                      `json:"isArchived"`
+	IsEdited       bool                      `json:"isEdited"`
+	LastEditedAt   *primitive.DateTime       `json:"lastEditedAt,omitempty"`
+	IsLiked        bool                      `json:"isLiked"`
+	IsDisliked     bool                      `json:"isDisliked"`
+	CreatedAt      primitive.DateTime        `json:"createdAt"`
 }
 
 func (p *PublicPost) IsActive() bool {
 	return p.Status == PostStatusActive
 }
+
+// PublicPostReactor is a single entry of the "who reacted" listing of a post
+type PublicPostReactor struct {
+	User      user.PublicUser    `json:"user"`
+	Reaction  ReactionType       `json:"reaction"`
+	ReactedAt primitive.DateTime `json:"reactedAt"`
+}
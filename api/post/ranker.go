@@ -0,0 +1,135 @@
+package post
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"sync-backend/api/post/model"
+	"sync-backend/arch/config"
+)
+
+// hotEpoch is the reference time of the hot score computed by the post analytics
+const hotEpoch = 1134028003
+
+// FeedSignals are the per-user inputs of the home feed ranking
+type FeedSignals struct {
+	FollowedAuthors map[string]bool    // Authors followed by the user
+	TagAffinity     map[string]float64 // Tag weights between 0 and 1 from the posts the user liked
+	GeneratedAt     time.Time          // Time the feed snapshot was taken
+}
+
+// FeedRanker orders feed candidates for a user
+type FeedRanker interface {
+	Rank(posts []*model.FeedPost, signals FeedSignals) []*model.FeedPost
+}
+
+type feedRanker struct {
+	config config.FeedConfig
+}
+
+func NewFeedRanker(config config.FeedConfig) FeedRanker {
+	if config.DiversityWindow <= 0 {
+		config.DiversityWindow = 10
+	}
+	return &feedRanker{config: config}
+}
+
+type rankedPost struct {
+	post  *model.FeedPost
+	score float64
+}
+
+// Rank scores the posts, sorts them by score and spreads communities so no more than
+// MaxPerCommunity posts of a community appear in any DiversityWindow consecutive posts
+func (r *feedRanker) Rank(posts []*model.FeedPost, signals FeedSignals) []*model.FeedPost {
+	ranked := make([]rankedPost, 0, len(posts))
+	for _, post := range posts {
+		if post == nil {
+			continue
+		}
+		ranked = append(ranked, rankedPost{post: post, score: r.score(post, signals)})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		if ranked[i].post.CreatedAt != ranked[j].post.CreatedAt {
+			return ranked[i].post.CreatedAt > ranked[j].post.CreatedAt
+		}
+		return ranked[i].post.ID > ranked[j].post.ID
+	})
+
+	return r.diversify(ranked)
+}
+
+func (r *feedRanker) score(post *model.FeedPost, signals FeedSignals) float64 {
+	score := r.config.HotWeight*r.relativeHotScore(post, signals.GeneratedAt) +
+		r.config.TrendingWeight*post.TrendingScore
+
+	if signals.FollowedAuthors[post.AuthorId] {
+		score += r.config.FollowedAuthorBoost
+	}
+
+	affinity := 0.0
+	for _, tag := range post.Tags {
+		affinity = math.Max(affinity, signals.TagAffinity[tag])
+	}
+	score += r.config.TagAffinityWeight * affinity
+
+	if post.IsSeen {
+		score -= r.config.SeenPenalty
+	}
+	return score
+}
+
+// relativeHotScore is the hot score measured against a post created at the snapshot time with no votes,
+// posts without analytics fall back to the same formula on their synergy
+func (r *feedRanker) relativeHotScore(post *model.FeedPost, generatedAt time.Time) float64 {
+	hot := post.HotScore
+	if hot == 0 {
+		order := math.Log10(math.Max(math.Abs(float64(post.Synergy)), 1))
+		sign := 0.0
+		if post.Synergy > 0 {
+			sign = 1.0
+		} else if post.Synergy < 0 {
+			sign = -1.0
+		}
+		hot = sign*order + float64(post.CreatedAt.Time().Unix()-hotEpoch)/45000.0
+	}
+	return hot - float64(generatedAt.Unix()-hotEpoch)/45000.0
+}
+
+// diversify greedily picks the best post that keeps its community under the cap within the window,
+// the best remaining post is taken when every candidate is capped
+func (r *feedRanker) diversify(ranked []rankedPost) []*model.FeedPost {
+	result := make([]*model.FeedPost, 0, len(ranked))
+	if r.config.MaxPerCommunity <= 0 {
+		for _, item := range ranked {
+			result = append(result, item.post)
+		}
+		return result
+	}
+
+	remaining := ranked
+	for len(remaining) > 0 {
+		windowStart := max(len(result)-r.config.DiversityWindow+1, 0)
+		counts := make(map[string]int)
+		for _, post := range result[windowStart:] {
+			counts[post.CommunityId]++
+		}
+
+		pick := 0
+		for i, item := range remaining {
+			if counts[item.post.CommunityId] < r.config.MaxPerCommunity {
+				pick = i
+				break
+			}
+		}
+
+		result = append(result, remaining[pick].post)
+		remaining = append(remaining[:pick], remaining[pick+1:]...)
+	}
+	return result
+}
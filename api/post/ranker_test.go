@@ -0,0 +1,77 @@
+package post
+
+import (
+	"testing"
+	"time"
+
+	"sync-backend/api/post/model"
+	"sync-backend/arch/config"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func feedPost(id string, communityId string, synergy int, createdAt time.Time) *model.FeedPost {
+	return &model.FeedPost{
+		ID:          id,
+		AuthorId:    "author-" + id,
+		CommunityId: communityId,
+		Synergy:     synergy,
+		CreatedAt:   primitive.NewDateTimeFromTime(createdAt),
+	}
+}
+
+func ids(posts []*model.FeedPost) []string {
+	result := make([]string, len(posts))
+	for i, post := range posts {
+		result[i] = post.ID
+	}
+	return result
+}
+
+func TestFeedRanker_BoostsFollowedAndDemotesSeen(t *testing.T) {
+	now := time.Now()
+	ranker := NewFeedRanker(config.FeedConfig{HotWeight: 1, FollowedAuthorBoost: 2, SeenPenalty: 5})
+
+	popular := feedPost("popular", "c1", 50, now)
+	followed := feedPost("followed", "c1", 1, now)
+	seen := feedPost("seen", "c1", 1000, now)
+	seen.IsSeen = true
+
+	ranked := ranker.Rank([]*model.FeedPost{seen, popular, followed}, FeedSignals{
+		FollowedAuthors: map[string]bool{"author-followed": true},
+		GeneratedAt:     now,
+	})
+	assert.Equal(t, []string{"followed", "popular", "seen"}, ids(ranked))
+}
+
+func TestFeedRanker_TagAffinity(t *testing.T) {
+	now := time.Now()
+	ranker := NewFeedRanker(config.FeedConfig{HotWeight: 1, TagAffinityWeight: 2})
+
+	plain := feedPost("plain", "c1", 10, now)
+	tagged := feedPost("tagged", "c1", 1, now)
+	tagged.Tags = []string{"golang"}
+
+	ranked := ranker.Rank([]*model.FeedPost{plain, tagged}, FeedSignals{
+		TagAffinity: map[string]float64{"golang": 1},
+		GeneratedAt: now,
+	})
+	assert.Equal(t, []string{"tagged", "plain"}, ids(ranked))
+}
+
+func TestFeedRanker_CapsCommunityWithinWindow(t *testing.T) {
+	now := time.Now()
+	ranker := NewFeedRanker(config.FeedConfig{HotWeight: 1, MaxPerCommunity: 2, DiversityWindow: 3})
+
+	posts := []*model.FeedPost{
+		feedPost("a1", "a", 1000, now),
+		feedPost("a2", "a", 900, now),
+		feedPost("a3", "a", 800, now),
+		feedPost("a4", "a", 700, now),
+		feedPost("b1", "b", 10, now),
+	}
+
+	ranked := ranker.Rank(posts, FeedSignals{GeneratedAt: now})
+	assert.Equal(t, []string{"a1", "a2", "b1", "a3", "a4"}, ids(ranked))
+}
@@ -0,0 +1,76 @@
+package post
+
+import (
+	"context"
+	"testing"
+
+	"sync-backend/api/post/model"
+	"sync-backend/arch/common"
+	"sync-backend/arch/mongo"
+	"sync-backend/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func newReactionSession(t *testing.T) *mongo.MockTransactionSession {
+	session := mongo.NewMockTransactionSession()
+	_, err := session.Collection(model.PostCollectionName).InsertOne(bson.M{"postId": "post-1"})
+	require.NoError(t, err)
+	return session
+}
+
+func postReactionCounts(t *testing.T, session *mongo.MockTransactionSession) map[model.ReactionType]int {
+	var post model.Post
+	require.NoError(t, session.Collection(model.PostCollectionName).FindOne(bson.M{"postId": "post-1"}).Decode(&post))
+	return post.ReactionCounts
+}
+
+func userPostReactions(session *mongo.MockTransactionSession, userId string) []model.ReactionType {
+	var reactions []model.ReactionType
+	for _, interaction := range session.Documents(model.PostInteractionCollectionName) {
+		if interaction["userId"] == userId {
+			reactions = append(reactions, model.ReactionType(interaction["reaction"].(string)))
+		}
+	}
+	return reactions
+}
+
+func TestApplyPostReactionSwapsTheSingleReaction(t *testing.T) {
+	ctx := context.Background()
+	s := &postService{logger: utils.NewServiceLogger("PostService")}
+	session := newReactionSession(t)
+
+	require.NoError(t, s.applyPostReaction(ctx, session, "user-1", "post-1", common.ReactionTypeLike))
+	require.NoError(t, s.applyPostReaction(ctx, session, "user-2", "post-1", common.ReactionTypeLike))
+	assert.Equal(t, map[model.ReactionType]int{common.ReactionTypeLike: 2}, postReactionCounts(t, session))
+
+	// Changing the reaction moves the count instead of adding a second reaction
+	require.NoError(t, s.applyPostReaction(ctx, session, "user-1", "post-1", common.ReactionTypeLove))
+	assert.Equal(t, []model.ReactionType{common.ReactionTypeLove}, userPostReactions(session, "user-1"))
+	assert.Equal(t, map[model.ReactionType]int{common.ReactionTypeLike: 1, common.ReactionTypeLove: 1}, postReactionCounts(t, session))
+
+	// Setting the held reaction again changes nothing
+	require.NoError(t, s.applyPostReaction(ctx, session, "user-1", "post-1", common.ReactionTypeLove))
+	assert.Len(t, session.Documents(model.PostInteractionCollectionName), 2)
+	assert.Equal(t, map[model.ReactionType]int{common.ReactionTypeLike: 1, common.ReactionTypeLove: 1}, postReactionCounts(t, session))
+}
+
+func TestClearPostReactionTakesItOffTheCounts(t *testing.T) {
+	ctx := context.Background()
+	s := &postService{logger: utils.NewServiceLogger("PostService")}
+	session := newReactionSession(t)
+
+	require.NoError(t, s.applyPostReaction(ctx, session, "user-1", "post-1", common.ReactionTypeWow))
+	require.NoError(t, s.applyPostReaction(ctx, session, "user-2", "post-1", common.ReactionTypeWow))
+
+	require.NoError(t, s.clearPostReaction(ctx, session, "user-1", "post-1"))
+	assert.Empty(t, userPostReactions(session, "user-1"))
+	assert.Equal(t, []model.ReactionType{common.ReactionTypeWow}, userPostReactions(session, "user-2"))
+	assert.Equal(t, map[model.ReactionType]int{common.ReactionTypeWow: 1}, postReactionCounts(t, session))
+
+	// Without a reaction there is nothing to take off
+	require.NoError(t, s.clearPostReaction(ctx, session, "user-1", "post-1"))
+	assert.Equal(t, map[model.ReactionType]int{common.ReactionTypeWow: 1}, postReactionCounts(t, session))
+}
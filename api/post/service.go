@@ -1,15 +1,25 @@
 package post
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"sync-backend/api/common/media"
+	"sync-backend/api/common/guard"
 	"sync-backend/api/community"
+	"sync-backend/api/media"
+	mediaModel "sync-backend/api/media/model"
 	"sync-backend/api/moderator"
 	moderatorModel "sync-backend/api/moderator/model"
 	"sync-backend/api/post/model"
 	"sync-backend/api/user"
+	"sync-backend/arch/background"
+	"sync-backend/arch/common"
+	"sync-backend/arch/config"
+	"sync-backend/arch/metrics"
 	"sync-backend/arch/mongo"
 	"sync-backend/arch/network"
+	"sync-backend/arch/redis"
+	"sync-backend/arch/tracing"
 	"sync-backend/utils"
 	"time"
 
@@ -19,105 +29,176 @@ import (
 )
 
 type PostService interface {
-	CreatePost(title string, content string, tags []string, media []string, userId string, communityId string, postType model.PostType, isNSFW bool, isSpoiler bool) (*model.Post, network.ApiError)
-	GetPost(postId string, userId string) (*model.PublicPost, network.ApiError)
-	RecordPostView(postId string, userId string) network.ApiError
-	EditPost(userId string, postId string, title *string, content *string, postType model.PostType, isNSFW *bool, isSpoiler *bool) (*string, network.ApiError)
-	DeletePost(userId string, postId string) network.ApiError
-
-	GetUserFeedPosts(userId string, page int, limit int) ([]*model.FeedPost, network.ApiError)
-	GetTrendingPosts(userId string, page int, limit int) ([]*model.FeedPost, network.ApiError)
-	GetPopularPosts(userId string, page int, limit int) ([]*model.FeedPost, network.ApiError)
-	GetUserSavedPosts(userId string, page int, limit int) ([]*model.FeedPost, network.ApiError)
-
-	LikePost(userId string, postId string) (*bool, *int, network.ApiError)
-	DislikePost(userId string, postId string) (*bool, *int, network.ApiError)
-	SavePost(userId string, postId string) network.ApiError
-	SharePost(userId string, postId string) network.ApiError
-
-	GetPostsByUserId(userId string, page int, limit int) (posts []*model.Post, numOfPosts int, err network.ApiError)
-	GetPostsByCommunityId(communityId string, page int, limit int) (posts []*model.Post, numOfPosts int, err network.ApiError)
+	CreatePost(ctx context.Context, title string, content string, tags []string, mediaIds []string, userId string, communityId string, postType model.PostType, isNSFW bool, isSpoiler bool) (*model.Post, network.ApiError)
+	GetPost(ctx context.Context, postId string, userId string) (*model.PublicPost, network.ApiError)
+	RecordPostView(ctx context.Context, postId string, userId string) network.ApiError
+	EditPost(ctx context.Context, userId string, postId string, title *string, content *string, postType model.PostType, isNSFW *bool, isSpoiler *bool) network.ApiError
+	GetPostRevisions(ctx context.Context, userId string, postId string) ([]model.PostEdit, network.ApiError)
+	DeletePost(ctx context.Context, userId string, postId string) network.ApiError
+
+	GetUserFeedPosts(ctx context.Context, userId string, cursor string, limit int) ([]*model.FeedPost, *mongo.CursorResult, network.ApiError)
+	GetTrendingPosts(ctx context.Context, userId string, page int, limit int) ([]*model.FeedPost, network.ApiError)
+	GetPopularPosts(ctx context.Context, userId string, page int, limit int) ([]*model.FeedPost, network.ApiError)
+	GetUserSavedPosts(ctx context.Context, userId string, page int, limit int) ([]*model.FeedPost, network.ApiError)
+
+	LikePost(ctx context.Context, userId string, postId string) (*bool, *int, network.ApiError)
+	DislikePost(ctx context.Context, userId string, postId string) (*bool, *int, network.ApiError)
+	SavePost(ctx context.Context, userId string, postId string) network.ApiError
+	SharePost(ctx context.Context, userId string, postId string) network.ApiError
+
+	GetPostsByUserId(ctx context.Context, userId string, page int, limit int) (posts []*model.Post, numOfPosts int, err network.ApiError)
+	GetPostsByCommunityId(ctx context.Context, communityId string, page int, limit int) (posts []*model.Post, numOfPosts int, err network.ApiError)
 
 	// Post moderation actions
-	ToggleStickyPost(userId string, postId string) (bool, network.ApiError)
-	ToggleLockPost(userId string, postId string) (bool, network.ApiError)
-	ToggleArchivePost(userId string, postId string) (bool, network.ApiError)
-	ToggleNSFWPost(userId string, postId string) (bool, network.ApiError)
+	ToggleStickyPost(ctx context.Context, userId string, postId string) (bool, network.ApiError)
+	ToggleLockPost(ctx context.Context, userId string, postId string) (bool, network.ApiError)
+	ToggleArchivePost(ctx context.Context, userId string, postId string) (bool, network.ApiError)
+	ToggleNSFWPost(ctx context.Context, userId string, postId string) (bool, network.ApiError)
+
+	// Post reactions
+	SetPostReaction(ctx context.Context, userId string, postId string, reaction model.ReactionType) (*common.ReactionResponse, network.ApiError)
+	RemovePostReaction(ctx context.Context, userId string, postId string) (*common.ReactionResponse, network.ApiError)
+	GetPostReactors(ctx context.Context, postId string, reaction model.ReactionType, cursor string, limit int) ([]*model.PublicPostReactor, *mongo.CursorResult, network.ApiError)
 }
 
 type postService struct {
 	network.BaseService
-	mediaService                media.MediaService
+	mediaLibraryService         media.MediaLibraryService
 	logger                      utils.AppLogger
 	communityService            community.CommunityService
 	userService                 user.UserService
 	moderatorService            moderator.ModeratorService
+	contentGuard                guard.ContentGuard
 	postQueryBuilder            mongo.QueryBuilder[model.Post]
 	postInteractionQueryBuilder mongo.QueryBuilder[model.PostInteraction]
 	getPostAggregateBuilder     mongo.AggregateBuilder[model.Post, model.PublicPost]
 	feedPostAggregateBuilder    mongo.AggregateBuilder[model.Post, model.FeedPost]
+	reactorAggregateBuilder     mongo.AggregateBuilder[model.PostInteraction, model.PublicPostReactor]
 	transaction                 mongo.TransactionBuilder
+	feedConfig                  config.FeedConfig
+	feedRanker                  FeedRanker
+	cursors                     mongo.CursorCodec
+	feedSnapshots               feedSnapshotStore
+	postCache                   redis.Cache[model.PublicPost]
 }
 
-func NewPostService(db mongo.Database, userService user.UserService, communityService community.CommunityService, mediaService media.MediaService, moderatorService moderator.ModeratorService) PostService {
+func NewPostService(db mongo.Database, feedConfig config.FeedConfig, userService user.UserService, communityService community.CommunityService, mediaLibraryService media.MediaLibraryService, moderatorService moderator.ModeratorService, contentGuard guard.ContentGuard, store redis.Store, cacheStore redis.CacheStore, cacheConfig config.CacheConfig) PostService {
+	if feedConfig.CandidateWindow <= 0 {
+		feedConfig.CandidateWindow = 72 * time.Hour
+	}
+	if feedConfig.CandidateLimit <= 0 {
+		feedConfig.CandidateLimit = 500
+	}
+	if feedConfig.SnapshotTTL <= 0 {
+		feedConfig.SnapshotTTL = 30 * time.Minute
+	}
 	return &postService{
 		BaseService:                 network.NewBaseService(),
 		logger:                      utils.NewServiceLogger("PostService"),
-		mediaService:                mediaService,
+		mediaLibraryService:         mediaLibraryService,
 		communityService:            communityService,
 		userService:                 userService,
 		moderatorService:            moderatorService,
+		contentGuard:                contentGuard,
 		postQueryBuilder:            mongo.NewQueryBuilder[model.Post](db, model.PostCollectionName),
 		postInteractionQueryBuilder: mongo.NewQueryBuilder[model.PostInteraction](db, model.PostInteractionCollectionName),
 		getPostAggregateBuilder:     mongo.NewAggregateBuilder[model.Post, model.PublicPost](db, model.PostCollectionName),
 		feedPostAggregateBuilder:    mongo.NewAggregateBuilder[model.Post, model.FeedPost](db, model.PostCollectionName),
+		reactorAggregateBuilder:     mongo.NewAggregateBuilder[model.PostInteraction, model.PublicPostReactor](db, model.PostInteractionCollectionName),
 		transaction:                 mongo.NewTransactionBuilder(db),
+		feedConfig:                  feedConfig,
+		feedRanker:                  NewFeedRanker(feedConfig),
+		cursors:                     db.GetCursorCodec(),
+		feedSnapshots:               newRedisFeedSnapshotStore(store),
+		// Entries are per viewer, the reactions of the user are part of the post
+		postCache: redis.NewCache[model.PublicPost](cacheStore, redis.CacheOptions{
+			Name:      "post",
+			TTL:       cacheConfig.PostTTL,
+			Jitter:    cacheConfig.Jitter,
+			ValueTags: model.EmbeddedCacheTags,
+		}),
+	}
+}
+
+// invalidatePost drops every cached view of the post after a write, whether or not the write went through
+func (s *postService) invalidatePost(ctx context.Context, postId string) {
+	if err := s.postCache.Invalidate(context.WithoutCancel(ctx), model.PostCacheTag(postId)); err != nil {
+		s.logger.WithContext(ctx).Error("Error invalidating cached post %s: %v", postId, err)
 	}
 }
 
 func (s *postService) CreatePost(
-	title string, content string, tags []string, media []string, userId string, communityId string, postType model.PostType, isNSFW bool, isSpoiler bool,
+	ctx context.Context,
+	title string, content string, tags []string, mediaIds []string, userId string, communityId string, postType model.PostType, isNSFW bool, isSpoiler bool,
 ) (*model.Post, network.ApiError) {
-	s.logger.Info("Creating post with title: %s", title)
-	var fileUrls []model.Media
-	for _, file := range media {
-		s.logger.Debug("File uploaded: %s", file)
-		mediaInfo, err := s.mediaService.UploadMedia(file, userId+"_post", "post")
-		if err != nil {
-			s.logger.Error("Failed to upload media: %v", err)
-			return nil, NewMediaError("uploading media", err.Error())
-		}
-		fileUrls = append(fileUrls, model.Media{
-			Id:        mediaInfo.Id,
-			Type:      model.MediaType(mediaInfo.Type),
-			Url:       mediaInfo.Url,
-			Width:     mediaInfo.Width,
-			Height:    mediaInfo.Height,
-			FileSize:  mediaInfo.Size,
-			CreatedAt: primitive.NewDateTimeFromTime(time.Now()),
-		})
-	}
+	ctx, span := tracing.Start(ctx, "PostService.CreatePost")
+	defer span.End()
 
-	post := model.NewPost(userId, communityId, title, content, tags, fileUrls, postType, isNSFW, isSpoiler)
+	s.logger.WithContext(ctx).Info("Creating post with title: %s", title)
 
-	if err := s.communityService.CheckUserInCommunity(userId, communityId); err != nil {
-		s.logger.Error("User is not a member of the community: %v", err)
+	if err := s.communityService.CheckUserInCommunity(ctx, userId, communityId); err != nil {
+		s.logger.WithContext(ctx).Error("User is not a member of the community: %v", err)
 		return nil, NewForbiddenError("create post in", userId, communityId)
 	}
 
-	_, err := s.postQueryBuilder.SingleQuery().InsertOne(post)
+	post := model.NewPost(userId, communityId, title, content, tags, nil, postType, isNSFW, isSpoiler)
+
+	// Media is uploaded beforehand, attaching it checks ownership and keeps it from being swept
+	attached, apiErr := s.mediaLibraryService.AttachMedia(ctx, userId, mediaIds, mediaModel.NewMediaReference(mediaModel.MediaReferencePost, post.PostId))
+	if apiErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to attach media to post: %v", apiErr)
+		return nil, apiErr
+	}
+	for _, media := range attached {
+		post.Media = append(post.Media, model.Media{
+			Id:        media.MediaId,
+			Type:      model.MediaType(media.Type),
+			Url:       media.Url,
+			Width:     media.Width,
+			Height:    media.Height,
+			FileSize:  media.Size,
+			CreatedAt: media.CreatedAt,
+
+			Renditions:    media.Renditions,
+			BlurHash:      media.BlurHash,
+			DominantColor: media.DominantColor,
+		})
+	}
+
+	_, err := s.postQueryBuilder.SingleQuery(ctx).InsertOne(post)
 	if err != nil {
-		s.logger.Error("Failed to create post: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to create post: %v", err)
+		if releaseErr := s.mediaLibraryService.ReleaseMedia(ctx, mediaModel.MediaReferencePost, post.PostId); releaseErr != nil {
+			s.logger.WithContext(ctx).Error("Failed to release media of unsaved post: %v", releaseErr)
+		}
 		return nil, NewDBError("creating post", err.Error())
 	}
-	s.logger.Info("Post created successfully with ID: %s", post.PostId)
+	metrics.PostCreated()
+	s.logger.WithContext(ctx).Info("Post created successfully with ID: %s", post.PostId)
 	return post, nil
 }
 
-func (s *postService) GetPost(postId string, userId string) (*model.PublicPost, network.ApiError) {
-	s.logger.Info("Getting post with ID: %s", postId)
+func (s *postService) GetPost(ctx context.Context, postId string, userId string) (*model.PublicPost, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "PostService.GetPost")
+	defer span.End()
+
+	post, err := s.postCache.GetOrLoad(ctx, postId+":"+userId, func(ctx context.Context) (*model.PublicPost, error) {
+		return s.findPost(ctx, postId, userId)
+	}, model.PostCacheTag(postId))
+	if err != nil {
+		var apiErr network.ApiError
+		if errors.As(err, &apiErr) {
+			return nil, apiErr
+		}
+		return nil, network.NewInternalServerError("failed to get post", fmt.Sprintf("Failed to get post %s. Context - [ Cache Failed ]", postId), network.CACHE_ERROR, err)
+	}
+	return post, nil
+}
+
+func (s *postService) findPost(ctx context.Context, postId string, userId string) (*model.PublicPost, network.ApiError) {
+	s.logger.WithContext(ctx).Info("Getting post with ID: %s", postId)
 	// use aggregation to get the post with author and community details
-	aggregate := s.getPostAggregateBuilder.SingleAggregate()
+	aggregate := s.getPostAggregateBuilder.SingleAggregate(ctx)
 	aggregate.Match(bson.M{"postId": postId, "status": model.PostStatusActive})
 	aggregate.Sort(bson.D{primitive.E{Key: "createdAt", Value: -1}, primitive.E{Key: "synergy", Value: -1}})
 	aggregate.Lookup("users", "authorId", "userId", "author")
@@ -144,6 +225,21 @@ func (s *postService) GetPost(postId string, userId string) (*model.PublicPost,
 	}) // Calculate isLiked and isDisliked flags
 	aggregate.AddFields(bson.M{
 		"userInteraction": bson.M{"$arrayElemAt": bson.A{"$userInteractions", 0}},
+		"myReaction": bson.M{"$arrayElemAt": bson.A{
+			bson.M{"$map": bson.M{
+				"input": bson.M{"$filter": bson.M{
+					"input": "$interactions",
+					"as":    "interaction",
+					"cond": bson.M{"$and": bson.A{
+						bson.M{"$eq": bson.A{"$$interaction.userId", userId}},
+						bson.M{"$eq": bson.A{"$$interaction.interactionType", model.InteractionTypeReaction}},
+					}},
+				}},
+				"as": "interaction",
+				"in": "$$interaction.reaction",
+			}},
+			0,
+		}},
 	})
 	aggregate.Project(bson.M{
 		"id":      "$postId",
@@ -186,132 +282,196 @@ func (s *postService) GetPost(postId string, userId string) (*model.PublicPost,
 				false,
 			},
 		},
-		"type":         1,
-		"status":       1,
-		"media":        1,
-		"tags":         1,
-		"synergy":      1,
-		"commentCount": 1,
-		"viewCount":    1,
-		"shareCount":   1,
-		"saveCount":    1,
-		"voters":       1,
-		"isNSFW":       1,
-		"isSpoiler":    1,
-		"isStickied":   1,
-		"isLocked":     1,
-		"isArchived":   1,
-		"createdAt":    1,
+		"type":           1,
+		"status":         1,
+		"media":          1,
+		"tags":           1,
+		"synergy":        1,
+		"commentCount":   1,
+		"viewCount":      1,
+		"shareCount":     1,
+		"saveCount":      1,
+		"voters":         1,
+		"reactionCounts": 1,
+		"myReaction":     1,
+		"isNSFW":         1,
+		"isSpoiler":      1,
+		"isStickied":     1,
+		"isLocked":       1,
+		"isArchived":     1,
+		"isEdited":       1,
+		"lastEditedAt":   1,
+		"createdAt":      1,
 	})
 	// execute the aggregation
 	posts, err := aggregate.Exec()
 	if err != nil {
-		s.logger.Error("Failed to get post: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to get post: %v", err)
 		return nil, NewDBError("getting post", err.Error())
 	}
 	if len(posts) == 0 {
-		s.logger.Error("Post not found")
+		s.logger.WithContext(ctx).Error("Post not found")
 		return nil, NewPostNotFoundError(postId)
 	}
-	s.logger.Info("Post retrieved successfully with ID: %s", postId)
+	s.logger.WithContext(ctx).Info("Post retrieved successfully with ID: %s", postId)
 	return posts[0], nil
 }
 
-func (s *postService) RecordPostView(postId string, userId string) network.ApiError {
-	s.logger.Info("Recording view for post with ID: %s by user: %s", postId, userId)
-	interaction, err := s.postInteractionQueryBuilder.SingleQuery().InsertOne(model.NewPostInteraction(postId, userId, model.InteractionTypeView))
+func (s *postService) RecordPostView(ctx context.Context, postId string, userId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "PostService.RecordPostView")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Recording view for post with ID: %s by user: %s", postId, userId)
+	// A user keeps one view interaction per post, created on the first view. The home feed uses it as
+	// the seen marker.
+	now := primitive.NewDateTimeFromTime(time.Now())
+	view := model.NewPostInteraction(userId, postId, model.InteractionTypeView)
+	_, err := s.postInteractionQueryBuilder.SingleQuery(ctx).UpdateOne(
+		bson.M{"postId": postId, "userId": userId, "interactionType": model.InteractionTypeView},
+		bson.M{
+			"$set":         bson.M{"updatedAt": now},
+			"$setOnInsert": bson.M{"interactionId": view.InteractionId, "createdAt": now},
+		},
+		options.Update().SetUpsert(true),
+	)
 	if err != nil {
-		s.logger.Error("Failed to record post view: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to record post view: %v", err)
 		return NewDBError("recording post view", err.Error())
 	}
-	if interaction == nil {
-		s.logger.Error("Failed to record post view: interaction is nil")
-		return network.NewInternalServerError(
-			"Failed to record post view",
-			"Interaction is nil after inserting post view",
-			network.DB_ERROR,
-			fmt.Errorf("interaction is nil after inserting post view for post %s by user %s", postId, userId),
-		)
-	}
 	// Update the post's view count
 	filter := bson.M{"postId": postId}
-	update := bson.M{"$inc": bson.M{"viewCount": 1}, "$set": bson.M{"updatedAt": primitive.NewDateTimeFromTime(time.Now())}}
-	s.postQueryBuilder.SingleQuery().UpdateOne(filter, update, nil)
+	update := bson.M{"$inc": bson.M{"viewCount": 1}, "$set": bson.M{"updatedAt": now}}
+	s.postQueryBuilder.SingleQuery(ctx).UpdateOne(filter, update, nil)
 	return nil
 }
 
-func (s *postService) EditPost(userId string, postId string, title *string, content *string, postType model.PostType, isNSFW *bool, isSpoiler *bool) (newPostId *string, err network.ApiError) {
-	s.logger.Info("Editing post with ID: %s", postId)
-	post, updateErr := s.GetPost(postId, userId)
-	if updateErr != nil {
-		return nil, updateErr
+func (s *postService) EditPost(ctx context.Context, userId string, postId string, title *string, content *string, postType model.PostType, isNSFW *bool, isSpoiler *bool) network.ApiError {
+	ctx, span := tracing.Start(ctx, "PostService.EditPost")
+	defer span.End()
+	defer s.invalidatePost(ctx, postId)
+
+	s.logger.WithContext(ctx).Info("Editing post with ID: %s", postId)
+	post, findErr := s.postQueryBuilder.SingleQuery(ctx).FindOne(bson.M{"postId": postId}, nil)
+	if findErr != nil {
+		if mongo.IsNoDocumentFoundError(findErr) {
+			return NewPostNotFoundError(postId)
+		}
+		s.logger.WithContext(ctx).Error("Failed to find post: %v", findErr)
+		return NewDBError("finding post", findErr.Error())
 	}
 	if !post.IsActive() {
-		s.logger.Error("Cannot edit inactive post with ID: %s", postId)
-		return nil, network.NewForbiddenError(
+		s.logger.WithContext(ctx).Error("Cannot edit inactive post with ID: %s", postId)
+		return network.NewForbiddenError(
 			"Cannot edit inactive post",
 			fmt.Sprintf("Cannot edit post with ID %s as it is inactive", postId),
 			fmt.Errorf("post %s is inactive", postId),
 		)
 	}
-	if post.Author.UserId != userId {
-		s.logger.Error("User is not the author of the post: %s", postId)
-		return nil, network.NewForbiddenError(
+	if post.AuthorId != userId {
+		s.logger.WithContext(ctx).Error("User is not the author of the post: %s", postId)
+		return network.NewForbiddenError(
 			"User is not the author of the post",
 			fmt.Sprintf("Cannot edit post with ID %s as user %s is not the author", postId, userId),
 			fmt.Errorf("user %s is not the author of post %s", userId, postId),
 		)
 	}
 
-	filter := bson.M{"postId": postId, "authorId": userId}
-	update := bson.M{}
+	newTitle := post.Title
 	if title != nil {
-		update["title"] = *title
+		newTitle = *title
 	}
+	newContent := post.Content
 	if content != nil {
-		update["content"] = *content
+		newContent = *content
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	set := bson.M{
+		"title":     newTitle,
+		"content":   newContent,
+		"updatedAt": now,
 	}
 	if postType != "" {
-		update["type"] = postType
+		set["type"] = postType
 	}
 	if isNSFW != nil {
-		update["isNSFW"] = *isNSFW
+		set["isNSFW"] = *isNSFW
 	}
 	if isSpoiler != nil {
-		update["isSpoiler"] = *isSpoiler
+		set["isSpoiler"] = *isSpoiler
 	}
-	update["updatedAt"] = primitive.NewDateTimeFromTime(time.Now())
-	options := options.Update().SetUpsert(true)
-	updatePost, queryErr := s.postQueryBuilder.SingleQuery().UpdateOne(filter, bson.M{"$set": update}, options)
-	if queryErr != nil && !mongo.IsNoDocumentFoundError(queryErr) {
-		s.logger.Error("Failed to edit post: %v", updateErr)
-		return nil, network.NewInternalServerError("Failed to edit post", "Failed to update post details", network.DB_ERROR, queryErr)
+	update := bson.M{"$set": set}
+
+	// Only title and content changes are revisions, flag toggles are not
+	if newTitle != post.Title || newContent != post.Content {
+		edit := model.NewPostEdit(userId, post.Title, post.Content, newTitle, newContent)
+		set["isEdited"] = true
+		set["lastEditedAt"] = edit.EditedAt
+		update["$push"] = bson.M{"editHistory": edit}
 	}
-	if updatePost == nil {
-		s.logger.Error("Post not found")
-		return nil, network.NewNotFoundError(
-			"Post not found",
-			fmt.Sprintf("Post with ID %s not found - it may have been deleted or never existed", postId),
-			fmt.Errorf("post %s not found", postId),
-		)
+
+	filter := bson.M{"postId": postId, "authorId": userId, "status": model.PostStatusActive}
+	result, queryErr := s.postQueryBuilder.SingleQuery(ctx).UpdateOne(filter, update, nil)
+	if queryErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to edit post: %v", queryErr)
+		return network.NewInternalServerError("Failed to edit post", "Failed to update post details", network.DB_ERROR, queryErr)
 	}
-	s.logger.Info("Post edited successfully with ID: %s -> New Id %s", postId, updatePost.UpsertedID)
-	if updatePost.UpsertedID != nil {
-		idStr := updatePost.UpsertedID.(string)
-		return &idStr, nil
+	if result == nil || result.MatchedCount == 0 {
+		s.logger.WithContext(ctx).Error("Post not found")
+		return NewPostNotFoundError(postId)
 	}
-	return nil, nil
+	s.logger.WithContext(ctx).Info("Post edited successfully with ID: %s", postId)
+	return nil
 }
 
-func (s *postService) DeletePost(userId string, postId string) network.ApiError {
-	s.logger.Info("Deleting post with ID: %s", postId)
-	post, err := s.GetPost(postId, userId)
+// GetPostRevisions returns the edit history of a post, newest first. Only the author
+// and moderators with the view_mod_log permission can read it.
+func (s *postService) GetPostRevisions(ctx context.Context, userId string, postId string) ([]model.PostEdit, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "PostService.GetPostRevisions")
+	defer span.End()
+
+	post, err := s.postQueryBuilder.SingleQuery(ctx).FindOne(
+		bson.M{"postId": postId},
+		options.FindOne().SetProjection(bson.M{"postId": 1, "authorId": 1, "communityId": 1, "editHistory": 1}),
+	)
 	if err != nil {
-		s.logger.Error("Failed to get post: %v", err)
+		if mongo.IsNoDocumentFoundError(err) {
+			return nil, NewPostNotFoundError(postId)
+		}
+		s.logger.WithContext(ctx).Error("Failed to find post: %v", err)
+		return nil, NewDBError("finding post", err.Error())
+	}
+
+	if post.AuthorId != userId {
+		canView, modErr := s.moderatorService.HasModeratorPermission(ctx, userId, post.CommunityId, moderatorModel.PermissionViewModLog)
+		if modErr != nil {
+			return nil, modErr
+		}
+		if !canView {
+			return nil, NewForbiddenError("view revisions of", userId, postId)
+		}
+	}
+
+	revisions := make([]model.PostEdit, 0, len(post.EditHistory))
+	for i := len(post.EditHistory) - 1; i >= 0; i-- {
+		revisions = append(revisions, post.EditHistory[i])
+	}
+	return revisions, nil
+}
+
+func (s *postService) DeletePost(ctx context.Context, userId string, postId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "PostService.DeletePost")
+	defer span.End()
+	defer s.invalidatePost(ctx, postId)
+
+	s.logger.WithContext(ctx).Info("Deleting post with ID: %s", postId)
+	post, err := s.GetPost(ctx, postId, userId)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to get post: %v", err)
 		return err
 	}
 	if post.Author.UserId != userId {
-		s.logger.Error("User is not the author of the post: %s", postId)
+		s.logger.WithContext(ctx).Error("User is not the author of the post: %s", postId)
 		return network.NewForbiddenError(
 			"User is not the author of the post",
 			fmt.Sprintf("Cannot delete post with ID %s as user %s is not the author", postId, userId),
@@ -319,7 +479,7 @@ func (s *postService) DeletePost(userId string, postId string) network.ApiError
 		)
 	}
 	if !post.IsActive() {
-		s.logger.Error("Cannot delete inactive post with ID: %s", postId)
+		s.logger.WithContext(ctx).Error("Cannot delete inactive post with ID: %s", postId)
 		return network.NewForbiddenError(
 			"Cannot delete inactive post",
 			fmt.Sprintf("Cannot delete post with ID %s as it is inactive", postId),
@@ -334,9 +494,9 @@ func (s *postService) DeletePost(userId string, postId string) network.ApiError
 		"updatedAt":      primitive.NewDateTimeFromTime(time.Now()),
 		"lastActivityAt": primitive.NewDateTimeFromTime(time.Now()),
 	}
-	updatePost, updateErr := s.postQueryBuilder.SingleQuery().UpdateOne(filter, bson.M{"$set": update}, nil)
+	updatePost, updateErr := s.postQueryBuilder.SingleQuery(ctx).UpdateOne(filter, bson.M{"$set": update}, nil)
 	if updateErr != nil && !mongo.IsNoDocumentFoundError(updateErr) {
-		s.logger.Error("Failed to delete post: %v", updateErr)
+		s.logger.WithContext(ctx).Error("Failed to delete post: %v", updateErr)
 		return network.NewInternalServerError(
 			"Failed to delete post",
 			fmt.Sprintf("Failed to delete post with ID %s", postId),
@@ -345,7 +505,7 @@ func (s *postService) DeletePost(userId string, postId string) network.ApiError
 		)
 	}
 	if updatePost == nil {
-		s.logger.Error("Post not found")
+		s.logger.WithContext(ctx).Error("Post not found")
 		return network.NewNotFoundError(
 			"Post not found",
 			fmt.Sprintf("Post with ID %s not found - it may have been deleted or never existed", postId),
@@ -353,143 +513,301 @@ func (s *postService) DeletePost(userId string, postId string) network.ApiError
 		)
 	}
 
-	s.logger.Info("Post deleted successfully with ID: %s", postId)
+	// The post stays soft deleted, its media can be swept once no other content uses it
+	if releaseErr := s.mediaLibraryService.ReleaseMedia(ctx, mediaModel.MediaReferencePost, postId); releaseErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to release media of deleted post: %v", releaseErr)
+	}
+
+	s.logger.WithContext(ctx).Info("Post deleted successfully with ID: %s", postId)
 	return nil
 }
 
-// GetUserFeedPosts retrieves posts from communities the user has joined
-func (s *postService) GetUserFeedPosts(userId string, page int, limit int) ([]*model.FeedPost, network.ApiError) {
-	s.logger.Info("Getting feed posts for user: %s", userId)
+// GetUserFeedPosts retrieves the ranked home feed from joined communities and followed users
+func (s *postService) GetUserFeedPosts(ctx context.Context, userId string, cursor string, limit int) ([]*model.FeedPost, *mongo.CursorResult, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "PostService.GetUserFeedPosts")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Getting feed posts for user: %s", userId)
+
+	// The first page ranks a snapshot of the candidates and saves the ranked ids, the cursor carries
+	// the snapshot time and the offset so later pages are cut from the same order
+	if cursor != "" {
+		var state feedCursor
+		if openErr := s.cursors.Open(cursor, &state); openErr != nil || state.Offset < 0 {
+			return nil, nil, NewInvalidCursorError(cursor)
+		}
+		return s.getFeedSnapshotPage(ctx, userId, cursor, state, limit)
+	}
 
-	// Get user's joined communities
-	user, err := s.userService.FindUserById(userId)
+	user, err := s.userService.FindUserById(ctx, userId)
 	if err != nil {
-		s.logger.Error("Failed to get user: %v", err)
-		return nil, err
+		s.logger.WithContext(ctx).Error("Failed to get user: %v", err)
+		return nil, nil, err
 	}
 
-	// Check if user has joined any communities
-	if len(user.JoinedWavelengths) == 0 {
-		s.logger.Info("User has not joined any communities")
-		return []*model.FeedPost{}, nil
+	if len(user.JoinedWavelengths) == 0 && len(user.Follows) == 0 {
+		s.logger.WithContext(ctx).Info("User has not joined any communities or followed any users")
+		return []*model.FeedPost{}, &mongo.CursorResult{}, nil
 	}
 
-	// Convert community IDs to primitive.ObjectID array
-	communityIds := user.JoinedWavelengths
+	generatedAt := time.Now()
+	state := feedCursor{GeneratedAt: primitive.NewDateTimeFromTime(generatedAt)}
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"communityId": bson.M{"$in": nonNilStrings(user.JoinedWavelengths)}},
+			bson.M{"authorId": bson.M{"$in": nonNilStrings(user.Follows)}},
+		},
+		"status": model.PostStatusActive,
+		"authorId": bson.M{
+			"$ne":  userId, // Exclude posts by the current user
+			"$nin": nonNilStrings(user.Preferences.BlockList),
+		},
+		"createdAt": bson.M{
+			"$gte": primitive.NewDateTimeFromTime(generatedAt.Add(-s.feedConfig.CandidateWindow)),
+			"$lte": state.GeneratedAt,
+		},
+	}
+	if !user.Preferences.ContentSettings.ShowAdultContent {
+		filter["isNSFW"] = false
+	}
 
-	// Build aggregation pipeline
-	aggregate := s.feedPostAggregateBuilder.SingleAggregate()
+	aggregate := s.feedPostAggregateBuilder.SingleAggregate(ctx)
+	aggregate.Match(filter)
+	aggregate.Sort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}})
+	aggregate.Limit(int64(s.feedConfig.CandidateLimit))
+	aggregate.Project(feedPostProjection)
 
-	// Match active posts in user's joined communities
-	aggregate.Match(bson.M{
-		"communityId": bson.M{"$in": communityIds},
-		"status":      model.PostStatusActive,
-		"authorId":    bson.M{"$ne": userId}, // Exclude posts by the current user
-	})
+	candidates, execErr := aggregate.Exec()
+	if execErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to get feed posts: %v", execErr)
+		return nil, nil, NewDBError("getting feed posts", execErr.Error())
+	}
 
-	// Sort by activity time, trending score, hot score, and creation time
-	aggregate.Sort(bson.D{
-		{Key: "lastActivityAt", Value: -1},
-		{Key: "analytics.trendingScore", Value: -1},
-		{Key: "analytics.hotScore", Value: -1},
-		{Key: "createdAt", Value: -1},
-	})
+	if apiErr := s.markFeedInteractions(ctx, userId, candidates, state.GeneratedAt); apiErr != nil {
+		return nil, nil, apiErr
+	}
 
-	// Skip and limit for pagination
-	aggregate.Skip(int64((page - 1) * limit))
-	aggregate.Limit(int64(limit))
+	signals := FeedSignals{
+		FollowedAuthors: make(map[string]bool, len(user.Follows)),
+		TagAffinity:     s.getTagAffinity(ctx, userId),
+		GeneratedAt:     generatedAt,
+	}
+	for _, authorId := range user.Follows {
+		signals.FollowedAuthors[authorId] = true
+	}
 
-	// Lookup user interactions to determine if the user has liked or disliked each post
-	aggregate.Lookup(
-		model.PostInteractionCollectionName,
-		"postId",
-		"postId",
-		"interactions",
-	)
+	ranked := s.feedRanker.Rank(candidates, signals)
+	end := min(limit, len(ranked))
+	page := &mongo.CursorResult{HasMore: end < len(ranked)}
+	if page.HasMore {
+		postIds := make([]string, len(ranked))
+		for i, post := range ranked {
+			postIds[i] = post.ID
+		}
+		if saveErr := s.feedSnapshots.save(ctx, feedSnapshotKey(userId, state.GeneratedAt), postIds, s.feedConfig.SnapshotTTL); saveErr != nil {
+			s.logger.WithContext(ctx).Error("Failed to save feed snapshot: %v", saveErr)
+			return nil, nil, NewDBError("saving feed snapshot", saveErr.Error())
+		}
+		next, sealErr := s.cursors.Seal(feedCursor{GeneratedAt: state.GeneratedAt, Offset: end})
+		if sealErr != nil {
+			s.logger.WithContext(ctx).Error("Failed to create feed cursor: %v", sealErr)
+			return nil, nil, NewDBError("creating feed cursor", sealErr.Error())
+		}
+		page.NextCursor = next
+	}
 
-	// Filter interactions for the current user
-	aggregate.AddFields(bson.M{
-		"userInteractions": bson.M{
-			"$filter": bson.M{
-				"input": "$interactions",
-				"as":    "interaction",
-				"cond": bson.M{
-					"$and": bson.A{
-						bson.M{"$eq": bson.A{"$$interaction.userId", userId}},
-						bson.M{"$in": bson.A{
-							"$$interaction.interactionType",
-							bson.A{model.InteractionTypeLike, model.InteractionTypeDislike},
-						}},
-					},
-				},
-			},
-		},
-	})
+	return ranked[:end], page, nil
+}
 
-	// Extract the single user interaction if it exists
-	aggregate.AddFields(bson.M{
-		"userInteraction": bson.M{"$arrayElemAt": bson.A{"$userInteractions", 0}},
-	})
+// getFeedSnapshotPage serves a later page of the feed from the ranked ids saved with its snapshot.
+// Posts removed since the snapshot are left out of the page rather than replaced.
+func (s *postService) getFeedSnapshotPage(ctx context.Context, userId string, cursor string, state feedCursor, limit int) ([]*model.FeedPost, *mongo.CursorResult, network.ApiError) {
+	postIds, total, err := s.feedSnapshots.page(ctx, feedSnapshotKey(userId, state.GeneratedAt), state.Offset, limit)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to get feed snapshot: %v", err)
+		return nil, nil, NewDBError("getting feed snapshot", err.Error())
+	}
+	if total == 0 {
+		return nil, nil, NewInvalidCursorError(cursor)
+	}
+	if len(postIds) == 0 {
+		return []*model.FeedPost{}, &mongo.CursorResult{}, nil
+	}
 
-	// Project required fields for the feed post format
-	aggregate.Project(bson.M{
-		"postId":       1,
-		"title":        1,
-		"content":      1,
-		"authorId":     1,
-		"communityId":  1,
-		"type":         1,
-		"status":       1,
-		"tags":         1,
-		"synergy":      1,
-		"commentCount": 1,
-		"viewCount":    1,
-		"shareCount":   1,
-		"saveCount":    1,
-		"isLiked": bson.M{
-			"$cond": bson.A{
-				bson.M{"$and": bson.A{
-					bson.M{"$ifNull": bson.A{"$userInteraction", false}},
-					bson.M{"$eq": bson.A{"$userInteraction.interactionType", model.InteractionTypeLike}},
-				}},
-				true,
-				false,
-			},
-		},
-		"isDisliked": bson.M{
-			"$cond": bson.A{
-				bson.M{"$and": bson.A{
-					bson.M{"$ifNull": bson.A{"$userInteraction", false}},
-					bson.M{"$eq": bson.A{"$userInteraction.interactionType", model.InteractionTypeDislike}},
-				}},
-				true,
-				false,
-			},
-		},
-		"isNSFW":     1,
-		"isSpoiler":  1,
-		"isStickied": 1,
-		"isLocked":   1,
-		"createdAt":  1,
-		"updatedAt":  1,
-	})
+	aggregate := s.feedPostAggregateBuilder.SingleAggregate(ctx)
+	aggregate.Match(bson.M{"postId": bson.M{"$in": postIds}, "status": model.PostStatusActive})
+	aggregate.Project(feedPostProjection)
 
-	// Execute the aggregation
-	posts, execErr := aggregate.Exec()
+	found, execErr := aggregate.Exec()
 	if execErr != nil {
-		s.logger.Error("Failed to get feed posts: %v", execErr)
-		return nil, NewDBError("getting feed posts", execErr.Error())
+		s.logger.WithContext(ctx).Error("Failed to get feed posts: %v", execErr)
+		return nil, nil, NewDBError("getting feed posts", execErr.Error())
+	}
+	if apiErr := s.markFeedInteractions(ctx, userId, found, state.GeneratedAt); apiErr != nil {
+		return nil, nil, apiErr
 	}
 
-	return posts, nil
+	byId := make(map[string]*model.FeedPost, len(found))
+	for _, post := range found {
+		byId[post.ID] = post
+	}
+	posts := make([]*model.FeedPost, 0, len(postIds))
+	for _, postId := range postIds {
+		if post, ok := byId[postId]; ok {
+			posts = append(posts, post)
+		}
+	}
+
+	end := state.Offset + len(postIds)
+	page := &mongo.CursorResult{HasMore: end < total}
+	if page.HasMore {
+		next, sealErr := s.cursors.Seal(feedCursor{GeneratedAt: state.GeneratedAt, Offset: end})
+		if sealErr != nil {
+			s.logger.WithContext(ctx).Error("Failed to create feed cursor: %v", sealErr)
+			return nil, nil, NewDBError("creating feed cursor", sealErr.Error())
+		}
+		page.NextCursor = next
+	}
+
+	return posts, page, nil
+}
+
+// feedPostProjection flattens the ranking scores of the analytics into the feed post
+var feedPostProjection = bson.M{
+	"postId":        1,
+	"title":         1,
+	"content":       1,
+	"authorId":      1,
+	"communityId":   1,
+	"type":          1,
+	"status":        1,
+	"tags":          1,
+	"synergy":       1,
+	"commentCount":  1,
+	"viewCount":     1,
+	"shareCount":    1,
+	"saveCount":     1,
+	"isNSFW":        1,
+	"isSpoiler":     1,
+	"isStickied":    1,
+	"isLocked":      1,
+	"createdAt":     1,
+	"updatedAt":     1,
+	"hotScore":      "$analytics.hotScore",
+	"trendingScore": "$analytics.trendingScore",
+}
+
+// nonNilStrings keeps $in and $nin operands arrays when a user document lacks the field
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// feedCursor is the signed pagination state of the ranked home feed
+type feedCursor struct {
+	GeneratedAt primitive.DateTime `bson:"g"`
+	Offset      int                `bson:"o"`
+}
+
+// markFeedInteractions sets the vote and seen flags of the feed posts. Only views recorded before the
+// snapshot count as seen, so posts served on earlier pages of the snapshot are not flagged as seen.
+func (s *postService) markFeedInteractions(ctx context.Context, userId string, posts []*model.FeedPost, generatedAt primitive.DateTime) network.ApiError {
+	if len(posts) == 0 {
+		return nil
+	}
+	postIds := make([]string, len(posts))
+	for i, post := range posts {
+		postIds[i] = post.ID
+	}
+
+	interactions, err := s.postInteractionQueryBuilder.SingleQuery(ctx).FindAll(bson.M{
+		"userId": userId,
+		"postId": bson.M{"$in": postIds},
+		"$or": bson.A{
+			bson.M{"interactionType": bson.M{"$in": bson.A{model.InteractionTypeLike, model.InteractionTypeDislike}}},
+			bson.M{"interactionType": model.InteractionTypeView, "createdAt": bson.M{"$lte": generatedAt}},
+		},
+	}, nil)
+	if err != nil && !mongo.IsNoDocumentFoundError(err) {
+		s.logger.WithContext(ctx).Error("Failed to get feed interactions: %v", err)
+		return NewDBError("getting feed interactions", err.Error())
+	}
+
+	byPost := make(map[string][]*model.PostInteraction, len(interactions))
+	for _, interaction := range interactions {
+		byPost[interaction.PostId] = append(byPost[interaction.PostId], interaction)
+	}
+	for _, post := range posts {
+		for _, interaction := range byPost[post.ID] {
+			switch interaction.InteractionType {
+			case model.InteractionTypeLike:
+				post.IsLiked = true
+			case model.InteractionTypeDislike:
+				post.IsDisliked = true
+			case model.InteractionTypeView:
+				post.IsSeen = true
+			}
+		}
+	}
+	return nil
+}
+
+// getTagAffinity weighs the tags of the posts the user recently liked or reacted to, the most
+// frequent tag gets 1. Failures only cost the personalization so they are logged and ignored.
+func (s *postService) getTagAffinity(ctx context.Context, userId string) map[string]float64 {
+	affinity := make(map[string]float64)
+	if s.feedConfig.TagAffinityWeight == 0 {
+		return affinity
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(100).SetProjection(bson.M{"postId": 1})
+	interactions, err := s.postInteractionQueryBuilder.SingleQuery(ctx).FindAll(bson.M{
+		"userId":          userId,
+		"interactionType": bson.M{"$in": bson.A{model.InteractionTypeLike, model.InteractionTypeReaction}},
+	}, opts)
+	if err != nil || len(interactions) == 0 {
+		if err != nil && !mongo.IsNoDocumentFoundError(err) {
+			s.logger.WithContext(ctx).Error("Failed to get liked posts for tag affinity: %v", err)
+		}
+		return affinity
+	}
+
+	postIds := make([]string, len(interactions))
+	for i, interaction := range interactions {
+		postIds[i] = interaction.PostId
+	}
+	posts, err := s.postQueryBuilder.SingleQuery(ctx).FindAll(bson.M{"postId": bson.M{"$in": postIds}}, options.Find().SetProjection(bson.M{"tags": 1}))
+	if err != nil {
+		if !mongo.IsNoDocumentFoundError(err) {
+			s.logger.WithContext(ctx).Error("Failed to get liked post tags for tag affinity: %v", err)
+		}
+		return affinity
+	}
+
+	top := 0.0
+	for _, post := range posts {
+		for _, tag := range post.Tags {
+			affinity[tag]++
+			top = max(top, affinity[tag])
+		}
+	}
+	for tag, count := range affinity {
+		affinity[tag] = count / top
+	}
+	return affinity
 }
 
 // GetTrendingPosts retrieves trending posts across all communities
-func (s *postService) GetTrendingPosts(userId string, page int, limit int) ([]*model.FeedPost, network.ApiError) {
-	s.logger.Info("Getting trending posts")
+func (s *postService) GetTrendingPosts(ctx context.Context, userId string, page int, limit int) ([]*model.FeedPost, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "PostService.GetTrendingPosts")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Getting trending posts")
 
 	// Build aggregation pipeline
-	aggregate := s.feedPostAggregateBuilder.SingleAggregate()
+	aggregate := s.feedPostAggregateBuilder.SingleAggregate(ctx)
 
 	// Match only active posts
 	aggregate.Match(bson.M{
@@ -604,18 +922,21 @@ func (s *postService) GetTrendingPosts(userId string, page int, limit int) ([]*m
 	// Execute the aggregation
 	posts, execErr := aggregate.Exec()
 	if execErr != nil {
-		s.logger.Error("Failed to get trending posts: %v", execErr)
+		s.logger.WithContext(ctx).Error("Failed to get trending posts: %v", execErr)
 		return nil, NewDBError("getting trending posts", execErr.Error())
 	}
 
 	return posts, nil
 }
 
-func (s *postService) GetPopularPosts(userId string, page int, limit int) ([]*model.FeedPost, network.ApiError) {
-	s.logger.Info("Getting popular posts, page: %d, limit: %d", page, limit)
+func (s *postService) GetPopularPosts(ctx context.Context, userId string, page int, limit int) ([]*model.FeedPost, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "PostService.GetPopularPosts")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Getting popular posts, page: %d, limit: %d", page, limit)
 
 	// Create a pipeline to get popular posts
-	aggregate := s.feedPostAggregateBuilder.SingleAggregate()
+	aggregate := s.feedPostAggregateBuilder.SingleAggregate(ctx)
 
 	// Match only active posts
 	aggregate.Match(bson.M{
@@ -739,7 +1060,7 @@ func (s *postService) GetPopularPosts(userId string, page int, limit int) ([]*mo
 	// Execute the aggregation
 	popularPosts, err := aggregate.Exec()
 	if err != nil {
-		s.logger.Error("Failed to execute popular posts aggregation: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to execute popular posts aggregation: %v", err)
 		return nil, network.NewInternalServerError(
 			"Failed to get popular posts",
 			"Failed to retrieve popular posts. Context - [ Query Failed ]",
@@ -747,19 +1068,22 @@ func (s *postService) GetPopularPosts(userId string, page int, limit int) ([]*mo
 			err)
 	}
 
-	s.logger.Info("Successfully retrieved %d popular posts", len(popularPosts))
+	s.logger.WithContext(ctx).Info("Successfully retrieved %d popular posts", len(popularPosts))
 	return popularPosts, nil
 }
 
-func (s *postService) GetUserSavedPosts(userId string, page int, limit int) ([]*model.FeedPost, network.ApiError) {
-	s.logger.Info("Getting saved posts for user: %s", userId)
+func (s *postService) GetUserSavedPosts(ctx context.Context, userId string, page int, limit int) ([]*model.FeedPost, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "PostService.GetUserSavedPosts")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Getting saved posts for user: %s", userId)
 	//get saved posts from post interactions
-	postIds, err := s.postInteractionQueryBuilder.SingleQuery().FindAll(
+	postIds, err := s.postInteractionQueryBuilder.SingleQuery(ctx).FindAll(
 		bson.M{"userId": userId, "interactionType": model.InteractionTypeSave},
 		options.Find().SetProjection(bson.M{"postId": 1}),
 	)
 	if err != nil {
-		s.logger.Error("Failed to get saved posts: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to get saved posts: %v", err)
 		return nil, network.NewInternalServerError(
 			"Failed to get saved posts",
 			fmt.Sprintf("Failed to retrieve saved posts for user %s. Context - [ Query Failed ]", userId),
@@ -777,7 +1101,7 @@ func (s *postService) GetUserSavedPosts(userId string, page int, limit int) ([]*
 	}
 
 	// Build aggregation to get saved posts
-	aggregate := s.feedPostAggregateBuilder.SingleAggregate()
+	aggregate := s.feedPostAggregateBuilder.SingleAggregate(ctx)
 	aggregate.Match(bson.M{
 		"postId": bson.M{"$in": postInteractionIds},
 		"status": model.PostStatusActive,
@@ -868,18 +1192,25 @@ func (s *postService) GetUserSavedPosts(userId string, page int, limit int) ([]*
 	// Execute the aggregation
 	posts, execErr := aggregate.Exec()
 	if execErr != nil {
-		s.logger.Error("Failed to get saved posts: %v", execErr)
+		s.logger.WithContext(ctx).Error("Failed to get saved posts: %v", execErr)
 		return nil, NewDBError("getting saved posts", execErr.Error())
 	}
 
-	s.logger.Info("Successfully retrieved %d saved posts for user %s", len(posts), userId)
+	s.logger.WithContext(ctx).Info("Successfully retrieved %d saved posts for user %s", len(posts), userId)
 	return posts, nil
 }
 
-func (s *postService) LikePost(userId string, postId string) (*bool, *int, network.ApiError) {
-	err := s.toggleInteraction(userId, postId, model.InteractionTypeLike)
+func (s *postService) LikePost(ctx context.Context, userId string, postId string) (*bool, *int, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "PostService.LikePost")
+	defer span.End()
+	defer s.invalidatePost(ctx, postId)
+
+	if guardErr := s.guardPostWrite(ctx, userId, postId); guardErr != nil {
+		return nil, nil, guardErr
+	}
+	err := s.toggleInteraction(ctx, userId, postId, model.InteractionTypeLike)
 	if err != nil {
-		s.logger.Error("Failed to toggle like interaction: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to toggle like interaction: %v", err)
 		return nil, nil, network.NewInternalServerError(
 			"Failed to toggle like interaction",
 			fmt.Sprintf("Failed to toggle like interaction for user %s on post %s. Context - [ Action Failed ]", userId, postId),
@@ -888,12 +1219,12 @@ func (s *postService) LikePost(userId string, postId string) (*bool, *int, netwo
 	}
 
 	//get post synergy
-	postSynergy, mongoErr := s.postQueryBuilder.SingleQuery().FindOne(
+	postSynergy, mongoErr := s.postQueryBuilder.SingleQuery(ctx).FindOne(
 		bson.M{"postId": postId},
 		options.FindOne().SetProjection(bson.M{"synergy": -1}),
 	)
 	if mongoErr != nil {
-		s.logger.Error("Failed to get post synergy: %v", mongoErr)
+		s.logger.WithContext(ctx).Error("Failed to get post synergy: %v", mongoErr)
 		return nil, nil, network.NewInternalServerError(
 			"Failed to get post synergy",
 			fmt.Sprintf("Failed to retrieve synergy count for post %s. Context - [ Query Failed ]", postId),
@@ -901,7 +1232,7 @@ func (s *postService) LikePost(userId string, postId string) (*bool, *int, netwo
 			mongoErr)
 	}
 	//get post interaction
-	postInteraction, mongoErr := s.postInteractionQueryBuilder.SingleQuery().FindOne(
+	postInteraction, mongoErr := s.postInteractionQueryBuilder.SingleQuery(ctx).FindOne(
 		bson.M{"postId": postId, "userId": userId},
 		options.FindOne().SetProjection(bson.M{"interactionType": 1}),
 	)
@@ -911,7 +1242,7 @@ func (s *postService) LikePost(userId string, postId string) (*bool, *int, netwo
 			falseValue := false
 			return &falseValue, &postSynergy.Synergy, nil
 		}
-		s.logger.Error("Failed to get post interaction: %v", mongoErr)
+		s.logger.WithContext(ctx).Error("Failed to get post interaction: %v", mongoErr)
 		return nil, nil, network.NewInternalServerError(
 			"Failed to get post interaction",
 			fmt.Sprintf("Failed to retrieve interaction for user %s on post %s. Context - [ Query Failed ]", userId, postId),
@@ -933,10 +1264,17 @@ func (s *postService) LikePost(userId string, postId string) (*bool, *int, netwo
 	return isLiked, &postSynergy.Synergy, nil
 }
 
-func (s *postService) DislikePost(userId string, postId string) (*bool, *int, network.ApiError) {
-	err := s.toggleInteraction(userId, postId, model.InteractionTypeDislike)
+func (s *postService) DislikePost(ctx context.Context, userId string, postId string) (*bool, *int, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "PostService.DislikePost")
+	defer span.End()
+	defer s.invalidatePost(ctx, postId)
+
+	if guardErr := s.guardPostWrite(ctx, userId, postId); guardErr != nil {
+		return nil, nil, guardErr
+	}
+	err := s.toggleInteraction(ctx, userId, postId, model.InteractionTypeDislike)
 	if err != nil {
-		s.logger.Error("Failed to toggle dislike interaction: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to toggle dislike interaction: %v", err)
 		return nil, nil, network.NewInternalServerError(
 			"Failed to toggle dislike interaction",
 			fmt.Sprintf("Failed to toggle dislike interaction for user %s on post %s. Context - [ Action Failed ]", userId, postId),
@@ -944,12 +1282,12 @@ func (s *postService) DislikePost(userId string, postId string) (*bool, *int, ne
 			err)
 	}
 	//get post synergy
-	postSynergy, mongoErr := s.postQueryBuilder.SingleQuery().FindOne(
+	postSynergy, mongoErr := s.postQueryBuilder.SingleQuery(ctx).FindOne(
 		bson.M{"postId": postId},
 		options.FindOne().SetProjection(bson.M{"synergy": -1}),
 	)
 	if mongoErr != nil {
-		s.logger.Error("Failed to get post synergy: %v", mongoErr)
+		s.logger.WithContext(ctx).Error("Failed to get post synergy: %v", mongoErr)
 		return nil, nil, network.NewInternalServerError(
 			"Failed to get post synergy",
 			fmt.Sprintf("Failed to retrieve synergy count for post %s. Context - [ Query Failed ]", postId),
@@ -957,7 +1295,7 @@ func (s *postService) DislikePost(userId string, postId string) (*bool, *int, ne
 			mongoErr)
 	}
 	//get post interaction
-	postInteraction, mongoErr := s.postInteractionQueryBuilder.SingleQuery().FindOne(
+	postInteraction, mongoErr := s.postInteractionQueryBuilder.SingleQuery(ctx).FindOne(
 		bson.M{"postId": postId, "userId": userId},
 		options.FindOne().SetProjection(bson.M{"interactionType": 1}),
 	)
@@ -967,7 +1305,7 @@ func (s *postService) DislikePost(userId string, postId string) (*bool, *int, ne
 			falseValue := false
 			return &falseValue, &postSynergy.Synergy, nil
 		}
-		s.logger.Error("Failed to get post interaction: %v", mongoErr)
+		s.logger.WithContext(ctx).Error("Failed to get post interaction: %v", mongoErr)
 		return nil, nil, network.NewInternalServerError(
 			"Failed to get post interaction",
 			fmt.Sprintf("Failed to retrieve interaction for user %s on post %s. Context - [ Query Failed ]", userId, postId),
@@ -990,14 +1328,26 @@ func (s *postService) DislikePost(userId string, postId string) (*bool, *int, ne
 	return isLiked, &postSynergy.Synergy, nil
 }
 
-func (s *postService) toggleInteraction(userId string, postId string, interactionType model.InteractionType) network.ApiError {
+// guardPostWrite loads the post and runs the vote through the content guard
+func (s *postService) guardPostWrite(ctx context.Context, userId string, postId string) network.ApiError {
+	post, err := s.postQueryBuilder.SingleQuery(ctx).FindOne(bson.M{"postId": postId, "status": model.PostStatusActive}, nil)
+	if err != nil {
+		if mongo.IsNoDocumentFoundError(err) {
+			return NewPostNotFoundError(postId)
+		}
+		return NewDBError("finding post", err.Error())
+	}
+	return s.contentGuard.CheckPostWrite(ctx, userId, post)
+}
+
+func (s *postService) toggleInteraction(ctx context.Context, userId string, postId string, interactionType model.InteractionType) network.ApiError {
 	action := "liking"
 	if interactionType == model.InteractionTypeDislike {
 		action = "disliking"
 	}
-	s.logger.Info("%s post with ID: %s by user: %s", action, postId, userId)
+	s.logger.WithContext(ctx).Info("%s post with ID: %s by user: %s", action, postId, userId)
 
-	tx := s.transaction.GetTransaction(mongo.DefaultShortTransactionTimeout)
+	tx := s.transaction.GetTransaction(ctx, mongo.DefaultShortTransactionTimeout)
 	// if err := tx.Start(); err != nil {
 	// 	s.logger.Error("Failed to start transaction: %v", err)
 	// 	return network.NewInternalServerError("Failed to start transaction", network.DB_ERROR, err)
@@ -1016,7 +1366,7 @@ func (s *postService) toggleInteraction(userId string, postId string, interactio
 			},
 		)
 		if err != nil {
-			s.logger.Error("Failed to get post interactions: %v", err)
+			s.logger.WithContext(ctx).Error("Failed to get post interactions: %v", err)
 			return network.NewInternalServerError(
 				"Failed to get post interactions",
 				fmt.Sprintf("Failed to retrieve interactions for user %s on post %s. Context - [ Query Failed ]", userId, postId),
@@ -1026,7 +1376,7 @@ func (s *postService) toggleInteraction(userId string, postId string, interactio
 
 		var existingInteractions []model.PostInteraction
 		if err := cursor.All(&existingInteractions); err != nil {
-			s.logger.Error("Failed to decode post interactions: %v", err)
+			s.logger.WithContext(ctx).Error("Failed to decode post interactions: %v", err)
 			return network.NewInternalServerError(
 				"Failed to decode post interactions",
 				fmt.Sprintf("Failed to process interaction data for user %s on post %s. Context - [ Data Processing Error ]", userId, postId),
@@ -1069,12 +1419,12 @@ func (s *postService) toggleInteraction(userId string, postId string, interactio
 			}
 		} else {
 			// Clean up duplicate interactions
-			s.logger.Warn("Multiple interactions found for user %s on post %s - cleaning up", userId, postId)
+			s.logger.WithContext(ctx).Warn("Multiple interactions found for user %s on post %s - cleaning up", userId, postId)
 			_, deleteErr := postInteractionCollection.DeleteMany(
 				bson.M{"postId": postId, "userId": userId},
 			)
 			if deleteErr != nil {
-				s.logger.Error("Failed to clean up duplicate interactions: %v", deleteErr)
+				s.logger.WithContext(ctx).Error("Failed to clean up duplicate interactions: %v", deleteErr)
 				return network.NewInternalServerError(
 					"Failed to clean up interactions",
 					fmt.Sprintf("Failed to remove duplicate interactions for user %s on post %s. Context - [ Cleanup Failed ]", userId, postId),
@@ -1099,7 +1449,7 @@ func (s *postService) toggleInteraction(userId string, postId string, interactio
 		)
 
 		if updateResult.Err() != nil {
-			s.logger.Error("Failed to update post synergy: %v", updateResult.Err())
+			s.logger.WithContext(ctx).Error("Failed to update post synergy: %v", updateResult.Err())
 			return network.NewInternalServerError(
 				"Failed to update post",
 				fmt.Sprintf("Failed to update synergy for post %s. Context - [ Update Failed ]", postId),
@@ -1113,7 +1463,7 @@ func (s *postService) toggleInteraction(userId string, postId string, interactio
 				bson.M{"_id": objID},
 			)
 			if deleteErr != nil {
-				s.logger.Error("Failed to remove existing interaction: %v", deleteErr)
+				s.logger.WithContext(ctx).Error("Failed to remove existing interaction: %v", deleteErr)
 				return network.NewInternalServerError(
 					"Failed to update interaction",
 					fmt.Sprintf("Failed to remove existing interaction for user %s on post %s. Context - [ Delete Failed ]", userId, postId),
@@ -1127,9 +1477,9 @@ func (s *postService) toggleInteraction(userId string, postId string, interactio
 			_, insertErr := postInteractionCollection.InsertOne(postInteraction)
 			if insertErr != nil {
 				if mongo.IsDuplicateKeyError(insertErr) {
-					s.logger.Warn("Post interaction already exists (race condition): %v", insertErr)
+					s.logger.WithContext(ctx).Warn("Post interaction already exists (race condition): %v", insertErr)
 				} else {
-					s.logger.Error("Failed to insert post interaction: %v", insertErr)
+					s.logger.WithContext(ctx).Error("Failed to insert post interaction: %v", insertErr)
 					return network.NewInternalServerError(
 						"Failed to insert interaction",
 						fmt.Sprintf("Failed to record interaction for user %s on post %s. Context - [ Insert Failed ]", userId, postId),
@@ -1143,10 +1493,10 @@ func (s *postService) toggleInteraction(userId string, postId string, interactio
 
 	if err != nil {
 		if network.IsApiError(err) {
-			s.logger.Error("Failed to toggle interaction: %v", err)
+			s.logger.WithContext(ctx).Error("Failed to toggle interaction: %v", err)
 			return network.AsApiError(err)
 		}
-		s.logger.Error("Failed to commit transaction: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to commit transaction: %v", err)
 		return network.NewInternalServerError(
 			"Failed to commit transaction",
 			fmt.Sprintf("Failed to commit interaction changes for user %s on post %s. Context - [ Transaction Failed ]", userId, postId),
@@ -1154,13 +1504,17 @@ func (s *postService) toggleInteraction(userId string, postId string, interactio
 			err)
 	}
 
-	s.logger.Info("Post interaction updated successfully for post ID: %s", postId)
+	s.logger.WithContext(ctx).Info("Post interaction updated successfully for post ID: %s", postId)
 	return nil
 }
 
-func (s *postService) SavePost(userId string, postId string) network.ApiError {
-	s.logger.Info("Saving post with ID: %s", postId)
-	tx := s.transaction.GetTransaction(mongo.DefaultShortTransactionTimeout)
+func (s *postService) SavePost(ctx context.Context, userId string, postId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "PostService.SavePost")
+	defer span.End()
+	defer s.invalidatePost(ctx, postId)
+
+	s.logger.WithContext(ctx).Info("Saving post with ID: %s", postId)
+	tx := s.transaction.GetTransaction(ctx, mongo.DefaultShortTransactionTimeout)
 
 	err := tx.PerformSingleTransaction(func(session mongo.TransactionSession) error {
 		postInteractionCollection := session.Collection(model.PostInteractionCollectionName)
@@ -1169,7 +1523,7 @@ func (s *postService) SavePost(userId string, postId string) network.ApiError {
 			bson.M{"postId": postId, "userId": userId, "interactionType": model.InteractionTypeSave},
 		)
 		if mongoErr != nil {
-			s.logger.Error("Failed to check if post is already saved: %v", mongoErr)
+			s.logger.WithContext(ctx).Error("Failed to check if post is already saved: %v", mongoErr)
 			return network.NewInternalServerError(
 				"Failed to check if post is already saved",
 				fmt.Sprintf("Failed to check save status for user %s on post %s. Context - [ Query Failed ]", userId, postId),
@@ -1177,7 +1531,7 @@ func (s *postService) SavePost(userId string, postId string) network.ApiError {
 				mongoErr)
 		}
 		if exists > 0 {
-			s.logger.Warn("Post already saved by user: %s", postId)
+			s.logger.WithContext(ctx).Warn("Post already saved by user: %s", postId)
 			return nil
 		}
 
@@ -1191,7 +1545,7 @@ func (s *postService) SavePost(userId string, postId string) network.ApiError {
 			},
 		)
 		if err.Err() != nil {
-			s.logger.Error("Failed to save post: %v", err)
+			s.logger.WithContext(ctx).Error("Failed to save post: %v", err)
 			return network.NewInternalServerError(
 				"Failed to save post",
 				fmt.Sprintf("Failed to update save count for post %s. Context - [ Update Failed ]", postId),
@@ -1202,7 +1556,7 @@ func (s *postService) SavePost(userId string, postId string) network.ApiError {
 		postInteraction := model.NewPostInteraction(userId, postId, model.InteractionTypeSave)
 		_, insertErr := postInteractionCollection.InsertOne(postInteraction)
 		if insertErr != nil {
-			s.logger.Error("Failed to insert post interaction: %v", insertErr)
+			s.logger.WithContext(ctx).Error("Failed to insert post interaction: %v", insertErr)
 			return network.NewInternalServerError(
 				"Failed to insert post interaction",
 				fmt.Sprintf("Failed to record save interaction for user %s on post %s. Context - [ Insert Failed ]", userId, postId),
@@ -1215,10 +1569,10 @@ func (s *postService) SavePost(userId string, postId string) network.ApiError {
 
 	if err != nil {
 		if network.IsApiError(err) {
-			s.logger.Error("Failed to save post: %v", err)
+			s.logger.WithContext(ctx).Error("Failed to save post: %v", err)
 			return network.AsApiError(err)
 		}
-		s.logger.Error("Failed to commit transaction: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to commit transaction: %v", err)
 		return network.NewInternalServerError(
 			"Failed to commit transaction",
 			fmt.Sprintf("Failed to commit save action for user %s on post %s. Context - [ Transaction Failed ]", userId, postId),
@@ -1227,13 +1581,17 @@ func (s *postService) SavePost(userId string, postId string) network.ApiError {
 		)
 	}
 
-	s.logger.Info("Post saved successfully: %s", postId)
+	s.logger.WithContext(ctx).Info("Post saved successfully: %s", postId)
 	return nil
 }
 
-func (s *postService) SharePost(userId string, postId string) network.ApiError {
-	s.logger.Info("Sharing post with ID: %s", postId)
-	tx := s.transaction.GetTransaction(mongo.DefaultShortTransactionTimeout)
+func (s *postService) SharePost(ctx context.Context, userId string, postId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "PostService.SharePost")
+	defer span.End()
+	defer s.invalidatePost(ctx, postId)
+
+	s.logger.WithContext(ctx).Info("Sharing post with ID: %s", postId)
+	tx := s.transaction.GetTransaction(ctx, mongo.DefaultShortTransactionTimeout)
 
 	err := tx.PerformSingleTransaction(func(session mongo.TransactionSession) error {
 		postInteractionCollection := session.Collection(model.PostInteractionCollectionName)
@@ -1242,7 +1600,7 @@ func (s *postService) SharePost(userId string, postId string) network.ApiError {
 			bson.M{"postId": postId, "userId": userId, "interactionType": model.InteractionTypeShare},
 		)
 		if mongoErr != nil {
-			s.logger.Error("Failed to check if post is already shared: %v", mongoErr)
+			s.logger.WithContext(ctx).Error("Failed to check if post is already shared: %v", mongoErr)
 			return network.NewInternalServerError(
 				"Failed to check if post is already shared",
 				fmt.Sprintf("Failed to check share status for user %s on post %s. Context - [ Query Failed ]", userId, postId),
@@ -1250,7 +1608,7 @@ func (s *postService) SharePost(userId string, postId string) network.ApiError {
 				mongoErr)
 		}
 		if exists > 0 {
-			s.logger.Warn("Post already shared by user: %s", postId)
+			s.logger.WithContext(ctx).Warn("Post already shared by user: %s", postId)
 			return nil
 		}
 
@@ -1264,7 +1622,7 @@ func (s *postService) SharePost(userId string, postId string) network.ApiError {
 			},
 		)
 		if err.Err() != nil {
-			s.logger.Error("Failed to update share count: %v", err)
+			s.logger.WithContext(ctx).Error("Failed to update share count: %v", err)
 			return network.NewInternalServerError(
 				"Failed to update share count",
 				fmt.Sprintf("Failed to update share count for post %s. Context - [ Update Failed ]", postId),
@@ -1275,7 +1633,7 @@ func (s *postService) SharePost(userId string, postId string) network.ApiError {
 		postInteraction := model.NewPostInteraction(userId, postId, model.InteractionTypeShare)
 		_, insertErr := postInteractionCollection.InsertOne(postInteraction)
 		if insertErr != nil {
-			s.logger.Error("Failed to insert post share interaction: %v", insertErr)
+			s.logger.WithContext(ctx).Error("Failed to insert post share interaction: %v", insertErr)
 			return network.NewInternalServerError(
 				"Failed to insert post share interaction",
 				fmt.Sprintf("Failed to record share interaction for user %s on post %s. Context - [ Insert Failed ]", userId, postId),
@@ -1288,10 +1646,10 @@ func (s *postService) SharePost(userId string, postId string) network.ApiError {
 
 	if err != nil {
 		if network.IsApiError(err) {
-			s.logger.Error("Failed to share post: %v", err)
+			s.logger.WithContext(ctx).Error("Failed to share post: %v", err)
 			return network.AsApiError(err)
 		}
-		s.logger.Error("Failed to commit transaction: %v", err)
+		s.logger.WithContext(ctx).Error("Failed to commit transaction: %v", err)
 		return network.NewInternalServerError(
 			"Failed to commit transaction",
 			fmt.Sprintf("Failed to commit share action for user %s on post %s. Context - [ Transaction Failed ]", userId, postId),
@@ -1300,18 +1658,21 @@ func (s *postService) SharePost(userId string, postId string) network.ApiError {
 		)
 	}
 
-	s.logger.Info("Post shared successfully: %s", postId)
+	s.logger.WithContext(ctx).Info("Post shared successfully: %s", postId)
 	return nil
 }
 
-func (s *postService) GetPostsByUserId(userId string, page int, limit int) (posts []*model.Post, numOfPosts int, err network.ApiError) {
-	s.logger.Info("Getting posts for user with ID: %s", userId)
+func (s *postService) GetPostsByUserId(ctx context.Context, userId string, page int, limit int) (posts []*model.Post, numOfPosts int, err network.ApiError) {
+	ctx, span := tracing.Start(ctx, "PostService.GetPostsByUserId")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Getting posts for user with ID: %s", userId)
 	filter := bson.M{"authorId": userId, "status": model.PostStatusActive}
 	options := options.Find().SetSort(bson.D{primitive.E{Key: "createdAt", Value: -1}})
 
-	dbPosts, mongoErr := s.postQueryBuilder.SingleQuery().FilterPaginated(filter, int64(page), int64(limit), options)
+	dbPosts, mongoErr := s.postQueryBuilder.SingleQuery(ctx).FilterPaginated(filter, int64(page), int64(limit), options)
 	if mongoErr != nil {
-		s.logger.Error("Failed to get posts: %v", mongoErr)
+		s.logger.WithContext(ctx).Error("Failed to get posts: %v", mongoErr)
 		return nil, 0, network.NewInternalServerError(
 			"Failed to get posts",
 			fmt.Sprintf("Failed to retrieve posts for user %s. Context - [ Query Failed ]", userId),
@@ -1319,9 +1680,9 @@ func (s *postService) GetPostsByUserId(userId string, page int, limit int) (post
 			mongoErr)
 	}
 
-	nPosts, mongoErr := s.postQueryBuilder.SingleQuery().FilterCount(filter)
+	nPosts, mongoErr := s.postQueryBuilder.SingleQuery(ctx).FilterCount(filter)
 	if mongoErr != nil {
-		s.logger.Error("Failed to count posts: %v", mongoErr)
+		s.logger.WithContext(ctx).Error("Failed to count posts: %v", mongoErr)
 		return nil, 0, network.NewInternalServerError(
 			"Failed to count posts",
 			fmt.Sprintf("Failed to count posts for user %s. Context - [ Query Failed ]", userId),
@@ -1329,18 +1690,21 @@ func (s *postService) GetPostsByUserId(userId string, page int, limit int) (post
 			mongoErr)
 	}
 
-	s.logger.Info("Posts retrieved successfully for user with ID: %s", userId)
+	s.logger.WithContext(ctx).Info("Posts retrieved successfully for user with ID: %s", userId)
 	return dbPosts, int(nPosts), nil
 }
 
-func (s *postService) GetPostsByCommunityId(communityId string, page int, limit int) (posts []*model.Post, numOfPosts int, err network.ApiError) {
-	s.logger.Info("Getting posts for community with ID: %s", communityId)
+func (s *postService) GetPostsByCommunityId(ctx context.Context, communityId string, page int, limit int) (posts []*model.Post, numOfPosts int, err network.ApiError) {
+	ctx, span := tracing.Start(ctx, "PostService.GetPostsByCommunityId")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Getting posts for community with ID: %s", communityId)
 	filter := bson.M{"communityId": communityId, "status": model.PostStatusActive}
 	options := options.Find().SetSort(bson.D{primitive.E{Key: "createdAt", Value: -1}})
 
-	dbPosts, mongoErr := s.postQueryBuilder.SingleQuery().FilterPaginated(filter, int64(page), int64(limit), options)
+	dbPosts, mongoErr := s.postQueryBuilder.SingleQuery(ctx).FilterPaginated(filter, int64(page), int64(limit), options)
 	if mongoErr != nil {
-		s.logger.Error("Failed to get posts: %v", mongoErr)
+		s.logger.WithContext(ctx).Error("Failed to get posts: %v", mongoErr)
 		return nil, 0, network.NewInternalServerError(
 			"Failed to get posts",
 			fmt.Sprintf("Failed to retrieve posts for community %s. Context - [ Query Failed ]", communityId),
@@ -1348,9 +1712,9 @@ func (s *postService) GetPostsByCommunityId(communityId string, page int, limit
 			mongoErr)
 	}
 
-	nPosts, mongoErr := s.postQueryBuilder.SingleQuery().FilterCount(filter)
+	nPosts, mongoErr := s.postQueryBuilder.SingleQuery(ctx).FilterCount(filter)
 	if mongoErr != nil {
-		s.logger.Error("Failed to count posts: %v", mongoErr)
+		s.logger.WithContext(ctx).Error("Failed to count posts: %v", mongoErr)
 		return nil, 0, network.NewInternalServerError(
 			"Failed to count posts",
 			fmt.Sprintf("Failed to count posts for community %s. Context - [ Query Failed ]", communityId),
@@ -1358,16 +1722,17 @@ func (s *postService) GetPostsByCommunityId(communityId string, page int, limit
 			mongoErr)
 	}
 
-	s.logger.Info("Posts retrieved successfully for community with ID: %s", communityId)
+	s.logger.WithContext(ctx).Info("Posts retrieved successfully for community with ID: %s", communityId)
 	return dbPosts, int(nPosts), nil
 }
 
 // togglePostField is a helper that toggles a boolean field on a post after verifying moderator permissions
-func (s *postService) togglePostField(userId, postId, field string, pinAction, unpinAction moderatorModel.ModActionType) (bool, network.ApiError) {
-	s.logger.Info("Toggling %s for post %s by user %s", field, postId, userId)
+func (s *postService) togglePostField(ctx context.Context, userId, postId, field string, pinAction, unpinAction moderatorModel.ModActionType) (bool, network.ApiError) {
+	defer s.invalidatePost(ctx, postId)
+	s.logger.WithContext(ctx).Info("Toggling %s for post %s by user %s", field, postId, userId)
 
 	// Find the post
-	post, err := s.postQueryBuilder.SingleQuery().FindOne(
+	post, err := s.postQueryBuilder.SingleQuery(ctx).FindOne(
 		bson.M{"postId": postId, "status": model.PostStatusActive},
 		nil,
 	)
@@ -1379,7 +1744,7 @@ func (s *postService) togglePostField(userId, postId, field string, pinAction, u
 	}
 
 	// Check moderator permission
-	isMod, modErr := s.moderatorService.IsModeratorOrHigher(userId, post.CommunityId)
+	isMod, modErr := s.moderatorService.IsModeratorOrHigher(ctx, userId, post.CommunityId)
 	if modErr != nil {
 		return false, modErr
 	}
@@ -1407,7 +1772,7 @@ func (s *postService) togglePostField(userId, postId, field string, pinAction, u
 		field:       newValue,
 		"updatedAt": primitive.NewDateTimeFromTime(time.Now()),
 	}}
-	_, updateErr := s.postQueryBuilder.SingleQuery().UpdateOne(filter, update, nil)
+	_, updateErr := s.postQueryBuilder.SingleQuery(ctx).UpdateOne(filter, update, nil)
 	if updateErr != nil {
 		return false, NewDBError(fmt.Sprintf("toggling %s", field), updateErr.Error())
 	}
@@ -1417,24 +1782,291 @@ func (s *postService) togglePostField(userId, postId, field string, pinAction, u
 	if !newValue {
 		action = unpinAction
 	}
-	go s.moderatorService.LogModAction(post.CommunityId, userId, action, postId, "post", fmt.Sprintf("%s set to %v", field, newValue))
+	background.Go(func() {
+		s.moderatorService.LogModAction(context.WithoutCancel(ctx), post.CommunityId, userId, action, postId, "post", fmt.Sprintf("%s set to %v", field, newValue))
+	})
 
-	s.logger.Info("Successfully toggled %s to %v for post %s", field, newValue, postId)
+	s.logger.WithContext(ctx).Info("Successfully toggled %s to %v for post %s", field, newValue, postId)
 	return newValue, nil
 }
 
-func (s *postService) ToggleStickyPost(userId string, postId string) (bool, network.ApiError) {
-	return s.togglePostField(userId, postId, "isStickied", moderatorModel.ActionPinPost, moderatorModel.ActionUnpinPost)
+func (s *postService) ToggleStickyPost(ctx context.Context, userId string, postId string) (bool, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "PostService.ToggleStickyPost")
+	defer span.End()
+
+	return s.togglePostField(ctx, userId, postId, "isStickied", moderatorModel.ActionPinPost, moderatorModel.ActionUnpinPost)
+}
+
+func (s *postService) ToggleLockPost(ctx context.Context, userId string, postId string) (bool, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "PostService.ToggleLockPost")
+	defer span.End()
+
+	return s.togglePostField(ctx, userId, postId, "isLocked", moderatorModel.ActionLockPost, moderatorModel.ActionUnlockPost)
 }
 
-func (s *postService) ToggleLockPost(userId string, postId string) (bool, network.ApiError) {
-	return s.togglePostField(userId, postId, "isLocked", moderatorModel.ActionLockPost, moderatorModel.ActionUnlockPost)
+func (s *postService) ToggleArchivePost(ctx context.Context, userId string, postId string) (bool, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "PostService.ToggleArchivePost")
+	defer span.End()
+
+	return s.togglePostField(ctx, userId, postId, "isArchived", moderatorModel.ActionLockPost, moderatorModel.ActionUnlockPost)
 }
 
-func (s *postService) ToggleArchivePost(userId string, postId string) (bool, network.ApiError) {
-	return s.togglePostField(userId, postId, "isArchived", moderatorModel.ActionLockPost, moderatorModel.ActionUnlockPost)
+func (s *postService) ToggleNSFWPost(ctx context.Context, userId string, postId string) (bool, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "PostService.ToggleNSFWPost")
+	defer span.End()
+
+	return s.togglePostField(ctx, userId, postId, "isNSFW", moderatorModel.ActionMarkNSFW, moderatorModel.ActionMarkNSFW)
+}
+
+// SetPostReaction sets or changes the caller's reaction on a post. The interaction and the
+// post's reaction counts are updated in one transaction so a user only ever holds one reaction.
+func (s *postService) SetPostReaction(ctx context.Context, userId string, postId string, reaction model.ReactionType) (*common.ReactionResponse, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "PostService.SetPostReaction")
+	defer span.End()
+	defer s.invalidatePost(ctx, postId)
+
+	s.logger.WithContext(ctx).Info("Setting reaction %s on post %s by user %s", reaction, postId, userId)
+	if guardErr := s.guardPostWrite(ctx, userId, postId); guardErr != nil {
+		return nil, guardErr
+	}
+
+	tx := s.transaction.GetTransaction(ctx, mongo.DefaultShortTransactionTimeout)
+	err := tx.PerformSingleTransaction(func(session mongo.TransactionSession) error {
+		return s.applyPostReaction(ctx, session, userId, postId, reaction)
+	})
+	if err != nil {
+		return nil, s.reactionTransactionError(ctx, err, userId, postId)
+	}
+
+	counts, countErr := s.getPostReactionCounts(ctx, postId)
+	if countErr != nil {
+		return nil, countErr
+	}
+	return common.NewReactionResponse(reaction, counts), nil
 }
 
-func (s *postService) ToggleNSFWPost(userId string, postId string) (bool, network.ApiError) {
-	return s.togglePostField(userId, postId, "isNSFW", moderatorModel.ActionMarkNSFW, moderatorModel.ActionMarkNSFW)
+// applyPostReaction records the reaction of the user in the session, replacing the one the user held
+// and moving the post counts from the old reaction to the new one
+func (s *postService) applyPostReaction(ctx context.Context, session mongo.TransactionSession, userId string, postId string, reaction model.ReactionType) error {
+	interactionCollection := session.Collection(model.PostInteractionCollectionName)
+	existing, lookupErr := s.findPostReaction(ctx, session, userId, postId)
+	if lookupErr != nil {
+		return lookupErr
+	}
+	if existing != nil && existing.Reaction == reaction {
+		return nil
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	inc := bson.M{"reactionCounts." + string(reaction): 1}
+	if existing != nil {
+		inc["reactionCounts."+string(existing.Reaction)] = -1
+		_, updateErr := interactionCollection.UpdateOne(
+			bson.M{"_id": existing.Id},
+			bson.M{"$set": bson.M{"reaction": reaction, "updatedAt": now}},
+		)
+		if updateErr != nil {
+			s.logger.WithContext(ctx).Error("Failed to change post reaction: %v", updateErr)
+			return network.NewInternalServerError(
+				"Failed to update reaction",
+				fmt.Sprintf("Failed to change reaction for user %s on post %s. Context - [ Update Failed ]", userId, postId),
+				network.DB_ERROR,
+				updateErr)
+		}
+	} else {
+		_, insertErr := interactionCollection.InsertOne(model.NewPostReaction(userId, postId, reaction))
+		if insertErr != nil {
+			if mongo.IsDuplicateKeyError(insertErr) {
+				return network.NewConflictError(
+					"Reaction already being updated",
+					fmt.Sprintf("Another reaction for user %s on post %s was recorded concurrently. Please retry. [Context: postId=%s]", userId, postId, postId),
+					insertErr)
+			}
+			s.logger.WithContext(ctx).Error("Failed to insert post reaction: %v", insertErr)
+			return network.NewInternalServerError(
+				"Failed to insert reaction",
+				fmt.Sprintf("Failed to record reaction for user %s on post %s. Context - [ Insert Failed ]", userId, postId),
+				network.DB_ERROR,
+				insertErr)
+		}
+	}
+
+	_, updateErr := session.Collection(model.PostCollectionName).UpdateOne(
+		bson.M{"postId": postId},
+		bson.M{"$inc": inc, "$set": bson.M{"updatedAt": now}},
+	)
+	if updateErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to update post reaction counts: %v", updateErr)
+		return network.NewInternalServerError(
+			"Failed to update post",
+			fmt.Sprintf("Failed to update reaction counts for post %s. Context - [ Update Failed ]", postId),
+			network.DB_ERROR,
+			updateErr)
+	}
+	return nil
+}
+
+// RemovePostReaction removes the caller's reaction from a post, it is a no-op when there is none
+func (s *postService) RemovePostReaction(ctx context.Context, userId string, postId string) (*common.ReactionResponse, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "PostService.RemovePostReaction")
+	defer span.End()
+	defer s.invalidatePost(ctx, postId)
+
+	s.logger.WithContext(ctx).Info("Removing reaction on post %s by user %s", postId, userId)
+	if guardErr := s.guardPostWrite(ctx, userId, postId); guardErr != nil {
+		return nil, guardErr
+	}
+
+	tx := s.transaction.GetTransaction(ctx, mongo.DefaultShortTransactionTimeout)
+	err := tx.PerformSingleTransaction(func(session mongo.TransactionSession) error {
+		return s.clearPostReaction(ctx, session, userId, postId)
+	})
+	if err != nil {
+		return nil, s.reactionTransactionError(ctx, err, userId, postId)
+	}
+
+	counts, countErr := s.getPostReactionCounts(ctx, postId)
+	if countErr != nil {
+		return nil, countErr
+	}
+	return common.NewReactionResponse("", counts), nil
+}
+
+// clearPostReaction deletes the reaction of the user in the session and takes it off the post counts
+func (s *postService) clearPostReaction(ctx context.Context, session mongo.TransactionSession, userId string, postId string) error {
+	existing, lookupErr := s.findPostReaction(ctx, session, userId, postId)
+	if lookupErr != nil {
+		return lookupErr
+	}
+	if existing == nil {
+		return nil
+	}
+
+	deleted, deleteErr := session.Collection(model.PostInteractionCollectionName).DeleteOne(bson.M{"_id": existing.Id})
+	if deleteErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to remove post reaction: %v", deleteErr)
+		return network.NewInternalServerError(
+			"Failed to remove reaction",
+			fmt.Sprintf("Failed to remove reaction for user %s on post %s. Context - [ Delete Failed ]", userId, postId),
+			network.DB_ERROR,
+			deleteErr)
+	}
+	if deleted == 0 {
+		return nil
+	}
+
+	_, updateErr := session.Collection(model.PostCollectionName).UpdateOne(
+		bson.M{"postId": postId},
+		bson.M{
+			"$inc": bson.M{"reactionCounts." + string(existing.Reaction): -1},
+			"$set": bson.M{"updatedAt": primitive.NewDateTimeFromTime(time.Now())},
+		},
+	)
+	if updateErr != nil {
+		s.logger.WithContext(ctx).Error("Failed to update post reaction counts: %v", updateErr)
+		return network.NewInternalServerError(
+			"Failed to update post",
+			fmt.Sprintf("Failed to update reaction counts for post %s. Context - [ Update Failed ]", postId),
+			network.DB_ERROR,
+			updateErr)
+	}
+	return nil
+}
+
+// GetPostReactors lists the users who reacted to a post, newest first, optionally filtered by reaction
+func (s *postService) GetPostReactors(ctx context.Context, postId string, reaction model.ReactionType, cursor string, limit int) ([]*model.PublicPostReactor, *mongo.CursorResult, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "PostService.GetPostReactors")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Debug("GetPostReactors - postId: %s, reaction: %s, limit: %d", postId, reaction, limit)
+	match := bson.M{"postId": postId, "interactionType": model.InteractionTypeReaction}
+	if reaction != "" {
+		match["reaction"] = reaction
+	}
+
+	aggregate := s.reactorAggregateBuilder.SingleAggregate(ctx)
+	aggregate.Match(match)
+	aggregate.Lookup("users", "userId", "userId", "user")
+	aggregate.AddFields(bson.M{
+		"user": bson.M{"$arrayElemAt": bson.A{"$user", 0}},
+	})
+	aggregate.Project(bson.M{
+		"user": bson.M{
+			"userId":     "$user.userId",
+			"username":   "$user.username",
+			"avatar":     "$user.avatar.profile.url",
+			"background": "$user.avatar.background.url",
+			"status":     "$user.status",
+		},
+		"reaction":  1,
+		"reactedAt": "$createdAt",
+		"createdAt": 1,
+	})
+
+	reactors, page, err := aggregate.ExecCursorPaginated(mongo.NewCursorQuery("createdAt", int64(limit), cursor))
+	if err != nil {
+		if mongo.IsInvalidCursorError(err) {
+			return nil, nil, NewInvalidCursorError(cursor)
+		}
+		s.logger.WithContext(ctx).Error("Failed to get post reactors - %v", err)
+		return nil, nil, network.NewInternalServerError(
+			"Failed to get reactions",
+			fmt.Sprintf("It seems the reactions for post '%s' could not be retrieved - Aggregation failed. Please try again later. [Context: postId=%s]", postId, postId),
+			network.DB_ERROR,
+			err,
+		)
+	}
+	if reactors == nil {
+		return []*model.PublicPostReactor{}, page, nil
+	}
+	return reactors, page, nil
+}
+
+func (s *postService) findPostReaction(ctx context.Context, session mongo.TransactionSession, userId string, postId string) (*model.PostInteraction, network.ApiError) {
+	var existing model.PostInteraction
+	err := session.Collection(model.PostInteractionCollectionName).FindOne(bson.M{
+		"postId":          postId,
+		"userId":          userId,
+		"interactionType": model.InteractionTypeReaction,
+	}).Decode(&existing)
+	if err != nil {
+		if mongo.IsNoDocumentFoundError(err) {
+			return nil, nil
+		}
+		s.logger.WithContext(ctx).Error("Failed to get post reaction: %v", err)
+		return nil, network.NewInternalServerError(
+			"Failed to get post reaction",
+			fmt.Sprintf("Failed to retrieve reaction for user %s on post %s. Context - [ Query Failed ]", userId, postId),
+			network.DB_ERROR,
+			err)
+	}
+	return &existing, nil
+}
+
+func (s *postService) getPostReactionCounts(ctx context.Context, postId string) (map[model.ReactionType]int, network.ApiError) {
+	postModel, err := s.postQueryBuilder.SingleQuery(ctx).FindOne(
+		bson.M{"postId": postId},
+		options.FindOne().SetProjection(bson.M{"reactionCounts": 1}),
+	)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to get post reaction counts - %v", err)
+		if mongo.IsNoDocumentFoundError(err) {
+			return nil, NewPostNotFoundError(postId)
+		}
+		return nil, NewDBError("getting post reaction counts", err.Error())
+	}
+	return postModel.ReactionCounts, nil
+}
+
+func (s *postService) reactionTransactionError(ctx context.Context, err error, userId string, postId string) network.ApiError {
+	if network.IsApiError(err) {
+		s.logger.WithContext(ctx).Error("Failed to update reaction: %v", err)
+		return network.AsApiError(err)
+	}
+	s.logger.WithContext(ctx).Error("Failed to commit transaction: %v", err)
+	return network.NewInternalServerError(
+		"Failed to commit transaction",
+		fmt.Sprintf("Failed to commit reaction changes for user %s on post %s. Context - [ Transaction Failed ]", userId, postId),
+		network.DB_ERROR,
+		err)
 }
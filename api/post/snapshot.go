@@ -0,0 +1,61 @@
+package post
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sync-backend/arch/redis"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// feedSnapshotStore keeps the ranked post ids of a feed snapshot, every page of the snapshot is cut
+// from the same order however the scores move afterwards
+type feedSnapshotStore interface {
+	save(ctx context.Context, key string, postIds []string, ttl time.Duration) error
+	// page returns the ids at [offset, offset+limit) and the length of the snapshot, 0 once it expired
+	page(ctx context.Context, key string, offset int, limit int) ([]string, int, error)
+}
+
+func feedSnapshotKey(userId string, generatedAt primitive.DateTime) string {
+	return fmt.Sprintf("feed:snapshot:%s:%d", userId, int64(generatedAt))
+}
+
+// redisFeedSnapshotStore shares the snapshots between instances, Redis expires them
+type redisFeedSnapshotStore struct {
+	client *goredis.Client
+}
+
+func newRedisFeedSnapshotStore(store redis.Store) *redisFeedSnapshotStore {
+	return &redisFeedSnapshotStore{client: store.GetInstance().Client}
+}
+
+func (s *redisFeedSnapshotStore) save(ctx context.Context, key string, postIds []string, ttl time.Duration) error {
+	values := make([]any, len(postIds))
+	for i, postId := range postIds {
+		values[i] = postId
+	}
+	_, err := s.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.RPush(ctx, key, values...)
+		pipe.PExpire(ctx, key, ttl)
+		return nil
+	})
+	return err
+}
+
+func (s *redisFeedSnapshotStore) page(ctx context.Context, key string, offset int, limit int) ([]string, int, error) {
+	var ids *goredis.StringSliceCmd
+	var total *goredis.IntCmd
+	_, err := s.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		ids = pipe.LRange(ctx, key, int64(offset), int64(offset+limit-1))
+		total = pipe.LLen(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return ids.Val(), int(total.Val()), nil
+}
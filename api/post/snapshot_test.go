@@ -0,0 +1,59 @@
+package post
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"sync-backend/arch/redis"
+	"sync-backend/utils"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestSnapshotStore(t *testing.T) (feedSnapshotStore, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	port, err := strconv.Atoi(server.Port())
+	require.NoError(t, err)
+	store := redis.NewStore(context.Background(), utils.NewServiceLogger("FeedSnapshotTest"), &redis.Config{
+		Host: server.Host(),
+		Port: uint16(port),
+	})
+	t.Cleanup(store.Disconnect)
+	return newRedisFeedSnapshotStore(store), server
+}
+
+func TestFeedSnapshotPagesKeepTheRankedOrder(t *testing.T) {
+	ctx := context.Background()
+	snapshots, server := newTestSnapshotStore(t)
+	key := feedSnapshotKey("user-1", primitive.NewDateTimeFromTime(time.Unix(1_700_000_000, 0)))
+
+	require.NoError(t, snapshots.save(ctx, key, []string{"p3", "p1", "p5", "p2", "p4"}, time.Minute))
+
+	postIds, total, err := snapshots.page(ctx, key, 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p5", "p2"}, postIds)
+	assert.Equal(t, 5, total)
+
+	postIds, total, err = snapshots.page(ctx, key, 4, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p4"}, postIds)
+	assert.Equal(t, 5, total)
+
+	// Saving the snapshot again replaces the order instead of appending to it
+	require.NoError(t, snapshots.save(ctx, key, []string{"p9"}, time.Minute))
+	postIds, total, err = snapshots.page(ctx, key, 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p9"}, postIds)
+	assert.Equal(t, 1, total)
+
+	server.FastForward(time.Minute + time.Second)
+	postIds, total, err = snapshots.page(ctx, key, 0, 2)
+	require.NoError(t, err)
+	assert.Empty(t, postIds)
+	assert.Equal(t, 0, total)
+}
@@ -27,7 +27,7 @@ func (c *Controller) MountRoutes(router *gin.RouterGroup) {
 
 // GetStatus returns the overall system status
 func (c *Controller) GetStatus(ctx *gin.Context) {
-	status, err := c.service.GetSystemStatus()
+	status, err := c.service.GetSystemStatus(ctx.Request.Context())
 	if err != nil {
 		c.Send(ctx).InternalServerError(
 			"Failed to get system status",
@@ -42,7 +42,7 @@ func (c *Controller) GetStatus(ctx *gin.Context) {
 
 // GetHealth returns detailed health check information
 func (c *Controller) GetHealth(ctx *gin.Context) {
-	health, err := c.service.GetHealthStatus()
+	health, err := c.service.GetHealthStatus(ctx.Request.Context())
 	if err != nil {
 		c.Send(ctx).InternalServerError(
 			"Failed to get system health",
@@ -57,7 +57,7 @@ func (c *Controller) GetHealth(ctx *gin.Context) {
 
 // GetRoutes returns all registered API routes
 func (c *Controller) GetRoutes(ctx *gin.Context) {
-	routes, err := c.service.GetAPIRoutes()
+	routes, err := c.service.GetAPIRoutes(ctx.Request.Context())
 	if err != nil {
 		c.Send(ctx).InternalServerError(
 			"Failed to get system routes",
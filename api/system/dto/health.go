@@ -14,8 +14,11 @@ type HealthStatusResponse struct {
 				Version    string `json:"version"`
 				Connection struct {
 					PoolSize          int    `json:"pool_size"`
+					MinPoolSize       int    `json:"min_pool_size"`
+					OpenConnections   int    `json:"open_connections"`
 					ActiveConnections int    `json:"active_connections"`
 					IdleConnections   int    `json:"idle_connections"`
+					CheckoutFailures  int64  `json:"checkout_failures"`
 					MaxConnectionAge  string `json:"max_connection_age"`
 					ConnectionTimeout string `json:"connection_timeout"`
 				} `json:"connection"`
@@ -156,7 +159,6 @@ type HealthStatusResponse struct {
 				} `json:"rate_limiting"`
 				Firewall struct {
 					Status          string `json:"status"`
-					BlockedIPs      int    `json:"blocked_ips"`
 					TotalRequests   int64  `json:"total_requests"`
 					BlockedRequests int    `json:"blocked_requests"`
 				} `json:"firewall"`
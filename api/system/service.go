@@ -11,9 +11,11 @@ import (
 
 	"sync-backend/api/system/dto"
 	"sync-backend/arch/config"
+	"sync-backend/arch/metrics"
 	"sync-backend/arch/mongo"
 	"sync-backend/arch/network"
 	"sync-backend/arch/redis"
+	"sync-backend/arch/tracing"
 	"sync-backend/utils"
 
 	"github.com/gin-gonic/gin"
@@ -25,9 +27,9 @@ import (
 )
 
 type SystemService interface {
-	GetSystemStatus() (*dto.SystemStatusResponse, network.ApiError)
-	GetHealthStatus() (*dto.HealthStatusResponse, network.ApiError)
-	GetAPIRoutes() ([]dto.APIRouteResponse, network.ApiError)
+	GetSystemStatus(ctx context.Context) (*dto.SystemStatusResponse, network.ApiError)
+	GetHealthStatus(ctx context.Context) (*dto.HealthStatusResponse, network.ApiError)
+	GetAPIRoutes(ctx context.Context) ([]dto.APIRouteResponse, network.ApiError)
 }
 
 type systemService struct {
@@ -58,8 +60,11 @@ func NewSystemService(
 var startTime = time.Now()
 
 // GetSystemStatus returns the overall system status
-func (s *systemService) GetSystemStatus() (*dto.SystemStatusResponse, network.ApiError) {
-	s.logger.Info("Getting system status")
+func (s *systemService) GetSystemStatus(ctx context.Context) (*dto.SystemStatusResponse, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "SystemService.GetSystemStatus")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Getting system status")
 	return &dto.SystemStatusResponse{
 		Status:    "operational",
 		Timestamp: time.Now(),
@@ -69,8 +74,11 @@ func (s *systemService) GetSystemStatus() (*dto.SystemStatusResponse, network.Ap
 }
 
 // GetHealthStatus returns detailed health check of all components
-func (s *systemService) GetHealthStatus() (*dto.HealthStatusResponse, network.ApiError) {
-	s.logger.Info("Getting health status")
+func (s *systemService) GetHealthStatus(ctx context.Context) (*dto.HealthStatusResponse, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "SystemService.GetHealthStatus")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Getting health status")
 	status := &dto.HealthStatusResponse{
 		Status:    "operational",
 		Timestamp: time.Now(),
@@ -78,22 +86,24 @@ func (s *systemService) GetHealthStatus() (*dto.HealthStatusResponse, network.Ap
 
 	// Check database
 	dbStart := time.Now()
-	ctx := context.Background()
 	err := s.db.Ping(ctx)
 	if err != nil {
 		status.Status = "degraded"
 		status.Components.Database.Status = "error"
-		s.logger.Error("Database connection error: %v", err)
+		s.logger.WithContext(ctx).Error("Database connection error: %v", err)
 	} else {
 		status.Components.Database.Status = "healthy"
 		// Get MongoDB stats
 		status.Components.Database.Details.Type = "MongoDB"
-		status.Components.Database.Details.Version = "6.0.12" // TODO: Get actual version
-		status.Components.Database.Details.Connection.PoolSize = 100
-		status.Components.Database.Details.Connection.ActiveConnections = 45
-		status.Components.Database.Details.Connection.IdleConnections = 55
-		status.Components.Database.Details.Connection.MaxConnectionAge = "1h"
-		status.Components.Database.Details.Connection.ConnectionTimeout = "5s"
+		// Connections of this process, counted from the driver pool events
+		pool := s.db.GetPoolStats()
+		status.Components.Database.Details.Connection.PoolSize = int(pool.MaxPoolSize)
+		status.Components.Database.Details.Connection.MinPoolSize = int(pool.MinPoolSize)
+		status.Components.Database.Details.Connection.OpenConnections = int(pool.Open)
+		status.Components.Database.Details.Connection.ActiveConnections = int(pool.InUse)
+		status.Components.Database.Details.Connection.IdleConnections = int(pool.Idle)
+		status.Components.Database.Details.Connection.CheckoutFailures = pool.CheckoutFailures
+		status.Components.Database.Details.Connection.ConnectionTimeout = s.config.DB.TimeoutConfig.ConnectTimeout.String()
 
 		// Get MongoDB operations stats
 		client := s.db.GetClient()
@@ -102,6 +112,10 @@ func (s *systemService) GetHealthStatus() (*dto.HealthStatusResponse, network.Ap
 			var serverStatus bson.M
 			err = client.Database("admin").RunCommand(ctx, bson.D{{Key: "serverStatus", Value: 1}}).Decode(&serverStatus)
 			if err == nil {
+				if version, ok := serverStatus["version"].(string); ok {
+					status.Components.Database.Details.Version = version
+				}
+
 				// Get operations stats
 				if opcounters, ok := serverStatus["opcounters"].(bson.M); ok {
 					if query, ok := opcounters["query"].(int64); ok {
@@ -112,16 +126,6 @@ func (s *systemService) GetHealthStatus() (*dto.HealthStatusResponse, network.Ap
 						status.Components.Database.Details.Operations.FailedQueries = int(failed)
 					}
 				}
-
-				// Get connections
-				if conn, ok := serverStatus["connections"].(bson.M); ok {
-					if current, ok := conn["current"].(int64); ok {
-						status.Components.Database.Details.Connection.ActiveConnections = int(current)
-					}
-					if available, ok := conn["available"].(int64); ok {
-						status.Components.Database.Details.Connection.IdleConnections = int(available)
-					}
-				}
 			}
 
 			// Get database stats
@@ -164,7 +168,7 @@ func (s *systemService) GetHealthStatus() (*dto.HealthStatusResponse, network.Ap
 	if err != nil {
 		status.Status = "degraded"
 		status.Components.Redis.Status = "error"
-		s.logger.Error("Redis connection error: %v", err)
+		s.logger.WithContext(ctx).Error("Redis connection error: %v", err)
 	} else {
 		status.Components.Redis.Status = "healthy"
 		// Get Redis stats
@@ -307,21 +311,24 @@ func (s *systemService) GetHealthStatus() (*dto.HealthStatusResponse, network.Ap
 	status.Components.Security.Details.SSL.Version = "TLS 1.3"
 	status.Components.Security.Details.SSL.CertExpiry = time.Now().AddDate(1, 0, 0)
 	status.Components.Security.Details.RateLimiting.Enabled = s.config.API.RateLimit.Enabled
-	status.Components.Security.Details.RateLimiting.MaxRequests = s.config.API.RateLimit.MaxRequests
-	status.Components.Security.Details.RateLimiting.Window = s.config.API.RateLimit.Window.String()
-
-	// Add firewall info
-	status.Components.Security.Details.Firewall.Status = "active"
-	status.Components.Security.Details.Firewall.BlockedIPs = 50
-	status.Components.Security.Details.Firewall.TotalRequests = 100000
-	status.Components.Security.Details.Firewall.BlockedRequests = 1000
-
-	// Add metrics
-	status.Metrics.ResponseTime.P50 = "50ms"
-	status.Metrics.ResponseTime.P90 = "100ms"
-	status.Metrics.ResponseTime.P99 = "200ms"
-	status.Metrics.ErrorRate = 0.01
-	status.Metrics.RequestsPerSecond = 100
+	status.Components.Security.Details.RateLimiting.MaxRequests = s.config.API.RateLimit.Global.Requests
+	status.Components.Security.Details.RateLimiting.Window = s.config.API.RateLimit.Global.Window.String()
+
+	// Add metrics, averaged since the process started
+	httpSummary := metrics.SummarizeHTTP()
+
+	// Requests seen and turned away by the rate limiters since the process started
+	status.Components.Security.Details.Firewall.Status = "disabled"
+	if s.config.API.RateLimit.Enabled {
+		status.Components.Security.Details.Firewall.Status = "active"
+	}
+	status.Components.Security.Details.Firewall.TotalRequests = int64(httpSummary.Requests)
+	status.Components.Security.Details.Firewall.BlockedRequests = int(httpSummary.RateLimited)
+	status.Metrics.ResponseTime.P50 = httpSummary.P50.String()
+	status.Metrics.ResponseTime.P90 = httpSummary.P90.String()
+	status.Metrics.ResponseTime.P99 = httpSummary.P99.String()
+	status.Metrics.ErrorRate = httpSummary.ErrorRate
+	status.Metrics.RequestsPerSecond = int(float64(httpSummary.Requests) / time.Since(startTime).Seconds())
 
 	// Add alerts if any
 	if m.Alloc > 1024*1024*1024 { // 1GB
@@ -354,8 +361,11 @@ func parseInt64(s string) int64 {
 }
 
 // GetAPIRoutes returns all registered API routes
-func (s *systemService) GetAPIRoutes() ([]dto.APIRouteResponse, network.ApiError) {
-	s.logger.Info("Getting API routes")
+func (s *systemService) GetAPIRoutes(ctx context.Context) ([]dto.APIRouteResponse, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "SystemService.GetAPIRoutes")
+	defer span.End()
+
+	s.logger.WithContext(ctx).Info("Getting API routes")
 	var routes []dto.APIRouteResponse
 	for _, route := range s.engine.Routes() {
 		routes = append(routes, dto.APIRouteResponse{
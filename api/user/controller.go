@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sync-backend/api/common/location"
 	"sync-backend/api/user/dto"
+	"sync-backend/api/user/model"
 	"sync-backend/arch/common"
 	coreMW "sync-backend/arch/middleware"
 	"sync-backend/arch/network"
@@ -47,6 +48,9 @@ func (c *userController) MountRoutes(group *gin.RouterGroup) {
 	group.DELETE("/me", c.DeleteMe)
 	group.PUT("/me/preferences", c.UpdatePreferences)
 	group.GET("/me/password", c.ChangePassword)
+	group.POST("/me/devices", c.RegisterDevice)
+	group.DELETE("/me/devices", c.UnregisterDevice)
+	group.GET("/me/logins", c.GetLoginHistory)
 
 	group.GET("/search", c.SearchUsers)
 
@@ -62,7 +66,7 @@ func (c *userController) MountRoutes(group *gin.RouterGroup) {
 func (c *userController) GetMe(ctx *gin.Context) {
 
 	userId := c.ContextPayload.MustGetUserId(ctx)
-	user, err := c.userService.FindUserById(*userId)
+	user, err := c.userService.FindUserById(ctx.Request.Context(), *userId)
 
 	if err != nil {
 		c.Send(ctx).MixedError(err)
@@ -97,7 +101,7 @@ func (c *userController) UpdateMe(ctx *gin.Context) {
 		form.BackgroundFilePath = &backgroundFile.Path
 	}
 
-	user, err := c.userService.UpdateUserProfile(*userId, &form.Bio, form.AvatarFilePath, form.BackgroundFilePath)
+	user, err := c.userService.UpdateUserProfile(ctx.Request.Context(), *userId, &form.Bio, form.AvatarFilePath, form.BackgroundFilePath)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -117,7 +121,7 @@ func (c *userController) UpdateMe(ctx *gin.Context) {
 func (c *userController) DeleteMe(ctx *gin.Context) {
 	userId := c.ContextPayload.MustGetUserId(ctx)
 
-	err := c.userService.DeleteUser(*userId)
+	err := c.userService.DeleteUser(ctx.Request.Context(), *userId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -126,6 +130,54 @@ func (c *userController) DeleteMe(ctx *gin.Context) {
 	c.Send(ctx).SuccessMsgResponse("User marked for deletion successfully")
 }
 
+// RegisterDevice binds a push token to the current session, it is removed again when the session ends
+func (c *userController) RegisterDevice(ctx *gin.Context) {
+	userId := c.ContextPayload.MustGetUserId(ctx)
+	body, err := network.ReqBody(ctx, dto.NewRegisterDeviceRequest())
+	if err != nil {
+		return
+	}
+
+	deviceToken := model.NewDeviceToken(
+		body.Token,
+		c.ContextPayload.MustGetDeviceId(ctx),
+		c.ContextPayload.MustGetSessionId(ctx),
+		model.DeviceTokenType(body.Type),
+	)
+	if err := c.userService.RegisterDeviceToken(ctx.Request.Context(), *userId, deviceToken); err != nil {
+		c.Send(ctx).MixedError(err)
+		return
+	}
+	c.Send(ctx).SuccessDataResponse("Device registered successfully", deviceToken)
+}
+
+// UnregisterDevice removes the push token of the current session
+func (c *userController) UnregisterDevice(ctx *gin.Context) {
+	userId := c.ContextPayload.MustGetUserId(ctx)
+	err := c.userService.UnregisterSessionDeviceToken(ctx.Request.Context(), *userId, c.ContextPayload.MustGetSessionId(ctx))
+	if err != nil {
+		c.Send(ctx).MixedError(err)
+		return
+	}
+	c.Send(ctx).SuccessMsgResponse("Device unregistered successfully")
+}
+
+// GetLoginHistory lists the recent logins of the current user, newest first, with why any looked risky
+func (c *userController) GetLoginHistory(ctx *gin.Context) {
+	userId := c.ContextPayload.MustGetUserId(ctx)
+	user, err := c.userService.FindUserById(ctx.Request.Context(), *userId)
+	if err != nil {
+		c.Send(ctx).MixedError(err)
+		return
+	}
+
+	loginHistory := user.LoginHistory
+	if loginHistory == nil {
+		loginHistory = []model.LoginHistory{}
+	}
+	c.Send(ctx).SuccessDataResponse("Login history fetched successfully", loginHistory)
+}
+
 func (c *userController) UpdatePreferences(ctx *gin.Context) {
 	userId := c.ContextPayload.MustGetUserId(ctx)
 	form, err := network.ReqForm(ctx, dto.NewUpdateUserPreferencesRequest())
@@ -133,7 +185,7 @@ func (c *userController) UpdatePreferences(ctx *gin.Context) {
 		return
 	}
 
-	user, err := c.userService.FindUserById(*userId)
+	user, err := c.userService.FindUserById(ctx.Request.Context(), *userId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -162,6 +214,9 @@ func (c *userController) UpdatePreferences(ctx *gin.Context) {
 	if form.ShowMobileNotifications != nil {
 		user.Preferences.Notifications.Push = *form.ShowMobileNotifications
 	}
+	if form.DigestFrequency != nil {
+		user.Preferences.Notifications.DigestFrequency = model.DigestFrequency(*form.DigestFrequency)
+	}
 	if form.ShowSensitiveContent != nil {
 		user.Preferences.ContentSettings.ShowSensitiveContent = *form.ShowSensitiveContent
 	}
@@ -181,7 +236,7 @@ func (c *userController) UpdatePreferences(ctx *gin.Context) {
 		user.Preferences.PrivacySettings.FollowersVisible = *form.FollowersVisible
 	}
 
-	_, err = c.userService.UpdateUserPreferences(*userId, user.Preferences)
+	_, err = c.userService.UpdateUserPreferences(ctx.Request.Context(), *userId, user.Preferences)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -197,7 +252,7 @@ func (c *userController) ChangePassword(ctx *gin.Context) {
 		return
 	}
 
-	err = c.userService.ChangePassword(*userId, form.OldPassword, form.NewPassword)
+	err = c.userService.ChangePassword(ctx.Request.Context(), *userId, form.OldPassword, form.NewPassword)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -213,7 +268,7 @@ func (c *userController) SearchUsers(ctx *gin.Context) {
 		return
 	}
 
-	users, err := c.userService.SearchUsers(*userId, params.Query, params.Page, params.Limit)
+	users, err := c.userService.SearchUsers(ctx.Request.Context(), *userId, params.Query, params.Page, params.Limit)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -228,7 +283,7 @@ func (c *userController) GetUserById(ctx *gin.Context) {
 		return
 	}
 	userId := params.UserId
-	user, err := c.userService.FindUserById(userId)
+	user, err := c.userService.FindUserById(ctx.Request.Context(), userId)
 
 	if err != nil {
 		c.Send(ctx).MixedError(err)
@@ -256,7 +311,7 @@ func (c *userController) FollowUser(ctx *gin.Context) {
 		return
 	}
 
-	err := c.userService.FollowUser(*userId, followUserId)
+	err := c.userService.FollowUser(ctx.Request.Context(), *userId, followUserId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -274,7 +329,7 @@ func (c *userController) UnfollowUser(ctx *gin.Context) {
 		return
 	}
 
-	err := c.userService.UnfollowUser(*userId, unfollowUserId)
+	err := c.userService.UnfollowUser(ctx.Request.Context(), *userId, unfollowUserId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -292,7 +347,7 @@ func (c *userController) BlockUser(ctx *gin.Context) {
 		return
 	}
 
-	err := c.userService.BlockUser(*userId, blockUserId)
+	err := c.userService.BlockUser(ctx.Request.Context(), *userId, blockUserId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
@@ -310,7 +365,7 @@ func (c *userController) UnblockUser(ctx *gin.Context) {
 		return
 	}
 
-	err := c.userService.UnblockUser(*userId, unblockUserId)
+	err := c.userService.UnblockUser(ctx.Request.Context(), *userId, unblockUserId)
 	if err != nil {
 		c.Send(ctx).MixedError(err)
 		return
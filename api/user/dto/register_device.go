@@ -0,0 +1,41 @@
+package dto
+
+import (
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ===========================================
+// ||         RegisterDevice Request         ||
+// ===========================================
+
+type RegisterDeviceRequest struct {
+	Token string `form:"token" json:"token" validate:"required,max=4096"`
+	Type  string `form:"type" json:"type" validate:"required,oneof=fcm apns"`
+}
+
+func NewRegisterDeviceRequest() *RegisterDeviceRequest {
+	return &RegisterDeviceRequest{}
+}
+
+func (r *RegisterDeviceRequest) GetValue() *RegisterDeviceRequest {
+	return r
+}
+
+func (r *RegisterDeviceRequest) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		switch err.Tag() {
+		case "required":
+			msgs = append(msgs, fmt.Sprintf("%s is required", err.Field()))
+		case "max":
+			msgs = append(msgs, fmt.Sprintf("%s must be at most %s characters", err.Field(), err.Param()))
+		case "oneof":
+			msgs = append(msgs, fmt.Sprintf("%s must be one of: %s", err.Field(), err.Param()))
+		default:
+			msgs = append(msgs, fmt.Sprintf("%s is invalid", err.Field()))
+		}
+	}
+	return msgs, nil
+}
@@ -15,10 +15,11 @@ type UpdateUserPreferencesRequest struct {
 	Theme    *string `form:"theme" json:"theme"`
 	Timezone *string `form:"timezone" json:"timezone"`
 
-	ShowEmailNotifications  *bool `form:"email_notifications" json:"email_notifications" validate:"omitempty,boolean"`
-	ShowMobileNotifications *bool `form:"mobile_notifications" json:"mobile_notifications" validate:"omitempty,boolean"`
-	ShowSensitiveContent    *bool `form:"show_sensitive_content" json:"show_sensitive_content" validate:"omitempty,boolean"`
-	ShowAdultContent        *bool `form:"show_adult_content" json:"show_adult_content" validate:"omitempty,boolean"`
+	ShowEmailNotifications  *bool   `form:"email_notifications" json:"email_notifications" validate:"omitempty,boolean"`
+	ShowMobileNotifications *bool   `form:"mobile_notifications" json:"mobile_notifications" validate:"omitempty,boolean"`
+	DigestFrequency         *string `form:"digest_frequency" json:"digest_frequency" validate:"omitempty,oneof=off daily weekly"`
+	ShowSensitiveContent    *bool   `form:"show_sensitive_content" json:"show_sensitive_content" validate:"omitempty,boolean"`
+	ShowAdultContent        *bool   `form:"show_adult_content" json:"show_adult_content" validate:"omitempty,boolean"`
 
 	IsProfileVisible           *bool `form:"is_profile_visible" json:"is_profile_visible" validate:"omitempty,boolean"`
 	IsEmailVisible             *bool `form:"is_email_visible" json:"is_email_visible" validate:"omitempty,boolean"`
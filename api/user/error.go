@@ -14,6 +14,7 @@ const (
 	ERR_USER_INACTIVE        = "ERR_USER_INACTIVE"
 	ERR_DB                   = "ERR_DB"
 	ERR_FORBIDDEN            = "ERR_FORBIDDEN"
+	ERR_MEDIA                = "ERR_MEDIA"
 )
 
 func NewUserNotFoundError(userId string) network.ApiError {
@@ -121,6 +122,15 @@ func NewDBError(action, extra string) network.ApiError {
 	)
 }
 
+func NewMediaError(action, extra string) network.ApiError {
+	return network.NewInternalServerError(
+		"Media Error",
+		fmt.Sprintf("Media error occurred during %s. Details: %s", action, extra),
+		ERR_MEDIA,
+		nil,
+	)
+}
+
 func NewSelfActionError(action string) network.ApiError {
 	return network.NewBadRequestError(
 		"Self Action Not Allowed",
@@ -128,3 +138,11 @@ func NewSelfActionError(action string) network.ApiError {
 		nil,
 	)
 }
+
+func NewDeviceTokenNotFoundError(userId, sessionId string) network.ApiError {
+	return network.NewNotFoundError(
+		"Device Not Registered",
+		fmt.Sprintf("No push token is registered for the current session. [Context: userId=%s, sessionId=%s]", userId, sessionId),
+		nil,
+	)
+}
@@ -45,6 +45,14 @@ type User struct {
 	EmailVerificationExpiry *primitive.DateTime `bson:"emailVerificationExpiry,omitempty" json:"-"`
 	PasswordResetToken      *string             `bson:"passwordResetToken,omitempty" json:"-"`
 	PasswordResetExpiry     *primitive.DateTime `bson:"passwordResetExpiry,omitempty" json:"-"`
+
+	// Last notification digest, used to schedule the next one
+	DigestSentAt *primitive.DateTime `bson:"digestSentAt,omitempty" json:"-"`
+}
+
+// UserCacheTag tags every cached view of a user, invalidating it drops them after a write
+func UserCacheTag(userId string) string {
+	return "user:" + userId
 }
 
 type UserStatus string
@@ -66,7 +74,6 @@ type NewUserArgs struct {
 	TimeZone      common.TimeZone
 	Theme         string
 	Country       string
-	DeviceToken   DeviceToken
 }
 
 func NewUser(
@@ -101,9 +108,7 @@ func NewUser(
 				Location: newUserArgs.Country,
 			},
 		),
-		DeviceTokens: []DeviceToken{
-			newUserArgs.DeviceToken,
-		},
+		DeviceTokens: []DeviceToken{},
 		LoginHistory: []LoginHistory{},
 		LastSeen:     primitive.NewDateTimeFromTime(now),
 		CreatedAt:    primitive.NewDateTimeFromTime(now),
@@ -201,6 +206,20 @@ func (*User) EnsureIndexes(db mongo.Database) {
 			},
 			Options: options.Index().SetName("idx_user_activity"),
 		},
+		{
+			Keys: bson.D{
+				{Key: "preferences.notifications.digestFrequency", Value: 1},
+				{Key: "digestSentAt", Value: 1},
+			},
+			Options: options.Index().SetName("idx_user_digest_schedule"),
+		},
+		// A push token is moved to whoever registered it last
+		{
+			Keys: bson.D{
+				{Key: "deviceTokens.token", Value: 1},
+			},
+			Options: options.Index().SetName("idx_user_device_tokens"),
+		},
 		// TTL index for deleted users - 30 days
 		{
 			Keys: bson.D{
@@ -1,5 +1,7 @@
 package model
 
+import mediaModels "sync-backend/api/common/media/model"
+
 type UserAvatar struct {
 	Profile    Image `bson:"profile" json:"profile"`
 	Background Image `bson:"background" json:"background"`
@@ -10,6 +12,32 @@ type Image struct {
 	Url    string `bson:"url" json:"url"`
 	Width  int    `bson:"width" json:"width"`
 	Height int    `bson:"height" json:"height"`
+
+	Renditions    []mediaModels.ImageRendition `bson:"renditions,omitempty" json:"renditions,omitempty"`
+	BlurHash      string                       `bson:"blurHash,omitempty" json:"blurHash,omitempty"`
+	DominantColor string                       `bson:"dominantColor,omitempty" json:"dominantColor,omitempty"`
+}
+
+// NewImage creates an image from a stored media file
+func NewImage(info mediaModels.MediaInfo) Image {
+	return Image{
+		Id:            info.Id,
+		Url:           info.Url,
+		Width:         info.Width,
+		Height:        info.Height,
+		Renditions:    info.Renditions,
+		BlurHash:      info.BlurHash,
+		DominantColor: info.DominantColor,
+	}
+}
+
+// StorageIds returns the storage IDs of the image and its renditions
+func (i Image) StorageIds() []string {
+	ids := []string{i.Id}
+	for _, rendition := range i.Renditions {
+		ids = append(ids, rendition.Id)
+	}
+	return ids
 }
 
 func NewUserAvatar(profileImage Image, backgroundImage Image) UserAvatar {
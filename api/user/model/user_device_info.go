@@ -1,18 +1,39 @@
 package model
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DeviceTokenType is the push provider a device token belongs to
+type DeviceTokenType string
+
+const (
+	DeviceTokenFCM  DeviceTokenType = "fcm"  // Android and web
+	DeviceTokenAPNs DeviceTokenType = "apns" // iOS
+)
+
+// MaxDeviceTokens caps the tokens kept per user, the oldest ones are dropped first
+const MaxDeviceTokens = 10
+
+// DeviceToken is a push token registered by a signed in device, it lives as long as the session
 type DeviceToken struct {
-	Id       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	DeviceId string             `bson:"deviceId" json:"deviceId"`
-	Token    string             `bson:"token" json:"token"`
-	Type     string             `bson:"type" json:"type"`
+	Id        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	DeviceId  string             `bson:"deviceId" json:"deviceId"`
+	SessionId string             `bson:"sessionId" json:"-"`
+	Token     string             `bson:"token" json:"token"`
+	Type      DeviceTokenType    `bson:"type" json:"type"`
+	CreatedAt primitive.DateTime `bson:"createdAt" json:"createdAt"`
 }
 
-func NewDeviceToken(token string, deviceId string, tokenType string) *DeviceToken {
+func NewDeviceToken(token string, deviceId string, sessionId string, tokenType DeviceTokenType) *DeviceToken {
 	return &DeviceToken{
-		Token:    token,
-		DeviceId: deviceId,
-		Type:     tokenType,
+		Id:        primitive.NewObjectID(),
+		Token:     token,
+		DeviceId:  deviceId,
+		SessionId: sessionId,
+		Type:      tokenType,
+		CreatedAt: primitive.NewDateTimeFromTime(time.Now()),
 	}
 }
@@ -2,13 +2,22 @@ package model
 
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
+// MaxLoginHistory is how many logins are kept per user, they are what new logins are compared to
+const MaxLoginHistory = 20
+
 type LoginHistory struct {
 	SessionId string             `bson:"sessionId" json:"sessionId"`
 	LoginTime primitive.DateTime `bson:"loginTime" json:"loginTime"`
 	IpAddress string             `bson:"ipAddress" json:"ipAddress"`
 	UserAgent string             `bson:"userAgent" json:"userAgent"`
+	DeviceId  string             `bson:"deviceId,omitempty" json:"deviceId,omitempty"`
 	Device    UserDeviceInfo     `bson:"device" json:"device"`
+	Location  *LoginLocation     `bson:"location,omitempty" json:"location,omitempty"`
 	Provider  string             `bson:"provider" json:"provider,omitempty"`
+	// Why the login looked risky, empty for usual logins
+	Risk []LoginRiskReason `bson:"risk,omitempty" json:"risk,omitempty"`
+	// Set when the login was completed with an emailed code
+	SteppedUp bool `bson:"steppedUp,omitempty" json:"steppedUp,omitempty"`
 }
 
 type UserDeviceInfo struct {
@@ -17,3 +26,19 @@ type UserDeviceInfo struct {
 	Name  string `bson:"name" json:"name"`
 	Model string `bson:"model" json:"model"`
 }
+
+// LoginLocation is where the login came from, resolved from the IP address
+type LoginLocation struct {
+	Country   string  `bson:"country" json:"country"`
+	City      string  `bson:"city" json:"city"`
+	Latitude  float64 `bson:"latitude" json:"latitude"`
+	Longitude float64 `bson:"longitude" json:"longitude"`
+}
+
+type LoginRiskReason string
+
+const (
+	LoginRiskNewDevice        LoginRiskReason = "new_device"
+	LoginRiskNewCountry       LoginRiskReason = "new_country"
+	LoginRiskImpossibleTravel LoginRiskReason = "impossible_travel"
+)
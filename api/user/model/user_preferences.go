@@ -14,8 +14,29 @@ type UserPreferences struct {
 }
 
 type UserNotificationSettings struct {
-	Email bool `bson:"email" json:"email"`
-	Push  bool `bson:"push" json:"push"`
+	Email           bool            `bson:"email" json:"email"`
+	Push            bool            `bson:"push" json:"push"`
+	DigestFrequency DigestFrequency `bson:"digestFrequency,omitempty" json:"digestFrequency"`
+}
+
+type DigestFrequency string
+
+const (
+	DigestOff    DigestFrequency = "off"
+	DigestDaily  DigestFrequency = "daily"
+	DigestWeekly DigestFrequency = "weekly"
+)
+
+// Digest returns the effective digest frequency, accounts created before digests existed get weekly ones
+// and turning email notifications off stops digests too
+func (n UserNotificationSettings) Digest() DigestFrequency {
+	if !n.Email {
+		return DigestOff
+	}
+	if n.DigestFrequency == "" {
+		return DigestWeekly
+	}
+	return n.DigestFrequency
 }
 
 type UserContentSettings struct {
@@ -46,8 +67,9 @@ func NewUserPreferences(
 		Timezone: userPreferencesArgs.timezone,
 		Location: userPreferencesArgs.Location,
 		Notifications: UserNotificationSettings{
-			Email: true,
-			Push:  false,
+			Email:           true,
+			Push:            false,
+			DigestFrequency: DigestWeekly,
 		},
 		ContentSettings: UserContentSettings{
 			ShowSensitiveContent: false,
@@ -1,17 +1,25 @@
 package user
 
 import (
+	"context"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"sync-backend/api/common/media"
 	"sync-backend/api/user/model"
 	"sync-backend/arch/common"
+	"sync-backend/arch/config"
 	"sync-backend/arch/mongo"
 	"sync-backend/arch/network"
+	"sync-backend/arch/redis"
+	"sync-backend/arch/tracing"
 	"sync-backend/utils"
 )
 
@@ -19,47 +27,54 @@ const EMPTY_PASSWORD_HASH = "$2a$10$Cv/Xb2ykZ9FLmWyB6vaPEueAzA51kkU2GDZj8C4hwgAH
 
 type UserService interface {
 	/* CREATING USER */
-	CreateUser(userName string, email string, password string, profile string, backgroundPic string, locale string, timezone string, country string) (*model.User, network.ApiError)
-	CreateUserWithGoogleId(userName string, googleIdToken string, locale string, timezone string, country string) (*model.User, network.ApiError)
+	CreateUser(ctx context.Context, userName string, email string, password string, profile string, backgroundPic string, locale string, timezone string, country string) (*model.User, network.ApiError)
+	CreateUserWithGoogleId(ctx context.Context, userName string, googleIdToken string, locale string, timezone string, country string) (*model.User, network.ApiError)
 
 	/* FINDING USER */
-	FindUserById(userId string) (*model.User, network.ApiError)
-	FindUserByEmail(email string) (*model.User, network.ApiError)
-	FindUserByUsername(username string) (*model.User, network.ApiError)
-	FindUserAuthProvider(userId string, username string, providerName string) (*model.User, network.ApiError)
+	FindUserById(ctx context.Context, userId string) (*model.User, network.ApiError)
+	FindUserByEmail(ctx context.Context, email string) (*model.User, network.ApiError)
+	FindUserByUsername(ctx context.Context, username string) (*model.User, network.ApiError)
+	FindUserAuthProvider(ctx context.Context, userId string, username string, providerName string) (*model.User, network.ApiError)
 
 	/* USER INFO UPDATE */
-	UpdateUserProfile(userId string, bio *string, profilePicPath *string, backgroundPicPath *string) (*model.User, network.ApiError)
-	UpdateUserPreferences(userId string, preferences model.UserPreferences) (*model.User, network.ApiError)
-	UpdateLoginHistory(userId string, loginHistory model.LoginHistory) network.ApiError
+	UpdateUserProfile(ctx context.Context, userId string, bio *string, profilePicPath *string, backgroundPicPath *string) (*model.User, network.ApiError)
+	UpdateUserPreferences(ctx context.Context, userId string, preferences model.UserPreferences) (*model.User, network.ApiError)
+	UpdateLoginHistory(ctx context.Context, userId string, loginHistory model.LoginHistory) network.ApiError
+
+	/* PUSH DEVICE TOKENS */
+	RegisterDeviceToken(ctx context.Context, userId string, deviceToken *model.DeviceToken) network.ApiError
+	UnregisterSessionDeviceToken(ctx context.Context, userId string, sessionId string) network.ApiError
+	RemoveSessionDeviceTokens(ctx context.Context, userId string, sessionId string) network.ApiError
+	RemoveDeviceTokens(ctx context.Context, userId string, tokens []string) network.ApiError
 
 	/* USER FUNCTIONALITY */
-	ValidateUserPassword(user *model.User, password string) network.ApiError
-	DeleteUser(userId string) network.ApiError
-	ChangePassword(userId string, oldPassword string, newPassword string) network.ApiError
+	ValidateUserPassword(ctx context.Context, user *model.User, password string) network.ApiError
+	DeleteUser(ctx context.Context, userId string) network.ApiError
+	UpdateUserStatus(ctx context.Context, userId string, status model.UserStatus) network.ApiError
+	ChangePassword(ctx context.Context, userId string, oldPassword string, newPassword string) network.ApiError
 
 	/* EMAIL VERIFICATION & PASSWORD RESET */
-	UpdateEmailVerificationToken(userId string, token string, expiry time.Time) network.ApiError
-	FindUserByEmailVerificationToken(token string) (*model.User, network.ApiError)
-	MarkEmailAsVerified(userId string) network.ApiError
-	UpdatePasswordResetToken(userId string, token string, expiry time.Time) network.ApiError
-	FindUserByPasswordResetToken(token string) (*model.User, network.ApiError)
-	UpdatePasswordWithResetToken(userId string, hashedPassword string) network.ApiError
+	UpdateEmailVerificationToken(ctx context.Context, userId string, token string, expiry time.Time) network.ApiError
+	FindUserByEmailVerificationToken(ctx context.Context, token string) (*model.User, network.ApiError)
+	MarkEmailAsVerified(ctx context.Context, userId string) network.ApiError
+	UpdatePasswordResetToken(ctx context.Context, userId string, token string, expiry time.Time) network.ApiError
+	FindUserByPasswordResetToken(ctx context.Context, token string) (*model.User, network.ApiError)
+	UpdatePasswordWithResetToken(ctx context.Context, userId string, hashedPassword string) network.ApiError
 
 	/* USER COMMUNITY */
-	JoinCommunity(userId string, communityId string) network.ApiError
-	LeaveCommunity(userId string, communityId string) network.ApiError
-	SearchUsers(userId string, query string, page int, limit int) ([]*model.SearchUser, network.ApiError)
+	JoinCommunity(ctx context.Context, userId string, communityId string) network.ApiError
+	LeaveCommunity(ctx context.Context, userId string, communityId string) network.ApiError
+	SearchUsers(ctx context.Context, userId string, query string, page int, limit int) ([]*model.SearchUser, network.ApiError)
 
 	/* USER FOLLOWING */
-	FollowUser(userId string, followUserId string) network.ApiError
-	UnfollowUser(userId string, unfollowUserId string) network.ApiError
-	BlockUser(userId string, blockUserId string) network.ApiError
-	UnblockUser(userId string, unblockUserId string) network.ApiError
+	FollowUser(ctx context.Context, userId string, followUserId string) network.ApiError
+	UnfollowUser(ctx context.Context, userId string, unfollowUserId string) network.ApiError
+	BlockUser(ctx context.Context, userId string, blockUserId string) network.ApiError
+	UnblockUser(ctx context.Context, userId string, unblockUserId string) network.ApiError
 
 	/* MODERATOR */
-	AddModerator(userId string, communityId string) network.ApiError
-	RemoveModerator(userId string, communityId string) network.ApiError
+	AddModerator(ctx context.Context, userId string, communityId string) network.ApiError
+	RemoveModerator(ctx context.Context, userId string, communityId string) network.ApiError
 }
 
 type userService struct {
@@ -68,20 +83,42 @@ type userService struct {
 	userQueryBuilder      mongo.QueryBuilder[model.User]
 	transactionBuilder    mongo.TransactionBuilder
 	searchUsersAggregator mongo.AggregateBuilder[model.User, model.SearchUser]
+	userCache             redis.Cache[model.User]
 }
 
-func NewUserService(db mongo.Database, mediaService media.MediaService) UserService {
+func NewUserService(db mongo.Database, mediaService media.MediaService, cacheStore redis.CacheStore, cacheConfig config.CacheConfig) UserService {
 	return &userService{
 		mediaService:          mediaService,
 		log:                   utils.NewServiceLogger("UserService"),
 		userQueryBuilder:      mongo.NewQueryBuilder[model.User](db, model.UserCollectionName),
 		transactionBuilder:    mongo.NewTransactionBuilder(db),
 		searchUsersAggregator: mongo.NewAggregateBuilder[model.User, model.SearchUser](db, model.UserCollectionName),
+		// Msgpack keeps the fields hidden from the API, callers check password hashes and device tokens
+		userCache: redis.NewCache[model.User](cacheStore, redis.CacheOptions{
+			Name:   "user",
+			TTL:    cacheConfig.UserTTL,
+			Jitter: cacheConfig.Jitter,
+			Codec:  redis.MsgpackCodec,
+		}),
+	}
+}
+
+// invalidateUsers drops the cached users after a write, whether or not the write went through
+func (s *userService) invalidateUsers(ctx context.Context, userIds ...string) {
+	tags := make([]string, len(userIds))
+	for i, userId := range userIds {
+		tags[i] = model.UserCacheTag(userId)
+	}
+	if err := s.userCache.Invalidate(context.WithoutCancel(ctx), tags...); err != nil {
+		s.log.WithContext(ctx).Error("Error invalidating cached users %v: %v", userIds, err)
 	}
 }
 
-func (s *userService) CreateUser(userName string, email string, password string, profile string, backgroundPic string, locale string, timezone string, country string) (*model.User, network.ApiError) {
-	s.log.Debug("Creating user with email: %s", email)
+func (s *userService) CreateUser(ctx context.Context, userName string, email string, password string, profile string, backgroundPic string, locale string, timezone string, country string) (*model.User, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+
+	s.log.WithContext(ctx).Debug("Creating user with email: %s", email)
 	filter := bson.M{
 		"$or": []bson.M{
 			{"email": email},
@@ -89,113 +126,109 @@ func (s *userService) CreateUser(userName string, email string, password string,
 		},
 	}
 
-	existingUser, err := s.userQueryBuilder.SingleQuery().FilterOne(filter, nil)
+	existingUser, err := s.userQueryBuilder.SingleQuery(ctx).FilterOne(filter, nil)
 	if err != nil && !mongo.IsNoDocumentFoundError(err) {
-		s.log.Error("Error checking for existing user: %v", err)
+		s.log.WithContext(ctx).Error("Error checking for existing user: %v", err)
 		return nil, NewDBError("checking for existing user", err.Error())
 	}
 
 	if existingUser != nil {
 		if existingUser.Email == email {
-			s.log.Error("User with this email already exists: %s", email)
+			s.log.WithContext(ctx).Error("User with this email already exists: %s", email)
 			return nil, NewUserExistsByEmailError(email)
 		} else {
-			s.log.Error("User with this username already exists: %s", userName)
+			s.log.WithContext(ctx).Error("User with this username already exists: %s", userName)
 			return nil, NewUserExistsByUsernameError(userName)
 		}
 	}
 
 	hashedPassword, err := utils.HashPassword(password)
 	if err != nil {
-		s.log.Error("Error hashing password: %v", err)
+		s.log.WithContext(ctx).Error("Error hashing password: %v", err)
 		return nil, NewDBError("hashing password", err.Error())
 	}
-	var profilePicUrl, profileId string
-	var profileHeight, profileWidth int
-	var backgroundUrl, backgroundId string
-	var backgroundHeight, backgroundWidth int
+	profileImage := model.Image{
+		Id:     "default-profile-id",
+		Url:    "https://placehold.co/150x150.png",
+		Width:  150,
+		Height: 150,
+	}
 	if profile != "" {
-		profileInfo, _ := s.mediaService.UploadMedia(profile, userName+"_profile", "profile")
-		profileId = profileInfo.Id
-		profilePicUrl = profileInfo.Url
-		profileHeight = profileInfo.Height
-		profileWidth = profileInfo.Width
-	} else {
-		profile = "https://placehold.co/150x150.png"
-		profileId = "default-profile-id"
-		profilePicUrl = profile
-		profileHeight = 150
-		profileWidth = 150
+		profileInfo, err := s.mediaService.UploadMedia(profile, userName+"_profile", "profile")
+		if err != nil {
+			s.log.WithContext(ctx).Error("Error uploading profile picture: %v", err)
+			return nil, NewMediaError("uploading profile picture", err.Error())
+		}
+		profileImage = model.NewImage(profileInfo)
 	}
 
+	backgroundImage := model.Image{
+		Id:     "default-background-id",
+		Url:    "https://placehold.co/1200x400.png",
+		Width:  1200,
+		Height: 400,
+	}
 	if backgroundPic != "" {
-		backgroundInfo, _ := s.mediaService.UploadMedia(backgroundPic, userName+"_background", "background")
-		backgroundId = backgroundInfo.Id
-		backgroundUrl = backgroundInfo.Url
-		backgroundHeight = backgroundInfo.Height
-		backgroundWidth = backgroundInfo.Width
-	} else {
-		backgroundPic = "https://placehold.co/1200x400.png"
-		backgroundId = "default-background-id"
-		backgroundUrl = backgroundPic
-		backgroundHeight = 400
-		backgroundWidth = 1200
+		backgroundInfo, err := s.mediaService.UploadMedia(backgroundPic, userName+"_background", "background")
+		if err != nil {
+			s.log.WithContext(ctx).Error("Error uploading background picture: %v", err)
+			s.deleteImage(ctx, profileImage)
+			return nil, NewMediaError("uploading background picture", err.Error())
+		}
+		backgroundImage = model.NewImage(backgroundInfo)
 	}
 
 	user, err := model.NewUser(model.NewUserArgs{
-		UserName:     userName,
-		Email:        email,
-		PasswordHash: hashedPassword,
-		AvatarUrl: model.Image{
-			Id:     profileId,
-			Url:    profilePicUrl,
-			Width:  profileWidth,
-			Height: profileHeight,
-		},
-		BackgroundUrl: model.Image{
-			Id:     backgroundId,
-			Url:    backgroundUrl,
-			Width:  backgroundWidth,
-			Height: backgroundHeight,
-		},
-		Language:    common.GetLanguageByID(locale),
-		TimeZone:    common.GetTimeZone(timezone),
-		Theme:       "light",
-		Country:     country,
-		DeviceToken: *model.NewDeviceToken("default-token-id-here", "DEVICE_ID", "PUSH"),
+		UserName:      userName,
+		Email:         email,
+		PasswordHash:  hashedPassword,
+		AvatarUrl:     profileImage,
+		BackgroundUrl: backgroundImage,
+		Language:      common.GetLanguageByID(locale),
+		TimeZone:      common.GetTimeZone(timezone),
+		Theme:         "light",
+		Country:       country,
 	})
 	if err != nil {
-		s.log.Error("Error creating user: %v", err)
+		s.log.WithContext(ctx).Error("Error creating user: %v", err)
+		s.deleteImage(ctx, profileImage)
+		s.deleteImage(ctx, backgroundImage)
 		return nil, NewDBError("creating user", err.Error())
 	}
 
-	id, err := s.userQueryBuilder.SingleQuery().InsertOne(user.GetValue())
+	id, err := s.userQueryBuilder.SingleQuery(ctx).InsertOne(user.GetValue())
 	if err != nil {
-		s.log.Error("Error inserting user into database: %v", err)
+		s.log.WithContext(ctx).Error("Error inserting user into database: %v", err)
+		s.deleteImage(ctx, profileImage)
+		s.deleteImage(ctx, backgroundImage)
 		return nil, NewDBError("inserting user into database", err.Error())
 	}
 	user.Id = *id
 	return user, nil
 }
 
-func (s *userService) CreateUserWithGoogleId(userName string, googleIdToken string, locale string, timezone string, country string) (*model.User, network.ApiError) {
-	s.log.Debug("Creating user with Google ID token: %s", googleIdToken[0:10]+"***********")
+func (s *userService) CreateUserWithGoogleId(ctx context.Context, userName string, googleIdToken string, locale string, timezone string, country string) (*model.User, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUserWithGoogleId")
+	defer span.End()
+
+	s.log.WithContext(ctx).Debug("Creating user with Google ID token: %s", googleIdToken[0:10]+"***********")
 	googleUser, err := utils.DecodeGoogleJWTToken(googleIdToken)
 	if err != nil {
-		s.log.Error("Error decoding Google ID token: %v", err)
+		s.log.WithContext(ctx).Error("Error decoding Google ID token: %v", err)
 		return nil, NewDBError("decoding Google ID token", err.Error())
 	}
 
-	existingUser, err := s.userQueryBuilder.SingleQuery().FilterOne(bson.M{"email": googleUser.Email}, nil)
+	existingUser, err := s.userQueryBuilder.SingleQuery(ctx).FilterOne(bson.M{"email": googleUser.Email}, nil)
 
 	if err != nil && !mongo.IsNoDocumentFoundError(err) {
 		return nil, NewDBError("checking for existing user", err.Error())
 	}
-	width, height, _ := utils.GetImageSize(googleUser.Picture)
+	// Google encodes the served size in the picture URL, no need to download it
+	width, height := googlePictureSize(googleUser.Picture)
 	if existingUser != nil {
 		for _, provider := range existingUser.Providers {
 			if provider.AuthProvider == model.GoogleProviderName {
-				s.log.Debug("User already exists with Google ID: %s", googleIdToken[0:10]+"***********")
+				s.log.WithContext(ctx).Debug("User already exists with Google ID: %s", googleIdToken[0:10]+"***********")
 				return existingUser, nil
 			}
 		}
@@ -211,17 +244,18 @@ func (s *userService) CreateUserWithGoogleId(userName string, googleIdToken stri
 		existingUser.Avatar.Profile.Height = height
 		existingUser.Email = googleUser.Email
 		existingUser.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
-		_, err := s.userQueryBuilder.SingleQuery().UpdateOne(bson.M{"userId": existingUser.UserId}, bson.M{
+		defer s.invalidateUsers(ctx, existingUser.UserId)
+		_, err := s.userQueryBuilder.SingleQuery(ctx).UpdateOne(bson.M{"userId": existingUser.UserId}, bson.M{
 			"$set": existingUser.GetValue(),
 		}, nil)
 		if err != nil {
-			s.log.Error("Error updating existing user: %v", err)
+			s.log.WithContext(ctx).Error("Error updating existing user: %v", err)
 			return nil, NewDBError("updating existing user", err.Error())
 		}
-		s.log.Debug("User updated successfully: %s", existingUser.Email)
+		s.log.WithContext(ctx).Debug("User updated successfully: %s", existingUser.Email)
 		return existingUser, nil
 	} else {
-		s.log.Debug("Creating new user with Google ID: %s", googleIdToken[0:10]+"***********")
+		s.log.WithContext(ctx).Debug("Creating new user with Google ID: %s", googleIdToken[0:10]+"***********")
 		user, err := model.NewUser(model.NewUserArgs{
 			UserName: userName,
 			Email:    googleUser.Email,
@@ -237,9 +271,8 @@ func (s *userService) CreateUserWithGoogleId(userName string, googleIdToken stri
 				Width:  1200,
 				Height: 400,
 			},
-			Language:    common.GetLanguageByID(locale),
-			TimeZone:    common.GetTimeZone(timezone),
-			DeviceToken: *model.NewDeviceToken("default-token-id-here", "DEVICE_ID", "PUSH"),
+			Language: common.GetLanguageByID(locale),
+			TimeZone: common.GetTimeZone(timezone),
 		})
 		if err != nil {
 			return nil, NewDBError("creating user from Google ID", err.Error())
@@ -250,155 +283,173 @@ func (s *userService) CreateUserWithGoogleId(userName string, googleIdToken stri
 			fmt.Sprintf("%s %s", googleUser.GivenName, googleUser.FamilyName),
 		)
 		if err != nil {
-			s.log.Error("Error creating auth provider: %v", err)
+			s.log.WithContext(ctx).Error("Error creating auth provider: %v", err)
 			return nil, NewDBError("creating auth provider", err.Error())
 		}
 
 		user.VerifiedEmail = googleUser.EmailVerified
 		user.Providers = append(user.Providers, *userAuthProvider)
-		id, err := s.userQueryBuilder.SingleQuery().InsertOne(user.GetValue())
+		id, err := s.userQueryBuilder.SingleQuery(ctx).InsertOne(user.GetValue())
 		if err != nil {
-			s.log.Error("Error inserting user into database: %v", err)
+			s.log.WithContext(ctx).Error("Error inserting user into database: %v", err)
 			return nil, NewDBError("inserting user into database", err.Error())
 		}
-		s.log.Debug("User created successfully: %s - %s", user.Email, id.Hex())
+		s.log.WithContext(ctx).Debug("User created successfully: %s - %s", user.Email, id.Hex())
 		return user, nil
 	}
 }
 
-func (s *userService) FindUserById(userId string) (*model.User, network.ApiError) {
-	s.log.Debug("Getting user by ID: %s", userId)
-	user, err := s.userQueryBuilder.SingleQuery().FilterOne(bson.M{"userId": userId}, nil)
+func (s *userService) FindUserById(ctx context.Context, userId string) (*model.User, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "UserService.FindUserById")
+	defer span.End()
+
+	s.log.WithContext(ctx).Debug("Getting user by ID: %s", userId)
+	user, err := s.userCache.GetOrLoad(ctx, userId, func(ctx context.Context) (*model.User, error) {
+		return s.userQueryBuilder.SingleQuery(ctx).FilterOne(bson.M{"userId": userId}, nil)
+	}, model.UserCacheTag(userId))
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
 			return nil, NewUserNotFoundError(userId)
 		}
-		s.log.Error("Error getting user by ID: %v", err)
+		s.log.WithContext(ctx).Error("Error getting user by ID: %v", err)
 		return nil, NewDBError("getting user by ID", err.Error())
 	}
 	if user.Status == model.Deleted {
-		s.log.Error("User is deleted: %s", userId)
+		s.log.WithContext(ctx).Error("User is deleted: %s", userId)
 		return nil, NewUserDeletedError(userId)
 	}
 	if user.Status == model.Inactive {
-		s.log.Error("User is inactive: %s", userId)
+		s.log.WithContext(ctx).Error("User is inactive: %s", userId)
 		return nil, NewUserInactiveError(userId)
 	}
 	if user.Status == model.Banned {
-		s.log.Error("User is banned: %s", userId)
+		s.log.WithContext(ctx).Error("User is banned: %s", userId)
 		return nil, NewUserBannedError(userId, "User violated terms and conditions of the platform")
 	}
 
-	s.log.Debug("User found by ID: %s", user.UserId)
+	s.log.WithContext(ctx).Debug("User found by ID: %s", user.UserId)
 	return user, nil
 }
 
-func (s *userService) FindUserByEmail(email string) (*model.User, network.ApiError) {
-	s.log.Debug("Finding user by email: %s", email)
-	user, err := s.userQueryBuilder.SingleQuery().FilterOne(bson.M{"email": email}, nil)
+func (s *userService) FindUserByEmail(ctx context.Context, email string) (*model.User, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "UserService.FindUserByEmail")
+	defer span.End()
+
+	s.log.WithContext(ctx).Debug("Finding user by email: %s", email)
+	user, err := s.userQueryBuilder.SingleQuery(ctx).FilterOne(bson.M{"email": email}, nil)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
 			return nil, nil
 		}
-		s.log.Error("Error finding user by email: %v", err)
+		s.log.WithContext(ctx).Error("Error finding user by email: %v", err)
 		return nil, NewDBError("finding user by email", err.Error())
 	}
 
 	switch user.Status {
 	case model.Deleted:
-		s.log.Error("User is deleted: %s", email)
+		s.log.WithContext(ctx).Error("User is deleted: %s", email)
 		return nil, NewUserDeletedError(email)
 	case model.Inactive:
-		s.log.Error("User is inactive: %s", email)
+		s.log.WithContext(ctx).Error("User is inactive: %s", email)
 		return nil, NewUserInactiveError(email)
 	case model.Banned:
-		s.log.Error("User is banned: %s", email)
+		s.log.WithContext(ctx).Error("User is banned: %s", email)
 		return nil, NewUserBannedError(email, "User violated terms and conditions of the platform")
 	}
 
 	if user == nil {
-		s.log.Debug("No user found with email: %s", email)
+		s.log.WithContext(ctx).Debug("No user found with email: %s", email)
 		return nil, NewUserNotFoundByEmailError(email)
 	}
 
-	s.log.Debug("User found: %s", user.Email)
+	s.log.WithContext(ctx).Debug("User found: %s", user.Email)
 	return user, nil
 }
 
-func (s *userService) FindUserByUsername(username string) (*model.User, network.ApiError) {
-	s.log.Debug("Finding user by username: %s", username)
-	user, err := s.userQueryBuilder.SingleQuery().FilterOne(bson.M{"username": username}, nil)
+func (s *userService) FindUserByUsername(ctx context.Context, username string) (*model.User, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "UserService.FindUserByUsername")
+	defer span.End()
+
+	s.log.WithContext(ctx).Debug("Finding user by username: %s", username)
+	user, err := s.userQueryBuilder.SingleQuery(ctx).FilterOne(bson.M{"username": username}, nil)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
 			return nil, nil
 		}
-		s.log.Error("Error finding user by username: %v", err)
+		s.log.WithContext(ctx).Error("Error finding user by username: %v", err)
 		return nil, NewDBError("finding user by username", err.Error())
 	}
 	if user.Status == model.Deleted {
-		s.log.Error("User is deleted: %s", username)
+		s.log.WithContext(ctx).Error("User is deleted: %s", username)
 		return nil, NewUserDeletedError(username)
 	}
 	if user.Status == model.Inactive {
-		s.log.Error("User is inactive: %s", username)
+		s.log.WithContext(ctx).Error("User is inactive: %s", username)
 		return nil, NewUserInactiveError(username)
 	}
 	if user.Status == model.Banned {
-		s.log.Error("User is banned: %s", username)
+		s.log.WithContext(ctx).Error("User is banned: %s", username)
 		return nil, NewUserBannedError(username, "User violated terms and conditions of the platform")
 	}
-	s.log.Debug("User found: %s", user.Username)
+	s.log.WithContext(ctx).Debug("User found: %s", user.Username)
 	return user, nil
 }
 
-func (s *userService) FindUserAuthProvider(userId string, username string, providerName string) (*model.User, network.ApiError) {
-	s.log.Debug("Finding auth provider by user ID: %s and provider name: %s", userId, providerName)
-	user, err := s.userQueryBuilder.SingleQuery().FilterOne(bson.M{"userId": userId, "username": username, "providers.providerName": providerName}, nil)
+func (s *userService) FindUserAuthProvider(ctx context.Context, userId string, username string, providerName string) (*model.User, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "UserService.FindUserAuthProvider")
+	defer span.End()
+
+	s.log.WithContext(ctx).Debug("Finding auth provider by user ID: %s and provider name: %s", userId, providerName)
+	user, err := s.userQueryBuilder.SingleQuery(ctx).FilterOne(bson.M{"userId": userId, "username": username, "providers.providerName": providerName}, nil)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
 			return nil, nil
 		}
-		s.log.Error("Error finding auth provider by user ID: %v", err)
+		s.log.WithContext(ctx).Error("Error finding auth provider by user ID: %v", err)
 		return nil, NewDBError("finding auth provider by user ID", err.Error())
 	}
 	if user == nil {
-		s.log.Debug("No auth provider found for user ID: %s and provider name: %s", userId, providerName)
+		s.log.WithContext(ctx).Debug("No auth provider found for user ID: %s and provider name: %s", userId, providerName)
 		return nil, nil
 	}
 
 	for _, p := range user.Providers {
 		if p.AuthProvider == providerName {
-			s.log.Debug("Auth provider found: %s", p.AuthProvider)
+			s.log.WithContext(ctx).Debug("Auth provider found: %s", p.AuthProvider)
 			return user, nil
 		}
 	}
-	s.log.Debug("No auth provider found for user ID: %s and provider name: %s", userId, providerName)
+	s.log.WithContext(ctx).Debug("No auth provider found for user ID: %s and provider name: %s", userId, providerName)
 	return nil, nil
 }
 
-func (s *userService) UpdateUserPreferences(userId string, preferences model.UserPreferences) (*model.User, network.ApiError) {
-	s.log.Debug("Updating user preferences for user ID: %s", userId)
+func (s *userService) UpdateUserPreferences(ctx context.Context, userId string, preferences model.UserPreferences) (*model.User, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUserPreferences")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId)
+
+	s.log.WithContext(ctx).Debug("Updating user preferences for user ID: %s", userId)
 
 	// Assuming the user is already fetched and available as 'user'
-	user, err := s.userQueryBuilder.SingleQuery().FilterOne(bson.M{"userId": userId}, nil)
+	user, err := s.userQueryBuilder.SingleQuery(ctx).FilterOne(bson.M{"userId": userId}, nil)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
-			s.log.Error("User not found for profile update: %s", userId)
+			s.log.WithContext(ctx).Error("User not found for profile update: %s", userId)
 			return nil, NewUserNotFoundError(userId)
 		}
-		s.log.Error("Error fetching user for profile update: %v", err)
+		s.log.WithContext(ctx).Error("Error fetching user for profile update: %v", err)
 		return nil, NewDBError("fetching user for profile update", err.Error())
 	}
 
 	switch user.Status {
 	case model.Deleted:
-		s.log.Error("User is deleted: %s", userId)
+		s.log.WithContext(ctx).Error("User is deleted: %s", userId)
 		return nil, NewUserDeletedError(userId)
 	case model.Inactive:
-		s.log.Error("User is inactive: %s", userId)
+		s.log.WithContext(ctx).Error("User is inactive: %s", userId)
 		return nil, NewUserInactiveError(userId)
 	case model.Banned:
-		s.log.Error("User is banned: %s", userId)
+		s.log.WithContext(ctx).Error("User is banned: %s", userId)
 		return nil, NewUserBannedError(userId, "User violated terms and conditions of the platform")
 	}
 
@@ -406,7 +457,7 @@ func (s *userService) UpdateUserPreferences(userId string, preferences model.Use
 	user.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
 
 	// update only if not nil
-	_, err = s.userQueryBuilder.SingleQuery().UpdateOne(
+	_, err = s.userQueryBuilder.SingleQuery(ctx).UpdateOne(
 		bson.M{"userId": user.UserId},
 		bson.M{"$set": bson.M{
 			"preferences": user.Preferences,
@@ -415,59 +466,67 @@ func (s *userService) UpdateUserPreferences(userId string, preferences model.Use
 		nil,
 	)
 	if err != nil {
-		s.log.Error("Error updating user preferences: %v", err)
+		s.log.WithContext(ctx).Error("Error updating user preferences: %v", err)
 		return nil, NewDBError("updating user preferences", err.Error())
 	}
-	s.log.Debug("User preferences updated successfully for user ID: %s", user.UserId)
+	s.log.WithContext(ctx).Debug("User preferences updated successfully for user ID: %s", user.UserId)
 	return user, nil
 }
 
-func (s *userService) UpdateUserProfile(userId string, bio *string, profilePicPath *string, backgroundPicPath *string) (*model.User, network.ApiError) {
-	s.log.Debug("Updating user profile for user ID: %s", userId)
+func (s *userService) UpdateUserProfile(ctx context.Context, userId string, bio *string, profilePicPath *string, backgroundPicPath *string) (*model.User, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUserProfile")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId)
+
+	s.log.WithContext(ctx).Debug("Updating user profile for user ID: %s", userId)
 
 	// Assuming the user is already fetched and available as 'user'
-	user, err := s.userQueryBuilder.SingleQuery().FilterOne(bson.M{"userId": userId}, nil)
+	user, err := s.userQueryBuilder.SingleQuery(ctx).FilterOne(bson.M{"userId": userId}, nil)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
-			s.log.Error("User not found for profile update: %s", userId)
+			s.log.WithContext(ctx).Error("User not found for profile update: %s", userId)
 			return nil, NewUserNotFoundError(userId)
 		}
-		s.log.Error("Error fetching user for profile update: %v", err)
+		s.log.WithContext(ctx).Error("Error fetching user for profile update: %v", err)
 		return nil, NewDBError("fetching user for profile update", err.Error())
 	}
 
 	switch user.Status {
 	case model.Deleted:
-		s.log.Error("User is deleted: %s", userId)
+		s.log.WithContext(ctx).Error("User is deleted: %s", userId)
 		return nil, NewUserDeletedError(userId)
 	case model.Inactive:
-		s.log.Error("User is inactive: %s", userId)
+		s.log.WithContext(ctx).Error("User is inactive: %s", userId)
 		return nil, NewUserInactiveError(userId)
 	case model.Banned:
-		s.log.Error("User is banned: %s", userId)
+		s.log.WithContext(ctx).Error("User is banned: %s", userId)
 		return nil, NewUserBannedError(userId, "User violated terms and conditions of the platform")
 	}
 
+	// The replaced images are deleted only once the new ones are saved, a failed update deletes the
+	// uploads instead and the profile keeps its images
+	var replaced, uploaded []model.Image
 	if profilePicPath != nil {
-		s.mediaService.DeleteMedia(user.Avatar.Profile.Id) // Delete old profile pic if exists
-		profileInfo, _ := s.mediaService.UploadMedia(*profilePicPath, user.Username+"_profile", "profile")
-		user.Avatar.Profile = model.Image{
-			Id:     profileInfo.Id,
-			Url:    profileInfo.Url,
-			Width:  profileInfo.Width,
-			Height: profileInfo.Height,
+		profileInfo, err := s.mediaService.UploadMedia(*profilePicPath, user.Username+"_profile", "profile")
+		if err != nil {
+			s.log.WithContext(ctx).Error("Error uploading profile picture: %v", err)
+			return nil, NewMediaError("uploading profile picture", err.Error())
 		}
+		replaced = append(replaced, user.Avatar.Profile)
+		user.Avatar.Profile = model.NewImage(profileInfo)
+		uploaded = append(uploaded, user.Avatar.Profile)
 	}
 
 	if backgroundPicPath != nil {
-		s.mediaService.DeleteMedia(user.Avatar.Background.Id) // Delete old background if exists
-		backgroundInfo, _ := s.mediaService.UploadMedia(*backgroundPicPath, user.Username+"_background", "background")
-		user.Avatar.Background = model.Image{
-			Id:     backgroundInfo.Id,
-			Url:    backgroundInfo.Url,
-			Width:  backgroundInfo.Width,
-			Height: backgroundInfo.Height,
+		backgroundInfo, err := s.mediaService.UploadMedia(*backgroundPicPath, user.Username+"_background", "background")
+		if err != nil {
+			s.log.WithContext(ctx).Error("Error uploading background picture: %v", err)
+			s.deleteImages(ctx, uploaded)
+			return nil, NewMediaError("uploading background picture", err.Error())
 		}
+		replaced = append(replaced, user.Avatar.Background)
+		user.Avatar.Background = model.NewImage(backgroundInfo)
+		uploaded = append(uploaded, user.Avatar.Background)
 	}
 
 	if bio != nil {
@@ -475,28 +534,34 @@ func (s *userService) UpdateUserProfile(userId string, bio *string, profilePicPa
 	}
 	user.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
 
-	_, err = s.userQueryBuilder.SingleQuery().UpdateOne(
+	_, err = s.userQueryBuilder.SingleQuery(ctx).UpdateOne(
 		bson.M{"userId": user.UserId},
 		bson.M{"$set": user.GetValue()},
 		nil,
 	)
 	if err != nil {
-		s.log.Error("Error updating user profile: %v", err)
+		s.log.WithContext(ctx).Error("Error updating user profile: %v", err)
+		s.deleteImages(ctx, uploaded)
 		return nil, NewDBError("updating user profile", err.Error())
 	}
+	s.deleteImages(ctx, replaced)
 
-	s.log.Debug("User profile updated successfully for user ID: %s", user.UserId)
+	s.log.WithContext(ctx).Debug("User profile updated successfully for user ID: %s", user.UserId)
 	return user, nil
 }
 
-func (s *userService) UpdateLoginHistory(userId string, loginHistory model.LoginHistory) network.ApiError {
-	s.log.Debug("Updating login history for user ID: %s", userId)
+func (s *userService) UpdateLoginHistory(ctx context.Context, userId string, loginHistory model.LoginHistory) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateLoginHistory")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId)
+
+	s.log.WithContext(ctx).Debug("Updating login history for user ID: %s", userId)
 
-	result, err := s.userQueryBuilder.SingleQuery().UpdateOne(bson.M{"userId": userId}, bson.M{
+	result, err := s.userQueryBuilder.SingleQuery(ctx).UpdateOne(bson.M{"userId": userId}, bson.M{
 		"$push": bson.M{
 			"loginHistory": bson.M{
 				"$each":     []model.LoginHistory{loginHistory},
-				"$slice":    -10,
+				"$slice":    model.MaxLoginHistory, // Newest first, keep the head
 				"$position": 0,
 			},
 		},
@@ -505,53 +570,182 @@ func (s *userService) UpdateLoginHistory(userId string, loginHistory model.Login
 		},
 	}, nil)
 	if err != nil {
-		s.log.Error("Error updating login history: %v", err)
+		s.log.WithContext(ctx).Error("Error updating login history: %v", err)
 		return NewDBError("updating login history", err.Error())
 	}
 
-	s.log.Debug("Login history updated successfully for user ID: %s - Modified count: %d", userId, result.ModifiedCount)
+	s.log.WithContext(ctx).Debug("Login history updated successfully for user ID: %s - Modified count: %d", userId, result.ModifiedCount)
+	return nil
+}
+
+// RegisterDeviceToken binds a push token to the session, replacing the session's previous token.
+// The token is taken away from any other account first, it belongs to whoever signed in on the device last.
+func (s *userService) RegisterDeviceToken(ctx context.Context, userId string, deviceToken *model.DeviceToken) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.RegisterDeviceToken")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId)
+
+	s.log.WithContext(ctx).Debug("Registering %s device token for user ID: %s, session ID: %s", deviceToken.Type, userId, deviceToken.SessionId)
+
+	// The token moves here from whoever held it before, their cached users must go too
+	tokenFilter := bson.M{"deviceTokens.token": deviceToken.Token, "userId": bson.M{"$ne": userId}}
+	previousOwners, err := s.userQueryBuilder.SingleQuery(ctx).FilterMany(tokenFilter, options.Find().SetProjection(bson.M{"userId": 1}))
+	if err != nil {
+		s.log.WithContext(ctx).Error("Error finding device token owners: %v", err)
+		return NewDBError("finding device token owners", err.Error())
+	}
+	ownerIds := make([]string, 0, len(previousOwners))
+	for _, owner := range previousOwners {
+		ownerIds = append(ownerIds, owner.UserId)
+	}
+	defer s.invalidateUsers(ctx, ownerIds...)
+
+	_, err = s.userQueryBuilder.SingleQuery(ctx).UpdateMany(
+		bson.M{"deviceTokens.token": deviceToken.Token},
+		bson.M{"$pull": bson.M{"deviceTokens": bson.M{"token": deviceToken.Token}}},
+		nil,
+	)
+	if err != nil {
+		s.log.WithContext(ctx).Error("Error releasing device token: %v", err)
+		return NewDBError("releasing device token", err.Error())
+	}
+
+	filter := bson.M{"userId": userId}
+	_, err = s.userQueryBuilder.SingleQuery(ctx).UpdateOne(filter, bson.M{
+		"$pull": bson.M{"deviceTokens": bson.M{"sessionId": deviceToken.SessionId}},
+	}, nil)
+	if err != nil {
+		s.log.WithContext(ctx).Error("Error replacing session device token: %v", err)
+		return NewDBError("replacing session device token", err.Error())
+	}
+
+	result, err := s.userQueryBuilder.SingleQuery(ctx).UpdateOne(filter, bson.M{
+		"$push": bson.M{
+			"deviceTokens": bson.M{
+				"$each":  []model.DeviceToken{*deviceToken},
+				"$slice": -model.MaxDeviceTokens,
+			},
+		},
+	}, nil)
+	if err != nil {
+		s.log.WithContext(ctx).Error("Error registering device token: %v", err)
+		return NewDBError("registering device token", err.Error())
+	}
+	if result.MatchedCount == 0 {
+		return NewUserNotFoundError(userId)
+	}
+	return nil
+}
+
+// UnregisterSessionDeviceToken removes the push token of the session, failing when there is none
+func (s *userService) UnregisterSessionDeviceToken(ctx context.Context, userId string, sessionId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.UnregisterSessionDeviceToken")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId)
+
+	s.log.WithContext(ctx).Debug("Unregistering device token for user ID: %s, session ID: %s", userId, sessionId)
+
+	result, err := s.userQueryBuilder.SingleQuery(ctx).UpdateOne(
+		bson.M{"userId": userId, "deviceTokens.sessionId": sessionId},
+		bson.M{"$pull": bson.M{"deviceTokens": bson.M{"sessionId": sessionId}}},
+		nil,
+	)
+	if err != nil {
+		s.log.WithContext(ctx).Error("Error unregistering device token: %v", err)
+		return NewDBError("unregistering device token", err.Error())
+	}
+	if result.MatchedCount == 0 {
+		return NewDeviceTokenNotFoundError(userId, sessionId)
+	}
 	return nil
 }
 
-func (s *userService) ValidateUserPassword(user *model.User, password string) network.ApiError {
-	s.log.Debug("Validating password for user: %s", user.Email)
+// RemoveSessionDeviceTokens removes the push tokens of an ended session
+func (s *userService) RemoveSessionDeviceTokens(ctx context.Context, userId string, sessionId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.RemoveSessionDeviceTokens")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId)
+
+	_, err := s.userQueryBuilder.SingleQuery(ctx).UpdateOne(
+		bson.M{"userId": userId},
+		bson.M{"$pull": bson.M{"deviceTokens": bson.M{"sessionId": sessionId}}},
+		nil,
+	)
+	if err != nil {
+		s.log.WithContext(ctx).Error("Error removing session device tokens: %v", err)
+		return NewDBError("removing session device tokens", err.Error())
+	}
+	return nil
+}
+
+// RemoveDeviceTokens removes tokens the push providers reported as invalid
+func (s *userService) RemoveDeviceTokens(ctx context.Context, userId string, tokens []string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.RemoveDeviceTokens")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId)
+
+	if len(tokens) == 0 {
+		return nil
+	}
+	_, err := s.userQueryBuilder.SingleQuery(ctx).UpdateOne(
+		bson.M{"userId": userId},
+		bson.M{"$pull": bson.M{"deviceTokens": bson.M{"token": bson.M{"$in": tokens}}}},
+		nil,
+	)
+	if err != nil {
+		s.log.WithContext(ctx).Error("Error removing device tokens: %v", err)
+		return NewDBError("removing device tokens", err.Error())
+	}
+	s.log.WithContext(ctx).Debug("Removed %d device tokens of user ID: %s", len(tokens), userId)
+	return nil
+}
+
+func (s *userService) ValidateUserPassword(ctx context.Context, user *model.User, password string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.ValidateUserPassword")
+	defer span.End()
+
+	s.log.WithContext(ctx).Debug("Validating password for user: %s", user.Email)
 
 	isValid, err := utils.CheckPasswordHash(user.PasswordHash, password)
 	if err != nil {
-		s.log.Error("Error comparing password: %v", err)
+		s.log.WithContext(ctx).Error("Error comparing password: %v", err)
 		return NewDBError("comparing password", err.Error())
 	}
 	if !isValid {
-		s.log.Error("Invalid password for user: %s", user.Email)
+		s.log.WithContext(ctx).Error("Invalid password for user: %s", user.Email)
 		return NewWrongPasswordError(user.UserId)
 	}
 	return nil
 }
 
-func (s *userService) DeleteUser(userId string) network.ApiError {
-	s.log.Debug("Deleting user with ID: %s", userId)
-	user, err := s.userQueryBuilder.SingleQuery().FilterOne(bson.M{"userId": userId}, nil)
+func (s *userService) DeleteUser(ctx context.Context, userId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId)
+
+	s.log.WithContext(ctx).Debug("Deleting user with ID: %s", userId)
+	user, err := s.userQueryBuilder.SingleQuery(ctx).FilterOne(bson.M{"userId": userId}, nil)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
-			s.log.Error("User not found for deletion: %s", userId)
+			s.log.WithContext(ctx).Error("User not found for deletion: %s", userId)
 			return NewUserNotFoundError(userId)
 		}
-		s.log.Error("Error checking if user exists for deletion: %v", err)
+		s.log.WithContext(ctx).Error("Error checking if user exists for deletion: %v", err)
 		return NewDBError("checking if user exists for deletion", err.Error())
 	}
 	switch user.Status {
 	case model.Deleted:
-		s.log.Error("User is already deleted: %s", userId)
+		s.log.WithContext(ctx).Error("User is already deleted: %s", userId)
 		return NewUserMarkedForDeletionError(userId)
 	case model.Inactive:
-		s.log.Error("User is inactive and cannot be deleted: %s", userId)
+		s.log.WithContext(ctx).Error("User is inactive and cannot be deleted: %s", userId)
 		return NewUserInactiveError(userId)
 	case model.Banned:
-		s.log.Error("User is banned and cannot be deleted: %s", userId)
+		s.log.WithContext(ctx).Error("User is banned and cannot be deleted: %s", userId)
 		return NewUserBannedError(userId, "User violated terms and conditions of the platform")
 	}
 
-	_, err = s.userQueryBuilder.SingleQuery().UpdateOne(
+	_, err = s.userQueryBuilder.SingleQuery(ctx).UpdateOne(
 		bson.M{"userId": userId},
 		bson.M{"$set": bson.M{
 			"status":    model.Deleted,
@@ -561,110 +755,125 @@ func (s *userService) DeleteUser(userId string) network.ApiError {
 		nil,
 	)
 	if err != nil {
-		s.log.Error("Error deleting user: %v", err)
+		s.log.WithContext(ctx).Error("Error deleting user: %v", err)
 		return NewDBError("deleting user", err.Error())
 	}
 
-	s.log.Debug("User marked for deletion successfully: %s", userId)
+	s.log.WithContext(ctx).Debug("User marked for deletion successfully: %s", userId)
 	return nil
 }
 
-func (s *userService) ChangePassword(userId string, oldPassword string, newPassword string) network.ApiError {
-	s.log.Debug("Changing password for user ID: %s", userId)
-	user, err := s.userQueryBuilder.SingleQuery().FilterOne(bson.M{"userId": userId}, nil)
+func (s *userService) ChangePassword(ctx context.Context, userId string, oldPassword string, newPassword string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.ChangePassword")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId)
+
+	s.log.WithContext(ctx).Debug("Changing password for user ID: %s", userId)
+	user, err := s.userQueryBuilder.SingleQuery(ctx).FilterOne(bson.M{"userId": userId}, nil)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
-			s.log.Error("User not found for password change: %s", userId)
+			s.log.WithContext(ctx).Error("User not found for password change: %s", userId)
 			return NewUserNotFoundError(userId)
 		}
-		s.log.Error("Error checking if user exists for password change: %v", err)
+		s.log.WithContext(ctx).Error("Error checking if user exists for password change: %v", err)
 		return NewDBError("checking if user exists for password change", err.Error())
 	}
 
 	switch user.Status {
 	case model.Deleted:
-		s.log.Error("User is deleted and cannot change password: %s", userId)
+		s.log.WithContext(ctx).Error("User is deleted and cannot change password: %s", userId)
 		return NewUserDeletedError(userId)
 	case model.Inactive:
-		s.log.Error("User is inactive and cannot change password: %s", userId)
+		s.log.WithContext(ctx).Error("User is inactive and cannot change password: %s", userId)
 		return NewUserInactiveError(userId)
 	case model.Banned:
-		s.log.Error("User is banned and cannot change password: %s", userId)
+		s.log.WithContext(ctx).Error("User is banned and cannot change password: %s", userId)
 		return NewUserBannedError(userId, "User violated terms and conditions of the platform")
 	}
 	hasntSetPassword := user.PasswordHash == EMPTY_PASSWORD_HASH
 	var newPasswordHash string
 	if hasntSetPassword {
-		s.log.Error("User has not set a password yet: %s", userId)
+		s.log.WithContext(ctx).Error("User has not set a password yet: %s", userId)
 		newPasswordHash, err = utils.HashPassword(newPassword)
 		if err != nil {
-			s.log.Error("Error hashing new password: %v", err)
+			s.log.WithContext(ctx).Error("Error hashing new password: %v", err)
 			return NewDBError("hashing new password", err.Error())
 		}
 	} else {
-		s.log.Debug("Validating old password for user ID: %s", userId)
-		err = s.ValidateUserPassword(user, oldPassword)
+		s.log.WithContext(ctx).Debug("Validating old password for user ID: %s", userId)
+		err = s.ValidateUserPassword(ctx, user, oldPassword)
 		if err != nil {
-			s.log.Error("Invalid old password for user ID: %s", userId)
+			s.log.WithContext(ctx).Error("Invalid old password for user ID: %s", userId)
 			return NewWrongOldPasswordError(userId)
 		}
-		s.log.Debug("Old password validated successfully for user ID: %s", userId)
+		s.log.WithContext(ctx).Debug("Old password validated successfully for user ID: %s", userId)
 		newPasswordHash, err = utils.HashPassword(newPassword)
 		if err != nil {
-			s.log.Error("Error hashing new password: %v", err)
+			s.log.WithContext(ctx).Error("Error hashing new password: %v", err)
 			return NewDBError("hashing new password", err.Error())
 		}
 	}
-	_, err = s.userQueryBuilder.SingleQuery().UpdateOne(
+	_, err = s.userQueryBuilder.SingleQuery(ctx).UpdateOne(
 		bson.M{"userId": userId},
 		bson.M{"$set": bson.M{"passwordHash": newPasswordHash, "updatedAt": primitive.NewDateTimeFromTime(time.Now())}},
 		nil,
 	)
 	if err != nil {
-		s.log.Error("Error setting new password for user: %v", err)
+		s.log.WithContext(ctx).Error("Error setting new password for user: %v", err)
 		return NewDBError("setting new password for user", err.Error())
 	}
-	s.log.Debug("Password changed successfully for user ID: %s", userId)
+	s.log.WithContext(ctx).Debug("Password changed successfully for user ID: %s", userId)
 	return nil
 }
 
-func (s *userService) JoinCommunity(userId string, communityId string) network.ApiError {
-	s.log.Debug("Joining community %s for user %s", communityId, userId)
-	_, err := s.userQueryBuilder.SingleQuery().UpdateOne(bson.M{"userId": userId}, bson.M{
+func (s *userService) JoinCommunity(ctx context.Context, userId string, communityId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.JoinCommunity")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId)
+
+	s.log.WithContext(ctx).Debug("Joining community %s for user %s", communityId, userId)
+	_, err := s.userQueryBuilder.SingleQuery(ctx).UpdateOne(bson.M{"userId": userId}, bson.M{
 		"$addToSet": bson.M{
 			"joinedWavelengths": communityId,
 		},
 	}, nil)
 	if err != nil {
-		s.log.Error("Error joining community: %v", err)
+		s.log.WithContext(ctx).Error("Error joining community: %v", err)
 		return NewDBError("joining community", err.Error())
 	}
 
-	s.log.Debug("User %s joined community %s successfully", userId, communityId)
+	s.log.WithContext(ctx).Debug("User %s joined community %s successfully", userId, communityId)
 	return nil
 }
 
-func (s *userService) LeaveCommunity(userId string, communityId string) network.ApiError {
-	s.log.Debug("Leaving community %s for user %s", communityId, userId)
-	_, err := s.userQueryBuilder.SingleQuery().UpdateOne(bson.M{"userId": userId}, bson.M{
+func (s *userService) LeaveCommunity(ctx context.Context, userId string, communityId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.LeaveCommunity")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId)
+
+	s.log.WithContext(ctx).Debug("Leaving community %s for user %s", communityId, userId)
+	_, err := s.userQueryBuilder.SingleQuery(ctx).UpdateOne(bson.M{"userId": userId}, bson.M{
 		"$pull": bson.M{
 			"joinedWavelengths": communityId,
 		},
 	}, nil)
 	if err != nil {
-		s.log.Error("Error leaving community: %v", err)
+		s.log.WithContext(ctx).Error("Error leaving community: %v", err)
 		return NewDBError("leaving community", err.Error())
 	}
 
-	s.log.Debug("User %s left community %s successfully", userId, communityId)
+	s.log.WithContext(ctx).Debug("User %s left community %s successfully", userId, communityId)
 	return nil
 }
 
-func (s *userService) SearchUsers(userId string, query string, page int, limit int) ([]*model.SearchUser, network.ApiError) {
-	s.log.Debug("Searching users with query: %s, page: %d, limit: %d", query, page, limit)
+func (s *userService) SearchUsers(ctx context.Context, userId string, query string, page int, limit int) ([]*model.SearchUser, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "UserService.SearchUsers")
+	defer span.End()
+
+	s.log.WithContext(ctx).Debug("Searching users with query: %s, page: %d, limit: %d", query, page, limit)
 	regexPattern := primitive.Regex{Pattern: query, Options: "i"}
 
-	aggregationPipeline := s.searchUsersAggregator.SingleAggregate()
+	aggregationPipeline := s.searchUsersAggregator.SingleAggregate(ctx)
 
 	// Match documents that contain the query in username, fullName, or bio
 	// And exclude the current user and deleted/banned users
@@ -697,33 +906,37 @@ func (s *userService) SearchUsers(userId string, query string, page int, limit i
 	// Execute the aggregation
 	results, err := aggregationPipeline.ExecPaginated(int64(page), int64(limit))
 	if err != nil {
-		s.log.Error("Error searching users: %v", err)
+		s.log.WithContext(ctx).Error("Error searching users: %v", err)
 		return nil, NewDBError("searching users", err.Error())
 	}
 
-	s.log.Debug("Found %d users matching query: %s", len(results), query)
+	s.log.WithContext(ctx).Debug("Found %d users matching query: %s", len(results), query)
 	return results, nil
 }
 
-func (s *userService) FollowUser(userId string, followUserId string) network.ApiError {
-	s.log.Debug("Following user %s for user %s", followUserId, userId)
-	_, err := s.userQueryBuilder.SingleQuery().FilterOne(bson.M{"userId": followUserId}, nil)
+func (s *userService) FollowUser(ctx context.Context, userId string, followUserId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.FollowUser")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId, followUserId)
+
+	s.log.WithContext(ctx).Debug("Following user %s for user %s", followUserId, userId)
+	_, err := s.userQueryBuilder.SingleQuery(ctx).FilterOne(bson.M{"userId": followUserId}, nil)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
-			s.log.Error("User to follow does not exist: %s", followUserId)
+			s.log.WithContext(ctx).Error("User to follow does not exist: %s", followUserId)
 			return NewUserNotFoundError(followUserId)
 		}
-		s.log.Error("Error checking if user to follow exists: %v", err)
+		s.log.WithContext(ctx).Error("Error checking if user to follow exists: %v", err)
 		return NewDBError("checking if user to follow exists", err.Error())
 	}
 	if userId == followUserId {
-		s.log.Error("Cannot follow self")
+		s.log.WithContext(ctx).Error("Cannot follow self")
 		return NewSelfActionError("follow")
 	}
 
-	transaction := s.transactionBuilder.GetTransaction(time.Minute * 5)
+	transaction := s.transactionBuilder.GetTransaction(ctx, time.Minute*5)
 	if err := transaction.Start(); err != nil {
-		s.log.Error("Error starting transaction: %v", err)
+		s.log.WithContext(ctx).Error("Error starting transaction: %v", err)
 		return NewDBError("starting transaction", err.Error())
 	}
 	err = transaction.PerformSingleTransaction(func(session mongo.TransactionSession) error {
@@ -737,7 +950,7 @@ func (s *userService) FollowUser(userId string, followUserId string) network.Api
 			},
 		)
 		if err != nil {
-			s.log.Error("error following user: %v", err)
+			s.log.WithContext(ctx).Error("error following user: %v", err)
 			return NewDBError("following user", err.Error())
 		}
 		_, err = userCollection.UpdateOne(
@@ -749,40 +962,44 @@ func (s *userService) FollowUser(userId string, followUserId string) network.Api
 			},
 		)
 		if err != nil {
-			s.log.Error("error following user: %v", err)
+			s.log.WithContext(ctx).Error("error following user: %v", err)
 			return NewDBError("following user", err.Error())
 		}
 		return nil
 	})
 
 	if err != nil {
-		s.log.Error("Error following user: %v", err)
+		s.log.WithContext(ctx).Error("Error following user: %v", err)
 		return NewDBError("following user", err.Error())
 	}
 
-	s.log.Debug("User %s followed user %s successfully", userId, followUserId)
+	s.log.WithContext(ctx).Debug("User %s followed user %s successfully", userId, followUserId)
 	return nil
 }
 
-func (s *userService) UnfollowUser(userId string, unfollowUserId string) network.ApiError {
-	s.log.Debug("Unfollowing user %s for user %s", unfollowUserId, userId)
-	_, err := s.userQueryBuilder.SingleQuery().FilterOne(bson.M{"userId": unfollowUserId}, nil)
+func (s *userService) UnfollowUser(ctx context.Context, userId string, unfollowUserId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.UnfollowUser")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId, unfollowUserId)
+
+	s.log.WithContext(ctx).Debug("Unfollowing user %s for user %s", unfollowUserId, userId)
+	_, err := s.userQueryBuilder.SingleQuery(ctx).FilterOne(bson.M{"userId": unfollowUserId}, nil)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
-			s.log.Error("User to follow does not exist: %s", unfollowUserId)
+			s.log.WithContext(ctx).Error("User to follow does not exist: %s", unfollowUserId)
 			return NewUserNotFoundError(unfollowUserId)
 		}
-		s.log.Error("Error checking if user to follow exists: %v", err)
+		s.log.WithContext(ctx).Error("Error checking if user to follow exists: %v", err)
 		return NewDBError("checking if user to follow exists", err.Error())
 	}
 	if userId == unfollowUserId {
-		s.log.Error("Cannot follow self")
+		s.log.WithContext(ctx).Error("Cannot follow self")
 		return NewSelfActionError("unfollow")
 	}
 
-	transaction := s.transactionBuilder.GetTransaction(time.Minute * 5)
+	transaction := s.transactionBuilder.GetTransaction(ctx, time.Minute*5)
 	if err := transaction.Start(); err != nil {
-		s.log.Error("Error starting transaction: %v", err)
+		s.log.WithContext(ctx).Error("Error starting transaction: %v", err)
 		return NewDBError("starting transaction", err.Error())
 	}
 
@@ -797,7 +1014,7 @@ func (s *userService) UnfollowUser(userId string, unfollowUserId string) network
 			},
 		)
 		if err != nil {
-			s.log.Error("error unfollowing user: %v", err)
+			s.log.WithContext(ctx).Error("error unfollowing user: %v", err)
 			return NewDBError("unfollowing user", err.Error())
 		}
 		_, err = userCollection.UpdateOne(
@@ -809,39 +1026,43 @@ func (s *userService) UnfollowUser(userId string, unfollowUserId string) network
 			},
 		)
 		if err != nil {
-			s.log.Error("error unfollowing user: %v", err)
+			s.log.WithContext(ctx).Error("error unfollowing user: %v", err)
 			return NewDBError("unfollowing user", err.Error())
 		}
 		return nil
 	})
 
 	if err != nil {
-		s.log.Error("Error unfollowing user: %v", err)
+		s.log.WithContext(ctx).Error("Error unfollowing user: %v", err)
 		return NewDBError("unfollowing user", err.Error())
 	}
 
-	s.log.Debug("User %s unfollowed user %s successfully", userId, unfollowUserId)
+	s.log.WithContext(ctx).Debug("User %s unfollowed user %s successfully", userId, unfollowUserId)
 	return nil
 }
 
-func (s *userService) BlockUser(userId string, blockUserId string) network.ApiError {
-	s.log.Debug("Blocking user %s for user %s", blockUserId, userId)
-	_, err := s.userQueryBuilder.SingleQuery().FilterOne(bson.M{"userId": blockUserId}, nil)
+func (s *userService) BlockUser(ctx context.Context, userId string, blockUserId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.BlockUser")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId, blockUserId)
+
+	s.log.WithContext(ctx).Debug("Blocking user %s for user %s", blockUserId, userId)
+	_, err := s.userQueryBuilder.SingleQuery(ctx).FilterOne(bson.M{"userId": blockUserId}, nil)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
-			s.log.Error("User to block does not exist: %s", blockUserId)
+			s.log.WithContext(ctx).Error("User to block does not exist: %s", blockUserId)
 			return NewUserNotFoundError(blockUserId)
 		}
-		s.log.Error("Error checking if user to block exists: %v", err)
+		s.log.WithContext(ctx).Error("Error checking if user to block exists: %v", err)
 		return NewDBError("checking if user to block exists", err.Error())
 	}
 	if userId == blockUserId {
-		s.log.Error("Cannot block self")
+		s.log.WithContext(ctx).Error("Cannot block self")
 		return NewSelfActionError("block")
 	}
-	transaction := s.transactionBuilder.GetTransaction(time.Minute * 5)
+	transaction := s.transactionBuilder.GetTransaction(ctx, time.Minute*5)
 	if err := transaction.Start(); err != nil {
-		s.log.Error("Error starting transaction: %v", err)
+		s.log.WithContext(ctx).Error("Error starting transaction: %v", err)
 		return NewDBError("starting transaction", err.Error())
 	}
 
@@ -855,7 +1076,7 @@ func (s *userService) BlockUser(userId string, blockUserId string) network.ApiEr
 			},
 		)
 		if err != nil {
-			s.log.Error("error blocking user: %v", err)
+			s.log.WithContext(ctx).Error("error blocking user: %v", err)
 			return NewDBError("blocking user", err.Error())
 		}
 		return nil
@@ -865,28 +1086,32 @@ func (s *userService) BlockUser(userId string, blockUserId string) network.ApiEr
 		return NewDBError("blocking user", err.Error())
 	}
 
-	s.log.Debug("User %s blocked user %s successfully", userId, blockUserId)
+	s.log.WithContext(ctx).Debug("User %s blocked user %s successfully", userId, blockUserId)
 	return nil
 }
 
-func (s *userService) UnblockUser(userId string, unblockUserId string) network.ApiError {
-	s.log.Debug("Unblocking user %s for user %s", unblockUserId, userId)
-	_, err := s.userQueryBuilder.SingleQuery().FilterOne(bson.M{"userId": unblockUserId}, nil)
+func (s *userService) UnblockUser(ctx context.Context, userId string, unblockUserId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.UnblockUser")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId, unblockUserId)
+
+	s.log.WithContext(ctx).Debug("Unblocking user %s for user %s", unblockUserId, userId)
+	_, err := s.userQueryBuilder.SingleQuery(ctx).FilterOne(bson.M{"userId": unblockUserId}, nil)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
-			s.log.Error("User to unblock does not exist: %s", unblockUserId)
+			s.log.WithContext(ctx).Error("User to unblock does not exist: %s", unblockUserId)
 			return NewUserNotFoundError(unblockUserId)
 		}
-		s.log.Error("Error checking if user to unblock exists: %v", err)
+		s.log.WithContext(ctx).Error("Error checking if user to unblock exists: %v", err)
 		return NewDBError("checking if user to unblock exists", err.Error())
 	}
 	if userId == unblockUserId {
-		s.log.Error("Cannot block self")
+		s.log.WithContext(ctx).Error("Cannot block self")
 		return NewSelfActionError("unblock")
 	}
-	transaction := s.transactionBuilder.GetTransaction(time.Minute * 5)
+	transaction := s.transactionBuilder.GetTransaction(ctx, time.Minute*5)
 	if err := transaction.Start(); err != nil {
-		s.log.Error("Error starting transaction: %v", err)
+		s.log.WithContext(ctx).Error("Error starting transaction: %v", err)
 		return NewDBError("starting transaction", err.Error())
 	}
 
@@ -901,36 +1126,40 @@ func (s *userService) UnblockUser(userId string, unblockUserId string) network.A
 			},
 		)
 		if err != nil {
-			s.log.Error("error unblocking user: %v", err)
+			s.log.WithContext(ctx).Error("error unblocking user: %v", err)
 			return NewDBError("unblocking user", err.Error())
 		}
 		return nil
 	})
 
 	if err != nil {
-		s.log.Error("Error unblocking user: %v", err)
+		s.log.WithContext(ctx).Error("Error unblocking user: %v", err)
 		return NewDBError("unblocking user", err.Error())
 	}
 
-	s.log.Debug("User %s unblocked user %s successfully", userId, unblockUserId)
+	s.log.WithContext(ctx).Debug("User %s unblocked user %s successfully", userId, unblockUserId)
 	return nil
 }
 
-func (s *userService) AddModerator(userId string, communityId string) network.ApiError {
-	s.log.Debug("Adding moderator %s to community %s", userId, communityId)
-	_, err := s.userQueryBuilder.SingleQuery().FilterOne(bson.M{"userId": userId}, nil)
+func (s *userService) AddModerator(ctx context.Context, userId string, communityId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.AddModerator")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId)
+
+	s.log.WithContext(ctx).Debug("Adding moderator %s to community %s", userId, communityId)
+	_, err := s.userQueryBuilder.SingleQuery(ctx).FilterOne(bson.M{"userId": userId}, nil)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
-			s.log.Error("User to add as moderator does not exist: %s", userId)
+			s.log.WithContext(ctx).Error("User to add as moderator does not exist: %s", userId)
 			return NewUserNotFoundError(userId)
 		}
-		s.log.Error("Error checking if user exists: %v", err)
+		s.log.WithContext(ctx).Error("Error checking if user exists: %v", err)
 		return NewDBError("checking if user exists", err.Error())
 	}
 
-	transaction := s.transactionBuilder.GetTransaction(time.Minute * 5)
+	transaction := s.transactionBuilder.GetTransaction(ctx, time.Minute*5)
 	if err := transaction.Start(); err != nil {
-		s.log.Error("Error starting transaction: %v", err)
+		s.log.WithContext(ctx).Error("Error starting transaction: %v", err)
 		return NewDBError("starting transaction", err.Error())
 	}
 
@@ -945,35 +1174,39 @@ func (s *userService) AddModerator(userId string, communityId string) network.Ap
 			},
 		)
 		if err != nil {
-			s.log.Error("error adding moderator: %v", err)
+			s.log.WithContext(ctx).Error("error adding moderator: %v", err)
 			return NewDBError("adding moderator", err.Error())
 		}
 		return nil
 	})
 
 	if err != nil {
-		s.log.Error("Error adding moderator: %v", err)
+		s.log.WithContext(ctx).Error("Error adding moderator: %v", err)
 		return NewDBError("adding moderator", err.Error())
 	}
 
-	s.log.Debug("Moderator %s added to community %s successfully", userId, communityId)
+	s.log.WithContext(ctx).Debug("Moderator %s added to community %s successfully", userId, communityId)
 	return nil
 }
 
-func (s *userService) RemoveModerator(userId string, communityId string) network.ApiError {
-	s.log.Debug("Removing moderator %s from community %s", userId, communityId)
-	_, err := s.userQueryBuilder.SingleQuery().FilterOne(bson.M{"userId": userId}, nil)
+func (s *userService) RemoveModerator(ctx context.Context, userId string, communityId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.RemoveModerator")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId)
+
+	s.log.WithContext(ctx).Debug("Removing moderator %s from community %s", userId, communityId)
+	_, err := s.userQueryBuilder.SingleQuery(ctx).FilterOne(bson.M{"userId": userId}, nil)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
-			s.log.Error("User to remove as moderator does not exist: %s", userId)
+			s.log.WithContext(ctx).Error("User to remove as moderator does not exist: %s", userId)
 			return NewUserNotFoundError(userId)
 		}
-		s.log.Error("Error checking if user exists: %v", err)
+		s.log.WithContext(ctx).Error("Error checking if user exists: %v", err)
 		return NewDBError("checking if user exists", err.Error())
 	}
-	transaction := s.transactionBuilder.GetTransaction(time.Minute * 5)
+	transaction := s.transactionBuilder.GetTransaction(ctx, time.Minute*5)
 	if err := transaction.Start(); err != nil {
-		s.log.Error("Error starting transaction: %v", err)
+		s.log.WithContext(ctx).Error("Error starting transaction: %v", err)
 		return NewDBError("starting transaction", err.Error())
 	}
 	err = transaction.PerformSingleTransaction(func(session mongo.TransactionSession) error {
@@ -987,23 +1220,27 @@ func (s *userService) RemoveModerator(userId string, communityId string) network
 			},
 		)
 		if err != nil {
-			s.log.Error("error removing moderator: %v", err)
+			s.log.WithContext(ctx).Error("error removing moderator: %v", err)
 			return NewDBError("removing moderator", err.Error())
 		}
 		return nil
 	})
 	if err != nil {
-		s.log.Error("Error removing moderator: %v", err)
+		s.log.WithContext(ctx).Error("Error removing moderator: %v", err)
 		return NewDBError("removing moderator", err.Error())
 	}
-	s.log.Debug("Moderator %s removed from community %s successfully", userId, communityId)
+	s.log.WithContext(ctx).Debug("Moderator %s removed from community %s successfully", userId, communityId)
 	return nil
 }
 
 /* EMAIL VERIFICATION & PASSWORD RESET METHODS */
 
-func (s *userService) UpdateEmailVerificationToken(userId string, token string, expiry time.Time) network.ApiError {
-	s.log.Debug("Updating email verification token for user %s", userId)
+func (s *userService) UpdateEmailVerificationToken(ctx context.Context, userId string, token string, expiry time.Time) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateEmailVerificationToken")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId)
+
+	s.log.WithContext(ctx).Debug("Updating email verification token for user %s", userId)
 
 	expiryDateTime := primitive.NewDateTimeFromTime(expiry)
 	update := bson.M{
@@ -1014,34 +1251,41 @@ func (s *userService) UpdateEmailVerificationToken(userId string, token string,
 		},
 	}
 
-	_, err := s.userQueryBuilder.SingleQuery().UpdateOne(bson.M{"userId": userId}, update, nil)
+	_, err := s.userQueryBuilder.SingleQuery(ctx).UpdateOne(bson.M{"userId": userId}, update, nil)
 	if err != nil {
-		s.log.Error("Error updating email verification token: %v", err)
+		s.log.WithContext(ctx).Error("Error updating email verification token: %v", err)
 		return NewDBError("updating email verification token", err.Error())
 	}
 
-	s.log.Debug("Email verification token updated successfully for user %s", userId)
+	s.log.WithContext(ctx).Debug("Email verification token updated successfully for user %s", userId)
 	return nil
 }
 
-func (s *userService) FindUserByEmailVerificationToken(token string) (*model.User, network.ApiError) {
-	s.log.Debug("Finding user by email verification token")
+func (s *userService) FindUserByEmailVerificationToken(ctx context.Context, token string) (*model.User, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "UserService.FindUserByEmailVerificationToken")
+	defer span.End()
 
-	user, err := s.userQueryBuilder.SingleQuery().FilterOne(bson.M{"emailVerificationToken": token}, nil)
+	s.log.WithContext(ctx).Debug("Finding user by email verification token")
+
+	user, err := s.userQueryBuilder.SingleQuery(ctx).FilterOne(bson.M{"emailVerificationToken": token}, nil)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
-			s.log.Debug("No user found with email verification token")
+			s.log.WithContext(ctx).Debug("No user found with email verification token")
 			return nil, NewUserNotFoundError("token")
 		}
-		s.log.Error("Error finding user by email verification token: %v", err)
+		s.log.WithContext(ctx).Error("Error finding user by email verification token: %v", err)
 		return nil, NewDBError("finding user by email verification token", err.Error())
 	}
 
 	return user, nil
 }
 
-func (s *userService) MarkEmailAsVerified(userId string) network.ApiError {
-	s.log.Debug("Marking email as verified for user %s", userId)
+func (s *userService) MarkEmailAsVerified(ctx context.Context, userId string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.MarkEmailAsVerified")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId)
+
+	s.log.WithContext(ctx).Debug("Marking email as verified for user %s", userId)
 
 	update := bson.M{
 		"$set": bson.M{
@@ -1054,18 +1298,48 @@ func (s *userService) MarkEmailAsVerified(userId string) network.ApiError {
 		},
 	}
 
-	_, err := s.userQueryBuilder.SingleQuery().UpdateOne(bson.M{"userId": userId}, update, nil)
+	_, err := s.userQueryBuilder.SingleQuery(ctx).UpdateOne(bson.M{"userId": userId}, update, nil)
 	if err != nil {
-		s.log.Error("Error marking email as verified: %v", err)
+		s.log.WithContext(ctx).Error("Error marking email as verified: %v", err)
 		return NewDBError("marking email as verified", err.Error())
 	}
 
-	s.log.Debug("Email marked as verified successfully for user %s", userId)
+	s.log.WithContext(ctx).Debug("Email marked as verified successfully for user %s", userId)
 	return nil
 }
 
-func (s *userService) UpdatePasswordResetToken(userId string, token string, expiry time.Time) network.ApiError {
-	s.log.Debug("Updating password reset token for user %s", userId)
+// UpdateUserStatus sets the platform status of the user, a banned user can no longer log in
+func (s *userService) UpdateUserStatus(ctx context.Context, userId string, status model.UserStatus) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUserStatus")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId)
+
+	s.log.WithContext(ctx).Debug("Updating status of user %s to %s", userId, status)
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":    status,
+			"updatedAt": primitive.NewDateTimeFromTime(time.Now()),
+		},
+	}
+
+	result, err := s.userQueryBuilder.SingleQuery(ctx).UpdateOne(bson.M{"userId": userId}, update, nil)
+	if err != nil {
+		s.log.WithContext(ctx).Error("Error updating user status: %v", err)
+		return NewDBError("updating user status", err.Error())
+	}
+	if result.MatchedCount == 0 {
+		return NewUserNotFoundError(userId)
+	}
+	return nil
+}
+
+func (s *userService) UpdatePasswordResetToken(ctx context.Context, userId string, token string, expiry time.Time) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.UpdatePasswordResetToken")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId)
+
+	s.log.WithContext(ctx).Debug("Updating password reset token for user %s", userId)
 
 	expiryDateTime := primitive.NewDateTimeFromTime(expiry)
 	update := bson.M{
@@ -1076,34 +1350,41 @@ func (s *userService) UpdatePasswordResetToken(userId string, token string, expi
 		},
 	}
 
-	_, err := s.userQueryBuilder.SingleQuery().UpdateOne(bson.M{"userId": userId}, update, nil)
+	_, err := s.userQueryBuilder.SingleQuery(ctx).UpdateOne(bson.M{"userId": userId}, update, nil)
 	if err != nil {
-		s.log.Error("Error updating password reset token: %v", err)
+		s.log.WithContext(ctx).Error("Error updating password reset token: %v", err)
 		return NewDBError("updating password reset token", err.Error())
 	}
 
-	s.log.Debug("Password reset token updated successfully for user %s", userId)
+	s.log.WithContext(ctx).Debug("Password reset token updated successfully for user %s", userId)
 	return nil
 }
 
-func (s *userService) FindUserByPasswordResetToken(token string) (*model.User, network.ApiError) {
-	s.log.Debug("Finding user by password reset token")
+func (s *userService) FindUserByPasswordResetToken(ctx context.Context, token string) (*model.User, network.ApiError) {
+	ctx, span := tracing.Start(ctx, "UserService.FindUserByPasswordResetToken")
+	defer span.End()
+
+	s.log.WithContext(ctx).Debug("Finding user by password reset token")
 
-	user, err := s.userQueryBuilder.SingleQuery().FilterOne(bson.M{"passwordResetToken": token}, nil)
+	user, err := s.userQueryBuilder.SingleQuery(ctx).FilterOne(bson.M{"passwordResetToken": token}, nil)
 	if err != nil {
 		if mongo.IsNoDocumentFoundError(err) {
-			s.log.Debug("No user found with password reset token")
+			s.log.WithContext(ctx).Debug("No user found with password reset token")
 			return nil, NewUserNotFoundError("token")
 		}
-		s.log.Error("Error finding user by password reset token: %v", err)
+		s.log.WithContext(ctx).Error("Error finding user by password reset token: %v", err)
 		return nil, NewDBError("finding user by password reset token", err.Error())
 	}
 
 	return user, nil
 }
 
-func (s *userService) UpdatePasswordWithResetToken(userId string, hashedPassword string) network.ApiError {
-	s.log.Debug("Updating password with reset token for user %s", userId)
+func (s *userService) UpdatePasswordWithResetToken(ctx context.Context, userId string, hashedPassword string) network.ApiError {
+	ctx, span := tracing.Start(ctx, "UserService.UpdatePasswordWithResetToken")
+	defer span.End()
+	defer s.invalidateUsers(ctx, userId)
+
+	s.log.WithContext(ctx).Debug("Updating password with reset token for user %s", userId)
 
 	update := bson.M{
 		"$set": bson.M{
@@ -1116,12 +1397,45 @@ func (s *userService) UpdatePasswordWithResetToken(userId string, hashedPassword
 		},
 	}
 
-	_, err := s.userQueryBuilder.SingleQuery().UpdateOne(bson.M{"userId": userId}, update, nil)
+	_, err := s.userQueryBuilder.SingleQuery(ctx).UpdateOne(bson.M{"userId": userId}, update, nil)
 	if err != nil {
-		s.log.Error("Error updating password with reset token: %v", err)
+		s.log.WithContext(ctx).Error("Error updating password with reset token: %v", err)
 		return NewDBError("updating password with reset token", err.Error())
 	}
 
-	s.log.Debug("Password updated successfully for user %s", userId)
+	s.log.WithContext(ctx).Debug("Password updated successfully for user %s", userId)
 	return nil
 }
+
+// deleteImage removes an uploaded image and its renditions, placeholder images have no stored file
+func (s *userService) deleteImage(ctx context.Context, image model.Image) {
+	if image.Id == "" || strings.HasPrefix(image.Id, "default-") {
+		return
+	}
+	for _, id := range image.StorageIds() {
+		if err := s.mediaService.DeleteMedia(id); err != nil {
+			s.log.WithContext(ctx).Warn("Failed to delete image %s: %v", id, err)
+		}
+	}
+}
+
+func (s *userService) deleteImages(ctx context.Context, images []model.Image) {
+	for _, image := range images {
+		s.deleteImage(ctx, image)
+	}
+}
+
+var googlePictureSizePattern = regexp.MustCompile(`=s(\d+)(-c)?$`)
+
+// googlePictureSize reads the square size from a Google profile picture URL such as ...=s96-c
+func googlePictureSize(url string) (int, int) {
+	match := googlePictureSizePattern.FindStringSubmatch(url)
+	if match == nil {
+		return 0, 0
+	}
+	size, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, 0
+	}
+	return size, size
+}
@@ -1,9 +1,13 @@
 package application
 
 import (
+	admin "sync-backend/api/admin/model"
 	comment "sync-backend/api/comment/model"
+	csrf "sync-backend/api/common/csrf/model"
+	email "sync-backend/api/common/email/model"
 	session "sync-backend/api/common/session/model"
 	community "sync-backend/api/community/model"
+	media "sync-backend/api/media/model"
 	moderator "sync-backend/api/moderator/model"
 	post "sync-backend/api/post/model"
 	user "sync-backend/api/user/model"
@@ -14,16 +18,23 @@ import (
 func EnsureDbIndexes(db mongo.Database) {
 	go mongo.Document[user.User](&user.User{}).EnsureIndexes(db)
 	go mongo.Document[session.Session](&session.Session{}).EnsureIndexes(db)
+	go mongo.Document[csrf.CSRFToken](&csrf.CSRFToken{}).EnsureIndexes(db)
 	go mongo.Document[community.Community](&community.Community{}).EnsureIndexes(db)
 	go mongo.Document[community.CommunityInteraction](&community.CommunityInteraction{}).EnsureIndexes(db)
 	go mongo.Document[post.Post](&post.Post{}).EnsureIndexes(db)
 	go mongo.Document[post.PostInteraction](&post.PostInteraction{}).EnsureIndexes(db)
 	go mongo.Document[comment.Comment](&comment.Comment{}).EnsureIndexes(db)
 	go mongo.Document[comment.CommentInteraction](&comment.CommentInteraction{}).EnsureIndexes(db)
+	go mongo.Document[media.Media](&media.Media{}).EnsureIndexes(db)
+	go mongo.Document[email.EmailLog](&email.EmailLog{}).EnsureIndexes(db)
 
 	go mongo.Document[moderator.Moderator](&moderator.Moderator{}).EnsureIndexes(db)
 	go mongo.Document[moderator.ModLog](&moderator.ModLog{}).EnsureIndexes(db)
 	go mongo.Document[moderator.Report](&moderator.Report{}).EnsureIndexes(db)
 	go mongo.Document[moderator.CommunityBan](&moderator.CommunityBan{}).EnsureIndexes(db)
+	go mongo.Document[moderator.CommunityMute](&moderator.CommunityMute{}).EnsureIndexes(db)
+
+	go mongo.Document[admin.PlatformStaff](&admin.PlatformStaff{}).EnsureIndexes(db)
+	go mongo.Document[admin.AdminLog](&admin.AdminLog{}).EnsureIndexes(db)
 
 }
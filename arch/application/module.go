@@ -3,23 +3,30 @@ package application
 import (
 	"context"
 
+	"sync-backend/api/admin"
 	"sync-backend/api/auth"
 	authMW "sync-backend/api/auth/middleware"
 	"sync-backend/api/comment"
 	"sync-backend/api/common/analytics"
+	"sync-backend/api/common/csrf"
 	"sync-backend/api/common/email"
+	"sync-backend/api/common/guard"
 	"sync-backend/api/common/location"
 	"sync-backend/api/common/media"
+	"sync-backend/api/common/push"
 	"sync-backend/api/common/session"
 	"sync-backend/api/common/token"
 	"sync-backend/api/community"
+	"sync-backend/api/digest"
 	"sync-backend/api/docs"
+	mediaLib "sync-backend/api/media"
 	"sync-backend/api/moderator"
 	modMW "sync-backend/api/moderator/middleware"
 	"sync-backend/api/post"
 	"sync-backend/api/system"
 	"sync-backend/api/user"
 	"sync-backend/arch/config"
+	"sync-backend/arch/limiter"
 	coreMW "sync-backend/arch/middleware"
 	"sync-backend/arch/mongo"
 	"sync-backend/arch/network"
@@ -44,8 +51,13 @@ type appModule struct {
 	SessionService  session.SessionService
 	LocationService location.LocationService
 	TokenService    token.TokenService
+	CSRFService     csrf.CSRFService
 	MediaService    media.MediaService
 	EmailService    email.EmailService
+	PushService     push.PushService
+	ContentGuard    guard.ContentGuard
+
+	MediaLibraryService mediaLib.MediaLibraryService
 
 	// Services
 	AuthService      auth.AuthService
@@ -54,6 +66,8 @@ type appModule struct {
 	CommentService   comment.CommentService
 	ModeratorService moderator.ModeratorService
 	SystemService    system.SystemService
+	DigestService    digest.DigestService
+	AdminService     admin.AdminService
 
 	// Analytics services
 	CommunityAnalyticsService analytics.CommunityAnalytics
@@ -67,26 +81,53 @@ func (m *appModule) GetInstance() *appModule {
 
 func (m *appModule) Controllers() []network.Controller {
 	return []network.Controller{
-		auth.NewAuthController(m.AuthenticationProvider(), m.LocationProvider(), m.UploadProvider(), m.AuthService),
-		community.NewCommunityController(m.AuthenticationProvider(), m.UploadProvider(), m.UserService, m.CommunityService, m.ModeratorService, m.ModeratorMiddleware(), m.CommunityAnalyticsService),
+		auth.NewAuthController(m.AuthenticationProvider(), m.LocationProvider(), m.UploadProvider(), m.CSRFProvider(), m.AuthService, m.Config.Auth),
+		community.NewCommunityController(m.AuthenticationProvider(), m.UploadProvider(), m.UserService, m.CommunityService, m.ModeratorService, m.ModeratorMiddleware(), m.RateLimitProvider(), m.IdempotencyProvider(), m.CommunityAnalyticsService),
 		user.NewUserController(m.AuthenticationProvider(), m.UploadProvider(), m.UserService, m.LocationService),
-		post.NewPostController(m.AuthenticationProvider(), m.UploadProvider(), m.PostService, m.PostAnalyticsService, m.CommunityAnalyticsService, m.ModeratorMiddleware()),
-		comment.NewCommentController(m.AuthenticationProvider(), m.LocationProvider(), m.CommentService),
+		post.NewPostController(m.AuthenticationProvider(), m.EmailVerificationProvider(), m.UploadProvider(), m.PostService, m.MediaLibraryService, m.PostAnalyticsService, m.CommunityAnalyticsService, m.ModeratorMiddleware(), m.RateLimitProvider(), m.IdempotencyProvider()),
+		comment.NewCommentController(m.AuthenticationProvider(), m.EmailVerificationProvider(), m.LocationProvider(), m.RateLimitProvider(), m.IdempotencyProvider(), m.CommentService, m.CommentAnalyticsService),
+		mediaLib.NewMediaController(m.AuthenticationProvider(), m.UploadProvider(), m.MediaLibraryService, m.MediaService, m.Config.Media.MaxFilesPerUpload),
+		digest.NewDigestController(m.AuthenticationProvider(), m.DigestService),
+		admin.NewAdminController(m.AuthenticationProvider(), m.AuthorizationProvider(), m.AdminService, m.EmailService),
 		system.NewSystemController(m.SystemService),
 		docs.NewDocsController(),
 	}
 }
 
 func (m *appModule) AuthenticationProvider() network.AuthenticationProvider {
-	return authMW.NewAuthenticationProvider(m.TokenService, m.UserService, m.SessionService, m.Store)
+	return authMW.NewAuthenticationProvider(m.TokenService, m.UserService, m.SessionService, m.Store, m.Config.Auth.Cookie, authMW.NewCSRFProvider(m.CSRFService, m.Config.Auth.Cookie))
+}
+
+func (m *appModule) CSRFProvider() network.CSRFProvider {
+	return authMW.NewCSRFProvider(m.CSRFService, m.Config.Auth.Cookie)
 }
 
 func (m *appModule) LocationProvider() network.LocationProvider {
-	return authMW.NewLocationProvider(m.LocationService, m.Store)
+	return authMW.NewLocationProvider(m.LocationService)
+}
+
+func (m *appModule) EmailVerificationProvider() network.EmailVerificationProvider {
+	return authMW.NewEmailVerificationProvider(m.Config.Auth.Verification, m.UserService)
+}
+
+func (m *appModule) AuthorizationProvider() network.AuthorizationProvider {
+	return authMW.NewAuthorizationProvider(m.AdminService)
 }
 
 func (m *appModule) UploadProvider() coreMW.UploadProvider {
-	return coreMW.NewUploadProvider()
+	return coreMW.NewUploadProvider(m.Config.Media.UploadDir)
+}
+
+func (m *appModule) RateLimitProvider() network.RateLimitProvider {
+	return m.rateLimiter()
+}
+
+func (m *appModule) rateLimiter() coreMW.RateLimiter {
+	return coreMW.NewRateLimiter(limiter.NewRedisLimiter(m.Store), m.Config.API.RateLimit)
+}
+
+func (m *appModule) IdempotencyProvider() network.IdempotencyProvider {
+	return coreMW.NewIdempotencyProvider(m.Store, m.Config.API.Idempotency)
 }
 
 func (m *appModule) ModeratorMiddleware() modMW.ModeratorMiddleware {
@@ -94,40 +135,56 @@ func (m *appModule) ModeratorMiddleware() modMW.ModeratorMiddleware {
 }
 
 func (m *appModule) RootMiddlewares() []network.RootMiddleware {
-	middlewares := []network.RootMiddleware{}
+	// The trace and request id are set before anything else can log or respond
+	middlewares := []network.RootMiddleware{coreMW.NewTracer(), coreMW.NewLogger(m.Config.Log)}
 
-	// CORS must be first to ensure headers are set for all responses
+	if m.Config.Metrics.Enabled {
+		middlewares = append(middlewares, coreMW.NewMetrics())
+	}
+
+	// CORS runs before anything that can reject a request, so error responses carry its headers too
 	if m.Config.API.CORS.Enabled {
 		middlewares = append(middlewares, coreMW.NewCORS(m.Config.API.CORS))
 	}
 
-	middlewares = append(middlewares, coreMW.NewErrorCatcher())
+	middlewares = append(middlewares, coreMW.NewErrorCatcher(), coreMW.NewRequestDeadline(m.Config.Server.RequestTimeout))
 
 	if m.Config.API.RateLimit.Enabled {
-		middlewares = append(middlewares, coreMW.NewRateLimiter(m.Store, *m.Config))
+		middlewares = append(middlewares, m.rateLimiter())
 	}
 
 	return middlewares
 }
 
 func NewAppModule(context context.Context, env *config.Env, config *config.Config, db mongo.Database, ipDb pg.Database, store redis.Store, engine *gin.Engine) Module {
-	emailService := email.NewEmailService(env, db)
-	mediaService := media.NewMediaService(*env)
-	locationService := location.NewLocationService(ipDb)
+	emailService := email.NewEmailService(env, config, db)
+	mediaService := media.NewMediaService(*env, config)
+	locationService := location.NewLocationService(config.Location, ipDb)
 	tokenService := token.NewTokenService(config)
 	sessionService := session.NewSessionService(db)
+	csrfService := csrf.NewCSRFService(config.Auth.CSRF, db, store)
 	systemService := system.NewSystemService(config, db, store, engine)
 
-	userService := user.NewUserService(db, mediaService)
-	authService := auth.NewAuthService(config, env, userService, sessionService, tokenService, emailService)
-	communityService := community.NewCommunityService(db, mediaService)
+	cacheStore := redis.NewNoopCacheStore()
+	if config.Cache.Enabled {
+		cacheStore = redis.NewCacheStore(store)
+	}
+
+	userService := user.NewUserService(db, mediaService, cacheStore, config.Cache)
+	pushService := push.NewPushService(env, config, userService)
+	authService := auth.NewAuthService(config, env, userService, sessionService, tokenService, csrfService, emailService, store)
+	communityService := community.NewCommunityService(db, mediaService, cacheStore, config.Cache)
+	mediaLibraryService := mediaLib.NewMediaLibraryService(db, config.Media, mediaService)
 	moderatorService := moderator.NewModeratorService(db)
-	postService := post.NewPostService(db, userService, communityService, mediaService, moderatorService)
-	commentService := comment.NewCommentService(db)
+	contentGuard := guard.NewContentGuard(moderatorService)
+	postService := post.NewPostService(db, config.Feed, userService, communityService, mediaLibraryService, moderatorService, contentGuard, store, cacheStore, config.Cache)
+	commentService := comment.NewCommentService(db, contentGuard, moderatorService, mediaLibraryService, pushService)
 
 	communityAnalyticsService := analytics.NewCommunityAnalyticsService(db)
 	postAnalyticsService := analytics.NewPostAnalyticsService(db)
 	commentAnalyticsService := analytics.NewCommentAnalyticsService(db)
+	digestService := digest.NewDigestService(env, config, db, emailService, postAnalyticsService, cacheStore)
+	adminService := admin.NewAdminService(config.Admin, db, store, cacheStore, userService, sessionService, communityService, moderatorService, emailService)
 
 	return &appModule{
 		Context: context,
@@ -142,16 +199,23 @@ func NewAppModule(context context.Context, env *config.Env, config *config.Confi
 		LocationService: locationService,
 		SessionService:  sessionService,
 		TokenService:    tokenService,
+		CSRFService:     csrfService,
 		MediaService:    mediaService,
 		EmailService:    emailService,
+		PushService:     pushService,
+		ContentGuard:    contentGuard,
 		SystemService:   systemService,
 
+		MediaLibraryService: mediaLibraryService,
+
 		// Services
 		AuthService:      authService,
 		CommunityService: communityService,
 		PostService:      postService,
 		CommentService:   commentService,
 		ModeratorService: moderatorService,
+		DigestService:    digestService,
+		AdminService:     adminService,
 
 		// Analytics services
 		CommunityAnalyticsService: communityAnalyticsService,
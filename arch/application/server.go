@@ -2,17 +2,23 @@ package application
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"strconv"
 	"syscall"
 	"time"
 
+	"sync-backend/api/common/location"
+	"sync-backend/arch/background"
 	"sync-backend/arch/config"
+	"sync-backend/arch/health"
+	"sync-backend/arch/metrics"
 	"sync-backend/arch/mongo"
 	"sync-backend/arch/network"
 	pg "sync-backend/arch/postgres"
 	"sync-backend/arch/redis"
+	"sync-backend/arch/tracing"
 	"sync-backend/utils"
 
 	"github.com/gin-gonic/gin"
@@ -24,52 +30,88 @@ func Server() {
 	env := config.NewEnv(".env")
 	config := config.LoadConfig("./configs")
 	router, _, shutdown := create(&env, &config)
-	defer shutdown()
+	logger := utils.NewServiceLogger("Server")
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGTERM, os.Interrupt, syscall.SIGQUIT, syscall.SIGINT)
+	failed := make(chan error, 1)
 	go func() {
 		if err := router.Start(env.Host, uint16(env.Port)); err != nil {
-			shutdown()
-			os.Exit(1)
+			failed <- err
 		}
 	}()
-	<-stop
+
+	select {
+	case err := <-failed:
+		logger.Error("Server stopped: %v", err)
+		shutdown()
+		os.Exit(1)
+	case sig := <-stop:
+		logger.Info("Received %s, shutting down", sig)
+		shutdown()
+	}
 }
 
 func create(env *config.Env, config *config.Config) (network.Router, Module, Shutdown) {
-	context := context.Background()
+	// Background jobs stop on shutdown
+	jobs, stopJobs := context.WithCancel(context.Background())
+	ctx := context.Background()
+
+	configureLogging(env, config)
+
+	shutdownTracing, err := tracing.Setup(ctx, config.Tracing, tracing.Service{
+		Name:        config.App.Name,
+		Version:     config.App.Version,
+		Environment: env.Env,
+	})
+	if err != nil {
+		panic(err)
+	}
 
-	serverLogger := utils.DefaultAppLogger(env.Env, env.LogLevel, "Server")
-	dbLogger := utils.DefaultAppLogger(env.Env, env.LogLevel, "Database")
-	redisLogger := utils.DefaultAppLogger(env.Env, env.LogLevel, "Redis")
+	serverLogger := utils.NewServiceLogger("Server")
+	dbLogger := utils.NewServiceLogger("Database")
+	redisLogger := utils.NewServiceLogger("Redis")
 
 	dbConfig := mongo.DbConfig{
-		User:        env.DBUser,
-		Pwd:         env.DBPassword,
-		Host:        env.DBHost,
-		Name:        env.DBName,
-		MinPoolSize: uint16(config.DB.MinPoolSize),
-		MaxPoolSize: uint16(config.DB.MaxPoolSize),
-		Timeout:     config.DB.TimeoutConfig.ConnectTimeout,
+		User:         env.DBUser,
+		Pwd:          env.DBPassword,
+		Host:         env.DBHost,
+		Name:         env.DBName,
+		MinPoolSize:  uint16(config.DB.MinPoolSize),
+		MaxPoolSize:  uint16(config.DB.MaxPoolSize),
+		Timeout:      config.DB.TimeoutConfig.ConnectTimeout,
+		QueryTimeout: config.DB.TimeoutConfig.QueryTimeout,
+		// Pagination cursors are signed with the JWT secret so they survive restarts
+		CursorSecret: env.JWTSecret,
 	}
 
-	db := mongo.NewDatabase(context, dbLogger, dbConfig)
+	db := mongo.NewDatabase(ctx, dbLogger, dbConfig)
 	db.Connect()
 
-	ipDbConfig := pg.DbConfig{
-		User:         env.IpDBUser,
-		Pwd:          env.IpDBPassword,
-		Host:         env.IpDBHost,
-		Port:         strconv.Itoa(env.IpDBPort),
-		Name:         env.IpDBName,
-		SSLMode:      "require",
-		MaxOpenConns: 20,
-		MaxIdleConns: 10,
-		MaxLifetime:  time.Minute * 50,
-	}
+	// The IP database is only needed when locations are read from postgres
+	var ipDb pg.Database
+	if config.Location.Backend == location.BackendPostgres || config.Location.Backend == "" {
+		if env.IpDBHost == "" || env.IpDBPort == 0 || env.IpDBUser == "" || env.IpDBName == "" {
+			panic("Mandatory env variables not found: the postgres location backend needs IP_DB_HOST, IP_DB_PORT, IP_DB_USER, IP_DB_PASSWORD and IP_DB_NAME")
+		}
+		ipDbConfig := pg.DbConfig{
+			User:         env.IpDBUser,
+			Pwd:          env.IpDBPassword,
+			Host:         env.IpDBHost,
+			Port:         strconv.Itoa(env.IpDBPort),
+			Name:         env.IpDBName,
+			SSLMode:      config.Location.Postgres.SSLMode,
+			MaxOpenConns: 20,
+			MaxIdleConns: 10,
+			MaxLifetime:  time.Minute * 50,
+		}
+		if ipDbConfig.SSLMode == "" {
+			ipDbConfig.SSLMode = "require"
+		}
 
-	ipDb := pg.NewDatabase(context, dbLogger, ipDbConfig)
-	ipDb.Connect()
+		ipDb = pg.NewDatabase(ctx, dbLogger, ipDbConfig)
+		ipDb.Connect()
+	}
 
 	if env.Env != gin.TestMode {
 		EnsureDbIndexes(db)
@@ -81,22 +123,123 @@ func create(env *config.Env, config *config.Config) (network.Router, Module, Shu
 		Pwd:  env.RedisPassword,
 	}
 
-	store := redis.NewStore(context, redisLogger, &redisConfig)
+	store := redis.NewStore(ctx, redisLogger, &redisConfig)
 	store.Connect()
 
 	versionInt, _ := strconv.Atoi(config.API.Version)
-	router := network.NewRouter(env.Env, config.API.Prefix, versionInt, serverLogger)
-	module := NewAppModule(context, env, config, db, ipDb, store, router.GetEngine())
+	router := network.NewRouter(env.Env, config.API.Prefix, versionInt, config.Server, serverLogger)
+	module := NewAppModule(ctx, env, config, db, ipDb, store, router.GetEngine())
 	router.RegisterValidationParsers(network.CustomTagNameFunc())
+
+	// Probes are mounted before the root middlewares so they are never rate limited, logged or traced
+	probe := health.NewProbe()
+	probe.AddCheck("mongo", db.Ping)
+	probe.AddCheck("redis", func(ctx context.Context) error {
+		return store.GetInstance().Ping(ctx).Err()
+	})
+	router.GetEngine().GET("/livez", probe.Livez)
+	router.GetEngine().GET("/readyz", probe.Readyz)
+
 	router.LoadRootMiddlewares(module.RootMiddlewares())
 	router.LoadControllers(module.Controllers())
+	if config.Metrics.Enabled {
+		metricsPath := config.Metrics.Path
+		if metricsPath == "" {
+			metricsPath = "/metrics"
+		}
+		// Fail closed, without a token the endpoint is only served in development
+		if env.MetricsToken == "" && env.Env != "development" {
+			serverLogger.Warn("METRICS_TOKEN is not set, %s is not mounted outside development", metricsPath)
+		} else {
+			router.GetEngine().GET(metricsPath, metrics.Handler(env.MetricsToken))
+		}
+	}
 
+	background.Go(func() { module.GetInstance().MediaLibraryService.StartOrphanSweeper(jobs) })
+	background.Go(func() { module.GetInstance().EmailService.StartOutboxWorker(jobs) })
+	background.Go(func() { module.GetInstance().DigestService.StartScheduler(jobs) })
+
+	// shutdown fails readiness, lets in-flight requests finish, then stops the jobs and waits for
+	// every background task before the connections they use are closed
 	shutdown := func() {
+		probe.Drain()
+		if config.Server.DrainDelay > 0 && env.Env != gin.TestMode {
+			serverLogger.Info("Draining for %s before closing the listener", config.Server.DrainDelay)
+			time.Sleep(config.Server.DrainDelay)
+		}
+
+		drain, cancelDrain := context.WithTimeout(context.Background(), orDefault(config.Server.ShutdownTimeout, 30*time.Second))
+		defer cancelDrain()
+		if err := router.Shutdown(drain); err != nil {
+			serverLogger.Error("Failed to drain in-flight requests: %v", err)
+		}
+
+		stopJobs()
+		flush, cancelFlush := context.WithTimeout(context.Background(), orDefault(config.Server.BackgroundTimeout, 30*time.Second))
+		defer cancelFlush()
+		if err := background.Wait(flush); err != nil {
+			serverLogger.Error("Failed to flush background tasks: %v", err)
+		}
+
 		db.Disconnect()
-		ipDb.Disconnect()
+		if ipDb != nil {
+			ipDb.Disconnect()
+		}
 		store.Disconnect()
-		context.Done()
+
+		// Spans of the shutdown itself are exported last
+		exportTraces, cancelExport := context.WithTimeout(context.Background(), traceFlushTimeout)
+		defer cancelExport()
+		if err := shutdownTracing(exportTraces); err != nil {
+			serverLogger.Error("Failed to flush traces: %v", err)
+		}
 	}
 
 	return router, module, shutdown
 }
+
+// traceFlushTimeout bounds exporting the spans still buffered at shutdown
+const traceFlushTimeout = 5 * time.Second
+
+func orDefault(timeout time.Duration, fallback time.Duration) time.Duration {
+	if timeout <= 0 {
+		return fallback
+	}
+	return timeout
+}
+
+// configureLogging applies the log section of app.yaml, LOG_LEVEL overrides its level when set
+func configureLogging(env *config.Env, config *config.Config) {
+	logConfig := config.Log
+	output, err := utils.NewLogOutput(logConfig.Output, logConfig.FilePath, utils.LogRotation{
+		Enabled:    logConfig.Rotation.Enabled,
+		MaxSize:    logConfig.Rotation.MaxSize,
+		MaxAge:     logConfig.Rotation.MaxAge,
+		MaxBackups: logConfig.Rotation.MaxBackups,
+		Compress:   logConfig.Rotation.Compress,
+	})
+	if err != nil {
+		panic(fmt.Errorf("error configuring logging: %w", err))
+	}
+
+	level := logConfig.Level
+	if env.LogLevel != "" {
+		level = env.LogLevel
+	}
+
+	fields := map[string]string{"app": config.App.Name, "version": config.App.Version, "env": env.Env}
+	for key, value := range logConfig.Fields {
+		fields[key] = value
+	}
+
+	utils.ConfigureLogging(utils.LogConfig{
+		Environment:     env.Env,
+		Level:           utils.ParseLogLevel(level),
+		Format:          logConfig.Format,
+		Output:          output,
+		Fields:          fields,
+		Sanitize:        logConfig.Sanitize.Fields,
+		Stacktrace:      logConfig.Stacktrace.Enabled,
+		StacktraceLevel: utils.ParseLogLevel(logConfig.Stacktrace.Level),
+	})
+}
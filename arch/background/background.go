@@ -0,0 +1,69 @@
+package background
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+
+	"sync-backend/utils"
+)
+
+// Group tracks goroutines that outlive the request which started them, so that shutdown can wait for them
+type Group struct {
+	logger  utils.AppLogger
+	wg      sync.WaitGroup
+	pending atomic.Int64
+}
+
+func NewGroup() *Group {
+	return &Group{logger: utils.NewServiceLogger("Background")}
+}
+
+// Go runs fn in a tracked goroutine, a panic is logged instead of taking the process down
+func (g *Group) Go(fn func()) {
+	g.wg.Add(1)
+	g.pending.Add(1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				g.logger.Error("Background task panicked: %v\n%s", r, debug.Stack())
+			}
+			g.pending.Add(-1)
+			g.wg.Done()
+		}()
+		fn()
+	}()
+}
+
+// Wait blocks until every tracked goroutine returned or the context is done
+func (g *Group) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d background tasks still running: %w", g.Pending(), ctx.Err())
+	}
+}
+
+func (g *Group) Pending() int64 {
+	return g.pending.Load()
+}
+
+var defaultGroup = NewGroup()
+
+// Go runs fn in a goroutine the process waits for on shutdown
+func Go(fn func()) {
+	defaultGroup.Go(fn)
+}
+
+// Wait blocks until the goroutines started by Go returned or the context is done
+func Wait(ctx context.Context) error {
+	return defaultGroup.Wait(ctx)
+}
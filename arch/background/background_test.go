@@ -0,0 +1,39 @@
+package background
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupWaitsForTasks(t *testing.T) {
+	group := NewGroup()
+	var finished atomic.Int32
+	for i := 0; i < 3; i++ {
+		group.Go(func() {
+			time.Sleep(10 * time.Millisecond)
+			finished.Add(1)
+		})
+	}
+	group.Go(func() { panic("boom") })
+
+	assert.NoError(t, group.Wait(context.Background()))
+	assert.Equal(t, int32(3), finished.Load())
+	assert.Zero(t, group.Pending())
+}
+
+func TestGroupWaitTimesOut(t *testing.T) {
+	group := NewGroup()
+	release := make(chan struct{})
+	defer close(release)
+	group.Go(func() { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := group.Wait(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int64(1), group.Pending())
+}
@@ -90,6 +90,19 @@ func (l Language) ToDetail() LanguageDetail {
 	return languageDetails[l]
 }
 
+// ID returns the language code, only the names are persisted so stored details are matched by name
+func (d LanguageDetail) ID() string {
+	if d.id != "" {
+		return d.id
+	}
+	for _, detail := range languageDetails {
+		if detail.DisplayName == d.DisplayName {
+			return detail.id
+		}
+	}
+	return English.ID()
+}
+
 func AllLanguages() []Language {
 	var all []Language
 	for lang := range languageDetails {
@@ -3,6 +3,7 @@ package common
 import (
 	coredto "sync-backend/arch/dto"
 	"sync-backend/arch/network"
+	"sync-backend/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,8 +16,12 @@ type ContextPayload interface {
 	MustGetSessionId(ctx *gin.Context) string
 	SetSessionId(ctx *gin.Context, value string)
 
+	GetPlatformRole(ctx *gin.Context) string
+	SetPlatformRole(ctx *gin.Context, value string)
+
 	MustGetIP(ctx *gin.Context) string
 	MustGetUserAgent(ctx *gin.Context) string
+	MustGetDeviceId(ctx *gin.Context) string
 	SetRequestDeviceDetails(ctx *gin.Context, req *coredto.BaseDeviceRequest)
 	SetRequestLocationDetails(ctx *gin.Context, req *coredto.BaseLocationRequest)
 }
@@ -52,6 +57,9 @@ func (payload *payload) MustGetUserId(ctx *gin.Context) *string {
 
 func (payload *payload) SetUserId(ctx *gin.Context, value string) {
 	ctx.Set(network.UserPayload, value)
+	if ctx.Request != nil {
+		utils.SetLogUserId(ctx.Request.Context(), value)
+	}
 }
 
 func (payload *payload) MustGetSessionId(ctx *gin.Context) string {
@@ -66,6 +74,15 @@ func (payload *payload) SetSessionId(ctx *gin.Context, value string) {
 	ctx.Set(network.SessionIdHeader, value)
 }
 
+// GetPlatformRole returns the platform role the authorization middleware resolved, empty on other routes
+func (payload *payload) GetPlatformRole(ctx *gin.Context) string {
+	return ctx.GetString(network.PlatformRole)
+}
+
+func (payload *payload) SetPlatformRole(ctx *gin.Context, value string) {
+	ctx.Set(network.PlatformRole, value)
+}
+
 func (payload *payload) MustGetIP(ctx *gin.Context) string {
 	return ctx.ClientIP()
 }
@@ -0,0 +1,141 @@
+package common
+
+import (
+	"fmt"
+	coredto "sync-backend/arch/dto"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ReactionType is a reaction a user can leave on a post or a comment
+type ReactionType string
+
+const (
+	ReactionTypeLike    ReactionType = "like"
+	ReactionTypeLove    ReactionType = "love"
+	ReactionTypeLaugh   ReactionType = "laugh"
+	ReactionTypeSad     ReactionType = "sad"
+	ReactionTypeAngry   ReactionType = "angry"
+	ReactionTypeWow     ReactionType = "wow"
+	ReactionTypeSupport ReactionType = "support"
+)
+
+// ReactionTypes lists every supported reaction
+var ReactionTypes = []ReactionType{
+	ReactionTypeLike,
+	ReactionTypeLove,
+	ReactionTypeLaugh,
+	ReactionTypeSad,
+	ReactionTypeAngry,
+	ReactionTypeWow,
+	ReactionTypeSupport,
+}
+
+func (r ReactionType) IsValid() bool {
+	for _, reaction := range ReactionTypes {
+		if r == reaction {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================
+// ||           SetReaction Request           ||
+// ============================================
+
+// SetReactionRequest is the body of setting a reaction on a post or a comment
+type SetReactionRequest struct {
+	Reaction ReactionType `json:"reaction" binding:"required" validate:"required,oneof=like love laugh sad angry wow support"`
+}
+
+func NewSetReactionRequest() *SetReactionRequest {
+	return &SetReactionRequest{}
+}
+
+func (l *SetReactionRequest) GetValue() *SetReactionRequest {
+	return l
+}
+
+func (s *SetReactionRequest) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		switch err.Tag() {
+		case "required":
+			msgs = append(msgs, fmt.Sprintf("%s is required", err.Field()))
+		case "oneof":
+			msgs = append(msgs, fmt.Sprintf("%s must be one of: %s", err.Field(), err.Param()))
+		default:
+			msgs = append(msgs, fmt.Sprintf("%s is invalid", err.Field()))
+		}
+	}
+	return msgs, nil
+}
+
+// ============================================
+// ||           GetReactors Request           ||
+// ============================================
+
+// GetReactorsRequest pages through the users who reacted to a post or a comment
+type GetReactorsRequest struct {
+	coredto.CursorPagination
+	Reaction ReactionType `form:"type" query:"type" validate:"omitempty,oneof=like love laugh sad angry wow support"`
+}
+
+func NewGetReactorsRequest() *GetReactorsRequest {
+	return &GetReactorsRequest{
+		CursorPagination: *coredto.NewCursorPagination(),
+	}
+}
+
+func (l *GetReactorsRequest) GetValue() *GetReactorsRequest {
+	return l
+}
+
+func (s *GetReactorsRequest) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		switch err.Tag() {
+		case "oneof":
+			msgs = append(msgs, fmt.Sprintf("%s must be one of: %s", err.Field(), err.Param()))
+		default:
+			msgs = append(msgs, fmt.Sprintf("%s is invalid", err.Field()))
+		}
+	}
+	return msgs, nil
+}
+
+// ============================================
+// ||            Reaction Response            ||
+// ============================================
+
+// ReactionResponse is the reaction of the caller and the reaction counts after a change
+type ReactionResponse struct {
+	MyReaction     ReactionType         `json:"myReaction,omitempty"`
+	ReactionCounts map[ReactionType]int `json:"reactionCounts"`
+}
+
+func NewReactionResponse(myReaction ReactionType, reactionCounts map[ReactionType]int) *ReactionResponse {
+	if reactionCounts == nil {
+		reactionCounts = make(map[ReactionType]int)
+	}
+	return &ReactionResponse{
+		MyReaction:     myReaction,
+		ReactionCounts: reactionCounts,
+	}
+}
+
+func (l *ReactionResponse) GetValue() *ReactionResponse {
+	return l
+}
+
+func (l *ReactionResponse) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		switch err.Tag() {
+		default:
+			msgs = append(msgs, err.Field()+" is invalid")
+		}
+	}
+	return msgs, nil
+}
@@ -0,0 +1,50 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReactionType_IsValid(t *testing.T) {
+	for _, reaction := range ReactionTypes {
+		assert.True(t, reaction.IsValid(), reaction)
+	}
+
+	assert.False(t, ReactionType("").IsValid())
+	assert.False(t, ReactionType("dislike").IsValid())
+	assert.False(t, ReactionType("Like").IsValid())
+}
+
+func TestSetReactionRequest_AcceptsEveryReaction(t *testing.T) {
+	validate := validator.New()
+	for _, reaction := range ReactionTypes {
+		assert.NoError(t, validate.Struct(&SetReactionRequest{Reaction: reaction}), reaction)
+	}
+}
+
+func TestSetReactionRequest_RejectsUnknownReaction(t *testing.T) {
+	req := &SetReactionRequest{Reaction: "dislike"}
+	err := validator.New().Struct(req)
+	require.Error(t, err)
+
+	msgs, _ := req.ValidateErrors(err.(validator.ValidationErrors))
+	assert.Equal(t, []string{"Reaction must be one of: like love laugh sad angry wow support"}, msgs)
+}
+
+func TestGetReactorsRequest_TypeIsOptional(t *testing.T) {
+	validate := validator.New()
+	assert.NoError(t, validate.Struct(NewGetReactorsRequest()))
+
+	req := NewGetReactorsRequest()
+	req.Reaction = "boo"
+	assert.Error(t, validate.Struct(req))
+}
+
+func TestNewReactionResponse_NeverReturnsNilCounts(t *testing.T) {
+	res := NewReactionResponse("", nil)
+	assert.NotNil(t, res.ReactionCounts)
+	assert.Empty(t, res.MyReaction)
+}
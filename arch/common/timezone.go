@@ -3,6 +3,7 @@ package common
 import (
 	"fmt"
 	"time"
+	_ "time/tzdata" // Locations must resolve in containers without a zoneinfo database
 )
 
 type TimeZone int
@@ -155,6 +156,24 @@ var timeZoneDetails = map[TimeZone]TimeZoneDetail{
 	AfricaCasablanca:   {id: "Africa/Casablanca", DisplayName: "Casablanca", Timezone: "UTC+0/UTC+1"},
 }
 
+// Location resolves a stored detail, only the names are persisted so it is matched by name. Unknown
+// zones resolve to UTC.
+func (d TimeZoneDetail) Location() *time.Location {
+	id := d.id
+	if id == "" {
+		for _, detail := range timeZoneDetails {
+			if detail.DisplayName == d.DisplayName {
+				id = detail.id
+				break
+			}
+		}
+	}
+	if loc, err := time.LoadLocation(id); err == nil && id != "" {
+		return loc
+	}
+	return time.UTC
+}
+
 func AllTimeZones() []TimeZone {
 	var all []TimeZone
 	for tz := range timeZoneDetails {
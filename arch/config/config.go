@@ -11,12 +11,22 @@ import (
 
 // Config holds all the configuration for the application
 type Config struct {
-	App    AppConfig    `mapstructure:"app"`
-	DB     DBConfig     `mapstructure:"db"`
-	Server ServerConfig `mapstructure:"server"`
-	API    APIConfig    `mapstructure:"api"`
-	Auth   AuthConfig   `mapstructure:"auth"`
-	Log    LogConfig    `mapstructure:"log"`
+	App      AppConfig      `mapstructure:"app"`
+	DB       DBConfig       `mapstructure:"db"`
+	Server   ServerConfig   `mapstructure:"server"`
+	API      APIConfig      `mapstructure:"api"`
+	Auth     AuthConfig     `mapstructure:"auth"`
+	Log      LogConfig      `mapstructure:"log"`
+	Feed     FeedConfig     `mapstructure:"feed"`
+	Media    MediaConfig    `mapstructure:"media"`
+	Email    EmailConfig    `mapstructure:"email"`
+	Digest   DigestConfig   `mapstructure:"digest"`
+	Push     PushConfig     `mapstructure:"push"`
+	Location LocationConfig `mapstructure:"location"`
+	Admin    AdminConfig    `mapstructure:"admin"`
+	Metrics  MetricsConfig  `mapstructure:"metrics"`
+	Tracing  TracingConfig  `mapstructure:"tracing"`
+	Cache    CacheConfig    `mapstructure:"cache"`
 }
 
 // AppConfig holds application-specific configuration
@@ -69,24 +79,53 @@ type ServerConfig struct {
 	MaxHeaderBytes  int           `mapstructure:"max_header_bytes"`
 	MaxConnections  int           `mapstructure:"max_connections"`
 	MaxIdleConns    int           `mapstructure:"max_idle_connections"`
-	RequestTimeout  string        `mapstructure:"request_timeout"`
+	RequestTimeout  time.Duration `mapstructure:"request_timeout"`
 	MaxRequestSize  int           `mapstructure:"max_request_size"`
 	MaxResponseSize int           `mapstructure:"max_response_size"`
+	// DrainDelay is how long readiness fails before the server stops accepting connections on shutdown
+	DrainDelay time.Duration `mapstructure:"drain_delay"`
+	// ShutdownTimeout bounds the wait for in-flight requests
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	// BackgroundTimeout bounds the wait for background tasks, it starts once the requests are done
+	BackgroundTimeout time.Duration `mapstructure:"background_timeout"`
 }
 
 // APIConfig holds API-specific configuration
 type APIConfig struct {
-	Version   string          `mapstructure:"version"`
-	Prefix    string          `mapstructure:"prefix"`
-	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
-	CORS      CORSConfig      `mapstructure:"cors"`
+	Version     string            `mapstructure:"version"`
+	Prefix      string            `mapstructure:"prefix"`
+	RateLimit   RateLimitConfig   `mapstructure:"rate_limit"`
+	CORS        CORSConfig        `mapstructure:"cors"`
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
 }
 
 // RateLimitConfig holds rate limiting configuration
 type RateLimitConfig struct {
-	Enabled     bool          `mapstructure:"enabled"`
-	MaxRequests int           `mapstructure:"max_requests"`
-	Window      time.Duration `mapstructure:"window"`
+	Enabled bool `mapstructure:"enabled"`
+	// FailOpen lets requests through when the limits can not be checked, otherwise they get a 503
+	FailOpen bool `mapstructure:"fail_open"`
+	// Global applies to every request
+	Global RateLimitPolicy `mapstructure:"global"`
+	// Policies are applied by name to route groups, a name without a policy is not limited
+	Policies map[string]RateLimitPolicy `mapstructure:"policies"`
+}
+
+// RateLimitPolicy allows Requests per Window for every IP or user
+type RateLimitPolicy struct {
+	// sliding_window or token_bucket
+	Algorithm string        `mapstructure:"algorithm"`
+	Requests  int           `mapstructure:"requests"`
+	Window    time.Duration `mapstructure:"window"`
+	// ip or user. Requests without a user are keyed by IP
+	KeyBy string `mapstructure:"key_by"`
+}
+
+// IdempotencyConfig holds the Idempotency-Key replay configuration of mutating routes
+type IdempotencyConfig struct {
+	// TTL is how long a response is replayed for repeats of its key
+	TTL time.Duration `mapstructure:"ttl"`
+	// LockTimeout bounds how long a request in flight holds its key, a crashed one must not hold it forever
+	LockTimeout time.Duration `mapstructure:"lock_timeout"`
 }
 
 // CORSConfig holds CORS configuration
@@ -108,7 +147,9 @@ type AuthConfig struct {
 	Verification  VerificationConfig  `mapstructure:"verification"`
 	PasswordReset PasswordResetConfig `mapstructure:"password-reset"`
 	CSRF          CSRFConfig          `mapstructure:"csrf"`
+	Cookie        AuthCookieConfig    `mapstructure:"cookie"`
 	RateLimit     AuthRateLimitConfig `mapstructure:"rate_limit"`
+	LoginRisk     LoginRiskConfig     `mapstructure:"login_risk"`
 }
 
 // JWTConfig holds JWT configuration
@@ -163,9 +204,21 @@ type PasswordResetConfig struct {
 
 // CSRFConfig holds CSRF configuration
 type CSRFConfig struct {
-	TokenLength  int    `mapstructure:"token_length"`
+	TokenLength  int    `mapstructure:"token_length"` // random bytes, the token is their base64url encoding
 	TokenExpiry  string `mapstructure:"token_expiry"`
-	TokenStorage string `mapstructure:"token_storage"`
+	TokenStorage string `mapstructure:"token_storage"` // in-memory, redis or database
+}
+
+// AuthCookieConfig holds the cookie transport of the tokens, an opt-in of web clients
+type AuthCookieConfig struct {
+	Enabled          bool   `mapstructure:"enabled"`
+	AccessTokenName  string `mapstructure:"access_token_name"`
+	RefreshTokenName string `mapstructure:"refresh_token_name"`
+	CSRFTokenName    string `mapstructure:"csrf_token_name"`
+	Domain           string `mapstructure:"domain"`
+	Path             string `mapstructure:"path"`
+	Secure           bool   `mapstructure:"secure"`
+	SameSite         string `mapstructure:"same_site"` // lax, strict or none, none requires secure
 }
 
 // AuthRateLimitConfig holds authentication rate limit configuration
@@ -174,7 +227,19 @@ type AuthRateLimitConfig struct {
 	Registration  RateLimitRule `mapstructure:"registration"`
 	PasswordReset RateLimitRule `mapstructure:"password_reset"`
 	Verification  RateLimitRule `mapstructure:"verification"`
-	General       RateLimitRule `mapstructure:"general"`
+}
+
+// LoginRiskConfig holds the checks comparing a login to the user's recent ones
+type LoginRiskConfig struct {
+	Enabled    bool `mapstructure:"enabled"`
+	AlertEmail bool `mapstructure:"alert_email"`
+	// Hold risky password logins back until a code emailed to the user is entered
+	StepUp            bool          `mapstructure:"step_up"`
+	MaxTravelSpeed    float64       `mapstructure:"max_travel_speed"`    // km/h, faster moves between logins are impossible travel
+	MinTravelDistance float64       `mapstructure:"min_travel_distance"` // km, shorter moves are never flagged
+	CodeLength        int           `mapstructure:"code_length"`
+	CodeExpiry        time.Duration `mapstructure:"code_expiry"`
+	CodeAttempts      int           `mapstructure:"code_attempts"`
 }
 
 // RateLimitRule holds rate limit rule configuration
@@ -237,6 +302,180 @@ type RotationConfig struct {
 	Compress   bool `mapstructure:"compress"`
 }
 
+// FeedConfig holds home feed ranking configuration
+type FeedConfig struct {
+	CandidateWindow     time.Duration `mapstructure:"candidate_window"`
+	CandidateLimit      int           `mapstructure:"candidate_limit"`
+	HotWeight           float64       `mapstructure:"hot_weight"`
+	TrendingWeight      float64       `mapstructure:"trending_weight"`
+	FollowedAuthorBoost float64       `mapstructure:"followed_author_boost"`
+	TagAffinityWeight   float64       `mapstructure:"tag_affinity_weight"`
+	SeenPenalty         float64       `mapstructure:"seen_penalty"`
+	MaxPerCommunity     int           `mapstructure:"max_per_community"`
+	DiversityWindow     int           `mapstructure:"diversity_window"`
+	SnapshotTTL         time.Duration `mapstructure:"snapshot_ttl"`
+}
+
+// MediaConfig holds uploaded media configuration
+type MediaConfig struct {
+	UploadDir         string             `mapstructure:"upload_dir"`
+	Storage           MediaStorageConfig `mapstructure:"storage"`
+	Images            ImageConfig        `mapstructure:"images"`
+	MaxFilesPerUpload int                `mapstructure:"max_files_per_upload"`
+	OrphanGracePeriod time.Duration      `mapstructure:"orphan_grace_period"`
+	SweepInterval     time.Duration      `mapstructure:"sweep_interval"`
+	SweepBatchSize    int                `mapstructure:"sweep_batch_size"`
+}
+
+// EmailConfig selects the email provider, one of sendgrid, smtp or file, and configures the outbox
+type EmailConfig struct {
+	Driver    string            `mapstructure:"driver"`
+	FromEmail string            `mapstructure:"from_email"`
+	FromName  string            `mapstructure:"from_name"`
+	SMTP      SMTPConfig        `mapstructure:"smtp"`
+	File      FileEmailConfig   `mapstructure:"file"`
+	Outbox    EmailOutboxConfig `mapstructure:"outbox"`
+}
+
+// LocationConfig selects where IP locations come from, postgres reads the geoip2 tables of the IP database
+// and maxmind a local GeoLite2 or GeoIP2 City .mmdb file. Lookups are cached in memory either way.
+type LocationConfig struct {
+	Backend   string                 `mapstructure:"backend"`
+	MaxMind   MaxMindConfig          `mapstructure:"maxmind"`
+	Postgres  LocationPostgresConfig `mapstructure:"postgres"`
+	CacheSize int                    `mapstructure:"cache_size"`
+	CacheTTL  time.Duration          `mapstructure:"cache_ttl"`
+}
+
+// MaxMindConfig holds the maxmind location backend configuration
+type MaxMindConfig struct {
+	Path string `mapstructure:"path"`
+}
+
+// LocationPostgresConfig holds the IP database connection settings, credentials come from the env
+type LocationPostgresConfig struct {
+	SSLMode string `mapstructure:"ssl_mode"`
+}
+
+// PushConfig selects the push driver, native delivers through FCM and APNs and fake only logs.
+// A native platform without credentials in the env is skipped.
+type PushConfig struct {
+	Driver string     `mapstructure:"driver"`
+	FCM    FCMConfig  `mapstructure:"fcm"`
+	APNs   APNsConfig `mapstructure:"apns"`
+}
+
+// FCMConfig holds Firebase Cloud Messaging configuration, the service account comes from the env
+type FCMConfig struct {
+	ProjectId string `mapstructure:"project_id"` // Defaults to the project of the service account
+}
+
+// APNsConfig holds Apple Push Notification service configuration, the .p8 auth key comes from the env
+type APNsConfig struct {
+	KeyId      string `mapstructure:"key_id"`
+	TeamId     string `mapstructure:"team_id"`
+	Topic      string `mapstructure:"topic"` // The app bundle ID
+	Production bool   `mapstructure:"production"`
+}
+
+// SMTPConfig holds SMTP server configuration, credentials come from the env
+type SMTPConfig struct {
+	Host string `mapstructure:"host"`
+	Port int    `mapstructure:"port"`
+	TLS  string `mapstructure:"tls"` // none, starttls or tls
+}
+
+// FileEmailConfig holds the dev email driver configuration, an empty dir logs to the console
+type FileEmailConfig struct {
+	Dir string `mapstructure:"dir"`
+}
+
+// EmailOutboxConfig holds the email outbox worker configuration
+type EmailOutboxConfig struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	BatchSize    int           `mapstructure:"batch_size"`
+	MaxAttempts  int           `mapstructure:"max_attempts"`
+	BaseBackoff  time.Duration `mapstructure:"base_backoff"`
+	MaxBackoff   time.Duration `mapstructure:"max_backoff"`
+	LockTimeout  time.Duration `mapstructure:"lock_timeout"`
+}
+
+// DigestConfig holds notification digest email configuration
+type DigestConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	SendHour       int           `mapstructure:"send_hour"`  // Hour of the day in the user's timezone
+	WeeklyDay      string        `mapstructure:"weekly_day"` // Weekday weekly digests go out on, e.g. monday
+	CheckInterval  time.Duration `mapstructure:"check_interval"`
+	BatchSize      int           `mapstructure:"batch_size"`
+	MaxItems       int           `mapstructure:"max_items"`       // Entries per digest section
+	MaxCommunities int           `mapstructure:"max_communities"` // Joined communities top posts are taken from
+	// Unsubscribe links stop working this long after their digest was sent
+	UnsubscribeExpiry time.Duration `mapstructure:"unsubscribe_expiry"`
+}
+
+// AdminConfig holds platform administration configuration
+type AdminConfig struct {
+	UserIds []string `mapstructure:"user_ids"`
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration
+type TracingConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// otlp or stdout
+	Exporter string `mapstructure:"exporter"`
+	// host:port of the OTLP/HTTP collector, spans go to stdout when empty
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// CacheConfig holds the Redis read-through cache configuration of hot entities
+type CacheConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Jitter spreads expiries by up to this fraction of the TTL
+	Jitter       float64       `mapstructure:"jitter"`
+	UserTTL      time.Duration `mapstructure:"user_ttl"`
+	CommunityTTL time.Duration `mapstructure:"community_ttl"`
+	PostTTL      time.Duration `mapstructure:"post_ttl"`
+}
+
+// MetricsConfig holds the Prometheus exposition endpoint configuration
+type MetricsConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Path    string `mapstructure:"path"`
+}
+
+// MediaStorageConfig selects the media storage driver, one of local, s3 or cloudinary
+type MediaStorageConfig struct {
+	Driver string             `mapstructure:"driver"`
+	Local  LocalStorageConfig `mapstructure:"local"`
+	S3     S3StorageConfig    `mapstructure:"s3"`
+}
+
+// ImageConfig holds the image processing pipeline configuration
+type ImageConfig struct {
+	MaxDimension     int   `mapstructure:"max_dimension"`
+	JPEGQuality      int   `mapstructure:"jpeg_quality"`
+	RenditionWidths  []int `mapstructure:"rendition_widths"`
+	BlurHashXComps   int   `mapstructure:"blurhash_x_components"`
+	BlurHashYComps   int   `mapstructure:"blurhash_y_components"`
+	MaxDecodedPixels int   `mapstructure:"max_decoded_pixels"`
+}
+
+// LocalStorageConfig holds local disk storage configuration
+type LocalStorageConfig struct {
+	Root string `mapstructure:"root"`
+}
+
+// S3StorageConfig holds S3-compatible object store configuration, credentials come from the env
+type S3StorageConfig struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	UseSSL    bool   `mapstructure:"use_ssl"`
+	PublicURL string `mapstructure:"public_url"`
+}
+
 // LoadConfig loads configuration from files and environment variables
 func LoadConfig(path string) Config {
 	var config Config
@@ -35,10 +35,23 @@ type Env struct {
 	CloudinaryAPIKey    string `mapstructure:"CLOUDINARY_API_KEY"`
 	CloudinaryAPISecret string `mapstructure:"CLOUDINARY_API_SECRET"`
 
+	S3AccessKey string `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey string `mapstructure:"S3_SECRET_KEY"`
+
+	MediaSigningKey string `mapstructure:"MEDIA_SIGNING_KEY"`
+
 	SendGridAPIKey   string `mapstructure:"SENDGRID_API_KEY"`
 	SendGridFromEmail string `mapstructure:"SENDGRID_FROM_EMAIL"`
 	SendGridFromName  string `mapstructure:"SENDGRID_FROM_NAME"`
 
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+
+	FCMCredentialsFile string `mapstructure:"FCM_CREDENTIALS_FILE"`
+	APNsAuthKeyFile    string `mapstructure:"APNS_AUTH_KEY_FILE"`
+
+	MetricsToken string `mapstructure:"METRICS_TOKEN"`
+
 	AppFrontendURL string `mapstructure:"APP_FRONTEND_URL"`
 	AppBackendURL  string `mapstructure:"APP_BACKEND_URL"`
 }
@@ -55,27 +68,40 @@ func NewEnv(file string) Env {
 		DBPassword:          GetStrEnvOrPanic("DB_PASSWORD"),
 		DBHost:              GetStrEnvOrPanic("DB_HOST"),
 		DBName:              GetStrEnvOrPanic("DB_NAME"),
-		IpDBHost:            GetStrEnvOrPanic("IP_DB_HOST"),
-		IpDBPort:            GetIntEnvOrPanic("IP_DB_PORT"),
-		IpDBUser:            GetStrEnvOrPanic("IP_DB_USER"),
-		IpDBPassword:        GetStrEnvOrPanic("IP_DB_PASSWORD"),
-		IpDBName:            GetStrEnvOrPanic("IP_DB_NAME"),
+		IpDBHost:            GetStrEnv("IP_DB_HOST"),
+		IpDBPort:            GetIntEnv("IP_DB_PORT"),
+		IpDBUser:            GetStrEnv("IP_DB_USER"),
+		IpDBPassword:        GetStrEnv("IP_DB_PASSWORD"),
+		IpDBName:            GetStrEnv("IP_DB_NAME"),
 		RedisHost:           GetStrEnvOrPanic("REDIS_HOST"),
 		RedisPort:           GetIntEnvOrPanic("REDIS_PORT"),
 		RedisDB:             GetIntEnvOrPanic("REDIS_DB"),
 		RedisPassword:       GetStrEnvOrPanic("REDIS_PASSWORD"),
-		CloudinaryCloudName: GetStrEnvOrPanic("CLOUDINARY_CLOUD_NAME"),
-		CloudinaryAPIKey:    GetStrEnvOrPanic("CLOUDINARY_API_KEY"),
-		CloudinaryAPISecret: GetStrEnvOrPanic("CLOUDINARY_API_SECRET"),
-		SendGridAPIKey:      GetStrEnvOrPanic("SENDGRID_API_KEY"),
-		SendGridFromEmail:   GetStrEnvOrPanic("SENDGRID_FROM_EMAIL"),
-		SendGridFromName:    GetStrEnvOrPanic("SENDGRID_FROM_NAME"),
+		CloudinaryCloudName: GetStrEnv("CLOUDINARY_CLOUD_NAME"),
+		CloudinaryAPIKey:    GetStrEnv("CLOUDINARY_API_KEY"),
+		CloudinaryAPISecret: GetStrEnv("CLOUDINARY_API_SECRET"),
+		S3AccessKey:         GetStrEnv("S3_ACCESS_KEY"),
+		S3SecretKey:         GetStrEnv("S3_SECRET_KEY"),
+		MediaSigningKey:     GetStrEnv("MEDIA_SIGNING_KEY"),
+		SendGridAPIKey:      GetStrEnv("SENDGRID_API_KEY"),
+		SendGridFromEmail:   GetStrEnv("SENDGRID_FROM_EMAIL"),
+		SendGridFromName:    GetStrEnv("SENDGRID_FROM_NAME"),
+		SMTPUsername:        GetStrEnv("SMTP_USERNAME"),
+		SMTPPassword:        GetStrEnv("SMTP_PASSWORD"),
+		FCMCredentialsFile:  GetStrEnv("FCM_CREDENTIALS_FILE"),
+		APNsAuthKeyFile:     GetStrEnv("APNS_AUTH_KEY_FILE"),
+		MetricsToken:        GetStrEnv("METRICS_TOKEN"),
 		AppFrontendURL:      GetStrEnvOrPanic("APP_FRONTEND_URL"),
 		AppBackendURL:       GetStrEnvOrPanic("APP_BACKEND_URL"),
 	}
 	return env
 }
 
+// GetStrEnv returns an optional env variable, empty when unset
+func GetStrEnv(env string) string {
+	return os.Getenv(env)
+}
+
 func GetStrEnvOrPanic(env string) string {
 	res := os.Getenv(env)
 	if len(res) == 0 {
@@ -84,6 +110,12 @@ func GetStrEnvOrPanic(env string) string {
 	return res
 }
 
+// GetIntEnv returns an optional env variable, zero when unset or not a number
+func GetIntEnv(env string) int {
+	i, _ := strconv.Atoi(os.Getenv(env))
+	return i
+}
+
 func GetIntEnvOrPanic(env string) int {
 	res := os.Getenv(env)
 	if len(res) == 0 {
@@ -0,0 +1,56 @@
+package coredto
+
+import (
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func NewCursorPagination() *CursorPagination {
+	return &CursorPagination{
+		Limit: 10,
+	}
+}
+
+// CursorPagination is the query of a cursor paginated list, Cursor is the nextCursor of the previous page
+type CursorPagination struct {
+	Cursor string `form:"cursor" query:"cursor" validate:"omitempty,max=1024"`
+	Limit  int    `form:"limit" query:"limit" validate:"min=1,max=100"`
+}
+
+func (d *CursorPagination) GetValue() *CursorPagination {
+	return d
+}
+
+func (d *CursorPagination) ValidateErrors(errs validator.ValidationErrors) ([]string, error) {
+	var msgs []string
+	for _, err := range errs {
+		switch err.Tag() {
+		case "min":
+			msgs = append(msgs, fmt.Sprintf("%s must be min %s", err.Field(), err.Param()))
+		case "max":
+			msgs = append(msgs, fmt.Sprintf("%s must be max %s", err.Field(), err.Param()))
+		default:
+			msgs = append(msgs, fmt.Sprintf("%s is invalid", err.Field()))
+		}
+	}
+	return msgs, nil
+}
+
+// CursorPage is the response envelope of a cursor paginated list
+type CursorPage[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+}
+
+func NewCursorPage[T any](items []T, nextCursor string, hasMore bool) *CursorPage[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return &CursorPage[T]{
+		Items:      items,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}
+}
@@ -0,0 +1,116 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sync-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusFailing  = "failing"
+	StatusDraining = "draining"
+
+	checkTimeout = 2 * time.Second
+)
+
+// Check reports whether a dependency can serve requests
+type Check func(ctx context.Context) error
+
+// Probe answers the liveness and readiness probes of the orchestrator. The process is live as long as
+// it answers, it is ready while it is not draining and every dependency check passes.
+type Probe struct {
+	logger   utils.AppLogger
+	draining atomic.Bool
+	mu       sync.RWMutex
+	checks   map[string]Check
+}
+
+type probeResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func NewProbe() *Probe {
+	return &Probe{
+		logger: utils.NewServiceLogger("Probe"),
+		checks: map[string]Check{},
+	}
+}
+
+func (p *Probe) AddCheck(name string, check Check) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checks[name] = check
+}
+
+// Drain makes the readiness probe fail so the load balancer stops sending traffic before the server stops
+func (p *Probe) Drain() {
+	p.draining.Store(true)
+}
+
+func (p *Probe) Draining() bool {
+	return p.draining.Load()
+}
+
+func (p *Probe) Livez(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, probeResponse{Status: StatusOK})
+}
+
+func (p *Probe) Readyz(ctx *gin.Context) {
+	if p.Draining() {
+		ctx.JSON(http.StatusServiceUnavailable, probeResponse{Status: StatusDraining})
+		return
+	}
+
+	results := p.runChecks(ctx.Request.Context())
+	status, code := StatusOK, http.StatusOK
+	for _, result := range results {
+		if result != StatusOK {
+			status, code = StatusFailing, http.StatusServiceUnavailable
+		}
+	}
+	ctx.JSON(code, probeResponse{Status: status, Checks: results})
+}
+
+// runChecks runs the checks concurrently, each bounded by checkTimeout. Errors are logged rather than
+// returned, the probe is public and they may name internal hosts.
+func (p *Probe) runChecks(ctx context.Context) map[string]string {
+	p.mu.RLock()
+	checks := make(map[string]Check, len(p.checks))
+	for name, check := range p.checks {
+		checks[name] = check
+	}
+	p.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]string, len(checks))
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := StatusOK
+			if err := check(ctx); err != nil {
+				p.logger.WithContext(ctx).Warn("Readiness check %s failed: %v", name, err)
+				status = StatusFailing
+			}
+			mu.Lock()
+			results[name] = status
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return results
+}
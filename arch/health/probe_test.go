@@ -0,0 +1,49 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveProbe(probe *Probe, path string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/livez", probe.Livez)
+	engine.GET("/readyz", probe.Readyz)
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+	return rr
+}
+
+func TestReadyzReportsChecks(t *testing.T) {
+	probe := NewProbe()
+	probe.AddCheck("mongo", func(context.Context) error { return nil })
+
+	rr := serveProbe(probe, "/readyz")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status":"ok","checks":{"mongo":"ok"}}`, rr.Body.String())
+
+	probe.AddCheck("redis", func(context.Context) error { return errors.New("dial tcp 10.0.0.5:6379: refused") })
+	rr = serveProbe(probe, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.JSONEq(t, `{"status":"failing","checks":{"mongo":"ok","redis":"failing"}}`, rr.Body.String())
+}
+
+func TestReadyzFailsWhileDraining(t *testing.T) {
+	probe := NewProbe()
+	probe.Drain()
+
+	rr := serveProbe(probe, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.JSONEq(t, `{"status":"draining"}`, rr.Body.String())
+
+	rr = serveProbe(probe, "/livez")
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
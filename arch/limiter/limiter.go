@@ -0,0 +1,143 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sync-backend/arch/redis"
+
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+type Algorithm string
+
+const (
+	// SlidingWindow keeps a log of the request times in the window, bursts are capped at the limit
+	SlidingWindow Algorithm = "sliding_window"
+	// TokenBucket refills the limit over the window, a full bucket allows a burst of limit requests
+	TokenBucket Algorithm = "token_bucket"
+)
+
+// Policy allows Limit requests per Window for every key it is applied to
+type Policy struct {
+	Name      string
+	Algorithm Algorithm
+	Limit     int
+	Window    time.Duration
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the full limit is available again
+	Reset time.Duration
+	// RetryAfter is the time until a rejected request would be allowed
+	RetryAfter time.Duration
+}
+
+type Limiter interface {
+	Allow(ctx context.Context, policy Policy, key string) (Result, error)
+}
+
+type redisLimiter struct {
+	client *goredis.Client
+}
+
+// NewRedisLimiter runs each check as one Lua script, so instances sharing the Redis share the limits and
+// concurrent requests can not both take the last slot. Time is read from the Redis server clock.
+func NewRedisLimiter(store redis.Store) Limiter {
+	return &redisLimiter{client: store.GetInstance().Client}
+}
+
+// slidingWindowScript drops the entries that left the window, then logs the request if there is room.
+// Returns allowed, remaining, reset ms and retry after ms.
+var slidingWindowScript = goredis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[3])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+
+local reset = 0
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+local retry = 0
+if allowed == 0 then
+	retry = reset
+end
+return {allowed, limit - count, reset, retry}
+`)
+
+// tokenBucketScript refills the bucket for the time since the last request, then takes a token if one
+// is left. Returns allowed, remaining, reset ms and retry after ms.
+var tokenBucketScript = goredis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local window = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local rate = capacity / window
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1]) or capacity
+local ts = tonumber(bucket[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], window)
+
+local retry = 0
+if allowed == 0 then
+	retry = math.ceil((1 - tokens) / rate)
+end
+return {allowed, math.floor(tokens), math.ceil((capacity - tokens) / rate), retry}
+`)
+
+func (l *redisLimiter) Allow(ctx context.Context, policy Policy, key string) (Result, error) {
+	redisKey := fmt.Sprintf("ratelimit:%s:%s", policy.Name, key)
+	window := policy.Window.Milliseconds()
+
+	var values []int64
+	var err error
+	switch policy.Algorithm {
+	case TokenBucket:
+		values, err = tokenBucketScript.Run(ctx, l.client, []string{redisKey}, window, policy.Limit).Int64Slice()
+	case SlidingWindow, "":
+		// Every logged request needs its own member, requests in the same millisecond would collapse
+		values, err = slidingWindowScript.Run(ctx, l.client, []string{redisKey}, window, policy.Limit, uuid.NewString()).Int64Slice()
+	default:
+		return Result{}, fmt.Errorf("unknown rate limit algorithm %q of policy %s", policy.Algorithm, policy.Name)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	if len(values) != 4 {
+		return Result{}, fmt.Errorf("unexpected rate limit script result %v", values)
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      policy.Limit,
+		Remaining:  int(max(values[1], 0)),
+		Reset:      time.Duration(values[2]) * time.Millisecond,
+		RetryAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
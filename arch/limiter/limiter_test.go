@@ -0,0 +1,117 @@
+package limiter
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"sync-backend/arch/redis"
+	"sync-backend/utils"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLimiter runs the scripts against miniredis, whose clock the tests move with SetTime
+func newTestLimiter(t *testing.T) (Limiter, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	port, err := strconv.Atoi(server.Port())
+	require.NoError(t, err)
+	store := redis.NewStore(context.Background(), utils.NewServiceLogger("LimiterTest"), &redis.Config{
+		Host: server.Host(),
+		Port: uint16(port),
+	})
+	t.Cleanup(store.Disconnect)
+	return NewRedisLimiter(store), server
+}
+
+func TestSlidingWindowRollsOver(t *testing.T) {
+	ctx := context.Background()
+	limiter, server := newTestLimiter(t)
+	policy := Policy{Name: "test", Algorithm: SlidingWindow, Limit: 3, Window: time.Minute}
+	start := time.Unix(1_700_000_000, 0)
+	server.SetTime(start)
+
+	for remaining := 2; remaining >= 0; remaining-- {
+		result, err := limiter.Allow(ctx, policy, "ip:10.0.0.7")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, remaining, result.Remaining)
+		assert.Equal(t, time.Minute, result.Reset)
+	}
+
+	result, err := limiter.Allow(ctx, policy, "ip:10.0.0.7")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, time.Minute, result.RetryAfter)
+
+	// Other keys have their own window
+	result, err = limiter.Allow(ctx, policy, "ip:10.0.0.8")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	server.SetTime(start.Add(30 * time.Second))
+	result, err = limiter.Allow(ctx, policy, "ip:10.0.0.7")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 30*time.Second, result.RetryAfter)
+
+	// Once the logged requests leave the window the full limit is back
+	server.SetTime(start.Add(time.Minute + time.Millisecond))
+	result, err = limiter.Allow(ctx, policy, "ip:10.0.0.7")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+}
+
+func TestTokenBucketRefills(t *testing.T) {
+	ctx := context.Background()
+	limiter, server := newTestLimiter(t)
+	policy := Policy{Name: "test", Algorithm: TokenBucket, Limit: 2, Window: time.Minute}
+	start := time.Unix(1_700_000_000, 0)
+	server.SetTime(start)
+
+	for remaining := 1; remaining >= 0; remaining-- {
+		result, err := limiter.Allow(ctx, policy, "user:u1")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, remaining, result.Remaining)
+	}
+
+	result, err := limiter.Allow(ctx, policy, "user:u1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Minute, result.Reset)
+	// One token comes back every window / limit
+	assert.Equal(t, 30*time.Second, result.RetryAfter)
+
+	server.SetTime(start.Add(30*time.Second + time.Millisecond))
+	result, err = limiter.Allow(ctx, policy, "user:u1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	result, err = limiter.Allow(ctx, policy, "user:u1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+
+	// The bucket never holds more than the limit, however long it was idle
+	server.SetTime(start.Add(time.Hour))
+	for i := 0; i < 2; i++ {
+		result, err = limiter.Allow(ctx, policy, "user:u1")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+	result, err = limiter.Allow(ctx, policy, "user:u1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+}
+
+func TestUnknownAlgorithmFails(t *testing.T) {
+	limiter, _ := newTestLimiter(t)
+	_, err := limiter.Allow(context.Background(), Policy{Name: "test", Algorithm: "leaky", Limit: 1, Window: time.Second}, "ip:10.0.0.7")
+	assert.Error(t, err)
+}
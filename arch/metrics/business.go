@@ -0,0 +1,66 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	signups = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "signups_total",
+		Help:      "Accounts created, by signup method.",
+	}, []string{"method"})
+
+	postsCreated = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "posts_created_total",
+		Help:      "Posts created.",
+	})
+
+	commentsCreated = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "comments_created_total",
+		Help:      "Comments created, replies are counted with kind reply.",
+	}, []string{"kind"})
+
+	reportsFiled = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reports_filed_total",
+		Help:      "Reports filed by users, by target type.",
+	}, []string{"target"})
+
+	bans = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bans_total",
+		Help:      "Bans issued, platform-wide by admins or within a community by its moderators.",
+	}, []string{"scope"})
+)
+
+const (
+	SignupPassword = "password"
+	SignupGoogle   = "google"
+
+	CommentKindComment = "comment"
+	CommentKindReply   = "reply"
+
+	BanScopePlatform  = "platform"
+	BanScopeCommunity = "community"
+)
+
+func SignupCompleted(method string) {
+	signups.WithLabelValues(method).Inc()
+}
+
+func PostCreated() {
+	postsCreated.Inc()
+}
+
+func CommentCreated(kind string) {
+	commentsCreated.WithLabelValues(kind).Inc()
+}
+
+func ReportFiled(target string) {
+	reportsFiled.WithLabelValues(target).Inc()
+}
+
+func BanIssued(scope string) {
+	bans.WithLabelValues(scope).Inc()
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuantile(t *testing.T) {
+	// 10 requests under 100ms, 10 between 100ms and 200ms
+	buckets := map[float64]uint64{0.1: 10, 0.2: 20, math.Inf(1): 20}
+
+	assert.Equal(t, 50*time.Millisecond, quantile(0.25, buckets, 20))
+	assert.Equal(t, 100*time.Millisecond, quantile(0.5, buckets, 20))
+	assert.Equal(t, 180*time.Millisecond, quantile(0.9, buckets, 20))
+	assert.Equal(t, time.Duration(0), quantile(0.5, map[float64]uint64{}, 0))
+}
+
+func TestSummarizeHTTP(t *testing.T) {
+	before := SummarizeHTTP()
+
+	RequestStarted()("GET", "/api/v1/post/:postId", http.StatusOK, 20*time.Millisecond)
+	RequestStarted()("GET", "", http.StatusInternalServerError, 20*time.Millisecond)
+	RateLimitRejected("global")
+
+	after := SummarizeHTTP()
+	assert.Equal(t, before.Requests+2, after.Requests)
+	assert.Equal(t, before.RateLimited+1, after.RateLimited)
+	assert.Greater(t, after.ErrorRate, 0.0)
+}
+
+func TestHandlerRequiresToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/metrics", Handler("secret"))
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rr = httptest.NewRecorder()
+	engine.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "sync_http_requests_in_flight")
+}
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	mongoCommandDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "mongo",
+		Name:      "command_duration_seconds",
+		Help:      "Mongo command latency by command name.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"command"})
+
+	mongoCommandErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mongo",
+		Name:      "command_errors_total",
+		Help:      "Mongo commands that failed, by command name.",
+	}, []string{"command"})
+
+	mongoPoolConnections = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "mongo",
+		Name:      "pool_connections",
+		Help:      "Mongo pool connections by state, open counts idle and in use ones.",
+	}, []string{"state"})
+
+	mongoPoolCheckoutFailures = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mongo",
+		Name:      "pool_checkout_failures_total",
+		Help:      "Connection checkouts from the Mongo pool that failed.",
+	})
+
+	redisCommandDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "redis",
+		Name:      "command_duration_seconds",
+		Help:      "Redis command latency by command name, pipelines are recorded as one command.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5},
+	}, []string{"command"})
+
+	redisCommandErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "redis",
+		Name:      "command_errors_total",
+		Help:      "Redis commands that failed, by command name. Missing keys are not errors.",
+	}, []string{"command"})
+
+	cacheLookups = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "lookups_total",
+		Help:      "Read-through cache lookups by cache name and result.",
+	}, []string{"cache", "result"})
+)
+
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error"
+)
+
+func ObserveMongoCommand(command string, duration time.Duration, failed bool) {
+	mongoCommandDuration.WithLabelValues(command).Observe(duration.Seconds())
+	if failed {
+		mongoCommandErrors.WithLabelValues(command).Inc()
+	}
+}
+
+func SetMongoPoolConnections(open int64, inUse int64) {
+	mongoPoolConnections.WithLabelValues("open").Set(float64(open))
+	mongoPoolConnections.WithLabelValues("in_use").Set(float64(inUse))
+}
+
+func MongoPoolCheckoutFailed() {
+	mongoPoolCheckoutFailures.Inc()
+}
+
+func ObserveRedisCommand(command string, duration time.Duration, failed bool) {
+	redisCommandDuration.WithLabelValues(command).Observe(duration.Seconds())
+	if failed {
+		redisCommandErrors.WithLabelValues(command).Inc()
+	}
+}
+
+// CacheLookup counts a lookup of the named cache, result is CacheHit, CacheMiss or CacheError
+func CacheLookup(cache string, result string) {
+	cacheLookups.WithLabelValues(cache, result).Inc()
+}
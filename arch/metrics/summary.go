@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// HTTPSummary condenses the HTTP metrics of all routes since the process started
+type HTTPSummary struct {
+	P50       time.Duration
+	P90       time.Duration
+	P99       time.Duration
+	Requests  uint64
+	ErrorRate float64
+	// RateLimited counts the requests rejected by any rate limiter
+	RateLimited uint64
+}
+
+func SummarizeHTTP() HTTPSummary {
+	var summary HTTPSummary
+	families, err := Registry.Gather()
+	if err != nil {
+		return summary
+	}
+
+	var errors uint64
+	buckets := map[float64]uint64{}
+	var observed uint64
+	for _, family := range families {
+		switch family.GetName() {
+		case namespace + "_http_requests_total":
+			for _, metric := range family.GetMetric() {
+				count := uint64(metric.GetCounter().GetValue())
+				summary.Requests += count
+				if strings.HasPrefix(labelValue(metric, "status"), "5") {
+					errors += count
+				}
+			}
+		case namespace + "_http_request_duration_seconds":
+			for _, metric := range family.GetMetric() {
+				histogram := metric.GetHistogram()
+				observed += histogram.GetSampleCount()
+				for _, bucket := range histogram.GetBucket() {
+					buckets[bucket.GetUpperBound()] += bucket.GetCumulativeCount()
+				}
+			}
+		case namespace + "_rate_limit_rejections_total":
+			for _, metric := range family.GetMetric() {
+				summary.RateLimited += uint64(metric.GetCounter().GetValue())
+			}
+		}
+	}
+
+	if summary.Requests > 0 {
+		summary.ErrorRate = float64(errors) / float64(summary.Requests)
+	}
+	summary.P50 = quantile(0.5, buckets, observed)
+	summary.P90 = quantile(0.9, buckets, observed)
+	summary.P99 = quantile(0.99, buckets, observed)
+	return summary
+}
+
+// quantile estimates like PromQL histogram_quantile, interpolating linearly inside the bucket
+func quantile(q float64, buckets map[float64]uint64, observed uint64) time.Duration {
+	if observed == 0 {
+		return 0
+	}
+	bounds := make([]float64, 0, len(buckets))
+	for bound := range buckets {
+		bounds = append(bounds, bound)
+	}
+	sort.Float64s(bounds)
+
+	rank := q * float64(observed)
+	lowerBound, lowerCount := 0.0, uint64(0)
+	for _, bound := range bounds {
+		count := buckets[bound]
+		if float64(count) >= rank {
+			if math.IsInf(bound, 1) || count == lowerCount {
+				return seconds(lowerBound)
+			}
+			return seconds(lowerBound + (bound-lowerBound)*(rank-float64(lowerCount))/float64(count-lowerCount))
+		}
+		lowerBound, lowerCount = bound, count
+	}
+	// slower than the largest bucket, which is as precise as the histogram gets
+	return seconds(lowerBound)
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
+
+func labelValue(metric *dto.Metric, name string) string {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
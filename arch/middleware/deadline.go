@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"sync-backend/arch/network"
+
+	"github.com/gin-gonic/gin"
+)
+
+type requestDeadline struct {
+	network.BaseMiddleware
+	timeout time.Duration
+}
+
+// NewRequestDeadline bounds every request by timeout. Services receive the request context, so Mongo,
+// Redis and outbound calls are cancelled once the deadline passes or the client goes away.
+func NewRequestDeadline(timeout time.Duration) network.RootMiddleware {
+	return &requestDeadline{
+		BaseMiddleware: network.NewBaseMiddleware(),
+		timeout:        timeout,
+	}
+}
+
+func (m *requestDeadline) Attach(engine *gin.Engine) {
+	engine.Use(m.Handler)
+}
+
+func (m *requestDeadline) Handler(ctx *gin.Context) {
+	if m.timeout <= 0 {
+		ctx.Next()
+		return
+	}
+
+	deadline, cancel := context.WithTimeout(ctx.Request.Context(), m.timeout)
+	defer cancel()
+	ctx.Request = ctx.Request.WithContext(deadline)
+
+	ctx.Next()
+
+	if !ctx.Writer.Written() && errors.Is(deadline.Err(), context.DeadlineExceeded) {
+		m.Send(ctx).MixedError(network.NewRequestTimeoutError(
+			"request timed out",
+			"The request did not complete within the allowed time",
+			deadline.Err(),
+		))
+		ctx.Abort()
+	}
+}
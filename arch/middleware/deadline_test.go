@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"sync-backend/arch/network"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestDeadlineRespondsWithTimeout(t *testing.T) {
+	mockHandler := func(ctx *gin.Context) {
+		<-ctx.Request.Context().Done()
+	}
+
+	rr := network.MockTestRootMiddleware(t, NewRequestDeadline(10*time.Millisecond), mockHandler)
+
+	assert.Equal(t, http.StatusRequestTimeout, rr.Code)
+	assert.Contains(t, rr.Body.String(), network.RequestTimeoutErrorCode)
+}
+
+func TestRequestDeadlineMapsServerErrorToTimeout(t *testing.T) {
+	mockHandler := func(ctx *gin.Context) {
+		err := ctx.Request.Context().Err()
+		for err == nil {
+			time.Sleep(time.Millisecond)
+			err = ctx.Request.Context().Err()
+		}
+		network.NewResponseSender().Send(ctx).InternalServerError("query failed", "", network.UnknownErrorCode, err)
+	}
+
+	rr := network.MockTestRootMiddleware(t, NewRequestDeadline(10*time.Millisecond), mockHandler)
+
+	assert.Equal(t, http.StatusRequestTimeout, rr.Code)
+}
@@ -38,7 +38,7 @@ func (m *errorCatcher) Handler(ctx *gin.Context) {
 				r,
 				string(stackTrace))
 
-			m.logger.Error("%s", errorMsg)
+			m.logger.WithContext(ctx).Error("%s", errorMsg)
 
 			// Return appropriate response to client
 			if err, ok := r.(error); ok {
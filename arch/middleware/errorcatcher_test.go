@@ -30,5 +30,5 @@ func TestErrorCatcherMiddleware_NonError(t *testing.T) {
 	rr := network.MockTestRootMiddleware(t, NewErrorCatcher(), mockHandler)
 
 	assert.Equal(t, http.StatusInternalServerError, rr.Code)
-	assert.Contains(t, rr.Body.String(), `"message":"something went wrong"`)
+	assert.Contains(t, rr.Body.String(), `"message":"Something went wrong"`)
 }
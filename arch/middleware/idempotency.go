@@ -0,0 +1,362 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"sync-backend/arch/common"
+	"sync-backend/arch/config"
+	"sync-backend/arch/network"
+	"sync-backend/arch/redis"
+	"sync-backend/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	defaultIdempotencyTTL     = 24 * time.Hour
+	defaultIdempotencyTimeout = 2 * time.Minute
+	// Request bodies up to this size are kept in memory for the handler, larger ones go to a temp file
+	idempotencySpoolMemory = 1 << 20
+)
+
+type idempotencyState string
+
+const (
+	idempotencyInFlight  idempotencyState = "in_flight"
+	idempotencyCompleted idempotencyState = "completed"
+)
+
+type idempotencyRecord struct {
+	State       idempotencyState `json:"state"`
+	Fingerprint string           `json:"fingerprint"`
+	Status      int              `json:"status,omitempty"`
+	ContentType string           `json:"contentType,omitempty"`
+	Body        []byte           `json:"body,omitempty"`
+}
+
+type idempotencyStore interface {
+	// Reserve stores record unless the key is taken, in which case the existing record is returned
+	Reserve(ctx context.Context, key string, record idempotencyRecord, ttl time.Duration) (*idempotencyRecord, error)
+	Save(ctx context.Context, key string, record idempotencyRecord, ttl time.Duration) error
+	Release(ctx context.Context, key string) error
+}
+
+type redisIdempotencyStore struct {
+	client *goredis.Client
+}
+
+func (s *redisIdempotencyStore) Reserve(ctx context.Context, key string, record idempotencyRecord, ttl time.Duration) (*idempotencyRecord, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	// The existing record may expire between the two calls, then the key can be taken on the next try
+	for attempt := 0; attempt < 2; attempt++ {
+		reserved, err := s.client.SetNX(ctx, key, data, ttl).Result()
+		if err != nil || reserved {
+			return nil, err
+		}
+		existing, err := s.client.Get(ctx, key).Bytes()
+		if errors.Is(err, goredis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var existingRecord idempotencyRecord
+		if err := json.Unmarshal(existing, &existingRecord); err != nil {
+			return nil, err
+		}
+		return &existingRecord, nil
+	}
+	return nil, fmt.Errorf("idempotency key %s could not be reserved", key)
+}
+
+func (s *redisIdempotencyStore) Save(ctx context.Context, key string, record idempotencyRecord, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, key, data, ttl).Err()
+}
+
+func (s *redisIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key).Err()
+}
+
+type idempotencyProvider struct {
+	network.BaseMiddleware
+	common.ContextPayload
+	logger utils.AppLogger
+	store  idempotencyStore
+	config config.IdempotencyConfig
+}
+
+// NewIdempotencyProvider replays the response of a request to its repeats with the same Idempotency-Key,
+// so that clients can retry creates safely. Routes opt in, it must run after authentication.
+func NewIdempotencyProvider(store redis.Store, config config.IdempotencyConfig) network.IdempotencyProvider {
+	return newIdempotencyProvider(&redisIdempotencyStore{client: store.GetInstance().Client}, config)
+}
+
+func newIdempotencyProvider(store idempotencyStore, config config.IdempotencyConfig) *idempotencyProvider {
+	if config.TTL <= 0 {
+		config.TTL = defaultIdempotencyTTL
+	}
+	if config.LockTimeout <= 0 {
+		config.LockTimeout = defaultIdempotencyTimeout
+	}
+	return &idempotencyProvider{
+		BaseMiddleware: network.NewBaseMiddleware(),
+		ContextPayload: common.NewContextPayload(),
+		logger:         utils.NewServiceLogger("IdempotencyProvider"),
+		store:          store,
+		config:         config,
+	}
+}
+
+// recordingWriter keeps a copy of the response body so that it can be replayed
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+func (p *idempotencyProvider) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		idempotencyKey := ctx.GetHeader(IdempotencyKeyHeader)
+		if idempotencyKey == "" {
+			ctx.Next()
+			return
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			p.Send(ctx).BadRequestError(
+				"Invalid Idempotency-Key",
+				fmt.Sprintf("The Idempotency-Key header must be at most %d characters", maxIdempotencyKeyLength),
+				nil,
+			)
+			ctx.Abort()
+			return
+		}
+
+		fingerprint, body, err := p.fingerprint(ctx)
+		if err != nil {
+			p.Send(ctx).BadRequestError("Unreadable request body", "The request body could not be read", err)
+			ctx.Abort()
+			return
+		}
+		defer body.remove()
+
+		requestCtx := ctx.Request.Context()
+		key := p.key(ctx, idempotencyKey)
+		existing, err := p.store.Reserve(requestCtx, key, idempotencyRecord{State: idempotencyInFlight, Fingerprint: fingerprint}, p.config.LockTimeout)
+		if err != nil {
+			// Without the store the request runs unprotected rather than not at all
+			p.logger.WithContext(ctx).Error("Failed to reserve idempotency key %s: %v", key, err)
+			ctx.Next()
+			return
+		}
+		if existing != nil {
+			p.answerRepeat(ctx, existing, fingerprint)
+			return
+		}
+
+		// A panic or a response that is not replayed frees the key, the retry of a request that failed must
+		// run again
+		completed := false
+		defer func() {
+			if !completed {
+				if err := p.store.Release(context.WithoutCancel(requestCtx), key); err != nil {
+					p.logger.WithContext(ctx).Error("Failed to release idempotency key %s: %v", key, err)
+				}
+			}
+		}()
+
+		writer := &recordingWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+		ctx.Next()
+		ctx.Writer = writer.ResponseWriter
+
+		status := writer.Status()
+		if !replayable(status) {
+			return
+		}
+		record := idempotencyRecord{
+			State:       idempotencyCompleted,
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		}
+		if err := p.store.Save(context.WithoutCancel(requestCtx), key, record, p.config.TTL); err != nil {
+			p.logger.WithContext(ctx).Error("Failed to save idempotent response %s: %v", key, err)
+			return
+		}
+		completed = true
+	}
+}
+
+// replayable reports whether a response is stored for the repeats of its key. Besides successes only the
+// rejections of the body itself are, a repeat carries the same body. Rejections a retry may get past,
+// such as expired credentials, a rate limit or a server error, must run the handler again.
+func replayable(status int) bool {
+	if status >= http.StatusOK && status < http.StatusMultipleChoices {
+		return true
+	}
+	return status == http.StatusBadRequest || status == http.StatusUnprocessableEntity
+}
+
+func (p *idempotencyProvider) answerRepeat(ctx *gin.Context, existing *idempotencyRecord, fingerprint string) {
+	defer ctx.Abort()
+	if existing.Fingerprint != fingerprint {
+		p.Send(ctx).UnprocessableEntityError(
+			"Idempotency-Key reused",
+			"The Idempotency-Key was already used with a different request body, use a new key for a new request",
+			nil,
+		)
+		return
+	}
+	if existing.State != idempotencyCompleted {
+		p.Send(ctx).ConflictError(
+			"Request in progress",
+			"A request with this Idempotency-Key is still being processed, retry once it completed",
+			nil,
+		)
+		return
+	}
+	ctx.Header(IdempotentReplayedHeader, "true")
+	ctx.Data(existing.Status, existing.ContentType, existing.Body)
+}
+
+// key scopes the client key to the user and route, two users or routes never share a response
+func (p *idempotencyProvider) key(ctx *gin.Context, idempotencyKey string) string {
+	owner := "ip:" + ctx.ClientIP()
+	if userId := p.GetUserId(ctx); userId != nil {
+		owner = "user:" + *userId
+	}
+	sum := sha256.Sum256([]byte(idempotencyKey))
+	return fmt.Sprintf("idempotency:%s:%s %s:%s", owner, ctx.Request.Method, ctx.FullPath(), hex.EncodeToString(sum[:]))
+}
+
+// fingerprint hashes the request body while spooling it for the handlers, so that large uploads are never
+// held in memory whole. The multipart boundary is left out, clients pick a new one when they rebuild the
+// same form for a retry.
+func (p *idempotencyProvider) fingerprint(ctx *gin.Context) (string, *spooledBody, error) {
+	hash := sha256.New()
+	var hashed io.Writer = hash
+	var stripper *boundaryStripper
+	if _, params, err := mime.ParseMediaType(ctx.GetHeader("Content-Type")); err == nil && params["boundary"] != "" {
+		stripper = &boundaryStripper{out: hash, boundary: []byte(params["boundary"])}
+		hashed = stripper
+	}
+
+	body, err := spoolBody(io.TeeReader(ctx.Request.Body, hashed))
+	if err != nil {
+		return "", nil, err
+	}
+	if stripper != nil {
+		stripper.flush()
+	}
+	ctx.Request.Body = body
+	return hex.EncodeToString(hash.Sum(nil)), body, nil
+}
+
+// spooledBody replays a request body that was read for its fingerprint
+type spooledBody struct {
+	io.Reader
+	file *os.File
+}
+
+// Close leaves the spool in place, the middleware removes it once the handlers are done
+func (b *spooledBody) Close() error {
+	return nil
+}
+
+func (b *spooledBody) remove() {
+	if b.file != nil {
+		b.file.Close()
+		os.Remove(b.file.Name())
+	}
+}
+
+func spoolBody(body io.Reader) (*spooledBody, error) {
+	var buffer bytes.Buffer
+	_, err := io.CopyN(&buffer, body, idempotencySpoolMemory+1)
+	if errors.Is(err, io.EOF) {
+		return &spooledBody{Reader: bytes.NewReader(buffer.Bytes())}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.CreateTemp("", "sync-idempotency-*")
+	if err != nil {
+		return nil, err
+	}
+	spool := &spooledBody{Reader: file, file: file}
+	if _, err := buffer.WriteTo(file); err != nil {
+		spool.remove()
+		return nil, err
+	}
+	if _, err := io.Copy(file, body); err != nil {
+		spool.remove()
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		spool.remove()
+		return nil, err
+	}
+	return spool, nil
+}
+
+// boundaryStripper drops every occurrence of boundary from what it passes on. The tail that could be
+// the start of a boundary split across writes is held back until the next write or flush.
+type boundaryStripper struct {
+	out      io.Writer
+	boundary []byte
+	pending  []byte
+}
+
+func (w *boundaryStripper) Write(data []byte) (int, error) {
+	w.pending = append(w.pending, data...)
+	for {
+		i := bytes.Index(w.pending, w.boundary)
+		if i < 0 {
+			break
+		}
+		w.out.Write(w.pending[:i])
+		w.pending = w.pending[i+len(w.boundary):]
+	}
+	if keep := len(w.boundary) - 1; len(w.pending) > keep {
+		w.out.Write(w.pending[:len(w.pending)-keep])
+		w.pending = append([]byte(nil), w.pending[len(w.pending)-keep:]...)
+	}
+	return len(data), nil
+}
+
+func (w *boundaryStripper) flush() {
+	w.out.Write(w.pending)
+	w.pending = nil
+}
@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sync-backend/arch/config"
+	"sync-backend/arch/network"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]idempotencyRecord
+}
+
+func (s *memoryIdempotencyStore) Reserve(_ context.Context, key string, record idempotencyRecord, _ time.Duration) (*idempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[key]; ok {
+		return &existing, nil
+	}
+	s.records[key] = record
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Save(_ context.Context, key string, record idempotencyRecord, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = record
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+func serveIdempotent(engine *gin.Engine, key string, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/create", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, key)
+	engine.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	provider := newIdempotencyProvider(&memoryIdempotencyStore{records: map[string]idempotencyRecord{}}, config.IdempotencyConfig{})
+
+	created := 0
+	engine := gin.New()
+	engine.POST("/create", func(ctx *gin.Context) {
+		ctx.Set(network.UserPayload, "user-1")
+	}, provider.Middleware(), func(ctx *gin.Context) {
+		created++
+		ctx.JSON(http.StatusCreated, gin.H{"id": created})
+	})
+
+	first := serveIdempotent(engine, "key-1", `{"title":"hello"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+
+	repeat := serveIdempotent(engine, "key-1", `{"title":"hello"}`)
+	assert.Equal(t, http.StatusCreated, repeat.Code)
+	assert.Equal(t, first.Body.String(), repeat.Body.String())
+	assert.Equal(t, "true", repeat.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 1, created)
+
+	reused := serveIdempotent(engine, "key-1", `{"title":"other"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+
+	serveIdempotent(engine, "key-2", `{"title":"hello"}`)
+	assert.Equal(t, 2, created)
+}
+
+func TestIdempotencyRejectsRequestInFlight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	provider := newIdempotencyProvider(&memoryIdempotencyStore{records: map[string]idempotencyRecord{}}, config.IdempotencyConfig{})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	engine := gin.New()
+	engine.POST("/create", provider.Middleware(), func(ctx *gin.Context) {
+		close(started)
+		<-release
+		ctx.Status(http.StatusCreated)
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- serveIdempotent(engine, "key-1", `{}`)
+	}()
+	<-started
+
+	assert.Equal(t, http.StatusConflict, serveIdempotent(engine, "key-1", `{}`).Code)
+	close(release)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+}
+
+func TestIdempotencyReleasesKeyOnServerError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	provider := newIdempotencyProvider(&memoryIdempotencyStore{records: map[string]idempotencyRecord{}}, config.IdempotencyConfig{})
+
+	attempts := 0
+	engine := gin.New()
+	engine.POST("/create", provider.Middleware(), func(ctx *gin.Context) {
+		attempts++
+		if attempts == 1 {
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		ctx.Status(http.StatusCreated)
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, serveIdempotent(engine, "key-1", `{}`).Code)
+	assert.Equal(t, http.StatusCreated, serveIdempotent(engine, "key-1", `{}`).Code)
+}
+
+func TestIdempotencyRunsRetriesOfTransientRejections(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusUnauthorized, http.StatusForbidden} {
+		provider := newIdempotencyProvider(&memoryIdempotencyStore{records: map[string]idempotencyRecord{}}, config.IdempotencyConfig{})
+		attempts := 0
+		engine := gin.New()
+		engine.POST("/create", provider.Middleware(), func(ctx *gin.Context) {
+			attempts++
+			if attempts == 1 {
+				ctx.Status(status)
+				return
+			}
+			ctx.Status(http.StatusCreated)
+		})
+
+		assert.Equal(t, status, serveIdempotent(engine, "key-1", `{}`).Code)
+		retry := serveIdempotent(engine, "key-1", `{}`)
+		assert.Equal(t, http.StatusCreated, retry.Code, "retry after %d", status)
+		assert.Empty(t, retry.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, 2, attempts)
+	}
+}
+
+func TestIdempotencyReplaysBodyRejections(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	provider := newIdempotencyProvider(&memoryIdempotencyStore{records: map[string]idempotencyRecord{}}, config.IdempotencyConfig{})
+
+	attempts := 0
+	engine := gin.New()
+	engine.POST("/create", provider.Middleware(), func(ctx *gin.Context) {
+		attempts++
+		ctx.Status(http.StatusBadRequest)
+	})
+
+	serveIdempotent(engine, "key-1", `{"title":""}`)
+	repeat := serveIdempotent(engine, "key-1", `{"title":""}`)
+	assert.Equal(t, http.StatusBadRequest, repeat.Code)
+	assert.Equal(t, "true", repeat.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 1, attempts)
+}
+
+func TestIdempotencySpoolsLargeBodies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	provider := newIdempotencyProvider(&memoryIdempotencyStore{records: map[string]idempotencyRecord{}}, config.IdempotencyConfig{})
+
+	body := bytes.Repeat([]byte("0123456789"), idempotencySpoolMemory/5)
+	var received []byte
+	engine := gin.New()
+	engine.POST("/create", provider.Middleware(), func(ctx *gin.Context) {
+		var err error
+		received, err = io.ReadAll(ctx.Request.Body)
+		require.NoError(t, err)
+		ctx.Status(http.StatusCreated)
+	})
+
+	assert.Equal(t, http.StatusCreated, serveIdempotent(engine, "key-1", string(body)).Code)
+	assert.Equal(t, body, received)
+	assert.Equal(t, "true", serveIdempotent(engine, "key-1", string(body)).Header().Get(IdempotentReplayedHeader))
+}
+
+func TestBoundaryStripperMatchesWholeBodyReplace(t *testing.T) {
+	boundary := []byte("----boundary42")
+	body := bytes.Repeat([]byte("--"+string(boundary)+"\r\nContent-Disposition: form-data; name=\"title\"\r\n\r\nhello-\r\n"), 50)
+	want := sha256.Sum256(bytes.ReplaceAll(body, boundary, nil))
+
+	// Every write size splits boundaries differently
+	for _, size := range []int{1, 3, len(boundary) - 1, len(boundary), 64, len(body)} {
+		hash := sha256.New()
+		stripper := &boundaryStripper{out: hash, boundary: boundary}
+		for i := 0; i < len(body); i += size {
+			_, err := stripper.Write(body[i:min(i+size, len(body))])
+			require.NoError(t, err)
+		}
+		stripper.flush()
+		assert.Equal(t, hex.EncodeToString(want[:]), hex.EncodeToString(hash.Sum(nil)), "writes of %d bytes", size)
+	}
+}
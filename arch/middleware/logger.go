@@ -1,44 +1,66 @@
 package middleware
 
 import (
-	"fmt"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync-backend/arch/config"
 	"sync-backend/arch/network"
 	"sync-backend/utils"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	RequestIdHeader = "X-Request-ID"
+
+	maxRequestIdLength   = 128
+	defaultSlowThreshold = time.Second
 )
 
 type logger struct {
 	network.BaseMiddleware
-	appLogger     *utils.AppLogger
-	environment   string
-	skipPaths     map[string]bool
-	defaultFields map[string]interface{}
+	appLogger      utils.AppLogger
+	config         config.LogConfig
+	level          utils.LogLevel
+	slowThreshold  time.Duration
+	includeHeaders []string
+	excludeHeaders map[string]bool
+	sanitize       map[string]bool
 }
 
-func NewLogger(appLogger *utils.AppLogger, environment string) network.RootMiddleware {
-	return &logger{
-		BaseMiddleware: network.NewBaseMiddleware(),
-		appLogger:      appLogger,
-		environment:    environment,
-		skipPaths:      make(map[string]bool),
-		defaultFields: map[string]interface{}{
-			"service": "sync-backend",
-		},
+// NewLogger assigns every request an id, echoed in the X-Request-ID response header, and logs it once it completes
+func NewLogger(logConfig config.LogConfig) network.RootMiddleware {
+	slowThreshold, err := time.ParseDuration(logConfig.Performance.SlowThreshold)
+	if err != nil || slowThreshold <= 0 {
+		slowThreshold = defaultSlowThreshold
 	}
-}
 
-func (m *logger) WithDefaultField(key string, value interface{}) *logger {
-	m.defaultFields[key] = value
-	return m
-}
+	excludeHeaders := make(map[string]bool, len(logConfig.HTTP.Headers.Exclude))
+	for _, header := range logConfig.HTTP.Headers.Exclude {
+		excludeHeaders[http.CanonicalHeaderKey(header)] = true
+	}
+	sanitize := make(map[string]bool, len(logConfig.Sanitize.Fields))
+	for _, field := range logConfig.Sanitize.Fields {
+		sanitize[strings.ToLower(field)] = true
+	}
 
-func (m *logger) WithSkipPaths(paths []string) *logger {
-	for _, path := range paths {
-		m.skipPaths[path] = true
+	return &logger{
+		BaseMiddleware: network.NewBaseMiddleware(),
+		appLogger:      utils.NewServiceLogger("HTTP"),
+		config:         logConfig,
+		level:          utils.ParseLogLevel(logConfig.HTTP.Level),
+		slowThreshold:  slowThreshold,
+		includeHeaders: logConfig.HTTP.Headers.Include,
+		excludeHeaders: excludeHeaders,
+		sanitize:       sanitize,
 	}
-	return m
 }
 
 func (m *logger) Attach(engine *gin.Engine) {
@@ -46,72 +68,147 @@ func (m *logger) Attach(engine *gin.Engine) {
 }
 
 func (m *logger) Handler(ctx *gin.Context) {
-	// Skip if path should be ignored
-	path := ctx.Request.URL.Path
-	if _, skip := m.skipPaths[path]; skip {
-		ctx.Next()
-		return
+	requestId := ctx.GetHeader(RequestIdHeader)
+	if !isValidRequestId(requestId) {
+		requestId = uuid.NewString()
 	}
+	ctx.Request = ctx.Request.WithContext(utils.WithRequestId(ctx.Request.Context(), requestId))
+	ctx.Header(RequestIdHeader, requestId)
 
-	// Record start time
-	startTime := time.Now()
-
-	// Format the incoming request log with exact spacing from example
-	method := fmt.Sprintf("%-7s", ctx.Request.Method)
-	clientIP := ctx.ClientIP()
-	userAgent := ctx.Request.UserAgent()
-
-	// Format request log matching the exact spacing in the example
-	requestLog := fmt.Sprintf("-> [%s]         |  %-40s  |  %-15s  |  %s",
-		method,
-		path,
-		clientIP,
-		userAgent,
-	)
-
-	m.appLogger.Info("%s", requestLog)
+	var body []byte
+	if m.config.HTTP.Enabled && m.config.HTTP.BodyLimit > 0 && ctx.ContentType() == gin.MIMEJSON && ctx.Request.Body != nil {
+		body = m.peekBody(ctx.Request)
+	}
 
-	// Process request
+	startTime := time.Now()
 	ctx.Next()
-
-	// Calculate duration
 	duration := time.Since(startTime)
-	durationMs := duration.Milliseconds()
-	size := ctx.Writer.Size()
+
 	status := ctx.Writer.Status()
+	slow := duration > m.slowThreshold
 
-	// Format response log matching the exact spacing in the example
-	responseLog := fmt.Sprintf("<- [%s]  [%d]  |  %-40s  |  %-15s  |  %-12dbytes    %dms",
-		method,
-		status,
-		path,
-		clientIP,
-		size,
-		durationMs,
-	)
-
-	// Log response based on status code
+	var level utils.LogLevel
 	switch {
 	case status >= 500:
-		m.appLogger.Error("%s", responseLog)
-	case status >= 400:
-		m.appLogger.Warn("%s", responseLog)
-	case status >= 300:
-		m.appLogger.Info("%s", responseLog)
-	case status >= 200:
-		m.appLogger.Success("%s", responseLog)
+		level = utils.ErrorLevel
+	case slow || status >= 400:
+		level = utils.WarnLevel
 	default:
-		m.appLogger.Info("%s", responseLog)
+		if !m.config.HTTP.Enabled || !m.sampled() {
+			return
+		}
+		level = m.level
 	}
 
-	// Add additional logging for errors if present
+	route := ctx.FullPath()
+	if route == "" {
+		route = ctx.Request.URL.Path
+	}
+	attrs := []slog.Attr{
+		slog.String("method", ctx.Request.Method),
+		slog.String("path", ctx.Request.URL.Path),
+		slog.String("route", route),
+		slog.Int("status", status),
+		slog.Int64("durationMs", duration.Milliseconds()),
+		slog.Int("bytes", ctx.Writer.Size()),
+		slog.String("clientIp", ctx.ClientIP()),
+	}
+	if slow {
+		attrs = append(attrs, slog.Bool("slow", true))
+	}
+	if m.config.HTTP.Enabled {
+		attrs = append(attrs, m.headerAttrs(ctx.Request.Header))
+		if len(body) > 0 {
+			attrs = append(attrs, slog.String("body", m.sanitizeBody(body)))
+		}
+	}
 	if len(ctx.Errors) > 0 {
-		errorLog := fmt.Sprintf("!! [%s]  [%d]  |  %-40s  |  Errors: %s",
-			method,
-			status,
-			path,
-			ctx.Errors.String(),
-		)
-		m.appLogger.Error("%s", errorLog)
+		attrs = append(attrs, slog.String("errors", ctx.Errors.String()))
+	}
+
+	m.appLogger.WithContext(ctx.Request.Context()).LogAttrs(level, "request completed", attrs...)
+}
+
+func (m *logger) sampled() bool {
+	rate := m.config.Performance.SamplingRate
+	return rate <= 0 || rate >= 1 || rand.Float64() < rate
+}
+
+// peekBody reads up to the body limit and puts it back in front of the unread rest
+func (m *logger) peekBody(request *http.Request) []byte {
+	body, err := io.ReadAll(io.LimitReader(request.Body, int64(m.config.HTTP.BodyLimit)))
+	if err != nil {
+		return nil
+	}
+	request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), request.Body), request.Body}
+	return body
+}
+
+func (m *logger) headerAttrs(header http.Header) slog.Attr {
+	var attrs []any
+	add := func(name string) {
+		name = http.CanonicalHeaderKey(name)
+		if m.excludeHeaders[name] {
+			return
+		}
+		if value := header.Get(name); value != "" {
+			attrs = append(attrs, slog.String(name, value))
+		}
+	}
+	if len(m.includeHeaders) > 0 {
+		for _, name := range m.includeHeaders {
+			add(name)
+		}
+	} else {
+		for name := range header {
+			add(name)
+		}
+	}
+	return slog.Group("headers", attrs...)
+}
+
+// sanitizeBody redacts sanitized fields at any depth, bodies cut at the limit are not valid JSON and are left out
+func (m *logger) sanitizeBody(body []byte) string {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return "[TRUNCATED]"
+	}
+	sanitized, err := json.Marshal(m.redact(value))
+	if err != nil {
+		return ""
+	}
+	return string(sanitized)
+}
+
+func (m *logger) redact(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if m.sanitize[strings.ToLower(key)] {
+				v[key] = utils.RedactedValue
+			} else {
+				v[key] = m.redact(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = m.redact(item)
+		}
+	}
+	return value
+}
+
+func isValidRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > maxRequestIdLength {
+		return false
+	}
+	for _, r := range requestId {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' || r == ':') {
+			return false
+		}
 	}
+	return true
 }
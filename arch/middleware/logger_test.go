@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"sync-backend/arch/config"
+	"sync-backend/arch/network"
+	"sync-backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestLoggerRequestId(t *testing.T) {
+	var requestId string
+	mockHandler := func(ctx *gin.Context) {
+		requestId = utils.RequestId(ctx.Request.Context())
+		ctx.Status(http.StatusOK)
+	}
+
+	rr := network.MockTestRootMiddleware(t, NewLogger(config.LogConfig{}), mockHandler,
+		primitive.E{Key: RequestIdHeader, Value: "client-request-1"})
+	assert.Equal(t, "client-request-1", requestId)
+	assert.Equal(t, "client-request-1", rr.Header().Get(RequestIdHeader))
+
+	rr = network.MockTestRootMiddleware(t, NewLogger(config.LogConfig{}), mockHandler,
+		primitive.E{Key: RequestIdHeader, Value: "bad id\n"})
+	assert.NotEqual(t, "bad id\n", requestId)
+	assert.Len(t, requestId, 36)
+	assert.Equal(t, requestId, rr.Header().Get(RequestIdHeader))
+}
+
+func TestLoggerSanitizeBody(t *testing.T) {
+	m := NewLogger(config.LogConfig{Sanitize: config.SanitizeConfig{Fields: []string{"password", "token"}}}).(*logger)
+
+	body := m.sanitizeBody([]byte(`{"email":"a@b.c","password":"hunter2","devices":[{"Token":"abc"}]}`))
+	assert.JSONEq(t, `{"email":"a@b.c","password":"[REDACTED]","devices":[{"Token":"[REDACTED]"}]}`, body)
+	assert.Equal(t, "[TRUNCATED]", m.sanitizeBody([]byte(`{"password":"hun`)))
+}
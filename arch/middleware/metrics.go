@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"sync-backend/arch/metrics"
+	"sync-backend/arch/network"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type httpMetrics struct {
+	network.BaseMiddleware
+}
+
+// NewMetrics records the count and latency of requests labeled by route template, never by raw path
+func NewMetrics() network.RootMiddleware {
+	return &httpMetrics{
+		BaseMiddleware: network.NewBaseMiddleware(),
+	}
+}
+
+func (m *httpMetrics) Attach(engine *gin.Engine) {
+	engine.Use(m.Handler)
+}
+
+func (m *httpMetrics) Handler(ctx *gin.Context) {
+	startTime := time.Now()
+	done := metrics.RequestStarted()
+	defer func() {
+		done(ctx.Request.Method, ctx.FullPath(), ctx.Writer.Status(), time.Since(startTime))
+	}()
+	ctx.Next()
+}
@@ -1,29 +1,83 @@
 package middleware
 
 import (
-	"context"
 	"fmt"
 	"math"
 	"strconv"
+	"sync-backend/arch/common"
 	"sync-backend/arch/config"
+	"sync-backend/arch/limiter"
+	"sync-backend/arch/metrics"
 	"sync-backend/arch/network"
-	"sync-backend/arch/redis"
+	"sync-backend/utils"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// Policies route groups are limited by, configured under api.rate_limit.policies
+const (
+	RateLimitPostCreate    = "post_create"
+	RateLimitCommentCreate = "comment_create"
+	RateLimitVote          = "vote"
+	RateLimitReport        = "report"
+)
+
+const (
+	KeyByIP   = "ip"
+	KeyByUser = "user"
+
+	// The remaining count of the strictest policy applied so far, its headers win
+	rateLimitRemainingKey = "rateLimitRemaining"
+)
+
+type rateLimitPolicy struct {
+	limiter.Policy
+	keyBy string
+}
+
 type rateLimiter struct {
 	network.BaseMiddleware
-	redis  redis.Store
-	config config.Config
+	common.ContextPayload
+	logger   utils.AppLogger
+	limiter  limiter.Limiter
+	config   config.RateLimitConfig
+	global   rateLimitPolicy
+	policies map[string]rateLimitPolicy
+}
+
+// RateLimiter applies the global policy to every request as a root middleware and the named policies to
+// the route groups that ask for them
+type RateLimiter interface {
+	network.RootMiddleware
+	network.RateLimitProvider
 }
 
-func NewRateLimiter(redis redis.Store, config config.Config) network.RootMiddleware {
-	return &rateLimiter{
+func NewRateLimiter(limiter limiter.Limiter, config config.RateLimitConfig) RateLimiter {
+	m := &rateLimiter{
 		BaseMiddleware: network.NewBaseMiddleware(),
-		redis:          redis,
+		ContextPayload: common.NewContextPayload(),
+		logger:         utils.NewServiceLogger("RateLimiter"),
+		limiter:        limiter,
 		config:         config,
+		global:         newRateLimitPolicy("global", config.Global),
+		policies:       make(map[string]rateLimitPolicy, len(config.Policies)),
+	}
+	for name, policy := range config.Policies {
+		m.policies[name] = newRateLimitPolicy(name, policy)
+	}
+	return m
+}
+
+func newRateLimitPolicy(name string, policy config.RateLimitPolicy) rateLimitPolicy {
+	return rateLimitPolicy{
+		Policy: limiter.Policy{
+			Name:      name,
+			Algorithm: limiter.Algorithm(policy.Algorithm),
+			Limit:     policy.Requests,
+			Window:    policy.Window,
+		},
+		keyBy: policy.KeyBy,
 	}
 }
 
@@ -32,50 +86,88 @@ func (m *rateLimiter) Attach(engine *gin.Engine) {
 }
 
 func (m *rateLimiter) Handler(ctx *gin.Context) {
-	ip := ctx.ClientIP()
+	m.limit(ctx, m.global)
+}
 
-	// Add timestamp to create a time-bound window key
-	windowStart := time.Now().Unix() / int64(m.config.Auth.RateLimit.General.Duration.Seconds()) * int64(m.config.Auth.RateLimit.General.Duration.Seconds())
-	key := fmt.Sprintf("ratelimit:ip:%s:%d", ip, windowStart)
+// Middleware limits the route group with the named policy, it must run after authentication for
+// policies keyed by user
+func (m *rateLimiter) Middleware(name string) gin.HandlerFunc {
+	policy, ok := m.policies[name]
+	if !ok || !m.config.Enabled {
+		if m.config.Enabled {
+			m.logger.Warn("Rate limit policy %s is not configured, its routes are not limited", name)
+		}
+		return func(ctx *gin.Context) {
+			ctx.Next()
+		}
+	}
+	return func(ctx *gin.Context) {
+		m.limit(ctx, policy)
+	}
+}
 
-	limit := m.config.Auth.RateLimit.General.Requests
-	windowSeconds := m.config.Auth.RateLimit.General.Duration.Seconds()
-	if limit <= 0 || windowSeconds <= 0 {
+func (m *rateLimiter) limit(ctx *gin.Context, policy rateLimitPolicy) {
+	if policy.Limit <= 0 || policy.Window <= 0 {
 		ctx.Next()
 		return
 	}
 
-	// Using a pipeline to make operations atomic
-	pipe := m.redis.GetInstance().Pipeline()
-	incr := pipe.Incr(context.Background(), key)
-	pipe.Expire(context.Background(), key, time.Duration(windowSeconds)*time.Second)
-	_, err := pipe.Exec(context.Background())
-
+	key := m.key(ctx, policy.keyBy)
+	result, err := m.limiter.Allow(ctx.Request.Context(), policy.Policy, key)
 	if err != nil {
-		ctx.Next()
+		m.logger.WithContext(ctx).Error("Failed to check rate limit %s for %s: %v", policy.Name, key, err)
+		if m.config.FailOpen {
+			ctx.Next()
+			return
+		}
+		m.Send(ctx).MixedError(network.NewServiceUnavailableError(
+			"Rate limiter unavailable",
+			"Request limits can not be checked right now, try again shortly",
+			err,
+		))
+		ctx.Abort()
 		return
 	}
 
-	val := incr.Val()
-
-	// Calculate time until window resets
-	timeUntilReset := windowStart + int64(windowSeconds) - time.Now().Unix()
-	if timeUntilReset < 0 {
-		timeUntilReset = int64(windowSeconds)
-	}
-
-	ctx.Header("X-RateLimit-Limit", strconv.Itoa(limit))
-	ctx.Header("X-RateLimit-Remaining", strconv.Itoa(int(math.Max(0, float64(limit)-float64(val)))))
-	ctx.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix()+timeUntilReset, 10))
-
-	if val > int64(limit) {
+	m.setHeaders(ctx, policy, result)
+	if !result.Allowed {
+		metrics.RateLimitRejected(policy.Name)
+		retryAfter := ceilSeconds(result.RetryAfter)
+		ctx.Header("Retry-After", strconv.Itoa(retryAfter))
 		m.Send(ctx).TooManyRequestsError(
 			"Rate limit exceeded",
-			fmt.Sprintf("Rate limit exceeded. Try again in %d seconds", timeUntilReset),
-			fmt.Errorf("Rate limit exceeded for IP %s: %d requests in %.0f seconds", ip, val, windowSeconds),
+			fmt.Sprintf("Rate limit exceeded. Try again in %d seconds", retryAfter),
+			fmt.Errorf("rate limit %s exceeded for %s: %d requests in %s", policy.Name, key, policy.Limit, policy.Window),
 		)
+		ctx.Abort()
 		return
 	}
-
 	ctx.Next()
 }
+
+// key identifies who the request is counted against, requests without a user count by IP
+func (m *rateLimiter) key(ctx *gin.Context, keyBy string) string {
+	if keyBy == KeyByUser {
+		if userId := m.GetUserId(ctx); userId != nil {
+			return "user:" + *userId
+		}
+	}
+	return "ip:" + ctx.ClientIP()
+}
+
+// setHeaders writes the RateLimit-* headers unless a stricter policy already did
+func (m *rateLimiter) setHeaders(ctx *gin.Context, policy rateLimitPolicy, result limiter.Result) {
+	if remaining, ok := ctx.Get(rateLimitRemainingKey); ok && remaining.(int) < result.Remaining {
+		return
+	}
+	ctx.Set(rateLimitRemainingKey, result.Remaining)
+
+	ctx.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	ctx.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	ctx.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	ctx.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, ceilSeconds(policy.Window)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
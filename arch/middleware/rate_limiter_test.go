@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"sync-backend/arch/config"
+	"sync-backend/arch/limiter"
+	"sync-backend/arch/network"
+	"sync-backend/arch/redis"
+	"sync-backend/utils"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubLimiter struct {
+	result limiter.Result
+	err    error
+	keys   []string
+}
+
+func (l *stubLimiter) Allow(_ context.Context, policy limiter.Policy, key string) (limiter.Result, error) {
+	l.keys = append(l.keys, policy.Name+"/"+key)
+	return l.result, l.err
+}
+
+func rateLimitConfig(failOpen bool) config.RateLimitConfig {
+	return config.RateLimitConfig{
+		Enabled:  true,
+		FailOpen: failOpen,
+		Global:   config.RateLimitPolicy{Algorithm: "sliding_window", Requests: 100, Window: time.Minute, KeyBy: KeyByIP},
+		Policies: map[string]config.RateLimitPolicy{
+			RateLimitVote: {Algorithm: "token_bucket", Requests: 10, Window: time.Minute, KeyBy: KeyByUser},
+		},
+	}
+}
+
+func TestRateLimiterRejectsWithHeaders(t *testing.T) {
+	stub := &stubLimiter{result: limiter.Result{Limit: 100, Remaining: 0, Reset: 30 * time.Second, RetryAfter: 1500 * time.Millisecond}}
+	mockHandler := func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	}
+
+	rr := network.MockTestRootMiddleware(t, NewRateLimiter(stub, rateLimitConfig(true)), mockHandler)
+
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "100", rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", rr.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "100;w=60", rr.Header().Get("RateLimit-Policy"))
+	assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+}
+
+func TestRateLimiterFailureMode(t *testing.T) {
+	stub := &stubLimiter{err: errors.New("dial tcp: connection refused")}
+	mockHandler := func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	}
+
+	rr := network.MockTestRootMiddleware(t, NewRateLimiter(stub, rateLimitConfig(true)), mockHandler)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = network.MockTestRootMiddleware(t, NewRateLimiter(stub, rateLimitConfig(false)), mockHandler)
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
+
+func TestRateLimitPolicyKeysByUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	stub := &stubLimiter{result: limiter.Result{Allowed: true, Limit: 10, Remaining: 9}}
+	rateLimiter := NewRateLimiter(stub, rateLimitConfig(true))
+
+	engine := gin.New()
+	engine.POST("/anonymous/vote", rateLimiter.Middleware(RateLimitVote), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	engine.POST("/vote", func(ctx *gin.Context) {
+		ctx.Set(network.UserPayload, "user-1")
+	}, rateLimiter.Middleware(RateLimitVote), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	engine.POST("/unlimited", rateLimiter.Middleware("unknown"), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/vote", "/anonymous/vote", "/unlimited"} {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.RemoteAddr = "10.0.0.7:4242"
+		engine.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+	assert.Equal(t, []string{"vote/user:user-1", "vote/ip:10.0.0.7"}, stub.keys)
+}
+
+func TestRateLimiterHeadersFromRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	server.SetTime(time.Unix(1_700_000_000, 0))
+	port, err := strconv.Atoi(server.Port())
+	require.NoError(t, err)
+	store := redis.NewStore(context.Background(), utils.NewServiceLogger("RateLimiterTest"), &redis.Config{
+		Host: server.Host(),
+		Port: uint16(port),
+	})
+	t.Cleanup(store.Disconnect)
+
+	limits := rateLimitConfig(false)
+	limits.Global = config.RateLimitPolicy{Algorithm: "sliding_window", Requests: 2, Window: 10 * time.Second, KeyBy: KeyByIP}
+	rateLimiter := NewRateLimiter(limiter.NewRedisLimiter(store), limits)
+	mockHandler := func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	}
+
+	for _, remaining := range []string{"1", "0"} {
+		rr := network.MockTestRootMiddleware(t, rateLimiter, mockHandler)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, remaining, rr.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "10", rr.Header().Get("RateLimit-Reset"))
+		assert.Equal(t, "2;w=10", rr.Header().Get("RateLimit-Policy"))
+	}
+
+	server.SetTime(time.Unix(1_700_000_004, 0))
+	rr := network.MockTestRootMiddleware(t, rateLimiter, mockHandler)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "6", rr.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "6", rr.Header().Get("Retry-After"))
+
+	server.SetTime(time.Unix(1_700_000_011, 0))
+	rr = network.MockTestRootMiddleware(t, rateLimiter, mockHandler)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("RateLimit-Remaining"))
+}
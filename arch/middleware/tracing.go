@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"sync-backend/arch/network"
+	"sync-backend/arch/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type tracer struct {
+	network.BaseMiddleware
+}
+
+// NewTracer starts the root span of every request, continuing the trace of the caller when it sent a traceparent header
+func NewTracer() network.RootMiddleware {
+	return &tracer{
+		BaseMiddleware: network.NewBaseMiddleware(),
+	}
+}
+
+func (m *tracer) Attach(engine *gin.Engine) {
+	engine.Use(m.Handler)
+}
+
+func (m *tracer) Handler(ctx *gin.Context) {
+	parent := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+
+	route := ctx.FullPath()
+	name := ctx.Request.Method + " " + route
+	if route == "" {
+		name = ctx.Request.Method
+	}
+	spanCtx, span := tracing.Tracer().Start(parent, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(ctx.Request.Method),
+			semconv.HTTPRoute(route),
+			semconv.URLPath(ctx.Request.URL.Path),
+			semconv.ClientAddress(ctx.ClientIP()),
+			semconv.UserAgentOriginal(ctx.Request.UserAgent()),
+		),
+	)
+	defer span.End()
+	ctx.Request = ctx.Request.WithContext(spanCtx)
+
+	ctx.Next()
+
+	status := ctx.Writer.Status()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+	if len(ctx.Errors) > 0 {
+		span.RecordError(ctx.Errors.Last())
+	}
+}
@@ -47,26 +47,32 @@ type UploadedFile struct {
 	Filename string `json:"filename"`
 	Size     int64  `json:"size"`
 	Path     string `json:"path"`
-	URL      string `json:"url"`
 }
 
 type FileUploadConfig struct {
-	StoragePath       string
-	MaxSize           int64
-	AllowedExtensions []string
-	UseUserID         bool
+	StoragePath      string
+	MaxSize          int64
+	AllowedMimeTypes []string // Checked against the magic bytes of the saved file, never the extension
+	UseUserID        bool
 }
 
-func NewUploadProvider() *uploadMiddleware {
+// NewUploadProvider stages multipart files below storagePath, the OS temp dir is used when it is empty
+func NewUploadProvider(storagePath string) *uploadMiddleware {
+	if storagePath == "" {
+		storagePath = filepath.Join(os.TempDir(), "sync-uploads")
+	}
 	return &uploadMiddleware{
 		ResponseSender: network.NewResponseSender(),
 		ContextPayload: common.NewContextPayload(),
 		logger:         utils.NewServiceLogger("UploadMiddleware"),
 		config: &FileUploadConfig{
-			StoragePath:       "./uploads",
-			MaxSize:           50 * 1024 * 1024, // 50 MB
-			AllowedExtensions: []string{".jpg", ".jpeg", ".png", ".gif", ".mp4", ".mov"},
-			UseUserID:         true,
+			StoragePath: storagePath,
+			MaxSize:     50 * 1024 * 1024, // 50 MB
+			AllowedMimeTypes: []string{
+				"image/jpeg", "image/png", "image/gif", "image/webp",
+				"video/mp4", "video/quicktime", "video/webm",
+			},
+			UseUserID: true,
 		},
 	}
 }
@@ -121,16 +127,7 @@ func (p *uploadMiddleware) Middleware(fieldNames ...string) gin.HandlerFunc {
 					p.logger.Warn("File %s exceeds maximum size limit of %d bytes", file.Filename, p.config.MaxSize)
 					continue
 				}
-				if len(p.config.AllowedExtensions) > 0 {
-					ext := filepath.Ext(file.Filename)
-					allowed := slices.Contains(p.config.AllowedExtensions, ext)
-					if !allowed {
-						p.logger.Warn("File %s has disallowed extension: %s", file.Filename, ext)
-						continue
-					}
-				}
-
-				uniqueFilename := fmt.Sprintf("%d-%s", time.Now().Unix(), file.Filename)
+				uniqueFilename := fmt.Sprintf("%d-%s", time.Now().Unix(), filepath.Base(file.Filename))
 				filePath := filepath.Join(storageDir, uniqueFilename)
 
 				if err := c.SaveUploadedFile(file, filePath); err != nil {
@@ -138,16 +135,19 @@ func (p *uploadMiddleware) Middleware(fieldNames ...string) gin.HandlerFunc {
 					continue
 				}
 
-				urlPath := fmt.Sprintf("/uploads/%s/%s", userID, uniqueFilename)
-				if !p.config.UseUserID || userID == "" {
-					urlPath = fmt.Sprintf("/uploads/common/%s", uniqueFilename)
+				if len(p.config.AllowedMimeTypes) > 0 {
+					mimeType, err := utils.DetectContentType(filePath)
+					if err != nil || !slices.Contains(p.config.AllowedMimeTypes, mimeType) {
+						p.logger.Warn("File %s has disallowed content type: %s", file.Filename, mimeType)
+						os.Remove(filePath)
+						continue
+					}
 				}
 
 				uploadedFiles.Files = append(uploadedFiles.Files, UploadedFile{
 					Filename: file.Filename,
 					Size:     file.Size,
 					Path:     filePath,
-					URL:      urlPath,
 				})
 
 				p.logger.Info("File uploaded successfully: %s -> %s", file.Filename, filePath)
@@ -75,6 +75,7 @@ type Aggregator[T any, R any] interface {
 	Exec() ([]*R, error)
 	ExecOne() (*R, error)
 	ExecPaginated(page, limit int64) ([]*R, error)
+	ExecCursorPaginated(cursor CursorQuery) ([]*R, *CursorResult, error)
 	ExecCount() (int64, error)
 	ExecRaw() (interface{}, error)
 
@@ -89,6 +90,7 @@ type Aggregator[T any, R any] interface {
 type aggregator[T any, R any] struct {
 	logger       utils.AppLogger
 	collection   *mongo.Collection
+	cursors      CursorCodec
 	context      context.Context
 	cancel       context.CancelFunc
 	pipeline     []bson.D
@@ -99,15 +101,18 @@ type aggregator[T any, R any] struct {
 // newSingleAggregator creates a new aggregator with a timeout
 func newSingleAggregator[T any, R any](
 	logger utils.AppLogger,
+	ctx context.Context,
 	collection *mongo.Collection,
+	cursors CursorCodec,
 	timeout time.Duration,
 ) Aggregator[T, R] {
-	context, cancel := context.WithTimeout(context.Background(), timeout)
+	context, cancel := context.WithTimeout(ctx, timeout)
 	return &aggregator[T, R]{
 		logger:       logger,
 		context:      context,
 		cancel:       cancel,
 		collection:   collection,
+		cursors:      cursors,
 		pipeline:     make([]bson.D, 0),
 		stageMap:     make(map[StageType]int),
 		allowDiskUse: true,
@@ -119,11 +124,13 @@ func newAggregator[T any, R any](
 	logger utils.AppLogger,
 	context context.Context,
 	collection *mongo.Collection,
+	cursors CursorCodec,
 ) Aggregator[T, R] {
 	return &aggregator[T, R]{
 		logger:       logger,
 		context:      context,
 		collection:   collection,
+		cursors:      cursors,
 		pipeline:     make([]bson.D, 0),
 		stageMap:     make(map[StageType]int),
 		allowDiskUse: true,
@@ -483,6 +490,88 @@ func (a *aggregator[T, R]) ExecPaginated(page, limit int64) ([]*R, error) {
 	return results, nil
 }
 
+// ExecCursorPaginated executes the aggregation pipeline for one page after cursor.After, ordered by
+// (cursor.SortField, _id). The keyset match, sort and limit are placed right after the leading $match
+// stages so lookups only run on the page, any other $sort, $skip or $limit stage is dropped. The
+// pipeline output must keep _id and the sort field under their original names.
+func (a *aggregator[T, R]) ExecCursorPaginated(cursor CursorQuery) ([]*R, *CursorResult, error) {
+	defer a.Close()
+	if err := cursor.normalize(); err != nil {
+		return nil, nil, err
+	}
+	keyset, err := cursor.filter(a.cursors)
+	if err != nil {
+		a.logger.Error("[ MONGO ] - Invalid cursor for paginated aggregation: %v", err)
+		return nil, nil, err
+	}
+	a.logger.Info("[ MONGO ] - Executing cursor paginated aggregation: sort %s, limit %d", cursor.SortField, cursor.Limit)
+
+	pageStages := make([]bson.D, 0, 3)
+	if keyset != nil {
+		pageStages = append(pageStages, bson.D{{Key: "$match", Value: keyset}})
+	}
+	pageStages = append(pageStages,
+		bson.D{{Key: "$sort", Value: cursor.sort()}},
+		bson.D{{Key: "$limit", Value: cursor.Limit + 1}},
+	)
+
+	pipeline := make([]bson.D, 0, len(a.pipeline)+len(pageStages))
+	inserted := false
+	for _, stage := range a.pipeline {
+		operator := stage[0].Key
+		if operator == string(StageSort) || operator == string(StageSkip) || operator == string(StageLimit) {
+			continue
+		}
+		if !inserted && operator != string(StageMatch) {
+			pipeline = append(pipeline, pageStages...)
+			inserted = true
+		}
+		pipeline = append(pipeline, stage)
+	}
+	if !inserted {
+		pipeline = append(pipeline, pageStages...)
+	}
+
+	opts := options.Aggregate().SetAllowDiskUse(a.allowDiskUse)
+	mongoCursor, err := a.collection.Aggregate(a.context, pipeline, opts)
+	if err != nil {
+		a.logger.Error("[ MONGO ] - Error executing cursor paginated aggregation: %v", err)
+		return nil, nil, fmt.Errorf("error executing aggregation: %w", err)
+	}
+	defer mongoCursor.Close(a.context)
+
+	var results []*R
+	var last bson.Raw
+	result := &CursorResult{}
+	for mongoCursor.Next(a.context) {
+		if int64(len(results)) == cursor.Limit {
+			result.HasMore = true
+			break
+		}
+		var doc R
+		if err := mongoCursor.Decode(&doc); err != nil {
+			a.logger.Error("[ MONGO ] - Error decoding cursor paginated result: %v", err)
+			return nil, nil, fmt.Errorf("error decoding result: %w", err)
+		}
+		results = append(results, &doc)
+		last = append(last[:0], mongoCursor.Current...)
+	}
+
+	if err := mongoCursor.Err(); err != nil {
+		a.logger.Error("[ MONGO ] - Cursor error: %v", err)
+		return nil, nil, fmt.Errorf("cursor error: %w", err)
+	}
+
+	if result.HasMore {
+		if result.NextCursor, err = cursor.next(a.cursors, last); err != nil {
+			a.logger.Error("[ MONGO ] - Error building next cursor: %v", err)
+			return nil, nil, err
+		}
+	}
+	a.logger.Info("[ MONGO ] - Cursor paginated aggregation executed successfully, retrieved %d results", len(results))
+	return results, result, nil
+}
+
 // ExecCount executes the aggregation pipeline and returns the count of results
 func (a *aggregator[T, R]) ExecCount() (int64, error) {
 	defer a.Close()
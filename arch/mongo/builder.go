@@ -11,26 +11,29 @@ import (
 type QueryBuilder[T any] interface {
 	GetLogger() utils.AppLogger
 	GetCollection() *mongo.Collection
-	SingleQuery() Query[T]
+	// SingleQuery runs one operation bounded by the query timeout, Query runs any number until the context is done
+	SingleQuery(ctx context.Context) Query[T]
 	Query(context context.Context) Query[T]
 }
 
 type AggregateBuilder[T any, R any] interface {
 	GetLogger() utils.AppLogger
 	GetCollection() *mongo.Collection
-	SingleAggregate() Aggregator[T, R]
+	SingleAggregate(ctx context.Context) Aggregator[T, R]
 	Aggregate(context context.Context) Aggregator[T, R]
 }
 
 type queryBuilder[T any] struct {
 	logger     utils.AppLogger
 	collection *mongo.Collection
+	cursors    CursorCodec
 	timeout    time.Duration
 }
 
 type aggregateBuilder[T any, R any] struct {
 	logger     utils.AppLogger
 	collection *mongo.Collection
+	cursors    CursorCodec
 	timeout    time.Duration
 }
 
@@ -42,12 +45,12 @@ func (c *queryBuilder[T]) GetLogger() utils.AppLogger {
 	return c.logger
 }
 
-func (c *queryBuilder[T]) SingleQuery() Query[T] {
-	return newSingleQuery[T](c.logger, c.collection, c.timeout)
+func (c *queryBuilder[T]) SingleQuery(ctx context.Context) Query[T] {
+	return newSingleQuery[T](c.logger, ctx, c.collection, c.cursors, c.timeout)
 }
 
 func (c *queryBuilder[T]) Query(context context.Context) Query[T] {
-	return newQuery[T](c.logger, context, c.collection)
+	return newQuery[T](c.logger, context, c.collection, c.cursors)
 }
 
 func (a *aggregateBuilder[T, R]) GetCollection() *mongo.Collection {
@@ -58,18 +61,19 @@ func (a *aggregateBuilder[T, R]) GetLogger() utils.AppLogger {
 	return a.logger
 }
 
-func (a *aggregateBuilder[T, R]) SingleAggregate() Aggregator[T, R] {
-	return newSingleAggregator[T, R](a.logger, a.collection, a.timeout)
+func (a *aggregateBuilder[T, R]) SingleAggregate(ctx context.Context) Aggregator[T, R] {
+	return newSingleAggregator[T, R](a.logger, ctx, a.collection, a.cursors, a.timeout)
 }
 
 func (a *aggregateBuilder[T, R]) Aggregate(context context.Context) Aggregator[T, R] {
-	return newAggregator[T, R](a.logger, context, a.collection)
+	return newAggregator[T, R](a.logger, context, a.collection, a.cursors)
 }
 
 func NewQueryBuilder[T any](db Database, collectionName string) QueryBuilder[T] {
 	return &queryBuilder[T]{
 		collection: db.GetInstance().Collection(collectionName),
-		timeout:    time.Minute * 5,
+		cursors:    db.GetCursorCodec(),
+		timeout:    db.GetInstance().queryTimeout(),
 		logger:     db.GetLogger(),
 	}
 }
@@ -77,7 +81,8 @@ func NewQueryBuilder[T any](db Database, collectionName string) QueryBuilder[T]
 func NewAggregateBuilder[T any, R any](db Database, collectionName string) AggregateBuilder[T, R] {
 	return &aggregateBuilder[T, R]{
 		collection: db.GetInstance().Collection(collectionName),
-		timeout:    time.Minute * 5,
+		cursors:    db.GetCursorCodec(),
+		timeout:    db.GetInstance().queryTimeout(),
 		logger:     db.GetLogger(),
 	}
 }
@@ -86,7 +91,7 @@ type TransactionBuilder interface {
 	GetLogger() utils.AppLogger
 	GetDatabase() string
 	GetClient() *mongo.Client
-	GetTransaction(timeout time.Duration) Transaction
+	GetTransaction(ctx context.Context, timeout time.Duration) Transaction
 }
 
 type transactionBuilder struct {
@@ -107,8 +112,8 @@ func (t *transactionBuilder) GetClient() *mongo.Client {
 	return t.client
 }
 
-func (t *transactionBuilder) GetTransaction(timeout time.Duration) Transaction {
-	return newTransaction(t.logger, t.client, t.database, timeout)
+func (t *transactionBuilder) GetTransaction(ctx context.Context, timeout time.Duration) Transaction {
+	return newTransaction(t.logger, ctx, t.client, t.database, timeout)
 }
 
 func NewTransactionBuilder(db Database) TransactionBuilder {
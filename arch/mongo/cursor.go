@@ -0,0 +1,206 @@
+package mongo
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor is malformed, was tampered with
+// or was issued for a different sort than the one it is used with
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+const cursorSignatureSize = 16
+
+// CursorQuery describes one page of keyset pagination ordered by (SortField, _id)
+type CursorQuery struct {
+	SortField string // Field to order by, "_id" orders by insertion only
+	SortOrder int    // 1 for ascending, -1 for descending
+	Limit     int64  // Maximum number of documents in the page
+	After     string // Opaque cursor returned by the previous page, empty for the first page
+}
+
+// NewCursorQuery creates a descending cursor query on sortField
+func NewCursorQuery(sortField string, limit int64, after string) CursorQuery {
+	return CursorQuery{
+		SortField: sortField,
+		SortOrder: -1,
+		Limit:     limit,
+		After:     after,
+	}
+}
+
+// CursorResult is the pagination state returned alongside a page of results
+type CursorResult struct {
+	NextCursor string
+	HasMore    bool
+}
+
+// cursorPosition is the signed payload of a cursor, the last (sort value, _id) pair of a page
+type cursorPosition struct {
+	Field string      `bson:"f"`
+	Order int         `bson:"o"`
+	Value interface{} `bson:"v"`
+	Id    interface{} `bson:"i"`
+}
+
+// CursorCodec signs and verifies the opaque pagination cursors handed to clients. Seal and Open
+// let callers paginating outside of a keyset query sign their own cursor state.
+type CursorCodec interface {
+	Seal(state interface{}) (string, error)
+	Open(token string, state interface{}) error
+	encode(position cursorPosition) (string, error)
+	decode(token string) (*cursorPosition, error)
+}
+
+type cursorCodec struct {
+	key []byte
+}
+
+// NewCursorCodec creates a codec signing cursors with a key derived from secret. An empty secret
+// falls back to a random key, cursors then stop being valid when the process restarts.
+func NewCursorCodec(secret string) CursorCodec {
+	var key []byte
+	if secret == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("failed to generate cursor signing key: %v", err))
+		}
+	} else {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte("mongo-pagination-cursor"))
+		key = mac.Sum(nil)
+	}
+	return &cursorCodec{key: key}
+}
+
+func (c *cursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+	return mac.Sum(nil)[:cursorSignatureSize]
+}
+
+// Seal bson encodes state and signs it into an opaque cursor
+func (c *cursorCodec) Seal(state interface{}) (string, error) {
+	payload, err := bson.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("error encoding cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(append(payload, c.sign(payload)...)), nil
+}
+
+// Open verifies a cursor created by Seal and decodes it into state
+func (c *cursorCodec) Open(token string, state interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) <= cursorSignatureSize {
+		return ErrInvalidCursor
+	}
+	payload, signature := data[:len(data)-cursorSignatureSize], data[len(data)-cursorSignatureSize:]
+	if !hmac.Equal(signature, c.sign(payload)) {
+		return ErrInvalidCursor
+	}
+	if err := bson.Unmarshal(payload, state); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+func (c *cursorCodec) encode(position cursorPosition) (string, error) {
+	return c.Seal(position)
+}
+
+func (c *cursorCodec) decode(token string) (*cursorPosition, error) {
+	var position cursorPosition
+	if err := c.Open(token, &position); err != nil {
+		return nil, err
+	}
+	return &position, nil
+}
+
+// normalize fills in defaults and validates the query
+func (q *CursorQuery) normalize() error {
+	if q.SortField == "" {
+		q.SortField = "_id"
+	}
+	if q.SortOrder >= 0 {
+		q.SortOrder = 1
+	} else {
+		q.SortOrder = -1
+	}
+	if q.Limit <= 0 {
+		return fmt.Errorf("cursor limit must be positive, got %d", q.Limit)
+	}
+	return nil
+}
+
+// sort returns the sort specification of the query, _id breaks ties on the sort field
+func (q *CursorQuery) sort() bson.D {
+	if q.SortField == "_id" {
+		return bson.D{{Key: "_id", Value: q.SortOrder}}
+	}
+	return bson.D{{Key: q.SortField, Value: q.SortOrder}, {Key: "_id", Value: q.SortOrder}}
+}
+
+// filter decodes the After cursor into the keyset condition selecting the documents past it,
+// nil is returned for the first page
+func (q *CursorQuery) filter(codec CursorCodec) (bson.M, error) {
+	if q.After == "" {
+		return nil, nil
+	}
+	position, err := codec.decode(q.After)
+	if err != nil {
+		return nil, err
+	}
+	if position.Field != q.SortField || position.Order != q.SortOrder {
+		return nil, ErrInvalidCursor
+	}
+
+	op := "$gt"
+	if q.SortOrder < 0 {
+		op = "$lt"
+	}
+	if q.SortField == "_id" {
+		return bson.M{"_id": bson.M{op: position.Id}}, nil
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{q.SortField: bson.M{op: position.Value}},
+		bson.M{q.SortField: position.Value, "_id": bson.M{op: position.Id}},
+	}}, nil
+}
+
+// next builds the cursor pointing past the last document of a page. The document must still
+// carry _id and the sort field under their original names.
+func (q *CursorQuery) next(codec CursorCodec, last bson.Raw) (string, error) {
+	position := cursorPosition{Field: q.SortField, Order: q.SortOrder}
+
+	idValue, err := last.LookupErr("_id")
+	if err != nil {
+		return "", fmt.Errorf("cursor document has no _id: %w", err)
+	}
+	if err := idValue.Unmarshal(&position.Id); err != nil {
+		return "", fmt.Errorf("error reading cursor _id: %w", err)
+	}
+
+	if q.SortField != "_id" {
+		sortValue, err := last.LookupErr(strings.Split(q.SortField, ".")...)
+		if err != nil {
+			return "", fmt.Errorf("cursor document has no %s: %w", q.SortField, err)
+		}
+		if err := sortValue.Unmarshal(&position.Value); err != nil {
+			return "", fmt.Errorf("error reading cursor %s: %w", q.SortField, err)
+		}
+	}
+
+	return codec.encode(position)
+}
+
+// IsInvalidCursorError reports whether err was caused by a bad pagination cursor
+func IsInvalidCursorError(err error) bool {
+	return errors.Is(err, ErrInvalidCursor)
+}
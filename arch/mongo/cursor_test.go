@@ -0,0 +1,89 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursorQuery_RoundTrip(t *testing.T) {
+	codec := NewCursorCodec("secret")
+	id := primitive.NewObjectID()
+	createdAt := primitive.NewDateTimeFromTime(time.Now())
+	last, err := bson.Marshal(bson.M{"_id": id, "createdAt": createdAt})
+	assert.NoError(t, err)
+
+	query := NewCursorQuery("createdAt", 10, "")
+	assert.NoError(t, query.normalize())
+	token, err := query.next(codec, last)
+	assert.NoError(t, err)
+
+	query.After = token
+	filter, err := query.filter(codec)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"createdAt": bson.M{"$lt": createdAt}},
+		bson.M{"createdAt": createdAt, "_id": bson.M{"$lt": id}},
+	}}, filter)
+}
+
+func TestCursorQuery_FirstPageHasNoFilter(t *testing.T) {
+	query := NewCursorQuery("createdAt", 10, "")
+	assert.NoError(t, query.normalize())
+
+	filter, err := query.filter(NewCursorCodec("secret"))
+	assert.NoError(t, err)
+	assert.Nil(t, filter)
+	assert.Equal(t, bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}, query.sort())
+}
+
+func TestCursorQuery_RejectsTamperedCursor(t *testing.T) {
+	codec := NewCursorCodec("secret")
+	last, _ := bson.Marshal(bson.M{"_id": primitive.NewObjectID(), "createdAt": primitive.NewDateTimeFromTime(time.Now())})
+
+	query := NewCursorQuery("createdAt", 10, "")
+	assert.NoError(t, query.normalize())
+	token, err := query.next(codec, last)
+	assert.NoError(t, err)
+
+	tampered := []byte(token)
+	tampered[len(tampered)/2] ^= 1
+	query.After = string(tampered)
+	_, err = query.filter(codec)
+	assert.True(t, IsInvalidCursorError(err))
+
+	query.After = token
+	_, err = query.filter(NewCursorCodec("another-secret"))
+	assert.True(t, IsInvalidCursorError(err))
+
+	query.After = "not-a-cursor"
+	_, err = query.filter(codec)
+	assert.True(t, IsInvalidCursorError(err))
+}
+
+func TestCursorQuery_RejectsCursorFromOtherSort(t *testing.T) {
+	codec := NewCursorCodec("secret")
+	last, _ := bson.Marshal(bson.M{"_id": primitive.NewObjectID(), "createdAt": primitive.NewDateTimeFromTime(time.Now()), "synergy": 4})
+
+	query := NewCursorQuery("createdAt", 10, "")
+	assert.NoError(t, query.normalize())
+	token, err := query.next(codec, last)
+	assert.NoError(t, err)
+
+	other := NewCursorQuery("synergy", 10, token)
+	assert.NoError(t, other.normalize())
+	_, err = other.filter(codec)
+	assert.True(t, IsInvalidCursorError(err))
+}
+
+func TestCursorQuery_NextRequiresSortField(t *testing.T) {
+	last, _ := bson.Marshal(bson.M{"_id": primitive.NewObjectID()})
+
+	query := NewCursorQuery("createdAt", 10, "")
+	assert.NoError(t, query.normalize())
+	_, err := query.next(NewCursorCodec("secret"), last)
+	assert.Error(t, err)
+}
@@ -12,6 +12,9 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// defaultQueryTimeout applies when the config sets no query timeout
+const defaultQueryTimeout = 30 * time.Second
+
 type DbConfig struct {
 	User        string
 	Pwd         string
@@ -20,6 +23,10 @@ type DbConfig struct {
 	MinPoolSize uint16
 	MaxPoolSize uint16
 	Timeout     time.Duration
+	// QueryTimeout bounds each SingleQuery and SingleAggregate on top of the deadline of their context
+	QueryTimeout time.Duration
+	// Secret used to sign pagination cursors handed out by cursor queries
+	CursorSecret string
 }
 
 type Document[T any] interface {
@@ -34,6 +41,8 @@ type Database interface {
 	GetInstance() *database
 	GetClient() *mongo.Client
 	GetDatabaseName() string
+	GetCursorCodec() CursorCodec
+	GetPoolStats() PoolStats
 	Ping(ctx context.Context) error
 	Connect()
 	Disconnect()
@@ -44,6 +53,8 @@ type database struct {
 	logger  utils.AppLogger
 	context context.Context
 	config  DbConfig
+	cursors CursorCodec
+	monitor *monitor
 }
 
 func NewDatabase(ctx context.Context, logger utils.AppLogger, config DbConfig) Database {
@@ -51,6 +62,8 @@ func NewDatabase(ctx context.Context, logger utils.AppLogger, config DbConfig) D
 		context: ctx,
 		logger:  logger,
 		config:  config,
+		cursors: NewCursorCodec(config.CursorSecret),
+		monitor: &monitor{},
 	}
 	return &db
 }
@@ -67,10 +80,25 @@ func (db *database) GetClient() *mongo.Client {
 	return client
 }
 
+func (db *database) queryTimeout() time.Duration {
+	if db.config.QueryTimeout <= 0 {
+		return defaultQueryTimeout
+	}
+	return db.config.QueryTimeout
+}
+
 func (db *database) GetDatabaseName() string {
 	return db.config.Name
 }
 
+func (db *database) GetCursorCodec() CursorCodec {
+	return db.cursors
+}
+
+func (db *database) GetPoolStats() PoolStats {
+	return db.monitor.stats(db.config)
+}
+
 func (db *database) GetLogger() utils.AppLogger {
 	return db.logger
 }
@@ -95,6 +123,9 @@ func (db *database) Connect() {
 	clientOptions.SetMinPoolSize(uint64(db.config.MinPoolSize))
 	clientOptions.SetMaxPoolSize(uint64(db.config.MaxPoolSize))
 
+	clientOptions.SetMonitor(db.monitor.commandMonitor())
+	clientOptions.SetPoolMonitor(db.monitor.poolMonitor())
+
 	client, err := mongo.Connect(db.context, clientOptions)
 	if err != nil {
 		db.logger.Fatal("Failed to connect to mongo: %v", err)
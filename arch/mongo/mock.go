@@ -0,0 +1,202 @@
+package mongo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MockTransactionSession keeps collections in memory so that transaction callbacks can be tested without
+// Mongo. Filters match on equality of top level fields and updates support $set and $inc, which is
+// what the callbacks of the services use.
+type MockTransactionSession struct {
+	mu          sync.Mutex
+	collections map[string][]bson.M
+}
+
+func NewMockTransactionSession() *MockTransactionSession {
+	return &MockTransactionSession{collections: map[string][]bson.M{}}
+}
+
+// Documents returns copies of the documents stored in a collection
+func (s *MockTransactionSession) Documents(name string) []bson.M {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	documents := make([]bson.M, 0, len(s.collections[name]))
+	for _, document := range s.collections[name] {
+		copied, _ := normalize(document)
+		documents = append(documents, copied)
+	}
+	return documents
+}
+
+func (s *MockTransactionSession) Collection(name string) CollectionHandle {
+	return &mockCollection{session: s, name: name}
+}
+
+func (s *MockTransactionSession) Client() ClientHandle {
+	return s
+}
+
+// normalize round-trips a value through bson, so that documents, filters and updates compare with the
+// types Mongo would store
+func normalize(value any) (bson.M, error) {
+	data, err := bson.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var document bson.M
+	if err := bson.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+type mockCollection struct {
+	// CollectionHandle is embedded so the operations the mock does not support fail loudly when called
+	CollectionHandle
+	session *MockTransactionSession
+	name    string
+}
+
+func (c *mockCollection) find(filter any) (int, error) {
+	normalized, err := normalize(filter)
+	if err != nil {
+		return -1, err
+	}
+	for i, document := range c.session.collections[c.name] {
+		matches := true
+		for key, value := range normalized {
+			if !reflect.DeepEqual(document[key], value) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+func (c *mockCollection) InsertOne(document any) (any, error) {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+	normalized, err := normalize(document)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := normalized["_id"]; !ok {
+		normalized["_id"] = primitive.NewObjectID()
+	}
+	c.session.collections[c.name] = append(c.session.collections[c.name], normalized)
+	return normalized["_id"], nil
+}
+
+func (c *mockCollection) UpdateOne(filter any, update any) (int64, error) {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+	i, err := c.find(filter)
+	if err != nil || i < 0 {
+		return 0, err
+	}
+	operations, err := normalize(update)
+	if err != nil {
+		return 0, err
+	}
+	document := c.session.collections[c.name][i]
+	for operator, fields := range operations {
+		for path, value := range fields.(bson.M) {
+			parent, key := nested(document, path)
+			switch operator {
+			case "$set":
+				parent[key] = value
+			case "$inc":
+				parent[key] = toInt64(parent[key]) + toInt64(value)
+			default:
+				return 0, fmt.Errorf("update operator %s is not supported by the mock", operator)
+			}
+		}
+	}
+	return 1, nil
+}
+
+func (c *mockCollection) DeleteOne(filter any) (int64, error) {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+	i, err := c.find(filter)
+	if err != nil || i < 0 {
+		return 0, err
+	}
+	documents := c.session.collections[c.name]
+	c.session.collections[c.name] = append(documents[:i], documents[i+1:]...)
+	return 1, nil
+}
+
+func (c *mockCollection) FindOne(filter any) SingleResultHandle {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+	i, err := c.find(filter)
+	if err == nil && i < 0 {
+		err = mongo.ErrNoDocuments
+	}
+	if err != nil {
+		return &mockSingleResult{err: err}
+	}
+	return &mockSingleResult{document: c.session.collections[c.name][i]}
+}
+
+// nested returns the document holding the last element of a dotted path, creating the ones missing
+func nested(document bson.M, path string) (bson.M, string) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := document[key].(bson.M)
+		if !ok {
+			child = bson.M{}
+			document[key] = child
+		}
+		document = child
+	}
+	return document, keys[len(keys)-1]
+}
+
+func toInt64(value any) int64 {
+	switch v := value.(type) {
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+type mockSingleResult struct {
+	document bson.M
+	err      error
+}
+
+func (r *mockSingleResult) Decode(value any) error {
+	if r.err != nil {
+		return r.err
+	}
+	data, err := bson.Marshal(r.document)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, value)
+}
+
+func (r *mockSingleResult) Err() error {
+	return r.err
+}
+
+func (r *mockSingleResult) IsNotFound() bool {
+	return r.err == mongo.ErrNoDocuments
+}
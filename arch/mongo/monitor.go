@@ -0,0 +1,124 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"sync-backend/arch/metrics"
+	"sync-backend/arch/tracing"
+
+	"go.mongodb.org/mongo-driver/event"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PoolStats is the state of the driver connection pool, summed over all servers
+type PoolStats struct {
+	MinPoolSize      uint64
+	MaxPoolSize      uint64
+	Open             int64
+	InUse            int64
+	Idle             int64
+	CheckoutFailures int64
+}
+
+// monitor exports command latency and pool usage of the client to the metrics registry,
+// and traces every command as a child span of the context the query ran with
+type monitor struct {
+	open             atomic.Int64
+	inUse            atomic.Int64
+	checkoutFailures atomic.Int64
+	// command spans between the started and finished events, by commandKey
+	spans sync.Map
+}
+
+type commandKey struct {
+	connectionId string
+	requestId    int64
+}
+
+func (m *monitor) commandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			_, span := tracing.Tracer().Start(ctx, "mongo."+e.CommandName,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					semconv.DBSystemMongoDB,
+					semconv.DBNamespace(e.DatabaseName),
+					semconv.DBOperationName(e.CommandName),
+					semconv.DBCollectionName(commandCollection(e)),
+				),
+			)
+			m.spans.Store(commandKey{e.ConnectionID, e.RequestID}, span)
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			metrics.ObserveMongoCommand(e.CommandName, e.Duration, false)
+			m.endSpan(e.CommandFinishedEvent, nil)
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			metrics.ObserveMongoCommand(e.CommandName, e.Duration, true)
+			m.endSpan(e.CommandFinishedEvent, errors.New(e.Failure))
+		},
+	}
+}
+
+func (m *monitor) endSpan(e event.CommandFinishedEvent, err error) {
+	value, ok := m.spans.LoadAndDelete(commandKey{e.ConnectionID, e.RequestID})
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	tracing.RecordError(span, err)
+	span.End()
+}
+
+// The collection is the value of the first element of the command, e.g. {find: "posts", ...}
+func commandCollection(e *event.CommandStartedEvent) string {
+	element, err := e.Command.IndexErr(0)
+	if err != nil {
+		return ""
+	}
+	collection, ok := element.Value().StringValueOK()
+	if !ok {
+		return ""
+	}
+	return collection
+}
+
+func (m *monitor) poolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			switch e.Type {
+			case event.ConnectionCreated:
+				m.open.Add(1)
+			case event.ConnectionClosed:
+				m.open.Add(-1)
+			case event.GetSucceeded:
+				m.inUse.Add(1)
+			case event.ConnectionReturned:
+				m.inUse.Add(-1)
+			case event.GetFailed:
+				m.checkoutFailures.Add(1)
+				metrics.MongoPoolCheckoutFailed()
+			default:
+				return
+			}
+			metrics.SetMongoPoolConnections(m.open.Load(), m.inUse.Load())
+		},
+	}
+}
+
+func (m *monitor) stats(config DbConfig) PoolStats {
+	open := m.open.Load()
+	inUse := m.inUse.Load()
+	return PoolStats{
+		MinPoolSize:      uint64(config.MinPoolSize),
+		MaxPoolSize:      uint64(config.MaxPoolSize),
+		Open:             open,
+		InUse:            inUse,
+		Idle:             max(open-inUse, 0),
+		CheckoutFailures: m.checkoutFailures.Load(),
+	}
+}
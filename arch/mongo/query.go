@@ -30,6 +30,7 @@ type Query[T any] interface {
 	FilterOneAndUpdate(filter bson.M, update bson.M, opts *options.FindOneAndUpdateOptions) (*T, error)
 	FilterMany(filter bson.M, opts *options.FindOptions) ([]*T, error)
 	FilterPaginated(filter bson.M, page int64, limit int64, opts *options.FindOptions) ([]*T, error)
+	FindCursorPaginated(filter bson.M, cursor CursorQuery, opts *options.FindOptions) ([]*T, *CursorResult, error)
 	FilterCount(filter bson.M) (int64, error)
 	CountDocuments(filter bson.M, opts *options.CountOptions) (int64, error)
 	UpdateOne(filter bson.M, update bson.M, opts *options.UpdateOptions) (*mongo.UpdateResult, error)
@@ -41,25 +42,28 @@ type Query[T any] interface {
 type query[T any] struct {
 	logger     utils.AppLogger
 	collection *mongo.Collection
+	cursors    CursorCodec
 	context    context.Context
 	cancel     context.CancelFunc
 }
 
-func newSingleQuery[T any](logger utils.AppLogger, collection *mongo.Collection, timeout time.Duration) Query[T] {
-	context, cancel := context.WithTimeout(context.Background(), timeout)
+func newSingleQuery[T any](logger utils.AppLogger, ctx context.Context, collection *mongo.Collection, cursors CursorCodec, timeout time.Duration) Query[T] {
+	context, cancel := context.WithTimeout(ctx, timeout)
 	return &query[T]{
 		logger:     logger,
 		context:    context,
 		cancel:     cancel,
 		collection: collection,
+		cursors:    cursors,
 	}
 }
 
-func newQuery[T any](logger utils.AppLogger, context context.Context, collection *mongo.Collection) Query[T] {
+func newQuery[T any](logger utils.AppLogger, context context.Context, collection *mongo.Collection, cursors CursorCodec) Query[T] {
 	return &query[T]{
 		logger:     logger,
 		context:    context,
 		collection: collection,
+		cursors:    cursors,
 	}
 }
 
@@ -252,6 +256,68 @@ func (q *query[T]) FindPaginated(filter bson.M, page int64, limit int64, opts *o
 	return docs, nil
 }
 
+// FindCursorPaginated finds one page of documents after cursor.After, ordered by (cursor.SortField, _id).
+// Any sort, skip or limit in opts is replaced by the cursor.
+func (q *query[T]) FindCursorPaginated(filter bson.M, cursor CursorQuery, opts *options.FindOptions) ([]*T, *CursorResult, error) {
+	defer q.Close()
+	if err := cursor.normalize(); err != nil {
+		return nil, nil, err
+	}
+	keyset, err := cursor.filter(q.cursors)
+	if err != nil {
+		q.logger.Error("[ MONGO ] - Invalid cursor for FindCursorPaginated query: %v", err)
+		return nil, nil, err
+	}
+	if keyset != nil {
+		filter = bson.M{"$and": bson.A{filter, keyset}}
+	}
+
+	if opts == nil {
+		opts = options.Find()
+	}
+	opts.SetSort(cursor.sort())
+	opts.SetSkip(0)
+	opts.SetLimit(cursor.Limit + 1)
+	q.logger.Info("[ MONGO ] - Executing FindCursorPaginated query with filter: %v, limit: %d", filter, cursor.Limit)
+	mongoCursor, err := q.collection.Find(q.context, filter, opts)
+	if err != nil {
+		q.logger.Error("[ MONGO ] - Error executing FindCursorPaginated query: %v", err)
+		return nil, nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer mongoCursor.Close(q.context)
+
+	var docs []*T
+	var last bson.Raw
+	result := &CursorResult{}
+	for mongoCursor.Next(q.context) {
+		if int64(len(docs)) == cursor.Limit {
+			result.HasMore = true
+			break
+		}
+		var doc T
+		if err := mongoCursor.Decode(&doc); err != nil {
+			q.logger.Error("[ MONGO ] - Error decoding result: %v", err)
+			return nil, nil, fmt.Errorf("error decoding result: %w", err)
+		}
+		docs = append(docs, &doc)
+		last = append(last[:0], mongoCursor.Current...)
+	}
+
+	if err := mongoCursor.Err(); err != nil {
+		q.logger.Error("[ MONGO ] - Cursor error: %v", err)
+		return nil, nil, fmt.Errorf("cursor error: %w", err)
+	}
+
+	if result.HasMore {
+		if result.NextCursor, err = cursor.next(q.cursors, last); err != nil {
+			q.logger.Error("[ MONGO ] - Error building next cursor: %v", err)
+			return nil, nil, err
+		}
+	}
+	q.logger.Info("[ MONGO ] - FindCursorPaginated query executed successfully, retrieved %d documents", len(docs))
+	return docs, result, nil
+}
+
 func (q *query[T]) FindOneAndUpdate(filter bson.M, update bson.M) (*T, error) {
 	defer q.Close()
 	q.logger.Info("[ MONGO ] - Executing FindOneAndUpdate query with filter: %v, update: %v", filter, update)
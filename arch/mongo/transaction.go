@@ -59,8 +59,8 @@ const DefaultShortTransactionTimeout = 30 * time.Second
 const DefaultTransactionTimeout = 1 * time.Minute
 const DefaultLongTransactionTimeout = 5 * time.Minute
 
-func newTransaction(logger utils.AppLogger, client *mongo.Client, database string, timeout time.Duration) Transaction {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+func newTransaction(logger utils.AppLogger, ctx context.Context, client *mongo.Client, database string, timeout time.Duration) Transaction {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	return &transaction{
 		logger:     logger,
 		database:   database,
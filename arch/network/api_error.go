@@ -166,11 +166,21 @@ func NewUnauthorizedError(message string, detail string, err error) ApiError {
 	return newApiError(http.StatusUnauthorized, message, detail, UnauthorizedErrorCode, err)
 }
 
+// 401 Unauthorized - Same as NewUnauthorizedError but with a domain specific error code
+func NewUnauthorizedErrorWithCode(message string, detail string, errCode string, err error) ApiError {
+	return newApiError(http.StatusUnauthorized, message, detail, errCode, err)
+}
+
 // 403 Forbidden - Valid auth but insufficient permissions
 func NewForbiddenError(message string, detail string, err error) ApiError {
 	return newApiError(http.StatusForbidden, message, detail, ForbiddenErrorCode, err)
 }
 
+// 403 Forbidden - Same as NewForbiddenError but with a domain specific error code
+func NewForbiddenErrorWithCode(message string, detail string, errCode string, err error) ApiError {
+	return newApiError(http.StatusForbidden, message, detail, errCode, err)
+}
+
 // 404 Not Found - Resource doesn't exist
 func NewNotFoundError(message string, detail string, err error) ApiError {
 	return newApiError(http.StatusNotFound, message, detail, NotFoundErrorCode, err)
@@ -231,6 +241,11 @@ func NewTooManyRequestsError(message string, detail string, err error) ApiError
 	return newApiError(http.StatusTooManyRequests, message, detail, TooManyRequestsErrorCode, err)
 }
 
+// 429 Too Many Requests - Same as NewTooManyRequestsError but with a domain specific error code
+func NewTooManyRequestsErrorWithCode(message string, detail string, errCode string, err error) ApiError {
+	return newApiError(http.StatusTooManyRequests, message, detail, errCode, err)
+}
+
 // 500 Internal Server Error - Unexpected server error
 func NewInternalServerError(message string, detail string, errCode string, err error) ApiError {
 	return newApiError(http.StatusInternalServerError, message, detail, errCode, err)
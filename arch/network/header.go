@@ -11,6 +11,12 @@ const (
 	DeviceModelHeader   = "X-Device-Model"
 	DeviceVersionHeader = "X-Device-Version"
 
+	// Web clients opt into the cookie transport of the tokens with TokenTransportHeader: CookieTransport,
+	// their unsafe requests then carry the CSRF token in CSRFTokenHeader
+	TokenTransportHeader = "X-Token-Transport"
+	CookieTransport      = "cookie"
+	CSRFTokenHeader      = "X-CSRF-Token"
+
 	DefaultDeviceId      = "default-device-id"
 	DefaultDeviceName    = "default-device-name"
 	DefaultDeviceType    = "default-device-type"
@@ -19,4 +25,5 @@ const (
 	DefaultDeviceVersion = "default-device-version"
 
 	UserLocation = "UserLocation"
+	PlatformRole = "PlatformRole"
 )
@@ -105,13 +105,18 @@ type ParamNMiddlewareProvider[T any] interface {
 
 type AuthenticationProvider Param0MiddlewareProvider
 type LocationProvider Param0MiddlewareProvider
+type EmailVerificationProvider Param0MiddlewareProvider
 type AuthorizationProvider ParamNMiddlewareProvider[string]
+type RateLimitProvider Param1MiddlewareProvider[string]
+type IdempotencyProvider Param0MiddlewareProvider
+type CSRFProvider Param0MiddlewareProvider
 
 type BaseRouter interface {
 	GetEngine() *gin.Engine
 	RegisterValidationParsers(tagNameFunc validator.TagNameFunc)
 	LoadRootMiddlewares(middlewares []RootMiddleware)
 	Start(ip string, port uint16) error
+	Shutdown(ctx context.Context) error
 }
 
 type Router interface {
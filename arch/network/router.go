@@ -1,7 +1,11 @@
 package network
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"sync-backend/arch/config"
 	"sync-backend/utils"
 
 	"github.com/gin-gonic/gin"
@@ -11,11 +15,12 @@ import (
 
 type router struct {
 	engine  *gin.Engine
+	server  *http.Server
 	prefix  string
 	version int
 }
 
-func NewRouter(env string, prefix string, version int, appLogger utils.AppLogger) Router {
+func NewRouter(env string, prefix string, version int, serverConfig config.ServerConfig, appLogger utils.AppLogger) Router {
 	var mode string
 	switch env {
 	case "debug":
@@ -33,11 +38,8 @@ func NewRouter(env string, prefix string, version int, appLogger utils.AppLogger
 		gin.DefaultErrorWriter = &logWriter{appLogger: appLogger, level: "error"}
 	}
 	eng := gin.New()
-	eng.Use(gin.LoggerWithConfig(gin.LoggerConfig{
-		Formatter: func(param gin.LogFormatterParams) string {
-			return LoggerFormatter(appLogger, gin.DebugMode == "debug")(param)
-		},
-	}))
+	// lets *gin.Context be passed as a context.Context, e.g. to carry the request id into log lines
+	eng.ContextWithFallback = true
 	eng.Use(gin.Recovery())
 	eng.Use(gin.ErrorLogger())
 
@@ -46,7 +48,15 @@ func NewRouter(env string, prefix string, version int, appLogger utils.AppLogger
 	eng.NoRoute(NotFound())
 
 	r := router{
-		engine:  eng,
+		engine: eng,
+		// Zero timeouts leave the corresponding limit off, as with a bare http.Server
+		server: &http.Server{
+			Handler:        eng,
+			ReadTimeout:    serverConfig.ReadTimeout,
+			WriteTimeout:   serverConfig.WriteTimeout,
+			IdleTimeout:    serverConfig.IdleTimeout,
+			MaxHeaderBytes: serverConfig.MaxHeaderBytes,
+		},
 		prefix:  prefix,
 		version: version,
 	}
@@ -70,14 +80,20 @@ func (r *router) LoadControllers(controllers []Controller) {
 	}
 }
 
+// Start serves until Shutdown is called, which makes it return nil
 func (r *router) Start(ip string, port uint16) error {
-	address := fmt.Sprintf("%s:%d", ip, port)
-	if err := r.engine.Run(address); err != nil {
+	r.server.Addr = fmt.Sprintf("%s:%d", ip, port)
+	if err := r.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("failed to start server: %w", err)
 	}
 	return nil
 }
 
+// Shutdown stops accepting connections and waits for in-flight requests until the context is done
+func (r *router) Shutdown(ctx context.Context) error {
+	return r.server.Shutdown(ctx)
+}
+
 func (r *router) RegisterValidationParsers(tagNameFunc validator.TagNameFunc) {
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterTagNameFunc(tagNameFunc)
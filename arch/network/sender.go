@@ -1,6 +1,7 @@
 package network
 
 import (
+	"context"
 	"errors"
 
 	"github.com/gin-gonic/gin"
@@ -166,6 +167,11 @@ func (s *send) sendResponse(response Response) {
 }
 
 func (s *send) sendError(err ApiError) {
+	// A query cut short by the request deadline surfaces as a server error, report it as the timeout it is
+	if err.GetStatusCode() >= 500 && s.context.Request != nil &&
+		errors.Is(s.context.Request.Context().Err(), context.DeadlineExceeded) {
+		err = NewRequestTimeoutError("request timed out", "The request did not complete within the allowed time", err)
+	}
 	res := NewEnvelopeWithErrors(false, err.GetStatusCode(), err.GetMessage(), err.GetErrors(s.debug))
 	s.sendResponse(res)
 }
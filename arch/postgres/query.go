@@ -4,9 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
 	"reflect"
+	"sync-backend/arch/tracing"
 	"sync-backend/utils"
 	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Query[T any] interface {
@@ -134,49 +139,71 @@ func (q *query[T]) scanRows(rows *sql.Rows) ([]*T, error) {
 }
 
 func (q *query[T]) ExecContext(query string, args ...any) (sql.Result, error) {
+	ctx, span := startSpan(q.context, "exec", query)
+	defer span.End()
+
 	start := time.Now()
-	result, err := q.db.ExecContext(q.context, query, args...)
+	result, err := q.db.ExecContext(ctx, query, args...)
 	elapsed := time.Since(start)
+	tracing.RecordError(span, err)
 
-	q.logger.Debug("ExecContext", map[string]interface{}{
-		"query":    query,
-		"args":     args,
-		"duration": elapsed,
-		"error":    err,
-	})
+	q.logger.LogAttrs(utils.DebugLevel, "ExecContext",
+		slog.String("query", query),
+		slog.Any("args", args),
+		slog.Duration("duration", elapsed),
+		slog.Any("error", err),
+	)
 
 	return result, err
 }
 
 func (q *query[T]) QueryContext(query string, args ...any) (*sql.Rows, error) {
+	ctx, span := startSpan(q.context, "query", query)
+	defer span.End()
+
 	start := time.Now()
-	rows, err := q.db.QueryContext(q.context, query, args...)
+	rows, err := q.db.QueryContext(ctx, query, args...)
 	elapsed := time.Since(start)
+	tracing.RecordError(span, err)
 
-	q.logger.Debug("QueryContext", map[string]interface{}{
-		"query":    query,
-		"args":     args,
-		"duration": elapsed,
-		"error":    err,
-	})
+	q.logger.LogAttrs(utils.DebugLevel, "QueryContext",
+		slog.String("query", query),
+		slog.Any("args", args),
+		slog.Duration("duration", elapsed),
+		slog.Any("error", err),
+	)
 
 	return rows, err
 }
 
 func (q *query[T]) QueryRowContext(query string, args ...any) *sql.Row {
+	ctx, span := startSpan(q.context, "query_row", query)
+	defer span.End()
+
 	start := time.Now()
-	row := q.db.QueryRowContext(q.context, query, args...)
+	row := q.db.QueryRowContext(ctx, query, args...)
 	elapsed := time.Since(start)
+	if err := row.Err(); err != sql.ErrNoRows {
+		tracing.RecordError(span, err)
+	}
 
-	q.logger.Debug("QueryRowContext", map[string]interface{}{
-		"query":    query,
-		"args":     args,
-		"duration": elapsed,
-	})
+	q.logger.LogAttrs(utils.DebugLevel, "QueryRowContext",
+		slog.String("query", query),
+		slog.Any("args", args),
+		slog.Duration("duration", elapsed),
+	)
 
 	return row
 }
 
+// startSpan traces a statement, the text is recorded without its arguments
+func startSpan(ctx context.Context, operation string, query string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "postgres."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBOperationName(operation), semconv.DBQueryText(query)),
+	)
+}
+
 func (q *query[T]) FindOne(query string, args ...any) (*T, error) {
 	row := q.QueryRowContext(query, args...)
 	return q.scanRow(row)
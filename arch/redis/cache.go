@@ -1,89 +1,392 @@
 package redis
 
-// import (
-// 	"context"
-// 	"encoding/json"
-// 	"time"
-// )
-
-// type Cache[T any] interface {
-// 	SetJSON(key string, value *T, expiration time.Duration) error
-// 	GetJSON(key string) (*T, error)
-// 	SetJSONList(key string, values []*T, expiration time.Duration) error
-// 	GetJSONList(key string) ([]*T, error)
-// }
-
-// type cache[T any] struct {
-// 	context context.Context
-// 	store   Store
-// }
-
-// func NewCache[T any](store Store) Cache[T] {
-// 	return &cache[T]{
-// 		context: context.Background(),
-// 		store:   store,
-// 	}
-// }
-
-// func (c *cache[T]) SetJSON(key string, value *T, expiration time.Duration) error {
-// 	data, err := json.Marshal(value)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	return c.store.GetInstance().Set(c.context, key, data, expiration).Err()
-// }
-
-// func (c *cache[T]) GetJSON(key string) (*T, error) {
-// 	data, err := c.store.GetInstance().Get(c.context, key).Bytes()
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	var dest T
-// 	err = json.Unmarshal(data, &dest)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	return &dest, nil
-// }
-
-// func (c *cache[T]) SetJSONList(key string, values []*T, expiration time.Duration) error {
-// 	var list []json.RawMessage
-// 	for _, value := range values {
-// 		data, err := json.Marshal(value)
-// 		if err != nil {
-// 			return err
-// 		}
-// 		list = append(list, data)
-// 	}
-
-// 	str, err := json.Marshal(list)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	return c.store.GetInstance().Set(c.context, key, str, expiration).Err()
-// }
-
-// func (c *cache[T]) GetJSONList(key string) ([]*T, error) {
-// 	str, err := c.store.GetInstance().Get(c.context, key).Result()
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	var list []json.RawMessage
-// 	if err := json.Unmarshal([]byte(str), &list); err != nil {
-// 		return nil, err
-// 	}
-
-// 	dest := make([]*T, len(list))
-// 	for i, data := range list {
-// 		if err := json.Unmarshal(data, &dest[i]); err != nil {
-// 			return nil, err
-// 		}
-// 	}
-
-// 	return dest, nil
-// }
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"sync-backend/arch/metrics"
+	"sync-backend/utils"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/ugorji/go/codec"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrCacheMiss is returned when a key is not cached
+var ErrCacheMiss = errors.New("cache miss")
+
+// Codec turns cached values into bytes and back
+type Codec interface {
+	Marshal(value any) ([]byte, error)
+	Unmarshal(data []byte, value any) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(value any) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (jsonCodec) Unmarshal(data []byte, value any) error {
+	return json.Unmarshal(data, value)
+}
+
+type msgpackCodec struct {
+	handle *codec.MsgpackHandle
+}
+
+func (c msgpackCodec) Marshal(value any) ([]byte, error) {
+	var data []byte
+	err := codec.NewEncoderBytes(&data, c.handle).Encode(value)
+	return data, err
+}
+
+func (c msgpackCodec) Unmarshal(data []byte, value any) error {
+	return codec.NewDecoderBytes(data, c.handle).Decode(value)
+}
+
+var (
+	// JSONCodec follows the json tags, fields hidden from the API such as password hashes are not cached
+	JSONCodec Codec = jsonCodec{}
+	// MsgpackCodec follows the bson tags, so a stored entity round-trips with every field it has in Mongo
+	MsgpackCodec Codec = msgpackCodec{handle: &codec.MsgpackHandle{
+		WriteExt:    true,
+		BasicHandle: codec.BasicHandle{TypeInfos: codec.NewTypeInfos([]string{"bson"})},
+	}}
+)
+
+// CacheStore holds cached bytes and the tags they were stored under
+type CacheStore interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	// AddToTag records key under tag and resets the expiry of the tag to ttl
+	AddToTag(ctx context.Context, tag string, key string, ttl time.Duration) error
+	// InvalidateTag deletes every key recorded under tag and the tag itself, and stamps the tag with
+	// the next invalidation generation
+	InvalidateTag(ctx context.Context, tag string) error
+	// Generation returns the current invalidation generation, it is read before loading a value
+	Generation(ctx context.Context) (int64, error)
+	// SetLoaded tags and stores a value loaded after generation was read, unless one of its tags was
+	// invalidated since. It reports whether the value was stored.
+	SetLoaded(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string, tagTTL time.Duration, generation int64) (bool, error)
+}
+
+// invalidationWindow is how long the generation of an invalidation is kept, loads running longer can
+// still store a value read before it
+const invalidationWindow = time.Hour
+
+type redisCacheStore struct {
+	client *redis.Client
+}
+
+func NewCacheStore(store Store) CacheStore {
+	return &redisCacheStore{client: store.GetInstance().Client}
+}
+
+func (s *redisCacheStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrCacheMiss
+	}
+	return data, err
+}
+
+func (s *redisCacheStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (s *redisCacheStore) Delete(ctx context.Context, keys ...string) error {
+	return s.client.Del(ctx, keys...).Err()
+}
+
+func (s *redisCacheStore) AddToTag(ctx context.Context, tag string, key string, ttl time.Duration) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, tagKey(tag), key)
+		pipe.Expire(ctx, tagKey(tag), ttl)
+		return nil
+	})
+	return err
+}
+
+// invalidateTagScript reads and deletes the members atomically, a key tagged in between would otherwise
+// be dropped from the set but survive the invalidation
+var invalidateTagScript = redis.NewScript(`
+local keys = redis.call('SMEMBERS', KEYS[1])
+for i = 1, #keys, 500 do
+	redis.call('DEL', unpack(keys, i, math.min(i + 499, #keys)))
+end
+local generation = redis.call('INCR', KEYS[2])
+redis.call('SET', KEYS[3], generation, 'PX', ARGV[1])
+return redis.call('DEL', KEYS[1])
+`)
+
+func (s *redisCacheStore) InvalidateTag(ctx context.Context, tag string) error {
+	keys := []string{tagKey(tag), generationKey, invalidatedKey(tag)}
+	return invalidateTagScript.Run(ctx, s.client, keys, invalidationWindow.Milliseconds()).Err()
+}
+
+func (s *redisCacheStore) Generation(ctx context.Context) (int64, error) {
+	generation, err := s.client.Get(ctx, generationKey).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return generation, err
+}
+
+// setLoadedScript checks the tags and writes the value atomically, an invalidation landing between
+// the check and the write would otherwise be missed. KEYS are the value key, the tag sets and the
+// invalidation generations of the tags.
+var setLoadedScript = redis.NewScript(`
+local tags = (#KEYS - 1) / 2
+for i = 1, tags do
+	if tonumber(redis.call('GET', KEYS[1 + tags + i]) or '0') > tonumber(ARGV[1]) then
+		return 0
+	end
+end
+for i = 1, tags do
+	redis.call('SADD', KEYS[1 + i], KEYS[1])
+	redis.call('PEXPIRE', KEYS[1 + i], ARGV[2])
+end
+redis.call('SET', KEYS[1], ARGV[3], 'PX', ARGV[4])
+return 1
+`)
+
+func (s *redisCacheStore) SetLoaded(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string, tagTTL time.Duration, generation int64) (bool, error) {
+	keys := make([]string, 1, 1+2*len(tags))
+	keys[0] = key
+	for _, tag := range tags {
+		keys = append(keys, tagKey(tag))
+	}
+	for _, tag := range tags {
+		keys = append(keys, invalidatedKey(tag))
+	}
+	stored, err := setLoadedScript.Run(ctx, s.client, keys, generation, tagTTL.Milliseconds(), value, ttl.Milliseconds()).Int()
+	return stored == 1, err
+}
+
+// generationKey counts the invalidations, each invalidated tag keeps the count it was invalidated at
+const generationKey = "cache:generation"
+
+func tagKey(tag string) string {
+	return "cache:tag:" + tag
+}
+
+func invalidatedKey(tag string) string {
+	return "cache:invalidated:" + tag
+}
+
+// Cache is a typed read-through cache. Values are written under their tags, so a write path invalidates
+// every cached view of an entity with the entity tag, whichever cache holds it.
+type Cache[T any] interface {
+	Get(ctx context.Context, key string) (*T, error)
+	Set(ctx context.Context, key string, value *T, tags ...string) error
+	// GetOrLoad returns the cached value or loads and caches it. Concurrent misses of a key share one load,
+	// a nil value is returned without being cached.
+	GetOrLoad(ctx context.Context, key string, load func(ctx context.Context) (*T, error), tags ...string) (*T, error)
+	Delete(ctx context.Context, keys ...string) error
+	Invalidate(ctx context.Context, tags ...string) error
+}
+
+type CacheOptions struct {
+	// Name prefixes the keys and labels the metrics
+	Name  string
+	TTL   time.Duration
+	Codec Codec
+	// Jitter spreads expiries by up to this fraction of the TTL either way, so that entries cached
+	// together do not all expire together
+	Jitter float64
+	// ValueTags adds tags derived from the cached value to the ones given by the caller, for entities
+	// embedding other entities whose tags are only known once the value is loaded
+	ValueTags func(value any) []string
+}
+
+type cache[T any] struct {
+	logger  utils.AppLogger
+	store   CacheStore
+	options CacheOptions
+	group   singleflight.Group
+}
+
+func NewCache[T any](store CacheStore, options CacheOptions) Cache[T] {
+	if options.Codec == nil {
+		options.Codec = MsgpackCodec
+	}
+	return &cache[T]{
+		logger:  utils.NewServiceLogger("Cache"),
+		store:   store,
+		options: options,
+	}
+}
+
+func (c *cache[T]) key(key string) string {
+	return "cache:" + c.options.Name + ":" + key
+}
+
+func (c *cache[T]) ttl() time.Duration {
+	if c.options.Jitter <= 0 {
+		return c.options.TTL
+	}
+	spread := float64(c.options.TTL) * c.options.Jitter
+	return c.options.TTL + time.Duration(spread*(2*rand.Float64()-1))
+}
+
+func (c *cache[T]) Get(ctx context.Context, key string) (*T, error) {
+	data, err := c.store.Get(ctx, c.key(key))
+	if err != nil {
+		return nil, err
+	}
+	return c.decode(data)
+}
+
+func (c *cache[T]) decode(data []byte) (*T, error) {
+	var value T
+	if err := c.options.Codec.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+func (c *cache[T]) Set(ctx context.Context, key string, value *T, tags ...string) error {
+	data, err := c.options.Codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.write(ctx, c.key(key), data, c.tags(value, tags))
+}
+
+func (c *cache[T]) tags(value *T, tags []string) []string {
+	if c.options.ValueTags == nil {
+		return tags
+	}
+	return append(tags[:len(tags):len(tags)], c.options.ValueTags(value)...)
+}
+
+// write tags the key before storing it, a value whose tagging failed could never be invalidated
+func (c *cache[T]) write(ctx context.Context, key string, data []byte, tags []string) error {
+	for _, tag := range tags {
+		if err := c.store.AddToTag(ctx, tag, key, c.tagTTL()); err != nil {
+			return err
+		}
+	}
+	return c.store.Set(ctx, key, data, c.ttl())
+}
+
+// tagTTL is the longest TTL an entry can get, so tags never expire before one of their keys
+func (c *cache[T]) tagTTL() time.Duration {
+	return c.options.TTL + time.Duration(float64(c.options.TTL)*max(c.options.Jitter, 0))
+}
+
+func (c *cache[T]) GetOrLoad(ctx context.Context, key string, load func(ctx context.Context) (*T, error), tags ...string) (*T, error) {
+	data, err := c.store.Get(ctx, c.key(key))
+	if err == nil {
+		value, decodeErr := c.decode(data)
+		if decodeErr == nil {
+			metrics.CacheLookup(c.options.Name, metrics.CacheHit)
+			return value, nil
+		}
+		c.logger.WithContext(ctx).Warn("Reloading undecodable %s cache entry %s: %v", c.options.Name, key, decodeErr)
+	}
+	if err != nil && !errors.Is(err, ErrCacheMiss) {
+		// The cache is an optimisation, a broken store falls through to the loader
+		metrics.CacheLookup(c.options.Name, metrics.CacheError)
+		c.logger.WithContext(ctx).Warn("Reading %s cache entry %s failed: %v", c.options.Name, key, err)
+	} else {
+		metrics.CacheLookup(c.options.Name, metrics.CacheMiss)
+	}
+
+	// The shared load must not fail for every waiter when the request that started it goes away
+	loadCtx := context.WithoutCancel(ctx)
+	result := c.group.DoChan(key, func() (any, error) {
+		// A write path invalidating while the load reads the old value must win, the value is only
+		// stored when none of its tags was invalidated after this generation
+		generation, generationErr := c.store.Generation(loadCtx)
+		value, err := load(loadCtx)
+		if err != nil || value == nil {
+			return nil, err
+		}
+		data, err := c.options.Codec.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if generationErr != nil {
+			c.logger.WithContext(loadCtx).Warn("Reading the cache generation for %s entry %s failed: %v", c.options.Name, key, generationErr)
+			return data, nil
+		}
+		stored, err := c.store.SetLoaded(loadCtx, c.key(key), data, c.ttl(), c.tags(value, tags), c.tagTTL(), generation)
+		switch {
+		case err != nil:
+			c.logger.WithContext(loadCtx).Warn("Writing %s cache entry %s failed: %v", c.options.Name, key, err)
+		case !stored:
+			c.logger.WithContext(loadCtx).Debug("Skipped caching %s entry %s, it was invalidated while loading", c.options.Name, key)
+		}
+		return data, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-result:
+		if res.Err != nil || res.Val == nil {
+			return nil, res.Err
+		}
+		// Every waiter decodes its own copy, the loaded value may be modified by the caller
+		return c.decode(res.Val.([]byte))
+	}
+}
+
+func (c *cache[T]) Delete(ctx context.Context, keys ...string) error {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.key(key)
+	}
+	return c.store.Delete(ctx, prefixed...)
+}
+
+func (c *cache[T]) Invalidate(ctx context.Context, tags ...string) error {
+	var errs []error
+	for _, tag := range tags {
+		if err := c.store.InvalidateTag(ctx, tag); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type noopCacheStore struct{}
+
+// NewNoopCacheStore caches nothing, it stands in for the Redis store when caching is disabled
+func NewNoopCacheStore() CacheStore {
+	return noopCacheStore{}
+}
+
+func (noopCacheStore) Get(context.Context, string) ([]byte, error) {
+	return nil, ErrCacheMiss
+}
+
+func (noopCacheStore) Set(context.Context, string, []byte, time.Duration) error {
+	return nil
+}
+
+func (noopCacheStore) Delete(context.Context, ...string) error {
+	return nil
+}
+
+func (noopCacheStore) AddToTag(context.Context, string, string, time.Duration) error {
+	return nil
+}
+
+func (noopCacheStore) InvalidateTag(context.Context, string) error {
+	return nil
+}
+
+func (noopCacheStore) Generation(context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (noopCacheStore) SetLoaded(context.Context, string, []byte, time.Duration, []string, time.Duration, int64) (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,180 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type cachedEntity struct {
+	Id           primitive.ObjectID  `bson:"_id,omitempty" json:"-"`
+	EntityId     string              `bson:"entityId" json:"id"`
+	PasswordHash string              `bson:"passwordHash" json:"-"`
+	Tags         []string            `bson:"tags" json:"tags"`
+	CreatedAt    primitive.DateTime  `bson:"createdAt" json:"createdAt"`
+	DeletedAt    *primitive.DateTime `bson:"deletedAt,omitempty" json:"-"`
+}
+
+func TestMsgpackCodecKeepsStoredFields(t *testing.T) {
+	deletedAt := primitive.NewDateTimeFromTime(time.Now())
+	entity := cachedEntity{
+		Id:           primitive.NewObjectID(),
+		EntityId:     "e1",
+		PasswordHash: "hash",
+		Tags:         []string{"go"},
+		CreatedAt:    primitive.NewDateTimeFromTime(time.Now()),
+		DeletedAt:    &deletedAt,
+	}
+
+	data, err := MsgpackCodec.Marshal(&entity)
+	require.NoError(t, err)
+	var decoded cachedEntity
+	require.NoError(t, MsgpackCodec.Unmarshal(data, &decoded))
+	assert.Equal(t, entity, decoded)
+
+	data, err = JSONCodec.Marshal(&entity)
+	require.NoError(t, err)
+	decoded = cachedEntity{}
+	require.NoError(t, JSONCodec.Unmarshal(data, &decoded))
+	assert.Empty(t, decoded.PasswordHash)
+}
+
+func TestGetOrLoadCachesAndInvalidatesByTag(t *testing.T) {
+	ctx := context.Background()
+	store := NewMockCacheStore()
+	cache := NewCache[cachedEntity](store, CacheOptions{Name: "entity", TTL: time.Minute, Jitter: 0.1})
+
+	var loads atomic.Int32
+	load := func(context.Context) (*cachedEntity, error) {
+		loads.Add(1)
+		return &cachedEntity{EntityId: "e1"}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		entity, err := cache.GetOrLoad(ctx, "e1", load, "entity:e1")
+		require.NoError(t, err)
+		assert.Equal(t, "e1", entity.EntityId)
+	}
+	assert.Equal(t, int32(1), loads.Load())
+
+	require.NoError(t, cache.Invalidate(ctx, "entity:e1"))
+	_, err := cache.Get(ctx, "e1")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	_, err = cache.GetOrLoad(ctx, "e1", load, "entity:e1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), loads.Load())
+}
+
+func TestValueTagsInvalidateEmbeddedEntities(t *testing.T) {
+	ctx := context.Background()
+	store := NewMockCacheStore()
+	cache := NewCache[cachedEntity](store, CacheOptions{
+		Name: "entity",
+		TTL:  time.Minute,
+		ValueTags: func(value any) []string {
+			return value.(*cachedEntity).Tags
+		},
+	})
+
+	load := func(context.Context) (*cachedEntity, error) {
+		return &cachedEntity{EntityId: "e1", Tags: []string{"owner:o1"}}, nil
+	}
+	_, err := cache.GetOrLoad(ctx, "e1", load, "entity:e1")
+	require.NoError(t, err)
+	require.NoError(t, cache.Set(ctx, "e2", &cachedEntity{EntityId: "e2", Tags: []string{"owner:o1"}}, "entity:e2"))
+
+	require.NoError(t, store.InvalidateTag(ctx, "owner:o1"))
+	_, err = cache.Get(ctx, "e1")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+	_, err = cache.Get(ctx, "e2")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestGetOrLoadSharesConcurrentLoads(t *testing.T) {
+	cache := NewCache[cachedEntity](NewMockCacheStore(), CacheOptions{Name: "entity", TTL: time.Minute})
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(context.Context) (*cachedEntity, error) {
+		loads.Add(1)
+		<-release
+		return &cachedEntity{EntityId: "e1"}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]*cachedEntity, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = cache.GetOrLoad(context.Background(), "e1", load)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), loads.Load())
+	for _, result := range results {
+		require.NotNil(t, result)
+		assert.Equal(t, "e1", result.EntityId)
+	}
+	// Waiters get their own copies
+	assert.NotSame(t, results[0], results[1])
+}
+
+func TestGetOrLoadDoesNotCacheFailures(t *testing.T) {
+	store := NewMockCacheStore()
+	cache := NewCache[cachedEntity](store, CacheOptions{Name: "entity", TTL: time.Minute})
+	errNotFound := errors.New("not found")
+
+	_, err := cache.GetOrLoad(context.Background(), "e1", func(context.Context) (*cachedEntity, error) {
+		return nil, errNotFound
+	})
+	assert.ErrorIs(t, err, errNotFound)
+
+	entity, err := cache.GetOrLoad(context.Background(), "e2", func(context.Context) (*cachedEntity, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, entity)
+	assert.Zero(t, store.Len())
+}
+
+func TestGetOrLoadDropsValuesInvalidatedWhileLoading(t *testing.T) {
+	ctx := context.Background()
+	store := NewMockCacheStore()
+	cache := NewCache[cachedEntity](store, CacheOptions{
+		Name: "entity",
+		TTL:  time.Minute,
+		ValueTags: func(value any) []string {
+			return value.(*cachedEntity).Tags
+		},
+	})
+
+	// The write path invalidates after the load read the old value but before it is cached
+	for _, tag := range []string{"entity:e1", "owner:o1"} {
+		entity, err := cache.GetOrLoad(ctx, "e1", func(context.Context) (*cachedEntity, error) {
+			require.NoError(t, store.InvalidateTag(ctx, tag))
+			return &cachedEntity{EntityId: "e1", Tags: []string{"owner:o1"}}, nil
+		}, "entity:e1")
+		require.NoError(t, err)
+		assert.Equal(t, "e1", entity.EntityId)
+		assert.Zero(t, store.Len(), "invalidated %s", tag)
+	}
+
+	// Invalidations before the load started do not keep it out of the cache
+	_, err := cache.GetOrLoad(ctx, "e1", func(context.Context) (*cachedEntity, error) {
+		return &cachedEntity{EntityId: "e1", Tags: []string{"owner:o1"}}, nil
+	}, "entity:e1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.Len())
+}
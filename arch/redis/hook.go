@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"sync-backend/arch/metrics"
+	"sync-backend/arch/tracing"
+
+	"github.com/redis/go-redis/v9"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationHook records the latency and errors of every command sent through the client
+// and traces it as a child span of the context the command ran with
+type instrumentationHook struct{}
+
+func (instrumentationHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (instrumentationHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := startSpan(ctx, cmd.Name())
+		defer span.End()
+
+		start := time.Now()
+		err := next(ctx, cmd)
+		failed := isCommandError(err)
+		metrics.ObserveRedisCommand(cmd.Name(), time.Since(start), failed)
+		if failed {
+			tracing.RecordError(span, err)
+		}
+		return err
+	}
+}
+
+func (instrumentationHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := startSpan(ctx, "pipeline")
+		defer span.End()
+
+		start := time.Now()
+		err := next(ctx, cmds)
+		failed := isCommandError(err)
+		metrics.ObserveRedisCommand("pipeline", time.Since(start), failed)
+		if failed {
+			tracing.RecordError(span, err)
+		}
+		return err
+	}
+}
+
+func startSpan(ctx context.Context, command string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "redis."+command,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperationName(command)),
+	)
+}
+
+func isCommandError(err error) bool {
+	return err != nil && !errors.Is(err, redis.Nil)
+}
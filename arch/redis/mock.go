@@ -0,0 +1,115 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type mockEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// MockCacheStore keeps the cache in memory so that cached services can be tested without Redis
+type MockCacheStore struct {
+	mu          sync.Mutex
+	entries     map[string]mockEntry
+	tags        map[string]map[string]struct{}
+	generation  int64
+	invalidated map[string]int64
+}
+
+func NewMockCacheStore() *MockCacheStore {
+	return &MockCacheStore{
+		entries:     map[string]mockEntry{},
+		tags:        map[string]map[string]struct{}{},
+		invalidated: map[string]int64{},
+	}
+}
+
+func (s *MockCacheStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, ErrCacheMiss
+	}
+	return entry.value, nil
+}
+
+func (s *MockCacheStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = mockEntry{value: value, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MockCacheStore) Delete(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+func (s *MockCacheStore) AddToTag(_ context.Context, tag string, key string, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addToTag(tag, key)
+	return nil
+}
+
+func (s *MockCacheStore) addToTag(tag string, key string) {
+	if s.tags[tag] == nil {
+		s.tags[tag] = map[string]struct{}{}
+	}
+	s.tags[tag][key] = struct{}{}
+}
+
+func (s *MockCacheStore) InvalidateTag(_ context.Context, tag string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.tags[tag] {
+		delete(s.entries, key)
+	}
+	delete(s.tags, tag)
+	s.generation++
+	s.invalidated[tag] = s.generation
+	return nil
+}
+
+func (s *MockCacheStore) Generation(context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generation, nil
+}
+
+func (s *MockCacheStore) SetLoaded(_ context.Context, key string, value []byte, ttl time.Duration, tags []string, _ time.Duration, generation int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tag := range tags {
+		if s.invalidated[tag] > generation {
+			return false, nil
+		}
+	}
+	for _, tag := range tags {
+		s.addToTag(tag, key)
+	}
+	s.entries[key] = mockEntry{value: value, expiresAt: time.Now().Add(ttl)}
+	return true, nil
+}
+
+// Len is the number of live entries
+func (s *MockCacheStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	live := 0
+	for _, entry := range s.entries {
+		if time.Now().Before(entry.expiresAt) {
+			live++
+		}
+	}
+	return live
+}
@@ -33,6 +33,7 @@ func NewStore(context context.Context, logger utils.AppLogger, config *Config) S
 		Password: config.Pwd,
 		DB:       config.DB,
 	})
+	client.AddHook(instrumentationHook{})
 	return &store{
 		context: context,
 		logger:  logger,
@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"sync-backend/utils"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCacheStore(t *testing.T) CacheStore {
+	server := miniredis.RunT(t)
+	port, err := strconv.Atoi(server.Port())
+	require.NoError(t, err)
+	store := NewStore(context.Background(), utils.NewServiceLogger("CacheStoreTest"), &Config{
+		Host: server.Host(),
+		Port: uint16(port),
+	})
+	t.Cleanup(store.Disconnect)
+	return NewCacheStore(store)
+}
+
+func TestRedisCacheStoreSkipsValuesLoadedBeforeAnInvalidation(t *testing.T) {
+	ctx := context.Background()
+	store := newTestCacheStore(t)
+
+	generation, err := store.Generation(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, generation)
+
+	stored, err := store.SetLoaded(ctx, "cache:entity:e1", []byte("v1"), time.Minute, []string{"entity:e1", "owner:o1"}, time.Minute, generation)
+	require.NoError(t, err)
+	assert.True(t, stored)
+
+	// Invalidating a tag drops the value and stamps the tag past the generation the load started at
+	require.NoError(t, store.InvalidateTag(ctx, "owner:o1"))
+	_, err = store.Get(ctx, "cache:entity:e1")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	stored, err = store.SetLoaded(ctx, "cache:entity:e1", []byte("stale"), time.Minute, []string{"entity:e1", "owner:o1"}, time.Minute, generation)
+	require.NoError(t, err)
+	assert.False(t, stored)
+	_, err = store.Get(ctx, "cache:entity:e1")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	generation, err = store.Generation(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), generation)
+	stored, err = store.SetLoaded(ctx, "cache:entity:e1", []byte("v2"), time.Minute, []string{"entity:e1", "owner:o1"}, time.Minute, generation)
+	require.NoError(t, err)
+	assert.True(t, stored)
+
+	// The value is tagged, so invalidating the other tag drops it too
+	require.NoError(t, store.InvalidateTag(ctx, "entity:e1"))
+	_, err = store.Get(ctx, "cache:entity:e1")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
@@ -60,7 +60,7 @@ func importData() {
 	env := config.NewEnv(".env")
 
 	// Create logger
-	appLogger := utils.DefaultAppLogger("development", "info", "IMPORT")
+	appLogger := utils.NewServiceLogger("IMPORT")
 
 	// Connect to database
 	ctx := context.Background()
@@ -141,7 +141,7 @@ func clearData() {
 
 	fmt.Printf("\n%s🗑️  Clearing seed data...%s\n\n", ColorRed, ColorReset)
 
-	appLogger := utils.DefaultAppLogger("development", "info", "CLEAR")
+	appLogger := utils.NewServiceLogger("CLEAR")
 
 	ctx := context.Background()
 	db := connectDatabase(ctx, &env, appLogger)
@@ -0,0 +1,21 @@
+package utils
+
+// DiffSize returns a cheap estimate of how many characters changed between two
+// versions of a text. The common prefix and suffix are trimmed and the size of
+// the larger remaining middle section is returned.
+func DiffSize(before string, after string) int {
+	a := []rune(before)
+	b := []rune(after)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	return max(len(a)-prefix-suffix, len(b)-prefix-suffix)
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffSize(t *testing.T) {
+	assert.Equal(t, 0, DiffSize("same text", "same text"))
+	assert.Equal(t, 5, DiffSize("", "hello"))
+	assert.Equal(t, 5, DiffSize("hello", ""))
+	assert.Equal(t, 1, DiffSize("hello world", "hello World"))
+	assert.Equal(t, 6, DiffSize("hello world", "hello brave world"))
+	assert.Equal(t, 3, DiffSize("abc", "xyz"))
+	assert.Equal(t, 1, DiffSize("aaa", "aaaa"))
+	assert.Equal(t, 1, DiffSize("héllo", "hallo"))
+}
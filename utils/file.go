@@ -1,9 +1,11 @@
 package utils
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"strings"
 )
@@ -40,3 +42,42 @@ func GetFileExtension(filePath string) string {
 	}
 	return parts[len(parts)-1]
 }
+
+// ISO base media brands the standard sniffer does not map, checked against the ftyp box
+var ftypBrands = map[string]string{
+	"qt  ": "video/quicktime",
+	"isom": "video/mp4",
+	"iso2": "video/mp4",
+	"mp41": "video/mp4",
+	"mp42": "video/mp4",
+	"avc1": "video/mp4",
+	"M4V ": "video/mp4",
+	"heic": "image/heic",
+	"heix": "image/heic",
+	"mif1": "image/heif",
+}
+
+// DetectContentType returns the MIME type of a file from its magic bytes, the name is never consulted
+func DetectContentType(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	head = head[:n]
+
+	if len(head) >= 12 && bytes.Equal(head[4:8], []byte("ftyp")) {
+		if mimeType, ok := ftypBrands[string(head[8:12])]; ok {
+			return mimeType, nil
+		}
+	}
+
+	mimeType, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	return mimeType, nil
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
     "os"
+    "path/filepath"
     "testing"
 
     "github.com/stretchr/testify/assert"
@@ -39,4 +40,27 @@ func TestLoadPEMFileIntoError(t *testing.T) {
     // Test case where the file does not exist
     _, err := LoadPEMFileInto("nonexistent-file.pem")
     assert.Error(t, err)
-}
\ No newline at end of file
+}
+func TestDetectContentType(t *testing.T) {
+    dir := t.TempDir()
+    write := func(name string, content []byte) string {
+        path := filepath.Join(dir, name)
+        assert.NoError(t, os.WriteFile(path, content, 0o600))
+        return path
+    }
+
+    png := write("upload.jpg", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"))
+    mimeType, err := DetectContentType(png)
+    assert.NoError(t, err)
+    assert.Equal(t, "image/png", mimeType, "content wins over the extension")
+
+    mov := write("clip.bin", []byte("\x00\x00\x00\x14ftypqt  \x00\x00\x00\x00qt  "))
+    mimeType, err = DetectContentType(mov)
+    assert.NoError(t, err)
+    assert.Equal(t, "video/quicktime", mimeType)
+
+    text := write("notes.txt", []byte("hello"))
+    mimeType, err = DetectContentType(text)
+    assert.NoError(t, err)
+    assert.Equal(t, "text/plain", mimeType)
+}
@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -22,3 +25,11 @@ func CheckPasswordHash(givenHash string, password string) (bool, error) {
 	}
 	return true, nil
 }
+
+// DeriveKey derives a key for a single purpose from a server secret, so a signature made for one
+// purpose can never be replayed against another one sharing the secret
+func DeriveKey(secret string, purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
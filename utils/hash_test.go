@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveKey(t *testing.T) {
+	key := DeriveKey("secret", "media-signed-url")
+
+	assert.Len(t, key, 32)
+	assert.Equal(t, key, DeriveKey("secret", "media-signed-url"))
+	assert.NotEqual(t, key, DeriveKey("secret", "digest-unsubscribe"))
+	assert.NotEqual(t, key, DeriveKey("other", "media-signed-url"))
+	assert.NotEqual(t, []byte("secret"), key)
+}
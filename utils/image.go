@@ -6,53 +6,23 @@ import (
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
-	"net/http"
+	"os"
 	"sort"
 )
 
-func GetImageFromURL(url string) (image.Image, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch image: %w", err)
-	}
-	defer resp.Body.Close()
-
-	img, _, err := image.Decode(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode image: %w", err)
-	}
-
-	return img, nil
-}
-
 func GetImageSizeFromFile(filePath string) (int, int, error) {
-	img, err := GetImageFromURL(filePath)
+	file, err := os.Open(filePath)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to get image from file: %w", err)
+		return 0, 0, fmt.Errorf("failed to open image file: %w", err)
 	}
+	defer file.Close()
 
-	width := img.Bounds().Dx()
-	height := img.Bounds().Dy()
-
-	return width, height, nil
-}
-
-func GetImageSize(url string) (int, int, error) {
-	resp, err := http.Get(url)
+	config, _, err := image.DecodeConfig(file)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to fetch image: %w", err)
+		return 0, 0, fmt.Errorf("failed to decode image config: %w", err)
 	}
-	defer resp.Body.Close()
-
-	img, _, err := image.Decode(resp.Body)
-	if err != nil {
-		return 0, 0, fmt.Errorf("failed to decode image: %w", err)
-	}
-
-	width := img.Bounds().Dx()
-	height := img.Bounds().Dy()
 
-	return width, height, nil
+	return config.Width, config.Height, nil
 }
 
 func GetImageDominantColors(img image.Image) ([]string, error) {
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// LogRotation limits the size of file output, sizes are in megabytes and ages in days
+type LogRotation struct {
+	Enabled    bool
+	MaxSize    int
+	MaxAge     int
+	MaxBackups int
+	Compress   bool
+}
+
+// NewLogOutput opens the log destination, output is stdout, stderr, file or both for stdout and a file
+func NewLogOutput(output string, filePath string, rotation LogRotation) (io.Writer, error) {
+	switch output {
+	case "", "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	case "file", "both":
+		file, err := newLogFile(filePath, rotation)
+		if err != nil {
+			return nil, err
+		}
+		if output == "both" {
+			return io.MultiWriter(os.Stdout, file), nil
+		}
+		return file, nil
+	default:
+		return nil, fmt.Errorf("unknown log output %q, expected stdout, stderr, file or both", output)
+	}
+}
+
+func newLogFile(filePath string, rotation LogRotation) (io.Writer, error) {
+	if filePath == "" {
+		return nil, fmt.Errorf("log.file_path is required when logging to a file")
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	if rotation.Enabled {
+		return &lumberjack.Logger{
+			Filename:   filePath,
+			MaxSize:    rotation.MaxSize,
+			MaxAge:     rotation.MaxAge,
+			MaxBackups: rotation.MaxBackups,
+			Compress:   rotation.Compress,
+			LocalTime:  true,
+		}, nil
+	}
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	return file, nil
+}
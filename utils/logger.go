@@ -1,44 +1,18 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
+	"runtime/debug"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"golang.org/x/term"
-)
-
-const (
-	topLeft     = "┌"
-	topRight    = "┐"
-	bottomLeft  = "└"
-	bottomRight = "┘"
-	horizontal  = "─"
-	vertical    = "│"
-)
-
-const (
-	Reset       = "\033[0m"
-	Bold        = "\033[1m"
-	Black       = "\033[30m"
-	Red         = "\033[31m"
-	Green       = "\033[32m"
-	Yellow      = "\033[33m"
-	Blue        = "\033[34m"
-	Magenta     = "\033[35m"
-	Cyan        = "\033[36m"
-	White       = "\033[37m"
-	BgBlack     = "\033[40m"
-	BgRed       = "\033[41m"
-	BgGreen     = "\033[42m"
-	BgYellow    = "\033[43m"
-	BgBlue      = "\033[44m"
-	BgMagenta   = "\033[45m"
-	BgCyan      = "\033[46m"
-	BgWhite     = "\033[47m"
-	BrightBlack = "\033[90m"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type LogLevel int
@@ -50,220 +24,266 @@ const (
 	ErrorLevel
 )
 
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+const RedactedValue = "[REDACTED]"
+
+// LogConfig controls every AppLogger in the process, it is applied once at startup by ConfigureLogging
 type LogConfig struct {
 	Environment string
 	Level       LogLevel
-	Output      io.Writer
-	ErrorOutput io.Writer
-	ServiceName string
-	UseColors   bool
+	// json or text, empty picks json in production and staging and text elsewhere
+	Format string
+	Output io.Writer
+	// Fields are added to every line, e.g. the app version
+	Fields map[string]string
+	// Sanitize lists attribute names, matched case-insensitively, whose values are never written
+	Sanitize        []string
+	Stacktrace      bool
+	StacktraceLevel LogLevel
 }
 
 type AppLogger struct {
-	config LogConfig
+	serviceName string
+	ctx         context.Context
 }
 
-func NewAppLogger(config LogConfig) AppLogger {
-	if config.Output == nil {
-		config.Output = os.Stdout
-	}
-	if config.ErrorOutput == nil {
-		config.ErrorOutput = os.Stderr
-	}
-	if config.Environment == "production" {
-		if config.Level < InfoLevel {
-			config.Level = InfoLevel
-		}
-	}
-
-	if !term.IsTerminal(int(os.Stdout.Fd())) {
-		config.UseColors = false
-	}
+var (
+	rootLogger  atomic.Pointer[slog.Logger]
+	defaultOnce sync.Once
+)
 
-	return AppLogger{
-		config: config,
-	}
+// ConfigureLogging replaces the output of all loggers, including the ones created before it was called
+func ConfigureLogging(config LogConfig) {
+	rootLogger.Store(slog.New(newLogHandler(config)))
 }
 
-func DefaultAppLogger(env string, logLevel string, serviceName string) AppLogger {
-	config := LogConfig{
-		Environment: env,
-		Output:      os.Stdout,
-		ErrorOutput: os.Stderr,
-		ServiceName: serviceName,
-		UseColors:   true,
-	}
-	if logLevel == "debug" {
-		config.Level = DebugLevel
-	} else {
-		config.Level = InfoLevel
-	}
-	return NewAppLogger(config)
+func NewServiceLogger(serviceName string) AppLogger {
+	return AppLogger{serviceName: serviceName}
 }
 
-func NewServiceLogger(serviceName string) AppLogger {
-	env := os.Getenv("ENV")
-	logLevel := os.Getenv("LOG_LEVEL")
-	return DefaultAppLogger(env, logLevel, serviceName)
+// Until ConfigureLogging runs, e.g. in scripts, lines are written as text to stdout at the LOG_LEVEL env level
+func root() *slog.Logger {
+	defaultOnce.Do(func() {
+		if rootLogger.Load() != nil {
+			return
+		}
+		rootLogger.CompareAndSwap(nil, slog.New(newLogHandler(LogConfig{
+			Environment: os.Getenv("ENV"),
+			Level:       ParseLogLevel(os.Getenv("LOG_LEVEL")),
+		})))
+	})
+	return rootLogger.Load()
 }
 
-func getTerminalWidth() int {
-	width, _, err := term.GetSize(int(os.Stdout.Fd()))
-	if err != nil || width <= 0 {
-		return 80
+func ParseLogLevel(level string) LogLevel {
+	switch strings.ToLower(level) {
+	case "debug":
+		return DebugLevel
+	case "warn", "warning":
+		return WarnLevel
+	case "error":
+		return ErrorLevel
+	default:
+		return InfoLevel
 	}
-	return width
 }
 
-func (l *AppLogger) simpleLog(level LogLevel, prefix, levelStr, color, format string, v ...interface{}) {
-	if level < l.config.Level {
-		return
+func (level LogLevel) slogLevel() slog.Level {
+	switch level {
+	case DebugLevel:
+		return slog.LevelDebug
+	case WarnLevel:
+		return slog.LevelWarn
+	case ErrorLevel:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
 	}
+}
 
-	timestamp := time.Now().Format("2006:01:02 15:04:05.000")
-	message := fmt.Sprintf(format, v...)
-
-	serviceName := l.config.ServiceName
-	if len(serviceName) > 15 {
-		serviceName = serviceName[:15]
+func newLogHandler(config LogConfig) slog.Handler {
+	if config.Output == nil {
+		config.Output = os.Stdout
 	}
-
-	logLine := fmt.Sprintf("%s | %-15s | %s | %s", timestamp, serviceName, levelStr, message)
-
-	if level >= ErrorLevel {
-		fmt.Fprintln(l.config.ErrorOutput, logLine)
-	} else {
-		fmt.Fprintln(l.config.Output, logLine)
+	if config.Environment == "production" && config.Level < InfoLevel {
+		config.Level = InfoLevel
+	}
+	if config.Format == "" {
+		config.Format = LogFormatText
+		if config.Environment == "production" || config.Environment == "staging" {
+			config.Format = LogFormatJSON
+		}
 	}
-}
 
-func (l *AppLogger) log(level LogLevel, prefix, levelStr, color, format string, v ...interface{}) {
-	if level < l.config.Level {
-		return
+	sanitize := make(map[string]bool, len(config.Sanitize))
+	for _, field := range config.Sanitize {
+		sanitize[strings.ToLower(field)] = true
 	}
-	if l.config.Environment == "production" || l.config.Environment == "staging" {
-		l.simpleLog(level, prefix, levelStr, color, format, v...)
-		return
+	options := &slog.HandlerOptions{
+		Level: config.Level.slogLevel(),
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if sanitize[strings.ToLower(attr.Key)] {
+				return slog.String(attr.Key, RedactedValue)
+			}
+			return attr
+		},
 	}
 
-	timestamp := time.Now().Format("2006:01:02 15:04:05.000")
-	message := fmt.Sprintf(format, v...)
-
-	termWidth := getTerminalWidth()
-
-	contentWidth := termWidth - 2
-
-	header := fmt.Sprintf(" %s %s %-15s %s %s %s%s ", timestamp, vertical, l.config.ServiceName, vertical, prefix, levelStr, vertical)
-	headerWidth := len(header)
-
-	messageLines := strings.Split(message, "\n")
-	var formattedLines []string
-
-	firstLineMax := contentWidth - headerWidth
-	if len(messageLines) > 0 && len(messageLines[0]) > 0 {
-		if len(messageLines[0]) <= firstLineMax {
-			formattedLines = append(formattedLines, header+messageLines[0])
-		} else {
-			formattedLines = append(formattedLines, header+messageLines[0][:firstLineMax])
-			remaining := messageLines[0][firstLineMax:]
-
-			for len(remaining) > 0 {
-				lineWidth := contentWidth - headerWidth
-				if len(remaining) <= lineWidth {
-					formattedLines = append(formattedLines, strings.Repeat(" ", headerWidth)+remaining)
-					remaining = ""
-				} else {
-					formattedLines = append(formattedLines, strings.Repeat(" ", headerWidth)+remaining[:lineWidth])
-					remaining = remaining[lineWidth:]
-				}
-			}
-		}
+	var handler slog.Handler
+	if config.Format == LogFormatJSON {
+		handler = slog.NewJSONHandler(config.Output, options)
 	} else {
-		formattedLines = append(formattedLines, header)
+		handler = slog.NewTextHandler(config.Output, options)
 	}
 
-	for i := 1; i < len(messageLines); i++ {
-		line := messageLines[i]
-		lineWidth := contentWidth - headerWidth
-
-		for len(line) > 0 {
-			if len(line) <= lineWidth {
-				formattedLines = append(formattedLines, strings.Repeat(" ", headerWidth)+line)
-				line = ""
-			} else {
-				formattedLines = append(formattedLines, strings.Repeat(" ", headerWidth)+line[:lineWidth])
-				line = line[lineWidth:]
-			}
+	if len(config.Fields) > 0 {
+		fields := make([]slog.Attr, 0, len(config.Fields))
+		for key, value := range config.Fields {
+			fields = append(fields, slog.String(key, value))
 		}
+		handler = handler.WithAttrs(fields)
 	}
 
-	var output strings.Builder
-
-	if l.config.UseColors && color != "" {
-		output.WriteString(color)
+	contextHandler := &contextHandler{Handler: handler, stacktrace: -1}
+	if config.Stacktrace {
+		contextHandler.stacktrace = config.StacktraceLevel.slogLevel()
 	}
+	return contextHandler
+}
 
-	output.WriteString(topLeft + strings.Repeat(horizontal, contentWidth) + topRight + "\n")
+// contextHandler adds the request fields and trace id found in the context and, from the configured level, the stack trace
+type contextHandler struct {
+	slog.Handler
+	stacktrace slog.Level
+}
 
-	for _, line := range formattedLines {
-		padding := contentWidth - len(line)
-		if padding < 0 {
-			line = line[:contentWidth]
-			padding = 0
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if fields := requestFieldsFrom(ctx); fields != nil {
+		record.AddAttrs(slog.String("requestId", fields.requestId))
+		if userId := fields.UserId(); userId != "" {
+			record.AddAttrs(slog.String("userId", userId))
 		}
-		output.WriteString(vertical + line + strings.Repeat(" ", padding) + "\n")
 	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(slog.String("traceId", spanContext.TraceID().String()))
+	}
+	if h.stacktrace >= 0 && record.Level >= h.stacktrace {
+		record.AddAttrs(slog.String("stacktrace", string(debug.Stack())))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs), stacktrace: h.stacktrace}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name), stacktrace: h.stacktrace}
+}
+
+type logContextKey struct{}
 
-	output.WriteString(bottomLeft + strings.Repeat(horizontal, contentWidth) + bottomRight)
+// requestFields are shared by every context derived from the request, so the user id set by the
+// authentication middleware also shows up on lines logged with a context taken before it ran
+type requestFields struct {
+	requestId string
+	mu        sync.RWMutex
+	userId    string
+}
+
+func (f *requestFields) UserId() string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.userId
+}
 
-	if l.config.UseColors && color != "" {
-		output.WriteString(Reset)
+func requestFieldsFrom(ctx context.Context) *requestFields {
+	if ctx == nil {
+		return nil
 	}
+	fields, _ := ctx.Value(logContextKey{}).(*requestFields)
+	return fields
+}
 
-	output.WriteString("\n")
+// WithRequestId returns a context whose log lines carry the request id
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, logContextKey{}, &requestFields{requestId: requestId})
+}
 
-	if level >= ErrorLevel {
-		fmt.Fprint(l.config.ErrorOutput, output.String())
-	} else {
-		fmt.Fprint(l.config.Output, output.String())
+func RequestId(ctx context.Context) string {
+	if fields := requestFieldsFrom(ctx); fields != nil {
+		return fields.requestId
 	}
+	return ""
 }
 
-func (l *AppLogger) Debug(format string, v ...interface{}) {
-	if l.config.Level > DebugLevel {
-		return
+// SetLogUserId adds the user id to the log lines of the request the context belongs to
+func SetLogUserId(ctx context.Context, userId string) {
+	if fields := requestFieldsFrom(ctx); fields != nil {
+		fields.mu.Lock()
+		fields.userId = userId
+		fields.mu.Unlock()
 	}
-	l.log(DebugLevel, "🐛", "DEBUG    ", Cyan, format, v...)
 }
 
-func (l *AppLogger) Info(format string, v ...interface{}) {
-	l.log(InfoLevel, "ℹ️ ", "INFO     ", Blue, format, v...)
+// WithContext returns a logger whose lines carry the request id and user id of the context
+func (l AppLogger) WithContext(ctx context.Context) AppLogger {
+	l.ctx = ctx
+	return l
+}
+
+// LogAttrs writes a structured line, attributes named in the sanitize list are redacted
+func (l AppLogger) LogAttrs(level LogLevel, message string, attrs ...slog.Attr) {
+	l.write(level.slogLevel(), message, attrs...)
 }
 
-func (l *AppLogger) Success(format string, v ...interface{}) {
-	l.log(InfoLevel, "✅", "SUCCESS  ", Green, format, v...)
+func (l AppLogger) write(level slog.Level, message string, attrs ...slog.Attr) {
+	ctx := l.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	logger := root()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	record := slog.NewRecord(time.Now(), level, message, 0)
+	record.AddAttrs(slog.String("service", l.serviceName))
+	record.AddAttrs(attrs...)
+	_ = logger.Handler().Handle(ctx, record)
 }
 
-func (l *AppLogger) Warn(format string, v ...interface{}) {
-	if l.config.Level > DebugLevel {
+func (l AppLogger) log(level slog.Level, format string, v ...interface{}) {
+	if !root().Enabled(context.Background(), level) {
 		return
 	}
-	l.log(WarnLevel, "⚠️ ", "WARN     ", Yellow, format, v...)
+	l.write(level, fmt.Sprintf(format, v...))
 }
 
-func (l *AppLogger) Error(format string, v ...interface{}) {
-	l.log(ErrorLevel, "❌", "ERROR    ", Red, format, v...)
+func (l AppLogger) Debug(format string, v ...interface{}) {
+	l.log(slog.LevelDebug, format, v...)
 }
 
-func (l *AppLogger) Fatal(format string, v ...interface{}) {
-	l.log(ErrorLevel, "❌", "FATAL    ", Red, format, v...)
-	os.Exit(1)
+func (l AppLogger) Info(format string, v ...interface{}) {
+	l.log(slog.LevelInfo, format, v...)
 }
 
-func (l *AppLogger) SetLevel(level LogLevel) {
-	l.config.Level = level
+func (l AppLogger) Success(format string, v ...interface{}) {
+	l.log(slog.LevelInfo, format, v...)
 }
 
-func (l *AppLogger) SetUseColors(useColors bool) {
-	l.config.UseColors = useColors
+func (l AppLogger) Warn(format string, v ...interface{}) {
+	l.log(slog.LevelWarn, format, v...)
+}
+
+func (l AppLogger) Error(format string, v ...interface{}) {
+	l.log(slog.LevelError, format, v...)
+}
+
+func (l AppLogger) Fatal(format string, v ...interface{}) {
+	l.log(slog.LevelError, format, v...)
+	os.Exit(1)
 }
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func configureTestLogging(t *testing.T, config LogConfig) *bytes.Buffer {
+	previous := root()
+	t.Cleanup(func() { rootLogger.Store(previous) })
+
+	var buffer bytes.Buffer
+	config.Output = &buffer
+	config.Format = LogFormatJSON
+	ConfigureLogging(config)
+	return &buffer
+}
+
+func decodeLogLine(t *testing.T, buffer *bytes.Buffer) map[string]any {
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &line))
+	return line
+}
+
+func TestAppLoggerWritesJSON(t *testing.T) {
+	buffer := configureTestLogging(t, LogConfig{Level: InfoLevel, Fields: map[string]string{"app": "sync"}})
+
+	NewServiceLogger("Post").Warn("Post %s not found", "p1")
+
+	line := decodeLogLine(t, buffer)
+	assert.Equal(t, "WARN", line["level"])
+	assert.Equal(t, "Post p1 not found", line["msg"])
+	assert.Equal(t, "Post", line["service"])
+	assert.Equal(t, "sync", line["app"])
+}
+
+func TestAppLoggerLevel(t *testing.T) {
+	buffer := configureTestLogging(t, LogConfig{Level: WarnLevel})
+
+	logger := NewServiceLogger("Post")
+	logger.Debug("debug")
+	logger.Info("info")
+	assert.Empty(t, buffer.String())
+
+	logger.Error("error")
+	assert.Equal(t, "ERROR", decodeLogLine(t, buffer)["level"])
+}
+
+func TestAppLoggerRedactsSanitizedFields(t *testing.T) {
+	buffer := configureTestLogging(t, LogConfig{Level: InfoLevel, Sanitize: []string{"password", "authorization"}})
+
+	NewServiceLogger("Auth").LogAttrs(InfoLevel, "login",
+		slog.String("Password", "hunter2"),
+		slog.Group("headers", slog.String("Authorization", "Bearer abc")),
+		slog.String("email", "a@b.c"),
+	)
+
+	line := decodeLogLine(t, buffer)
+	assert.Equal(t, RedactedValue, line["Password"])
+	assert.Equal(t, RedactedValue, line["headers"].(map[string]any)["Authorization"])
+	assert.Equal(t, "a@b.c", line["email"])
+}
+
+func TestAppLoggerAddsRequestFields(t *testing.T) {
+	buffer := configureTestLogging(t, LogConfig{Level: InfoLevel})
+
+	ctx := WithRequestId(context.Background(), "req-1")
+	logger := NewServiceLogger("Post").WithContext(ctx)
+	// the user id is known only after authentication, it must still reach loggers created before
+	SetLogUserId(ctx, "user-1")
+	logger.Info("liked")
+
+	line := decodeLogLine(t, buffer)
+	assert.Equal(t, "req-1", line["requestId"])
+	assert.Equal(t, "user-1", line["userId"])
+	assert.Equal(t, "req-1", RequestId(ctx))
+}